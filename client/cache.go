@@ -21,6 +21,9 @@ type CacheEntry struct {
 	ContentHash string `json:"content_hash"`
 	Bytes       int64  `json:"bytes"`
 	Filename    string `json:"filename"`
+	// SheetNames caches the workbook's sheet names for shell completion.
+	// It is dropped whenever the entry is replaced for new content.
+	SheetNames []string `json:"sheet_names,omitempty"`
 }
 
 // cacheData is the on-disk JSON structure.
@@ -65,6 +68,12 @@ func NewFileCache() *FileCache {
 	return fc
 }
 
+// Dir returns the directory the cache persists to, or "" when it is
+// in-memory only.
+func (fc *FileCache) Dir() string {
+	return fc.dir
+}
+
 // Get looks up a cache entry by local file identity.
 func (fc *FileCache) Get(filePath, baseURL, orgID string) (CacheEntry, bool) {
 	key := entryKey(filePath, baseURL, orgID)
@@ -127,13 +136,10 @@ func hashFile(filePath string) (string, error) {
 
 // entryKey returns the cache key for a local file identity.
 // Includes path so that distinct files with identical bytes do not collapse
-// into one server-side fileID.
+// into one server-side fileID. The path is normalized (see NormalizePath)
+// so spellings of one path share an entry.
 func entryKey(filePath, baseURL, orgID string) string {
-	absPath, err := filepath.Abs(filePath)
-	if err != nil {
-		absPath = filePath
-	}
-	return filepath.Clean(absPath) + "@" + baseURL + "@" + orgID
+	return NormalizePath(filePath) + "@" + baseURL + "@" + orgID
 }
 
 func (fc *FileCache) load() {
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes what the API deployment supports, as reported by
+// GET /v0/meta. Empty fields mean the server did not say, and callers
+// should assume support.
+type Capabilities struct {
+	APIVersion    string   `json:"api_version,omitempty"`
+	Features      []string `json:"features,omitempty"`       // e.g. "upload_sessions", "exec_stream", "jobs"
+	RenderFormats []string `json:"render_formats,omitempty"` // e.g. "png", "webp", "svg", "pdf"
+	MaxFileBytes  int64    `json:"max_file_bytes,omitempty"`
+}
+
+// HasFeature reports whether the server lists feature. A server that lists
+// no features is assumed to have them all.
+func (c *Capabilities) HasFeature(feature string) bool {
+	return c == nil || len(c.Features) == 0 || slices.Contains(c.Features, feature)
+}
+
+// SupportsRenderFormat reports whether the server renders format. A server
+// that lists no formats is assumed to render them all.
+func (c *Capabilities) SupportsRenderFormat(format string) bool {
+	return c == nil || len(c.RenderFormats) == 0 || slices.Contains(c.RenderFormats, format)
+}
+
+// capabilitiesCache holds the result of GET /v0/meta; it is shared by
+// WithContext copies.
+type capabilitiesCache struct {
+	mu   sync.Mutex
+	call *capabilitiesCall // the fetch in flight, or the one whose result is kept
+}
+
+type capabilitiesCall struct {
+	done chan struct{}
+	caps *Capabilities
+	err  error
+}
+
+// Capabilities fetches the server's capabilities via GET /v0/meta. Callers
+// that arrive while the request is in flight wait for it and share its
+// result. The result, success or error (such as a 404 from a server without
+// /v0/meta), is kept for the life of the client, so a failing server is
+// asked only once.
+func (c *Client) Capabilities() (*Capabilities, error) {
+	cache := c.capabilities
+	cache.mu.Lock()
+	if call := cache.call; call != nil {
+		cache.mu.Unlock()
+		<-call.done
+		return call.caps, call.err
+	}
+	call := &capabilitiesCall{done: make(chan struct{})}
+	cache.call = call
+	cache.mu.Unlock()
+
+	call.caps, call.err = c.fetchCapabilities()
+	close(call.done)
+	return call.caps, call.err
+}
+
+func (c *Client) fetchCapabilities() (*Capabilities, error) {
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		u, err := url.Parse(c.BaseURL + "/v0/meta")
+		if err != nil {
+			return nil, fmt.Errorf("building URL: %w", err)
+		}
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode != http.StatusOK {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	var caps Capabilities
+	if err := json.Unmarshal(raw.Body, &caps); err != nil {
+		return nil, fmt.Errorf("parsing capabilities: %w", err)
+	}
+	return &caps, nil
+}
+
+// sizeLookupFloor is the smallest file for which checkFileSize fetches the
+// limit from Capabilities when WithMaxFileBytes did not set one; smaller
+// files are well under any deployment's limit.
+const sizeLookupFloor = 1 << 20
+
+// FileTooLargeError is returned, before any of the file is sent, when a file
+// is larger than the API accepts. Its message suggests how to shrink the
+// file where the file type allows.
+type FileTooLargeError struct {
+	Path  string
+	Size  int64
+	Limit int64
+}
+
+func (e *FileTooLargeError) Error() string {
+	msg := fmt.Sprintf("%s is %.1f MB; the API accepts files up to %.1f MB",
+		filepath.Base(e.Path), float64(e.Size)/(1<<20), float64(e.Limit)/(1<<20))
+	if hint := shrinkHint(e.Path); hint != "" {
+		msg += ". " + hint
+	}
+	return msg
+}
+
+// shrinkHint suggests how to make a file of filePath's type smaller.
+func shrinkHint(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".xlsx", ".xlsm":
+		return "Retry with --strip minimal to drop cached formula values from the upload, or delete unused sheets, images, and pivot caches"
+	case ".xls":
+		return "Saving it as .xlsx usually makes it much smaller"
+	case ".pptx":
+		return "Compressing pictures (File > Compress Pictures in PowerPoint) usually shrinks a deck"
+	case ".pdf":
+		return "Split it into smaller PDFs or reduce its image resolution"
+	case ".png", ".jpg", ".jpeg", ".tif", ".tiff", ".webp", ".gif", ".bmp":
+		return "Downscale or re-encode the image"
+	case ".csv", ".txt", ".md", ".json", ".html", ".htm":
+		return "Split it into smaller files"
+	}
+	return ""
+}
+
+// checkFileSize rejects a file larger than the API accepts before any of it
+// is sent. The limit is WithMaxFileBytes, or for files of a megabyte or more
+// the one Capabilities reports; with neither, the server decides.
+func (c *Client) checkFileSize(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil // opening the file reports it
+	}
+	limit := c.maxFileBytes
+	if limit <= 0 && info.Size() >= sizeLookupFloor {
+		if caps, err := c.Capabilities(); err == nil {
+			limit = caps.MaxFileBytes
+		}
+	}
+	if limit > 0 && info.Size() > limit {
+		return &FileTooLargeError{Path: filePath, Size: info.Size(), Limit: limit}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCapabilities_FetchedOncePerClient(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/meta" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"api_version":"2026.10.1","features":["jobs"],"render_formats":["png","svg"],"max_file_bytes":1024}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "org_1", true)
+	caps, err := c.Capabilities()
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+	if _, err := c.Capabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected one request, got %d", calls.Load())
+	}
+	if caps.MaxFileBytes != 1024 || !caps.HasFeature("jobs") || caps.HasFeature("exec_stream") {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+	if !caps.SupportsRenderFormat("svg") || caps.SupportsRenderFormat("webp") {
+		t.Fatalf("unexpected render formats: %+v", caps.RenderFormats)
+	}
+
+	var unknown *Capabilities
+	if !unknown.HasFeature("jobs") || !(&Capabilities{}).SupportsRenderFormat("webp") {
+		t.Fatal("unreported capabilities should be assumed available")
+	}
+}
+
+func TestCapabilities_ConcurrentCallsShareOneRequestAndErrorsAreKept(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"code":"not_found","message":"no such route"}}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "org_1", true)
+	c.maxAttempts = 1
+	errs := make(chan error, 4)
+	for range 4 {
+		go func() {
+			_, err := c.WithContext(context.Background()).Capabilities()
+			errs <- err
+		}()
+	}
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for range 4 {
+		if err := <-errs; !IsNotFound(err) {
+			t.Fatalf("expected a not-found error, got %v", err)
+		}
+	}
+	if _, err := c.Capabilities(); !IsNotFound(err) {
+		t.Fatalf("expected the kept not-found error, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected one request, got %d", n)
+	}
+}
+
+func TestWithMaxFileBytes_RejectsLargeUploadWithoutRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "big.xlsx")
+	if err := os.WriteFile(filePath, make([]byte, 2<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(server.URL, "test-key", "", false, WithMaxFileBytes(1<<20))
+	_, err := c.UploadFile(filePath)
+	if err == nil || !strings.Contains(err.Error(), "big.xlsx is 2.0 MB; the API accepts files up to 1.0 MB. Retry with --strip minimal") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckFileSize_FetchesLimitForStatelessRequest(t *testing.T) {
+	var metaCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/meta" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		metaCalls.Add(1)
+		fmt.Fprint(w, `{"max_file_bytes":1048576}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "scan.pdf")
+	if err := os.WriteFile(filePath, make([]byte, 3<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(server.URL, "", "", true)
+	_, err := c.Read(filePath, nil)
+	var tooLarge *FileTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 1<<20 || tooLarge.Size != 3<<20 {
+		t.Fatalf("err = %v, want a FileTooLargeError with the fetched limit", err)
+	}
+	if !strings.Contains(err.Error(), "scan.pdf is 3.0 MB; the API accepts files up to 1.0 MB. Split it into smaller PDFs") {
+		t.Fatalf("unexpected message: %v", err)
+	}
+	if metaCalls.Load() != 1 {
+		t.Fatalf("meta calls = %d, want 1", metaCalls.Load())
+	}
+}
@@ -0,0 +1,301 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// cassetteVersion is the Cassette format written by Recorder.
+const cassetteVersion = 1
+
+// Cassette is a recording of HTTP interactions, written by Recorder and
+// served by Replayer, for deterministic tests of tools that drive the API.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest describes a request. The body is kept only as a size and
+// SHA-256; uploads can be large and multipart boundaries change per run.
+type RecordedRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"headers,omitempty"`
+	BodyBytes  int64       `json:"body_bytes,omitempty"`
+	BodySHA256 string      `json:"body_sha256,omitempty"`
+}
+
+// RecordedResponse is a response. Body holds UTF-8 text; other bodies are
+// stored in BodyBase64.
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// LoadCassette reads a cassette written by Recorder.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	if c.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette %s has version %d; this client reads version %d", path, c.Version, cassetteVersion)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path as indented JSON.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// Recorder is an http.RoundTripper that sends each request through Next
+// and records it with its response. Credential headers are recorded as
+// [REDACTED], as in request logs, and so are credential fields in JSON
+// response bodies (see redactBody). Requests that fail without a response
+// are not recorded.
+type Recorder struct {
+	Next http.RoundTripper // nil means http.DefaultTransport
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder sending requests through next.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	return &Recorder{Next: next, cassette: Cassette{Version: cassetteVersion}}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := RecordedRequest{Method: req.Method, URL: req.URL.String(), Header: redactHeader(req.Header)}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		sum := sha256.Sum256(body)
+		recorded.BodyBytes = int64(len(body))
+		recorded.BodySHA256 = hex.EncodeToString(sum[:])
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	response := RecordedResponse{Status: resp.StatusCode, Header: redactHeader(resp.Header)}
+	if utf8.Valid(body) {
+		response.Body = string(redactBody(req.URL, body))
+	} else {
+		response.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Cassette returns a copy of what has been recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := Cassette{Version: r.cassette.Version, Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+	return &c
+}
+
+// Replayer is an http.RoundTripper that answers requests from a Cassette
+// without network access. A request matches the first unused interaction
+// with the same method, path, and query; the host is ignored, so a
+// cassette replays against any --api-url. A request with no match fails
+// with an error matching ErrNotRecorded.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayer returns a Replayer serving c.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{cassette: c, used: make([]bool, len(c.Interactions))}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	want := req.URL.RequestURI()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request.Method != req.Method || requestURI(in.Request.URL) != want {
+			continue
+		}
+		r.used[i] = true
+		body := []byte(in.Response.Body)
+		if in.Response.BodyBase64 != "" {
+			decoded, err := base64.StdEncoding.DecodeString(in.Response.BodyBase64)
+			if err != nil {
+				return nil, fmt.Errorf("decoding recorded response for %s %s: %w", req.Method, want, err)
+			}
+			body = decoded
+		}
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, want)
+}
+
+// Unused returns the interactions no request has matched, e.g. to check
+// that a test made every call the cassette expects.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, in := range r.cassette.Interactions {
+		if !r.used[i] {
+			unused = append(unused, in)
+		}
+	}
+	return unused
+}
+
+// requestURI returns the path and query of a recorded URL.
+func requestURI(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
+
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	h = h.Clone()
+	for k, vs := range h {
+		for i, v := range vs {
+			vs[i] = redactHeaderValue(k, v)
+		}
+	}
+	return h
+}
+
+// credentialFields are JSON fields whose string values are redacted in
+// every recorded response body.
+var credentialFields = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"api_key":       true,
+	"secret":        true,
+}
+
+// isCredentialEndpoint reports whether u is an auth or management API
+// endpoint, whose responses also carry new API keys in a "key" field.
+func isCredentialEndpoint(u *url.URL) bool {
+	return strings.HasPrefix(u.Hostname(), "management-api.") ||
+		strings.Contains(u.Path, "/auth/") ||
+		strings.HasSuffix(u.Path, "/api-keys")
+}
+
+// redactBody replaces credential values in a JSON body with [REDACTED], so
+// cassettes of session-auth commands can be committed. Other bodies are
+// returned unchanged.
+func redactBody(u *url.URL, body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return body
+	}
+	withKey := isCredentialEndpoint(u)
+	if !redactJSON(v, withKey) {
+		return body
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactJSON redacts credential fields in v in place and reports whether
+// it changed anything.
+func redactJSON(v any, withKey bool) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			name := strings.ToLower(k)
+			if _, ok := field.(string); ok && (credentialFields[name] || withKey && name == "key") {
+				v[k] = "[REDACTED]"
+				changed = true
+				continue
+			}
+			changed = redactJSON(field, withKey) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = redactJSON(item, withKey) || changed
+		}
+	}
+	return changed
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderReplayer_RoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "affinity=node-3")
+		w.Write([]byte(`{"diagnostics":[{"ruleId":"D001","message":"circular reference"}]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	book := filepath.Join(dir, "model.xlsx")
+	if err := os.WriteFile(book, []byte("PK workbook"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	params := url.Values{"range": {"Summary!A1"}}
+
+	recorder := NewRecorder(http.DefaultTransport)
+	c := New(server.URL, "secret-key", "org1", true)
+	c.HTTPClient.Transport = recorder
+	recorded, err := c.Lint(book, params)
+	if err != nil {
+		t.Fatalf("recording Lint: %v", err)
+	}
+	cassettePath := filepath.Join(dir, "cassette.json")
+	if err := recorder.Cassette().Save(cassettePath); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(cassettePath)
+	if strings.Contains(string(raw), "secret-key") || strings.Contains(string(raw), "node-3") {
+		t.Errorf("cassette contains credentials:\n%s", raw)
+	}
+
+	cassette, err := LoadCassette(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := NewReplayer(cassette)
+	// Another host: only method, path, and query are matched.
+	c = New("https://replay.example.test", "other-key", "org1", true)
+	c.HTTPClient.Transport = replayer
+	replayed, err := c.Lint(book, params)
+	if err != nil {
+		t.Fatalf("replaying Lint: %v", err)
+	}
+	if len(replayed.Diagnostics) != 1 || replayed.Diagnostics[0].Message != recorded.Diagnostics[0].Message {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if len(replayer.Unused()) != 0 {
+		t.Errorf("unused interactions: %+v", replayer.Unused())
+	}
+
+	// Each interaction answers once.
+	if _, err := c.Lint(book, params); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("second replay error = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestRecorder_RedactsCredentialBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/auth/token":
+			w.Write([]byte(`{"token":"jwt-secret-value"}`))
+		case "/v0/orgs/org1/api-keys":
+			w.Write([]byte(`{"id":"key_1","name":"ci","key":"wk_live_secret"}`))
+		default:
+			w.Write([]byte(`{"rows":[{"key":"region","value":1.50}]}`))
+		}
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder(http.DefaultTransport)
+	httpClient := &http.Client{Transport: recorder}
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/v0/auth/token"},
+		{"POST", "/v0/orgs/org1/api-keys"},
+		{"GET", "/v0/files/f1/read"},
+	} {
+		r, _ := http.NewRequest(req.method, server.URL+req.path, nil)
+		resp, err := httpClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The caller still sees the real body.
+		var got map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if req.path == "/v0/auth/token" && got["token"] != "jwt-secret-value" {
+			t.Errorf("caller got token %v", got["token"])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := recorder.Cassette().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(path)
+	for _, secret := range []string{"jwt-secret-value", "wk_live_secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette contains %s:\n%s", secret, raw)
+		}
+	}
+	// A "key" field outside credential endpoints is data, not a secret.
+	if body := recorder.Cassette().Interactions[2].Response.Body; body != `{"rows":[{"key":"region","value":1.50}]}` {
+		t.Errorf("data body was rewritten: %s", body)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/witanlabs/witan-cli/config"
@@ -37,8 +38,10 @@ type Client struct {
 	OrgID      string
 	UserAgent  string
 	HTTPClient *http.Client
-	Stateless  bool       // when true, use POST-file-in-body endpoints only
-	cache      *FileCache // nil when stateless
+	Stateless  bool                  // when true, use POST-file-in-body endpoints only
+	Logger     func(RequestLogEvent) // optional; receives one event per attempt and retry wait
+	cache      *FileCache            // nil when stateless
+	ctx        context.Context       // nil means context.Background(); see WithContext
 
 	requestTimeout time.Duration
 	maxAttempts    int
@@ -47,19 +50,34 @@ type Client struct {
 	sleep          func(time.Duration)
 	randInt63n     func(int64) int64
 	now            func() time.Time
+
+	uploadProgress UploadProgressFunc // optional; see WithUploadProgress
+	chunkThreshold int64              // 0 means chunkedUploadThreshold
+	noCompress     *atomic.Bool       // see WithRequestCompression; shared by WithContext copies
+	limiter        *requestLimiter    // shared by WithContext copies
+	responses      *responseCache     // nil unless WithResponseCache(true)
+	onNotice       func(Notice)       // optional; see WithNoticeHandler
+	capabilities   *capabilitiesCache // shared by WithContext copies
+	maxFileBytes   int64              // 0 means no client-side limit; see WithMaxFileBytes
+	tokens         *tokenRefresher    // nil unless WithTokenRefresh; shared by WithContext copies
+	uploads        *uploadGroup       // dedupes concurrent uploads; shared by WithContext copies
+	strip          StripLevel         // "" means off; see WithStrip
+	session        *Session           // nil unless WithSession
 }
 
 type rawResponse struct {
 	StatusCode  int
 	ContentType string
 	RetryAfter  string
+	Header      http.Header // nil for synthesized responses
 	Body        []byte
 }
 
 // New creates a new Witan API client. By default it uses the /v0/files
 // endpoints with a local hash cache for deduplication. Pass stateless=true
-// to use POST-file-in-body endpoints instead (zero data retention).
-func New(baseURL, apiKey, orgID string, stateless bool) *Client {
+// to use POST-file-in-body endpoints instead (zero data retention). Options
+// are applied after the defaults.
+func New(baseURL, apiKey, orgID string, stateless bool, opts ...Option) *Client {
 	c := &Client{
 		BaseURL:        strings.TrimRight(baseURL, "/"),
 		APIKey:         apiKey,
@@ -74,14 +92,30 @@ func New(baseURL, apiKey, orgID string, stateless bool) *Client {
 		sleep:          time.Sleep,
 		randInt63n:     rand.Int63n,
 		now:            time.Now,
+		limiter:        &requestLimiter{},
+		capabilities:   &capabilitiesCache{},
+		uploads:        &uploadGroup{},
+		noCompress:     &atomic.Bool{},
 	}
 	if !stateless {
 		c.cache = NewFileCache()
 		c.HTTPClient.Jar = newDefaultPersistentCookieJar()
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
 	return c
 }
 
+// SetRequestTimeout sets the per-attempt HTTP request timeout. A zero or
+// negative duration restores the default (60s).
+func (c *Client) SetRequestTimeout(d time.Duration) {
+	if d <= 0 {
+		d = defaultRequestTimeout
+	}
+	c.requestTimeout = d
+}
+
 func newDefaultPersistentCookieJar() http.CookieJar {
 	path, err := config.CookieJarPath()
 	if err != nil {
@@ -102,48 +136,96 @@ func (c *Client) buildPath(version, path string) string {
 	return "/" + version + path
 }
 
-func (c *Client) doWithRetry(makeRequest func() (*http.Request, error)) (*rawResponse, error) {
+func (c *Client) doWithRetry(makeRequest func() (*http.Request, error)) (raw *rawResponse, err error) {
+	var tr apiTrace
+	defer func() { tr.end(raw, err) }()
+
 	maxAttempts := c.maxAttempts
 	if maxAttempts < 1 {
 		maxAttempts = 1
 	}
 
+	parent := c.Context()
+	var waited time.Time // end of a 429 pause this request's backoff covered
+	reauthed := false
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		req, err := makeRequest()
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		compressed, err := c.compressRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		release, err := c.acquire(waited)
+		if err != nil {
+			return nil, fmt.Errorf("API request canceled after %d attempt(s): %w", attempt-1, err)
+		}
 
 		timeout := c.requestTimeout
 		if timeout <= 0 {
 			timeout = defaultRequestTimeout
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, cancel := context.WithTimeout(tr.begin(parent, req, attempt), timeout)
 		req = req.WithContext(ctx)
 
+		start := c.clock()
 		resp, err := c.HTTPClient.Do(req)
 		if err != nil {
 			cancel()
-			if attempt < maxAttempts && isRetryableTransportError(err) {
-				c.sleepWithBackoff(attempt, "")
+			release()
+			c.logAttempt(req, attempt, 0, start, err)
+			tr.recordAttempt(0, err)
+			if parent.Err() == nil && attempt < maxAttempts && isRetryableTransportError(err) {
+				if err := c.sleepWithBackoff(req, attempt, ""); err != nil {
+					return nil, fmt.Errorf("API request canceled after %d attempt(s): %w", attempt, err)
+				}
 				continue
 			}
 			return nil, fmt.Errorf("API request failed after %d attempt(s): %w", attempt, err)
 		}
 
+		c.noteResponse(req, resp)
 		body, readErr := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		cancel()
+		release()
+		if resp.StatusCode == http.StatusTooManyRequests {
+			waited = c.pauseForRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		c.logAttempt(req, attempt, resp.StatusCode, start, readErr)
+		tr.recordAttempt(resp.StatusCode, readErr)
 		if readErr != nil {
-			if attempt < maxAttempts && isRetryableTransportError(readErr) {
-				c.sleepWithBackoff(attempt, "")
+			if parent.Err() == nil && attempt < maxAttempts && isRetryableTransportError(readErr) {
+				if err := c.sleepWithBackoff(req, attempt, ""); err != nil {
+					return nil, fmt.Errorf("API request canceled after %d attempt(s): %w", attempt, err)
+				}
 				continue
 			}
 			return nil, fmt.Errorf("reading response after %d attempt(s): %w", attempt, readErr)
 		}
 
+		sentToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if resp.StatusCode != http.StatusUnauthorized {
+			c.noteTokenAccepted(sentToken)
+		} else if !reauthed && c.refreshBearerToken(sentToken) {
+			reauthed = true
+			attempt--
+			continue
+		}
+
+		if compressed && resp.StatusCode == http.StatusUnsupportedMediaType {
+			// The server does not accept gzip bodies: resend uncompressed
+			// and stop compressing for this client and its copies.
+			c.noCompress.Store(true)
+			attempt--
+			continue
+		}
+
 		if attempt < maxAttempts && shouldRetryStatus(resp.StatusCode) {
-			c.sleepWithBackoff(attempt, resp.Header.Get("Retry-After"))
+			if err := c.sleepWithBackoff(req, attempt, resp.Header.Get("Retry-After")); err != nil {
+				return nil, fmt.Errorf("API request canceled after %d attempt(s): %w", attempt, err)
+			}
 			continue
 		}
 
@@ -151,6 +233,7 @@ func (c *Client) doWithRetry(makeRequest func() (*http.Request, error)) (*rawRes
 			StatusCode:  resp.StatusCode,
 			ContentType: resp.Header.Get("Content-Type"),
 			RetryAfter:  resp.Header.Get("Retry-After"),
+			Header:      resp.Header,
 			Body:        body,
 		}, nil
 	}
@@ -185,10 +268,50 @@ func shouldRetryStatus(status int) bool {
 	}
 }
 
-func (c *Client) sleepWithBackoff(attempt int, retryAfterHeader string) {
-	if d, ok := c.parseRetryAfter(retryAfterHeader); ok {
+// sleepWithBackoff waits before the next attempt. It returns the context's
+// error if the client's context is cancelled while waiting.
+func (c *Client) sleepWithBackoff(req *http.Request, attempt int, retryAfterHeader string) error {
+	delay := c.backoffDelay(attempt, retryAfterHeader)
+	c.logRetryWait(req, attempt, delay)
+	return c.wait(delay)
+}
+
+// wait sleeps for d, returning the context's error early if the client's
+// context is cancelled.
+func (c *Client) wait(d time.Duration) error {
+	done := c.Context().Done()
+	if done == nil {
 		c.sleep(d)
-		return
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-done:
+		return c.Context().Err()
+	}
+}
+
+// pauseForRetryAfter holds back all of the client's requests, not just the
+// one that was throttled, for a 429 response's Retry-After. It returns the
+// end of the pause, or the zero time when there is none.
+func (c *Client) pauseForRetryAfter(retryAfterHeader string) time.Time {
+	d, ok := c.parseRetryAfter(retryAfterHeader)
+	if !ok || c.limiter == nil {
+		return time.Time{}
+	}
+	until := c.clock().Add(d)
+	c.limiter.pause(until)
+	return until
+}
+
+// backoffDelay returns how long to wait before the next attempt: the server's
+// Retry-After when present, otherwise capped exponential backoff with full jitter.
+func (c *Client) backoffDelay(attempt int, retryAfterHeader string) time.Duration {
+	if d, ok := c.parseRetryAfter(retryAfterHeader); ok {
+		return d
 	}
 
 	base := c.baseBackoff
@@ -212,14 +335,14 @@ func (c *Client) sleepWithBackoff(attempt int, retryAfterHeader string) {
 		delay = maxBackoff
 	}
 	if delay <= 0 {
-		return
+		return 0
 	}
 
 	// Full jitter in [0, delay).
 	if c.randInt63n != nil {
 		delay = time.Duration(c.randInt63n(int64(delay)))
 	}
-	c.sleep(delay)
+	return delay
 }
 
 func (c *Client) parseRetryAfter(headerValue string) (time.Duration, bool) {
@@ -248,6 +371,9 @@ func (c *Client) parseRetryAfter(headerValue string) (time.Duration, bool) {
 
 // Render renders a region of a spreadsheet and returns the image bytes
 func (c *Client) Render(filePath string, params map[string]string) ([]byte, string, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, "", err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -289,6 +415,14 @@ func (c *Client) Render(filePath string, params map[string]string) ([]byte, stri
 
 // Lint runs lint on a file via POST /v0/xlsx/lint and returns diagnostics
 func (c *Client) Lint(filePath string, params url.Values) (*LintResponse, error) {
+	filePath, cleanup, err := c.strippedUpload(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -330,6 +464,14 @@ func (c *Client) Lint(filePath string, params url.Values) (*LintResponse, error)
 
 // Calc recalculates formulas via POST /v0/xlsx/calc and returns results
 func (c *Client) Calc(filePath string, params url.Values) (*CalcResponse, error) {
+	filePath, cleanup, err := c.strippedUpload(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -371,12 +513,15 @@ func (c *Client) Calc(filePath string, params url.Values) (*CalcResponse, error)
 
 // Exec runs JavaScript against a workbook via multipart POST /v0/xlsx/exec.
 func (c *Client) Exec(filePath string, req ExecRequest, save bool) (*ExecResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	payload, contentType, err := buildExecMultipartPayload(filePath, req, true)
 	if err != nil {
 		return nil, err
 	}
 
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doExec(req, func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/xlsx/exec"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -394,6 +539,7 @@ func (c *Client) Exec(filePath string, req ExecRequest, save bool) (*ExecRespons
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		recordMultipartFiles(httpReq, execPayloadFiles(filePath, req, true))
 		httpReq.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(httpReq)
 		if req.Locale != "" {
@@ -425,7 +571,7 @@ func (c *Client) ExecCreate(filePath string, req ExecRequest, save bool) (*ExecR
 		return nil, err
 	}
 
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doExec(req, func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/xlsx/exec"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -444,6 +590,7 @@ func (c *Client) ExecCreate(filePath string, req ExecRequest, save bool) (*ExecR
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		recordMultipartFiles(httpReq, execPayloadFiles(filePath, req, false))
 		httpReq.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(httpReq)
 		if req.Locale != "" {
@@ -498,6 +645,12 @@ func buildExecMultipartPayload(filePath string, req ExecRequest, includeFile boo
 		return nil, "", fmt.Errorf("writing exec field: %w", err)
 	}
 
+	for _, df := range req.DataFiles {
+		if err := writeExecDataPart(writer, df); err != nil {
+			return nil, "", err
+		}
+	}
+
 	if err := writer.Close(); err != nil {
 		return nil, "", fmt.Errorf("finalizing multipart payload: %w", err)
 	}
@@ -505,6 +658,54 @@ func buildExecMultipartPayload(filePath string, req ExecRequest, includeFile boo
 	return buf.Bytes(), writer.FormDataContentType(), nil
 }
 
+// execPayloadFiles lists the local files buildExecMultipartPayload puts in
+// the payload, in part order.
+func execPayloadFiles(filePath string, req ExecRequest, includeFile bool) []string {
+	var files []string
+	if includeFile {
+		files = append(files, filePath)
+	}
+	for _, df := range req.DataFiles {
+		files = append(files, df.Path)
+	}
+	return files
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeExecDataPart(writer *multipart.Writer, df ExecDataFile) error {
+	f, err := os.Open(df.Path)
+	if err != nil {
+		return fmt.Errorf("cannot open data file: %w", err)
+	}
+	defer f.Close()
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="data"; filename="%s"`, quoteEscaper.Replace(df.Name)))
+	h.Set("Content-Type", detectContentType(df.Path))
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("creating data part: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return fmt.Errorf("writing data file %s: %w", df.Name, err)
+	}
+	return nil
+}
+
+// execRequestBody encodes a files-backed exec request: JSON, or multipart
+// with an "exec" field when the request carries data files.
+func execRequestBody(req ExecRequest) ([]byte, string, error) {
+	if len(req.DataFiles) > 0 {
+		return buildExecMultipartPayload("", req, false)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling exec body: %w", err)
+	}
+	return body, "application/json", nil
+}
+
 // APIError is a typed error returned by API calls, with the HTTP status code.
 type APIError struct {
 	StatusCode int
@@ -531,6 +732,12 @@ func friendlyErrorMessage(statusCode int, code, message, retryAfter string) stri
 		}
 		return "rate limited by API; retry in a moment"
 	}
+	if statusCode == http.StatusRequestEntityTooLarge || code == "payload_too_large" {
+		if code != "" && message != "" {
+			return message + "; shrink the file or split it into smaller files"
+		}
+		return "the API rejected the file as too large (HTTP 413); shrink the file or split it into smaller files"
+	}
 	if statusCode == http.StatusNotFound && code == "not_found" {
 		if strings.Contains(message, "/pptx/") || strings.Contains(message, "/pptx") {
 			return "PPTX commands are not enabled on this Witan deployment. Contact your administrator."
@@ -585,6 +792,16 @@ func IsNotFound(err error) bool {
 	return false
 }
 
+// IsRevisionConflict returns true if the error is a 409 APIError: the request
+// named a revision that is no longer the file's latest because another
+// client saved a newer one.
+func IsRevisionConflict(err error) bool {
+	if apiErr, ok := err.(*APIError); ok {
+		return apiErr.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
 func isRouteNotFound(apiErr *APIError) bool {
 	if apiErr == nil {
 		return false
@@ -666,7 +883,7 @@ func (c *Client) doJSONRequestWith(do doRequest, method, urlPath string, reqBody
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+	if resp.StatusCode != 200 && resp.StatusCode != 201 && resp.StatusCode != 202 {
 		return parseAPIError(resp.StatusCode, resp.Body, resp.RetryAfter)
 	}
 
@@ -678,8 +895,40 @@ func (c *Client) doJSONRequestWith(do doRequest, method, urlPath string, reqBody
 	return nil
 }
 
-// doOnce performs a single request attempt with no retries.
+// doOnce performs a single request attempt with no retries, other than
+// the resend after a token refresh (see withTokenRefresh).
 func (c *Client) doOnce(makeRequest func() (*http.Request, error)) (*rawResponse, error) {
+	return c.withTokenRefresh(makeRequest, c.doOnceAttempt)
+}
+
+// withTokenRefresh sends a request through send and, when the API rejects
+// its bearer token with a 401, refreshes the token (see WithTokenRefresh)
+// and sends it once more. A request rejected with 401 was not processed,
+// so resending it is safe even where retries are not.
+func (c *Client) withTokenRefresh(makeRequest func() (*http.Request, error), send doRequest) (*rawResponse, error) {
+	var sent string
+	build := func() (*http.Request, error) {
+		req, err := makeRequest()
+		if err == nil {
+			sent = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		}
+		return req, err
+	}
+	raw, err := send(build)
+	if err == nil && raw.StatusCode == http.StatusUnauthorized && c.refreshBearerToken(sent) {
+		raw, err = send(build)
+	}
+	if err == nil && raw.StatusCode != http.StatusUnauthorized {
+		c.noteTokenAccepted(sent)
+	}
+	return raw, err
+}
+
+// doOnceAttempt sends one request attempt.
+func (c *Client) doOnceAttempt(makeRequest func() (*http.Request, error)) (raw *rawResponse, err error) {
+	var tr apiTrace
+	defer func() { tr.end(raw, err) }()
+
 	req, err := makeRequest()
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -689,16 +938,31 @@ func (c *Client) doOnce(makeRequest func() (*http.Request, error)) (*rawResponse
 	if timeout <= 0 {
 		timeout = defaultRequestTimeout
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(tr.begin(c.Context(), req, 1), timeout)
 	defer cancel()
 	req = req.WithContext(ctx)
 
+	release, err := c.acquire(time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("API request canceled: %w", err)
+	}
+	defer release()
+
+	start := c.clock()
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
+		c.logAttempt(req, 1, 0, start, err)
+		tr.recordAttempt(0, err)
 		return nil, fmt.Errorf("API request failed: %w", err)
 	}
+	c.noteResponse(req, resp)
 	body, readErr := io.ReadAll(resp.Body)
 	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.pauseForRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	c.logAttempt(req, 1, resp.StatusCode, start, readErr)
+	tr.recordAttempt(resp.StatusCode, readErr)
 	if readErr != nil {
 		return nil, fmt.Errorf("reading response: %w", readErr)
 	}
@@ -706,6 +970,7 @@ func (c *Client) doOnce(makeRequest func() (*http.Request, error)) (*rawResponse
 		StatusCode:  resp.StatusCode,
 		ContentType: resp.Header.Get("Content-Type"),
 		RetryAfter:  resp.Header.Get("Retry-After"),
+		Header:      resp.Header,
 		Body:        body,
 	}, nil
 }
@@ -717,10 +982,11 @@ func (c *Client) setCommonHeaders(req *http.Request) {
 	}
 	req.Header.Set("User-Agent", userAgent)
 
-	if c.APIKey == "" {
+	token := c.BearerToken()
+	if token == "" {
 		return
 	}
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Authorization", "Bearer "+token)
 }
 
 // IsGoogleSheetsURL returns true if the path looks like a Google Sheets URL.
@@ -0,0 +1,524 @@
+// Package clienttest provides an in-process mock of the Witan API for
+// testing Go code that drives package client, without credentials or
+// network access.
+//
+// The mock stores uploaded files and their revisions, and answers the lint,
+// calc, exec, and render endpoints, stateless and files-backed, from
+// fixture functions:
+//
+//	srv := clienttest.NewServer(t)
+//	srv.Lint = func(wb clienttest.Workbook, params url.Values) (*client.LintResponse, error) {
+//		return &client.LintResponse{Total: 1, Diagnostics: diags}, nil
+//	}
+//	c := client.New(srv.URL, "test-key", "", true)
+//	res, err := c.Lint("testdata/model.xlsx", nil)
+//
+// A fixture that returns an error produced by Error answers with that
+// status and error code. Set fixtures before making requests.
+package clienttest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// Workbook is the file a request operates on.
+type Workbook struct {
+	Filename string
+	Content  []byte
+}
+
+// Request is a request the mock received, with any /orgs/<id> prefix
+// removed from Path.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Server is a mock Witan API. Unset fixtures give empty successful
+// results: no lint diagnostics, no calc changes, an exec result of null,
+// and a 1x1 PNG render.
+type Server struct {
+	// URL is the base URL to pass to client.New.
+	URL string
+
+	// Lint answers stateless and files-backed lint requests.
+	Lint func(wb Workbook, params url.Values) (*client.LintResponse, error)
+	// Calc answers calc requests. A File in the response (base64) is the
+	// recalculated workbook; without one the workbook is unchanged. A
+	// files-backed calc without verify=true stores it as a new revision.
+	Calc func(wb Workbook, params url.Values) (*client.CalcResponse, error)
+	// Exec answers exec requests. With save=true, a File in the response
+	// is the saved workbook, stored as a new revision when files-backed.
+	Exec func(wb Workbook, req client.ExecRequest) (*client.ExecResponse, error)
+	// Render answers render requests with image bytes and a content type.
+	Render func(wb Workbook, params url.Values) ([]byte, string, error)
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	files    map[string]*storedFile
+	nextID   int
+	requests []Request
+}
+
+type storedFile struct {
+	filename  string
+	revisions []storedRevision // oldest first
+}
+
+type storedRevision struct {
+	id      string
+	content []byte
+	source  string
+	created time.Time
+}
+
+// NewServer starts a mock server that is closed when t's test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{files: make(map[string]*storedFile)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// Close shuts the server down before the test ends.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Error returns an error for a fixture to answer with: the API's error body
+// with code and message, at HTTP status.
+func Error(status int, code, message string) error {
+	return &client.APIError{StatusCode: status, Code: code, Message: message}
+}
+
+// Requests returns the requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// AddFile stores content as a new file, as if uploaded, and returns its
+// file and revision IDs.
+func (s *Server) AddFile(filename string, content []byte) (fileID, revisionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFileLocked(filename, content)
+}
+
+func (s *Server) addFileLocked(filename string, content []byte) (fileID, revisionID string) {
+	s.nextID++
+	fileID = fmt.Sprintf("file_%d", s.nextID)
+	s.files[fileID] = &storedFile{filename: filename}
+	return fileID, s.addRevisionLocked(fileID, content, "upload")
+}
+
+// Content returns the bytes of a stored revision; an empty revisionID
+// means the newest. ok is false when there is no such file or revision.
+func (s *Server) Content(fileID, revisionID string) (content []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rev, ok := s.revisionLocked(fileID, revisionID)
+	if !ok {
+		return nil, false
+	}
+	return rev.content, true
+}
+
+func (s *Server) addRevisionLocked(fileID string, content []byte, source string) string {
+	s.nextID++
+	id := fmt.Sprintf("rev_%d", s.nextID)
+	f := s.files[fileID]
+	f.revisions = append(f.revisions, storedRevision{id: id, content: content, source: source, created: time.Now().UTC()})
+	return id
+}
+
+func (s *Server) revisionLocked(fileID, revisionID string) (storedRevision, bool) {
+	f, ok := s.files[fileID]
+	if !ok || len(f.revisions) == 0 {
+		return storedRevision{}, false
+	}
+	if revisionID == "" {
+		return f.revisions[len(f.revisions)-1], true
+	}
+	for _, rev := range f.revisions {
+		if rev.id == revisionID {
+			return rev, true
+		}
+	}
+	return storedRevision{}, false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v0")
+	if rest, ok := strings.CutPrefix(path, "/orgs/"); ok {
+		if _, after, found := strings.Cut(rest, "/"); found {
+			path = "/" + after
+		}
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Query: r.URL.Query()})
+	s.mu.Unlock()
+
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeError(w, Error(http.StatusBadRequest, "invalid_request", "bad gzip body"))
+			return
+		}
+		r.Body = io.NopCloser(zr)
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == "POST" && path == "/files":
+		s.upload(w, r, "")
+	case r.Method == "PUT" && len(parts) == 2 && parts[0] == "files":
+		s.upload(w, r, parts[1])
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "files" && parts[2] == "revisions":
+		s.listRevisions(w, parts[1])
+	case r.Method == "GET" && len(parts) == 3 && parts[0] == "files" && parts[2] == "content":
+		s.content(w, r, parts[1])
+	case len(parts) == 4 && parts[0] == "files" && parts[2] == "xlsx":
+		s.filesOperation(w, r, parts[1], parts[3])
+	case r.Method == "POST" && len(parts) == 2 && parts[0] == "xlsx":
+		s.statelessOperation(w, r, parts[1])
+	default:
+		writeError(w, Error(http.StatusNotFound, "not_found", "Route "+r.Method+" "+r.URL.Path+" not found"))
+	}
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, fileID string) {
+	wb, _, err := readMultipart(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mu.Lock()
+	var revisionID string
+	if fileID == "" {
+		fileID, revisionID = s.addFileLocked(wb.Filename, wb.Content)
+	} else if f, ok := s.files[fileID]; !ok {
+		s.mu.Unlock()
+		writeError(w, Error(http.StatusNotFound, "not_found", "file not found"))
+		return
+	} else {
+		f.filename = wb.Filename
+		revisionID = s.addRevisionLocked(fileID, wb.Content, "upload")
+	}
+	s.mu.Unlock()
+	writeJSON(w, client.FileResponse{
+		ID:         fileID,
+		Object:     "file",
+		Filename:   wb.Filename,
+		Bytes:      int64(len(wb.Content)),
+		RevisionID: revisionID,
+		Status:     "processed",
+	})
+}
+
+func (s *Server) listRevisions(w http.ResponseWriter, fileID string) {
+	s.mu.Lock()
+	f, ok := s.files[fileID]
+	var list client.RevisionList
+	if ok {
+		list.Object = "list"
+		for i := len(f.revisions) - 1; i >= 0; i-- {
+			rev := f.revisions[i]
+			list.Data = append(list.Data, client.Revision{
+				ID:        rev.id,
+				Object:    "revision",
+				Bytes:     int64(len(rev.content)),
+				CreatedAt: rev.created.Format(time.RFC3339),
+				Source:    rev.source,
+			})
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, Error(http.StatusNotFound, "not_found", "file not found"))
+		return
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) content(w http.ResponseWriter, r *http.Request, fileID string) {
+	wb, err := s.storedWorkbook(fileID, r.URL.Query().Get("revision"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(wb.Content)
+}
+
+func (s *Server) storedWorkbook(fileID, revisionID string) (Workbook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rev, ok := s.revisionLocked(fileID, revisionID)
+	if !ok {
+		return Workbook{}, Error(http.StatusNotFound, "not_found", "file or revision not found")
+	}
+	return Workbook{Filename: s.files[fileID].filename, Content: rev.content}, nil
+}
+
+// filesOperation answers /files/:id/xlsx/{lint,calc,exec,render}.
+func (s *Server) filesOperation(w http.ResponseWriter, r *http.Request, fileID, op string) {
+	query := r.URL.Query()
+	wb, err := s.storedWorkbook(fileID, query.Get("revision"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	switch {
+	case r.Method == "GET" && op == "lint":
+		s.lint(w, wb, query)
+	case r.Method == "GET" && op == "render":
+		s.render(w, wb, query)
+	case r.Method == "GET" && op == "calc":
+		resp, err := s.calc(wb, query)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if query.Get("verify") != "true" {
+			id, err := s.saveRevision(fileID, wb, resp.File, "calc")
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			resp.RevisionID = &id
+		}
+		resp.File = nil
+		writeJSON(w, resp)
+	case r.Method == "POST" && op == "exec":
+		req, err := readExecRequest(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		resp, err := s.exec(wb, req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if query.Get("save") == "true" && resp.Ok {
+			id, err := s.saveRevision(fileID, wb, resp.File, "exec")
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			resp.RevisionID = &id
+		}
+		resp.File = nil
+		writeJSON(w, resp)
+	default:
+		writeError(w, Error(http.StatusNotFound, "not_found", "Route "+r.Method+" "+r.URL.Path+" not found"))
+	}
+}
+
+// statelessOperation answers POST /xlsx/{lint,calc,exec,render}.
+func (s *Server) statelessOperation(w http.ResponseWriter, r *http.Request, op string) {
+	query := r.URL.Query()
+	if op == "exec" {
+		wb, req, err := readMultipart(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		resp, err := s.exec(wb, req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if query.Get("save") == "true" && resp.Ok && resp.File == nil {
+			resp.File = encode(wb.Content)
+		} else if query.Get("save") != "true" {
+			resp.File = nil
+		}
+		writeJSON(w, resp)
+		return
+	}
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, Error(http.StatusBadRequest, "invalid_request", err.Error()))
+		return
+	}
+	wb := Workbook{Content: content}
+	switch op {
+	case "lint":
+		s.lint(w, wb, query)
+	case "render":
+		s.render(w, wb, query)
+	case "calc":
+		resp, err := s.calc(wb, query)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if resp.File == nil && query.Get("verify") != "true" {
+			resp.File = encode(content)
+		}
+		writeJSON(w, resp)
+	default:
+		writeError(w, Error(http.StatusNotFound, "not_found", "Route "+r.Method+" "+r.URL.Path+" not found"))
+	}
+}
+
+func (s *Server) lint(w http.ResponseWriter, wb Workbook, query url.Values) {
+	resp := &client.LintResponse{Diagnostics: []client.LintDiagnostic{}}
+	if s.Lint != nil {
+		var err error
+		if resp, err = s.Lint(wb, query); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) calc(wb Workbook, query url.Values) (*client.CalcResponse, error) {
+	if s.Calc == nil {
+		return &client.CalcResponse{Touched: map[string]client.CalcTouchedCell{}, Errors: []client.CellError{}}, nil
+	}
+	return s.Calc(wb, query)
+}
+
+func (s *Server) exec(wb Workbook, req client.ExecRequest) (*client.ExecResponse, error) {
+	if s.Exec == nil {
+		return &client.ExecResponse{Ok: true, Result: json.RawMessage("null")}, nil
+	}
+	return s.Exec(wb, req)
+}
+
+func (s *Server) render(w http.ResponseWriter, wb Workbook, query url.Values) {
+	img, contentType := blankPNG, "image/png"
+	if s.Render != nil {
+		var err error
+		if img, contentType, err = s.Render(wb, query); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(img)
+}
+
+// saveRevision stores a fixture's base64 workbook, or wb unchanged when it
+// returned none, as a new revision of fileID.
+func (s *Server) saveRevision(fileID string, wb Workbook, file *string, source string) (string, error) {
+	content := wb.Content
+	if file != nil {
+		decoded, err := base64.StdEncoding.DecodeString(*file)
+		if err != nil {
+			return "", fmt.Errorf("clienttest: fixture returned a File that is not base64: %w", err)
+		}
+		content = decoded
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addRevisionLocked(fileID, content, source), nil
+}
+
+// readMultipart reads an upload or stateless exec body: the "file" part,
+// and the "exec" field when present.
+func readMultipart(r *http.Request) (Workbook, client.ExecRequest, error) {
+	var wb Workbook
+	var req client.ExecRequest
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return wb, req, Error(http.StatusBadRequest, "invalid_request", "expected a multipart/form-data body")
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return wb, req, Error(http.StatusBadRequest, "invalid_request", err.Error())
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return wb, req, Error(http.StatusBadRequest, "invalid_request", err.Error())
+		}
+		switch part.FormName() {
+		case "file":
+			wb = Workbook{Filename: part.FileName(), Content: data}
+		case "exec":
+			if err := json.Unmarshal(data, &req); err != nil {
+				return wb, req, Error(http.StatusBadRequest, "invalid_request", "exec field is not JSON: "+err.Error())
+			}
+		}
+	}
+	return wb, req, nil
+}
+
+// readExecRequest reads a files-backed exec body: JSON, or multipart with
+// an "exec" field.
+func readExecRequest(r *http.Request) (client.ExecRequest, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		_, req, err := readMultipart(r)
+		return req, err
+	}
+	var req client.ExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, Error(http.StatusBadRequest, "invalid_request", "exec body is not JSON: "+err.Error())
+	}
+	return req, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	apiErr := &client.APIError{StatusCode: http.StatusInternalServerError, Code: "internal_error", Message: err.Error()}
+	errors.As(err, &apiErr)
+	status := apiErr.StatusCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	var body client.ErrorResponse
+	body.Error.Code = apiErr.Code
+	body.Error.Message = apiErr.Message
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func encode(content []byte) *string {
+	s := base64.StdEncoding.EncodeToString(content)
+	return &s
+}
+
+// blankPNG is the default render: a 1x1 transparent PNG.
+var blankPNG = func() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+	return buf.Bytes()
+}()
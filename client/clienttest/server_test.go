@@ -0,0 +1,95 @@
+package clienttest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func writeWorkbook(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model.xlsx")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServer_Stateless(t *testing.T) {
+	srv := NewServer(t)
+	srv.Lint = func(wb Workbook, params url.Values) (*client.LintResponse, error) {
+		if string(wb.Content) != "PK v1" || params.Get("range") != "A1" {
+			t.Errorf("lint got %q with %v", wb.Content, params)
+		}
+		return &client.LintResponse{Total: 1, Diagnostics: []client.LintDiagnostic{{RuleId: "D001", Message: "circular"}}}, nil
+	}
+	srv.Exec = func(wb Workbook, req client.ExecRequest) (*client.ExecResponse, error) {
+		if req.Code == "throw" {
+			return nil, Error(http.StatusUnprocessableEntity, "invalid_script", "script rejected")
+		}
+		return &client.ExecResponse{Ok: true, Result: json.RawMessage(`"` + wb.Filename + `"`)}, nil
+	}
+	book := writeWorkbook(t, "PK v1")
+	c := client.New(srv.URL, "test-key", "org1", true)
+
+	lint, err := c.Lint(book, url.Values{"range": {"A1"}})
+	if err != nil || lint.Total != 1 {
+		t.Fatalf("Lint = %+v, %v", lint, err)
+	}
+	exec, err := c.Exec(book, client.ExecRequest{Code: "return 1"}, false)
+	if err != nil || string(exec.Result) != `"model.xlsx"` {
+		t.Fatalf("Exec = %+v, %v", exec, err)
+	}
+	_, err = c.Exec(book, client.ExecRequest{Code: "throw"}, false)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Code != "invalid_script" {
+		t.Fatalf("Exec error = %v, want a 422 invalid_script", err)
+	}
+	png, contentType, err := c.Render(book, map[string]string{"range": "A1:B2"})
+	if err != nil || contentType != "image/png" || len(png) == 0 {
+		t.Fatalf("Render = %d bytes %q, %v", len(png), contentType, err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 4 || reqs[0].Path != "/xlsx/lint" || reqs[0].Query.Get("range") != "A1" {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
+
+func TestServer_FilesBacked(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir()) // upload cache
+	srv := NewServer(t)
+	srv.Calc = func(wb Workbook, params url.Values) (*client.CalcResponse, error) {
+		file := base64.StdEncoding.EncodeToString(append(wb.Content, " calculated"...))
+		return &client.CalcResponse{Changed: []string{"Sheet1!B2"}, File: &file}, nil
+	}
+	book := writeWorkbook(t, "PK v1")
+	c := client.New(srv.URL, "test-key", "", false)
+
+	fileID, revisionID, err := c.EnsureUploaded(book)
+	if err != nil {
+		t.Fatalf("EnsureUploaded: %v", err)
+	}
+	calc, err := c.FilesCalc(fileID, revisionID, nil)
+	if err != nil || calc.RevisionID == nil || *calc.RevisionID == revisionID {
+		t.Fatalf("FilesCalc = %+v, %v", calc, err)
+	}
+	content, err := c.DownloadFileContent(fileID, *calc.RevisionID)
+	if err != nil || string(content) != "PK v1 calculated" {
+		t.Fatalf("DownloadFileContent = %q, %v", content, err)
+	}
+	revisions, err := c.ListRevisions(fileID)
+	if err != nil || len(revisions) != 2 || revisions[0].Source != "calc" {
+		t.Fatalf("ListRevisions = %+v, %v", revisions, err)
+	}
+	if _, err := c.FilesLint(fileID, "rev_missing", nil); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("FilesLint on a missing revision = %v, want ErrNotFound", err)
+	}
+}
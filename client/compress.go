@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// compressMinBytes is the smallest request body that is gzipped; below it
+// the saving does not pay for the encoding.
+const compressMinBytes = 8 << 10
+
+// isCompressibleContentType reports whether a request body of this type is
+// worth gzipping: text and JSON-like payloads. Office files, PDFs, images,
+// and multipart uploads (which carry those files) are already compressed.
+func isCompressibleContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/xml":
+		return true
+	}
+	return false
+}
+
+// compressRequestBody gzips req's body in place when compression is on, the
+// content type is compressible, and the body is at least compressMinBytes.
+// It reports whether the body was compressed. A body that does not shrink
+// is sent as-is.
+func (c *Client) compressRequestBody(req *http.Request) (bool, error) {
+	if c.noCompress.Load() || req.Body == nil || req.Body == http.NoBody ||
+		req.Header.Get("Content-Encoding") != "" ||
+		!isCompressibleContentType(req.Header.Get("Content-Type")) {
+		return false, nil
+	}
+	if req.ContentLength > 0 && req.ContentLength < compressMinBytes {
+		return false, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return false, fmt.Errorf("reading request body: %w", err)
+	}
+	setRequestBody(req, body)
+	if len(body) < compressMinBytes {
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return false, fmt.Errorf("compressing request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return false, fmt.Errorf("compressing request body: %w", err)
+	}
+	if buf.Len() >= len(body) {
+		return false, nil
+	}
+	setRequestBody(req, buf.Bytes())
+	req.Header.Set("Content-Encoding", "gzip")
+	return true, nil
+}
+
+func setRequestBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+}
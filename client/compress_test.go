@@ -0,0 +1,111 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead_GzipsLargeTextBody(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "data.csv")
+	content := strings.Repeat("region,revenue\nnorth,100\n", 1000)
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+		body, _ := io.ReadAll(zr)
+		if string(body) != content {
+			t.Fatalf("decompressed body mismatch: got %d bytes, want %d", len(body), len(content))
+		}
+		if r.ContentLength >= int64(len(content)) {
+			t.Fatalf("Content-Length = %d, expected smaller than %d", r.ContentLength, len(content))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"text":"ok"}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "", "", true)
+	c.maxAttempts = 1
+	if _, err := c.Read(filePath, url.Values{}); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+}
+
+func TestRead_ResendsUncompressedOn415(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "data.json")
+	content := "[" + strings.Repeat(`{"a":1},`, 2000) + `{"a":1}]`
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			io.WriteString(w, `{"error":{"code":"unsupported_media_type","message":"gzip not accepted"}}`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != content {
+			t.Fatalf("uncompressed body mismatch")
+		}
+		io.WriteString(w, `{"text":"ok"}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "", "", true)
+	c.maxAttempts = 1
+	if _, err := c.Read(filePath, url.Values{}); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	// Copies share the fallback.
+	if _, err := c.WithContext(context.Background()).Read(filePath, url.Values{}); err != nil {
+		t.Fatalf("second Read failed: %v", err)
+	}
+	if strings.Join(encodings, ",") != "gzip,," {
+		t.Fatalf("Content-Encoding per request = %q, want gzip then two uncompressed", encodings)
+	}
+}
+
+func TestRead_WithRequestCompressionDisabledSendsPlainBody(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "data.csv")
+	if err := os.WriteFile(filePath, []byte(strings.Repeat("a,b\n", 5000)), 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Encoding"); got != "" {
+			t.Fatalf("Content-Encoding = %q, want none", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"text":"ok"}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "", "", true, WithRequestCompression(false))
+	c.maxAttempts = 1
+	if _, err := c.Read(filePath, url.Values{}); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// FeatureXLSB is the Capabilities feature of a server that processes .xlsb
+// (BIFF12) workbooks directly; without it, convert them with
+// ConvertWorkbook first.
+const FeatureXLSB = "xlsb"
+
+// ConvertWorkbook converts the workbook at filePath to format (e.g. "xlsx")
+// via POST /v0/xlsx/convert and returns the converted workbook's bytes.
+func (c *Client) ConvertWorkbook(filePath, format string) ([]byte, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open file: %w", err)
+		}
+
+		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/xlsx/convert"))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("building URL: %w", err)
+		}
+		u.RawQuery = url.Values{"format": {format}}.Encode()
+
+		req, err := http.NewRequest("POST", u.String(), f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return os.Open(filePath)
+		}
+		req.Header.Set("Content-Type", detectContentType(filePath))
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode != http.StatusOK {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	return raw.Body, nil
+}
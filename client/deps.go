@@ -0,0 +1,126 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DepsOptions selects what Deps traces from a cell.
+type DepsOptions struct {
+	Dependents bool // trace cells that use the cell instead of cells it uses
+	Depth      int  // levels to follow; 0 means 1
+	MaxNodes   int  // stop adding cells after this many; 0 means 500
+}
+
+// DepsGraph is a cell's dependency graph. Nodes are listed breadth-first
+// from Root; each edge runs from a cell to one of its precedents (or, when
+// Direction is "dependents", to one of its dependents).
+type DepsGraph struct {
+	Root      string     `json:"root"`
+	Direction string     `json:"direction"` // precedents or dependents
+	Depth     int        `json:"depth"`
+	Nodes     []DepsNode `json:"nodes"`
+	Edges     []DepsEdge `json:"edges"`
+	Truncated bool       `json:"truncated,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
+}
+
+// DepsNode is one cell in a DepsGraph. Depth is its distance from the root.
+type DepsNode struct {
+	Address       string `json:"address"`
+	Depth         int    `json:"depth"`
+	Value         any    `json:"value,omitempty"`
+	Text          string `json:"text,omitempty"`
+	Formula       string `json:"formula,omitempty"`
+	ReferenceType string `json:"referenceType,omitempty"` // direct, range, named, or table
+}
+
+// DepsEdge links two cells in a DepsGraph.
+type DepsEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+const defaultDepsMaxNodes = 500
+
+// depsCode walks the graph one level at a time so each edge is known; the
+// runtime's own depth option returns cells without saying what links them.
+const depsCode = `const trace = input.dependents ? xlsx.getCellDependents : xlsx.getCellPrecedents;
+const describe = async (address, depth, referenceType) => {
+  const node = { address, depth };
+  if (referenceType) node.referenceType = referenceType;
+  try {
+    const cell = await xlsx.readCell(wb, address);
+    node.address = cell.address || address;
+    node.value = cell.value;
+    node.text = cell.text;
+    if (cell.formula) node.formula = cell.formula;
+  } catch (e) {}
+  return node;
+};
+const root = await describe(input.cell, 0);
+const nodes = new Map([[root.address, root]]);
+const edges = [];
+const warnings = [];
+let truncated = false;
+let frontier = [root.address];
+for (let depth = 1; depth <= input.depth && frontier.length && !truncated; depth++) {
+  const next = [];
+  for (const from of frontier) {
+    const deps = await trace(wb, from, 1);
+    for (const w of deps.warnings || []) warnings.push(w.message || String(w));
+    for (const c of deps.cells) {
+      if (!nodes.has(c.address)) {
+        if (nodes.size >= input.maxNodes) { truncated = true; break; }
+        nodes.set(c.address, await describe(c.address, depth, c.referenceType));
+        next.push(c.address);
+      }
+      edges.push({ from, to: c.address });
+    }
+    if (truncated) break;
+  }
+  frontier = next;
+}
+return { root: root.address, nodes: [...nodes.values()], edges, truncated, warnings };`
+
+// Deps traces the precedents (or dependents) of cell, e.g. "Summary!B10",
+// via a read-only exec call.
+func (c *Client) Deps(filePath, cell string, opts DepsOptions) (*DepsGraph, error) {
+	depth := opts.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+	maxNodes := opts.MaxNodes
+	if maxNodes <= 0 {
+		maxNodes = defaultDepsMaxNodes
+	}
+	input := map[string]any{
+		"cell":       cell,
+		"dependents": opts.Dependents,
+		"depth":      depth,
+		"maxNodes":   maxNodes,
+	}
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: depsCode, Input: input})
+	if err != nil {
+		return nil, err
+	}
+	graph := &DepsGraph{}
+	if err := json.Unmarshal(result, graph); err != nil {
+		return nil, fmt.Errorf("parsing dependency graph: %w", err)
+	}
+	graph.Direction = "precedents"
+	if opts.Dependents {
+		graph.Direction = "dependents"
+	}
+	graph.Depth = depth
+	if graph.Root == "" {
+		graph.Root = cell
+	}
+	if graph.Nodes == nil {
+		graph.Nodes = []DepsNode{}
+	}
+	if graph.Edges == nil {
+		graph.Edges = []DepsEdge{}
+	}
+	return graph, nil
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// contentHash returns "sha256:<hex>" for data, in the form hashFile uses.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// reportedSHA256 returns the SHA-256 digest of the response body that the
+// server reported in header, as lowercase hex. It reads, in order:
+// Repr-Digest (RFC 9530, sha-256), X-Content-SHA256, and an ETag of the form
+// "sha256:<hex>" or a bare 64-character hex string. ok is false when none is
+// present; other ETags are opaque and ignored.
+func reportedSHA256(header http.Header) (digest string, ok bool) {
+	if header == nil {
+		return "", false
+	}
+	for _, item := range strings.Split(header.Get("Repr-Digest"), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || !strings.EqualFold(name, "sha-256") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.Trim(value, ":"))
+		if err == nil && len(raw) == sha256.Size {
+			return hex.EncodeToString(raw), true
+		}
+	}
+	if v := strings.ToLower(strings.TrimSpace(header.Get("X-Content-SHA256"))); isSHA256Hex(v) {
+		return v, true
+	}
+	etag := header.Get("ETag")
+	if strings.HasPrefix(etag, "W/") {
+		return "", false // a weak ETag does not identify the bytes
+	}
+	etag = strings.ToLower(strings.Trim(etag, `"`))
+	etag = strings.TrimPrefix(etag, "sha256:")
+	if isSHA256Hex(etag) {
+		return etag, true
+	}
+	return "", false
+}
+
+func isSHA256Hex(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// verifyContent checks body against the digest the server reported for it,
+// if any.
+func verifyContent(header http.Header, body []byte) error {
+	want, ok := reportedSHA256(header)
+	if !ok {
+		return nil
+	}
+	if got := strings.TrimPrefix(contentHash(body), "sha256:"); got != want {
+		return fmt.Errorf("%w (got sha256 %s, expected %s)", ErrCorruptDownload, got[:12], want[:12])
+	}
+	return nil
+}
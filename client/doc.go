@@ -0,0 +1,19 @@
+// Package client is a Go client for the Witan API, used by the witan CLI
+// and usable on its own.
+//
+// Create a client with New and functional options:
+//
+//	c := client.New("https://api.witanlabs.com", apiKey, orgID, false,
+//		client.WithTimeout(2*time.Minute),
+//		client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 5}),
+//	)
+//	res, err := c.WithContext(ctx).Calc("model.xlsx", nil)
+//	if errors.Is(err, client.ErrRateLimited) {
+//		// back off
+//	}
+//
+// A stateful client (stateless=false) uploads each workbook once and uses
+// the files endpoints (see Files); a stateless client sends the file with
+// every call (see Workbooks). API failures are *APIError values, which
+// match the Err* sentinels with errors.Is.
+package client
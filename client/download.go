@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DownloadInfo describes a file fetched by DownloadURL. ETag and
+// LastModified are the response's validators, for a later conditional
+// download.
+type DownloadInfo struct {
+	ContentType  string
+	Size         int64
+	ETag         string
+	LastModified string
+	NotModified  bool // a conditional download found the copy current; dst is untouched
+}
+
+// DownloadError is returned by DownloadURL for a non-2xx response.
+type DownloadError struct {
+	StatusCode int
+}
+
+func (e *DownloadError) Error() string { return fmt.Sprintf("HTTP %d", e.StatusCode) }
+
+// DownloadURL fetches rawURL, which need not be a Witan API URL, into dst.
+// It retries timeouts, 408/429, and 5xx responses with the client's retry
+// policy and honors Retry-After. When an attempt fails partway through the
+// body and the server supports byte ranges, the next attempt resumes with a
+// Range request instead of starting over; an attempt that resumed and made
+// progress does not count against the retry budget.
+func (c *Client) DownloadURL(rawURL string, dst *os.File) (*DownloadInfo, error) {
+	return c.DownloadURLIfModified(rawURL, dst, "", "")
+}
+
+// DownloadURLIfModified is DownloadURL made conditional on the validators of
+// an earlier download: when the server answers 304 Not Modified, nothing is
+// written and the result has NotModified set. Empty validators download
+// unconditionally.
+func (c *Client) DownloadURLIfModified(rawURL string, dst *os.File, etag, lastModified string) (*DownloadInfo, error) {
+	maxAttempts := c.maxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	parent := c.Context()
+
+	var (
+		written   int64
+		validator string // strong ETag or Last-Modified; empty when ranges are unsupported
+		info      DownloadInfo
+	)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		timeout := c.requestTimeout
+		if timeout <= 0 {
+			timeout = defaultRequestTimeout
+		}
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid URL: %w", err)
+		}
+		if c.UserAgent != "" {
+			req.Header.Set("User-Agent", c.UserAgent)
+		}
+		resuming := written > 0 && validator != ""
+		if resuming {
+			req.Header.Set("Range", "bytes="+strconv.FormatInt(written, 10)+"-")
+			req.Header.Set("If-Range", validator)
+		} else if written == 0 {
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+
+		start := c.clock()
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			cancel()
+			c.logAttempt(req, attempt, 0, start, err)
+			if parent.Err() == nil && attempt < maxAttempts && isRetryableTransportError(err) {
+				if err := c.sleepWithBackoff(req, attempt, ""); err != nil {
+					return nil, fmt.Errorf("download canceled after %d attempt(s): %w", attempt, err)
+				}
+				continue
+			}
+			return nil, fmt.Errorf("download failed after %d attempt(s): %w", attempt, err)
+		}
+
+		if resp.StatusCode == http.StatusNotModified && written == 0 && (etag != "" || lastModified != "") {
+			resp.Body.Close()
+			cancel()
+			c.logAttempt(req, attempt, resp.StatusCode, start, nil)
+			return &DownloadInfo{NotModified: true, ETag: etag, LastModified: lastModified}, nil
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			resp.Body.Close()
+			cancel()
+			c.logAttempt(req, attempt, resp.StatusCode, start, nil)
+			if attempt < maxAttempts && shouldRetryStatus(resp.StatusCode) {
+				if err := c.sleepWithBackoff(req, attempt, resp.Header.Get("Retry-After")); err != nil {
+					return nil, fmt.Errorf("download canceled after %d attempt(s): %w", attempt, err)
+				}
+				continue
+			}
+			return nil, &DownloadError{StatusCode: resp.StatusCode}
+		}
+
+		if !resuming || resp.StatusCode != http.StatusPartialContent || !contentRangeStartsAt(resp.Header.Get("Content-Range"), written) {
+			// A full body: the first attempt, or the server ignored the
+			// range because the file changed.
+			if err := restartDownload(dst); err != nil {
+				resp.Body.Close()
+				cancel()
+				return nil, err
+			}
+			written = 0
+			validator = rangeValidator(resp.Header)
+			info.ContentType = resp.Header.Get("Content-Type")
+			info.ETag = resp.Header.Get("ETag")
+			info.LastModified = resp.Header.Get("Last-Modified")
+		}
+
+		n, copyErr := io.Copy(dst, resp.Body)
+		written += n
+		if copyErr == nil && resp.ContentLength >= 0 && n < resp.ContentLength {
+			copyErr = io.ErrUnexpectedEOF
+		}
+		resp.Body.Close()
+		cancel()
+		c.logAttempt(req, attempt, resp.StatusCode, start, copyErr)
+		if copyErr != nil {
+			if _, ok := copyErr.(*os.PathError); ok || parent.Err() != nil || attempt >= maxAttempts {
+				return nil, fmt.Errorf("download failed after %d attempt(s): %w", attempt, copyErr)
+			}
+			if validator != "" && n > 0 {
+				attempt-- // progress made; the next attempt resumes
+			} else if err := c.sleepWithBackoff(req, attempt, ""); err != nil {
+				return nil, fmt.Errorf("download canceled after %d attempt(s): %w", attempt, err)
+			}
+			continue
+		}
+
+		info.Size = written
+		return &info, nil
+	}
+	return nil, fmt.Errorf("download failed after %d attempt(s)", maxAttempts)
+}
+
+// rangeValidator returns the If-Range value for resuming a response's body,
+// or "" when the server does not accept byte ranges or gives nothing to
+// validate against.
+func rangeValidator(header http.Header) string {
+	if !strings.EqualFold(strings.TrimSpace(header.Get("Accept-Ranges")), "bytes") {
+		return ""
+	}
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// contentRangeStartsAt reports whether a Content-Range header ("bytes
+// start-end/size") starts at offset.
+func contentRangeStartsAt(contentRange string, offset int64) bool {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(contentRange), "bytes ")
+	if !ok {
+		return false
+	}
+	startText, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return false
+	}
+	start, err := strconv.ParseInt(startText, 10, 64)
+	return err == nil && start == offset
+}
+
+func restartDownload(dst *os.File) error {
+	if err := dst.Truncate(0); err != nil {
+		return fmt.Errorf("writing download: %w", err)
+	}
+	if _, err := dst.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("writing download: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// brokenBody yields data and then fails like a dropped connection.
+type brokenBody struct{ r io.Reader }
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *brokenBody) Close() error { return nil }
+
+func downloadTestFile(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "download"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func readDownload(t *testing.T, f *os.File) string {
+	t.Helper()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDownloadURL_RetriesRetryAfter(t *testing.T) {
+	var waits []time.Duration
+	calls := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"3"}}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/pdf"}}, Body: io.NopCloser(strings.NewReader("%PDF-1.7")), ContentLength: -1, Request: req}, nil
+	}))
+	c.sleep = func(d time.Duration) { waits = append(waits, d) }
+	c.UserAgent = "witan-cli/test"
+
+	f := downloadTestFile(t)
+	info, err := c.DownloadURL("https://files.example.com/report.pdf", f)
+	if err != nil {
+		t.Fatalf("DownloadURL: %v", err)
+	}
+	if calls != 2 || len(waits) != 1 || waits[0] != 3*time.Second {
+		t.Fatalf("calls=%d waits=%v, want one 3s Retry-After wait", calls, waits)
+	}
+	if info.ContentType != "application/pdf" || info.Size != 8 || readDownload(t, f) != "%PDF-1.7" {
+		t.Fatalf("info=%+v body=%q", info, readDownload(t, f))
+	}
+}
+
+func TestDownloadURL_ResumesPartialBody(t *testing.T) {
+	const full = "0123456789"
+	var ranges []string
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		ranges = append(ranges, req.Header.Get("Range"))
+		if req.Header.Get("Range") == "" {
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Accept-Ranges": {"bytes"}, "Etag": {`"v1"`}},
+				Body:          &brokenBody{strings.NewReader(full[:4])},
+				ContentLength: int64(len(full)),
+				Request:       req,
+			}, nil
+		}
+		if req.Header.Get("If-Range") != `"v1"` {
+			t.Errorf("If-Range = %q", req.Header.Get("If-Range"))
+		}
+		return &http.Response{
+			StatusCode:    http.StatusPartialContent,
+			Header:        http.Header{"Content-Range": {"bytes 4-9/10"}},
+			Body:          io.NopCloser(strings.NewReader(full[4:])),
+			ContentLength: 6,
+			Request:       req,
+		}, nil
+	}))
+
+	f := downloadTestFile(t)
+	info, err := c.DownloadURL("https://files.example.com/big.pdf", f)
+	if err != nil {
+		t.Fatalf("DownloadURL: %v", err)
+	}
+	if len(ranges) != 2 || ranges[1] != "bytes=4-" {
+		t.Fatalf("ranges = %q, want a resume from byte 4", ranges)
+	}
+	if got := readDownload(t, f); got != full || info.Size != int64(len(full)) {
+		t.Fatalf("body = %q size=%d", got, info.Size)
+	}
+}
+
+func TestDownloadURL_RestartsWithoutRangeSupport(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if req.Header.Get("Range") != "" {
+			t.Errorf("sent Range %q to a server without range support", req.Header.Get("Range"))
+		}
+		var body io.ReadCloser = io.NopCloser(strings.NewReader("abcdef"))
+		if calls == 1 {
+			body = &brokenBody{strings.NewReader("abc")}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body, ContentLength: 6, Request: req}, nil
+	}))
+
+	f := downloadTestFile(t)
+	if _, err := c.DownloadURL("https://files.example.com/a.docx", f); err != nil {
+		t.Fatalf("DownloadURL: %v", err)
+	}
+	if got := readDownload(t, f); got != "abcdef" {
+		t.Fatalf("body = %q, want the full second response only", got)
+	}
+}
+
+func TestDownloadURL_NotFoundIsNotRetried(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))
+
+	_, err := c.DownloadURL("https://files.example.com/missing.pdf", downloadTestFile(t))
+	var dlErr *DownloadError
+	if !errors.As(err, &dlErr) || dlErr.StatusCode != http.StatusNotFound || err.Error() != "HTTP 404" {
+		t.Fatalf("err = %v, want HTTP 404", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDownloadURLIfModified_NotModified(t *testing.T) {
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-None-Match") != `"v1"` || req.Header.Get("If-Modified-Since") != "Tue, 01 Sep 2026 00:00:00 GMT" {
+			t.Errorf("conditional headers = %q / %q", req.Header.Get("If-None-Match"), req.Header.Get("If-Modified-Since"))
+		}
+		return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))
+
+	f := downloadTestFile(t)
+	info, err := c.DownloadURLIfModified("https://files.example.com/report.pdf", f, `"v1"`, "Tue, 01 Sep 2026 00:00:00 GMT")
+	if err != nil {
+		t.Fatalf("DownloadURLIfModified: %v", err)
+	}
+	if !info.NotModified || readDownload(t, f) != "" {
+		t.Fatalf("info=%+v body=%q, want NotModified and nothing written", info, readDownload(t, f))
+	}
+}
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// DryRunFormat selects how DryRunTransport prints a request.
+type DryRunFormat string
+
+const (
+	// DryRunRequest prints the method, URL, query parameters, headers, and
+	// body, with file content summarized by name, size, and SHA-256.
+	DryRunRequest DryRunFormat = "request"
+	// DryRunCurl prints an equivalent curl command.
+	DryRunCurl DryRunFormat = "curl"
+)
+
+// ParseDryRunFormat parses a --dry-run value.
+func ParseDryRunFormat(s string) (DryRunFormat, error) {
+	switch f := DryRunFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case DryRunRequest, DryRunCurl:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid dry-run format %q (want request or curl)", s)
+}
+
+// DryRunTransport prints each request to Out instead of sending it and
+// fails it with an error matching ErrDryRun. Credential headers are
+// redacted as in request logs; curl commands read the key from
+// $WITAN_API_KEY.
+type DryRunTransport struct {
+	Format DryRunFormat
+	Out    io.Writer
+}
+
+func (t DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, file, parts, err := dryRunBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if t.Format == DryRunCurl {
+		writeCurlCommand(t.Out, req, body, file, parts)
+	} else {
+		writeDryRunRequest(t.Out, req, body, file)
+	}
+	return nil, fmt.Errorf("%w: %s %s was not sent", ErrDryRun, req.Method, req.URL.Path)
+}
+
+// multipartFilesBody is a multipart request body that remembers the local
+// path of each file part, in part order, so a dry run can print it.
+type multipartFilesBody struct {
+	io.ReadCloser
+	files []string
+}
+
+// recordMultipartFiles wraps req's body to remember the local paths of its
+// file parts. The length and GetBody set by http.NewRequest are kept.
+func recordMultipartFiles(req *http.Request, files []string) {
+	if len(files) > 0 && req.Body != nil && req.Body != http.NoBody {
+		req.Body = multipartFilesBody{ReadCloser: req.Body, files: files}
+	}
+}
+
+// dryRunBody reads and closes the request body, undoing gzip compression.
+// file is the local path when the body is a file opened for upload, and
+// parts the local paths of a multipart body's file parts.
+func dryRunBody(req *http.Request) (body []byte, file string, parts []string, err error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, "", nil, nil
+	}
+	defer req.Body.Close()
+	switch b := req.Body.(type) {
+	case *os.File:
+		file = b.Name()
+	case multipartFilesBody:
+		parts = b.files
+	}
+	body, err = io.ReadAll(req.Body)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("reading request body: %w", err)
+	}
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("reading request body: %w", err)
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			return nil, "", nil, fmt.Errorf("reading request body: %w", err)
+		}
+	}
+	return body, file, parts, nil
+}
+
+func writeDryRunRequest(w io.Writer, req *http.Request, body []byte, file string) {
+	fmt.Fprintf(w, "%s %s\n", req.Method, req.URL.String())
+	if query := req.URL.Query(); len(query) > 0 {
+		fmt.Fprintln(w, "Query:")
+		for _, key := range sortedKeys(query) {
+			for _, v := range query[key] {
+				fmt.Fprintf(w, "  %s=%s\n", key, v)
+			}
+		}
+	}
+	fmt.Fprintln(w, "Headers:")
+	headers := RedactHeaders(req.Header)
+	for _, key := range sortedKeys(headers) {
+		fmt.Fprintf(w, "  %s: %s\n", key, headers[key])
+	}
+	if body == nil {
+		fmt.Fprintln(w)
+		return
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "multipart/form-data":
+		fmt.Fprintln(w, "Body (multipart/form-data):")
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			if part.FileName() != "" {
+				fmt.Fprintf(w, "  %s: %s\n", part.FormName(), fileSummary(part.FileName(), data))
+				continue
+			}
+			fmt.Fprintf(w, "  %s: %s\n", part.FormName(), indentBody(data, "  "))
+		}
+	case file != "":
+		fmt.Fprintf(w, "Body: %s\n", fileSummary(filepath.Base(file), body))
+	case isCompressibleContentType(req.Header.Get("Content-Type")):
+		fmt.Fprintf(w, "Body:\n%s\n", indentBody(body, ""))
+	default:
+		fmt.Fprintf(w, "Body: %d bytes, sha256 %s\n", len(body), sha256Hex(body))
+	}
+	fmt.Fprintln(w)
+}
+
+func writeCurlCommand(w io.Writer, req *http.Request, body []byte, file string, parts []string) {
+	args := []string{"curl", "-X", req.Method, internal.ShellQuote(req.URL.String())}
+	for _, key := range sortedKeys(req.Header) {
+		switch key {
+		case "Authorization":
+			args = append(args, "-H", `"Authorization: Bearer $WITAN_API_KEY"`)
+			continue
+		case "Content-Encoding", "Content-Length":
+			// The body below is uncompressed; curl sets the length.
+			continue
+		case "Content-Type":
+			if strings.HasPrefix(req.Header.Get(key), "multipart/") {
+				continue // curl -F writes its own boundary
+			}
+		}
+		for _, v := range req.Header[key] {
+			args = append(args, "-H", internal.ShellQuote(key+": "+v))
+		}
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case body == nil:
+	case mediaType == "multipart/form-data":
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		filePart := 0
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			if part.FileName() != "" {
+				// curl reads @path from disk, so name the local file rather
+				// than the uploaded filename.
+				path := part.FileName()
+				if filePart < len(parts) {
+					path = parts[filePart]
+				}
+				filePart++
+				field := part.FormName() + "=@" + curlFormPath(path)
+				if filepath.Base(path) != part.FileName() {
+					field += ";filename=" + part.FileName()
+				}
+				if ct := part.Header.Get("Content-Type"); ct != "" {
+					field += ";type=" + ct
+				}
+				args = append(args, "-F", internal.ShellQuote(field))
+				continue
+			}
+			// --form-string: -F would read ; in a script as an attribute.
+			args = append(args, "--form-string", internal.ShellQuote(part.FormName()+"="+string(data)))
+		}
+	case file != "":
+		args = append(args, "--data-binary", internal.ShellQuote("@"+file))
+	default:
+		args = append(args, "--data-binary", internal.ShellQuote(string(body)))
+	}
+	fmt.Fprintln(w, strings.Join(args, " "))
+}
+
+// curlFormPath quotes a -F file path that curl would otherwise split at a
+// ; or , into attributes.
+func curlFormPath(path string) string {
+	if !strings.ContainsAny(path, `;,"`) {
+		return path
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path) + `"`
+}
+
+// fileSummary describes file content by name, size, and SHA-256.
+func fileSummary(name string, data []byte) string {
+	return fmt.Sprintf("%s (%d bytes, sha256 %s)", name, len(data), sha256Hex(data))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// indentBody pretty-prints a JSON body, or returns text as is, with each
+// line after the first indented by prefix.
+func indentBody(data []byte, prefix string) string {
+	var buf bytes.Buffer
+	if json.Indent(&buf, data, prefix, "  ") == nil {
+		return buf.String()
+	}
+	return strings.ReplaceAll(string(data), "\n", "\n"+prefix)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
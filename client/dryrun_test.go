@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunTransport_PrintsWithoutSending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.xlsx")
+	if err := os.WriteFile(path, []byte("PK workbook"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c := New("https://api.example.test", "secret-key", "org1", true)
+	c.HTTPClient.Transport = DryRunTransport{Format: DryRunRequest, Out: &out}
+
+	_, err := c.Lint(path, url.Values{"range": {"Summary!A1:B2"}})
+	if !errors.Is(err, ErrDryRun) {
+		t.Fatalf("Lint error = %v, want ErrDryRun", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"POST https://api.example.test/v0/orgs/org1/xlsx/lint?range=Summary%21A1%3AB2\n",
+		"  range=Summary!A1:B2\n",
+		"  Authorization: Bearer [REDACTED]\n",
+		"Body: model.xlsx (11 bytes, sha256 ",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret-key") {
+		t.Errorf("output contains the API key:\n%s", got)
+	}
+	if strings.Count(got, "POST ") != 1 {
+		t.Errorf("request was retried:\n%s", got)
+	}
+}
+
+func TestDryRunTransport_Curl(t *testing.T) {
+	var out bytes.Buffer
+	c := New("https://api.example.test", "secret-key", "org1", true)
+	c.HTTPClient.Transport = DryRunTransport{Format: DryRunCurl, Out: &out}
+
+	_, err := c.ExecAPIReference()
+	if !errors.Is(err, ErrDryRun) {
+		t.Fatalf("ExecAPIReference error = %v, want ErrDryRun", err)
+	}
+	got := out.String()
+	if !strings.HasPrefix(got, "curl -X GET 'https://api.example.test/v0/orgs/org1/xlsx/exec/api' ") ||
+		!strings.Contains(got, `-H "Authorization: Bearer $WITAN_API_KEY"`) {
+		t.Errorf("curl command = %s", got)
+	}
+}
+
+func TestDryRunTransport_CurlMultipartUsesLocalPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.xlsx")
+	dataPath := filepath.Join(dir, "input-2024.csv")
+	if err := os.WriteFile(path, []byte("PK workbook"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dataPath, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c := New("https://api.example.test", "secret-key", "org1", true)
+	c.HTTPClient.Transport = DryRunTransport{Format: DryRunCurl, Out: &out}
+
+	req := ExecRequest{Code: "return 1", DataFiles: []ExecDataFile{{Name: "input.csv", Path: dataPath}}}
+	if _, err := c.Exec(path, req, false); !errors.Is(err, ErrDryRun) {
+		t.Fatalf("Exec error = %v, want ErrDryRun", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"-F 'file=@" + path + ";type=",
+		"-F 'data=@" + dataPath + ";filename=input.csv;type=",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("curl command missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ExecAPIFunction is one function of the scripting API exec scripts call,
+// e.g. xlsx.readRange.
+type ExecAPIFunction struct {
+	Name        string `json:"name"`
+	Signature   string `json:"signature,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ExecAPIReference lists the scripting API available to exec scripts via
+// GET /v0/xlsx/exec/api.
+func (c *Client) ExecAPIReference() ([]ExecAPIFunction, error) {
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/xlsx/exec/api"))
+		if err != nil {
+			return nil, fmt.Errorf("building URL: %w", err)
+		}
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode != http.StatusOK {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	var resp struct {
+		Functions []ExecAPIFunction `json:"functions"`
+	}
+	if err := json.Unmarshal(raw.Body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing exec API reference: %w", err)
+	}
+	return resp.Functions, nil
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ExecLibrary is a server-side helper library an exec script can load with
+// ExecRequest.Require.
+type ExecLibrary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// ExecLibraries lists the helper libraries available to exec scripts via
+// GET /v0/xlsx/exec/libraries.
+func (c *Client) ExecLibraries() ([]ExecLibrary, error) {
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/xlsx/exec/libraries"))
+		if err != nil {
+			return nil, fmt.Errorf("building URL: %w", err)
+		}
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode != http.StatusOK {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	var resp struct {
+		Libraries []ExecLibrary `json:"libraries"`
+	}
+	if err := json.Unmarshal(raw.Body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing exec libraries: %w", err)
+	}
+	return resp.Libraries, nil
+}
@@ -0,0 +1,22 @@
+package client
+
+// cellsPrelude is prepended to the exec code of the read-only helpers
+// (Snapshot, WhatIf, and through WhatIf, RunScenarios). It defines:
+//
+//   - quote(sheet): the sheet name as it appears in a reference, quoted
+//     unless it is a plain identifier that cannot be read as a cell (A1,
+//     XFD1048576) or an R1C1 reference (R1C1, RC, R2);
+//   - readCells(range): the cells of range that are not blank, each with a
+//     sheet-qualified address, value, and text.
+const cellsPrelude = `const quote = (s) => /^[A-Za-z_][A-Za-z0-9_.]*$/.test(s) && !/^([A-Za-z]{1,3}[0-9]+|[Rr][0-9]*([Cc][0-9]*)?|[Cc][0-9]*)$/.test(s) ? s : "'" + s.replace(/'/g, "''") + "'";
+const readCells = async (range) => {
+  const cells = [];
+  for (const row of await xlsx.readRange(wb, range)) {
+    for (const v of row) {
+      if (v.type === "blank" && !v.formula) continue;
+      cells.push({ address: quote(v.sheet) + "!" + v.colLetter + v.row, value: v.value, text: v.text });
+    }
+  }
+  return cells;
+};
+`
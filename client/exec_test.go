@@ -360,6 +360,66 @@ func TestFilesExec_PostJSONWithRevisionAndParsesSuccess(t *testing.T) {
 	}
 }
 
+func TestFilesExec_DataFilesSentAsMultipartParts(t *testing.T) {
+	tmpDir := t.TempDir()
+	salesPath := filepath.Join(tmpDir, "sales.csv")
+	ratesPath := filepath.Join(tmpDir, "rates.json")
+	if err := os.WriteFile(salesPath, []byte("region,total\nnorth,10\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ratesPath, []byte(`{"usd":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Fatalf("parsing multipart form: %v", err)
+		}
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(r.FormValue("exec")), &payload); err != nil {
+			t.Fatalf("decoding exec field: %v", err)
+		}
+		if payload["code"] != "return files;" {
+			t.Fatalf("unexpected code: %#v", payload["code"])
+		}
+		if _, ok := payload["DataFiles"]; ok {
+			t.Fatal("data files must not be encoded in the exec field")
+		}
+		parts := r.MultipartForm.File["data"]
+		if len(parts) != 2 || parts[0].Filename != "sales.csv" || parts[1].Filename != "rates.json" {
+			t.Fatalf("unexpected data parts: %+v", parts)
+		}
+		f, err := parts[0].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if got, _ := io.ReadAll(f); string(got) != "region,total\nnorth,10\n" {
+			t.Fatalf("unexpected sales.csv bytes: %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":2}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", false)
+	c.maxAttempts = 1
+
+	resp, err := c.FilesExec("file_123", "rev_9", ExecRequest{
+		Code: "return files;",
+		DataFiles: []ExecDataFile{
+			{Name: "sales.csv", Path: salesPath},
+			{Name: "rates.json", Path: ratesPath},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("FilesExec failed: %v", err)
+	}
+	if !resp.Ok {
+		t.Fatalf("unexpected response: %#v", resp)
+	}
+}
+
 func TestFilesExec_SaveQueryParam(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if got := r.URL.Query().Get("revision"); got != "rev_9" {
@@ -426,6 +486,45 @@ func TestFilesExec_Non200ReturnsAPIError(t *testing.T) {
 	}
 }
 
+func TestFilesExec_StreamErrorEventReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: stdout\ndata: {\"text\":\"loading\\n\"}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"status\":404,\"error\":{\"code\":\"NOT_FOUND\",\"message\":\"missing revision\"}}\n\n")
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", false)
+	var streamed string
+	req := ExecRequest{Code: "return 1", OnStdout: func(text string) { streamed += text }}
+	_, err := c.FilesExec("file_123", "rev_9", req, false)
+	if !IsNotFound(err) {
+		t.Fatalf("expected not-found APIError, got %v", err)
+	}
+	if streamed != "loading\n" {
+		t.Fatalf("streamed stdout = %q", streamed)
+	}
+}
+
+func TestFilesExec_StreamFallsBackToJSONResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"done\n","result":1}`)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", false)
+	called := false
+	req := ExecRequest{Code: "return 1", OnStdout: func(string) { called = true }}
+	resp, err := c.FilesExec("file_123", "rev_9", req, false)
+	if err != nil {
+		t.Fatalf("FilesExec failed: %v", err)
+	}
+	if called || resp.Stdout != "done\n" {
+		t.Fatalf("expected unstreamed stdout in response, got called=%v stdout=%q", called, resp.Stdout)
+	}
+}
+
 func TestBuildPath_WithAndWithoutOrgID(t *testing.T) {
 	c := New("https://api.test.local", "test-key", "", false)
 	if got := c.buildPath("v0", "/xlsx/calc"); got != "/v0/xlsx/calc" {
@@ -3,6 +3,7 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -24,19 +25,44 @@ type FileResponse struct {
 	Status     string `json:"status"`
 }
 
+// Revision is one stored version of an uploaded file.
+type Revision struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt string `json:"created_at"`
+	Source    string `json:"source,omitempty"` // upload|calc|exec, when known
+}
+
+// RevisionList is the response from GET /v0/files/:fileId/revisions.
+type RevisionList struct {
+	Object string     `json:"object"`
+	Data   []Revision `json:"data"`
+}
+
 // UploadFile uploads a local file via multipart POST to /v0/files
 // and returns the file metadata including fileId and revisionId.
+// Files at or above the chunking threshold go through a resumable upload
+// session when the server supports it.
 func (c *Client) UploadFile(filePath string) (*FileResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
+	if resp, err := c.uploadChunked(filePath, ""); !errors.Is(err, errChunkedUploadUnsupported) {
+		return resp, err
+	}
+
 	payload, contentType, err := buildMultipartPayload(filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
-		req, err := http.NewRequest("POST", c.BaseURL+c.buildPath("v0", "/files"), bytes.NewReader(payload))
+		req, err := http.NewRequest("POST", c.BaseURL+c.buildPath("v0", "/files"), c.uploadBody(filePath, payload))
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		recordMultipartFiles(req, []string{filePath})
 		req.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(req)
 		return req, nil
@@ -58,16 +84,24 @@ func (c *Client) UploadFile(filePath string) (*FileResponse, error) {
 
 // UploadFileVersion uploads a local file as a new revision of an existing file.
 func (c *Client) UploadFileVersion(fileID, filePath string) (*FileResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
+	if resp, err := c.uploadChunked(filePath, fileID); !errors.Is(err, errChunkedUploadUnsupported) {
+		return resp, err
+	}
+
 	payload, contentType, err := buildMultipartPayload(filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
-		req, err := http.NewRequest("PUT", c.BaseURL+c.buildPath("v0", "/files/"+fileID), bytes.NewReader(payload))
+		req, err := http.NewRequest("PUT", c.BaseURL+c.buildPath("v0", "/files/"+fileID), c.uploadBody(filePath, payload))
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		recordMultipartFiles(req, []string{filePath})
 		req.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(req)
 		return req, nil
@@ -87,6 +121,20 @@ func (c *Client) UploadFileVersion(fileID, filePath string) (*FileResponse, erro
 	return &result, nil
 }
 
+// uploadBody wraps a single-shot multipart payload so each attempt reports
+// progress when WithUploadProgress is set.
+func (c *Client) uploadBody(filePath string, payload []byte) io.Reader {
+	if c.uploadProgress == nil {
+		return bytes.NewReader(payload)
+	}
+	return &progressReader{
+		r:        bytes.NewReader(payload),
+		filename: filepath.Base(filePath),
+		total:    int64(len(payload)),
+		report:   c.uploadProgress,
+	}
+}
+
 func buildMultipartPayload(filePath string) ([]byte, string, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -124,6 +172,9 @@ func buildMultipartPayload(filePath string) ([]byte, string, error) {
 // the fileID is gone (or the server rejects the version), it falls back
 // to a fresh POST. With no cache entry, a fresh POST is made.
 //
+// Concurrent calls for the same file share one upload: later callers wait
+// for the first and get its result.
+//
 // On a 404 from a downstream op, the caller should call ReuploadFile,
 // which evicts and runs through this path again.
 func (c *Client) EnsureUploaded(filePath string) (fileId, revisionId string, err error) {
@@ -135,7 +186,21 @@ func (c *Client) EnsureUploaded(filePath string) (fileId, revisionId string, err
 		}
 		return resp.ID, resp.RevisionID, nil
 	}
+	if fileID, revisionID, ok := c.session.lookup(filePath, c.BaseURL, c.OrgID); ok {
+		return fileID, revisionID, nil
+	}
+	fileId, revisionId, err = c.uploads.do(entryKey(filePath, c.BaseURL, c.OrgID), func() (string, string, error) {
+		return c.ensureUploaded(filePath)
+	})
+	if err == nil {
+		c.session.update(filePath, c.BaseURL, c.OrgID, fileId, revisionId)
+	}
+	return fileId, revisionId, err
+}
 
+// ensureUploaded is EnsureUploaded for a client with a cache, without the
+// in-flight deduplication.
+func (c *Client) ensureUploaded(filePath string) (fileId, revisionId string, err error) {
 	if entry, ok := c.cache.Get(filePath, c.BaseURL, c.OrgID); ok {
 		hash, err := hashFile(filePath)
 		if err != nil {
@@ -174,10 +239,68 @@ func (c *Client) EnsureUploaded(filePath string) (fileId, revisionId string, err
 func (c *Client) ReuploadFile(filePath string) (fileId, revisionId string, err error) {
 	if c.cache != nil {
 		c.cache.Evict(filePath, c.BaseURL, c.OrgID)
+		c.session.invalidate(filePath, c.BaseURL, c.OrgID)
 	}
 	return c.EnsureUploaded(filePath)
 }
 
+// RefreshRevision uploads the local file as the newest revision of its cached
+// fileID. Use this after a 409 from a files endpoint, when another client has
+// saved a newer revision. Without a usable cache entry it behaves like
+// ReuploadFile.
+func (c *Client) RefreshRevision(filePath string) (fileId, revisionId string, err error) {
+	if c.cache == nil {
+		return c.EnsureUploaded(filePath)
+	}
+	entry, ok := c.cache.Get(filePath, c.BaseURL, c.OrgID)
+	if !ok {
+		return c.EnsureUploaded(filePath)
+	}
+	resp, err := c.UploadFileVersion(entry.FileID, filePath)
+	if err != nil {
+		if shouldFallbackToFreshUpload(err) {
+			return c.ReuploadFile(filePath)
+		}
+		return "", "", err
+	}
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	c.cache.Put(filePath, c.BaseURL, c.OrgID, cacheEntryFromUpload(resp, hash))
+	c.session.update(filePath, c.BaseURL, c.OrgID, resp.ID, resp.RevisionID)
+	return resp.ID, resp.RevisionID, nil
+}
+
+// RecordDownloadedRevision ties filePath to revisionID after content, the
+// revision's downloaded bytes, has been written there. The entry records the
+// hash of content itself, so the next EnsureUploaded is a cache hit. If the
+// file on disk no longer holds content, the entry is evicted instead so the
+// file is uploaded afresh rather than matched to the wrong revision.
+func (c *Client) RecordDownloadedRevision(filePath, fileID, revisionID string, content []byte) error {
+	if c.cache == nil {
+		return nil
+	}
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return err
+	}
+	if hash != contentHash(content) {
+		c.cache.Evict(filePath, c.BaseURL, c.OrgID)
+		c.session.invalidate(filePath, c.BaseURL, c.OrgID)
+		return nil
+	}
+	c.cache.Put(filePath, c.BaseURL, c.OrgID, CacheEntry{
+		FileID:      fileID,
+		RevisionID:  revisionID,
+		ContentHash: hash,
+		Bytes:       int64(len(content)),
+		Filename:    filepath.Base(filePath),
+	})
+	c.session.update(filePath, c.BaseURL, c.OrgID, fileID, revisionID)
+	return nil
+}
+
 // UpdateCachedRevision updates the cache entry after a command produces a new
 // revision for the given file path.
 func (c *Client) UpdateCachedRevision(filePath, fileID, revisionID string) error {
@@ -201,6 +324,7 @@ func (c *Client) UpdateCachedRevision(filePath, fileID, revisionID string) error
 	}
 
 	c.cache.Put(filePath, c.BaseURL, c.OrgID, entry)
+	c.session.update(filePath, c.BaseURL, c.OrgID, fileID, revisionID)
 	return nil
 }
 
@@ -224,6 +348,8 @@ func knownMIMEType(ext string) string {
 		return "application/vnd.ms-excel"
 	case ".xlsm":
 		return "application/vnd.ms-excel.sheet.macroEnabled.12"
+	case ".xlsb":
+		return "application/vnd.ms-excel.sheet.binary.macroEnabled.12"
 	case ".csv":
 		return "text/csv"
 	case ".pdf":
@@ -242,6 +368,22 @@ func knownMIMEType(ext string) string {
 		return "application/xml"
 	case ".html", ".htm":
 		return "text/html"
+	case ".epub":
+		return "application/epub+zip"
+	case ".rtf":
+		return "application/rtf"
+	case ".odt":
+		return "application/vnd.oasis.opendocument.text"
+	case ".eml":
+		return "message/rfc822"
+	case ".msg":
+		return "application/vnd.ms-outlook"
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".tif", ".tiff":
+		return "image/tiff"
 	default:
 		return ""
 	}
@@ -258,9 +400,44 @@ func shouldFallbackToFreshUpload(err error) bool {
 	return apiErr.Code == "filename_mismatch" || apiErr.Code == "content_type_mismatch"
 }
 
+// CachedUpload returns the cached server identity of filePath, whether or
+// not the file has changed since it was uploaded. ok is false when the file
+// has not been uploaded from this machine (or the client is stateless).
+func (c *Client) CachedUpload(filePath string) (entry CacheEntry, ok bool) {
+	if c.cache == nil {
+		return CacheEntry{}, false
+	}
+	return c.cache.Get(filePath, c.BaseURL, c.OrgID)
+}
+
+// UploadIsCurrent reports whether filePath still has the content it had when
+// it was last uploaded from this machine, so its cached revision holds the
+// same bytes. It is false when nothing is cached for the file.
+func (c *Client) UploadIsCurrent(filePath string) (bool, error) {
+	entry, ok := c.CachedUpload(filePath)
+	if !ok {
+		return false, nil
+	}
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	return hash == entry.ContentHash, nil
+}
+
+// ListRevisions calls GET /v0/files/:fileId/revisions and returns the file's
+// revisions, newest first.
+func (c *Client) ListRevisions(fileID string) ([]Revision, error) {
+	var list RevisionList
+	if err := c.doJSONRequest("GET", c.buildPath("v0", "/files/"+fileID+"/revisions"), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
 // FilesLint calls GET /v0/files/:fileId/xlsx/lint and returns lint diagnostics.
 func (c *Client) FilesLint(fileId, revisionId string, params url.Values) (*LintResponse, error) {
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doRevisionGet(true, func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileId+"/xlsx/lint"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -294,8 +471,10 @@ func (c *Client) FilesLint(fileId, revisionId string, params url.Values) (*LintR
 }
 
 // FilesCalc calls GET /v0/files/:fileId/xlsx/calc and returns calc results.
+// Only verify=true calls are served from the response cache; a plain calc
+// creates a new revision.
 func (c *Client) FilesCalc(fileId, revisionId string, params url.Values) (*CalcResponse, error) {
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doRevisionGet(params.Get("verify") == "true", func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileId+"/xlsx/calc"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -328,14 +507,15 @@ func (c *Client) FilesCalc(fileId, revisionId string, params url.Values) (*CalcR
 	return &result, nil
 }
 
-// FilesExec calls POST /v0/files/:fileId/xlsx/exec with JSON body and returns exec results.
+// FilesExec calls POST /v0/files/:fileId/xlsx/exec with a JSON body (multipart
+// when the request has data files) and returns exec results.
 func (c *Client) FilesExec(fileID, revisionID string, req ExecRequest, save bool) (*ExecResponse, error) {
-	body, err := json.Marshal(req)
+	body, contentType, err := execRequestBody(req)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling exec body: %w", err)
+		return nil, err
 	}
 
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doExec(req, func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileID+"/xlsx/exec"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -355,7 +535,8 @@ func (c *Client) FilesExec(fileID, revisionID string, req ExecRequest, save bool
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
-		httpReq.Header.Set("Content-Type", "application/json")
+		recordMultipartFiles(httpReq, execPayloadFiles("", req, false))
+		httpReq.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(httpReq)
 		if req.Locale != "" {
 			httpReq.Header.Set("Accept-Language", req.Locale)
@@ -377,7 +558,34 @@ func (c *Client) FilesExec(fileID, revisionID string, req ExecRequest, save bool
 }
 
 // DownloadFileContent calls GET /v0/files/:fileId/content and returns the raw file bytes.
+// When the server reports a SHA-256 of the content (Repr-Digest,
+// X-Content-SHA256, or a sha256 ETag), the bytes are checked against it; a
+// mismatch is downloaded once more before failing with ErrCorruptDownload.
 func (c *Client) DownloadFileContent(fileId, revisionId string) ([]byte, error) {
+	body, _, err := c.DownloadFileContentIfChanged(fileId, revisionId, "")
+	return body, err
+}
+
+// DownloadFileContentIfChanged is DownloadFileContent for a caller that
+// already holds content with hash knownHash ("sha256:<hex>", as in
+// CacheEntry.ContentHash). It sends If-None-Match with that hash, and when
+// the server answers 304 Not Modified it returns changed=false and no bytes.
+// An empty knownHash always downloads.
+func (c *Client) DownloadFileContentIfChanged(fileId, revisionId, knownHash string) (content []byte, changed bool, err error) {
+	content, changed, err = c.downloadFileContent(fileId, revisionId, knownHash)
+	if errors.Is(err, ErrCorruptDownload) {
+		content, changed, err = c.downloadFileContent(fileId, revisionId, knownHash)
+	}
+	return content, changed, err
+}
+
+// ifNoneMatch lists knownHash in both ETag forms reportedSHA256 accepts.
+func ifNoneMatch(knownHash string) string {
+	hexSum := strings.TrimPrefix(knownHash, "sha256:")
+	return `"sha256:` + hexSum + `", "` + hexSum + `"`
+}
+
+func (c *Client) downloadFileContent(fileId, revisionId, knownHash string) ([]byte, bool, error) {
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileId+"/content"))
 		if err != nil {
@@ -393,16 +601,25 @@ func (c *Client) DownloadFileContent(fileId, revisionId string) ([]byte, error)
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		if knownHash != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch(knownHash))
+		}
 		c.setCommonHeaders(req)
 		return req, nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if raw.StatusCode == http.StatusNotModified && knownHash != "" {
+		return nil, false, nil
 	}
 	if raw.StatusCode != 200 {
-		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+		return nil, false, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	if err := verifyContent(raw.Header, raw.Body); err != nil {
+		return nil, false, err
 	}
-	return raw.Body, nil
+	return raw.Body, true, nil
 }
 
 // FilesRender calls GET /v0/files/:fileId/xlsx/render and returns image bytes.
@@ -1,12 +1,19 @@
 package client
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestEnsureUploaded_CacheHitMatchingHashSkipsNetwork(t *testing.T) {
@@ -191,6 +198,55 @@ func TestEnsureUploaded_FreshUploadOnCacheMiss(t *testing.T) {
 	}
 }
 
+func TestEnsureUploaded_ConcurrentCallsShareOneUpload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.xlsx")
+	if err := os.WriteFile(filePath, []byte("v5"), 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	var postCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v0/files" {
+			postCalls.Add(1)
+			// Hold the upload open so every caller arrives while it is in flight.
+			time.Sleep(100 * time.Millisecond)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"test.xlsx","bytes":2,"revision_id":"rev_1","status":"ready"}`)
+			return
+		}
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", false)
+	c.cache = &FileCache{inMemory: make(map[string]CacheEntry)}
+	c.maxAttempts = 1
+
+	var wg sync.WaitGroup
+	revisions := make([]string, 8)
+	for i := range revisions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, rev, err := c.WithContext(t.Context()).EnsureUploaded(filePath)
+			if err != nil {
+				t.Errorf("EnsureUploaded failed: %v", err)
+			}
+			revisions[i] = rev
+		}()
+	}
+	wg.Wait()
+
+	if n := postCalls.Load(); n != 1 {
+		t.Fatalf("expected 1 upload for %d concurrent callers, got %d", len(revisions), n)
+	}
+	for _, rev := range revisions {
+		if rev != "rev_1" {
+			t.Fatalf("expected every caller to get rev_1, got %v", revisions)
+		}
+	}
+}
+
 // Item 2 fix: two distinct files with identical bytes must NOT collapse onto one fileID.
 func TestEnsureUploaded_IdenticalContentDistinctPathsGetDistinctFileIDs(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -307,3 +363,156 @@ func TestUpdateCachedRevision_StoresEntryByPath(t *testing.T) {
 		t.Fatalf("unexpected entry: %+v", entry)
 	}
 }
+
+func TestSheetNames_CachedUntilContentChanges(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "test.xlsx")
+	if err := os.WriteFile(filePath, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	execCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/files":
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"test.xlsx","bytes":2,"revision_id":"rev_1","status":"ready"}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/v0/files/file_1":
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"test.xlsx","bytes":2,"revision_id":"rev_2","status":"ready"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/files/file_1/xlsx/exec":
+			execCalls++
+			fmt.Fprintf(w, `{"ok":true,"stdout":"","result":[{"sheet":"Inputs","address":"Inputs!A1:B2","rows":2,"cols":2},{"sheet":"Run %d","address":"'Run %d'!A1","rows":1,"cols":1}]}`, execCalls, execCalls)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", false)
+	c.cache = &FileCache{inMemory: make(map[string]CacheEntry)}
+	c.maxAttempts = 1
+
+	for i := 0; i < 2; i++ {
+		names, err := c.SheetNames(filePath)
+		if err != nil {
+			t.Fatalf("SheetNames: %v", err)
+		}
+		if len(names) != 2 || names[0] != "Inputs" || names[1] != "Run 1" {
+			t.Fatalf("call %d: unexpected names %v", i, names)
+		}
+	}
+	if execCalls != 1 {
+		t.Fatalf("expected 1 exec call for unchanged file, got %d", execCalls)
+	}
+
+	if err := os.WriteFile(filePath, []byte("v2"), 0o644); err != nil {
+		t.Fatalf("rewriting temp file: %v", err)
+	}
+	names, err := c.SheetNames(filePath)
+	if err != nil {
+		t.Fatalf("SheetNames after change: %v", err)
+	}
+	if execCalls != 2 || names[1] != "Run 2" {
+		t.Fatalf("expected fresh lookup after content change, got calls=%d names=%v", execCalls, names)
+	}
+}
+
+func TestDownloadFileContent_VerifiesReportedDigest(t *testing.T) {
+	content := []byte("PK\x03\x04revision")
+	sum := sha256.Sum256(content)
+	corrupt := 1
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+		if calls <= corrupt {
+			w.Write([]byte("PK\x03\x04truncat"))
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", true)
+	got, err := c.DownloadFileContent("file_1", "rev_2")
+	if err != nil || string(got) != string(content) || calls != 2 {
+		t.Fatalf("expected a corrupt download to be fetched again, got %q, %v after %d calls", got, err, calls)
+	}
+
+	calls, corrupt = 0, 2
+	if _, err := c.DownloadFileContent("file_1", "rev_2"); !errors.Is(err, ErrCorruptDownload) {
+		t.Fatalf("expected ErrCorruptDownload, got %v", err)
+	}
+}
+
+func TestReportedSHA256(t *testing.T) {
+	hexSum := strings.Repeat("ab", sha256.Size)
+	cases := []struct {
+		header http.Header
+		want   string
+		ok     bool
+	}{
+		{http.Header{"X-Content-Sha256": {strings.ToUpper(hexSum)}}, hexSum, true},
+		{http.Header{"Etag": {`"sha256:` + hexSum + `"`}}, hexSum, true},
+		{http.Header{"Etag": {`"` + hexSum + `"`}}, hexSum, true},
+		{http.Header{"Etag": {`W/"` + hexSum + `"`}}, "", false},
+		{http.Header{"Etag": {`"rev_2"`}}, "", false},
+		{nil, "", false},
+	}
+	for _, tc := range cases {
+		got, ok := reportedSHA256(tc.header)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("reportedSHA256(%v) = %q, %v; want %q, %v", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestRecordDownloadedRevision(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	content := []byte("downloaded")
+	if err := os.WriteFile(filePath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New("https://api.example.com", "test-key", "", false)
+	c.cache = &FileCache{inMemory: make(map[string]CacheEntry)}
+	if err := c.RecordDownloadedRevision(filePath, "file_1", "rev_2", content); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := c.UploadIsCurrent(filePath); !current {
+		t.Fatal("expected the written revision to be a cache hit")
+	}
+	if entry, _ := c.CachedUpload(filePath); entry.RevisionID != "rev_2" || entry.Bytes != int64(len(content)) {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+
+	// A file that does not hold the revision's bytes is not tied to it.
+	if err := c.RecordDownloadedRevision(filePath, "file_1", "rev_3", []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.CachedUpload(filePath); ok {
+		t.Fatal("expected the entry to be evicted")
+	}
+}
+
+func TestDownloadFileContentIfChanged_NotModified(t *testing.T) {
+	known := contentHash([]byte("same bytes"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("If-None-Match"), strings.TrimPrefix(known, "sha256:")) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("new bytes"))
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", true)
+	content, changed, err := c.DownloadFileContentIfChanged("file_1", "rev_2", known)
+	if err != nil || changed || content != nil {
+		t.Fatalf("expected not modified, got %q, %v, %v", content, changed, err)
+	}
+	content, changed, err = c.DownloadFileContentIfChanged("file_1", "rev_2", "")
+	if err != nil || !changed || string(content) != "new bytes" {
+		t.Fatalf("expected a full download without a known hash, got %q, %v, %v", content, changed, err)
+	}
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GoalSeekRequest asks GoalSeek for the value of By, between Min and Max,
+// that makes the formula cell Target equal Goal.
+type GoalSeekRequest struct {
+	Target string  `json:"target"`
+	Goal   float64 `json:"goal"`
+	By     string  `json:"by"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	// Tolerance is the largest accepted |Target - Goal|.
+	Tolerance float64 `json:"tolerance"`
+	// MaxIterations caps the recalculations, including the two at Min and
+	// Max.
+	MaxIterations int `json:"max_iterations"`
+}
+
+// GoalSeekResult is the outcome of GoalSeek. Value is the best input found
+// and Result the target's value with it; Residual is Result - Goal.
+// Converged is false when the residual is still above the tolerance, and
+// Reason is "not_bracketed" when Target does not cross Goal between Min
+// and Max (AtMin and AtMax give its values there), "max_iterations", or
+// "stalled" when the search stopped making progress. StartingInput and
+// StartingValue are By and Target before the search, nil when blank.
+type GoalSeekResult struct {
+	Target        string  `json:"target"`
+	Goal          float64 `json:"goal"`
+	By            string  `json:"by"`
+	Value         float64 `json:"value"`
+	Result        float64 `json:"result"`
+	Residual      float64 `json:"residual"`
+	Iterations    int     `json:"iterations"`
+	Converged     bool    `json:"converged"`
+	Reason        string  `json:"reason,omitempty"`
+	AtMin         float64 `json:"at_min"`
+	AtMax         float64 `json:"at_max"`
+	StartingInput any     `json:"starting_input"`
+	StartingValue any     `json:"starting_value"`
+}
+
+// goalSeekCode searches input.by between input.min and input.max with the
+// Illinois variant of regula falsi, recalculating input.target after each
+// candidate. The exec is never saved, so the workbook is unchanged.
+const goalSeekCode = `const valueOf = async (address) => {
+  const rows = await xlsx.readRange(wb, address);
+  const v = rows.length > 0 && rows[0].length > 0 ? rows[0][0] : null;
+  return v && v.type !== "blank" ? v.value : null;
+};
+const startingInput = await valueOf(input.by);
+const startingValue = await valueOf(input.target);
+let iterations = 0;
+const f = async (x) => {
+  iterations++;
+  await xlsx.setCells(wb, [{ address: input.by, value: x }]);
+  const v = await valueOf(input.target);
+  if (typeof v !== "number" || !Number.isFinite(v)) {
+    throw new Error(input.target + " is " + JSON.stringify(v) + " when " + input.by + " is " + x + "; it must be a number");
+  }
+  return v - input.goal;
+};
+let a = input.min, b = input.max;
+let fa = await f(a), fb = await f(b);
+const atMin = fa + input.goal, atMax = fb + input.goal;
+let best = Math.abs(fa) <= Math.abs(fb) ? { x: a, r: fa } : { x: b, r: fb };
+let reason = "";
+if (Math.abs(best.r) > input.tolerance) {
+  if (fa * fb > 0) {
+    reason = "not_bracketed";
+  } else {
+    while (Math.abs(best.r) > input.tolerance) {
+      if (iterations >= input.max_iterations) {
+        reason = "max_iterations";
+        break;
+      }
+      if (fb === fa) {
+        reason = "stalled";
+        break;
+      }
+      const c = (a * fb - b * fa) / (fb - fa);
+      const fc = await f(c);
+      if (Math.abs(fc) < Math.abs(best.r)) best = { x: c, r: fc };
+      if (fc * fb < 0) {
+        a = b;
+        fa = fb;
+      } else {
+        fa /= 2;
+      }
+      b = c;
+      fb = fc;
+    }
+  }
+}
+return {
+  value: best.x, result: best.r + input.goal, residual: best.r, iterations,
+  converged: reason === "", reason, at_min: atMin, at_max: atMax,
+  starting_input: startingInput, starting_value: startingValue,
+};`
+
+// GoalSeek searches for the input value that makes req.Target reach
+// req.Goal, in one read-only exec call: every candidate is recalculated on
+// the server and the workbook, local or uploaded, is not modified.
+func (c *Client) GoalSeek(filePath string, req GoalSeekRequest) (*GoalSeekResult, error) {
+	if req.Min >= req.Max {
+		return nil, fmt.Errorf("goal seek: min (%g) must be less than max (%g)", req.Min, req.Max)
+	}
+	raw, err := c.execReadOnly(filePath, ExecRequest{Code: goalSeekCode, Input: req})
+	if err != nil {
+		return nil, err
+	}
+	result := GoalSeekResult{Target: req.Target, Goal: req.Goal, By: req.By}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("parsing goal seek result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// Job operations accepted by SubmitJob.
+const (
+	JobOperationCalc = "xlsx.calc"
+	JobOperationExec = "xlsx.exec"
+)
+
+// JobRequest submits an operation to run asynchronously against an uploaded
+// revision.
+type JobRequest struct {
+	Operation string       `json:"operation"`
+	FileID    string       `json:"file_id"`
+	Revision  string       `json:"revision"`
+	Params    url.Values   `json:"params,omitempty"` // calc query parameters
+	Exec      *ExecRequest `json:"exec,omitempty"`
+	Save      bool         `json:"save,omitempty"`
+}
+
+// JobError describes why a job failed.
+type JobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Job is the state of an asynchronous operation.
+type Job struct {
+	ID          string    `json:"id"`
+	Object      string    `json:"object"`
+	Operation   string    `json:"operation"`
+	Status      string    `json:"status"` // queued|running|succeeded|failed|canceled
+	CreatedAt   string    `json:"created_at,omitempty"`
+	CompletedAt string    `json:"completed_at,omitempty"`
+	Error       *JobError `json:"error,omitempty"`
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (j *Job) Done() bool {
+	switch j.Status {
+	case "succeeded", "failed", "canceled":
+		return true
+	}
+	return false
+}
+
+// SubmitJob calls POST /v0/jobs. It is not retried, so a lost response never
+// starts the same operation twice.
+func (c *Client) SubmitJob(req JobRequest) (*Job, error) {
+	var job Job
+	if err := c.doJSONRequestOnce("POST", c.buildPath("v0", "/jobs"), req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob calls GET /v0/jobs/:id.
+func (c *Client) GetJob(id string) (*Job, error) {
+	var job Job
+	if err := c.doJSONRequest("GET", c.buildPath("v0", "/jobs/"+url.PathEscape(id)), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// JobResult calls GET /v0/jobs/:id/result and returns the finished
+// operation's response: a CalcResponse or ExecResponse, as JSON.
+func (c *Client) JobResult(id string) (json.RawMessage, error) {
+	var result json.RawMessage
+	if err := c.doJSONRequest("GET", c.buildPath("v0", "/jobs/"+url.PathEscape(id)+"/result"), nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
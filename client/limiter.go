@@ -0,0 +1,118 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// requestLimiter coordinates every request made through a Client and its
+// WithContext copies: it caps requests in flight, paces them with an
+// optional token bucket, and holds all of them back while a 429
+// Retry-After from any request is in effect.
+type requestLimiter struct {
+	slots chan struct{} // nil means no concurrency cap
+
+	mu         sync.Mutex
+	pauseUntil time.Time
+	rate       float64 // tokens per second; 0 disables the bucket
+	burst      float64
+	tokens     float64
+	refilledAt time.Time
+}
+
+// WithMaxConcurrency caps the requests the client has in flight at once,
+// across goroutines. n <= 0 removes the cap.
+func WithMaxConcurrency(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.limiter.slots = nil
+			return
+		}
+		c.limiter.slots = make(chan struct{}, n)
+	}
+}
+
+// WithRateLimit paces requests with a token bucket of perSecond requests and
+// the given burst. perSecond <= 0 disables pacing.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Client) {
+		l := c.limiter
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if perSecond <= 0 {
+			l.rate = 0
+			return
+		}
+		l.rate = perSecond
+		l.burst = float64(max(burst, 1))
+		l.tokens = l.burst
+		l.refilledAt = time.Time{}
+	}
+}
+
+// acquire blocks until a request may be sent and returns the function that
+// releases its slot. A pause ending at or before waited has already been
+// waited out by this request's own retry backoff and is skipped. It returns
+// the context's error when the client's context is cancelled while waiting.
+func (c *Client) acquire(waited time.Time) (func(), error) {
+	l := c.limiter
+	if l == nil {
+		return func() {}, nil
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-c.Context().Done():
+			return nil, c.Context().Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+	if wait := l.reserve(c.clock(), waited); wait > 0 {
+		if err := c.wait(wait); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// reserve takes a token from the bucket, going into debt when it is empty,
+// and returns how long the caller must wait: until any pause in effect ends
+// and the token is paid off, whichever is later.
+func (l *requestLimiter) reserve(now, waited time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var wait time.Duration
+	if l.pauseUntil.After(waited) && now.Before(l.pauseUntil) {
+		wait = l.pauseUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return wait
+	}
+	if l.refilledAt.IsZero() {
+		l.refilledAt = now
+	}
+	if now.After(l.refilledAt) {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.refilledAt).Seconds()*l.rate)
+		l.refilledAt = now
+	}
+	l.tokens--
+	if l.tokens < 0 {
+		wait = max(wait, time.Duration(-l.tokens/l.rate*float64(time.Second)))
+	}
+	return wait
+}
+
+// pause holds back every request until until, extending any pause already
+// in effect.
+func (l *requestLimiter) pause(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.pauseUntil) {
+		l.pauseUntil = until
+	}
+}
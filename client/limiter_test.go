@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type blockingTransport struct {
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (b *blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := b.inFlight.Add(1)
+	for {
+		p := b.peak.Load()
+		if n <= p || b.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	b.inFlight.Add(-1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("ok")),
+		Request:    req,
+	}, nil
+}
+
+func TestWithMaxConcurrency_CapsRequestsInFlight(t *testing.T) {
+	tr := &blockingTransport{}
+	c := newTestClient(t, tr)
+	WithMaxConcurrency(2)(c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.doWithRetry(func() (*http.Request, error) {
+				return http.NewRequest("GET", "https://api.test.local/v0/test", nil)
+			}); err != nil {
+				t.Errorf("doWithRetry failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := tr.peak.Load(); peak != 2 {
+		t.Fatalf("peak in-flight requests = %d, want 2", peak)
+	}
+}
+
+func TestDoWithRetry_RetryAfterPausesOtherRequests(t *testing.T) {
+	tr := &sequenceTransport{
+		t: t,
+		results: []transportResult{
+			{status: http.StatusTooManyRequests, body: "rate limited", headers: map[string]string{"Retry-After": "5"}},
+			{status: http.StatusOK, body: "ok"},
+		},
+	}
+	c := newTestClient(t, tr)
+	c.maxAttempts = 1
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	var slept []time.Duration
+	c.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	if _, err := c.doWithRetry(func() (*http.Request, error) {
+		return http.NewRequest("GET", "https://api.test.local/v0/a", nil)
+	}); err != nil {
+		t.Fatalf("first request failed: %v", err)
+	}
+	if len(slept) != 0 {
+		t.Fatalf("terminal 429 should not sleep, got %v", slept)
+	}
+
+	// A different request made through a WithContext copy waits out the
+	// remaining pause before it is sent.
+	now = now.Add(2 * time.Second)
+	other := c.WithContext(context.Background())
+	if _, err := other.doWithRetry(func() (*http.Request, error) {
+		return http.NewRequest("GET", "https://api.test.local/v0/b", nil)
+	}); err != nil {
+		t.Fatalf("second request failed: %v", err)
+	}
+	if tr.calls != 2 {
+		t.Fatalf("expected 2 requests, got %d", tr.calls)
+	}
+	if len(slept) != 1 || slept[0] != 3*time.Second {
+		t.Fatalf("expected one 3s pause before the second request, got %v", slept)
+	}
+}
+
+func TestRequestLimiter_TokenBucketPacesRequests(t *testing.T) {
+	l := &requestLimiter{}
+	c := &Client{limiter: l}
+	WithRateLimit(2, 1)(c)
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if wait := l.reserve(now, time.Time{}); wait != 0 {
+		t.Fatalf("first request waited %s, want 0", wait)
+	}
+	if wait := l.reserve(now, time.Time{}); wait != 500*time.Millisecond {
+		t.Fatalf("second request waited %s, want 500ms", wait)
+	}
+	if wait := l.reserve(now.Add(time.Second), time.Time{}); wait != 0 {
+		t.Fatalf("request after refill waited %s, want 0", wait)
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Request log event kinds.
+const (
+	LogEventResponse  = "response"
+	LogEventError     = "error"
+	LogEventRetryWait = "retry_wait"
+)
+
+// RequestLogEvent describes one HTTP attempt or the wait before a retry. It
+// is JSON-serializable so callers can write it directly as a structured log.
+type RequestLogEvent struct {
+	Time       time.Time         `json:"time"`
+	Event      string            `json:"event"`
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Attempt    int               `json:"attempt"`
+	Status     int               `json:"status,omitempty"`
+	DurationMS int64             `json:"duration_ms,omitempty"`
+	WaitMS     int64             `json:"wait_ms,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// redactedHeaders lists headers whose values never reach logs, dry runs,
+// or recorded cassettes.
+var redactedHeaders = map[string]bool{
+	"Authorization":          true,
+	"Cookie":                 true,
+	"Set-Cookie":             true,
+	"Sec-Websocket-Protocol": true,
+}
+
+// RedactHeaders flattens request headers for logging, replacing credentials
+// with "[REDACTED]" while keeping the auth scheme (e.g. "Bearer [REDACTED]").
+func RedactHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]string, len(keys))
+	for _, k := range keys {
+		out[k] = redactHeaderValue(k, strings.Join(h.Values(k), ", "))
+	}
+	return out
+}
+
+// redactHeaderValue returns v, or "[REDACTED]" when k is a credential
+// header; an Authorization scheme is kept.
+func redactHeaderValue(k, v string) string {
+	if !redactedHeaders[http.CanonicalHeaderKey(k)] {
+		return v
+	}
+	if scheme, _, ok := strings.Cut(v, " "); ok && http.CanonicalHeaderKey(k) == "Authorization" {
+		return scheme + " [REDACTED]"
+	}
+	return "[REDACTED]"
+}
+
+func (c *Client) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Client) logAttempt(req *http.Request, attempt, status int, start time.Time, err error) {
+	if c.Logger == nil {
+		return
+	}
+	now := c.clock()
+	ev := RequestLogEvent{
+		Time:       now,
+		Event:      LogEventResponse,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Attempt:    attempt,
+		Status:     status,
+		DurationMS: now.Sub(start).Milliseconds(),
+		Headers:    RedactHeaders(req.Header),
+	}
+	if err != nil {
+		ev.Event = LogEventError
+		ev.Error = err.Error()
+	}
+	c.Logger(ev)
+}
+
+func (c *Client) logRetryWait(req *http.Request, attempt int, wait time.Duration) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger(RequestLogEvent{
+		Time:    c.clock(),
+		Event:   LogEventRetryWait,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Attempt: attempt,
+		WaitMS:  wait.Milliseconds(),
+	})
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDoWithRetry_LogsAttemptsAndRetryWaits(t *testing.T) {
+	tr := &sequenceTransport{
+		t: t,
+		results: []transportResult{
+			{status: http.StatusServiceUnavailable, body: "busy", headers: map[string]string{"Retry-After": "2"}},
+			{status: http.StatusOK, body: "ok"},
+		},
+	}
+	c := newTestClient(t, tr)
+	var events []RequestLogEvent
+	c.Logger = func(ev RequestLogEvent) { events = append(events, ev) }
+
+	_, err := c.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", "https://api.test.local/v0/test", nil)
+		if err != nil {
+			return nil, err
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		t.Fatalf("doWithRetry failed: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(events), events)
+	}
+	if events[0].Event != LogEventResponse || events[0].Status != http.StatusServiceUnavailable || events[0].Attempt != 1 {
+		t.Fatalf("unexpected first event: %+v", events[0])
+	}
+	if events[1].Event != LogEventRetryWait || events[1].WaitMS != (2*time.Second).Milliseconds() || events[1].Attempt != 1 {
+		t.Fatalf("unexpected retry wait event: %+v", events[1])
+	}
+	if events[2].Status != http.StatusOK || events[2].Attempt != 2 || events[2].URL != "https://api.test.local/v0/test" {
+		t.Fatalf("unexpected final event: %+v", events[2])
+	}
+	if got := events[0].Headers["Authorization"]; got != "Bearer [REDACTED]" {
+		t.Fatalf("expected redacted Authorization header, got %q", got)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "ApiKey secret")
+	h.Set("Cookie", "AWSALB=sticky")
+	h.Set("User-Agent", "witan-cli/dev")
+
+	got := RedactHeaders(h)
+	if got["Authorization"] != "ApiKey [REDACTED]" {
+		t.Fatalf("unexpected Authorization: %q", got["Authorization"])
+	}
+	if got["Cookie"] != "[REDACTED]" {
+		t.Fatalf("unexpected Cookie: %q", got["Cookie"])
+	}
+	if got["User-Agent"] != "witan-cli/dev" {
+		t.Fatalf("unexpected User-Agent: %q", got["User-Agent"])
+	}
+}
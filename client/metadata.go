@@ -0,0 +1,318 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// DefinedName is a workbook defined name. Scope is the sheet name for
+// sheet-scoped names and nil for workbook-scoped names.
+type DefinedName struct {
+	Name  string  `json:"name"`
+	Range string  `json:"range"`
+	Scope *string `json:"scope"`
+}
+
+const listDefinedNamesCode = "return (await xlsx.listDefinedNames(wb));"
+
+// ListDefinedNames returns the workbook's defined names via a read-only exec
+// call. Stateful clients reuse (or create) the uploaded revision; stateless
+// clients send the file bytes.
+func (c *Client) ListDefinedNames(filePath string) ([]DefinedName, error) {
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: listDefinedNamesCode})
+	if err != nil {
+		return nil, err
+	}
+	var names []DefinedName
+	if err := json.Unmarshal(result, &names); err != nil {
+		return nil, fmt.Errorf("parsing defined names: %w", err)
+	}
+	return names, nil
+}
+
+// Sheet is one entry from the workbook's sheet inventory.
+type Sheet struct {
+	Sheet   string   `json:"sheet"`
+	Address string   `json:"address"`
+	Rows    int      `json:"rows"`
+	Cols    int      `json:"cols"`
+	Hidden  bool     `json:"hidden,omitempty"`
+	Tables  []string `json:"listObjects,omitempty"` // Excel table names on the sheet
+}
+
+const listSheetsCode = "return (await xlsx.listSheets(wb));"
+
+// ListSheets returns the workbook's sheets in tab order via a read-only
+// exec call.
+func (c *Client) ListSheets(filePath string) ([]Sheet, error) {
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: listSheetsCode})
+	if err != nil {
+		return nil, err
+	}
+	var sheets []Sheet
+	if err := json.Unmarshal(result, &sheets); err != nil {
+		return nil, fmt.Errorf("parsing sheets: %w", err)
+	}
+	return sheets, nil
+}
+
+// SheetNames returns the workbook's sheet names in tab order. Stateful
+// clients keep the names in the file cache next to the uploaded revision, so
+// repeat lookups for an unchanged file make no API calls.
+func (c *Client) SheetNames(filePath string) ([]string, error) {
+	if c.cache != nil {
+		if entry, ok := c.cache.Get(filePath, c.BaseURL, c.OrgID); ok && entry.SheetNames != nil {
+			if hash, err := hashFile(filePath); err == nil && hash == entry.ContentHash {
+				return entry.SheetNames, nil
+			}
+		}
+	}
+
+	sheets, err := c.ListSheets(filePath)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(sheets))
+	for _, s := range sheets {
+		names = append(names, s.Sheet)
+	}
+
+	// ListSheets has just uploaded (or confirmed) the current content, so
+	// the entry's hash describes the file the names came from.
+	if c.cache != nil {
+		if entry, ok := c.cache.Get(filePath, c.BaseURL, c.OrgID); ok {
+			if hash, err := hashFile(filePath); err == nil && hash == entry.ContentHash {
+				entry.SheetNames = names
+				c.cache.Put(filePath, c.BaseURL, c.OrgID, entry)
+			}
+		}
+	}
+	return names, nil
+}
+
+// execReadOnly runs a non-saving exec script against filePath and returns its
+// JSON result, retrying once with a fresh upload if the cached revision is gone.
+func (c *Client) execReadOnly(filePath string, req ExecRequest) (json.RawMessage, error) {
+	var result *ExecResponse
+	var err error
+	if c.Stateless {
+		result, err = c.Exec(filePath, req, false)
+	} else {
+		var fileID, revisionID string
+		fileID, revisionID, err = c.EnsureUploaded(filePath)
+		if err == nil {
+			result, err = c.FilesExec(fileID, revisionID, req, false)
+			if IsNotFound(err) {
+				fileID, revisionID, err = c.ReuploadFile(filePath)
+				if err == nil {
+					result, err = c.FilesExec(fileID, revisionID, req, false)
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !result.Ok {
+		if result.Error != nil {
+			return nil, fmt.Errorf("reading workbook: %s", result.Error.Message)
+		}
+		return nil, fmt.Errorf("reading workbook failed")
+	}
+	return result.Result, nil
+}
+
+// TableColumn is one column of an Excel table (ListObject).
+type TableColumn struct {
+	Name string `json:"name"`
+}
+
+// Table describes an Excel table (ListObject). Ref covers the whole table,
+// including header and totals rows, and may be sheet-qualified.
+type Table struct {
+	Name          string        `json:"name"`
+	Sheet         string        `json:"sheet"`
+	Ref           string        `json:"ref"`
+	Columns       []TableColumn `json:"columns"`
+	ShowHeaderRow *bool         `json:"showHeaderRow,omitempty"` // nil means true, Excel's default
+	ShowTotalsRow bool          `json:"showTotalsRow"`
+}
+
+const getTablesCode = `const out = {};
+for (const name of input.names) out[name] = await xlsx.getListObject(wb, name);
+return out;`
+
+// GetTables returns the named Excel tables via one read-only exec call.
+func (c *Client) GetTables(filePath string, names []string) (map[string]Table, error) {
+	result, err := c.execReadOnly(filePath, ExecRequest{
+		Code:  getTablesCode,
+		Input: map[string]any{"names": names},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var tables map[string]Table
+	if err := json.Unmarshal(result, &tables); err != nil {
+		return nil, fmt.Errorf("parsing table metadata: %w", err)
+	}
+	return tables, nil
+}
+
+// WorkbookMetadata describes a workbook's structure: its sheets with their
+// used ranges, its defined names, and its Excel tables.
+type WorkbookMetadata struct {
+	Sheets       []Sheet       `json:"sheets"`
+	DefinedNames []DefinedName `json:"defined_names"`
+	Tables       []TableName   `json:"tables"`
+}
+
+// TableName locates an Excel table (ListObject) by name; see GetTables for
+// its columns and range.
+type TableName struct {
+	Name  string `json:"name"`
+	Sheet string `json:"sheet"`
+}
+
+// SheetNames returns the sheet names in tab order.
+func (m *WorkbookMetadata) SheetNames() []string {
+	names := make([]string, 0, len(m.Sheets))
+	for _, s := range m.Sheets {
+		names = append(names, s.Sheet)
+	}
+	return names
+}
+
+// errMetadataUnsupported means the server has no metadata endpoint; callers
+// fall back to a read-only exec script.
+var errMetadataUnsupported = errors.New("metadata endpoint not supported")
+
+const workbookMetadataCode = `const sheets = await xlsx.listSheets(wb);
+return { sheets, defined_names: await xlsx.listDefinedNames(wb) };`
+
+// Metadata returns the workbook's sheets, defined names, and tables.
+// Stateful clients use GET /v0/files/:fileId/xlsx/metadata on the uploaded
+// revision (re-uploading once if it is gone); stateless clients POST the
+// bytes to /v0/xlsx/metadata. Against an API without those endpoints it
+// falls back to a read-only exec call.
+func (c *Client) Metadata(filePath string) (*WorkbookMetadata, error) {
+	var meta *WorkbookMetadata
+	var err error
+	if c.Stateless {
+		meta, err = c.statelessMetadata(filePath)
+	} else {
+		var fileID, revisionID string
+		fileID, revisionID, err = c.EnsureUploaded(filePath)
+		if err == nil {
+			meta, err = c.FilesMetadata(fileID, revisionID)
+			if IsNotFound(err) {
+				fileID, revisionID, err = c.ReuploadFile(filePath)
+				if err == nil {
+					meta, err = c.FilesMetadata(fileID, revisionID)
+				}
+			}
+		}
+	}
+	if errors.Is(err, errMetadataUnsupported) {
+		return c.execMetadata(filePath)
+	}
+	return meta, err
+}
+
+// FilesMetadata calls GET /v0/files/:fileId/xlsx/metadata and returns the
+// revision's sheets, defined names, and tables. The response is immutable
+// per revision and is served from the response cache when that is on.
+func (c *Client) FilesMetadata(fileId, revisionId string) (*WorkbookMetadata, error) {
+	raw, err := c.doRevisionGet(true, func() (*http.Request, error) {
+		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileId+"/xlsx/metadata"))
+		if err != nil {
+			return nil, fmt.Errorf("building URL: %w", err)
+		}
+		q := u.Query()
+		q.Set("revision", revisionId)
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequest("GET", u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseMetadataResponse(raw)
+}
+
+func (c *Client) statelessMetadata(filePath string) (*WorkbookMetadata, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open file: %w", err)
+		}
+		req, err := http.NewRequest("POST", c.BaseURL+c.buildPath("v0", "/xlsx/metadata"), f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return os.Open(filePath)
+		}
+		req.Header.Set("Content-Type", detectContentType(filePath))
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode == http.StatusNotFound {
+		// Stateless requests name no file, so a 404 is a missing route.
+		return nil, errMetadataUnsupported
+	}
+	return parseMetadataResponse(raw)
+}
+
+func parseMetadataResponse(raw *rawResponse) (*WorkbookMetadata, error) {
+	if raw.StatusCode == http.StatusMethodNotAllowed {
+		return nil, errMetadataUnsupported
+	}
+	if raw.StatusCode != http.StatusOK {
+		err := parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+		if apiErr, ok := err.(*APIError); ok && isRouteNotFound(apiErr) {
+			return nil, errMetadataUnsupported
+		}
+		return nil, err
+	}
+	var meta WorkbookMetadata
+	if err := json.Unmarshal(raw.Body, &meta); err != nil {
+		return nil, fmt.Errorf("parsing workbook metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// execMetadata builds WorkbookMetadata from the sheet inventory and defined
+// names, via one read-only exec call.
+func (c *Client) execMetadata(filePath string) (*WorkbookMetadata, error) {
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: workbookMetadataCode})
+	if err != nil {
+		return nil, err
+	}
+	var meta WorkbookMetadata
+	if err := json.Unmarshal(result, &meta); err != nil {
+		return nil, fmt.Errorf("parsing workbook metadata: %w", err)
+	}
+	for _, s := range meta.Sheets {
+		for _, name := range s.Tables {
+			meta.Tables = append(meta.Tables, TableName{Name: name, Sheet: s.Sheet})
+		}
+	}
+	return &meta, nil
+}
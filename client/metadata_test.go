@@ -0,0 +1,85 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMetadataTestWorkbook(t *testing.T) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04meta"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestMetadata_FilesEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/files":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_1","status":"ready"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/files/file_1/xlsx/metadata":
+			if got := r.URL.Query().Get("revision"); got != "rev_1" {
+				t.Errorf("unexpected revision: %q", got)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"sheets":[{"sheet":"Data","address":"A1:C10","rows":10,"cols":3,"listObjects":["Sales"]},{"sheet":"Summary","address":"A1:B2","rows":2,"cols":2}],
+				"defined_names":[{"name":"Rate","range":"Summary!B1","scope":null}],
+				"tables":[{"name":"Sales","sheet":"Data"}]}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", false)
+	c.cache = &FileCache{inMemory: make(map[string]CacheEntry)}
+	meta, err := c.Metadata(writeMetadataTestWorkbook(t))
+	if err != nil {
+		t.Fatalf("Metadata failed: %v", err)
+	}
+	if got := strings.Join(meta.SheetNames(), ","); got != "Data,Summary" {
+		t.Fatalf("sheet names = %s", got)
+	}
+	if meta.Sheets[0].Rows != 10 || meta.DefinedNames[0].Name != "Rate" || meta.Tables[0] != (TableName{Name: "Sales", Sheet: "Data"}) {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestMetadata_FallsBackToExecWithoutEndpoint(t *testing.T) {
+	var execCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/xlsx/metadata":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"not_found","message":"Route POST /v0/xlsx/metadata not found"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/xlsx/exec":
+			execCalls++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true,"stdout":"","result":{"sheets":[{"sheet":"Data","address":"A1:C10","rows":10,"cols":3,"listObjects":["Sales","Costs"]}],"defined_names":[]}}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "test-key", "", true)
+	meta, err := c.Metadata(writeMetadataTestWorkbook(t))
+	if err != nil {
+		t.Fatalf("Metadata failed: %v", err)
+	}
+	if execCalls != 1 {
+		t.Fatalf("expected one exec fallback, got %d", execCalls)
+	}
+	if len(meta.Tables) != 2 || meta.Tables[1] != (TableName{Name: "Costs", Sheet: "Data"}) {
+		t.Fatalf("tables = %+v", meta.Tables)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// API notice kinds.
+const (
+	NoticeMinCLIVersion = "min_cli_version"
+	NoticeDeprecation   = "deprecation"
+)
+
+// Notice is an advisory the API attached to a response: the oldest CLI
+// version it still supports (X-Witan-Min-CLI-Version), or a deprecation of
+// the requested endpoint (Deprecation, with optional Sunset and a Link with
+// rel="deprecation").
+type Notice struct {
+	Kind          string
+	MinCLIVersion string // NoticeMinCLIVersion
+	Method        string // NoticeDeprecation: the deprecated request
+	Path          string
+	Deprecation   string // header value: "@<unix seconds>", a date, or "true"
+	Sunset        string // HTTP date after which the endpoint may stop working
+	Link          string // documentation URL
+}
+
+// WithNoticeHandler calls fn for each notice found in a response. fn may be
+// called concurrently and once per response, so callers deduplicate.
+func WithNoticeHandler(fn func(Notice)) Option {
+	return func(c *Client) { c.onNotice = fn }
+}
+
+var deprecationLinkRe = regexp.MustCompile(`<([^>]+)>\s*;[^,]*rel="?deprecation"?`)
+
+// noteResponse reports the notices in resp's headers to the notice handler.
+func (c *Client) noteResponse(req *http.Request, resp *http.Response) {
+	if c.onNotice == nil {
+		return
+	}
+	if v := strings.TrimSpace(resp.Header.Get("X-Witan-Min-CLI-Version")); v != "" {
+		c.onNotice(Notice{Kind: NoticeMinCLIVersion, MinCLIVersion: v})
+	}
+	if v := strings.TrimSpace(resp.Header.Get("Deprecation")); v != "" && v != "false" {
+		n := Notice{
+			Kind:        NoticeDeprecation,
+			Method:      req.Method,
+			Path:        req.URL.Path,
+			Deprecation: v,
+			Sunset:      strings.TrimSpace(resp.Header.Get("Sunset")),
+		}
+		for _, link := range resp.Header.Values("Link") {
+			if m := deprecationLinkRe.FindStringSubmatch(link); m != nil {
+				n.Link = m[1]
+				break
+			}
+		}
+		c.onNotice(n)
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWithNoticeHandler_ReportsVersionAndDeprecationHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Witan-Min-CLI-Version", "v1.8.0")
+		w.Header().Set("Deprecation", "@1798761600")
+		w.Header().Set("Sunset", "Fri, 01 Jan 2027 00:00:00 GMT")
+		w.Header().Add("Link", `<https://docs.witanlabs.com/jobs-v1>; rel="deprecation"; type="text/html"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"job_1","status":"running"}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var notices []Notice
+	c := New(server.URL, "", "", true, WithNoticeHandler(func(n Notice) {
+		mu.Lock()
+		defer mu.Unlock()
+		notices = append(notices, n)
+	}))
+	if _, err := c.GetJob("job_1"); err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+
+	if len(notices) != 2 {
+		t.Fatalf("got %d notices, want 2: %+v", len(notices), notices)
+	}
+	if notices[0].Kind != NoticeMinCLIVersion || notices[0].MinCLIVersion != "v1.8.0" {
+		t.Fatalf("unexpected version notice: %+v", notices[0])
+	}
+	dep := notices[1]
+	if dep.Kind != NoticeDeprecation || dep.Method != "GET" || dep.Path != "/v0/jobs/job_1" {
+		t.Fatalf("unexpected deprecation notice: %+v", dep)
+	}
+	if dep.Sunset != "Fri, 01 Jan 2027 00:00:00 GMT" || dep.Link != "https://docs.witanlabs.com/jobs-v1" {
+		t.Fatalf("unexpected deprecation details: %+v", dep)
+	}
+}
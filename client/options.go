@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configures a Client in New.
+type Option func(*Client)
+
+// RetryPolicy controls how requests are retried on timeouts, 408/429, and
+// 5xx responses. Zero fields keep the defaults (3 attempts, 200ms base
+// backoff, 2s cap). A server Retry-After header overrides the backoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// WithTimeout sets the per-attempt request timeout; see SetRequestTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.SetRequestTimeout(d) }
+}
+
+// WithRetryPolicy replaces the retry policy. Use MaxAttempts: 1 to disable
+// retries.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		if p.MaxAttempts > 0 {
+			c.maxAttempts = p.MaxAttempts
+		}
+		if p.BaseBackoff > 0 {
+			c.baseBackoff = p.BaseBackoff
+		}
+		if p.MaxBackoff > 0 {
+			c.maxBackoff = p.MaxBackoff
+		}
+	}
+}
+
+// WithHTTPClient sends requests through hc instead of a client built by New.
+// hc is used as-is: stateful clients do not attach the persistent cookie
+// jar to it.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.HTTPClient = hc
+		}
+	}
+}
+
+// WithUploadProgress reports upload progress to fn. Large files report once
+// per chunk; smaller files report as the request body is sent, once per
+// attempt.
+func WithUploadProgress(fn UploadProgressFunc) Option {
+	return func(c *Client) { c.uploadProgress = fn }
+}
+
+// WithRequestCompression turns gzip request bodies on or off. It is on by
+// default: text and JSON bodies of 8 KiB or more are sent with
+// Content-Encoding: gzip, and a 415 response resends them uncompressed.
+func WithRequestCompression(enabled bool) Option {
+	return func(c *Client) { c.noCompress.Store(!enabled) }
+}
+
+// WithOffline makes the client refuse network access. Reads of an uploaded
+// revision are answered from the response cache, which it turns on if
+// needed; every other request fails with an error matching ErrOffline.
+func WithOffline() Option {
+	return func(c *Client) {
+		c.HTTPClient = &http.Client{Transport: OfflineTransport{}}
+		if c.responses == nil {
+			WithResponseCache(true)(c)
+		}
+	}
+}
+
+// WithMaxFileBytes rejects uploads larger than n bytes before sending them,
+// typically with the limit from Capabilities. Zero disables the check.
+func WithMaxFileBytes(n int64) Option {
+	return func(c *Client) { c.maxFileBytes = n }
+}
+
+// WithSession answers EnsureUploaded for files pinned in s from the pin,
+// and moves pins as the client writes new revisions. Stateless clients
+// ignore it.
+func WithSession(s *Session) Option {
+	return func(c *Client) { c.session = s }
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.UserAgent = ua }
+}
+
+// WithContext returns a shallow copy of c whose requests are bound to ctx.
+// Cancelling ctx aborts in-flight requests and stops further retries; the
+// copy shares c's HTTP client, file cache, and in-flight uploads.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("client: nil context")
+	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// Context returns the context requests are bound to (Background by default).
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// Sentinel errors for common API failures. APIError values match them with
+// errors.Is, e.g. errors.Is(err, client.ErrRateLimited).
+var (
+	ErrUnauthorized = errors.New("witan: unauthorized")
+	ErrForbidden    = errors.New("witan: forbidden")
+	ErrNotFound     = errors.New("witan: not found")
+	ErrRateLimited  = errors.New("witan: rate limited")
+	ErrServer       = errors.New("witan: server error")
+	// ErrOffline is returned for a request made in offline mode; see
+	// WithOffline and OfflineTransport.
+	ErrOffline = errors.New("witan: offline")
+	// ErrDryRun is returned for a request DryRunTransport printed instead
+	// of sending.
+	ErrDryRun = errors.New("witan: dry run")
+	// ErrNotRecorded is returned by Replayer for a request the cassette
+	// has no unused interaction for.
+	ErrNotRecorded = errors.New("witan: request not in cassette")
+	// ErrCorruptDownload is returned when downloaded file content does not
+	// match the checksum the server reported for it.
+	ErrCorruptDownload = errors.New("witan: downloaded content does not match the server's checksum")
+)
+
+// Is reports whether e matches one of the sentinel errors. ErrNotFound
+// matches the same errors as IsNotFound: a missing resource, not a missing
+// route on an older deployment.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound && !isRouteNotFound(e)
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Workbooks is the stateless spreadsheet API: every call sends the workbook
+// bytes. *Client implements it.
+type Workbooks interface {
+	Calc(filePath string, params url.Values) (*CalcResponse, error)
+	Lint(filePath string, params url.Values) (*LintResponse, error)
+	Render(filePath string, params map[string]string) ([]byte, string, error)
+	Exec(filePath string, req ExecRequest, save bool) (*ExecResponse, error)
+}
+
+// Files is the files-backed spreadsheet API: workbooks are uploaded once and
+// addressed by file and revision ID. *Client implements it.
+type Files interface {
+	EnsureUploaded(filePath string) (fileID, revisionID string, err error)
+	ReuploadFile(filePath string) (fileID, revisionID string, err error)
+	DownloadFileContent(fileID, revisionID string) ([]byte, error)
+	FilesCalc(fileID, revisionID string, params url.Values) (*CalcResponse, error)
+	FilesLint(fileID, revisionID string, params url.Values) (*LintResponse, error)
+	FilesRender(fileID, revisionID string, params map[string]string) ([]byte, string, error)
+	FilesExec(fileID, revisionID string, req ExecRequest, save bool) (*ExecResponse, error)
+}
+
+// Documents is the document text-extraction API. *Client implements it.
+type Documents interface {
+	Read(filePath string, params url.Values) (*ReadResponse, error)
+	ReadOutline(filePath string, params url.Values) (*ReadOutlineResponse, error)
+}
+
+var (
+	_ Workbooks = (*Client)(nil)
+	_ Files     = (*Client)(nil)
+	_ Documents = (*Client)(nil)
+)
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNew_AppliesOptions(t *testing.T) {
+	hc := &http.Client{}
+	c := New("https://api.test.local", "key", "", true,
+		WithTimeout(5*time.Second),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 5, MaxBackoff: time.Second}),
+		WithHTTPClient(hc),
+		WithUserAgent("my-app/1.0"),
+	)
+	if c.requestTimeout != 5*time.Second {
+		t.Fatalf("requestTimeout = %s, want 5s", c.requestTimeout)
+	}
+	if c.maxAttempts != 5 || c.maxBackoff != time.Second || c.baseBackoff != defaultBaseBackoff {
+		t.Fatalf("retry policy not applied: attempts=%d base=%s max=%s", c.maxAttempts, c.baseBackoff, c.maxBackoff)
+	}
+	if c.HTTPClient != hc {
+		t.Fatal("expected WithHTTPClient to replace the HTTP client")
+	}
+	if c.UserAgent != "my-app/1.0" {
+		t.Fatalf("UserAgent = %q", c.UserAgent)
+	}
+}
+
+func TestWithContext_CancelStopsRetries(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	base := New(server.URL, "key", "", true, WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+	c := base.WithContext(ctx)
+	if base.Context() != context.Background() || c.Context() != ctx {
+		t.Fatal("WithContext must not modify the original client")
+	}
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.doWithRetry(func() (*http.Request, error) {
+		return http.NewRequest("GET", server.URL+"/v0/test", nil)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("cancel did not interrupt the Retry-After wait (took %s)", elapsed)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 attempt before cancel, got %d", calls)
+	}
+}
+
+func TestAPIError_MatchesSentinels(t *testing.T) {
+	cases := []struct {
+		err  *APIError
+		want error
+	}{
+		{&APIError{StatusCode: 401}, ErrUnauthorized},
+		{&APIError{StatusCode: 403}, ErrForbidden},
+		{&APIError{StatusCode: 404, Code: "file_not_found"}, ErrNotFound},
+		{&APIError{StatusCode: 429}, ErrRateLimited},
+		{&APIError{StatusCode: 502}, ErrServer},
+	}
+	for _, tc := range cases {
+		wrapped := fmt.Errorf("calc: %w", tc.err)
+		if !errors.Is(wrapped, tc.want) {
+			t.Errorf("errors.Is(%d, %v) = false, want true", tc.err.StatusCode, tc.want)
+		}
+	}
+
+	route := &APIError{StatusCode: 404, Code: "not_found", Message: "Route GET /v0/x not found"}
+	if errors.Is(route, ErrNotFound) {
+		t.Error("route-not-found must not match ErrNotFound")
+	}
+	if errors.Is(&APIError{StatusCode: 400}, ErrServer) {
+		t.Error("400 must not match ErrServer")
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// NormalizePath returns the form of a local path used to key per-file
+// state such as the upload cache: ~ expanded, absolute, and cleaned. On
+// Windows the \\?\ verbatim prefix is dropped (\\?\UNC\server\share becomes
+// \\server\share) and the drive letter is upper-cased, so one file on a
+// local disk or network share has one key however it is spelled.
+func NormalizePath(path string) string {
+	path = ExpandHome(path)
+	if runtime.GOOS == "windows" {
+		path = trimVerbatimPrefix(path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" {
+		path = upperDriveLetter(path)
+	}
+	return path
+}
+
+// ExpandHome replaces a leading ~ in path with the user's home directory.
+// Shells usually do this, but not inside quotes, in --flag=~/path, or in
+// Windows cmd. ~user forms and paths without a home directory are
+// returned unchanged.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !(runtime.GOOS == "windows" && strings.HasPrefix(path, `~\`)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// trimVerbatimPrefix removes the \\?\ prefix Windows uses to lift the
+// MAX_PATH limit. Go adds it itself when opening long paths, so the plain
+// form names the same file.
+func trimVerbatimPrefix(path string) string {
+	if rest, ok := strings.CutPrefix(path, `\\?\UNC\`); ok {
+		return `\\` + rest
+	}
+	if rest, ok := strings.CutPrefix(path, `\\?\`); ok && len(rest) >= 2 && rest[1] == ':' {
+		return rest
+	}
+	return path
+}
+
+// upperDriveLetter upper-cases the drive letter of a path such as c:\x;
+// Windows drive letters are case-insensitive.
+func upperDriveLetter(path string) string {
+	if len(path) >= 2 && path[1] == ':' && path[0] >= 'a' && path[0] <= 'z' {
+		return string(path[0]-'a'+'A') + path[1:]
+	}
+	return path
+}
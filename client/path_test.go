@@ -0,0 +1,42 @@
+package client
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWindowsPathHelpers(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{`\\?\C:\models\big.xlsx`, `C:\models\big.xlsx`},
+		{`\\?\UNC\fileserver\finance\q3.xlsx`, `\\fileserver\finance\q3.xlsx`},
+		{`\\fileserver\finance\q3.xlsx`, `\\fileserver\finance\q3.xlsx`},
+		{`\\?\Volume{b75e2c83}\q3.xlsx`, `\\?\Volume{b75e2c83}\q3.xlsx`},
+	} {
+		if got := trimVerbatimPrefix(tc.in); got != tc.want {
+			t.Errorf("trimVerbatimPrefix(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+	if got := upperDriveLetter(`c:\models\big.xlsx`); got != `C:\models\big.xlsx` {
+		t.Errorf("upperDriveLetter = %q", got)
+	}
+	if got := upperDriveLetter(`\\fileserver\finance`); got != `\\fileserver\finance` {
+		t.Errorf("upperDriveLetter changed a UNC path: %q", got)
+	}
+}
+
+func TestNormalizePath_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	want := filepath.Join(home, "models", "big.xlsx")
+	if got := NormalizePath("~/models/../models/big.xlsx"); got != want {
+		t.Errorf("NormalizePath = %q, want %q", got, want)
+	}
+	if got := ExpandHome("~other/big.xlsx"); got != "~other/big.xlsx" {
+		t.Errorf("ExpandHome expanded ~user: %q", got)
+	}
+	if entryKey("~/models/big.xlsx", "u", "o") != entryKey(want, "u", "o") {
+		t.Error("cache keys differ for ~ and absolute spellings of one path")
+	}
+}
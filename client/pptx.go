@@ -13,6 +13,9 @@ import (
 
 // PPTXRender renders a PPTX slide and returns the image bytes.
 func (c *Client) PPTXRender(filePath string, params map[string]string) ([]byte, string, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, "", err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -54,6 +57,9 @@ func (c *Client) PPTXRender(filePath string, params map[string]string) ([]byte,
 // PPTXExec runs Office.js-compatible JavaScript against a PPTX file via
 // multipart POST /v0/pptx/exec.
 func (c *Client) PPTXExec(filePath string, req ExecRequest, save bool) (*ExecResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	payload, contentType, err := buildExecMultipartPayload(filePath, req, true)
 	if err != nil {
 		return nil, err
@@ -77,6 +83,7 @@ func (c *Client) PPTXExec(filePath string, req ExecRequest, save bool) (*ExecRes
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		recordMultipartFiles(httpReq, execPayloadFiles(filePath, req, true))
 		httpReq.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(httpReq)
 		if req.Locale != "" {
@@ -128,6 +135,7 @@ func (c *Client) PPTXExecCreate(filePath string, req ExecRequest, save bool) (*E
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
+		recordMultipartFiles(httpReq, execPayloadFiles(filePath, req, false))
 		httpReq.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(httpReq)
 		if req.Locale != "" {
@@ -149,11 +157,12 @@ func (c *Client) PPTXExecCreate(filePath string, req ExecRequest, save bool) (*E
 	return &result, nil
 }
 
-// FilesPPTXExec calls POST /v0/files/:fileId/pptx/exec with a JSON body.
+// FilesPPTXExec calls POST /v0/files/:fileId/pptx/exec with a JSON body, or
+// multipart when the request has data files.
 func (c *Client) FilesPPTXExec(fileID, revisionID string, req ExecRequest, save bool) (*ExecResponse, error) {
-	body, err := json.Marshal(req)
+	body, contentType, err := execRequestBody(req)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling exec body: %w", err)
+		return nil, err
 	}
 
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
@@ -175,7 +184,8 @@ func (c *Client) FilesPPTXExec(fileID, revisionID string, req ExecRequest, save
 		if err != nil {
 			return nil, fmt.Errorf("creating request: %w", err)
 		}
-		httpReq.Header.Set("Content-Type", "application/json")
+		recordMultipartFiles(httpReq, execPayloadFiles("", req, false))
+		httpReq.Header.Set("Content-Type", contentType)
 		c.setCommonHeaders(httpReq)
 		if req.Locale != "" {
 			httpReq.Header.Set("Accept-Language", req.Locale)
@@ -254,6 +264,9 @@ func (c *Client) PPTXExecTypes() ([]byte, error) {
 
 // PPTXLint lints a PPTX file via POST /v0/pptx/lint.
 func (c *Client) PPTXLint(filePath string, params url.Values) (*PptxLintResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
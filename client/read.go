@@ -26,6 +26,16 @@ func detectReadContentType(filePath string) string {
 		return "application/vnd.ms-powerpoint"
 	case strings.HasSuffix(lower, ".html"), strings.HasSuffix(lower, ".htm"):
 		return "text/html"
+	case strings.HasSuffix(lower, ".epub"):
+		return "application/epub+zip"
+	case strings.HasSuffix(lower, ".rtf"):
+		return "application/rtf"
+	case strings.HasSuffix(lower, ".odt"):
+		return "application/vnd.oasis.opendocument.text"
+	case strings.HasSuffix(lower, ".eml"):
+		return "message/rfc822"
+	case strings.HasSuffix(lower, ".msg"):
+		return "application/vnd.ms-outlook"
 	case strings.HasSuffix(lower, ".md"):
 		return "text/markdown"
 	case strings.HasSuffix(lower, ".csv"):
@@ -42,6 +52,12 @@ func detectReadContentType(filePath string) string {
 		return "text/yaml"
 	case strings.HasSuffix(lower, ".toml"):
 		return "text/x-toml"
+	case strings.HasSuffix(lower, ".png"):
+		return "image/png"
+	case strings.HasSuffix(lower, ".jpg"), strings.HasSuffix(lower, ".jpeg"):
+		return "image/jpeg"
+	case strings.HasSuffix(lower, ".tif"), strings.HasSuffix(lower, ".tiff"):
+		return "image/tiff"
 	default:
 		return "text/plain"
 	}
@@ -49,6 +65,9 @@ func detectReadContentType(filePath string) string {
 
 // Read calls POST /v0/read with a file in the body.
 func (c *Client) Read(filePath string, params url.Values) (*ReadResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -90,6 +109,9 @@ func (c *Client) Read(filePath string, params url.Values) (*ReadResponse, error)
 
 // ReadOutline calls POST /v0/read?outline=true with a file in the body.
 func (c *Client) ReadOutline(filePath string, params url.Values) (*ReadOutlineResponse, error) {
+	if err := c.checkFileSize(filePath); err != nil {
+		return nil, err
+	}
 	raw, err := c.doWithRetry(func() (*http.Request, error) {
 		f, err := os.Open(filePath)
 		if err != nil {
@@ -136,7 +158,7 @@ func (c *Client) ReadOutline(filePath string, params url.Values) (*ReadOutlineRe
 
 // FilesRead calls GET /v0/files/:fileId/read.
 func (c *Client) FilesRead(fileId, revisionId string, params url.Values) (*ReadResponse, error) {
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doRevisionGet(true, func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileId+"/read"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -171,7 +193,7 @@ func (c *Client) FilesRead(fileId, revisionId string, params url.Values) (*ReadR
 
 // FilesReadOutline calls GET /v0/files/:fileId/read?outline=true.
 func (c *Client) FilesReadOutline(fileId, revisionId string, params url.Values) (*ReadOutlineResponse, error) {
-	raw, err := c.doWithRetry(func() (*http.Request, error) {
+	raw, err := c.doRevisionGet(true, func() (*http.Request, error) {
 		u, err := url.Parse(c.BaseURL + c.buildPath("v0", "/files/"+fileId+"/read"))
 		if err != nil {
 			return nil, fmt.Errorf("building URL: %w", err)
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WorkbookSheetText is one sheet's used range as displayed text, as read by
+// ReadWorkbookText. Rows is empty for a blank sheet.
+type WorkbookSheetText struct {
+	Sheet   string     `json:"sheet"`
+	Address string     `json:"address"`
+	Hidden  bool       `json:"hidden,omitempty"`
+	Rows    [][]string `json:"rows"`
+}
+
+// readWorkbookTextCode returns every sheet's used range as rows of
+// displayed text, in tab order.
+const readWorkbookTextCode = `const out = [];
+for (const s of await xlsx.listSheets(wb)) {
+  const rows = s.rows > 0 ? (await xlsx.readRange(wb, { sheet: s.sheet })).map((row) => row.map((v) => v.text ?? "")) : [];
+  out.push({ sheet: s.sheet, address: s.address, hidden: !!s.hidden, rows });
+}
+return out;`
+
+// ReadWorkbookText reads the displayed text of every sheet via a read-only
+// exec call, for rendering a workbook as a document.
+func (c *Client) ReadWorkbookText(filePath string) ([]WorkbookSheetText, error) {
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: readWorkbookTextCode})
+	if err != nil {
+		return nil, err
+	}
+	sheets := []WorkbookSheetText{}
+	if err := json.Unmarshal(result, &sheets); err != nil {
+		return nil, fmt.Errorf("parsing workbook text: %w", err)
+	}
+	return sheets, nil
+}
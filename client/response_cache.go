@@ -0,0 +1,108 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// responseCache stores successful responses for GET requests on an uploaded
+// revision (lint, calc --verify, read, outline). A revision never changes,
+// so a response keyed by its full URL stays valid and is never expired.
+type responseCache struct {
+	mu  sync.Mutex
+	dir string // empty string = in-memory only
+	mem map[string][]byte
+}
+
+// WithResponseCache turns the revision-keyed response cache on or off. It is
+// off by default. The cache lives next to the upload cache, in
+// responses/ under its directory, or in memory when that has none.
+func WithResponseCache(enabled bool) Option {
+	return func(c *Client) {
+		if !enabled {
+			c.responses = nil
+			return
+		}
+		dir := ""
+		if c.cache != nil && c.cache.dir != "" {
+			dir = filepath.Join(c.cache.dir, "responses")
+		}
+		c.responses = &responseCache{dir: dir, mem: make(map[string][]byte)}
+	}
+}
+
+func responseKey(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return hex.EncodeToString(sum[:])
+}
+
+func (rc *responseCache) get(u string) ([]byte, bool) {
+	key := responseKey(u)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if body, ok := rc.mem[key]; ok {
+		return body, true
+	}
+	if rc.dir == "" {
+		return nil, false
+	}
+	body, err := os.ReadFile(filepath.Join(rc.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	rc.mem[key] = body
+	return body, true
+}
+
+func (rc *responseCache) put(u string, body []byte) {
+	key := responseKey(u)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.mem[key] = body
+	if rc.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(rc.dir, 0o755); err != nil {
+		return
+	}
+	// Write then rename so a concurrent reader never sees a partial body.
+	tmp, err := os.CreateTemp(rc.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(body)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(rc.dir, key+".json")); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// doRevisionGet sends a GET for an immutable revision resource. When the
+// response cache is on and cacheable is true, a stored response for the
+// same URL is returned without a request, and a 200 response is stored.
+func (c *Client) doRevisionGet(cacheable bool, makeRequest func() (*http.Request, error)) (*rawResponse, error) {
+	if c.responses == nil || !cacheable {
+		return c.doWithRetry(makeRequest)
+	}
+	req, err := makeRequest()
+	if err != nil {
+		return nil, err
+	}
+	u := req.URL.String()
+	if body, ok := c.responses.get(u); ok {
+		return &rawResponse{StatusCode: http.StatusOK, ContentType: "application/json", Body: body}, nil
+	}
+	raw, err := c.doWithRetry(makeRequest)
+	if err == nil && raw.StatusCode == http.StatusOK {
+		c.responses.put(u, raw.Body)
+	}
+	return raw, err
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWithResponseCache_ServesRepeatedRevisionReadsFromDisk(t *testing.T) {
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/files/file_1/xlsx/lint":
+			fmt.Fprint(w, `{"diagnostics":[],"total":0}`)
+		case "/v0/files/file_1/xlsx/calc":
+			fmt.Fprint(w, `{"touched":{},"changed":[],"errors":[]}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	newClient := func() *Client {
+		c := New(server.URL, "", "", false)
+		c.cache = &FileCache{dir: dir, inMemory: make(map[string]CacheEntry)}
+		WithResponseCache(true)(c)
+		c.maxAttempts = 1
+		return c
+	}
+
+	c := newClient()
+	params := url.Values{"range": {"Sheet1!A1:B2"}}
+	for i := 0; i < 2; i++ {
+		if _, err := c.FilesLint("file_1", "rev_1", params); err != nil {
+			t.Fatalf("FilesLint failed: %v", err)
+		}
+	}
+	// A fresh client (a later CLI run) reads the stored response from disk.
+	if _, err := newClient().FilesLint("file_1", "rev_1", params); err != nil {
+		t.Fatalf("FilesLint from disk failed: %v", err)
+	}
+	if calls["/v0/files/file_1/xlsx/lint"] != 1 {
+		t.Fatalf("lint requests = %d, want 1", calls["/v0/files/file_1/xlsx/lint"])
+	}
+
+	// A new revision is a different resource.
+	if _, err := c.FilesLint("file_1", "rev_2", params); err != nil {
+		t.Fatalf("FilesLint rev_2 failed: %v", err)
+	}
+	if calls["/v0/files/file_1/xlsx/lint"] != 2 {
+		t.Fatalf("lint requests = %d, want 2 after new revision", calls["/v0/files/file_1/xlsx/lint"])
+	}
+
+	// calc is only cached with verify=true; a plain calc writes a revision.
+	for i := 0; i < 2; i++ {
+		if _, err := c.FilesCalc("file_1", "rev_1", url.Values{"verify": {"true"}}); err != nil {
+			t.Fatalf("FilesCalc verify failed: %v", err)
+		}
+		if _, err := c.FilesCalc("file_1", "rev_1", url.Values{}); err != nil {
+			t.Fatalf("FilesCalc failed: %v", err)
+		}
+	}
+	if calls["/v0/files/file_1/xlsx/calc"] != 3 {
+		t.Fatalf("calc requests = %d, want 3 (1 verify + 2 plain)", calls["/v0/files/file_1/xlsx/calc"])
+	}
+}
+
+func TestWithOffline_ServesCachedResponsesAndRefusesTheRest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"diagnostics":[],"total":0}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	online := New(server.URL, "", "", false)
+	online.cache = &FileCache{dir: dir, inMemory: make(map[string]CacheEntry)}
+	WithResponseCache(true)(online)
+	if _, err := online.FilesLint("file_1", "rev_1", url.Values{}); err != nil {
+		t.Fatalf("online FilesLint failed: %v", err)
+	}
+
+	server.Close()
+	offline := New(server.URL, "", "", false)
+	offline.cache = &FileCache{dir: dir, inMemory: make(map[string]CacheEntry)}
+	WithOffline()(offline)
+
+	if _, err := offline.FilesLint("file_1", "rev_1", url.Values{}); err != nil {
+		t.Fatalf("offline FilesLint should be served from cache: %v", err)
+	}
+	_, err := offline.FilesLint("file_1", "rev_2", url.Values{})
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline for uncached revision, got %v", err)
+	}
+}
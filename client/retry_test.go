@@ -372,3 +372,31 @@ func TestCreateGoogleSheet_DoesNotRetryMutatingPost(t *testing.T) {
 		t.Fatalf("expected 1 attempt (create must not retry), got %d", tr.calls)
 	}
 }
+
+func TestSetRequestTimeout_AppliesDeadlinePerAttempt(t *testing.T) {
+	var gotDeadline time.Time
+	tr := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotDeadline, _ = req.Context().Deadline()
+		return &http.Response{
+			StatusCode: 200,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+			Request:    req,
+		}, nil
+	})
+	c := newTestClient(t, tr)
+	c.SetRequestTimeout(5 * time.Minute)
+
+	start := time.Now()
+	if err := c.doJSONRequest("GET", "/v0/ping", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if remaining := gotDeadline.Sub(start); remaining < 4*time.Minute || remaining > 5*time.Minute+time.Second {
+		t.Fatalf("expected ~5m deadline, got %v", remaining)
+	}
+
+	c.SetRequestTimeout(0)
+	if c.requestTimeout != defaultRequestTimeout {
+		t.Fatalf("expected zero to restore default, got %v", c.requestTimeout)
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import "sync"
+
+// Scenario is a named set of input overrides for RunScenarios.
+type Scenario struct {
+	Name   string         `json:"name"`
+	Inputs []CellOverride `json:"inputs"`
+}
+
+// ScenarioResult is one scenario's recalculated outputs, or the error that
+// stopped it.
+type ScenarioResult struct {
+	Name    string         `json:"name"`
+	Inputs  []CellOverride `json:"inputs"`
+	Outputs []WhatIfRange  `json:"outputs,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// RunScenarios runs WhatIf on filePath once per scenario, at most
+// concurrency at a time, and returns the results in scenario order. A
+// failed scenario records its error and does not stop the others. Like
+// WhatIf, it never modifies the workbook.
+func (c *Client) RunScenarios(filePath string, scenarios []Scenario, outputs []string, concurrency int) []ScenarioResult {
+	results := make([]ScenarioResult, len(scenarios))
+	sem := make(chan struct{}, max(1, min(concurrency, len(scenarios))))
+	var wg sync.WaitGroup
+	for i, s := range scenarios {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = ScenarioResult{Name: s.Name, Inputs: s.Inputs}
+			result, err := c.WhatIf(filePath, s.Inputs, outputs)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Outputs = result.Outputs
+		}()
+	}
+	wg.Wait()
+	return results
+}
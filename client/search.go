@@ -0,0 +1,62 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SearchOptions selects the cells Search returns. Pattern matches cell text,
+// or formula text when Formulas is set, case-insensitively unless MatchCase
+// is set.
+type SearchOptions struct {
+	Pattern   string
+	Formulas  bool   // match formulas instead of displayed values
+	Regex     bool   // Pattern is a JavaScript regular expression, not literal text
+	MatchCase bool   // match case exactly
+	In        string // sheet or range to search; empty searches every sheet
+	Limit     int    // most matches to return; 0 uses the server default
+}
+
+// SearchMatch is one cell Search found.
+type SearchMatch struct {
+	Address string `json:"address"`
+	Sheet   string `json:"sheet"`
+	Value   any    `json:"value"`
+	Text    string `json:"text"`
+	Formula string `json:"formula,omitempty"`
+}
+
+// searchCode runs findCells with a regular expression built from the
+// options, so literal text matches as a substring rather than fuzzily.
+const searchCode = `const flags = input.matchCase ? "" : "i";
+const source = input.regex ? input.pattern : input.pattern.replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
+const re = new RegExp(source, flags);
+const opts = { formulas: input.formulas };
+if (input.in) opts.in = input.in;
+if (input.limit) opts.limit = input.limit;
+const cells = await xlsx.findCells(wb, re, opts);
+return cells
+  .filter((c) => !input.formulas || (c.formula && re.test(c.formula)))
+  .map((c) => ({ address: c.address, sheet: c.sheet, value: c.value, text: c.text, formula: c.formula }));`
+
+// Search finds cells whose text (or formula) matches opts.Pattern via a
+// read-only exec call.
+func (c *Client) Search(filePath string, opts SearchOptions) ([]SearchMatch, error) {
+	input := map[string]any{
+		"pattern":   opts.Pattern,
+		"formulas":  opts.Formulas,
+		"regex":     opts.Regex,
+		"matchCase": opts.MatchCase,
+		"in":        opts.In,
+		"limit":     opts.Limit,
+	}
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: searchCode, Input: input})
+	if err != nil {
+		return nil, err
+	}
+	matches := []SearchMatch{}
+	if err := json.Unmarshal(result, &matches); err != nil {
+		return nil, fmt.Errorf("parsing search results: %w", err)
+	}
+	return matches, nil
+}
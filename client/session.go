@@ -0,0 +1,210 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const sessionVersion = 1
+
+// Session pins local files to uploaded revisions for a series of commands,
+// such as those run in one shell after `witan xlsx session start`. While a
+// pinned file's size and modification time are unchanged, EnsureUploaded
+// returns its pinned revision without hashing the file or consulting the
+// upload cache. Writes back through the client move the pin to the new
+// revision. The session is a JSON file, saved on every change.
+type Session struct {
+	path string
+
+	mu   sync.Mutex
+	data sessionData
+}
+
+type sessionData struct {
+	Version int                   `json:"v"`
+	Pins    map[string]SessionPin `json:"pins"`
+}
+
+// SessionPin is one pinned file.
+type SessionPin struct {
+	File       string    `json:"file"`
+	FileID     string    `json:"file_id"`
+	RevisionID string    `json:"revision_id"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+}
+
+// NewSession returns an empty session that saves to path.
+func NewSession(path string) *Session {
+	return &Session{path: path, data: sessionData{Version: sessionVersion, Pins: map[string]SessionPin{}}}
+}
+
+// OpenSession reads the session saved at path.
+func OpenSession(path string) (*Session, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	s := NewSession(path)
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("parsing session %s: %w", path, err)
+	}
+	if s.data.Version != sessionVersion {
+		return nil, fmt.Errorf("session %s has version %d; this client reads version %d", path, s.data.Version, sessionVersion)
+	}
+	if s.data.Pins == nil {
+		s.data.Pins = map[string]SessionPin{}
+	}
+	return s, nil
+}
+
+// Path returns the file the session is saved to.
+func (s *Session) Path() string {
+	return s.path
+}
+
+// Pins returns the pinned files, sorted by path.
+func (s *Session) Pins() []SessionPin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pins := make([]SessionPin, 0, len(s.data.Pins))
+	for _, p := range s.data.Pins {
+		pins = append(pins, p)
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].File < pins[j].File })
+	return pins
+}
+
+// Pin uploads filePath through c if needed and pins it to that revision.
+func (s *Session) Pin(c *Client, filePath string) (SessionPin, error) {
+	if c.Stateless {
+		return SessionPin{}, errors.New("pinning a file needs a stateful client")
+	}
+	fileID, revisionID, err := c.EnsureUploaded(filePath)
+	if err != nil {
+		return SessionPin{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pin, err := s.pinLocked(entryKey(filePath, c.BaseURL, c.OrgID), filePath, fileID, revisionID)
+	if err != nil {
+		return SessionPin{}, err
+	}
+	return pin, s.saveLocked()
+}
+
+// Save writes the session to its file.
+func (s *Session) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked()
+}
+
+func (s *Session) pinLocked(key, filePath, fileID, revisionID string) (SessionPin, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return SessionPin{}, err
+	}
+	pin := SessionPin{
+		File:       NormalizePath(filePath),
+		FileID:     fileID,
+		RevisionID: revisionID,
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+	}
+	s.data.Pins[key] = pin
+	return pin, nil
+}
+
+func (s *Session) saveLocked() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	// Write then rename so a command reading the session concurrently
+	// never sees a partial file.
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	_, werr := tmp.Write(append(raw, '\n'))
+	cerr := tmp.Close()
+	if err := errors.Join(werr, cerr); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("saving session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("saving session: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the pinned revision for filePath when the file still has
+// the size and modification time it had when pinned. A nil session has no
+// pins.
+func (s *Session) lookup(filePath, baseURL, orgID string) (fileID, revisionID string, ok bool) {
+	if s == nil {
+		return "", "", false
+	}
+	s.mu.Lock()
+	pin, ok := s.data.Pins[entryKey(filePath, baseURL, orgID)]
+	s.mu.Unlock()
+	if !ok || pin.RevisionID == "" {
+		return "", "", false
+	}
+	info, err := os.Stat(filePath)
+	if err != nil || info.Size() != pin.Size || !info.ModTime().Equal(pin.ModTime) {
+		return "", "", false
+	}
+	return pin.FileID, pin.RevisionID, true
+}
+
+// update moves an existing pin to revisionID, recording the file's current
+// size and modification time. Files that are not pinned are ignored.
+func (s *Session) update(filePath, baseURL, orgID, fileID, revisionID string) {
+	if s == nil {
+		return
+	}
+	key := entryKey(filePath, baseURL, orgID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pin, ok := s.data.Pins[key]
+	if !ok || pin.FileID == fileID && pin.RevisionID == revisionID && s.unchangedLocked(filePath, pin) {
+		return
+	}
+	if _, err := s.pinLocked(key, filePath, fileID, revisionID); err == nil {
+		_ = s.saveLocked()
+	}
+}
+
+// invalidate keeps filePath pinned but stops lookup from answering for it
+// until the next update, e.g. after its revision turned out to be gone.
+func (s *Session) invalidate(filePath, baseURL, orgID string) {
+	if s == nil {
+		return
+	}
+	key := entryKey(filePath, baseURL, orgID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pin, ok := s.data.Pins[key]; ok && pin.RevisionID != "" {
+		pin.RevisionID = ""
+		s.data.Pins[key] = pin
+		_ = s.saveLocked()
+	}
+}
+
+func (s *Session) unchangedLocked(filePath string, pin SessionPin) bool {
+	info, err := os.Stat(filePath)
+	return err == nil && info.Size() == pin.Size && info.ModTime().Equal(pin.ModTime)
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// sessionTestServer answers uploads with file_1 and revisions rev_1,
+// rev_2, ... and fails any other request.
+func sessionTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var uploads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/files" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		n := uploads.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":2,"revision_id":"rev_%d","status":"ready"}`, n)
+	}))
+	t.Cleanup(server.Close)
+	return server, &uploads
+}
+
+func newSessionTestClient(url string, s *Session) *Client {
+	c := New(url, "test-key", "", false, WithSession(s))
+	c.cache = &FileCache{inMemory: make(map[string]CacheEntry)}
+	c.maxAttempts = 1
+	return c
+}
+
+func TestSession_PinnedFileSkipsUploadCache(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	server, uploads := sessionTestServer(t)
+	sessionPath := filepath.Join(dir, "session.json")
+
+	s := NewSession(sessionPath)
+	pin, err := s.Pin(newSessionTestClient(server.URL, nil), filePath)
+	if err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	if pin.FileID != "file_1" || pin.RevisionID != "rev_1" {
+		t.Fatalf("pin = %+v", pin)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("expected only the workbook and the session in %s, got %d entries", dir, len(entries))
+	}
+
+	// A later command: a client with an empty upload cache still finds the
+	// revision through the saved session.
+	reopened, err := OpenSession(sessionPath)
+	if err != nil {
+		t.Fatalf("OpenSession: %v", err)
+	}
+	c := newSessionTestClient(server.URL, reopened)
+	fileID, revID, err := c.EnsureUploaded(filePath)
+	if err != nil || fileID != "file_1" || revID != "rev_1" {
+		t.Fatalf("EnsureUploaded = %q, %q, %v; want the pinned revision", fileID, revID, err)
+	}
+	if n := uploads.Load(); n != 1 {
+		t.Fatalf("uploads = %d, want 1", n)
+	}
+}
+
+func TestSession_ChangedFileIsUploadedAndRepinned(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	server, _ := sessionTestServer(t)
+	sessionPath := filepath.Join(dir, "session.json")
+	s := NewSession(sessionPath)
+	c := newSessionTestClient(server.URL, s)
+	if _, err := s.Pin(c, filePath); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+
+	if err := os.WriteFile(filePath, []byte("v2 edited"), 0o644); err != nil {
+		t.Fatalf("editing workbook: %v", err)
+	}
+	c.cache = &FileCache{inMemory: make(map[string]CacheEntry)}
+	if _, revID, err := c.EnsureUploaded(filePath); err != nil || revID != "rev_2" {
+		t.Fatalf("EnsureUploaded after edit = %q, %v; want rev_2", revID, err)
+	}
+
+	reopened, err := OpenSession(sessionPath)
+	if err != nil {
+		t.Fatalf("OpenSession: %v", err)
+	}
+	pins := reopened.Pins()
+	if len(pins) != 1 || pins[0].RevisionID != "rev_2" || pins[0].Size != int64(len("v2 edited")) {
+		t.Fatalf("pins after edit = %+v", pins)
+	}
+}
+
+func TestSession_ReuploadIgnoresPin(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("v1"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	server, uploads := sessionTestServer(t)
+	s := NewSession(filepath.Join(dir, "session.json"))
+	c := newSessionTestClient(server.URL, s)
+	if _, err := s.Pin(c, filePath); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+
+	// The pinned revision is gone on the server (a 404 from an operation).
+	if _, revID, err := c.ReuploadFile(filePath); err != nil || revID != "rev_2" {
+		t.Fatalf("ReuploadFile = %q, %v; want a fresh upload", revID, err)
+	}
+	if n := uploads.Load(); n != 2 {
+		t.Fatalf("uploads = %d, want 2", n)
+	}
+	if _, revID, _ := c.EnsureUploaded(filePath); revID != "rev_2" {
+		t.Fatalf("pin after reupload = %q, want rev_2", revID)
+	}
+}
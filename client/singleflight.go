@@ -0,0 +1,48 @@
+package client
+
+import "sync"
+
+// uploadGroup collapses concurrent EnsureUploaded calls for the same cache
+// key into one upload, in the manner of golang.org/x/sync/singleflight.
+// Callers that arrive while an upload is in flight wait for it and share its
+// result.
+type uploadGroup struct {
+	mu    sync.Mutex
+	calls map[string]*uploadCall
+}
+
+type uploadCall struct {
+	done       chan struct{}
+	fileID     string
+	revisionID string
+	err        error
+}
+
+// do runs fn for key unless a call for key is already running, in which case
+// it waits for that call and returns its result. A nil group runs fn directly.
+func (g *uploadGroup) do(key string, fn func() (string, string, error)) (fileID, revisionID string, err error) {
+	if g == nil {
+		return fn()
+	}
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.fileID, call.revisionID, call.err
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*uploadCall)
+	}
+	call := &uploadCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.fileID, call.revisionID, call.err = fn()
+	return call.fileID, call.revisionID, call.err
+}
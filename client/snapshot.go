@@ -0,0 +1,49 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SnapshotRange holds the non-blank cells of one range as Snapshot read them.
+type SnapshotRange struct {
+	Range string         `json:"range"`
+	Cells []SnapshotCell `json:"cells"`
+}
+
+// SnapshotCell is one cell's computed value. Address is sheet-qualified.
+type SnapshotCell struct {
+	Address string `json:"address"`
+	Value   any    `json:"value"`
+	Text    string `json:"text,omitempty"`
+}
+
+// snapshotCode reads each of input.ranges (a sheet-qualified range, or a
+// sheet name for its used range; every sheet when empty) and returns the
+// value of each cell that is not blank.
+const snapshotCode = cellsPrelude + `let specs = input.ranges;
+if (specs.length === 0) {
+  specs = (await xlsx.listSheets(wb)).map((s) => s.sheet);
+}
+const out = [];
+for (const spec of specs) {
+  out.push({ range: spec, cells: await readCells(spec.includes("!") ? spec : { sheet: spec }) });
+}
+return out;`
+
+// Snapshot reads the computed values of ranges via a read-only exec call.
+// An empty ranges reads every sheet's used range.
+func (c *Client) Snapshot(filePath string, ranges []string) ([]SnapshotRange, error) {
+	if ranges == nil {
+		ranges = []string{}
+	}
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: snapshotCode, Input: map[string]any{"ranges": ranges}})
+	if err != nil {
+		return nil, err
+	}
+	snapshot := []SnapshotRange{}
+	if err := json.Unmarshal(result, &snapshot); err != nil {
+		return nil, fmt.Errorf("parsing snapshot: %w", err)
+	}
+	return snapshot, nil
+}
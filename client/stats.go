@@ -0,0 +1,157 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SheetStats summarizes the formulas on one sheet.
+type SheetStats struct {
+	Sheet            string         `json:"sheet"`
+	UsedRange        string         `json:"used_range"`
+	Rows             int            `json:"rows"`
+	Cols             int            `json:"cols"`
+	Hidden           bool           `json:"hidden,omitempty"`
+	Formulas         int            `json:"formulas"`
+	VolatileFormulas int            `json:"volatile_formulas"`
+	ArrayFormulas    int            `json:"array_formulas"`
+	ExternalRefs     int            `json:"external_refs"`
+	ReferencedCells  int64          `json:"referenced_cells"`
+	Volatile         map[string]int `json:"volatile,omitempty"` // function name -> formulas using it
+	ExternalLinks    []string       `json:"external_links,omitempty"`
+}
+
+// WorkbookStats is a workbook complexity report. Totals are summed over
+// Sheets; CalcCost estimates recalculation work as one unit per formula plus
+// one per cell in each range a formula references.
+type WorkbookStats struct {
+	Sheets           []SheetStats   `json:"sheets"`
+	Formulas         int            `json:"formulas"`
+	VolatileFormulas int            `json:"volatile_formulas"`
+	ArrayFormulas    int            `json:"array_formulas"`
+	Volatile         map[string]int `json:"volatile"`
+	ExternalLinks    []string       `json:"external_links"`
+	LargestSheet     string         `json:"largest_sheet,omitempty"`
+	LargestRange     string         `json:"largest_range,omitempty"`
+	LargestCells     int64          `json:"largest_cells"`
+	CalcCost         int64          `json:"calc_cost"`
+	CalcCostLevel    string         `json:"calc_cost_level"` // low, medium, or high
+}
+
+// Thresholds for WorkbookStats.CalcCostLevel.
+const (
+	calcCostMedium int64 = 100_000
+	calcCostHigh   int64 = 10_000_000
+)
+
+// volatileFunctions recalculate on every calc regardless of their inputs.
+var volatileFunctions = []string{"NOW", "TODAY", "RAND", "RANDBETWEEN", "RANDARRAY", "OFFSET", "INDIRECT", "CELL", "INFO"}
+
+// dynamicArrayFunctions return spilled arrays; formulas using them are
+// counted as array formulas alongside legacy {=...} ones.
+var dynamicArrayFunctions = []string{"FILTER", "SORT", "SORTBY", "UNIQUE", "SEQUENCE", "RANDARRAY"}
+
+// statsCode reads every used range in chunks and tallies its formulas.
+const statsCode = `const colNum = (s) => [...s].reduce((n, ch) => n * 26 + ch.charCodeAt(0) - 64, 0);
+const colName = (n) => { let s = ""; for (; n > 0; n = Math.floor((n - 1) / 26)) s = String.fromCharCode(65 + ((n - 1) % 26)) + s; return s; };
+const quote = (name) => "'" + name.replace(/'/g, "''") + "'";
+const fnRe = (names, flags) => new RegExp("\\b(" + names.join("|") + ")\\s*\\(", flags);
+const volatileRe = fnRe(input.volatile, "gi");
+const dynamicRe = fnRe(input.dynamicArray, "i");
+const externalRes = [/'[^']*\[([^\]]+)\][^']*'!/g, /\[([^\][]+)\][A-Za-z0-9_.]*!/g];
+const areaRe = /\$?([A-Z]{1,3})\$?(\d+):\$?([A-Z]{1,3})\$?(\d+)/g;
+const columnsRe = /(^|[^A-Za-z0-9_$])\$?([A-Z]{1,3}):\$?([A-Z]{1,3})(?![A-Za-z0-9_(])/g;
+const out = [];
+for (const s of await xlsx.listSheets(wb)) {
+  const st = { sheet: s.sheet, used_range: s.address, rows: s.rows, cols: s.cols, hidden: !!s.hidden,
+    formulas: 0, volatile_formulas: 0, array_formulas: 0, external_refs: 0, referenced_cells: 0, volatile: {}, external_links: [] };
+  out.push(st);
+  const m = /^\$?([A-Z]+)\$?(\d+)(?::\$?([A-Z]+)\$?(\d+))?$/.exec(s.address || "");
+  if (!m || !s.rows || !s.cols) continue;
+  const c1 = colName(colNum(m[1])), c2 = colName(colNum(m[3] || m[1]));
+  const r1 = Number(m[2]), r2 = Number(m[4] || m[2]);
+  const step = Math.max(1, Math.floor(input.chunkCells / s.cols));
+  const links = new Set();
+  for (let r = r1; r <= r2; r += step) {
+    const rows = await xlsx.readRange(wb, quote(s.sheet) + "!" + c1 + r + ":" + c2 + Math.min(r2, r + step - 1));
+    for (const row of rows) for (const cell of row) {
+      const f = cell && cell.formula;
+      if (!f) continue;
+      st.formulas++;
+      const vol = new Set([...f.matchAll(volatileRe)].map((x) => x[1].toUpperCase()));
+      if (vol.size) st.volatile_formulas++;
+      for (const name of vol) st.volatile[name] = (st.volatile[name] || 0) + 1;
+      if (f.startsWith("{") || dynamicRe.test(f)) st.array_formulas++;
+      let ext = false;
+      for (const re of externalRes) for (const x of f.matchAll(re)) { ext = true; links.add(x[1]); }
+      if (ext) st.external_refs++;
+      let cells = 0;
+      for (const x of f.matchAll(areaRe)) cells += (Math.abs(colNum(x[3]) - colNum(x[1])) + 1) * (Math.abs(Number(x[4]) - Number(x[2])) + 1);
+      for (const x of f.matchAll(columnsRe)) cells += (Math.abs(colNum(x[3]) - colNum(x[2])) + 1) * 1048576;
+      st.referenced_cells += cells;
+    }
+  }
+  st.external_links = [...links].sort();
+}
+return out;`
+
+// Stats reports formula counts, volatile and array formulas, external
+// links, and an estimated calc cost for a workbook via a read-only exec call.
+func (c *Client) Stats(filePath string) (*WorkbookStats, error) {
+	input := map[string]any{
+		"volatile":     volatileFunctions,
+		"dynamicArray": dynamicArrayFunctions,
+		"chunkCells":   50_000,
+	}
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: statsCode, Input: input})
+	if err != nil {
+		return nil, err
+	}
+	var sheets []SheetStats
+	if err := json.Unmarshal(result, &sheets); err != nil {
+		return nil, fmt.Errorf("parsing workbook stats: %w", err)
+	}
+	return summarizeStats(sheets), nil
+}
+
+// summarizeStats totals per-sheet stats into a WorkbookStats.
+func summarizeStats(sheets []SheetStats) *WorkbookStats {
+	stats := &WorkbookStats{
+		Sheets:        sheets,
+		Volatile:      map[string]int{},
+		ExternalLinks: []string{},
+	}
+	if stats.Sheets == nil {
+		stats.Sheets = []SheetStats{}
+	}
+	links := map[string]bool{}
+	for _, s := range sheets {
+		stats.Formulas += s.Formulas
+		stats.VolatileFormulas += s.VolatileFormulas
+		stats.ArrayFormulas += s.ArrayFormulas
+		for name, n := range s.Volatile {
+			stats.Volatile[name] += n
+		}
+		for _, link := range s.ExternalLinks {
+			if !links[link] {
+				links[link] = true
+				stats.ExternalLinks = append(stats.ExternalLinks, link)
+			}
+		}
+		if cells := int64(s.Rows) * int64(s.Cols); cells > stats.LargestCells {
+			stats.LargestSheet, stats.LargestRange, stats.LargestCells = s.Sheet, s.UsedRange, cells
+		}
+		stats.CalcCost += int64(s.Formulas) + s.ReferencedCells
+	}
+	sort.Strings(stats.ExternalLinks)
+	switch {
+	case stats.CalcCost >= calcCostHigh:
+		stats.CalcCostLevel = "high"
+	case stats.CalcCost >= calcCostMedium:
+		stats.CalcCostLevel = "medium"
+	default:
+		stats.CalcCostLevel = "low"
+	}
+	return stats
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeStats_TotalsSheets(t *testing.T) {
+	stats := summarizeStats([]SheetStats{
+		{Sheet: "Data", UsedRange: "A1:Z5000", Rows: 5000, Cols: 26, Formulas: 1000, VolatileFormulas: 10, ArrayFormulas: 1,
+			ReferencedCells: 200_000, Volatile: map[string]int{"OFFSET": 10}, ExternalLinks: []string{"Rates.xlsx"}},
+		{Sheet: "Summary", UsedRange: "A1:F40", Rows: 40, Cols: 6, Formulas: 50, VolatileFormulas: 2,
+			ReferencedCells: 500, Volatile: map[string]int{"NOW": 1, "OFFSET": 1}, ExternalLinks: []string{"1", "Rates.xlsx"}},
+	})
+
+	if stats.Formulas != 1050 || stats.VolatileFormulas != 12 || stats.ArrayFormulas != 1 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+	if !reflect.DeepEqual(stats.Volatile, map[string]int{"OFFSET": 11, "NOW": 1}) {
+		t.Fatalf("volatile = %v", stats.Volatile)
+	}
+	if !reflect.DeepEqual(stats.ExternalLinks, []string{"1", "Rates.xlsx"}) {
+		t.Fatalf("external links = %v", stats.ExternalLinks)
+	}
+	if stats.LargestSheet != "Data" || stats.LargestCells != 130_000 {
+		t.Fatalf("largest = %s (%d cells)", stats.LargestSheet, stats.LargestCells)
+	}
+	if stats.CalcCost != 201_550 || stats.CalcCostLevel != "medium" {
+		t.Fatalf("calc cost = %d (%s)", stats.CalcCost, stats.CalcCostLevel)
+	}
+}
+
+func TestSummarizeStats_EmptyWorkbook(t *testing.T) {
+	stats := summarizeStats(nil)
+	if stats.Sheets == nil || stats.ExternalLinks == nil || stats.CalcCostLevel != "low" {
+		t.Fatalf("unexpected stats for empty workbook: %+v", stats)
+	}
+}
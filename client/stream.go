@@ -0,0 +1,170 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// execStreamEvent is the data of a "stdout" event in a streamed exec
+// response.
+type execStreamEvent struct {
+	Text string `json:"text"`
+}
+
+// execStreamError is the data of an "error" event: the usual error body
+// plus the HTTP status the failure maps to.
+type execStreamError struct {
+	Status int `json:"status"`
+}
+
+// doExec sends an exec request. With req.OnStdout set it asks for a
+// text/event-stream response and passes console output to OnStdout as it
+// arrives; a server that answers with plain JSON is handled as usual, and
+// nothing is streamed.
+func (c *Client) doExec(req ExecRequest, makeRequest func() (*http.Request, error)) (*rawResponse, error) {
+	if req.OnStdout == nil {
+		return c.doWithRetry(makeRequest)
+	}
+	return c.doStream(func() (*http.Request, error) {
+		httpReq, err := makeRequest()
+		if err != nil {
+			return nil, err
+		}
+		q := httpReq.URL.Query()
+		q.Set("stream", "true")
+		httpReq.URL.RawQuery = q.Encode()
+		httpReq.Header.Set("Accept", "text/event-stream, application/json")
+		return httpReq, nil
+	}, req.OnStdout)
+}
+
+// doStream sends one request (streams are not retried: output may already
+// have been shown) and reads a server-sent event stream of "stdout",
+// "result", and "error" events. It returns the "result" event's data as a
+// 200 response body, or an "error" event as that status's body, so callers
+// parse it like a plain response. Non-stream responses are returned as-is.
+//
+// A 401 refreshes the token and resends the request once, as in doOnce.
+func (c *Client) doStream(makeRequest func() (*http.Request, error), onStdout func(string)) (*rawResponse, error) {
+	return c.withTokenRefresh(makeRequest, func(makeRequest func() (*http.Request, error)) (*rawResponse, error) {
+		return c.doStreamAttempt(makeRequest, onStdout)
+	})
+}
+
+// doStreamAttempt sends one streamed request attempt for doStream.
+func (c *Client) doStreamAttempt(makeRequest func() (*http.Request, error), onStdout func(string)) (raw *rawResponse, err error) {
+	var tr apiTrace
+	defer func() { tr.end(raw, err) }()
+
+	req, err := makeRequest()
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if _, err := c.compressRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	timeout := c.requestTimeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(tr.begin(c.Context(), req, 1), timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	release, err := c.acquire(time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("API request canceled: %w", err)
+	}
+	defer release()
+
+	start := c.clock()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		c.logAttempt(req, 1, 0, start, err)
+		tr.recordAttempt(0, err)
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	c.noteResponse(req, resp)
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, readErr := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			c.pauseForRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		c.logAttempt(req, 1, resp.StatusCode, start, readErr)
+		tr.recordAttempt(resp.StatusCode, readErr)
+		if readErr != nil {
+			return nil, fmt.Errorf("reading response: %w", readErr)
+		}
+		return &rawResponse{
+			StatusCode:  resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			RetryAfter:  resp.Header.Get("Retry-After"),
+			Body:        body,
+		}, nil
+	}
+
+	raw, readErr := readEventStream(resp.Body, onStdout)
+	c.logAttempt(req, 1, resp.StatusCode, start, readErr)
+	tr.recordAttempt(resp.StatusCode, readErr)
+	if readErr != nil {
+		return nil, fmt.Errorf("reading response stream: %w", readErr)
+	}
+	return raw, nil
+}
+
+// readEventStream dispatches events from r until a "result" or "error"
+// event ends the stream.
+func readEventStream(r io.Reader, onStdout func(string)) (*rawResponse, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		payload := []byte(strings.Join(data, "\n"))
+		name := event
+		event, data = "", nil
+		switch name {
+		case "stdout":
+			var ev execStreamEvent
+			if err := json.Unmarshal(payload, &ev); err != nil {
+				return nil, fmt.Errorf("parsing stdout event: %w", err)
+			}
+			onStdout(ev.Text)
+		case "result":
+			return &rawResponse{StatusCode: http.StatusOK, ContentType: "application/json", Body: payload}, nil
+		case "error":
+			var ev execStreamError
+			_ = json.Unmarshal(payload, &ev)
+			if ev.Status == 0 {
+				ev.Status = http.StatusInternalServerError
+			}
+			return &rawResponse{StatusCode: ev.Status, ContentType: "application/json", Body: payload}, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.ErrUnexpectedEOF
+}
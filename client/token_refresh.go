@@ -0,0 +1,80 @@
+package client
+
+import "sync"
+
+// tokenRefresher replaces the client's bearer token after the API rejects
+// it; shared by WithContext copies so concurrent requests refresh only once.
+type tokenRefresher struct {
+	mu      sync.Mutex
+	token   string // replacement for Client.APIKey; empty until refreshed
+	refresh func() (string, error)
+	// stale is true while the current token has been accepted by the API
+	// (or never refreshed), so a 401 means it expired and is worth
+	// refreshing. A refreshed token that is rejected outright is not
+	// refreshed again.
+	stale bool
+}
+
+// WithTokenRefresh lets the client recover from a rejected bearer token,
+// such as a session JWT that expired or was revoked mid-command: on a 401
+// response it calls fn for a new token and resends the request once with it.
+// If the new token is rejected too, the 401 is returned as usual and fn is
+// not called again until a token it returned has been accepted.
+func WithTokenRefresh(fn func() (string, error)) Option {
+	return func(c *Client) { c.tokens = &tokenRefresher{refresh: fn, stale: true} }
+}
+
+// BearerToken returns the token requests are sent with: APIKey, or its
+// replacement after a refresh.
+func (c *Client) BearerToken() string {
+	if c.tokens != nil {
+		c.tokens.mu.Lock()
+		defer c.tokens.mu.Unlock()
+		if c.tokens.token != "" {
+			return c.tokens.token
+		}
+	}
+	return c.APIKey
+}
+
+// noteTokenAccepted records that a request sent with token got past
+// authentication, so a later 401 for it means it expired.
+func (c *Client) noteTokenAccepted(sent string) {
+	t := c.tokens
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if sent != "" && sent == t.token {
+		t.stale = true
+	}
+}
+
+// refreshBearerToken handles a 401 for a request sent with token sent and
+// reports whether to resend it with a new token.
+func (c *Client) refreshBearerToken(sent string) bool {
+	t := c.tokens
+	if t == nil || sent == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current := t.token
+	if current == "" {
+		current = c.APIKey
+	}
+	if sent != current {
+		return true // another request already refreshed it
+	}
+	if !t.stale {
+		return false
+	}
+	token, err := t.refresh()
+	if err != nil || token == "" {
+		return false
+	}
+	t.token = token
+	t.stale = false
+	return true
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDoWithRetry_RefreshesRejectedToken(t *testing.T) {
+	var sent []string
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get("Authorization"))
+		status := http.StatusOK
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			status = http.StatusUnauthorized
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))
+	refreshes := 0
+	WithTokenRefresh(func() (string, error) {
+		refreshes++
+		return "fresh", nil
+	})(c)
+
+	get := func() int {
+		t.Helper()
+		raw, err := c.doWithRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", c.BaseURL+"/v0/ping", nil)
+			if err == nil {
+				c.setCommonHeaders(req)
+			}
+			return req, err
+		})
+		if err != nil {
+			t.Fatalf("doWithRetry: %v", err)
+		}
+		return raw.StatusCode
+	}
+
+	if status := get(); status != http.StatusOK {
+		t.Fatalf("status = %d, want 200 after refresh", status)
+	}
+	if len(sent) != 2 || sent[0] != "Bearer test-key" || sent[1] != "Bearer fresh" {
+		t.Fatalf("sent = %q, want the old token then the refreshed one", sent)
+	}
+	if get(); refreshes != 1 || sent[2] != "Bearer fresh" {
+		t.Fatalf("refreshes=%d sent=%q, want later requests to reuse the refreshed token", refreshes, sent)
+	}
+}
+
+func TestDoWithRetry_RefreshesOnlyOnce(t *testing.T) {
+	calls := 0
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))
+	WithTokenRefresh(func() (string, error) { return "still-bad", nil })(c)
+
+	for i := 0; i < 2; i++ {
+		raw, err := c.doWithRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", c.BaseURL+"/v0/ping", nil)
+			if err == nil {
+				c.setCommonHeaders(req)
+			}
+			return req, err
+		})
+		if err != nil || raw.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("raw=%v err=%v, want the 401 returned", raw, err)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want one resend after the only refresh", calls)
+	}
+}
+
+func TestDoWithRetry_RefreshesAgainAfterAcceptedTokenExpires(t *testing.T) {
+	valid := "test-key"
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		status := http.StatusOK
+		if req.Header.Get("Authorization") != "Bearer "+valid {
+			status = http.StatusUnauthorized
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))
+	refreshes := 0
+	WithTokenRefresh(func() (string, error) {
+		refreshes++
+		return valid, nil
+	})(c)
+
+	get := func() int {
+		t.Helper()
+		raw, err := c.doWithRetry(func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", c.BaseURL+"/v0/ping", nil)
+			if err == nil {
+				c.setCommonHeaders(req)
+			}
+			return req, err
+		})
+		if err != nil {
+			t.Fatalf("doWithRetry: %v", err)
+		}
+		return raw.StatusCode
+	}
+
+	// Each token works for a while and then expires, as in a long batch job.
+	for i, token := range []string{"jwt-1", "jwt-2", "jwt-3"} {
+		valid = token
+		if status := get(); status != http.StatusOK || refreshes != i+1 || c.BearerToken() != token {
+			t.Fatalf("expiry %d: status=%d refreshes=%d token=%q", i+1, status, refreshes, c.BearerToken())
+		}
+		if status := get(); status != http.StatusOK || refreshes != i+1 {
+			t.Fatalf("expiry %d: status=%d refreshes=%d, want the refreshed token reused", i+1, status, refreshes)
+		}
+	}
+}
+
+func TestSingleAttemptPaths_RefreshRejectedToken(t *testing.T) {
+	var sent []string
+	c := newTestClient(t, roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get("Authorization"))
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			return &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+		header := http.Header{"Content-Type": {"application/json"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(`{"id":"job_1","status":"queued"}`)), Request: req}, nil
+	}))
+	WithTokenRefresh(func() (string, error) { return "fresh", nil })(c)
+
+	// jobs submit and --async are not retried, but a 401 is resent.
+	job, err := c.SubmitJob(JobRequest{})
+	if err != nil || job.ID != "job_1" {
+		t.Fatalf("SubmitJob = %+v, %v; want the job after a token refresh", job, err)
+	}
+	if len(sent) != 2 || sent[1] != "Bearer fresh" {
+		t.Fatalf("sent = %q, want the old token then the refreshed one", sent)
+	}
+
+	// exec --stream resends too; the refreshed token is reused from now on.
+	c2 := newTestClient(t, c.HTTPClient.Transport)
+	WithTokenRefresh(func() (string, error) { return "fresh", nil })(c2)
+	sent = nil
+	raw, err := c2.doStream(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c2.BaseURL+"/v0/exec", strings.NewReader("{}"))
+		if err == nil {
+			c2.setCommonHeaders(req)
+		}
+		return req, err
+	}, func(string) {})
+	if err != nil || raw.StatusCode != http.StatusOK {
+		t.Fatalf("doStream = %v, %v; want 200 after a token refresh", raw, err)
+	}
+	if len(sent) != 2 || sent[1] != "Bearer fresh" {
+		t.Fatalf("sent = %q, want the old token then the refreshed one", sent)
+	}
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/witanlabs/witan-cli/client"
+
+// apiTrace wraps one API call (all of its retry attempts) in a span. Spans go
+// to the global tracer provider, which is a no-op unless the caller installs
+// one (the CLI does so from OTEL_* env vars).
+type apiTrace struct {
+	span    trace.Span
+	ctx     context.Context
+	attempt int
+}
+
+// begin starts the span (as a child of parent) on the first attempt and
+// returns the context each attempt should derive from. Trace context is
+// injected into req's headers.
+func (t *apiTrace) begin(parent context.Context, req *http.Request, attempt int) context.Context {
+	t.attempt = attempt
+	if t.span == nil {
+		op := operationName(req.Method, req.URL.Path)
+		t.ctx, t.span = otel.Tracer(tracerName).Start(parent, op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("witan.operation", op),
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.full", req.URL.String()),
+			),
+		)
+		if size := requestBodySize(req); size > 0 {
+			t.span.SetAttributes(attribute.Int64("witan.file.size", size))
+		}
+	}
+	otel.GetTextMapPropagator().Inject(t.ctx, propagation.HeaderCarrier(req.Header))
+	return t.ctx
+}
+
+// recordAttempt adds a span event for one HTTP attempt.
+func (t *apiTrace) recordAttempt(status int, err error) {
+	if t.span == nil {
+		return
+	}
+	attrs := []attribute.KeyValue{attribute.Int("witan.attempt", t.attempt)}
+	if status != 0 {
+		attrs = append(attrs, attribute.Int("http.response.status_code", status))
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("error.message", err.Error()))
+	}
+	t.span.AddEvent("attempt", trace.WithAttributes(attrs...))
+}
+
+// end records the final outcome and ends the span.
+func (t *apiTrace) end(raw *rawResponse, err error) {
+	if t.span == nil {
+		return
+	}
+	t.span.SetAttributes(attribute.Int("witan.attempts", t.attempt))
+	if raw != nil {
+		t.span.SetAttributes(attribute.Int("http.response.status_code", raw.StatusCode))
+		if raw.StatusCode >= 400 {
+			t.span.SetStatus(codes.Error, http.StatusText(raw.StatusCode))
+		}
+	}
+	if err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	}
+	t.span.End()
+}
+
+// operationName returns a low-cardinality span name such as
+// "POST /v0/files/{id}/xlsx/calc": the org segment is dropped and file,
+// revision, and spreadsheet IDs are replaced with "{id}".
+func operationName(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	out := make([]string, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		seg := segments[i]
+		if seg == "orgs" && i+1 < len(segments) {
+			i++
+			continue
+		}
+		out = append(out, seg)
+		if (seg == "files" || seg == "revisions" || seg == "gsheets") && i+1 < len(segments) {
+			i++
+			out = append(out, "{id}")
+		}
+	}
+	return method + " /" + strings.Join(out, "/")
+}
+
+// requestBodySize returns the upload size in bytes, or 0 when unknown.
+func requestBodySize(req *http.Request) int64 {
+	if req.ContentLength > 0 {
+		return req.ContentLength
+	}
+	if f, ok := req.Body.(*os.File); ok {
+		if st, err := f.Stat(); err == nil {
+			return st.Size()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOperationName(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"POST", "/v0/xlsx/calc", "POST /v0/xlsx/calc"},
+		{"POST", "/v0/orgs/org_1/xlsx/calc", "POST /v0/xlsx/calc"},
+		{"POST", "/v0/orgs/org_1/files/f_1/xlsx/calc", "POST /v0/files/{id}/xlsx/calc"},
+		{"GET", "/v0/orgs/org_1/files/f_1/revisions/r_1/content", "GET /v0/files/{id}/revisions/{id}/content"},
+		{"POST", "/v0/orgs/org_1/gsheets/sheet_1/exec", "POST /v0/gsheets/{id}/exec"},
+		{"POST", "/v0/orgs/org_1/gsheets", "POST /v0/gsheets"},
+	}
+	for _, tt := range tests {
+		if got := operationName(tt.method, tt.path); got != tt.want {
+			t.Errorf("operationName(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDoWithRetry_RecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	origProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(origProvider) })
+
+	tr := &sequenceTransport{
+		t: t,
+		results: []transportResult{
+			{status: http.StatusServiceUnavailable, body: "busy"},
+			{status: http.StatusOK, body: "ok"},
+		},
+	}
+	c := newTestClient(t, tr)
+	c.OrgID = "org_1"
+
+	_, err := c.doWithRetry(func() (*http.Request, error) {
+		return http.NewRequest("POST", c.BaseURL+c.buildPath("v0", "/xlsx/calc"), nil)
+	})
+	if err != nil {
+		t.Fatalf("doWithRetry failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "POST /v0/xlsx/calc" {
+		t.Fatalf("unexpected span name: %q", span.Name())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["witan.attempts"].AsInt64(); got != 2 {
+		t.Fatalf("expected witan.attempts=2, got %d", got)
+	}
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", got)
+	}
+	if got := len(span.Events()); got != 2 {
+		t.Fatalf("expected 2 attempt events, got %d", got)
+	}
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TransportOptions configures the HTTP transport used for Witan API calls.
+type TransportOptions struct {
+	// CACertPath is a PEM bundle trusted in addition to the system roots,
+	// e.g. for a self-hosted API gateway with a private CA.
+	CACertPath string
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool
+}
+
+// NewTransport returns an HTTP transport based on http.DefaultTransport that
+// honors HTTP_PROXY / HTTPS_PROXY / NO_PROXY and the given TLS options.
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+
+	if opts.CACertPath == "" && !opts.InsecureSkipVerify {
+		return t, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.TLSClientConfig != nil {
+		tlsConfig = t.TLSClientConfig.Clone()
+	}
+	if opts.CACertPath != "" {
+		pem, err := os.ReadFile(opts.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("reading CA bundle: no PEM certificates found in %s", opts.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
+// OfflineTransport refuses every request with an error matching ErrOffline.
+type OfflineTransport struct{}
+
+func (OfflineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, fmt.Errorf("%w: %s %s needs the network and has no cached response", ErrOffline, req.Method, req.URL.Path)
+}
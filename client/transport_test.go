@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewTransport_HonorsProxyEnv(t *testing.T) {
+	tr, err := NewTransport(TransportOptions{CACertPath: "", InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// http.ProxyFromEnvironment reads HTTP_PROXY/HTTPS_PROXY/NO_PROXY once per
+	// process, so assert the wiring rather than a specific proxy URL.
+	if tr.Proxy == nil {
+		t.Fatal("expected transport to honor proxy environment variables")
+	}
+}
+
+func TestNewTransport_TrustsCACertBundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Without the bundle the self-signed test certificate is rejected.
+	plain, err := NewTransport(TransportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := (&http.Client{Transport: plain}).Get(server.URL); err == nil {
+		t.Fatal("expected certificate verification error without CA bundle")
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caPath, certPEM, 0o600); err != nil {
+		t.Fatalf("writing CA bundle: %v", err)
+	}
+	tr, err := NewTransport(TransportOptions{CACertPath: caPath})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected request to succeed with CA bundle: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTransport_InsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tr, err := NewTransport(TransportOptions{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected request to succeed with verification disabled: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTransport_RejectsInvalidCABundle(t *testing.T) {
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("writing CA bundle: %v", err)
+	}
+	_, err := NewTransport(TransportOptions{CACertPath: caPath})
+	if err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Fatalf("expected invalid bundle error, got %v", err)
+	}
+
+	_, err = NewTransport(TransportOptions{CACertPath: filepath.Join(t.TempDir(), "missing.pem")})
+	if err == nil || !strings.Contains(err.Error(), "reading CA bundle") {
+		t.Fatalf("expected missing bundle error, got %v", err)
+	}
+}
@@ -82,6 +82,22 @@ type ExecRequest struct {
 	Locale         string `json:"locale,omitempty"`
 	TimeoutMS      int    `json:"timeout_ms,omitempty"`
 	MaxOutputChars int    `json:"max_output_chars,omitempty"`
+	// Require names server-side helper libraries to load before the script
+	// runs; see ExecLibraries.
+	Require []string `json:"require,omitempty"`
+	// OnStdout, when set, receives console output as the script runs if the
+	// server can stream it; the final ExecResponse still carries Stdout.
+	// Streamed requests are not retried.
+	OnStdout func(text string) `json:"-"`
+	// DataFiles are sent as extra multipart parts; the script reads each
+	// one as files[Name].
+	DataFiles []ExecDataFile `json:"-"`
+}
+
+// ExecDataFile is an auxiliary file attached to an exec request.
+type ExecDataFile struct {
+	Name string // key the script sees, usually the base name
+	Path string // local path to read
 }
 
 // ExecAccess describes a workbook access observed during execution.
@@ -122,6 +138,26 @@ type ReadMetadata struct {
 	TotalLines  int  `json:"total_lines"`
 	Offset      int  `json:"offset"`
 	Limit       int  `json:"limit"`
+	OCR         bool `json:"ocr,omitempty"` // text was recognized from page images
+}
+
+// ReadTable is a table detected in the source document (read with tables=true).
+type ReadTable struct {
+	Sheet string     `json:"sheet,omitempty"`
+	Page  *int       `json:"page,omitempty"`
+	Slide *int       `json:"slide,omitempty"`
+	Line  *int       `json:"line,omitempty"`
+	Rows  [][]string `json:"rows"`
+}
+
+// ReadImage is an image embedded in the source document (read with images=true).
+type ReadImage struct {
+	Name        string `json:"name,omitempty"`
+	Page        *int   `json:"page,omitempty"`
+	Slide       *int   `json:"slide,omitempty"`
+	ContentType string `json:"content_type"`
+	Data        string `json:"data,omitempty"` // base64
+	Path        string `json:"path,omitempty"` // local path, set by the CLI after extraction
 }
 
 // ReadResponse is the response from the read endpoint (content mode).
@@ -129,6 +165,8 @@ type ReadResponse struct {
 	Content  string       `json:"content"`
 	Format   string       `json:"format"`
 	Metadata ReadMetadata `json:"metadata"`
+	Tables   []ReadTable  `json:"tables,omitempty"`
+	Images   []ReadImage  `json:"images,omitempty"`
 }
 
 // OutlineEntry is a single entry in a document outline.
@@ -0,0 +1,248 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// chunkedUploadThreshold is the file size from which uploads use an
+	// upload session sent in chunks, so a dropped connection only re-sends
+	// the current chunk instead of the whole workbook.
+	chunkedUploadThreshold = 8 << 20
+	defaultUploadChunkSize = 4 << 20
+	// maxUploadResumes bounds how often a session is resumed from the
+	// server's checkpoint after a chunk fails its retries.
+	maxUploadResumes = 5
+)
+
+// UploadProgressFunc receives upload progress: bytes sent so far out of
+// total for the named file. It is called from the uploading goroutine.
+type UploadProgressFunc func(filename string, sent, total int64)
+
+// errChunkedUploadUnsupported means the file is below the chunking
+// threshold or the server has no upload-session endpoints; callers fall
+// back to a single multipart upload.
+var errChunkedUploadUnsupported = errors.New("chunked upload not supported")
+
+// uploadSession is the response from POST /v0/uploads, PUT
+// /v0/uploads/:id, and GET /v0/uploads/:id. Offset is the number of bytes
+// the server has stored, i.e. the resume checkpoint.
+type uploadSession struct {
+	ID        string `json:"id"`
+	Offset    int64  `json:"offset"`
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+type uploadSessionRequest struct {
+	Filename    string `json:"filename"`
+	Bytes       int64  `json:"bytes"`
+	ContentType string `json:"content_type"`
+	FileID      string `json:"file_id,omitempty"`
+}
+
+// uploadChunked uploads filePath through an upload session: the file is
+// sent in Content-Range chunks, and after a chunk exhausts its retries the
+// upload resumes from the server's recorded offset. A non-empty fileID
+// uploads a new revision of that file. Returns errChunkedUploadUnsupported
+// for small files or when the server lacks the endpoints.
+func (c *Client) uploadChunked(filePath, fileID string) (*FileResponse, error) {
+	threshold := c.chunkThreshold
+	if threshold <= 0 {
+		threshold = chunkedUploadThreshold
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file: %w", err)
+	}
+	size := info.Size()
+	if size < threshold {
+		return nil, errChunkedUploadUnsupported
+	}
+
+	filename := filepath.Base(filePath)
+	sess, err := c.createUploadSession(uploadSessionRequest{
+		Filename:    filename,
+		Bytes:       size,
+		ContentType: detectContentType(filePath),
+		FileID:      fileID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := sess.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultUploadChunkSize
+	}
+
+	offset := sess.Offset
+	c.reportUploadProgress(filename, offset, size)
+	for resumes := 0; offset < size; {
+		end := min(offset+chunkSize, size)
+		next, err := c.putUploadChunk(sess.ID, f, offset, end, size)
+		if err != nil {
+			if resumes >= maxUploadResumes || !isResumableUploadError(err) {
+				return nil, err
+			}
+			resumes++
+			checkpoint, getErr := c.getUploadSession(sess.ID)
+			if getErr != nil {
+				return nil, err
+			}
+			offset = checkpoint.Offset
+			continue
+		}
+		offset = next
+		c.reportUploadProgress(filename, offset, size)
+	}
+
+	return c.completeUploadSession(sess.ID)
+}
+
+func (c *Client) createUploadSession(body uploadSessionRequest) (*uploadSession, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encoding upload request: %w", err)
+	}
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.BaseURL+c.buildPath("v0", "/uploads"), bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode == http.StatusNotFound || raw.StatusCode == http.StatusMethodNotAllowed {
+		// Servers without upload sessions: use the multipart endpoints.
+		return nil, errChunkedUploadUnsupported
+	}
+	if raw.StatusCode != 200 && raw.StatusCode != 201 {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	return decodeUploadSession(raw.Body)
+}
+
+// putUploadChunk sends bytes [start, end) and returns the server's new
+// offset.
+func (c *Client) putUploadChunk(id string, f io.ReaderAt, start, end, total int64) (int64, error) {
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("PUT", c.BaseURL+c.buildPath("v0", "/uploads/"+id), io.NewSectionReader(f, start, end-start))
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.ContentLength = end - start
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, total))
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if raw.StatusCode != 200 {
+		return 0, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	sess, err := decodeUploadSession(raw.Body)
+	if err != nil {
+		return 0, err
+	}
+	return sess.Offset, nil
+}
+
+func (c *Client) getUploadSession(id string) (*uploadSession, error) {
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", c.BaseURL+c.buildPath("v0", "/uploads/"+id), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode != 200 {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	return decodeUploadSession(raw.Body)
+}
+
+func (c *Client) completeUploadSession(id string) (*FileResponse, error) {
+	raw, err := c.doWithRetry(func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", c.BaseURL+c.buildPath("v0", "/uploads/"+id+"/complete"), nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setCommonHeaders(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if raw.StatusCode != 200 {
+		return nil, parseAPIError(raw.StatusCode, raw.Body, raw.RetryAfter)
+	}
+	var result FileResponse
+	if err := json.Unmarshal(raw.Body, &result); err != nil {
+		return nil, fmt.Errorf("parsing upload response: %w", err)
+	}
+	return &result, nil
+}
+
+func decodeUploadSession(body []byte) (*uploadSession, error) {
+	var sess uploadSession
+	if err := json.Unmarshal(body, &sess); err != nil {
+		return nil, fmt.Errorf("parsing upload session: %w", err)
+	}
+	return &sess, nil
+}
+
+// isResumableUploadError reports whether a failed chunk is worth resuming
+// from the server checkpoint: transport failures, retryable statuses, and
+// offset conflicts (the server already holds a different amount).
+func isResumableUploadError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.StatusCode == http.StatusConflict || shouldRetryStatus(apiErr.StatusCode)
+}
+
+func (c *Client) reportUploadProgress(filename string, sent, total int64) {
+	if c.uploadProgress != nil {
+		c.uploadProgress(filename, sent, total)
+	}
+}
+
+// progressReader reports bytes read from r as upload progress.
+type progressReader struct {
+	r        io.Reader
+	filename string
+	total    int64
+	sent     int64
+	report   UploadProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.report(p.filename, p.sent, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadFile_ChunkedResumesFromServerOffset(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "big.xlsx")
+	content := bytes.Repeat([]byte("0123456789"), 3)
+	if err := os.WriteFile(filePath, content, 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	var stored []byte
+	var ranges []string
+	failedOnce := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/uploads":
+			fmt.Fprint(w, `{"id":"up_1","offset":0,"chunk_size":10}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/v0/uploads/up_1":
+			body, _ := io.ReadAll(r.Body)
+			ranges = append(ranges, r.Header.Get("Content-Range"))
+			stored = append(stored, body...)
+			if len(stored) == 20 && !failedOnce {
+				// The chunk lands but the response is lost.
+				failedOnce = true
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprintf(w, `{"id":"up_1","offset":%d}`, len(stored))
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/uploads/up_1":
+			fmt.Fprintf(w, `{"id":"up_1","offset":%d}`, len(stored))
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/uploads/up_1/complete":
+			fmt.Fprintf(w, `{"id":"file_big","object":"file","filename":"big.xlsx","bytes":%d,"revision_id":"rev_1","status":"ready"}`, len(stored))
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	var progress []int64
+	c := New(server.URL, "", "", false, WithUploadProgress(func(filename string, sent, total int64) {
+		progress = append(progress, sent)
+	}))
+	c.maxAttempts = 1
+	c.chunkThreshold = 10
+
+	resp, err := c.UploadFile(filePath)
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if resp.ID != "file_big" || resp.RevisionID != "rev_1" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if !bytes.Equal(stored, content) {
+		t.Fatalf("server stored %q, want %q", stored, content)
+	}
+	wantRanges := []string{"bytes 0-9/30", "bytes 10-19/30", "bytes 20-29/30"}
+	if fmt.Sprint(ranges) != fmt.Sprint(wantRanges) {
+		t.Fatalf("Content-Range = %v, want %v (no chunk re-sent after resume)", ranges, wantRanges)
+	}
+	if fmt.Sprint(progress) != "[0 10 30]" {
+		t.Fatalf("progress = %v, want [0 10 30]", progress)
+	}
+}
+
+func TestUploadFile_FallsBackToMultipartWithoutUploadSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "big.xlsx")
+	if err := os.WriteFile(filePath, bytes.Repeat([]byte("x"), 32), 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+
+	postCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/uploads":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"not_found","message":"Route POST /v0/uploads not found"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/files":
+			postCalls++
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"big.xlsx","bytes":32,"revision_id":"rev_1","status":"ready"}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "", "", false)
+	c.maxAttempts = 1
+	c.chunkThreshold = 10
+
+	resp, err := c.UploadFile(filePath)
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if resp.ID != "file_1" || postCalls != 1 {
+		t.Fatalf("expected one multipart upload, got resp=%+v posts=%d", resp, postCalls)
+	}
+}
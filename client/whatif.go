@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CellOverride is a hypothetical input for WhatIf: a value, or a formula
+// when Formula is set.
+type CellOverride struct {
+	Address string `json:"address"`
+	Value   any    `json:"value"`
+	Formula string `json:"formula,omitempty"`
+}
+
+// WhatIfResult is the outcome of WhatIf: the overrides applied and the
+// recalculated output ranges.
+type WhatIfResult struct {
+	Inputs  []CellOverride `json:"inputs"`
+	Outputs []WhatIfRange  `json:"outputs"`
+}
+
+// WhatIfRange holds one output range's cells.
+type WhatIfRange struct {
+	Range string       `json:"range"`
+	Cells []WhatIfCell `json:"cells"`
+}
+
+// WhatIfCell is an output cell's value with the overrides applied, and its
+// value before them. Address is sheet-qualified.
+type WhatIfCell struct {
+	Address    string `json:"address"`
+	Value      any    `json:"value"`
+	Text       string `json:"text,omitempty"`
+	Before     any    `json:"before"`
+	BeforeText string `json:"before_text,omitempty"`
+	Changed    bool   `json:"changed"`
+}
+
+// whatIfCode reads input.outputs, writes input.cells, and reads the
+// outputs again, pairing each non-blank cell with its earlier value. The
+// exec is never saved, so the workbook is unchanged.
+const whatIfCode = cellsPrelude + `const read = async () => {
+  const out = [];
+  for (const range of input.outputs) {
+    out.push({ range, cells: await readCells(range) });
+  }
+  return out;
+};
+const before = await read();
+await xlsx.setCells(wb, input.cells.map((c) => c.formula ? { address: c.address, formula: c.formula } : { address: c.address, value: c.value }));
+const after = await read();
+return after.map((r, i) => {
+  const old = new Map(before[i].cells.map((c) => [c.address, c]));
+  return {
+    range: r.range,
+    cells: r.cells.map((c) => {
+      const b = old.get(c.address);
+      return { ...c, before: b ? b.value : null, before_text: b ? b.text : "", changed: JSON.stringify(b ? b.value : null) !== JSON.stringify(c.value) };
+    }),
+  };
+});`
+
+// WhatIf recalculates filePath with the cells in inputs overridden and
+// returns the values of the outputs ranges, through a read-only exec call:
+// the workbook, local or uploaded, is not modified.
+func (c *Client) WhatIf(filePath string, inputs []CellOverride, outputs []string) (*WhatIfResult, error) {
+	result, err := c.execReadOnly(filePath, ExecRequest{Code: whatIfCode, Input: map[string]any{"cells": inputs, "outputs": outputs}})
+	if err != nil {
+		return nil, err
+	}
+	ranges := []WhatIfRange{}
+	if err := json.Unmarshal(result, &ranges); err != nil {
+		return nil, fmt.Errorf("parsing what-if result: %w", err)
+	}
+	return &WhatIfResult{Inputs: inputs, Outputs: ranges}, nil
+}
@@ -0,0 +1,374 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// StripLevel selects what StripWorkbook removes from an xlsx before upload.
+type StripLevel string
+
+const (
+	// StripOff uploads the workbook unchanged.
+	StripOff StripLevel = "off"
+	// StripMinimal removes the cached values of formula cells and the
+	// document thumbnail. Recalculation restores the values.
+	StripMinimal StripLevel = "minimal"
+	// StripAggressive also removes printer settings and cell styles no cell,
+	// row, or column uses.
+	StripAggressive StripLevel = "aggressive"
+)
+
+// ParseStripLevel parses "off", "minimal", or "aggressive"; "" means off.
+func ParseStripLevel(s string) (StripLevel, error) {
+	switch level := StripLevel(strings.ToLower(strings.TrimSpace(s))); level {
+	case "", StripOff:
+		return StripOff, nil
+	case StripMinimal, StripAggressive:
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid strip level %q: use off, minimal, or aggressive", s)
+}
+
+// WithStrip makes stateless Lint and Calc upload a copy of an .xlsx or .xlsm
+// workbook with volatile parts removed; see StripWorkbook. Other file types
+// and files that are not valid zips are sent as-is.
+func WithStrip(level StripLevel) Option {
+	return func(c *Client) { c.strip = level }
+}
+
+// strippedUpload returns the file to send for filePath: a stripped temp
+// copy when WithStrip is set, else filePath. cleanup removes the copy.
+func (c *Client) strippedUpload(filePath string) (string, func(), error) {
+	noop := func() {}
+	if c.strip == "" || c.strip == StripOff {
+		return filePath, noop, nil
+	}
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".xlsx" && ext != ".xlsm" {
+		return filePath, noop, nil
+	}
+	tmp, err := os.CreateTemp("", "witan-strip-*"+ext)
+	if err != nil {
+		return "", noop, fmt.Errorf("creating stripped copy: %w", err)
+	}
+	tmp.Close()
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if err := StripWorkbook(filePath, tmp.Name(), c.strip); err != nil {
+		// Let the server judge a file we cannot rewrite.
+		cleanup()
+		return filePath, noop, nil
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+var (
+	stripCellRe         = regexp.MustCompile(`(?s)<c\b([^>]*?)(?:/>|>(.*?)</c>)`)
+	stripFormulaRe      = regexp.MustCompile(`<f[\s/>]`)
+	stripValueRe        = regexp.MustCompile(`(?s)<v/>|<v\b[^>]*>.*?</v>`)
+	stripTypeAttrRe     = regexp.MustCompile(`\st="[^"]*"`)
+	stripStyleAttrRe    = regexp.MustCompile(`\ss="(\d+)"`)
+	stripColStyleAttrRe = regexp.MustCompile(`\sstyle="(\d+)"`)
+	stripRowRe          = regexp.MustCompile(`<row\b[^>]*>`)
+	stripColRe          = regexp.MustCompile(`<col\b[^>]*>`)
+	stripPrefixedCellRe = regexp.MustCompile(`<[A-Za-z_][\w.-]*:(?:c|row|col)\b`)
+	stripPageSetupRe    = regexp.MustCompile(`<pageSetup\b[^>]*>`)
+	stripRelIDAttrRe    = regexp.MustCompile(`\s[A-Za-z_][\w.-]*:id="[^"]*"`)
+	stripRelationshipRe = regexp.MustCompile(`(?s)<Relationship\b[^>]*?(?:/>|>.*?</Relationship>)`)
+	stripOverrideRe     = regexp.MustCompile(`(?s)<Override\b[^>]*?(?:/>|>.*?</Override>)`)
+	stripTargetAttrRe   = regexp.MustCompile(`\sTarget="([^"]*)"`)
+	stripPartNameAttrRe = regexp.MustCompile(`\sPartName="([^"]*)"`)
+	stripCellXfsRe      = regexp.MustCompile(`(?s)(<cellXfs\b[^>]*>)(.*?)(</cellXfs>)`)
+	stripXfRe           = regexp.MustCompile(`(?s)<xf\b[^>]*?(?:/>|>.*?</xf>)`)
+	stripCountAttrRe    = regexp.MustCompile(`\scount="\d+"`)
+)
+
+// StripWorkbook writes a copy of the xlsx workbook at src to dst without
+// parts the API does not need to lint or recalculate it, which can shrink
+// large models substantially. See StripLevel for what each level removes.
+// Everything else is copied byte for byte.
+//
+// The copy is meant for upload only: StripMinimal leaves formula cells
+// without values until they are recalculated, and StripAggressive loses
+// printer settings.
+func StripWorkbook(src, dst string, level StripLevel) error {
+	if level != StripMinimal && level != StripAggressive {
+		return fmt.Errorf("invalid strip level %q", level)
+	}
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("opening workbook: %w", err)
+	}
+	defer zr.Close()
+
+	dropped := map[string]bool{}
+	for _, f := range zr.File {
+		if isStrippedPart(f.Name, level) {
+			dropped[f.Name] = true
+		}
+	}
+	remap, err := unusedStyleRemap(zr.File, level)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	for _, f := range zr.File {
+		if dropped[f.Name] {
+			continue
+		}
+		rewrite := stripRewriter(f.Name, level, dropped, remap)
+		if rewrite == nil {
+			if err = zw.Copy(f); err != nil {
+				break
+			}
+			continue
+		}
+		var data []byte
+		if data, err = readZipFile(f); err != nil {
+			break
+		}
+		var w io.Writer
+		if w, err = zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified}); err != nil {
+			break
+		}
+		if _, err = w.Write(rewrite(data)); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("writing stripped workbook: %w", err)
+	}
+	return nil
+}
+
+// isStrippedPart reports whether level removes the part called name.
+func isStrippedPart(name string, level StripLevel) bool {
+	if strings.HasPrefix(name, "docProps/thumbnail.") {
+		return true
+	}
+	return level == StripAggressive && strings.HasPrefix(name, "xl/printerSettings/")
+}
+
+// isSheetPart reports whether name is a part whose cells reference styles.
+func isSheetPart(name string) bool {
+	for _, dir := range []string{"xl/worksheets/", "xl/macrosheets/", "xl/dialogsheets/"} {
+		if rest, ok := strings.CutPrefix(name, dir); ok {
+			return !strings.Contains(rest, "/") && strings.HasSuffix(rest, ".xml")
+		}
+	}
+	return false
+}
+
+// stripRewriter returns the rewrite for the part called name, or nil when
+// the part is copied unchanged.
+func stripRewriter(name string, level StripLevel, dropped map[string]bool, remap map[int]int) func([]byte) []byte {
+	switch {
+	case name == "[Content_Types].xml":
+		return func(data []byte) []byte { return dropOverrides(data, dropped) }
+	case strings.HasSuffix(name, ".rels"):
+		return func(data []byte) []byte { return dropRelationships(name, data, dropped) }
+	case name == "xl/styles.xml" && remap != nil:
+		return func(data []byte) []byte { return pruneCellXfs(data, remap) }
+	case isSheetPart(name):
+		return func(data []byte) []byte { return stripSheet(data, level, remap) }
+	case level == StripAggressive && strings.HasPrefix(name, "xl/chartsheets/") && strings.HasSuffix(name, ".xml"):
+		return dropPageSetupRelID
+	}
+	return nil
+}
+
+// stripSheet removes the cached values of formula cells and, with remap,
+// renumbers style references.
+func stripSheet(data []byte, level StripLevel, remap map[int]int) []byte {
+	data = stripCellRe.ReplaceAllFunc(data, func(cell []byte) []byte {
+		m := stripCellRe.FindSubmatchIndex(cell)
+		attrs, body := cell[m[2]:m[3]], []byte(nil)
+		if m[4] >= 0 {
+			body = cell[m[4]:m[5]]
+		}
+		formula := stripFormulaRe.Match(body)
+		if !formula && remap == nil {
+			return cell
+		}
+		if remap != nil {
+			attrs = remapStyleAttr(attrs, stripStyleAttrRe, remap)
+		}
+		if formula {
+			// The type attribute describes the cached value.
+			attrs = stripTypeAttrRe.ReplaceAll(attrs, nil)
+			body = stripValueRe.ReplaceAll(body, nil)
+		}
+		var b bytes.Buffer
+		b.WriteString("<c")
+		b.Write(attrs)
+		if body == nil {
+			b.WriteString("/>")
+		} else {
+			b.WriteByte('>')
+			b.Write(body)
+			b.WriteString("</c>")
+		}
+		return b.Bytes()
+	})
+	if remap != nil {
+		data = stripRowRe.ReplaceAllFunc(data, func(tag []byte) []byte {
+			return remapStyleAttr(tag, stripStyleAttrRe, remap)
+		})
+		data = stripColRe.ReplaceAllFunc(data, func(tag []byte) []byte {
+			return remapStyleAttr(tag, stripColStyleAttrRe, remap)
+		})
+	}
+	if level == StripAggressive {
+		data = dropPageSetupRelID(data)
+	}
+	return data
+}
+
+// remapStyleAttr renumbers the style index attribute attrRe matches in tag.
+func remapStyleAttr(tag []byte, attrRe *regexp.Regexp, remap map[int]int) []byte {
+	return attrRe.ReplaceAllFunc(tag, func(attr []byte) []byte {
+		m := attrRe.FindSubmatch(attr)
+		old, _ := strconv.Atoi(string(m[1]))
+		idx := bytes.Index(attr, m[1])
+		return []byte(string(attr[:idx]) + strconv.Itoa(remap[old]) + `"`)
+	})
+}
+
+// dropPageSetupRelID removes pageSetup's reference to printer settings.
+func dropPageSetupRelID(data []byte) []byte {
+	return stripPageSetupRe.ReplaceAllFunc(data, func(tag []byte) []byte {
+		return stripRelIDAttrRe.ReplaceAll(tag, nil)
+	})
+}
+
+// dropRelationships removes relationships from the rels part called name
+// whose targets were dropped.
+func dropRelationships(name string, data []byte, dropped map[string]bool) []byte {
+	// xl/_rels/workbook.xml.rels holds relationships of xl/workbook.xml.
+	base := path.Dir(path.Dir(name))
+	return stripRelationshipRe.ReplaceAllFunc(data, func(rel []byte) []byte {
+		m := stripTargetAttrRe.FindSubmatch(rel)
+		if m == nil || bytes.Contains(rel, []byte(`TargetMode="External"`)) {
+			return rel
+		}
+		target := string(m[1])
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(base, target)
+		}
+		if dropped[target] {
+			return nil
+		}
+		return rel
+	})
+}
+
+// dropOverrides removes content type overrides for dropped parts.
+func dropOverrides(data []byte, dropped map[string]bool) []byte {
+	return stripOverrideRe.ReplaceAllFunc(data, func(o []byte) []byte {
+		if m := stripPartNameAttrRe.FindSubmatch(o); m != nil && dropped[strings.TrimPrefix(string(m[1]), "/")] {
+			return nil
+		}
+		return o
+	})
+}
+
+// unusedStyleRemap maps the indexes of cell styles that are used to their
+// positions once unused ones are removed. It returns nil, leaving styles
+// alone, below StripAggressive or when a sheet uses prefixed element names
+// the rewrite does not recognize.
+func unusedStyleRemap(files []*zip.File, level StripLevel) (map[int]int, error) {
+	if level != StripAggressive {
+		return nil, nil
+	}
+	used := map[int]bool{0: true} // the default style
+	var styles []byte
+	for _, f := range files {
+		if f.Name != "xl/styles.xml" && !isSheetPart(f.Name) {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name == "xl/styles.xml" {
+			styles = data
+			continue
+		}
+		if stripPrefixedCellRe.Match(data) {
+			return nil, nil
+		}
+		collect := func(tagRe, attrRe *regexp.Regexp) {
+			for _, tag := range tagRe.FindAll(data, -1) {
+				if m := attrRe.FindSubmatch(tag); m != nil {
+					n, _ := strconv.Atoi(string(m[1]))
+					used[n] = true
+				}
+			}
+		}
+		collect(stripCellRe, stripStyleAttrRe)
+		collect(stripRowRe, stripStyleAttrRe)
+		collect(stripColRe, stripColStyleAttrRe)
+	}
+	m := stripCellXfsRe.FindSubmatch(styles)
+	if m == nil {
+		return nil, nil
+	}
+	xfs := stripXfRe.FindAll(m[2], -1)
+	remap := make(map[int]int, len(used))
+	for i := range xfs {
+		if used[i] {
+			remap[i] = len(remap)
+		}
+	}
+	if len(remap) == len(xfs) {
+		return nil, nil // nothing to prune
+	}
+	return remap, nil
+}
+
+// pruneCellXfs removes the cell styles remap leaves out.
+func pruneCellXfs(data []byte, remap map[int]int) []byte {
+	return stripCellXfsRe.ReplaceAllFunc(data, func(section []byte) []byte {
+		m := stripCellXfsRe.FindSubmatch(section)
+		var b bytes.Buffer
+		b.Write(stripCountAttrRe.ReplaceAll(m[1], []byte(fmt.Sprintf(` count="%d"`, len(remap)))))
+		for i, xf := range stripXfRe.FindAll(m[2], -1) {
+			if _, ok := remap[i]; ok {
+				b.Write(xf)
+			}
+		}
+		b.Write(m[3])
+		return b.Bytes()
+	})
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestZip(t *testing.T, path string, parts map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, body)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func readTestZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+	}
+	return parts
+}
+
+func TestStripWorkbook_Aggressive(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "model.xlsx")
+	writeTestZip(t, src, map[string]string{
+		"[Content_Types].xml": `<Types><Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="ws"/>` +
+			`<Override PartName="/xl/printerSettings/printerSettings1.bin" ContentType="ps"/>` +
+			`<Override PartName="/docProps/thumbnail.jpeg" ContentType="image/jpeg"/></Types>`,
+		"_rels/.rels": `<Relationships><Relationship Id="rId1" Target="xl/workbook.xml"/>` +
+			`<Relationship Id="rId2" Target="docProps/thumbnail.jpeg"/></Relationships>`,
+		"docProps/thumbnail.jpeg":                 "jpeg",
+		"xl/printerSettings/printerSettings1.bin": "bin",
+		"xl/worksheets/_rels/sheet1.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Target="../printerSettings/printerSettings1.bin"/>` +
+			`<Relationship Id="rId2" Target="../drawings/drawing1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><cols><col min="1" max="1" style="3"/></cols><sheetData>` +
+			`<row r="1"><c r="A1" s="5"><v>2</v></c><c r="B1" s="5" t="str"><f>A1&amp;"x"</f><v>2x</v></c><c r="C1"/></row>` +
+			`</sheetData><pageSetup orientation="landscape" r:id="rId1"/></worksheet>`,
+		"xl/styles.xml": `<styleSheet><cellStyleXfs count="1"><xf numFmtId="0"/></cellStyleXfs>` +
+			`<cellXfs count="6"><xf numFmtId="0"/><xf numFmtId="1"/><xf numFmtId="2"/>` +
+			`<xf numFmtId="3"/><xf numFmtId="4"><alignment wrapText="1"/></xf><xf numFmtId="5"/></cellXfs></styleSheet>`,
+	})
+
+	dst := filepath.Join(dir, "stripped.xlsx")
+	if err := StripWorkbook(src, dst, StripAggressive); err != nil {
+		t.Fatalf("StripWorkbook: %v", err)
+	}
+	parts := readTestZip(t, dst)
+
+	for _, name := range []string{"docProps/thumbnail.jpeg", "xl/printerSettings/printerSettings1.bin"} {
+		if _, ok := parts[name]; ok {
+			t.Errorf("%s was not removed", name)
+		}
+	}
+	if ct := parts["[Content_Types].xml"]; strings.Contains(ct, "printerSettings") || strings.Contains(ct, "thumbnail") || !strings.Contains(ct, "sheet1.xml") {
+		t.Errorf("content types = %s", ct)
+	}
+	if rels := parts["_rels/.rels"]; strings.Contains(rels, "thumbnail") || !strings.Contains(rels, "workbook.xml") {
+		t.Errorf("package rels = %s", rels)
+	}
+	if rels := parts["xl/worksheets/_rels/sheet1.xml.rels"]; strings.Contains(rels, "printerSettings") || !strings.Contains(rels, "drawing1.xml") {
+		t.Errorf("sheet rels = %s", rels)
+	}
+
+	// Styles 0, 3, and 5 are used; they become 0, 1, and 2.
+	wantSheet := `<worksheet><cols><col min="1" max="1" style="1"/></cols><sheetData>` +
+		`<row r="1"><c r="A1" s="2"><v>2</v></c><c r="B1" s="2"><f>A1&amp;"x"</f></c><c r="C1"/></row>` +
+		`</sheetData><pageSetup orientation="landscape"/></worksheet>`
+	if got := parts["xl/worksheets/sheet1.xml"]; got != wantSheet {
+		t.Errorf("sheet =\n%s\nwant\n%s", got, wantSheet)
+	}
+	wantStyles := `<styleSheet><cellStyleXfs count="1"><xf numFmtId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="3"/><xf numFmtId="5"/></cellXfs></styleSheet>`
+	if got := parts["xl/styles.xml"]; got != wantStyles {
+		t.Errorf("styles =\n%s\nwant\n%s", got, wantStyles)
+	}
+
+	// Minimal keeps styles and printer settings.
+	if err := StripWorkbook(src, dst, StripMinimal); err != nil {
+		t.Fatalf("StripWorkbook: %v", err)
+	}
+	parts = readTestZip(t, dst)
+	if _, ok := parts["xl/printerSettings/printerSettings1.bin"]; !ok {
+		t.Error("minimal removed printer settings")
+	}
+	if got := parts["xl/worksheets/sheet1.xml"]; !strings.Contains(got, `<c r="B1" s="5"><f>A1&amp;"x"</f></c>`) || !strings.Contains(got, `r:id="rId1"`) {
+		t.Errorf("minimal sheet = %s", got)
+	}
+}
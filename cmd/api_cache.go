@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// apiCacheEntry is a value fetched from one API (base URL and org), kept in
+// the user cache dir so later commands can skip the request.
+type apiCacheEntry struct {
+	API       string          `json:"api"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// apiCacheKey identifies the API (base URL and org) a cached value came from.
+func apiCacheKey(baseURL, orgID string) string {
+	return strings.TrimRight(baseURL, "/") + "|" + orgID
+}
+
+func apiCachePath(name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "witan", name+".json"), nil
+}
+
+// readAPICache decodes the cached value for api into v and returns when it
+// was fetched. ok is false when nothing is cached for api.
+func readAPICache(name, api string, v any) (fetchedAt time.Time, ok bool) {
+	path, err := apiCachePath(name)
+	if err != nil {
+		return time.Time{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var entry apiCacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.API != api || json.Unmarshal(entry.Value, v) != nil {
+		return time.Time{}, false
+	}
+	return entry.FetchedAt, true
+}
+
+// writeAPICache stores v for api best-effort; a failed write only costs a
+// request on the next run.
+func writeAPICache(name, api string, v any) {
+	path, err := apiCachePath(name)
+	if err != nil {
+		return
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(apiCacheEntry{API: api, FetchedAt: time.Now().UTC(), Value: value})
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0o700) != nil {
+		return
+	}
+	_ = writeFileAtomic(path, data)
+}
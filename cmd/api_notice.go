@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// apiNoticeInterval is how long a notice stays quiet after it is shown.
+const apiNoticeInterval = 24 * time.Hour
+
+var apiNotices = struct {
+	sync.Mutex
+	seen map[string]bool // keys already handled by this process
+	out  io.Writer       // swapped in tests
+	now  func() time.Time
+}{out: os.Stderr, now: time.Now}
+
+// apiNoticeStatePath returns the file recording when each notice was last
+// shown.
+func apiNoticeStatePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "witan", "notices.json"), nil
+}
+
+// warnAPINotice prints a one-line warning for an API notice, at most once
+// per process and once per apiNoticeInterval across runs.
+func warnAPINotice(n client.Notice) {
+	key, msg := apiNoticeMessage(n)
+	if msg == "" {
+		return
+	}
+
+	apiNotices.Lock()
+	defer apiNotices.Unlock()
+	if apiNotices.seen[key] {
+		return
+	}
+	if apiNotices.seen == nil {
+		apiNotices.seen = map[string]bool{}
+	}
+	apiNotices.seen[key] = true
+	if !claimAPINotice(key, apiNotices.now()) {
+		return
+	}
+	fmt.Fprintf(apiNotices.out, "Warning: %s\n", msg)
+}
+
+// apiNoticeMessage returns the rate-limit key and warning for n, or an empty
+// message when there is nothing to say.
+func apiNoticeMessage(n client.Notice) (key, msg string) {
+	switch n.Kind {
+	case client.NoticeMinCLIVersion:
+		// Development builds cannot be compared; they are not nagged.
+		if _, ok := parseVersion(Version); !ok || compareVersions(Version, n.MinCLIVersion) >= 0 {
+			return "", ""
+		}
+		return "min_cli_version|" + n.MinCLIVersion, fmt.Sprintf(
+			"the Witan API requires witan %s or newer (this is %s); run `witan update`",
+			n.MinCLIVersion, strings.TrimSpace(Version))
+	case client.NoticeDeprecation:
+		msg = fmt.Sprintf("the Witan API has deprecated %s %s", n.Method, n.Path)
+		if t, err := http.ParseTime(n.Sunset); err == nil {
+			msg += " and may remove it after " + t.Format("2006-01-02")
+		} else if n.Sunset != "" {
+			msg += " and may remove it after " + n.Sunset
+		}
+		msg += "; run `witan update`"
+		if n.Link != "" {
+			msg += " (see " + n.Link + ")"
+		}
+		return strings.Join([]string{"deprecation", n.Deprecation, n.Sunset, n.Link}, "|"), msg
+	}
+	return "", ""
+}
+
+// claimAPINotice reports whether key is due to be shown at now and, if so,
+// records it. When the state file cannot be used the notice is shown.
+func claimAPINotice(key string, now time.Time) bool {
+	path, err := apiNoticeStatePath()
+	if err != nil {
+		return true
+	}
+	state := map[string]int64{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	if last, ok := state[key]; ok && now.Sub(time.Unix(last, 0)) < apiNoticeInterval {
+		return false
+	}
+	state[key] = now.Unix()
+	// Drop entries nobody has seen for a while so the file does not grow.
+	for k, last := range state {
+		if now.Sub(time.Unix(last, 0)) > 30*apiNoticeInterval {
+			delete(state, k)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return true
+	}
+	data, _ := json.Marshal(state)
+	_ = writeFileAtomic(path, data)
+	return true
+}
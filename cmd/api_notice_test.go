@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestWarnAPINotice_OncePerDay(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	origVersion := Version
+	origOut := apiNotices.out
+	origNow := apiNotices.now
+	origSeen := apiNotices.seen
+	t.Cleanup(func() {
+		Version = origVersion
+		apiNotices.out = origOut
+		apiNotices.now = origNow
+		apiNotices.seen = origSeen
+	})
+
+	var out bytes.Buffer
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	Version = "v1.2.0"
+	apiNotices.out = &out
+	apiNotices.now = func() time.Time { return now }
+	apiNotices.seen = nil
+
+	notice := client.Notice{Kind: client.NoticeMinCLIVersion, MinCLIVersion: "v1.3.0"}
+	warnAPINotice(notice)
+	warnAPINotice(notice)
+	if got := strings.Count(out.String(), "Warning:"); got != 1 {
+		t.Fatalf("got %d warnings in one process, want 1:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "requires witan v1.3.0 or newer") {
+		t.Fatalf("unexpected warning: %q", out.String())
+	}
+
+	// A later run the same day stays quiet; the next day it warns again.
+	out.Reset()
+	apiNotices.seen = nil
+	now = now.Add(3 * time.Hour)
+	warnAPINotice(notice)
+	if out.Len() != 0 {
+		t.Fatalf("expected no warning within a day, got %q", out.String())
+	}
+	apiNotices.seen = nil
+	now = now.Add(24 * time.Hour)
+	warnAPINotice(notice)
+	if !strings.Contains(out.String(), "Warning:") {
+		t.Fatal("expected the warning again after a day")
+	}
+}
+
+func TestAPINoticeMessage(t *testing.T) {
+	origVersion := Version
+	t.Cleanup(func() { Version = origVersion })
+
+	Version = "dev"
+	if _, msg := apiNoticeMessage(client.Notice{Kind: client.NoticeMinCLIVersion, MinCLIVersion: "v9.0.0"}); msg != "" {
+		t.Fatalf("development builds should not warn, got %q", msg)
+	}
+	Version = "v1.4.0"
+	if _, msg := apiNoticeMessage(client.Notice{Kind: client.NoticeMinCLIVersion, MinCLIVersion: "v1.3.0"}); msg != "" {
+		t.Fatalf("a new enough CLI should not warn, got %q", msg)
+	}
+
+	_, msg := apiNoticeMessage(client.Notice{
+		Kind:        client.NoticeDeprecation,
+		Method:      "POST",
+		Path:        "/v0/xlsx/calc",
+		Deprecation: "@1798761600",
+		Sunset:      "Fri, 01 Jan 2027 00:00:00 GMT",
+		Link:        "https://docs.witanlabs.com/calc-v1",
+	})
+	want := "the Witan API has deprecated POST /v0/xlsx/calc and may remove it after 2027-01-01; run `witan update` (see https://docs.witanlabs.com/calc-v1)"
+	if msg != want {
+		t.Fatalf("message = %q\nwant      %q", msg, want)
+	}
+}
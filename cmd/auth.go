@@ -10,11 +10,13 @@ var authCmd = &cobra.Command{
 Use login to start browser sign-in and save a local session.
 Use status to inspect which credential is active right now.
 Use logout to revoke that session and clear local credentials.
+Use create-key to mint an API key for CI from a signed-in session.
 
 Examples:
   witan auth login
   witan auth status
-  witan auth logout`,
+  witan auth logout
+  witan auth create-key --name ci-bot --expires 90d`,
 }
 
 func init() {
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/config"
+)
+
+var (
+	createKeyName    string
+	createKeyExpires string
+	createKeyJSON    bool
+)
+
+var createKeyCmd = &cobra.Command{
+	Use:   "create-key",
+	Short: "Create an API key for CI and automation",
+	Long: `Create an API key for the organization of the signed-in session, for use
+in CI and other unattended automation via --api-key or WITAN_API_KEY.
+
+The key is printed to stdout once and cannot be retrieved again; store it in
+your CI secret store right away. Details go to stderr, so the key can be
+piped or redirected on its own. --expires sets a lifetime such as 90d or
+720h; without it the key does not expire.
+
+Requires a session from 'witan auth login'; an API key cannot create keys.
+
+Examples:
+  witan auth create-key --name ci-bot --expires 90d
+  witan auth create-key --name nightly --json`,
+	Args: cobra.NoArgs,
+	RunE: runCreateKey,
+}
+
+func init() {
+	createKeyCmd.SilenceUsage = true
+	createKeyCmd.Flags().StringVar(&createKeyName, "name", "", "Name that identifies the key in the console (required)")
+	createKeyCmd.Flags().StringVar(&createKeyExpires, "expires", "", "Key lifetime, e.g. 90d or 720h (default: no expiry)")
+	createKeyCmd.Flags().BoolVar(&createKeyJSON, "json", false, "Output the created key as JSON")
+	_ = createKeyCmd.MarkFlagRequired("name")
+	authCmd.AddCommand(createKeyCmd)
+}
+
+// createdAPIKey is the management API's response for a new API key. Key is
+// only ever returned here.
+type createdAPIKey struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	OrgID     string `json:"org_id"`
+	CreatedAt string `json:"created_at,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+func runCreateKey(cmd *cobra.Command, args []string) error {
+	name := strings.TrimSpace(createKeyName)
+	if name == "" {
+		return fmt.Errorf("--name must not be empty")
+	}
+	var expiresAt time.Time
+	if createKeyExpires != "" {
+		lifetime, err := parseKeyLifetime(createKeyExpires)
+		if err != nil {
+			return err
+		}
+		expiresAt = time.Now().Add(lifetime).UTC()
+	}
+
+	if resolveRawAPIKey() != "" {
+		return fmt.Errorf("creating API keys requires user authentication.\nRun 'witan auth login' and try again without --api-key or WITAN_API_KEY.")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("loading auth config: %w", err)
+	}
+	if cfg.SessionToken == "" || cfg.SessionOrgID == "" {
+		return fmt.Errorf("not authenticated: run 'witan auth login' first")
+	}
+	jwt, err := sessionJWT(cfg)
+	if err != nil {
+		return fmt.Errorf("session expired: run 'witan auth login' to re-authenticate")
+	}
+
+	key, err := createAPIKey(resolveManagementAPIURL(), jwt, cfg.SessionOrgID, name, expiresAt)
+	if err != nil {
+		return err
+	}
+	if key.OrgID == "" {
+		key.OrgID = cfg.SessionOrgID
+	}
+
+	// Best-effort: record the key's org so using it here skips the lookup.
+	cfg.SetOrgIDForAPIKey(key.Key, key.OrgID)
+	_ = config.Save(cfg)
+
+	if createKeyJSON {
+		return jsonPrintTo(cmd.OutOrStdout(), key)
+	}
+	expiry := "never expires"
+	if key.ExpiresAt != "" {
+		expiry = "expires " + key.ExpiresAt
+	}
+	fmt.Fprintf(os.Stderr, "\u2713 Created API key %q (%s) for %s; it %s.\n", key.Name, key.ID, key.OrgID, expiry)
+	fmt.Fprintln(os.Stderr, "Store it now: it is not shown again.")
+	fmt.Fprintln(cmd.OutOrStdout(), key.Key)
+	return nil
+}
+
+// parseKeyLifetime parses a key lifetime: a number of days ("90d") or a Go
+// duration ("720h").
+func parseKeyLifetime(raw string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --expires %q: use a number of days like 90d or a duration like 720h", raw)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(raw); err != nil {
+			return 0, fmt.Errorf("invalid --expires %q: use a number of days like 90d or a duration like 720h", raw)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("--expires must be positive, got %q", raw)
+	}
+	return d, nil
+}
+
+// createAPIKey calls POST {mgmtURL}/v0/orgs/{orgID}/api-keys. A zero
+// expiresAt creates a key that does not expire.
+func createAPIKey(mgmtURL, jwt, orgID, name string, expiresAt time.Time) (*createdAPIKey, error) {
+	payload := map[string]string{"name": name}
+	if !expiresAt.IsZero() {
+		payload["expires_at"] = expiresAt.Format(time.RFC3339)
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", mgmtURL+"/v0/orgs/"+url.PathEscape(orgID)+"/api-keys", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	setCLIUserAgent(req)
+
+	resp, err := newHTTPClient(30 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		err := parseManagementAPIError(resp.StatusCode, respBody)
+		if apiErr, ok := err.(*ManagementAPIError); ok {
+			switch apiErr.StatusCode {
+			case http.StatusUnauthorized:
+				return nil, fmt.Errorf("session expired: run 'witan auth login' to re-authenticate")
+			case http.StatusForbidden:
+				return nil, fmt.Errorf("you don't have permission to create API keys in %s: %w", orgID, err)
+			}
+		}
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	var key createdAPIKey
+	if err := json.Unmarshal(respBody, &key); err != nil {
+		return nil, fmt.Errorf("failed to parse API key response: %w", err)
+	}
+	if key.Key == "" {
+		return nil, fmt.Errorf("failed to create API key: empty key in response")
+	}
+	return &key, nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/config"
+)
+
+func TestParseKeyLifetime(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"90d": 90 * 24 * time.Hour, "720h": 720 * time.Hour} {
+		if got, err := parseKeyLifetime(raw); err != nil || got != want {
+			t.Errorf("parseKeyLifetime(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "ninety", "0d", "-5h", "3w"} {
+		if _, err := parseKeyLifetime(raw); err == nil {
+			t.Errorf("parseKeyLifetime(%q) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestRunCreateKey_PrintsKeyOnceAndCachesOrg(t *testing.T) {
+	origAPIKey := apiKey
+	origName, origExpires, origJSON := createKeyName, createKeyExpires, createKeyJSON
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		createKeyName, createKeyExpires, createKeyJSON = origName, origExpires, origJSON
+	})
+	apiKey = ""
+	t.Setenv("WITAN_API_KEY", "")
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	if err := config.Save(config.Config{SessionToken: "session", SessionOrgID: "org_1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0/auth/token":
+			fmt.Fprint(w, `{"token":"jwt"}`)
+		case "/v0/orgs/org_1/api-keys":
+			if r.Method != "POST" || r.Header.Get("Authorization") != "Bearer jwt" {
+				t.Errorf("unexpected request: %s auth=%q", r.Method, r.Header.Get("Authorization"))
+			}
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "ci-bot" || body["expires_at"] == "" {
+				t.Errorf("unexpected body: %v", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id":"key_1","name":"ci-bot","key":"wk_secret","org_id":"org_1","expires_at":%q}`, body["expires_at"])
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("WITAN_MANAGEMENT_API_URL", server.URL)
+
+	createKeyName, createKeyExpires, createKeyJSON = "ci-bot", "90d", false
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	if err := runCreateKey(cmd, nil); err != nil {
+		t.Fatalf("runCreateKey: %v", err)
+	}
+	if out.String() != "wk_secret\n" {
+		t.Fatalf("stdout = %q, want only the key", out.String())
+	}
+	cfg, err := config.Load()
+	if err != nil || cfg.OrgIDForAPIKey("wk_secret") != "org_1" || cfg.SessionToken != "session" {
+		t.Fatalf("config after create-key: %+v, %v", cfg, err)
+	}
+
+	apiKey = "wk_other"
+	if err := runCreateKey(cmd, nil); err == nil || !strings.Contains(err.Error(), "user authentication") {
+		t.Fatalf("expected API-key auth to be rejected, got %v", err)
+	}
+}
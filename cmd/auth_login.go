@@ -35,19 +35,25 @@ runs non-interactively: it prints the verification URL and one-time code up
 front, does not open a browser, and polls to completion in the same process.
 Hand the URL/code to a human on another device.
 
+On a remote or SSH machine, --no-browser prints the verification URL and code
+without waiting for Enter; open the URL on any device and enter the code.
+--code-only prints just the URL and code, one per line, for constrained
+terminals.
+
 With multiple organizations, select one non-interactively via --org <id> or
 WITAN_ORG. If neither is set in non-interactive mode, the organization list is
-emitted and the command exits with code 3 (the session is saved, so a re-run
+emitted and the command exits with code 4 (the session is saved, so a re-run
 with --org finishes without re-authenticating).
 
 In --json mode, stdout is newline-delimited JSON (one object per line), each
 tagged with a "type": device_authorization (the verification URL/code),
-org_selection_required (exit code 3), or login_complete.
+org_selection_required (exit code 4), or login_complete.
 
 For non-session, fully unattended use, prefer --api-key or WITAN_API_KEY.
 
 Example:
   witan auth login
+  witan auth login --no-browser
   witan auth login --json --org org_123`,
 	RunE: runLogin,
 }
@@ -55,13 +61,15 @@ Example:
 var (
 	loginJSON      bool
 	loginNoBrowser bool
+	loginCodeOnly  bool
 	loginOrg       string
 )
 
 func init() {
 	loginCmd.SilenceUsage = true
 	loginCmd.Flags().BoolVar(&loginJSON, "json", false, "Emit machine-readable JSONL events (device_authorization, org_selection_required, login_complete) and run non-interactively")
-	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Do not attempt to open a browser")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Print the verification URL and code instead of opening a browser")
+	loginCmd.Flags().BoolVar(&loginCodeOnly, "code-only", false, "Print only the verification URL and code, one per line (implies --no-browser)")
 	loginCmd.Flags().StringVar(&loginOrg, "org", "", "Organization ID to select (env: WITAN_ORG)")
 	authCmd.AddCommand(loginCmd)
 }
@@ -128,8 +136,11 @@ type sessionResponse struct {
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
+	if loginCodeOnly && loginJSON {
+		return fmt.Errorf("--code-only and --json cannot be combined")
+	}
 	mgmtURL := resolveManagementAPIURL()
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := newHTTPClient(30 * time.Second)
 
 	nonInteractive := loginJSON || !stdinIsTTY()
 	orgPref := resolveLoginOrg()
@@ -179,7 +190,7 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	if len(displayCode) >= 8 {
 		displayCode = displayCode[:4] + "-" + displayCode[4:]
 	}
-	if nonInteractive {
+	if nonInteractive || loginNoBrowser || loginCodeOnly {
 		emitHandoff(&dcResp, displayCode)
 	} else {
 		fmt.Fprintf(os.Stderr, "! First, copy your one-time code: %s\n", displayCode)
@@ -189,10 +200,8 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		buf := make([]byte, 1)
 		os.Stdin.Read(buf)
 
-		if !loginNoBrowser {
-			if err := openBrowser(dcResp.VerificationURI); err != nil {
-				fmt.Fprintf(os.Stderr, "Could not open browser. Please visit:\n  %s\n", dcResp.VerificationURI)
-			}
+		if err := openBrowser(dcResp.VerificationURI); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not open browser. Please visit:\n  %s\n", dcResp.VerificationURI)
 		}
 	}
 
@@ -205,7 +214,9 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		interval = 5 * time.Second
 	}
 
-	fmt.Fprintf(os.Stderr, "Waiting for authorization...\n")
+	if !loginCodeOnly {
+		fmt.Fprintf(os.Stderr, "Waiting for authorization...\n")
+	}
 
 	deadline := time.Now().Add(time.Duration(dcResp.ExpiresIn) * time.Second)
 
@@ -240,22 +251,33 @@ func runLogin(cmd *cobra.Command, args []string) error {
 // otherwise it prints a human-readable prompt to stderr. It never reads stdin
 // or opens a browser.
 func emitHandoff(dc *deviceCodeResponse, displayCode string) {
-	if loginJSON {
-		jsonlPrint(map[string]any{
-			"type":                      "device_authorization",
-			"verification_uri":          dc.VerificationURI,
-			"verification_uri_complete": dc.VerificationURIComplete,
-			"user_code":                 dc.UserCode,
-			"expires_in":                dc.ExpiresIn,
-		})
+	if !loginJSON {
+		writeHandoff(os.Stderr, dc, displayCode)
+		return
+	}
+	jsonlPrint(map[string]any{
+		"type":                      "device_authorization",
+		"verification_uri":          dc.VerificationURI,
+		"verification_uri_complete": dc.VerificationURIComplete,
+		"user_code":                 dc.UserCode,
+		"expires_in":                dc.ExpiresIn,
+	})
+}
+
+// writeHandoff writes the human-readable handoff prompt. With --code-only it
+// is just the short verification URL and the code, one per line, so it fits
+// a narrow terminal and is easy to copy.
+func writeHandoff(w io.Writer, dc *deviceCodeResponse, displayCode string) {
+	if loginCodeOnly {
+		fmt.Fprintf(w, "%s\n%s\n", dc.VerificationURI, displayCode)
 		return
 	}
 	target := dc.VerificationURIComplete
 	if target == "" {
 		target = dc.VerificationURI
 	}
-	fmt.Fprintf(os.Stderr, "To sign in, open this URL in a browser:\n  %s\n", target)
-	fmt.Fprintf(os.Stderr, "and enter the code: %s\n", displayCode)
+	fmt.Fprintf(w, "To sign in, open this URL in a browser:\n  %s\n", target)
+	fmt.Fprintf(w, "and enter the code: %s\n", displayCode)
 }
 
 // completeLogin exchanges a freshly minted session token for the user's orgs,
@@ -284,11 +306,15 @@ func completeLogin(client *http.Client, mgmtURL, sessionToken, orgPref string, n
 		return err
 	}
 
-	// Save config
-	if err := config.Save(config.Config{
+	// Save config, caching the JWT so the next command skips the exchange
+	cfg := config.Config{
 		SessionToken: sessionToken,
 		SessionOrgID: selectedOrgID,
-	}); err != nil {
+	}
+	if expiresAt, ok := jwtExpiry(jwt); ok {
+		cfg.SetSessionJWT(jwt, expiresAt)
+	}
+	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
@@ -320,7 +346,7 @@ func emitLoginComplete(email, orgID string) {
 // selectOrg chooses the active organization. A non-empty orgPref must match one
 // of the user's orgs. With multiple orgs and no preference: in non-interactive
 // mode the org list is emitted, the session token is saved (so a re-run with
-// --org can finish without re-authenticating), and an &ExitError{Code: 3} is
+// --org can finish without re-authenticating), and an ExitAuth error is
 // returned; interactively, the user is prompted.
 func selectOrg(orgs []orgEntry, orgPref, sessionToken string, nonInteractive bool) (string, error) {
 	if orgPref != "" {
@@ -342,7 +368,7 @@ func selectOrg(orgs []orgEntry, orgPref, sessionToken string, nonInteractive boo
 				return "", fmt.Errorf("failed to save config: %w", err)
 			}
 			emitOrgChoices(orgs)
-			return "", &ExitError{Code: 3}
+			return "", &ExitError{Code: ExitAuth}
 		}
 		return promptOrg(orgs)
 	}
@@ -470,7 +496,8 @@ func openBrowser(url string) error {
 	case "linux":
 		cmd = exec.Command("xdg-open", url)
 	case "windows":
-		cmd = exec.Command("cmd", "/c", "start", url)
+		// The empty title keeps start from treating a quoted path as the window title.
+		cmd = exec.Command("cmd", "/c", "start", "", url)
 	default:
 		return fmt.Errorf("unsupported platform %s", runtime.GOOS)
 	}
@@ -123,7 +123,7 @@ func TestSelectOrg_SingleOrgNoPreference(t *testing.T) {
 
 // TestSelectOrg_MultiNonInteractiveExits verifies the non-blocking path for an
 // agent: with multiple orgs and no preference, selectOrg saves the session
-// token (so a re-run with --org can finish) and returns ExitError{Code: 3}
+// token (so a re-run with --org can finish) and returns ExitError{Code: ExitAuth}
 // instead of reading from stdin.
 func TestSelectOrg_MultiNonInteractiveExits(t *testing.T) {
 	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
@@ -135,8 +135,8 @@ func TestSelectOrg_MultiNonInteractiveExits(t *testing.T) {
 	if !errors.As(err, &exitErr) {
 		t.Fatalf("expected *ExitError, got %v", err)
 	}
-	if exitErr.Code != 3 {
-		t.Fatalf("expected exit code 3, got %d", exitErr.Code)
+	if exitErr.Code != ExitAuth {
+		t.Fatalf("expected exit code %d, got %d", ExitAuth, exitErr.Code)
 	}
 
 	cfg, loadErr := config.Load()
@@ -257,3 +257,25 @@ func TestEmitLoginComplete(t *testing.T) {
 		t.Fatalf("expected no output outside --json, got %q", silent)
 	}
 }
+
+func TestWriteHandoff_CodeOnly(t *testing.T) {
+	dc := &deviceCodeResponse{
+		UserCode:                "ABCD1234",
+		VerificationURI:         "https://example.test/device",
+		VerificationURIComplete: "https://example.test/device?user_code=ABCD1234",
+	}
+
+	var full strings.Builder
+	writeHandoff(&full, dc, "ABCD-1234")
+	if !strings.Contains(full.String(), dc.VerificationURIComplete) || !strings.Contains(full.String(), "ABCD-1234") {
+		t.Fatalf("unexpected handoff prompt: %q", full.String())
+	}
+
+	loginCodeOnly = true
+	defer func() { loginCodeOnly = false }()
+	var short strings.Builder
+	writeHandoff(&short, dc, "ABCD-1234")
+	if got, want := short.String(), "https://example.test/device\nABCD-1234\n"; got != want {
+		t.Fatalf("--code-only output = %q, want %q", got, want)
+	}
+}
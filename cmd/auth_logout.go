@@ -44,7 +44,7 @@ func runLogout(cmd *cobra.Command, args []string) error {
 
 	// Revoke session server-side (best effort)
 	mgmtURL := resolveManagementAPIURL()
-	httpClient := &http.Client{Timeout: 10 * time.Second}
+	httpClient := newHTTPClient(10 * time.Second)
 	req, err := http.NewRequest("POST", mgmtURL+"/v0/auth/sign-out", bytes.NewReader(nil))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not revoke session: %v\n", err)
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -53,16 +52,16 @@ type authCredentialReport struct {
 func init() {
 	authStatusCmd.SilenceUsage = true
 	authStatusCmd.Flags().BoolVar(&authStatusJSON, "json", false, "Output raw JSON authentication status")
+	addResultOutputFlag(authStatusCmd)
 	authCmd.AddCommand(authStatusCmd)
 }
 
 func runAuthStatus(cmd *cobra.Command, args []string) error {
 	report := inspectAuthStatus()
-	if authStatusJSON {
-		return jsonPrintTo(cmd.OutOrStdout(), report)
-	}
-	printAuthStatus(cmd, report)
-	return nil
+	return emitResultTo(cmd.OutOrStdout(), report, report, authStatusJSON, func() error {
+		printAuthStatus(cmd, report)
+		return nil
+	})
 }
 
 func inspectAuthStatus() authStatusReport {
@@ -157,7 +156,7 @@ func inspectSessionCredential(sessionToken, sessionOrgID string) authCredentialR
 
 	report.Validation = "ok"
 
-	httpClient := &http.Client{Timeout: 10 * time.Second}
+	httpClient := newHTTPClient(10 * time.Second)
 	session, err := getSession(httpClient, resolveManagementAPIURL(), sessionToken)
 	if err == nil {
 		report.UserEmail = strings.TrimSpace(session.User.Email)
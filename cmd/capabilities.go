@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// capabilitiesTTL is how long fetched server capabilities are trusted
+// before serverCapabilities asks again.
+const capabilitiesTTL = 24 * time.Hour
+
+// serverCapabilities returns the API's capabilities, from the user cache
+// when they were fetched within capabilitiesTTL. It returns nil when they
+// are unknown (offline, or an API without /v0/meta); callers then assume
+// every feature is available.
+func serverCapabilities(c *client.Client) *client.Capabilities {
+	var caps client.Capabilities
+	api := apiCacheKey(c.BaseURL, "")
+	if fetchedAt, ok := readAPICache("capabilities", api, &caps); ok && time.Since(fetchedAt) < capabilitiesTTL {
+		return &caps
+	}
+	fetched, err := c.Capabilities()
+	if err != nil {
+		return nil
+	}
+	writeAPICache("capabilities", api, fetched)
+	return fetched
+}
+
+// cachedCapabilities returns the capabilities an earlier command fetched
+// from baseURL, at any age, without making a request; nil when none are
+// cached.
+func cachedCapabilities(baseURL string) *client.Capabilities {
+	var caps client.Capabilities
+	if _, ok := readAPICache("capabilities", apiCacheKey(baseURL, ""), &caps); !ok {
+		return nil
+	}
+	return &caps
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestServerCapabilities_CachedAcrossClients(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"render_formats":["png","svg"],"max_file_bytes":26214400}`))
+	}))
+	defer server.Close()
+
+	if cachedCapabilities(server.URL) != nil {
+		t.Fatal("nothing should be cached yet")
+	}
+	caps := serverCapabilities(client.New(server.URL, "", "", true))
+	if caps == nil || caps.MaxFileBytes != 25<<20 {
+		t.Fatalf("unexpected capabilities: %+v", caps)
+	}
+	// A later command reads them from the user cache.
+	if caps := serverCapabilities(client.New(server.URL, "", "", true)); caps == nil || calls.Load() != 1 {
+		t.Fatalf("expected the cached capabilities, got %+v after %d requests", caps, calls.Load())
+	}
+	if caps := cachedCapabilities(server.URL + "/"); caps == nil || caps.MaxFileBytes != 25<<20 {
+		t.Fatalf("cachedCapabilities = %+v", caps)
+	}
+
+	rejected := &client.APIError{StatusCode: http.StatusBadRequest, Code: "invalid_format", Message: "invalid format"}
+	err := explainUnsupportedRenderFormat(client.New(server.URL, "", "", true), "webp", rejected)
+	if err == nil || err.Error() != "this Witan API does not render --format webp (supported: png, svg)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := explainUnsupportedRenderFormat(client.New(server.URL, "", "", true), "svg", rejected); err != rejected {
+		t.Fatalf("a supported format should keep the API error, got %v", err)
+	}
+
+	origAPIURL := apiURL
+	t.Cleanup(func() { apiURL = origAPIURL })
+	apiURL = server.URL
+	if formats, _ := completeRenderFormat(renderCmd, nil, ""); strings.Join(formats, " ") != "png svg pdf" {
+		t.Fatalf("completed formats = %v, want png svg pdf", formats)
+	}
+}
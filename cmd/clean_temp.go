@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanTempOlderThan time.Duration
+	cleanTempJSON      bool
+)
+
+var cleanTempCmd = &cobra.Command{
+	Use:   "clean-temp",
+	Short: "Remove temp files left by earlier runs",
+	Long: `Remove the per-run temp directories under $TMPDIR/witan-<uid>: exec
+images, renders written without -o, and downloads of earlier invocations.
+
+Each invocation that needs temp files gets its own directory,
+$TMPDIR/witan-<uid>/run-<id>, and prints it once when it leaves files
+there. Directories older than 7 days are removed automatically; clean-temp
+removes them all now, or those older than --older-than. Directories of
+invocations still running are kept. The upload cache is kept.
+
+Examples:
+  witan clean-temp
+  witan clean-temp --older-than 24h`,
+	Args: cobra.NoArgs,
+	RunE: runCleanTemp,
+}
+
+func init() {
+	cleanTempCmd.Flags().DurationVar(&cleanTempOlderThan, "older-than", 0, "Only remove run directories last modified more than this long ago, e.g. 24h")
+	cleanTempCmd.Flags().BoolVar(&cleanTempJSON, "json", false, "Output what was removed as JSON")
+	addResultOutputFlag(cleanTempCmd)
+	rootCmd.AddCommand(cleanTempCmd)
+}
+
+// cleanTempResult is clean-temp's --json output.
+type cleanTempResult struct {
+	Dir     string `json:"dir"`
+	Removed int    `json:"removed"`
+	Bytes   int64  `json:"bytes"`
+}
+
+func runCleanTemp(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if cleanTempOlderThan < 0 {
+		return fmt.Errorf("--older-than must not be negative")
+	}
+	root := runTempRoot()
+	removed, size, err := removeRunTempDirs(root, cleanTempOlderThan)
+	if err != nil {
+		return fmt.Errorf("removing temp files: %w", err)
+	}
+	result := cleanTempResult{Dir: root, Removed: removed, Bytes: size}
+	return emitResult(result, cleanTempJSON, func() error {
+		fmt.Printf("\u2713 Removed %s (%s) from %s\n", pluralize(removed, "run directory", "run directories"), formatBytes(size), root)
+		return nil
+	})
+}
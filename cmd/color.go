@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// noColor disables ANSI colors in human output (--no-color).
+var noColor bool
+
+// ANSI SGR codes used in human output.
+const (
+	ansiRed    = "31"
+	ansiGreen  = "32"
+	ansiYellow = "33"
+	ansiCyan   = "36"
+	ansiBold   = "1"
+)
+
+// stdoutIsTTY is swapped in tests.
+var stdoutIsTTY = func() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorEnabled reports whether human output on stdout should be colored:
+// only on a terminal, and never with --no-color, NO_COLOR
+// (https://no-color.org), or TERM=dumb.
+func colorEnabled() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return stdoutIsTTY()
+}
+
+// colorize wraps s in the given SGR codes when color is enabled.
+func colorize(s string, codes ...string) string {
+	if s == "" || len(codes) == 0 || !colorEnabled() {
+		return s
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + s + "\x1b[0m"
+}
+
+// colorSeverity colors s by lint severity: Error red, Warning yellow, Info
+// cyan.
+func colorSeverity(severity, s string) string {
+	switch severity {
+	case "Error":
+		return colorize(s, ansiRed)
+	case "Warning":
+		return colorize(s, ansiYellow)
+	default:
+		return colorize(s, ansiCyan)
+	}
+}
+
+// colorDiffSummary colors a render diff summary: green when nothing
+// changed, yellow otherwise.
+func colorDiffSummary(summary string) string {
+	if strings.HasSuffix(summary, "no changes") {
+		return colorize(summary, ansiGreen)
+	}
+	return colorize(summary, ansiYellow)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import "testing"
+
+func TestColorize_RespectsTTYAndNoColor(t *testing.T) {
+	origTTY := stdoutIsTTY
+	origNoColor := noColor
+	t.Cleanup(func() {
+		stdoutIsTTY = origTTY
+		noColor = origNoColor
+	})
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	noColor = false
+	stdoutIsTTY = func() bool { return false }
+	if got := colorize("D001", ansiYellow); got != "D001" {
+		t.Fatalf("non-TTY output must be plain, got %q", got)
+	}
+
+	stdoutIsTTY = func() bool { return true }
+	if got := colorSeverity("Error", "D004"); got != "\x1b[31mD004\x1b[0m" {
+		t.Fatalf("colorSeverity = %q", got)
+	}
+	if got := colorDiffSummary("diff: no changes"); got != "\x1b[32mdiff: no changes\x1b[0m" {
+		t.Fatalf("colorDiffSummary = %q", got)
+	}
+
+	noColor = true
+	if got := colorize("x", ansiRed); got != "x" {
+		t.Fatalf("--no-color must disable color, got %q", got)
+	}
+	noColor = false
+	t.Setenv("NO_COLOR", "1")
+	if got := colorize("x", ansiRed); got != "x" {
+		t.Fatalf("NO_COLOR must disable color, got %q", got)
+	}
+}
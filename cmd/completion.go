@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// workbookExtensions are offered when completing a workbook argument.
+var workbookExtensions = []string{"xlsx", "xlsm", "xls", "xlsb"}
+
+// completeWorkbookArg completes the single workbook argument of xlsx
+// commands with spreadsheet files.
+func completeWorkbookArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return workbookExtensions, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// renderFormats are the --format values of xlsx render.
+var renderFormats = []string{"png", "webp", "svg", "pdf"}
+
+// completeRenderFormat completes render --format values, leaving out those
+// the capabilities an earlier command cached say the API does not render.
+// pdf is always offered: render converts a PNG locally when the API cannot.
+func completeRenderFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	caps := cachedCapabilities(resolveAPIURL())
+	var formats []string
+	for _, f := range renderFormats {
+		if f == "pdf" || caps.SupportsRenderFormat(f) {
+			formats = append(formats, f)
+		}
+	}
+	return formats, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeWorkbookRange completes --range values with "Sheet!" prefixes for
+// the workbook already on the command line. Sheet names come from
+// Client.SheetNames, which caches them per file content, so only the first
+// completion for a given file makes an API call. Completion never prompts
+// or prints errors: if auth or the lookup fails, it offers nothing.
+func completeWorkbookRange(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 || strings.Contains(toComplete, "!") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	filePath := args[0]
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, err := newAPIClient(key, orgID).SheetNames(filePath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return sheetRangePrefixes(names, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeWorkbookThenRange completes a workbook argument followed by a
+// range or cell argument within it.
+func completeWorkbookThenRange(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeWorkbookArg(cmd, args, toComplete)
+	case 1:
+		return completeWorkbookRange(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// sheetRangePrefixes returns "Sheet!" completions for names matching the
+// typed prefix (case-insensitive). Names that need quoting are quoted, and a
+// typed leading quote matches them too.
+func sheetRangePrefixes(names []string, toComplete string) []string {
+	typed := strings.ToLower(strings.TrimPrefix(toComplete, "'"))
+	var out []string
+	for _, name := range names {
+		if strings.HasPrefix(strings.ToLower(name), typed) {
+			out = append(out, internal.QuoteSheetName(name)+"!")
+		}
+	}
+	return out
+}
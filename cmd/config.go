@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/config"
+)
+
+var configListJSON bool
+
+// settingSpec describes one key of settings.json.
+type settingSpec struct {
+	Key   string
+	Kind  string // bool, int, string, or duration
+	Flag  string // flag it sets a default for on any command that has it
+	Env   string // env var that takes precedence over the setting
+	Usage string
+}
+
+var settingSpecs = []settingSpec{
+	{Key: "api_url", Kind: "string", Flag: "api-url", Env: "WITAN_API_URL", Usage: "Witan API base URL"},
+	{Key: "stateless", Kind: "bool", Flag: "stateless", Env: "WITAN_STATELESS", Usage: "Send workbook bytes on every request"},
+	{Key: "json", Kind: "bool", Flag: "json", Usage: "Output JSON by default on xlsx, pptx, sheets, and read"},
+	{Key: "dpr", Kind: "int", Flag: "dpr", Usage: "Default --dpr for render commands (1-3)"},
+	{Key: "timeout", Kind: "duration", Flag: "timeout", Env: "WITAN_TIMEOUT", Usage: "Per-request timeout, e.g. 90s"},
+	{Key: "max_concurrency", Kind: "int", Flag: "max-concurrency", Env: "WITAN_MAX_CONCURRENCY", Usage: "Most API requests in flight at once"},
+	{Key: "max_attempts", Kind: "int", Usage: "Attempts per API request, including retries (default 3)"},
+	{Key: "fix_ext", Kind: "string", Flag: "fix-ext", Env: "WITAN_FIX_EXT", Usage: "When a workbook's extension does not match its content: rename, copy, or off"},
+	{Key: "render_dir", Kind: "string", Usage: "Directory for rendered images when -o is omitted (default: the run's temp dir)"},
+	{Key: "telemetry", Kind: "bool", Usage: "Send anonymous usage metrics: command, duration, result class (default off)"},
+}
+
+// Settings without a flag, applied in applySettings.
+var (
+	settingsMaxAttempts int
+	settingsRenderDir   string
+)
+
+// parseBoolSetting accepts on/off as well as the strconv.ParseBool forms.
+func parseBoolSetting(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Get and set defaults for frequently used flags",
+	Long: `Manage defaults stored in settings.json in the witan config directory
+(~/.config/witan, or $WITAN_CONFIG_DIR).
+
+Settings are defaults: a flag on the command line wins, then the matching
+environment variable, then the setting.
+
+Examples:
+  witan config set stateless true
+  witan config set dpr 2
+  witan config get api_url
+  witan config list
+  witan config unset timeout`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List known settings and their stored values",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+func init() {
+	configListCmd.Flags().BoolVar(&configListJSON, "json", false, "Output stored settings as JSON")
+	addResultOutputFlag(configListCmd)
+	for _, c := range []*cobra.Command{configGetCmd, configSetCmd, configUnsetCmd} {
+		c.ValidArgsFunction = completeSettingKey
+		configCmd.AddCommand(c)
+	}
+	configCmd.AddCommand(configListCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func completeSettingKey(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := make([]string, 0, len(settingSpecs))
+	for _, s := range settingSpecs {
+		keys = append(keys, s.Key+"\t"+s.Usage)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+func lookupSetting(key string) (settingSpec, error) {
+	for _, s := range settingSpecs {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+	keys := make([]string, 0, len(settingSpecs))
+	for _, s := range settingSpecs {
+		keys = append(keys, s.Key)
+	}
+	return settingSpec{}, fmt.Errorf("unknown setting %q (known: %s)", key, strings.Join(keys, ", "))
+}
+
+// parseSetting converts a command-line value to the JSON value stored for spec.
+func parseSetting(spec settingSpec, raw string) (any, error) {
+	switch spec.Kind {
+	case "bool":
+		b, err := parseBoolSetting(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be on/true or off/false, got %q", spec.Key, raw)
+		}
+		return b, nil
+	case "int":
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s must be a positive integer, got %q", spec.Key, raw)
+		}
+		if spec.Key == "dpr" && n > 3 {
+			return nil, fmt.Errorf("dpr must be 1-3, got %d", n)
+		}
+		return n, nil
+	case "duration":
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%s must be a positive duration like 90s or 5m, got %q", spec.Key, raw)
+		}
+		return raw, nil
+	}
+	if spec.Key == "fix_ext" && raw != fixExtRename && raw != fixExtCopy && raw != fixExtOff {
+		return nil, fmt.Errorf("fix_ext must be rename, copy, or off, got %q", raw)
+	}
+	return raw, nil
+}
+
+// formatSetting renders a stored value the way a flag would accept it.
+func formatSetting(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	spec, err := lookupSetting(args[0])
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	v, ok := settings[spec.Key]
+	if !ok {
+		return fmt.Errorf("%s is not set", spec.Key)
+	}
+	fmt.Println(formatSetting(v))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	spec, err := lookupSetting(args[0])
+	if err != nil {
+		return err
+	}
+	v, err := parseSetting(spec, args[1])
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	settings[spec.Key] = v
+	return config.SaveSettings(settings)
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	spec, err := lookupSetting(args[0])
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	if _, ok := settings[spec.Key]; !ok {
+		return nil
+	}
+	delete(settings, spec.Key)
+	return config.SaveSettings(settings)
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	return emitResult(settings, configListJSON, func() error {
+		return printConfigList(settings)
+	})
+}
+
+func printConfigList(settings config.Settings) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tDESCRIPTION")
+	for _, s := range settingSpecs {
+		value := "-"
+		if v, ok := settings[s.Key]; ok {
+			value = formatSetting(v)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, value, s.Usage)
+	}
+	var unknown []string
+	for k := range settings {
+		if _, err := lookupSetting(k); err != nil {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		fmt.Fprintf(w, "%s\t%s\t(unknown; ignored)\n", k, formatSetting(settings[k]))
+	}
+	return w.Flush()
+}
+
+// applySettings fills in flag defaults from settings.json for cmd. A setting
+// is skipped when its flag was given or its env var is set. The config
+// commands are left alone so a bad value can still be fixed with them.
+func applySettings(cmd *cobra.Command) error {
+	if isConfigCommand(cmd) {
+		return nil
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	for _, spec := range settingSpecs {
+		v, ok := settings[spec.Key]
+		if !ok {
+			continue
+		}
+		raw := formatSetting(v)
+		if _, err := parseSetting(spec, raw); err != nil {
+			return fmt.Errorf("settings.json: %w", err)
+		}
+		switch spec.Key {
+		case "max_attempts":
+			settingsMaxAttempts, _ = strconv.Atoi(raw)
+			continue
+		case "render_dir":
+			settingsRenderDir = raw
+			continue
+		case "telemetry":
+			settingsTelemetry, _ = parseBoolSetting(raw)
+			continue
+		case "json":
+			// auth login --json changes the login flow, not just the output.
+			if cmd.Parent() == authCmd {
+				continue
+			}
+		}
+		f := cmd.Flags().Lookup(spec.Flag)
+		if f == nil || f.Changed || (spec.Env != "" && os.Getenv(spec.Env) != "") {
+			continue
+		}
+		// Value.Set leaves Changed false, so the setting still reads as a default.
+		if err := f.Value.Set(raw); err != nil {
+			return fmt.Errorf("settings.json: %s: %w", spec.Key, err)
+		}
+	}
+	return nil
+}
+
+func isConfigCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == configCmd {
+			return true
+		}
+	}
+	return false
+}
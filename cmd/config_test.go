@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestConfigSetGetUnset(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WITAN_CONFIG_DIR", dir)
+
+	if err := runConfigSet(configSetCmd, []string{"dpr", "2"}); err != nil {
+		t.Fatalf("set dpr: %v", err)
+	}
+	if err := runConfigSet(configSetCmd, []string{"stateless", "true"}); err != nil {
+		t.Fatalf("set stateless: %v", err)
+	}
+	if err := runConfigSet(configSetCmd, []string{"dpr", "5"}); err == nil {
+		t.Fatal("expected error for dpr 5")
+	}
+	if err := runConfigSet(configSetCmd, []string{"colour", "red"}); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Fatalf("expected unknown setting error, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "settings.json"))
+	if err != nil {
+		t.Fatalf("reading settings.json: %v", err)
+	}
+	if !strings.Contains(string(data), `"dpr": 2`) || !strings.Contains(string(data), `"stateless": true`) {
+		t.Fatalf("unexpected settings.json: %s", data)
+	}
+
+	out, err := captureExecStdout(t, func() error { return runConfigGet(configGetCmd, []string{"dpr"}) })
+	if err != nil {
+		t.Fatalf("get dpr: %v", err)
+	}
+	if out != "2\n" {
+		t.Fatalf("get dpr = %q, want 2", out)
+	}
+
+	if err := runConfigUnset(configUnsetCmd, []string{"dpr"}); err != nil {
+		t.Fatalf("unset dpr: %v", err)
+	}
+	if err := runConfigGet(configGetCmd, []string{"dpr"}); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Fatalf("expected not set error, got %v", err)
+	}
+}
+
+func TestApplySettings_FlagsAndEnvWin(t *testing.T) {
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	t.Setenv("WITAN_API_URL", "https://env.example")
+	origMaxAttempts := settingsMaxAttempts
+	origRenderDir := settingsRenderDir
+	t.Cleanup(func() {
+		settingsMaxAttempts = origMaxAttempts
+		settingsRenderDir = origRenderDir
+	})
+
+	for _, kv := range [][2]string{
+		{"dpr", "2"},
+		{"json", "true"},
+		{"api_url", "https://settings.example"},
+		{"max_attempts", "5"},
+		{"render_dir", "/tmp/renders"},
+	} {
+		if err := runConfigSet(configSetCmd, kv[:]); err != nil {
+			t.Fatalf("set %s: %v", kv[0], err)
+		}
+	}
+
+	var dpr int
+	var useJSON bool
+	var url string
+	cmd := &cobra.Command{Use: "render"}
+	cmd.Flags().IntVar(&dpr, "dpr", 0, "")
+	cmd.Flags().BoolVar(&useJSON, "json", false, "")
+	cmd.Flags().StringVar(&url, "api-url", "", "")
+	if err := cmd.ParseFlags([]string{"--json=false"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applySettings(cmd); err != nil {
+		t.Fatalf("applySettings: %v", err)
+	}
+	if dpr != 2 {
+		t.Fatalf("dpr = %d, want 2 from settings", dpr)
+	}
+	if cmd.Flags().Changed("dpr") {
+		t.Fatal("setting should not mark --dpr as changed")
+	}
+	if useJSON {
+		t.Fatal("--json=false on the command line should win over the setting")
+	}
+	if url != "" {
+		t.Fatalf("api-url = %q, WITAN_API_URL should win over the setting", url)
+	}
+	if settingsMaxAttempts != 5 || settingsRenderDir != "/tmp/renders" {
+		t.Fatalf("got max_attempts=%d render_dir=%q", settingsMaxAttempts, settingsRenderDir)
+	}
+}
+
+func TestApplySettings_MalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WITAN_CONFIG_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "settings.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySettings(&cobra.Command{Use: "calc"}); err == nil {
+		t.Fatal("expected error for malformed settings.json")
+	}
+	if err := applySettings(configSetCmd); err != nil {
+		t.Fatalf("config commands should ignore a malformed settings.json, got %v", err)
+	}
+}
+
+func TestConfigList_OutputFlag(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WITAN_CONFIG_DIR", dir)
+	t.Cleanup(func() {
+		resetRootCommandForTest()
+		resetCommandFlag(configListCmd.Flags(), "json", "false")
+		resetCommandFlag(configListCmd.Flags(), "output", "")
+	})
+	if err := runConfigSet(configSetCmd, []string{"dpr", "2"}); err != nil {
+		t.Fatalf("set dpr: %v", err)
+	}
+
+	outPath := filepath.Join(dir, "settings-out.json")
+	rootCmd.SetArgs([]string{"config", "list", "--json", "-o", outPath})
+	stdout, err := captureExecStdout(t, rootCmd.Execute)
+	if err != nil {
+		t.Fatalf("config list --json -o: %v", err)
+	}
+	if stdout != "" {
+		t.Fatalf("stdout = %q, want the result only in the -o file", stdout)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading -o file: %v", err)
+	}
+	if !strings.Contains(string(data), `"dpr": 2`) {
+		t.Fatalf("unexpected -o file: %s", data)
+	}
+}
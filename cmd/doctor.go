@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/config"
+)
+
+// Clock skew beyond these limits is reported: tokens are checked against the
+// server's clock, so large skew shows up as spurious auth failures.
+const (
+	doctorSkewWarn = 30 * time.Second
+	doctorSkewFail = 5 * time.Minute
+)
+
+var (
+	doctorJSON bool
+
+	// doctorSetupErr is what the root PersistentPreRunE returned; doctor
+	// reports it as a check instead of failing before it runs.
+	doctorSetupErr error
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment, credentials, and API connectivity",
+	Long: `Check that witan can work in this environment and suggest fixes.
+
+Checks:
+  - flags, environment variables, and settings.json are valid
+  - the config, upload cache, and undo directories are writable
+  - credentials resolve and validate
+  - the API and management API are reachable (through any proxy or
+    --ca-cert) and the local clock agrees with the API's
+
+Prints the CLI, API, and Go versions. --json emits the full report, which
+is safe to attach to support tickets: credentials appear only as
+fingerprints. Exits 1 when any check fails; warnings do not.
+
+Examples:
+  witan doctor
+  witan doctor --json > witan-doctor.json`,
+	Args: cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		doctorSetupErr = rootCmd.PersistentPreRunE(cmd, args)
+		return nil
+	},
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output the report as JSON")
+	addResultOutputFlag(doctorCmd)
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctorCheck is one line of the doctor report.
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, warn, or fail
+	Detail string `json:"detail,omitempty"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+type doctorReport struct {
+	CLIVersion       string               `json:"cli_version"`
+	APIVersion       string               `json:"api_version,omitempty"`
+	Capabilities     *client.Capabilities `json:"capabilities,omitempty"`
+	GoVersion        string               `json:"go_version"`
+	Platform         string               `json:"platform"`
+	APIURL           string               `json:"api_url"`
+	ManagementAPIURL string               `json:"management_api_url"`
+	Checks           []doctorCheck        `json:"checks"`
+}
+
+func (r *doctorReport) add(name, status, detail, fix string) {
+	r.Checks = append(r.Checks, doctorCheck{Name: name, Status: status, Detail: detail, Fix: fix})
+}
+
+func (r *doctorReport) failed() bool {
+	for _, c := range r.Checks {
+		if c.Status == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	report := &doctorReport{
+		CLIVersion:       strings.TrimSpace(Version),
+		GoVersion:        runtime.Version(),
+		Platform:         runtime.GOOS + "/" + runtime.GOARCH,
+		APIURL:           resolveAPIURL(),
+		ManagementAPIURL: resolveManagementAPIURL(),
+	}
+
+	if doctorSetupErr != nil {
+		report.add("setup", "fail", doctorSetupErr.Error(), "fix the flag, environment variable, or setting named above")
+	} else {
+		report.add("setup", "ok", "flags, environment, and TLS settings are valid", "")
+	}
+	checkDoctorDirs(report)
+	checkDoctorSettings(report)
+
+	if resolveOffline() {
+		for _, name := range []string{"credentials", "api", "clock", "server features", "management api"} {
+			report.add(name, "warn", "skipped (offline)", "run without --offline or WITAN_OFFLINE to check connectivity")
+		}
+	} else {
+		checkDoctorAPI(report)
+		checkDoctorCapabilities(report)
+		checkDoctorManagementAPI(report)
+		checkDoctorCredentials(report)
+	}
+
+	if err := emitResult(report, doctorJSON, func() error {
+		printDoctorReport(report)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if report.failed() {
+		return &ExitError{Code: ExitFailure}
+	}
+	return nil
+}
+
+func checkDoctorDirs(report *doctorReport) {
+	if dir, err := config.Dir(); err != nil {
+		report.add("config dir", "fail", err.Error(), "set WITAN_CONFIG_DIR to a writable directory")
+	} else if err := probeDirWritable(dir); err != nil {
+		report.add("config dir", "fail", err.Error(), "make "+dir+" writable or set WITAN_CONFIG_DIR")
+	} else {
+		report.add("config dir", "ok", dir, "")
+	}
+
+	if dir := client.NewFileCache().Dir(); dir == "" {
+		report.add("upload cache", "warn", "in memory only; uploads are not reused between commands",
+			"make "+filepath.Join(os.TempDir(), "witan")+" writable or point TMPDIR at a writable directory")
+	} else {
+		report.add("upload cache", "ok", dir, "")
+	}
+
+	if dir, err := resolveUndoDir(); err != nil {
+		report.add("undo dir", "warn", err.Error(), "set WITAN_UNDO_DIR to a writable directory")
+	} else if err := probeDirWritable(dir); err != nil {
+		report.add("undo dir", "warn", err.Error(), "make "+dir+" writable or set WITAN_UNDO_DIR")
+	} else {
+		report.add("undo dir", "ok", dir, "")
+	}
+}
+
+// probeDirWritable creates dir if needed and writes and removes a file in it.
+func probeDirWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func checkDoctorSettings(report *doctorReport) {
+	path, _ := config.SettingsPath()
+	settings, err := config.LoadSettings()
+	if err != nil {
+		report.add("settings", "fail", err.Error(), "fix or remove "+path)
+		return
+	}
+	for key, v := range settings {
+		spec, err := lookupSetting(key)
+		if err != nil {
+			report.add("settings", "warn", err.Error(), "witan config unset "+key)
+			return
+		}
+		if _, err := parseSetting(spec, formatSetting(v)); err != nil {
+			report.add("settings", "fail", err.Error(), "witan config set "+key+" <value>")
+			return
+		}
+	}
+	if len(settings) == 0 {
+		report.add("settings", "ok", "none", "")
+		return
+	}
+	report.add("settings", "ok", fmt.Sprintf("%s in %s", pluralize(len(settings), "setting", "settings"), path), "")
+}
+
+func checkDoctorCredentials(report *doctorReport) {
+	status := inspectAuthStatus()
+	active := status.ActiveAuth
+	detail := humanAuthType(active.Type)
+	if active.Source != "" {
+		detail += " from " + active.Source
+	}
+	if active.OrgID != "" {
+		detail += ", org " + active.OrgID
+	}
+	switch {
+	case status.Status == "authenticated":
+		report.add("credentials", "ok", detail, "")
+	case active.Type == "none" && status.Error == "":
+		report.add("credentials", "warn", "none; requests run stateless", status.Hint)
+	case active.Validation == "unknown":
+		report.add("credentials", "warn", detail+": could not validate: "+active.ValidationError, "re-run once the API is reachable")
+	default:
+		if active.ValidationError != "" {
+			detail += ": " + active.ValidationError
+		} else if status.Error != "" {
+			detail += ": " + status.Error
+		}
+		report.add("credentials", "fail", detail, status.Hint)
+	}
+}
+
+// doctorProbe is the outcome of one GET against an endpoint.
+type doctorProbe struct {
+	StatusCode int
+	RTT        time.Duration
+	ServerTime time.Time
+	APIVersion string // from a /health body, when it has one
+}
+
+func probeEndpoint(url string) (*doctorProbe, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setCLIUserAgent(req)
+	start := time.Now()
+	resp, err := newHTTPClient(versionHealthRequestTimeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	probe := &doctorProbe{StatusCode: resp.StatusCode, RTT: time.Since(start)}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		// The Date header is stamped mid-request; compare it with the midpoint.
+		probe.ServerTime = date.Add(-probe.RTT / 2)
+	}
+	var health healthResponse
+	if json.NewDecoder(resp.Body).Decode(&health) == nil {
+		probe.APIVersion = health.Meta.Version
+	}
+	return probe, nil
+}
+
+func checkDoctorAPI(report *doctorReport) {
+	const fix = "check the network and proxy (HTTPS_PROXY), --api-url or WITAN_API_URL, and --ca-cert"
+	probe, err := probeEndpoint(strings.TrimRight(report.APIURL, "/") + "/health")
+	if err != nil {
+		report.add("api", "fail", err.Error(), fix)
+		report.add("clock", "warn", "skipped (API unreachable)", "")
+		return
+	}
+	if probe.StatusCode != http.StatusOK {
+		report.add("api", "fail", fmt.Sprintf("%s/health returned HTTP %d", report.APIURL, probe.StatusCode), fix)
+	} else {
+		report.APIVersion = probe.APIVersion
+		report.add("api", "ok", fmt.Sprintf("reachable in %s", probe.RTT.Round(time.Millisecond)), "")
+	}
+
+	if probe.ServerTime.IsZero() {
+		report.add("clock", "warn", "API response had no Date header", "")
+		return
+	}
+	skew := time.Since(probe.ServerTime).Round(time.Second)
+	abs, direction := skew, "ahead of"
+	if skew < 0 {
+		abs, direction = -skew, "behind"
+	}
+	detail := fmt.Sprintf("local clock is %s %s the API", abs, direction)
+	switch {
+	case abs > doctorSkewFail:
+		report.add("clock", "fail", detail, "sync the system clock (enable NTP)")
+	case abs > doctorSkewWarn:
+		report.add("clock", "warn", detail, "sync the system clock (enable NTP)")
+	default:
+		report.add("clock", "ok", detail, "")
+	}
+}
+
+func checkDoctorCapabilities(report *doctorReport) {
+	c := newAPIClientMode("", "", true)
+	caps, err := c.Capabilities()
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		report.add("server features", "ok", "not reported by this API; all features assumed", "")
+		return
+	}
+	if err != nil {
+		report.add("server features", "warn", err.Error(), "")
+		return
+	}
+	writeAPICache("capabilities", apiCacheKey(c.BaseURL, ""), caps)
+	report.Capabilities = caps
+
+	var parts []string
+	if len(caps.Features) > 0 {
+		parts = append(parts, strings.Join(caps.Features, ", "))
+	}
+	if len(caps.RenderFormats) > 0 {
+		parts = append(parts, "render "+strings.Join(caps.RenderFormats, "/"))
+	}
+	if caps.MaxFileBytes > 0 {
+		parts = append(parts, "files up to "+formatBytes(caps.MaxFileBytes))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "none listed; all features assumed")
+	}
+	report.add("server features", "ok", strings.Join(parts, "; "), "")
+}
+
+func checkDoctorManagementAPI(report *doctorReport) {
+	// Any HTTP response shows the host is reachable; only sign-in and session
+	// credentials use it, so an outage is a warning.
+	probe, err := probeEndpoint(strings.TrimRight(report.ManagementAPIURL, "/") + "/health")
+	if err != nil {
+		report.add("management api", "warn", err.Error(),
+			"needed for `witan auth login` and saved sessions; check the network, proxy, or WITAN_MANAGEMENT_API_URL")
+		return
+	}
+	report.add("management api", "ok", fmt.Sprintf("reachable in %s", probe.RTT.Round(time.Millisecond)), "")
+}
+
+func printDoctorReport(report *doctorReport) {
+	fmt.Printf("witan %s (%s, %s)\n", report.CLIVersion, report.GoVersion, report.Platform)
+	apiVersion := report.APIVersion
+	if apiVersion == "" {
+		apiVersion = "unavailable"
+	}
+	fmt.Printf("API: %s (version %s)\n", report.APIURL, apiVersion)
+	fmt.Printf("Management API: %s\n\n", report.ManagementAPIURL)
+	for _, c := range report.Checks {
+		var status string
+		switch c.Status {
+		case "ok":
+			status = colorize("ok  ", ansiGreen)
+		case "warn":
+			status = colorize("warn", ansiYellow)
+		default:
+			status = colorize("FAIL", ansiRed, ansiBold)
+		}
+		fmt.Printf("%s  %-15s %s\n", status, c.Name, c.Detail)
+		if c.Fix != "" {
+			fmt.Printf("      %-15s fix: %s\n", "", c.Fix)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func runDoctorJSON(t *testing.T, serverTime time.Time) (doctorReport, error) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !serverTime.IsZero() {
+			w.Header().Set("Date", serverTime.UTC().Format(http.TimeFormat))
+		}
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"meta":{"VERSION":"2026.10.1"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origOffline := offline
+	origDoctorJSON := doctorJSON
+	origSetupErr := doctorSetupErr
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		offline = origOffline
+		doctorJSON = origDoctorJSON
+		doctorSetupErr = origSetupErr
+	})
+	apiKey = ""
+	apiURL = srv.URL
+	offline = false
+	doctorJSON = true
+	doctorSetupErr = nil
+	t.Setenv("WITAN_API_KEY", "")
+	t.Setenv("WITAN_OFFLINE", "")
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	t.Setenv("WITAN_MANAGEMENT_API_URL", srv.URL)
+
+	var report doctorReport
+	out, runErr := captureExecStdout(t, func() error { return runDoctor(doctorCmd, nil) })
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decoding report: %v\n%s", err, out)
+	}
+	return report, runErr
+}
+
+func doctorStatus(report doctorReport, name string) string {
+	for _, c := range report.Checks {
+		if c.Name == name {
+			return c.Status
+		}
+	}
+	return ""
+}
+
+func TestRunDoctor_HealthyWithoutCredentials(t *testing.T) {
+	report, err := runDoctorJSON(t, time.Time{})
+	if err != nil {
+		t.Fatalf("runDoctor: %v", err)
+	}
+	if report.APIVersion != "2026.10.1" {
+		t.Fatalf("api_version = %q", report.APIVersion)
+	}
+	for name, want := range map[string]string{
+		"setup":           "ok",
+		"config dir":      "ok",
+		"settings":        "ok",
+		"api":             "ok",
+		"clock":           "ok",
+		"server features": "ok",
+		"management api":  "ok",
+		"credentials":     "warn",
+	} {
+		if got := doctorStatus(report, name); got != want {
+			t.Errorf("%s: status %q, want %q (%+v)", name, got, want, report.Checks)
+		}
+	}
+}
+
+func TestRunDoctor_ClockSkewFails(t *testing.T) {
+	report, err := runDoctorJSON(t, time.Now().Add(-10*time.Minute))
+	if exitErr, ok := err.(*ExitError); !ok || exitErr.Code != ExitFailure {
+		t.Fatalf("expected exit %d, got %v", ExitFailure, err)
+	}
+	if got := doctorStatus(report, "clock"); got != "fail" {
+		t.Fatalf("clock status = %q, want fail", got)
+	}
+}
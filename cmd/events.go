@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// uploadEventStep is the percentage between upload_progress events.
+const uploadEventStep = 10
+
+var (
+	// ndjsonOutput streams progress events and the result to stdout as
+	// newline-delimited JSON (--ndjson).
+	ndjsonOutput bool
+
+	// Uploads and retries are reported from request goroutines.
+	eventMu sync.Mutex
+)
+
+// emitEvent writes one NDJSON event line to stdout when --ndjson is set.
+// Every event has "type" and "time"; fields adds the rest.
+func emitEvent(typ string, fields map[string]any) {
+	if !ndjsonOutput {
+		return
+	}
+	ev := map[string]any{
+		"type": typ,
+		"time": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	for k, v := range fields {
+		ev[k] = v
+	}
+	eventMu.Lock()
+	defer eventMu.Unlock()
+	_ = jsonlPrint(ev)
+}
+
+// uploadEvents returns an upload progress callback that emits
+// upload_started, upload_progress every uploadEventStep percent, and
+// upload_done. A retried upload starts over with a new upload_started.
+func uploadEvents() client.UploadProgressFunc {
+	type state struct{ sent, step int64 }
+	var mu sync.Mutex
+	files := map[string]*state{}
+	return func(filename string, sent, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		st := files[filename]
+		if st == nil || sent < st.sent {
+			st = &state{}
+			files[filename] = st
+			emitEvent("upload_started", map[string]any{"file": filename, "bytes": total})
+		}
+		st.sent = sent
+		if sent >= total {
+			delete(files, filename)
+			emitEvent("upload_done", map[string]any{"file": filename, "bytes": total})
+			return
+		}
+		if step := sent * 100 / total / uploadEventStep; step > st.step {
+			st.step = step
+			emitEvent("upload_progress", map[string]any{"file": filename, "sent": sent, "bytes": total})
+		}
+	}
+}
+
+// withRetryEvents wraps a request logger so retry waits also emit a retry
+// event. It returns logger unchanged when --ndjson is not set.
+func withRetryEvents(logger func(client.RequestLogEvent)) func(client.RequestLogEvent) {
+	if !ndjsonOutput {
+		return logger
+	}
+	return func(ev client.RequestLogEvent) {
+		if ev.Event == client.LogEventRetryWait {
+			emitEvent("retry", map[string]any{
+				"method":  ev.Method,
+				"url":     ev.URL,
+				"attempt": ev.Attempt,
+				"wait_ms": ev.WaitMS,
+			})
+		}
+		if logger != nil {
+			logger(ev)
+		}
+	}
+}
+
+// emitErrorEvent reports a failed command as a final error event, since an
+// orchestrator reading the stream may not see stderr.
+func emitErrorEvent(err error) {
+	if err == nil {
+		return
+	}
+	fields := map[string]any{"class": resultClass(err)}
+	if msg := err.Error(); msg != "" {
+		fields["message"] = msg
+	}
+	emitEvent("error", fields)
+}
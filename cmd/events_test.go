@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func decodeEvents(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("line is not JSON: %q", line)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func eventTypes(events []map[string]any) []string {
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev["type"].(string))
+	}
+	return types
+}
+
+func TestNDJSONEvents(t *testing.T) {
+	origNDJSON := ndjsonOutput
+	origOutput := resultOutputPath
+	t.Cleanup(func() {
+		ndjsonOutput = origNDJSON
+		resultOutputPath = origOutput
+	})
+	ndjsonOutput = true
+	resultOutputPath = ""
+
+	out, err := captureExecStdout(t, func() error {
+		progress := uploadEvents()
+		for _, sent := range []int64{0, 5, 25, 26, 100} {
+			progress("book.xlsx", sent, 100)
+		}
+		withRetryEvents(nil)(client.RequestLogEvent{Event: client.LogEventRetryWait, Method: "POST", URL: "https://api/v0/files", Attempt: 1, WaitMS: 500})
+		withRetryEvents(nil)(client.RequestLogEvent{Event: client.LogEventResponse, Status: 200})
+		emitEvent("exec_started", map[string]any{"file": "book.xlsx"})
+		return emitResult(map[string]any{"ok": true}, false, func() error {
+			t.Fatal("the human summary must not be printed with --ndjson")
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := decodeEvents(t, out)
+	got := strings.Join(eventTypes(events), ",")
+	want := "upload_started,upload_progress,upload_done,retry,exec_started,result"
+	if got != want {
+		t.Fatalf("events = %s\nwant     %s\n%s", got, want, out)
+	}
+	if events[1]["sent"] != float64(25) || events[3]["wait_ms"] != float64(500) {
+		t.Fatalf("unexpected event fields:\n%s", out)
+	}
+	if result, _ := events[5]["result"].(map[string]any); result["ok"] != true {
+		t.Fatalf("unexpected result event: %v", events[5])
+	}
+	if !resultShownOnStdout(false) {
+		t.Fatal("the result event counts as shown on stdout")
+	}
+
+	out, _ = captureExecStdout(t, func() error {
+		emitErrorEvent(&ExitError{Code: ExitFindings})
+		emitErrorEvent(errors.New("boom"))
+		return nil
+	})
+	events = decodeEvents(t, out)
+	if events[0]["class"] != "findings" || events[0]["message"] != nil || events[1]["message"] != "boom" {
+		t.Fatalf("unexpected error events:\n%s", out)
+	}
+}
+
+func TestEmitEvent_OffByDefault(t *testing.T) {
+	origNDJSON := ndjsonOutput
+	t.Cleanup(func() { ndjsonOutput = origNDJSON })
+	ndjsonOutput = false
+
+	out, _ := captureExecStdout(t, func() error {
+		emitEvent("exec_started", nil)
+		uploadEvents()("book.xlsx", 100, 100)
+		return nil
+	})
+	if out != "" {
+		t.Fatalf("expected no events without --ndjson, got %q", out)
+	}
+}
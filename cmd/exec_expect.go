@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// execExpectation is one --expect assertion: a path into the exec response
+// envelope, optionally piped through length, compared to a JSON literal.
+// With no operator the path must be truthy (present, not null/false/0/"").
+type execExpectation struct {
+	source string
+	path   []any // string keys and int indexes
+	length bool
+	op     string
+	value  any
+}
+
+var execExpectOps = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// parseExecExpectation parses expressions such as
+//
+//	.ok
+//	.result.total >= 1000
+//	.result.rows[0]["Net Income"] != null
+//	.result.errors | length == 0
+func parseExecExpectation(src string) (*execExpectation, error) {
+	e := &execExpectation{source: src}
+	rest := strings.TrimSpace(src)
+	if !strings.HasPrefix(rest, ".") {
+		return nil, fmt.Errorf("invalid --expect %q: must start with a path such as .result", src)
+	}
+
+	path, rest, err := parseExecExpectPath(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --expect %q: %w", src, err)
+	}
+	e.path = path
+
+	rest = strings.TrimSpace(rest)
+	if after, ok := strings.CutPrefix(rest, "|"); ok {
+		after = strings.TrimSpace(after)
+		if !strings.HasPrefix(after, "length") {
+			return nil, fmt.Errorf("invalid --expect %q: only \"| length\" is supported", src)
+		}
+		e.length = true
+		rest = strings.TrimSpace(strings.TrimPrefix(after, "length"))
+	}
+	if rest == "" {
+		return e, nil
+	}
+
+	for _, op := range execExpectOps {
+		if after, ok := strings.CutPrefix(rest, op); ok {
+			e.op = op
+			rest = strings.TrimSpace(after)
+			break
+		}
+	}
+	if e.op == "" {
+		return nil, fmt.Errorf("invalid --expect %q: expected one of %s after the path", src, strings.Join(execExpectOps, " "))
+	}
+	if err := json.Unmarshal([]byte(rest), &e.value); err != nil {
+		return nil, fmt.Errorf("invalid --expect %q: right-hand side must be a JSON value (quote strings): %w", src, err)
+	}
+	return e, nil
+}
+
+// parseExecExpectPath consumes a path of .key, ["key"], and [n] segments and
+// returns the segments and the unparsed remainder.
+func parseExecExpectPath(s string) ([]any, string, error) {
+	var path []any
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := 0
+			for end < len(s) && (s[end] == '_' || s[end] == '-' || isAlnumByte(s[end])) {
+				end++
+			}
+			if end == 0 {
+				// A bare "." refers to the whole envelope.
+				if len(path) == 0 {
+					continue
+				}
+				return nil, "", fmt.Errorf("empty key after '.'")
+			}
+			path = append(path, s[:end])
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, "", fmt.Errorf("unclosed '['")
+			}
+			inner := strings.TrimSpace(s[1:end])
+			if strings.HasPrefix(inner, `"`) {
+				var key string
+				if err := json.Unmarshal([]byte(inner), &key); err != nil {
+					return nil, "", fmt.Errorf("invalid key %s", inner)
+				}
+				path = append(path, key)
+			} else {
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid index [%s]", inner)
+				}
+				path = append(path, idx)
+			}
+			s = s[end+1:]
+		default:
+			return path, s, nil
+		}
+	}
+	return path, "", nil
+}
+
+func isAlnumByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// check evaluates the expectation against a decoded envelope and returns a
+// failure message, or "" when it holds.
+func (e *execExpectation) check(envelope any) string {
+	v, found := lookupExecExpectPath(envelope, e.path)
+	if e.length {
+		switch t := v.(type) {
+		case []any:
+			v = float64(len(t))
+		case map[string]any:
+			v = float64(len(t))
+		case string:
+			v = float64(len([]rune(t)))
+		case nil:
+			v = float64(0)
+		default:
+			return fmt.Sprintf("%s: cannot take length of %s", e.source, formatExpectValue(v))
+		}
+		found = true
+	}
+
+	if e.op == "" {
+		if !found || !execExpectTruthy(v) {
+			return fmt.Sprintf("%s: got %s", e.source, formatExpectFound(v, found))
+		}
+		return ""
+	}
+
+	var ok bool
+	switch e.op {
+	case "==":
+		ok = found && reflect.DeepEqual(v, e.value)
+	case "!=":
+		ok = !found || !reflect.DeepEqual(v, e.value)
+	default:
+		ok = found && compareExpectValues(v, e.value, e.op)
+	}
+	if !ok {
+		return fmt.Sprintf("%s: got %s", e.source, formatExpectFound(v, found))
+	}
+	return ""
+}
+
+func lookupExecExpectPath(v any, path []any) (any, bool) {
+	for _, seg := range path {
+		switch key := seg.(type) {
+		case string:
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := v.([]any)
+			if !ok {
+				return nil, false
+			}
+			if key < 0 {
+				key += len(arr)
+			}
+			if key < 0 || key >= len(arr) {
+				return nil, false
+			}
+			v = arr[key]
+		}
+	}
+	return v, true
+}
+
+// compareExpectValues orders two numbers or two strings.
+func compareExpectValues(a, b any, op string) bool {
+	var cmp int
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case av < bv:
+			cmp = -1
+		case av > bv:
+			cmp = 1
+		}
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(av, bv)
+	default:
+		return false
+	}
+	switch op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	}
+	return false
+}
+
+func execExpectTruthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	}
+	return true
+}
+
+func formatExpectFound(v any, found bool) string {
+	if !found {
+		return "(missing)"
+	}
+	return formatExpectValue(v)
+}
+
+func formatExpectValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const maxLen = 200
+	if len(b) > maxLen {
+		return string(b[:maxLen]) + "…"
+	}
+	return string(b)
+}
+
+// checkExecExpectations evaluates --expect and --expect-json against the
+// response envelope (the same shape --json prints) and returns one message
+// per failed assertion.
+func checkExecExpectations(envelope any, exprs []string, expectJSON string) ([]string, error) {
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("encoding exec response: %w", err)
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decoding exec response: %w", err)
+	}
+
+	var failures []string
+	for _, src := range exprs {
+		e, err := parseExecExpectation(src)
+		if err != nil {
+			return nil, err
+		}
+		if msg := e.check(doc); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	if expectJSON != "" {
+		var want any
+		if err := json.Unmarshal([]byte(expectJSON), &want); err != nil {
+			return nil, fmt.Errorf("invalid --expect-json: %w", err)
+		}
+		got, found := lookupExecExpectPath(doc, []any{"result"})
+		if !found || !reflect.DeepEqual(got, want) {
+			failures = append(failures, fmt.Sprintf("--expect-json: result is %s", formatExpectFound(got, found)))
+		}
+	}
+	return failures, nil
+}
+
+// validateExecExpectations reports malformed --expect/--expect-json values
+// before any API call is made.
+func validateExecExpectations(exprs []string, expectJSON string) error {
+	for _, src := range exprs {
+		if _, err := parseExecExpectation(src); err != nil {
+			return err
+		}
+	}
+	if expectJSON != "" && !json.Valid([]byte(expectJSON)) {
+		return fmt.Errorf("invalid --expect-json: not valid JSON")
+	}
+	return nil
+}
+
+// enforceExecExpectations checks a successful exec response against the
+// assertions, prints each failure to stderr, and returns ExitAssertion if
+// any failed.
+func enforceExecExpectations(result *client.ExecResponse, exprs []string, expectJSON string) error {
+	if len(exprs) == 0 && expectJSON == "" {
+		return nil
+	}
+	result.File = nil
+	failures, err := checkExecExpectations(result, exprs, expectJSON)
+	if err != nil {
+		return err
+	}
+	for _, msg := range failures {
+		fmt.Fprintf(os.Stderr, "expectation failed: %s\n", msg)
+	}
+	if len(failures) > 0 {
+		return &ExitError{Code: ExitAssertion}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExecExpectation_Check(t *testing.T) {
+	var envelope any
+	if err := json.Unmarshal([]byte(`{
+		"ok": true,
+		"writes_detected": false,
+		"result": {
+			"total": 1250.5,
+			"status": "balanced",
+			"errors": [],
+			"rows": [{"Net Income": 10}, {"Net Income": null}]
+		}
+	}`), &envelope); err != nil {
+		t.Fatalf("decoding envelope: %v", err)
+	}
+
+	cases := []struct {
+		expr string
+		pass bool
+	}{
+		{`.ok`, true},
+		{`.writes_detected`, false},
+		{`.writes_detected == false`, true},
+		{`.result.total >= 1000`, true},
+		{`.result.total < 1000`, false},
+		{`.result.status == "balanced"`, true},
+		{`.result.status != "balanced"`, false},
+		{`.result.errors | length == 0`, true},
+		{`.result.rows | length > 2`, false},
+		{`.result.rows[0]["Net Income"] == 10`, true},
+		{`.result.rows[-1]["Net Income"] != null`, false},
+		{`.result.missing`, false},
+		{`.result.missing != 1`, true},
+		{`.result.status > 1`, false},
+	}
+	for _, tc := range cases {
+		e, err := parseExecExpectation(tc.expr)
+		if err != nil {
+			t.Fatalf("parseExecExpectation(%q): %v", tc.expr, err)
+		}
+		msg := e.check(envelope)
+		if (msg == "") != tc.pass {
+			t.Errorf("%s: pass=%v, want %v (msg %q)", tc.expr, msg == "", tc.pass, msg)
+		}
+	}
+}
+
+func TestParseExecExpectation_Errors(t *testing.T) {
+	cases := map[string]string{
+		`result.total`:          "must start with a path",
+		`.result[0`:             "unclosed '['",
+		`.result | keys`:        `only "| length"`,
+		`.result ~ 1`:           "expected one of",
+		`.result.status == foo`: "must be a JSON value",
+	}
+	for expr, want := range cases {
+		_, err := parseExecExpectation(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseExecExpectation(%q) = %v, want error containing %q", expr, err, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// Image modes for the images an exec script returns.
+const (
+	imageModeFiles   = "files"
+	imageModeInline  = "inline"
+	imageModeDiscard = "discard"
+)
+
+// execImageMode is --image-mode; empty picks inline for JSON results and
+// files for the human summary.
+var execImageMode string
+
+// addImageModeFlag registers --image-mode on an exec command.
+func addImageModeFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&execImageMode, "image-mode", "", "Return script images as files (temp files, listed by path), inline (base64 data URLs), or discard (default: inline with --json, files otherwise)")
+}
+
+// validateImageMode rejects an unknown --image-mode before any request is made.
+func validateImageMode() error {
+	switch execImageMode {
+	case "", imageModeFiles, imageModeInline, imageModeDiscard:
+		return nil
+	}
+	return fmt.Errorf("--image-mode must be files, inline, or discard")
+}
+
+func resolveImageMode(useJSON bool) string {
+	if execImageMode != "" {
+		return execImageMode
+	}
+	if useJSON || ndjsonOutput {
+		return imageModeInline
+	}
+	return imageModeFiles
+}
+
+// applyImageMode rewrites result.Images for the image mode: files replaces
+// each data URL with the path it was written to, discard drops them, and
+// inline leaves them as they are.
+func applyImageMode(result *client.ExecResponse, useJSON bool, prefix string) error {
+	switch resolveImageMode(useJSON) {
+	case imageModeDiscard:
+		result.Images = nil
+	case imageModeFiles:
+		for i, img := range result.Images {
+			path, err := writeExecImage(img, prefix)
+			if err != nil {
+				return err
+			}
+			result.Images[i] = path
+		}
+	}
+	return nil
+}
+
+// writeExecImage writes a data URL image to the render_dir setting or this
+// run's temp dir. The file is named by a hash of its content, so a script
+// that returns the same chart more than once reuses one file instead of
+// writing a new copy each time.
+func writeExecImage(dataURL, prefix string) (string, error) {
+	b64 := dataURL
+	if _, after, ok := strings.Cut(dataURL, ","); ok {
+		b64 = after
+	}
+	decoded, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return "", fmt.Errorf("decoding exec image: %w", err)
+	}
+	dir := settingsRenderDir
+	if dir == "" {
+		if dir, err = runTempDir(); err != nil {
+			return "", err
+		}
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating render_dir: %w", err)
+	}
+	sum := sha256.Sum256(decoded)
+	path := filepath.Join(dir, prefix+hex.EncodeToString(sum[:8])+execImageExt(dataURL))
+	// render_dir is shared, so reuse a file only if it holds these bytes.
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, decoded) {
+		return path, nil
+	}
+	f, err := os.CreateTemp(dir, prefix+"*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("creating temp image file: %w", err)
+	}
+	tmpPath := f.Name()
+	if _, err := f.Write(decoded); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("writing exec image: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("closing exec image file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("writing exec image: %w", err)
+	}
+	return path, nil
+}
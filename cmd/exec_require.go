@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// execLibrariesTTL is how long a fetched library list is trusted.
+const execLibrariesTTL = 24 * time.Hour
+
+// parseExecRequire normalizes --require values: comma-separated names are
+// split, blanks dropped, and duplicates removed.
+func parseExecRequire(values []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateExecRequire rejects library names the API does not offer. The list
+// is cached for a day; an unknown name refreshes it once in case a library
+// was added. When no current list can be had (offline, or an API without
+// the endpoint), the names are sent unchecked and the server decides.
+func validateExecRequire(c *client.Client, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	api := apiCacheKey(c.BaseURL, c.OrgID)
+	var libs []client.ExecLibrary
+	fetchedAt, ok := readAPICache("exec-libraries", api, &libs)
+	fresh := ok && time.Since(fetchedAt) < execLibrariesTTL
+	if !fresh || len(unknownExecLibraries(names, libs)) > 0 {
+		if fetched, err := c.ExecLibraries(); err == nil {
+			writeAPICache("exec-libraries", api, fetched)
+			libs, fresh = fetched, true
+		}
+	}
+	if !fresh {
+		return nil
+	}
+	unknown := unknownExecLibraries(names, libs)
+	if len(unknown) == 0 {
+		return nil
+	}
+	available := make([]string, 0, len(libs))
+	for _, lib := range libs {
+		available = append(available, lib.Name)
+	}
+	sort.Strings(available)
+	list := "none"
+	if len(available) > 0 {
+		list = strings.Join(available, ", ")
+	}
+	noun := "library"
+	if len(unknown) > 1 {
+		noun = "libraries"
+	}
+	return fmt.Errorf("unknown --require %s %s (available: %s)", noun, strings.Join(unknown, ", "), list)
+}
+
+func unknownExecLibraries(names []string, libs []client.ExecLibrary) []string {
+	known := map[string]bool{}
+	for _, lib := range libs {
+		known[lib.Name] = true
+	}
+	var unknown []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestParseExecRequire(t *testing.T) {
+	got := strings.Join(parseExecRequire([]string{"stats, dates", "stats", " ", "lodash"}), "|")
+	if got != "stats|dates|lodash" {
+		t.Fatalf("parseExecRequire = %q", got)
+	}
+}
+
+func TestValidateExecRequire(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var fetches atomic.Int32
+	libraries := `{"libraries":[{"name":"stats"},{"name":"dates"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec/libraries" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(libraries))
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, "test-key", "", true)
+
+	if err := validateExecRequire(c, []string{"stats"}); err != nil {
+		t.Fatalf("known library rejected: %v", err)
+	}
+	if err := validateExecRequire(c, []string{"dates", "stats"}); err != nil {
+		t.Fatalf("known libraries rejected: %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected the list to be fetched once and cached, got %d fetches", n)
+	}
+
+	// An unknown name refreshes the list before failing.
+	err := validateExecRequire(c, []string{"stats", "lodahs"})
+	if err == nil || err.Error() != "unknown --require library lodahs (available: dates, stats)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("expected an unknown name to refresh the list, got %d fetches", n)
+	}
+	libraries = `{"libraries":[{"name":"stats"},{"name":"dates"},{"name":"lodahs"}]}`
+	if err := validateExecRequire(c, []string{"lodahs"}); err != nil {
+		t.Fatalf("a newly added library should be accepted after a refresh: %v", err)
+	}
+}
+
+func TestValidateExecRequire_UncheckedWithoutList(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	c := client.New(server.URL, "test-key", "", true)
+	if err := validateExecRequire(c, []string{"anything"}); err != nil {
+		t.Fatalf("names should pass unchecked when the list is unavailable: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// execStarterScript is what exec --init prints: a commented script that
+// runs as-is against any workbook and shows the pieces most scripts need.
+const execStarterScript = `// Starter script for witan xlsx exec.
+//
+// Run it with:
+//   witan xlsx exec model.xlsx --script script.js --input-json '{"sheet":"Summary"}'
+//
+// In scope:
+//   wb     the workbook; pass it to every xlsx.* call
+//   xlsx   the scripting API (witan xlsx exec --list-api prints it)
+//   input  the --input-json value ({} when omitted)
+//   files  files attached with --data, by base name, e.g. files["sales.csv"]
+//
+// Whatever the script returns must be JSON-serializable; it is printed as
+// the result, or as .result with --json. console.log output is printed
+// before it.
+
+// 1. Sheets: list every sheet with its used range.
+const sheets = await xlsx.listSheets(wb);
+console.log("sheets:", sheets.map((s) => s.sheet).join(", "));
+
+// 2. Inputs: read options from --input-json, with defaults.
+const sheetName = input.sheet ?? sheets[0].sheet;
+
+// 3. Cells: read one cell, or a block of cells as rows.
+const first = await xlsx.readCell(wb, sheetName + "!A1");
+const rows = await xlsx.readRange(wb, { sheet: sheetName });
+
+// 4. Writes: set values, formulas, and number formats. Writes are kept
+// only with --save or --save-to.
+// await xlsx.setCells(wb, [
+//   { address: sheetName + "!A1", value: "Total" },
+//   { address: sheetName + "!B1", formula: "=SUM(B2:B10)", format: "$#,##0" },
+// ]);
+
+// 5. Result: return plain data.
+return {
+  sheet: sheetName,
+  sheets: sheets.length,
+  a1: first,
+  rows: rows.length,
+};
+`
+
+// writeExecAPIReference prints the scripting API as one function per
+// entry: its signature, then its description indented below.
+func writeExecAPIReference(w io.Writer, fns []client.ExecAPIFunction) {
+	if len(fns) == 0 {
+		fmt.Fprintln(w, "The API did not list any scripting functions.")
+		return
+	}
+	for i, fn := range fns {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		sig := fn.Signature
+		if sig == "" {
+			sig = fn.Name
+		}
+		fmt.Fprintln(w, sig)
+		for _, line := range strings.Split(strings.TrimSpace(fn.Description), "\n") {
+			if line != "" {
+				fmt.Fprintln(w, "    "+line)
+			}
+		}
+	}
+}
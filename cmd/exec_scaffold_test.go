@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestExecAPIReference_Printed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec/api" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"functions":[
+			{"name":"xlsx.readCell","signature":"xlsx.readCell(wb, address)","description":"Read one cell.\nReturns its value and text."},
+			{"name":"xlsx.listSheets"}
+		]}`))
+	}))
+	defer server.Close()
+
+	fns, err := client.New(server.URL, "", "", true).ExecAPIReference()
+	if err != nil {
+		t.Fatalf("ExecAPIReference: %v", err)
+	}
+	var out bytes.Buffer
+	writeExecAPIReference(&out, fns)
+	want := "xlsx.readCell(wb, address)\n    Read one cell.\n    Returns its value and text.\n\nxlsx.listSheets\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -1,11 +1,11 @@
 package cmd
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -105,19 +105,52 @@ func validateExecExpr(expr string) error {
 	return nil
 }
 
+// execStdoutStreamed is set once --stream has written console output to
+// stdout, so outputExecResult does not print it a second time.
+var execStdoutStreamed bool
+
+// streamExecStdout returns the --stream callback: console output goes to
+// stdout alongside the human summary, or to stderr when stdout carries JSON
+// or the summary is suppressed. With --ndjson each chunk is a stdout event.
+func streamExecStdout(useJSON bool) func(string) {
+	if ndjsonOutput {
+		return func(text string) { emitEvent("stdout", map[string]any{"text": text}) }
+	}
+	if useJSON || quietOutput {
+		return func(text string) { fmt.Fprint(os.Stderr, text) }
+	}
+	return func(text string) {
+		execStdoutStreamed = true
+		fmt.Print(text)
+	}
+}
+
+// execTemplateData is what --template renders for exec: the response plus
+// the file it ran against, with Result decoded from JSON.
+type execTemplateData struct {
+	*client.ExecResponse
+	File   string
+	Result any
+}
+
 // outputExecResult handles the output of an exec response.
 // It prints stdout, then either the result (if ok=true) or an error (if ok=false).
 // If useJSON is true, it prints the full JSON response.
 // If not, it prints stdout first, then pretty-prints the result or formats the error.
-// Images are decoded from base64 data URLs and written to temp files.
-func outputExecResult(result *client.ExecResponse, useJSON bool, formatError func(*client.ExecError) string) error {
-	if useJSON {
-		result.File = nil
-		if err := jsonPrint(result); err != nil {
-			return err
+// Images are handled according to --image-mode (see applyImageMode).
+func outputExecResult(result *client.ExecResponse, file string, useJSON bool, formatError func(*client.ExecError) string) error {
+	result.File = nil
+	if err := applyImageMode(result, useJSON, "witan-exec-"); err != nil {
+		return err
+	}
+	data := execTemplateData{ExecResponse: result, File: file}
+	if len(result.Result) > 0 {
+		if err := json.Unmarshal(result.Result, &data.Result); err != nil {
+			data.Result = string(result.Result)
 		}
-	} else {
-		if result.Stdout != "" {
+	}
+	if err := emitResultAs(result, data, useJSON, func() error {
+		if result.Stdout != "" && !execStdoutStreamed {
 			fmt.Print(result.Stdout)
 		}
 
@@ -129,36 +162,20 @@ func outputExecResult(result *client.ExecResponse, useJSON bool, formatError fun
 			fmt.Println(formatError(result.Error))
 		}
 
+		// Paths in files mode, data URLs in inline mode.
 		for _, img := range result.Images {
-			ext := execImageExt(img)
-			b64 := img
-			if _, after, ok := strings.Cut(img, ","); ok {
-				b64 = after
-			}
-			decoded, err := base64.StdEncoding.DecodeString(b64)
-			if err != nil {
-				return fmt.Errorf("decoding exec image: %w", err)
-			}
-			f, err := os.CreateTemp("", "witan-exec-*"+ext)
-			if err != nil {
-				return fmt.Errorf("creating temp image file: %w", err)
-			}
-			tmpPath := f.Name()
-			if _, err := f.Write(decoded); err != nil {
-				f.Close()
-				os.Remove(tmpPath)
-				return fmt.Errorf("writing exec image: %w", err)
-			}
-			if err := f.Close(); err != nil {
-				os.Remove(tmpPath)
-				return fmt.Errorf("closing exec image file: %w", err)
-			}
-			fmt.Println(tmpPath)
+			fmt.Println(img)
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	if !result.Ok {
-		return &ExitError{Code: 1}
+		if !resultShownOnStdout(useJSON) {
+			fmt.Fprintln(os.Stderr, formatError(result.Error))
+		}
+		return &ExitError{Code: ExitFailure}
 	}
 	return nil
 }
@@ -208,6 +225,29 @@ func formatExecError(execErr *client.ExecError) string {
 	return "execution failed"
 }
 
+// execDataFiles resolves --data paths. The script sees each file under its
+// base name, so two files with the same base name are rejected.
+func execDataFiles(paths []string) ([]client.ExecDataFile, error) {
+	var files []client.ExecDataFile
+	seen := map[string]string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("--data: %w", err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("--data %s is a directory", path)
+		}
+		name := filepath.Base(path)
+		if prev, ok := seen[name]; ok {
+			return nil, fmt.Errorf("--data %s and %s are both named %q; the script sees data files by base name", prev, path, name)
+		}
+		seen[name] = path
+		files = append(files, client.ExecDataFile{Name: name, Path: path})
+	}
+	return files, nil
+}
+
 // validateExecPositiveFlag validates that a flag value is > 0 when explicitly set.
 func validateExecPositiveFlag(cmd *cobra.Command, name string, value int) error {
 	if cmd.Flags().Changed(name) && value <= 0 {
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// warnExecSyntax runs checkExecSyntax on xlsx exec's code and prints what
+// it finds to stderr as a warning, before the workbook is uploaded. The
+// check is a heuristic, so the script is sent either way and the server's
+// parser has the final word. Positions refer to the --script file, --code,
+// stdin, or --expr as given.
+func warnExecSyntax(cmd *cobra.Command, code string) {
+	name := "--code"
+	switch {
+	case cmd.Flags().Changed("expr"):
+		name, code = "--expr", execExpr
+	case cmd.Flags().Changed("script"):
+		name = execScript
+	case execStdin:
+		name = "stdin"
+	}
+	err := checkExecSyntax(code)
+	if err == nil {
+		return
+	}
+	msg := fmt.Sprintf("warning: possible syntax error in %s:%v; sending the script anyway (--no-syntax-check hides this warning)", name, err)
+	var synErr *execSyntaxError
+	if errors.As(err, &synErr) && !jsonOutput && !ndjsonOutput {
+		msg += "\n" + execCodeFrame(code, synErr.Line, synErr.Col)
+	}
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+// execCodeFrame renders the source line at line (and the one before it)
+// with a caret under col, for human error output:
+//
+//	 9 |   const b = rows[0]
+//	10 |   return [b, 2)
+//	   |               ^
+func execCodeFrame(src string, line, col int) string {
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	width := len(strconv.Itoa(line))
+	var b strings.Builder
+	for n := max(1, line-1); n <= line; n++ {
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, strings.TrimRight(lines[n-1], "\r"))
+	}
+	// Keep tabs so the caret lines up with the text above it.
+	var pad strings.Builder
+	for i, r := range []rune(lines[line-1]) {
+		if i >= col-1 {
+			break
+		}
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	fmt.Fprintf(&b, "%*s | %s^", width, "", pad.String())
+	return b.String()
+}
+
+// execSyntaxError is a syntax problem found in an exec script before it
+// is sent. Line and Col are 1-based; Col counts characters, not bytes.
+type execSyntaxError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *execSyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// regexKeywords are the keywords after which a / starts a regular
+// expression rather than a division.
+var regexKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// regexAfterParenKeywords are the keywords whose parenthesized condition
+// can be followed by a statement, so a / after its ) starts a regular
+// expression: if (ok) /x/.test(s).
+var regexAfterParenKeywords = map[string]bool{
+	"if": true, "while": true, "for": true, "with": true,
+}
+
+// execOpen is an unclosed (, [, or {, or a ${ inside a template literal
+// (ch '$', with the template's own position). regexAfter reports whether
+// a / after the matching ) starts a regular expression.
+type execOpen struct {
+	ch         byte
+	line, col  int
+	regexAfter bool
+}
+
+// execScanner walks exec source tracking the 1-based line and column.
+type execScanner struct {
+	src       string
+	i         int
+	line, col int
+}
+
+func (s *execScanner) peek(off int) byte {
+	if s.i+off < len(s.src) {
+		return s.src[s.i+off]
+	}
+	return 0
+}
+
+func (s *execScanner) advance() {
+	c := s.src[s.i]
+	s.i++
+	switch {
+	case c == '\n':
+		s.line++
+		s.col = 1
+	case utf8.RuneStart(c):
+		s.col++
+	}
+}
+
+func (s *execScanner) errorAt(line, col int, format string, args ...any) error {
+	return &execSyntaxError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// checkExecSyntax looks for the mistakes that make a script fail to parse
+// on the server: unterminated strings, template literals, comments, and
+// regular expressions, and unbalanced brackets. It is a tokenizer, not a
+// full parser, so TypeScript syntax passes unchanged; it reports only what
+// it is sure of.
+func checkExecSyntax(src string) error {
+	s := &execScanner{src: src, line: 1, col: 1}
+	if strings.HasPrefix(src, "#!") {
+		for s.i < len(src) && src[s.i] != '\n' {
+			s.advance()
+		}
+	}
+	var stack []execOpen
+	regexAllowed := true
+	// word is the previous token when it was an identifier or keyword.
+	word := ""
+	for s.i < len(src) {
+		c := src[s.i]
+		line, col := s.line, s.col
+		prevWord := word
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && !(c == '/' && (s.peek(1) == '/' || s.peek(1) == '*')) {
+			word = ""
+		}
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			s.advance()
+		case c == '/' && s.peek(1) == '/':
+			for s.i < len(src) && src[s.i] != '\n' {
+				s.advance()
+			}
+		case c == '/' && s.peek(1) == '*':
+			s.advance()
+			s.advance()
+			for s.i < len(src) && !(src[s.i] == '*' && s.peek(1) == '/') {
+				s.advance()
+			}
+			if s.i >= len(src) {
+				return s.errorAt(line, col, "unterminated block comment")
+			}
+			s.advance()
+			s.advance()
+		case c == '/' && regexAllowed:
+			if err := s.scanRegex(line, col); err != nil {
+				return err
+			}
+			regexAllowed = false
+		case c == '\'' || c == '"':
+			if err := s.scanString(c, line, col); err != nil {
+				return err
+			}
+			regexAllowed = false
+		case c == '`':
+			s.advance()
+			switch s.scanTemplate() {
+			case templateExpr:
+				stack = append(stack, execOpen{ch: '$', line: line, col: col})
+				regexAllowed = true
+			case templateEOF:
+				return s.errorAt(line, col, "unterminated template literal")
+			default:
+				regexAllowed = false
+			}
+		case c == '(' || c == '[' || c == '{':
+			stack = append(stack, execOpen{ch: c, line: line, col: col, regexAfter: c == '(' && regexAfterParenKeywords[prevWord]})
+			s.advance()
+			regexAllowed = true
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) == 0 {
+				return s.errorAt(line, col, "unexpected %q with no matching %q", c, openerFor(c))
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			s.advance()
+			if top.ch == '$' && c == '}' {
+				// Back inside the template literal that opened ${.
+				switch s.scanTemplate() {
+				case templateExpr:
+					stack = append(stack, top)
+					regexAllowed = true
+				case templateEOF:
+					return s.errorAt(top.line, top.col, "unterminated template literal")
+				default:
+					regexAllowed = false
+				}
+				continue
+			}
+			if top.ch == '$' {
+				return s.errorAt(line, col, "unexpected %q inside ${ of the template literal at %d:%d", c, top.line, top.col)
+			}
+			if top.ch != openerFor(c) {
+				return s.errorAt(line, col, "unexpected %q; %q at %d:%d is not closed", c, top.ch, top.line, top.col)
+			}
+			// A / after a block, or after the condition of if, while, for,
+			// or with, starts a regular expression.
+			regexAllowed = c == '}' || top.regexAfter
+		case isIdentStart(c):
+			start := s.i
+			for s.i < len(src) && isIdentPart(src[s.i]) {
+				s.advance()
+			}
+			word = src[start:s.i]
+			regexAllowed = regexKeywords[word]
+		case c >= '0' && c <= '9' || c == '.' && s.peek(1) >= '0' && s.peek(1) <= '9':
+			for s.i < len(src) && (isIdentPart(src[s.i]) || src[s.i] == '.') {
+				s.advance()
+			}
+			regexAllowed = false
+		case (c == '+' || c == '-') && s.peek(1) == c, c == '!' && s.peek(1) != '=':
+			// ++, --, and ! (a TypeScript non-null assertion when postfix)
+			// leave the operand position as it was.
+			s.advance()
+			if c != '!' {
+				s.advance()
+			}
+		default:
+			s.advance()
+			regexAllowed = true
+		}
+	}
+	if len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.ch == '$' {
+			return s.errorAt(top.line, top.col, "unterminated template literal")
+		}
+		return s.errorAt(top.line, top.col, "%q is never closed", top.ch)
+	}
+	return nil
+}
+
+// scanString consumes a quoted string starting at the opening quote.
+func (s *execScanner) scanString(quote byte, line, col int) error {
+	s.advance()
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; c {
+		case quote:
+			s.advance()
+			return nil
+		case '\\':
+			s.advance()
+			if s.i < len(s.src) {
+				s.advance()
+			}
+		case '\n':
+			return s.errorAt(line, col, "unterminated string literal")
+		default:
+			s.advance()
+		}
+	}
+	return s.errorAt(line, col, "unterminated string literal")
+}
+
+// templateStop is where scanTemplate stopped.
+type templateStop int
+
+const (
+	templateEnd  templateStop = iota // after the closing backtick
+	templateExpr                     // after a ${
+	templateEOF                      // at end of input
+)
+
+// scanTemplate consumes template literal text up to and including the
+// closing backtick or the next ${.
+func (s *execScanner) scanTemplate() templateStop {
+	for s.i < len(s.src) {
+		switch s.src[s.i] {
+		case '`':
+			s.advance()
+			return templateEnd
+		case '\\':
+			s.advance()
+			if s.i < len(s.src) {
+				s.advance()
+			}
+		case '$':
+			s.advance()
+			if s.i < len(s.src) && s.src[s.i] == '{' {
+				s.advance()
+				return templateExpr
+			}
+		default:
+			s.advance()
+		}
+	}
+	return templateEOF
+}
+
+// scanRegex consumes a regular expression literal and its flags.
+func (s *execScanner) scanRegex(line, col int) error {
+	s.advance()
+	inClass := false
+	for s.i < len(s.src) {
+		c := s.src[s.i]
+		switch {
+		case c == '\n':
+			return s.errorAt(line, col, "unterminated regular expression")
+		case c == '\\':
+			s.advance()
+			if s.i < len(s.src) && s.src[s.i] != '\n' {
+				s.advance()
+			}
+			continue
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			s.advance()
+			for s.i < len(s.src) && isIdentPart(s.src[s.i]) {
+				s.advance()
+			}
+			return nil
+		}
+		s.advance()
+	}
+	return s.errorAt(line, col, "unterminated regular expression")
+}
+
+func openerFor(c byte) byte {
+	switch c {
+	case ')':
+		return '('
+	case ']':
+		return '['
+	}
+	return '{'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckExecSyntax_Valid(t *testing.T) {
+	for _, src := range []string{
+		`const sheets = await xlsx.listSheets(wb); return sheets.map(s => s.sheet)`,
+		"const total: number = rows.reduce((a: number, r: Row) => a + r.value, 0) / rows.length\nreturn { total }",
+		"const label = `Sheet ${input.sheet ?? `#${n}`}: {not a brace}`\nreturn label",
+		`if (/^[A-Z]+\d+$/.test(addr) && addr.split("/").length) return x / 2 / y`,
+		`const re = /[/\]]+/g; return "it's \"quoted\"" + 'a}b'`,
+		"// an apostrophe: don't\n/* a bracket: ( */ return [1, 2]",
+		`const v = cell!.value / 100; i++ / 2; return typeof v`,
+		"#!/usr/bin/env node\nreturn 1",
+		"const s = 'line\\\ncontinued'; return s",
+		// A / after the condition of if or while starts a regular expression.
+		`if (ok) /\(/.test(s)`,
+		`if (x) /'/.test(s)`,
+		"while (i < n) /[)]/.exec(s)",
+		`const r = (a + b) / (c) / 2; return f(x) / 2`,
+	} {
+		if err := checkExecSyntax(src); err != nil {
+			t.Errorf("checkExecSyntax(%q) = %v, want nil", src, err)
+		}
+	}
+}
+
+func TestCheckExecSyntax_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		src       string
+		line, col int
+		msg       string
+	}{
+		{"const a = 1\nconst b = \"open\nreturn b", 2, 11, "unterminated string literal"},
+		{"return xlsx.readCell(wb, \"A1\"", 1, 21, `'(' is never closed`},
+		{"if (x) {\n  return [1, 2)\n}", 2, 15, `unexpected ')'; '[' at 2:10 is not closed`},
+		{"return 1 }", 1, 10, `unexpected '}' with no matching '{'`},
+		{"const s = `total ${n}\nreturn s", 1, 11, "unterminated template literal"},
+		{"const s = `a ${f(n}`", 1, 19, `unexpected '}'; '(' at 1:17 is not closed`},
+		{"/* notes\nreturn 1", 1, 1, "unterminated block comment"},
+		{"return /abc\n", 1, 8, "unterminated regular expression"},
+		{"const é = 'x\nreturn é", 1, 11, "unterminated string literal"},
+	} {
+		err := checkExecSyntax(tc.src)
+		var synErr *execSyntaxError
+		if !errors.As(err, &synErr) {
+			t.Errorf("checkExecSyntax(%q) = %v, want a syntax error", tc.src, err)
+			continue
+		}
+		if synErr.Line != tc.line || synErr.Col != tc.col || synErr.Msg != tc.msg {
+			t.Errorf("checkExecSyntax(%q) = %d:%d %s, want %d:%d %s", tc.src, synErr.Line, synErr.Col, synErr.Msg, tc.line, tc.col, tc.msg)
+		}
+	}
+}
+
+func TestExecCodeFrame(t *testing.T) {
+	src := "if (x) {\n\tconst b = rows[0]\n\treturn [b, 2)\n}"
+	want := "2 | \tconst b = rows[0]\n3 | \treturn [b, 2)\n  | \t            ^"
+	if got := execCodeFrame(src, 3, 14); got != want {
+		t.Errorf("execCodeFrame =\n%s\nwant\n%s", got, want)
+	}
+	if got := execCodeFrame("return 1 }", 1, 10); got != "1 | return 1 }\n  |          ^" {
+		t.Errorf("execCodeFrame on line 1 = %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// readStagedWorkbook copies the version of path staged in git's index to a
+// temp file with the same name, so --staged checks what will be committed
+// rather than the working tree. The caller must call cleanup.
+func readStagedWorkbook(path string) (tmpPath string, cleanup func(), err error) {
+	out, err := gitOutput("ls-files", "--stage", "--", path)
+	if err != nil {
+		return "", nil, err
+	}
+	// <mode> <object> <stage>\t<path>; only stage 0 exists outside a merge.
+	fields := strings.Fields(strings.SplitN(string(out), "\t", 2)[0])
+	if len(fields) < 3 {
+		return "", nil, fmt.Errorf("%s is not staged in git", path)
+	}
+	if fields[2] != "0" {
+		return "", nil, fmt.Errorf("%s has unresolved merge conflicts", path)
+	}
+	blob, err := gitOutput("cat-file", "blob", fields[1])
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "witan-staged-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("buffering staged workbook: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	tmpPath = filepath.Join(dir, filepath.Base(path))
+	if err := os.WriteFile(tmpPath, blob, 0o600); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("buffering staged workbook: %w", err)
+	}
+	return tmpPath, cleanup, nil
+}
+
+// gitOutput runs git with args in the current directory and returns its
+// stdout. Failures include git's stderr.
+func gitOutput(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// --format github prints findings as GitHub Actions workflow commands
+// (::error, ::warning, ::notice) so they appear as annotations on pull
+// request checks. A cell maps to the pseudo-path "<workbook>/<sheet>" with
+// its row as the line and its column number as the column.
+const (
+	resultFormatText   = "text"
+	resultFormatGitHub = "github"
+)
+
+var resultFormat string
+
+// addResultFormatFlag registers --format on lint and calc.
+func addResultFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&resultFormat, "format", resultFormatText, "Summary format: text, or github for GitHub Actions annotations")
+}
+
+func validateResultFormat() error {
+	switch resultFormat {
+	case "", resultFormatText:
+		return nil
+	case resultFormatGitHub:
+		if jsonOutput || ndjsonOutput || resultTemplate != nil {
+			return fmt.Errorf("--format github cannot be combined with --json, --ndjson, or --template")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid --format %q: must be text or github", resultFormat)
+}
+
+// githubAnnotation is one workflow command, e.g.
+// ::warning file=book.xlsx/Sheet1,line=7,col=2,title=D031 Sheet1!B7::message
+type githubAnnotation struct {
+	Level   string // error, warning, or notice
+	File    string
+	Line    int
+	EndLine int
+	Col     int
+	EndCol  int
+	Title   string
+	Message string
+}
+
+func (a githubAnnotation) String() string {
+	var props []string
+	add := func(key, value string) {
+		if value != "" {
+			props = append(props, key+"="+escapeGitHubProperty(value))
+		}
+	}
+	addInt := func(key string, n int) {
+		if n > 0 {
+			add(key, fmt.Sprint(n))
+		}
+	}
+	add("file", a.File)
+	addInt("line", a.Line)
+	if a.EndLine != a.Line {
+		addInt("endLine", a.EndLine)
+	}
+	addInt("col", a.Col)
+	if a.EndCol != a.Col {
+		addInt("endColumn", a.EndCol)
+	}
+	add("title", a.Title)
+	return "::" + a.Level + " " + strings.Join(props, ",") + "::" + escapeGitHubData(a.Message)
+}
+
+// newGitHubAnnotation places an annotation at location ("Sheet1!B7" or a
+// range) within workbook. A location that is not a cell reference annotates
+// the workbook itself.
+func newGitHubAnnotation(level, workbook, location, title, message string) githubAnnotation {
+	a := githubAnnotation{Level: level, File: filepath.ToSlash(workbook), Title: title, Message: message}
+	if location == "" {
+		return a
+	}
+	a.Title = strings.TrimSpace(title + " " + location)
+	sheet, row, col, endRow, endCol, err := internal.ParseRange(upperRangePart(location))
+	if err != nil {
+		return a
+	}
+	a.File += "/" + sheet
+	a.Line, a.Col, a.EndLine, a.EndCol = row, col, endRow, endCol
+	return a
+}
+
+func githubLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	}
+	return "notice"
+}
+
+func printLintAnnotations(workbook string, diagnostics []client.LintDiagnostic) {
+	for _, d := range diagnostics {
+		location := ""
+		if d.Location != nil {
+			location = *d.Location
+		}
+		fmt.Println(newGitHubAnnotation(githubLevel(d.Severity), workbook, location, d.RuleId, d.Message))
+	}
+}
+
+// printCalcAnnotations reports formula errors, and with --verify the cells
+// whose value changed on recalculation.
+func printCalcAnnotations(workbook string, result *client.CalcResponse, verify bool) {
+	for _, e := range result.Errors {
+		message := e.Code
+		if e.Formula != nil {
+			message += " in " + *e.Formula
+		}
+		if e.Detail != nil {
+			message += ": " + *e.Detail
+		}
+		fmt.Println(newGitHubAnnotation("error", workbook, e.Address, e.Code, message))
+	}
+	if !verify {
+		return
+	}
+	for _, addr := range result.Changed {
+		message := "value changed on recalculation"
+		if cell, ok := result.Touched[addr]; ok {
+			message += " (now " + cell.Value + ")"
+		}
+		fmt.Println(newGitHubAnnotation("warning", workbook, addr, "changed", message))
+	}
+}
+
+// printCalcVerifyAnnotations reports each workbook a directory verify found
+// inconsistent or could not check.
+func printCalcVerifyAnnotations(report *calcVerifyReport) {
+	for _, f := range report.Files {
+		switch f.Status {
+		case "inconsistent":
+			message := fmt.Sprintf("%s, %d changed on recalculation", pluralize(f.Errors, "formula error", "formula errors"), f.Changed)
+			fmt.Println(newGitHubAnnotation("error", f.File, "", "calc --verify", message))
+		case "failed":
+			fmt.Println(newGitHubAnnotation("error", f.File, "", "calc --verify failed", f.Error))
+		}
+	}
+}
+
+// escapeGitHubData escapes a workflow command message.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
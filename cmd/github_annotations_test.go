@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestGitHubAnnotation_MapsCellsToPseudoPaths(t *testing.T) {
+	cases := []struct {
+		name string
+		got  githubAnnotation
+		want string
+	}{
+		{
+			"cell",
+			newGitHubAnnotation("warning", "models/book.xlsx", "Sheet1!b7", "D031", "misspelled"),
+			"::warning file=models/book.xlsx/Sheet1,line=7,col=2,title=D031 Sheet1!b7::misspelled",
+		},
+		{
+			"quoted range",
+			newGitHubAnnotation("error", "book.xlsx", "'Q1, Plan'!A1:C3", "#REF!", "50% off\nnext"),
+			"::error file=book.xlsx/Q1%2C Plan,line=1,endLine=3,col=1,endColumn=3,title=#REF! 'Q1%2C Plan'!A1%3AC3::50%25 off%0Anext",
+		},
+		{
+			"no location",
+			newGitHubAnnotation("notice", "book.xlsx", "", "D001", "workbook-level"),
+			"::notice file=book.xlsx,title=D001::workbook-level",
+		},
+	}
+	for _, tc := range cases {
+		if got := tc.got.String(); got != tc.want {
+			t.Errorf("%s:\n got %s\nwant %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOutputLintResult_GitHubFormat(t *testing.T) {
+	origFormat := resultFormat
+	t.Cleanup(func() { resultFormat = origFormat })
+	resultFormat = resultFormatGitHub
+
+	location := "Data!C4"
+	result := &client.LintResponse{Diagnostics: []client.LintDiagnostic{
+		{Severity: "Error", RuleId: "D002", Message: "type mismatch", Location: &location},
+		{Severity: "Info", RuleId: "D030", Message: "hint"},
+	}, Total: 2}
+	out, err := captureExecStdout(t, func() error {
+		return outputLintResult(result, "book.xlsx", false, "none")
+	})
+	if err != nil {
+		t.Fatalf("outputLintResult: %v", err)
+	}
+	want := "::error file=book.xlsx/Data,line=4,col=3,title=D002 Data!C4::type mismatch\n" +
+		"::notice file=book.xlsx,title=D030::hint\n"
+	if out != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestValidateResultFormat(t *testing.T) {
+	origFormat, origJSON := resultFormat, jsonOutput
+	t.Cleanup(func() { resultFormat, jsonOutput = origFormat, origJSON })
+
+	resultFormat, jsonOutput = "sarif", false
+	if err := validateResultFormat(); err == nil || !strings.Contains(err.Error(), "text or github") {
+		t.Fatalf("expected invalid format error, got %v", err)
+	}
+	resultFormat, jsonOutput = resultFormatGitHub, true
+	if err := validateResultFormat(); err == nil {
+		t.Fatal("expected --json conflict")
+	}
+}
@@ -31,7 +31,7 @@ Authorization model:
     picker). The grant persists until you disconnect.
   - Sheets you create via Witan are authorized automatically.
   - Operations on an un-authorized sheet fail with code needs_file_authorization
-    and exit code 3.
+    and exit code 4.
 
 Spreadsheet references:
   You can reference spreadsheets using either format:
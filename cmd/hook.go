@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookChecks []string
+	hookForce  bool
+)
+
+// hookMarker identifies a pre-commit hook written by `witan hook install`,
+// which it may overwrite without --force.
+const hookMarker = "# witan pre-commit hook"
+
+// hookCheckCommands maps --checks names to the command run on each staged
+// workbook.
+var hookCheckCommands = map[string]string{
+	"lint":   `"$witan" xlsx lint --staged "$f"`,
+	"verify": `"$witan" xlsx calc --verify --staged "$f"`,
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage git hooks that check workbooks",
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a git pre-commit hook that checks staged workbooks",
+	Long: `Write a git pre-commit hook that runs witan against every workbook
+(.xlsx, .xlsm, .xls) added or modified in the commit.
+
+Each check reads the staged version of the workbook, so unstaged edits do
+not affect the result:
+  lint    witan xlsx lint --staged <file>
+  verify  witan xlsx calc --verify --staged <file>
+
+The commit is blocked when any check fails; git commit --no-verify skips
+the hook. The hook runs "witan" from PATH, or $WITAN when set. It honors
+core.hooksPath. An existing hook not written by witan is kept unless
+--force is given.
+
+Examples:
+  witan hook install
+  witan hook install --checks lint
+  witan hook install --force`,
+	Args: cobra.NoArgs,
+	RunE: runHookInstall,
+}
+
+func init() {
+	hookInstallCmd.Flags().StringSliceVar(&hookChecks, "checks", []string{"lint", "verify"}, "Checks to run on staged workbooks: lint, verify")
+	hookInstallCmd.Flags().BoolVar(&hookForce, "force", false, "Overwrite an existing pre-commit hook")
+	hookCmd.AddCommand(hookInstallCmd)
+	rootCmd.AddCommand(hookCmd)
+}
+
+func runHookInstall(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	script, err := preCommitHookScript(hookChecks)
+	if err != nil {
+		return err
+	}
+
+	out, err := gitOutput("rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return err
+	}
+	hookPath := strings.TrimSpace(string(out))
+	if existing, err := os.ReadFile(hookPath); err == nil && !hookForce && !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("%s already exists; use --force to replace it", hookPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return fmt.Errorf("installing hook: %w", err)
+	}
+	if err := os.WriteFile(hookPath, []byte(script), 0o755); err != nil {
+		return fmt.Errorf("installing hook: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(hookPath, 0o755); err != nil {
+		return fmt.Errorf("installing hook: %w", err)
+	}
+	fmt.Printf("Installed pre-commit hook (%s): %s\n", strings.Join(hookChecks, ", "), hookPath)
+	return nil
+}
+
+// preCommitHookScript returns a POSIX sh hook that runs each check on the
+// staged workbooks and fails if any check fails.
+func preCommitHookScript(checks []string) (string, error) {
+	if len(checks) == 0 {
+		return "", fmt.Errorf("--checks must name at least one check: lint, verify")
+	}
+	var lines []string
+	for _, check := range checks {
+		command, ok := hookCheckCommands[check]
+		if !ok {
+			return "", fmt.Errorf("unknown check %q: must be lint or verify", check)
+		}
+		if !slices.Contains(lines, command) {
+			lines = append(lines, command)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(hookMarker + " (installed by `witan hook install`).\n")
+	b.WriteString("# Checks: " + strings.Join(checks, ", ") + ". Skip with git commit --no-verify.\n\n")
+	b.WriteString("witan=${WITAN:-witan}\n")
+	fmt.Fprintf(&b, "files=$(git -c core.quotePath=false diff --cached --name-only --diff-filter=ACMR | grep -iE '\\.(%s)$')\n", strings.Join(workbookExtensions, "|"))
+	b.WriteString("[ -n \"$files\" ] || exit 0\n\n")
+	b.WriteString("printf '%s\\n' \"$files\" | {\n")
+	b.WriteString("  status=0\n")
+	b.WriteString("  while IFS= read -r f; do\n")
+	for _, command := range lines {
+		b.WriteString("    " + command + " </dev/null || status=1\n")
+	}
+	b.WriteString("  done\n")
+	b.WriteString("  exit $status\n")
+	b.WriteString("}\n")
+	return b.String(), nil
+}
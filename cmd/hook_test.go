@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if _, err := gitOutput("init", "-q"); err != nil {
+		t.Fatalf("git init: %v", err)
+	}
+	return dir
+}
+
+func TestReadStagedWorkbook_ReadsIndexNotWorkingTree(t *testing.T) {
+	initTestRepo(t)
+	if err := os.WriteFile("book.xlsx", []byte("PK\x03\x04staged"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	if _, err := gitOutput("add", "book.xlsx"); err != nil {
+		t.Fatalf("git add: %v", err)
+	}
+	if err := os.WriteFile("book.xlsx", []byte("PK\x03\x04unstaged edit"), 0o644); err != nil {
+		t.Fatalf("editing workbook: %v", err)
+	}
+
+	path, cleanup, err := readStagedWorkbook("book.xlsx")
+	if err != nil {
+		t.Fatalf("readStagedWorkbook: %v", err)
+	}
+	defer cleanup()
+	if !strings.HasSuffix(path, "book.xlsx") {
+		t.Fatalf("expected temp copy named book.xlsx, got %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading staged copy: %v", err)
+	}
+	if string(data) != "PK\x03\x04staged" {
+		t.Fatalf("staged copy = %q", data)
+	}
+
+	if _, _, err := readStagedWorkbook("other.xlsx"); err == nil || !strings.Contains(err.Error(), "not staged") {
+		t.Fatalf("expected not-staged error, got %v", err)
+	}
+}
+
+func TestRunHookInstall_KeepsForeignHookWithoutForce(t *testing.T) {
+	initTestRepo(t)
+	origChecks, origForce := hookChecks, hookForce
+	t.Cleanup(func() { hookChecks, hookForce = origChecks, origForce })
+	hookChecks, hookForce = []string{"lint"}, false
+
+	hookPath := ".git/hooks/pre-commit"
+	if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nmake test\n"), 0o755); err != nil {
+		t.Fatalf("writing existing hook: %v", err)
+	}
+	if _, err := captureExecStdout(t, func() error { return runHookInstall(hookInstallCmd, nil) }); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected --force error, got %v", err)
+	}
+
+	hookForce = true
+	if _, err := captureExecStdout(t, func() error { return runHookInstall(hookInstallCmd, nil) }); err != nil {
+		t.Fatalf("runHookInstall --force: %v", err)
+	}
+	data, err := os.ReadFile(hookPath)
+	if err != nil {
+		t.Fatalf("reading hook: %v", err)
+	}
+	script := string(data)
+	if !strings.Contains(script, hookMarker) || !strings.Contains(script, "xlsx lint --staged") || strings.Contains(script, "calc --verify") {
+		t.Fatalf("unexpected hook:\n%s", script)
+	}
+
+	// A hook witan wrote can be reinstalled without --force.
+	hookForce = false
+	hookChecks = []string{"lint", "verify"}
+	if _, err := captureExecStdout(t, func() error { return runHookInstall(hookInstallCmd, nil) }); err != nil {
+		t.Fatalf("reinstalling hook: %v", err)
+	}
+}
+
+func TestPreCommitHookScript_RejectsUnknownCheck(t *testing.T) {
+	if _, err := preCommitHookScript([]string{"lint", "format"}); err == nil || !strings.Contains(err.Error(), `unknown check "format"`) {
+		t.Fatalf("expected unknown check error, got %v", err)
+	}
+}
@@ -10,7 +10,7 @@ import (
 // authRequiredExitCode signals that a Google authorization step is needed
 // (account connect or per-sheet authorize) before an operation can proceed.
 // Agents branch on this exit code instead of parsing messages.
-const authRequiredExitCode = 3
+const authRequiredExitCode = ExitAuth
 
 // isInteractive reports whether the CLI is attached to a terminal on both
 // stdin and stdout. Browser-based flows (connect, authorize) only open a
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// introspectSchemaVersion is bumped when the manifest layout changes
+// incompatibly.
+const introspectSchemaVersion = 1
+
+var introspectJSON bool
+
+var introspectCmd = &cobra.Command{
+	Use:   "introspect",
+	Short: "Describe every command, flag, exit code, and JSON output shape",
+	Long: `Describe the CLI for tools and agents: every command with its arguments,
+flags (name, type, default, required, repeatable), the exit codes, and a
+JSON Schema for the --json result of commands that have one.
+
+The manifest is generated from the command definitions, so it always
+matches this binary. Without --json a compact command list is printed.
+
+Examples:
+  witan introspect --json
+  witan introspect --json | jq '.commands[] | select(.path == "witan xlsx calc")'`,
+	Args: cobra.NoArgs,
+	RunE: runIntrospect,
+}
+
+func init() {
+	introspectCmd.Flags().BoolVar(&introspectJSON, "json", false, "Output the manifest as JSON")
+	addResultOutputFlag(introspectCmd)
+	rootCmd.AddCommand(introspectCmd)
+}
+
+type introspectManifest struct {
+	SchemaVersion int                 `json:"schema_version"`
+	CLIVersion    string              `json:"cli_version"`
+	GlobalFlags   []introspectFlag    `json:"global_flags"`
+	ExitCodes     []introspectExit    `json:"exit_codes"`
+	Output        []string            `json:"output_conventions"`
+	Commands      []introspectCommand `json:"commands"`
+}
+
+type introspectCommand struct {
+	Path        string           `json:"path"`
+	Usage       string           `json:"usage"`
+	Short       string           `json:"short"`
+	Long        string           `json:"long,omitempty"`
+	Aliases     []string         `json:"aliases,omitempty"`
+	Runnable    bool             `json:"runnable"`
+	Subcommands []string         `json:"subcommands,omitempty"`
+	Args        []introspectArg  `json:"args,omitempty"`
+	Flags       []introspectFlag `json:"flags,omitempty"`
+	Output      any              `json:"output_schema,omitempty"`
+}
+
+type introspectArg struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // path, path_or_url, spreadsheet, or string
+	Required bool   `json:"required"`
+	Variadic bool   `json:"variadic,omitempty"`
+}
+
+type introspectFlag struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Default    string `json:"default,omitempty"`
+	Usage      string `json:"usage"`
+	Required   bool   `json:"required,omitempty"`
+	Repeatable bool   `json:"repeatable,omitempty"`
+	Inherited  bool   `json:"inherited,omitempty"`
+}
+
+type introspectExit struct {
+	Code        int    `json:"code"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// introspectExitCodes mirrors the exit code constants in output.go.
+var introspectExitCodes = []introspectExit{
+	{ExitOK, "ok", "The command succeeded."},
+	{ExitFailure, "failure", "A transport, API, or usage error, or a script that returned ok=false."},
+	{ExitFindings, "findings", "The checks ran and reported findings (lint diagnostics, calc formula errors or --verify changes, update --check staleness). --exit-zero maps it to 0."},
+	{ExitAssertion, "assertion", "An exec --expect/--expect-json assertion or an xlsx assert snapshot check failed."},
+	{ExitAuth, "auth", "Sign-in, organization selection, or Google authorization is required."},
+	{ExitOffline, "offline", "--offline is set and the command needed the network."},
+	{ExitConflict, "conflict", "The file changed on the server: --if-revision did not match, or another client saved a newer revision."},
+}
+
+var introspectOutputConventions = []string{
+	"stdout carries the result: a human summary, or JSON (output_schema) with --json.",
+	"stderr carries errors, warnings, progress, and paths of side files.",
+	"-o/--output FILE on calc, exec, lint, read, and snapshot writes the JSON result to FILE; render commands use -o for the image path.",
+	"-q/--quiet suppresses the human summary; exit codes are unchanged.",
+	"--ndjson streams events to stdout, one JSON object per line, each with a type: upload_started, upload_progress, upload_done, retry, calc_started, exec_started, stdout, then result or error.",
+}
+
+// introspectOutputs maps commands to the value their --json result is
+// encoded from.
+func introspectOutputs() map[*cobra.Command]any {
+	return map[*cobra.Command]any{
+		assertCmd:     xlsxAssertResult{},
+		authStatusCmd: authStatusReport{},
+		calcCmd:       client.CalcResponse{},
+		configListCmd: map[string]any{},
+		depsCmd:       client.DepsGraph{},
+		doctorCmd:     doctorReport{},
+		historyCmd:    []client.Revision{},
+		jobsStatusCmd: client.Job{},
+		lintCmd:       client.LintResponse{},
+		pptxExecCmd:   client.ExecResponse{},
+		pptxLintCmd:   client.PptxLintResponse{},
+		readCmd:       client.ReadResponse{},
+		searchCmd:     xlsxSearchResult{},
+		sheetsExecCmd: client.ExecResponse{},
+		sheetsLintCmd: client.LintResponse{},
+		snapshotCmd:   xlsxSnapshot{},
+		statsCmd:      client.WorkbookStats{},
+		undoCmd:       []undoVersion{},
+		xlsxExecCmd:   client.ExecResponse{},
+		xlsxNewCmd:    client.ExecResponse{},
+	}
+}
+
+func runIntrospect(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	manifest := buildIntrospectManifest(rootCmd)
+	return emitResult(manifest, introspectJSON, func() error {
+		for _, c := range manifest.Commands {
+			if !c.Runnable {
+				continue
+			}
+			fmt.Printf("%-40s %s\n", c.Usage, c.Short)
+		}
+		return nil
+	})
+}
+
+func buildIntrospectManifest(root *cobra.Command) introspectManifest {
+	m := introspectManifest{
+		SchemaVersion: introspectSchemaVersion,
+		CLIVersion:    strings.TrimSpace(Version),
+		GlobalFlags:   introspectFlags(root.PersistentFlags(), false),
+		ExitCodes:     introspectExitCodes,
+		Output:        introspectOutputConventions,
+	}
+	outputs := introspectOutputs()
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if c != root {
+			m.Commands = append(m.Commands, describeCommand(c, outputs[c]))
+		}
+		for _, sub := range c.Commands() {
+			if sub.IsAvailableCommand() {
+				walk(sub)
+			}
+		}
+	}
+	walk(root)
+	return m
+}
+
+func describeCommand(c *cobra.Command, output any) introspectCommand {
+	d := introspectCommand{
+		Path:     c.CommandPath(),
+		Usage:    strings.TrimSpace(c.Parent().CommandPath() + " " + c.Use),
+		Short:    c.Short,
+		Long:     c.Long,
+		Aliases:  c.Aliases,
+		Runnable: c.Runnable(),
+		Args:     parseUseArgs(c.Use),
+	}
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() {
+			d.Subcommands = append(d.Subcommands, sub.Name())
+		}
+	}
+	// Root persistent flags are listed once under global_flags.
+	d.Flags = introspectFlags(c.LocalFlags(), false)
+	c.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if c.Root().PersistentFlags().Lookup(f.Name) == nil {
+			d.Flags = append(d.Flags, describeFlag(f, true))
+		}
+	})
+	if output != nil {
+		d.Output = jsonSchemaFor(reflect.TypeOf(output), map[reflect.Type]bool{})
+	}
+	return d
+}
+
+func introspectFlags(fs *pflag.FlagSet, inherited bool) []introspectFlag {
+	var flags []introspectFlag
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Name == "help" {
+			return
+		}
+		flags = append(flags, describeFlag(f, inherited))
+	})
+	return flags
+}
+
+func describeFlag(f *pflag.Flag, inherited bool) introspectFlag {
+	typ := f.Value.Type()
+	d := introspectFlag{
+		Name:       f.Name,
+		Shorthand:  f.Shorthand,
+		Type:       typ,
+		Usage:      f.Usage,
+		Repeatable: strings.HasSuffix(typ, "Array") || strings.HasSuffix(typ, "Slice"),
+		Inherited:  inherited,
+	}
+	if f.DefValue != "" && f.DefValue != "[]" && !(typ == "bool" && f.DefValue == "false") {
+		d.Default = f.DefValue
+	}
+	if req := f.Annotations[cobra.BashCompOneRequiredFlag]; len(req) > 0 && req[0] == "true" {
+		d.Required = true
+	}
+	return d
+}
+
+// parseUseArgs reads positional arguments from a Use line such as
+// "calc <file|dir>" or "read <file-or-url>...". Placeholders that follow a
+// flag ("-o <file.xlsx>") are flag values, not arguments.
+func parseUseArgs(use string) []introspectArg {
+	fields := strings.Fields(use)
+	var args []introspectArg
+	for i := 1; i < len(fields); i++ {
+		tok := fields[i]
+		if strings.HasPrefix(tok, "-") {
+			i++ // skip the flag's value
+			continue
+		}
+		if tok == "..." {
+			if len(args) > 0 {
+				args[len(args)-1].Variadic = true
+			}
+			continue
+		}
+		arg := introspectArg{Required: true}
+		if strings.HasPrefix(tok, "[") {
+			arg.Required = false
+			tok = strings.Trim(tok, "[]")
+		}
+		if strings.HasSuffix(tok, "...") {
+			arg.Variadic = true
+			tok = strings.TrimSuffix(tok, "...")
+		}
+		arg.Name = strings.Trim(tok, "<>")
+		arg.Type = argType(arg.Name)
+		args = append(args, arg)
+	}
+	return args
+}
+
+func argType(name string) string {
+	switch {
+	case strings.Contains(name, "url"):
+		return "path_or_url"
+	case strings.Contains(name, "spreadsheet"):
+		return "spreadsheet"
+	case strings.Contains(name, "file"), strings.Contains(name, "dir"):
+		return "path"
+	}
+	return "string"
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// jsonSchemaFor describes how encoding/json encodes values of t. Recursive
+// types are cut off with a bare {"type": "object"}.
+func jsonSchemaFor(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case rawMessageType:
+		return map[string]any{}
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": jsonSchemaFor(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		props := map[string]any{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = jsonSchemaFor(f.Type, seen)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func findIntrospectCommand(t *testing.T, m introspectManifest, path string) introspectCommand {
+	t.Helper()
+	for _, c := range m.Commands {
+		if c.Path == path {
+			return c
+		}
+	}
+	t.Fatalf("command %q not in manifest", path)
+	return introspectCommand{}
+}
+
+func findIntrospectFlag(flags []introspectFlag, name string) *introspectFlag {
+	for i := range flags {
+		if flags[i].Name == name {
+			return &flags[i]
+		}
+	}
+	return nil
+}
+
+func TestBuildIntrospectManifest(t *testing.T) {
+	m := buildIntrospectManifest(rootCmd)
+
+	if findIntrospectFlag(m.GlobalFlags, "stateless") == nil {
+		t.Fatal("expected --stateless among global flags")
+	}
+	if len(m.ExitCodes) != 7 || m.ExitCodes[2].Code != ExitFindings || m.ExitCodes[2].Category != "findings" {
+		t.Fatalf("unexpected exit codes: %+v", m.ExitCodes)
+	}
+
+	calc := findIntrospectCommand(t, m, "witan xlsx calc")
+	if len(calc.Args) != 1 || calc.Args[0].Type != "path" || !calc.Args[0].Required {
+		t.Fatalf("unexpected calc args: %+v", calc.Args)
+	}
+	if f := findIntrospectFlag(calc.Flags, "json"); f == nil || !f.Inherited || f.Type != "bool" {
+		t.Fatalf("expected inherited --json on calc, got %+v", f)
+	}
+	if findIntrospectFlag(calc.Flags, "stateless") != nil {
+		t.Fatal("root persistent flags belong under global_flags only")
+	}
+	schema, _ := json.Marshal(calc.Output)
+	var parsed struct {
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Type != "object" || parsed.Properties["touched"] == nil || parsed.Properties["errors"] == nil {
+		t.Fatalf("unexpected calc output schema: %s", schema)
+	}
+
+	merge := findIntrospectCommand(t, m, "witan xlsx merge")
+	if len(merge.Args) != 0 {
+		t.Fatalf("flag placeholders should not be args: %+v", merge.Args)
+	}
+	if f := findIntrospectFlag(merge.Flags, "take"); f == nil || !f.Required || !f.Repeatable {
+		t.Fatalf("expected required repeatable --take, got %+v", f)
+	}
+
+	read := findIntrospectCommand(t, m, "witan read")
+	if len(read.Args) != 1 || !read.Args[0].Variadic || read.Args[0].Type != "path_or_url" {
+		t.Fatalf("unexpected read args: %+v", read.Args)
+	}
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// jobPollInterval is how often jobs result --wait checks a running job.
+var jobPollInterval = 2 * time.Second
+
+// defaultJobWaitTimeout bounds how long jobs result --wait polls.
+const defaultJobWaitTimeout = 30 * time.Minute
+
+var (
+	jobsResultWait        bool
+	jobsResultWaitTimeout time.Duration
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Check and fetch asynchronous calc/exec jobs",
+	Long: `Check and fetch jobs submitted with --async on xlsx calc or xlsx exec.
+
+Submitting with --async prints the job ID and returns at once, so
+orchestration systems do not hold a connection open while the operation
+runs. Jobs need files-backed mode (sign in or set an API key).
+
+Examples:
+  id=$(witan xlsx calc model.xlsx --verify --async)
+  witan jobs status "$id"
+  witan jobs result "$id" --wait`,
+}
+
+var jobsStatusCmd = &cobra.Command{
+	Use:   "status <job-id>",
+	Short: "Show a job's status",
+	Long: `Show a job's status: queued, running, succeeded, failed, or canceled.
+
+Use --json for the full job object.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsStatus,
+}
+
+var jobsResultCmd = &cobra.Command{
+	Use:   "result <job-id>",
+	Short: "Print a finished job's result",
+	Long: `Print a finished job's result as JSON: the same response xlsx calc --json
+or xlsx exec --json would print.
+
+Behavior:
+  - Exits 1 if the job has not finished; --wait polls until it has.
+    --wait gives up and exits 1 after --wait-timeout (default 30m) or on
+    Ctrl-C; the job keeps running.
+  - Exits 1 with the job's error if it failed or was canceled.
+  - Results of async calc and exec are not written to local files; use the
+    revision_id in the result to fetch an updated workbook.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsResult,
+}
+
+func init() {
+	jobsResultCmd.Flags().BoolVar(&jobsResultWait, "wait", false, "Wait for the job to finish")
+	jobsResultCmd.Flags().DurationVar(&jobsResultWaitTimeout, "wait-timeout", defaultJobWaitTimeout, "Longest time --wait polls before giving up, e.g. 10m or 2h")
+	addResultOutputFlag(jobsResultCmd)
+	addResultOutputFlag(jobsStatusCmd)
+	jobsCmd.AddCommand(jobsStatusCmd)
+	jobsCmd.AddCommand(jobsResultCmd)
+	rootCmd.AddCommand(jobsCmd)
+}
+
+// newJobsClient returns a files-backed client; jobs do not exist in
+// stateless mode.
+func newJobsClient() (*client.Client, error) {
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return nil, err
+	}
+	c := newAPIClient(key, orgID)
+	if c.Stateless {
+		return nil, fmt.Errorf("jobs require files-backed mode: sign in with 'witan auth login' or set --api-key, and do not use --stateless")
+	}
+	return c, nil
+}
+
+// submitJob uploads filePath (or reuses its cached revision) and submits req
+// against it.
+func submitJob(c *client.Client, filePath string, req client.JobRequest) (*client.Job, error) {
+	if c.Stateless {
+		return nil, fmt.Errorf("--async requires files-backed mode: sign in with 'witan auth login' or set --api-key, and do not use --stateless")
+	}
+	fileID, revisionID, err := c.EnsureUploaded(filePath)
+	if err != nil {
+		return nil, err
+	}
+	req.FileID, req.Revision = fileID, revisionID
+	job, err := c.SubmitJob(req)
+	if client.IsNotFound(err) {
+		fileID, revisionID, err = c.ReuploadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		req.FileID, req.Revision = fileID, revisionID
+		job, err = c.SubmitJob(req)
+	}
+	return job, err
+}
+
+// outputSubmittedJob prints the job ID on stdout (or the job with --json)
+// and how to follow it on stderr.
+func outputSubmittedJob(job *client.Job, useJSON bool) error {
+	if err := emitResult(job, useJSON, func() error {
+		fmt.Println(job.ID)
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Submitted job %s (%s). Check it with: witan jobs status %s\n", job.ID, job.Status, job.ID)
+	return nil
+}
+
+func runJobsStatus(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	c, err := newJobsClient()
+	if err != nil {
+		return err
+	}
+	job, err := c.GetJob(args[0])
+	if err != nil {
+		return err
+	}
+	return emitResult(job, jsonOutput, func() error {
+		fmt.Printf("%s  %s  %s\n", job.ID, colorJobStatus(job.Status), job.Operation)
+		if job.CreatedAt != "" {
+			fmt.Printf("  created:   %s\n", job.CreatedAt)
+		}
+		if job.CompletedAt != "" {
+			fmt.Printf("  completed: %s\n", job.CompletedAt)
+		}
+		if job.Error != nil {
+			fmt.Printf("  error:     %s\n", job.Error.Message)
+		}
+		return nil
+	})
+}
+
+func runJobsResult(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if jobsResultWait && jobsResultWaitTimeout <= 0 {
+		return fmt.Errorf("--wait-timeout must be > 0")
+	}
+	c, err := newJobsClient()
+	if err != nil {
+		return err
+	}
+	id := args[0]
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	c = c.WithContext(ctx)
+
+	job, err := c.GetJob(id)
+	if err != nil {
+		return err
+	}
+	if !job.Done() && jobsResultWait {
+		err := pollUntil(ctx, jobPollInterval, jobsResultWaitTimeout,
+			func() string {
+				return fmt.Sprintf("timed out after %s waiting for job %s (still %s); check it with: witan jobs status %s", jobsResultWaitTimeout, id, job.Status, id)
+			},
+			func() (bool, error) {
+				next, err := c.GetJob(id)
+				if err != nil {
+					return false, err
+				}
+				job = next
+				return job.Done(), nil
+			})
+		if err != nil {
+			return err
+		}
+	}
+	switch {
+	case !job.Done():
+		return fmt.Errorf("job %s is %s; try again later or use --wait", id, job.Status)
+	case job.Status != "succeeded":
+		msg := job.Status
+		if job.Error != nil && job.Error.Message != "" {
+			msg += ": " + job.Error.Message
+		}
+		return fmt.Errorf("job %s %s", id, msg)
+	}
+
+	result, err := c.JobResult(id)
+	if err != nil {
+		return err
+	}
+	// The result is JSON with or without --json.
+	return emitResult(result, true, nil)
+}
+
+// colorJobStatus colors a job status for terminal output.
+func colorJobStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return colorize(status, ansiGreen)
+	case "failed", "canceled":
+		return colorize(status, ansiRed)
+	}
+	return colorize(status, ansiYellow)
+}
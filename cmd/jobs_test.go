@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunCalcAsync_SubmitsJobAndJobsResultWaits(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origCalcRanges := append([]string(nil), calcRanges...)
+	origCalcVerify := calcVerify
+	origCalcAsync := calcAsync
+	origJobsResultWait := jobsResultWait
+	origJobPollInterval := jobPollInterval
+	origJobsResultWaitTimeout := jobsResultWaitTimeout
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		calcRanges = origCalcRanges
+		calcVerify = origCalcVerify
+		calcAsync = origCalcAsync
+		jobsResultWait = origJobsResultWait
+		jobPollInterval = origJobPollInterval
+		jobsResultWaitTimeout = origJobsResultWaitTimeout
+	})
+
+	statusCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files":
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_1","status":"ready"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/jobs":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding job request: %v", err)
+			}
+			if body["operation"] != "xlsx.calc" || body["file_id"] != "file_1" || body["revision"] != "rev_1" {
+				t.Fatalf("unexpected job request: %v", body)
+			}
+			if params, _ := body["params"].(map[string]any); fmt.Sprint(params["verify"]) != "[true]" {
+				t.Fatalf("expected verify param, got %v", body["params"])
+			}
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"id":"job_1","object":"job","operation":"xlsx.calc","status":"queued"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/jobs/job_1":
+			statusCalls++
+			status := "running"
+			if statusCalls > 1 {
+				status = "succeeded"
+			}
+			fmt.Fprintf(w, `{"id":"job_1","object":"job","operation":"xlsx.calc","status":%q}`, status)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/jobs/job_1/result":
+			fmt.Fprint(w, `{"touched":{},"changed":["Sheet1!A1"],"errors":[]}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04async"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	mockMgmtOrgsServer(t)
+	apiKey = "test-key"
+	apiURL = server.URL
+	stateless = false
+	jsonOutput = false
+	calcRanges = nil
+	calcVerify = true
+	calcAsync = true
+
+	out, err := captureExecStdout(t, func() error {
+		return runCalc(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runCalc --async failed: %v", err)
+	}
+	if out != "job_1\n" {
+		t.Fatalf("expected the job ID on stdout, got %q", out)
+	}
+
+	jobsResultWait = false
+	if err := runJobsResult(&cobra.Command{}, []string{"job_1"}); err == nil || !strings.Contains(err.Error(), "running") {
+		t.Fatalf("expected not-finished error, got %v", err)
+	}
+
+	jobsResultWait = true
+	jobPollInterval = time.Millisecond
+	jobsResultWaitTimeout = time.Minute
+	out, err = captureExecStdout(t, func() error {
+		return runJobsResult(&cobra.Command{}, []string{"job_1"})
+	})
+	if err != nil {
+		t.Fatalf("runJobsResult --wait failed: %v", err)
+	}
+	if !strings.Contains(out, `"Sheet1!A1"`) {
+		t.Fatalf("expected calc result JSON, got %q", out)
+	}
+}
+
+func TestRunJobsResult_WaitTimesOut(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless := apiKey, apiURL, stateless
+	origWait, origTimeout, origInterval := jobsResultWait, jobsResultWaitTimeout, jobPollInterval
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless = origAPIKey, origAPIURL, origStateless
+		jobsResultWait, jobsResultWaitTimeout, jobPollInterval = origWait, origTimeout, origInterval
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v0/orgs/org_test/jobs/job_1" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"job_1","object":"job","operation":"xlsx.calc","status":"running"}`)
+	}))
+	defer server.Close()
+
+	mockMgmtOrgsServer(t)
+	apiKey, apiURL, stateless = "test-key", server.URL, false
+	jobsResultWait, jobsResultWaitTimeout, jobPollInterval = true, 20*time.Millisecond, time.Millisecond
+
+	err := runJobsResult(&cobra.Command{}, []string{"job_1"})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 20ms waiting for job job_1 (still running)") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+
+	jobsResultWaitTimeout = 0
+	if err := runJobsResult(&cobra.Command{}, []string{"job_1"}); err == nil || err.Error() != "--wait-timeout must be > 0" {
+		t.Fatalf("expected --wait-timeout error, got %v", err)
+	}
+}
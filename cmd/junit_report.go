@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// Report formats for --report FORMAT:PATH. A bare PATH is JSON.
+const (
+	reportFormatJSON  = "json"
+	reportFormatJUnit = "junit"
+)
+
+// parseReportSpec splits a --report value such as "junit:report.xml" into
+// its format and path.
+func parseReportSpec(spec string) (format, path string, err error) {
+	format, path = reportFormatJSON, spec
+	for _, f := range []string{reportFormatJSON, reportFormatJUnit} {
+		if rest, ok := strings.CutPrefix(spec, f+":"); ok {
+			format, path = f, rest
+			break
+		}
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("--report needs a path, e.g. junit:report.xml")
+	}
+	return format, path, nil
+}
+
+// writeReportFile writes a --report file: v as JSON, or suites as JUnit XML.
+func writeReportFile(spec string, v any, suites func() junitTestSuites) error {
+	format, path, err := parseReportSpec(spec)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if format == reportFormatJUnit {
+		data, err = xml.MarshalIndent(suites(), "", "  ")
+		data = append([]byte(xml.Header), data...)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
+
+// JUnit XML as read by CI systems: one <testsuite> per workbook, one
+// <testcase> per finding, and a passing "clean" case for a workbook without
+// findings so it still shows up in dashboards.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func newJUnitTestSuites(name string, suites ...junitTestSuite) junitTestSuites {
+	out := junitTestSuites{Name: name, Suites: suites}
+	for _, s := range suites {
+		out.Tests += s.Tests
+		out.Failures += s.Failures
+		out.Errors += s.Errors
+	}
+	return out
+}
+
+func (s *junitTestSuite) add(tc junitTestCase) {
+	tc.ClassName = s.Name
+	s.Cases = append(s.Cases, tc)
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	if tc.Error != nil {
+		s.Errors++
+	}
+}
+
+// addCleanCase records a passing case when the suite has no findings.
+func (s *junitTestSuite) addCleanCase(name string) {
+	if len(s.Cases) == 0 {
+		s.add(junitTestCase{Name: name})
+	}
+}
+
+// lintJUnitSuite maps each diagnostic to a test case. Diagnostics at or
+// above the --fail-on severity are failures; the rest pass, with the
+// message in system-out.
+func lintJUnitSuite(file string, result *client.LintResponse, failRank int) junitTestSuite {
+	suite := junitTestSuite{Name: file}
+	for _, d := range result.Diagnostics {
+		name := d.RuleId
+		if d.Location != nil {
+			name += " " + *d.Location
+		}
+		tc := junitTestCase{Name: name}
+		text := d.Severity + ": " + d.Message
+		if lintDiagnosticFails(d, failRank) {
+			tc.Failure = &junitFailure{Message: d.Message, Type: d.RuleId, Text: text}
+		} else {
+			tc.SystemOut = text
+		}
+		suite.add(tc)
+	}
+	suite.addCleanCase("lint")
+	return suite
+}
+
+// calcJUnitSuite maps each formula error and, with --verify, each changed
+// cell to a failing test case.
+func calcJUnitSuite(file string, result *client.CalcResponse) junitTestSuite {
+	suite := junitTestSuite{Name: file}
+	for _, e := range result.Errors {
+		text := e.Code
+		if e.Formula != nil {
+			text += " in " + *e.Formula
+		}
+		if e.Detail != nil {
+			text += ": " + *e.Detail
+		}
+		suite.add(junitTestCase{
+			Name:    e.Address,
+			Failure: &junitFailure{Message: e.Code, Type: "formula-error", Text: text},
+		})
+	}
+	for _, addr := range result.Changed {
+		text := "value changed on recalculation"
+		if cell, ok := result.Touched[addr]; ok {
+			text += " (now " + cell.Value + ")"
+		}
+		suite.add(junitTestCase{
+			Name:    addr,
+			Failure: &junitFailure{Message: "value changed", Type: "changed", Text: text},
+		})
+	}
+	suite.addCleanCase("verify")
+	return suite
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestParseReportSpec(t *testing.T) {
+	cases := map[string][2]string{
+		"junit:out/report.xml": {"junit", "out/report.xml"},
+		"json:verify.json":     {"json", "verify.json"},
+		"verify.json":          {"json", "verify.json"},
+		`C:\reports\v.json`:    {"json", `C:\reports\v.json`},
+	}
+	for spec, want := range cases {
+		format, path, err := parseReportSpec(spec)
+		if err != nil || format != want[0] || path != want[1] {
+			t.Errorf("parseReportSpec(%q) = %q, %q, %v; want %q, %q", spec, format, path, err, want[0], want[1])
+		}
+	}
+	if _, _, err := parseReportSpec("junit:"); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
+
+func TestWriteReportFile_LintJUnit(t *testing.T) {
+	loc := "Sheet1!B7"
+	result := &client.LintResponse{Diagnostics: []client.LintDiagnostic{
+		{Severity: "Warning", RuleId: "D031", Message: "misspelled <word>", Location: &loc},
+		{Severity: "Info", RuleId: "D030", Message: "hint"},
+	}, Total: 2}
+	path := filepath.Join(t.TempDir(), "lint.xml")
+	err := writeReportFile("junit:"+path, result, func() junitTestSuites {
+		return newJUnitTestSuites("witan xlsx lint", lintJUnitSuite("book.xlsx", result, 2))
+	})
+	if err != nil {
+		t.Fatalf("writeReportFile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") {
+		t.Fatalf("expected an XML header, got %q", data[:20])
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	if got.Tests != 2 || got.Failures != 1 || len(got.Suites) != 1 {
+		t.Fatalf("unexpected totals: %+v", got)
+	}
+	cases := got.Suites[0].Cases
+	if cases[0].Name != "D031 Sheet1!B7" || cases[0].ClassName != "book.xlsx" || cases[0].Failure == nil || cases[0].Failure.Message != "misspelled <word>" {
+		t.Fatalf("unexpected failing case: %+v", cases[0])
+	}
+	if cases[1].Failure != nil || cases[1].SystemOut != "Info: hint" {
+		t.Fatalf("info below --fail-on should pass: %+v", cases[1])
+	}
+}
+
+func TestCalcJUnitSuite_CleanWorkbookPasses(t *testing.T) {
+	suite := calcJUnitSuite("book.xlsx", &client.CalcResponse{})
+	if suite.Tests != 1 || suite.Failures != 0 || suite.Cases[0].Name != "verify" {
+		t.Fatalf("unexpected suite: %+v", suite)
+	}
+
+	suite = calcJUnitSuite("book.xlsx", &client.CalcResponse{
+		Errors:  []client.CellError{{Address: "Sheet1!A1", Code: "#REF!"}},
+		Changed: []string{"Sheet1!B2"},
+		Touched: map[string]client.CalcTouchedCell{"Sheet1!B2": {Value: "42"}},
+	})
+	if suite.Tests != 2 || suite.Failures != 2 || suite.Cases[1].Failure.Text != "value changed on recalculation (now 42)" {
+		t.Fatalf("unexpected suite: %+v", suite)
+	}
+}
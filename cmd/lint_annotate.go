@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// lintAnnotateScript adds one comment per annotated cell. Cells that already
+// have a comment get the findings as a reply so existing threads are kept.
+const lintAnnotateScript = `// @office-script
+function main(workbook, input) {
+  let annotated = 0;
+  for (const note of input.notes) {
+    let existing = null;
+    try {
+      existing = workbook.getCommentByCell(note.address);
+    } catch (e) {
+      existing = null;
+    }
+    if (existing) {
+      existing.addCommentReply(note.text);
+    } else {
+      workbook.addComment(note.address, note.text);
+    }
+    annotated++;
+  }
+  return annotated;
+}`
+
+// lintNote is one cell comment written by --annotate.
+type lintNote struct {
+	Address string `json:"address"`
+	Text    string `json:"text"`
+}
+
+// defaultAnnotatePath returns the copy written by --annotate when no
+// --annotate-output is given: book.xlsx -> book.lint.xlsx.
+func defaultAnnotatePath(filePath string) string {
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + ".lint" + ext
+}
+
+// lintNotes groups diagnostics by the top-left cell of their location, one
+// note per cell. Diagnostics without a cell location are skipped.
+func lintNotes(diagnostics []client.LintDiagnostic) []lintNote {
+	byCell := map[string][]string{}
+	for _, d := range diagnostics {
+		if d.Location == nil {
+			continue
+		}
+		sheet, row, col, _, _, err := internal.ParseRange(upperRangePart(*d.Location))
+		if err != nil {
+			continue
+		}
+		address := internal.FormatAddress(internal.QuoteSheetName(sheet), row, col, row, col)
+		byCell[address] = append(byCell[address], fmt.Sprintf("[%s %s] %s", d.RuleId, d.Severity, d.Message))
+	}
+
+	notes := make([]lintNote, 0, len(byCell))
+	for address, lines := range byCell {
+		notes = append(notes, lintNote{Address: address, Text: "witan lint:\n" + strings.Join(lines, "\n")})
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Address < notes[j].Address })
+	return notes
+}
+
+// annotateLintFindings copies filePath to outPath and writes each finding
+// as a cell comment in the copy. The original workbook is not modified.
+func annotateLintFindings(c *client.Client, filePath, outPath string, result *client.LintResponse) (int, error) {
+	notes := lintNotes(result.Diagnostics)
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("reading workbook: %w", err)
+	}
+	if err := os.WriteFile(outPath, data, 0o644); err != nil {
+		return 0, fmt.Errorf("writing annotated copy: %w", err)
+	}
+	if len(notes) == 0 {
+		return 0, nil
+	}
+
+	req := client.ExecRequest{
+		Code:  lintAnnotateScript,
+		Input: map[string]any{"notes": notes},
+	}
+	execResult, err := execWorkbook(c, outPath, req, true, false, "", "")
+	if err != nil {
+		return 0, fmt.Errorf("annotating workbook: %w", err)
+	}
+	if !execResult.Ok {
+		return 0, fmt.Errorf("annotating workbook: %s", formatExecError(execResult.Error))
+	}
+	return len(notes), nil
+}
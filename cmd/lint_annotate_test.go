@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestLintNotes_GroupsByTopLeftCell(t *testing.T) {
+	loc := func(s string) *string { return &s }
+	notes := lintNotes([]client.LintDiagnostic{
+		{Severity: "Warning", RuleId: "D001", Message: "double counting", Location: loc("Sheet1!b2:C4")},
+		{Severity: "Error", RuleId: "D004", Message: "#DIV/0!", Location: loc("Sheet1!B2")},
+		{Severity: "Warning", RuleId: "D041", Message: "overlap", Location: loc("'My Sheet'!A1")},
+		{Severity: "Warning", RuleId: "D110", Message: "chart"},
+	})
+	if len(notes) != 2 {
+		t.Fatalf("expected 2 notes, got %+v", notes)
+	}
+	if notes[0].Address != "'My Sheet'!A1" || notes[1].Address != "Sheet1!B2" {
+		t.Fatalf("unexpected addresses: %+v", notes)
+	}
+	if want := "witan lint:\n[D001 Warning] double counting\n[D004 Error] #DIV/0!"; notes[1].Text != want {
+		t.Fatalf("note text = %q, want %q", notes[1].Text, want)
+	}
+}
+
+func TestRunLint_AnnotateWritesCommentedCopy(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origLintAnnotate := lintAnnotate
+	origLintAnnotateTo := lintAnnotateTo
+	origLintNoConfig := lintNoConfig
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		lintAnnotate = origLintAnnotate
+		lintAnnotateTo = origLintAnnotateTo
+		lintNoConfig = origLintNoConfig
+	})
+
+	annotated := []byte("PK\x03\x04annotated")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/xlsx/lint":
+			fmt.Fprint(w, `{"diagnostics":[{"severity":"Warning","ruleId":"D001","message":"double counting","location":"Sheet1!B2"}],"total":1}`)
+		case "/v0/xlsx/exec":
+			body, _ := io.ReadAll(r.Body)
+			if r.URL.Query().Get("save") != "true" || !bytes.Contains(body, []byte(`Sheet1!B2`)) || !bytes.Contains(body, []byte("addComment")) {
+				t.Errorf("unexpected annotate request: %s\n%s", r.URL, body)
+			}
+			fmt.Fprintf(w, `{"ok":true,"stdout":"","result":1,"writes_detected":true,"file":"%s"}`, base64.StdEncoding.EncodeToString(annotated))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	original := []byte("PK\x03\x04original")
+	if err := os.WriteFile(filePath, original, 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = true
+	lintAnnotate = true
+	lintAnnotateTo = ""
+	lintNoConfig = true
+
+	output, err := captureExecStdout(t, func() error {
+		return runLint(&cobra.Command{}, []string{filePath})
+	})
+	if err == nil || !strings.Contains(output, "D001") {
+		t.Fatalf("expected lint findings and exit 2, got err=%v output=%s", err, output)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "book.lint.xlsx"))
+	if err != nil || !bytes.Equal(got, annotated) {
+		t.Fatalf("annotated copy = %q, %v", got, err)
+	}
+	if after, _ := os.ReadFile(filePath); !bytes.Equal(after, original) {
+		t.Fatalf("original workbook was modified: %q", after)
+	}
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/internal"
+	"gopkg.in/yaml.v3"
+)
+
+// lintConfigNames are the project lint config file names, in lookup order.
+var lintConfigNames = []string{".witanlint.yml", ".witanlint.yaml"}
+
+// lintConfig is a project-level .witanlint.yml:
+//
+//	rules:
+//	  D001: off        # off, on, error, warning, or info
+//	  D003: info
+//	exclude:
+//	  - range: "Assumptions!A1:Z200"
+//	    rules: [D003]  # omit to exclude every rule
+//	  - range: Scratch # a bare sheet name excludes the whole sheet
+//	dictionary:        # words the D031 spelling check accepts
+//	  - EBITDA
+//	fail_on: error     # error, warning, info, or none
+type lintConfig struct {
+	Rules      map[string]string   `yaml:"rules"`
+	Exclude    []lintConfigExclude `yaml:"exclude"`
+	Dictionary []string            `yaml:"dictionary"`
+	FailOn     string              `yaml:"fail_on"`
+}
+
+type lintConfigExclude struct {
+	Range string   `yaml:"range"`
+	Rules []string `yaml:"rules"`
+}
+
+var lintConfigSeverities = map[string]string{
+	"error":   "Error",
+	"warning": "Warning",
+	"info":    "Info",
+}
+
+// findLintConfig returns the nearest lint config file in dir or one of its
+// parents, or "" if there is none.
+func findLintConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range lintConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadLintConfig reads and validates a lint config file.
+func loadLintConfig(path string) (*lintConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading lint config: %w", err)
+	}
+	cfg := &lintConfig{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	rules := make(map[string]string, len(cfg.Rules))
+	for id, setting := range cfg.Rules {
+		setting = strings.ToLower(strings.TrimSpace(setting))
+		if _, ok := lintConfigSeverities[setting]; !ok && setting != "off" && setting != "on" {
+			return nil, fmt.Errorf("%s: rule %s: unknown setting %q (use off, on, error, warning, or info)", path, id, setting)
+		}
+		rules[strings.ToUpper(id)] = setting
+	}
+	cfg.Rules = rules
+
+	if cfg.FailOn != "" {
+		if _, err := lintFailOnRank(cfg.FailOn); err != nil {
+			return nil, fmt.Errorf("%s: fail_on: %w", path, err)
+		}
+	}
+
+	for i, ex := range cfg.Exclude {
+		if strings.TrimSpace(ex.Range) == "" {
+			return nil, fmt.Errorf("%s: exclude[%d]: range is required", path, i)
+		}
+		if strings.Contains(ex.Range, "!") {
+			if _, _, _, _, _, err := internal.ParseRange(upperRangePart(ex.Range)); err != nil {
+				return nil, fmt.Errorf("%s: exclude[%d]: %w", path, i, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// resolveLintConfig picks the config for filePath: explicit when set,
+// otherwise the nearest .witanlint.yml above the workbook. It returns nil
+// when disabled or when no config exists.
+func resolveLintConfig(filePath, explicit string, disabled bool) (*lintConfig, error) {
+	if disabled {
+		return nil, nil
+	}
+	path := explicit
+	if path == "" {
+		var err error
+		if path, err = findLintConfig(filepath.Dir(filePath)); err != nil || path == "" {
+			return nil, err
+		}
+	}
+	return loadLintConfig(path)
+}
+
+// applyParams adds the config's disabled rules and dictionary to the lint
+// request. Rules named with --only-rule are never skipped.
+func (cfg *lintConfig) applyParams(params url.Values, onlyRules []string) {
+	ids := make([]string, 0, len(cfg.Rules))
+	for id := range cfg.Rules {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		if cfg.Rules[id] == "off" && !slices.ContainsFunc(onlyRules, func(r string) bool { return strings.EqualFold(r, id) }) {
+			params.Add("skipRule", id)
+		}
+	}
+	for _, word := range cfg.Dictionary {
+		params.Add("dictionary", word)
+	}
+}
+
+// filter applies severity overrides and range exclusions to the response.
+func (cfg *lintConfig) filter(result *client.LintResponse) {
+	kept := result.Diagnostics[:0]
+	for _, d := range result.Diagnostics {
+		if cfg.excludes(d) {
+			continue
+		}
+		if severity, ok := lintConfigSeverities[cfg.Rules[strings.ToUpper(d.RuleId)]]; ok {
+			d.Severity = severity
+		}
+		kept = append(kept, d)
+	}
+	result.Diagnostics = kept
+	result.Total = len(kept)
+}
+
+// excludes reports whether d's location starts inside an excluded range
+// that applies to its rule.
+func (cfg *lintConfig) excludes(d client.LintDiagnostic) bool {
+	if d.Location == nil || len(cfg.Exclude) == 0 {
+		return false
+	}
+	sheet, row, col, _, _, err := internal.ParseRange(upperRangePart(*d.Location))
+	if err != nil {
+		return false
+	}
+	for _, ex := range cfg.Exclude {
+		if len(ex.Rules) > 0 && !slices.ContainsFunc(ex.Rules, func(r string) bool { return strings.EqualFold(r, d.RuleId) }) {
+			continue
+		}
+		if !strings.Contains(ex.Range, "!") {
+			if strings.EqualFold(strings.Trim(ex.Range, "'"), sheet) {
+				return true
+			}
+			continue
+		}
+		exSheet, r1, c1, r2, c2, err := internal.ParseRange(upperRangePart(ex.Range))
+		if err != nil || !strings.EqualFold(exSheet, sheet) {
+			continue
+		}
+		if row >= r1 && row <= r2 && col >= c1 && col <= c2 {
+			return true
+		}
+	}
+	return false
+}
+
+// upperRangePart uppercases the cell part of a sheet-qualified address so
+// "Sheet1!a1:b2" parses like "Sheet1!A1:B2".
+func upperRangePart(address string) string {
+	sheet, ref, ok := strings.Cut(address, "!")
+	if !ok {
+		return address
+	}
+	return sheet + "!" + strings.ToUpper(ref)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestResolveLintConfig_DiscoversUpwardAndApplies(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".witanlint.yml"), []byte(`
+rules:
+  D001: off
+  d003: Info
+exclude:
+  - range: "Assumptions!A1:c10"
+    rules: [D005]
+  - range: Scratch
+dictionary: [EBITDA, Capex]
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	workbook := filepath.Join(root, "models", "q3", "book.xlsx")
+
+	cfg, err := resolveLintConfig(workbook, "", false)
+	if err != nil || cfg == nil {
+		t.Fatalf("resolveLintConfig = %v, %v", cfg, err)
+	}
+	if cfg, _ := resolveLintConfig(workbook, "", true); cfg != nil {
+		t.Fatal("--no-config must disable discovery")
+	}
+
+	params := url.Values{}
+	cfg.applyParams(params, nil)
+	if got := params["skipRule"]; len(got) != 1 || got[0] != "D001" {
+		t.Fatalf("skipRule = %v", got)
+	}
+	if got := strings.Join(params["dictionary"], ","); got != "EBITDA,Capex" {
+		t.Fatalf("dictionary = %s", got)
+	}
+	params = url.Values{}
+	cfg.applyParams(params, []string{"d001"})
+	if len(params["skipRule"]) != 0 {
+		t.Fatalf("--only-rule must override a disabled rule, got %v", params)
+	}
+
+	loc := func(s string) *string { return &s }
+	result := &client.LintResponse{Diagnostics: []client.LintDiagnostic{
+		{Severity: "Warning", RuleId: "D003", Location: loc("Summary!B2")},
+		{Severity: "Warning", RuleId: "D005", Location: loc("Assumptions!B5")},
+		{Severity: "Warning", RuleId: "D005", Location: loc("Assumptions!D5")},
+		{Severity: "Warning", RuleId: "D002", Location: loc("Assumptions!B5")},
+		{Severity: "Error", RuleId: "D004", Location: loc("scratch!A1")},
+	}, Total: 5}
+	cfg.filter(result)
+
+	var got []string
+	for _, d := range result.Diagnostics {
+		got = append(got, d.RuleId+":"+d.Severity+":"+*d.Location)
+	}
+	want := "D003:Info:Summary!B2,D005:Warning:Assumptions!D5,D002:Warning:Assumptions!B5"
+	if strings.Join(got, ",") != want || result.Total != 3 {
+		t.Fatalf("filtered = %s (total %d), want %s", strings.Join(got, ","), result.Total, want)
+	}
+}
+
+func TestLoadLintConfig_RejectsInvalidSettings(t *testing.T) {
+	cases := map[string]string{
+		"rules:\n  D001: loud\n":              `unknown setting "loud"`,
+		"exclude:\n  - rules: [D001]\n":       "range is required",
+		"exclude:\n  - range: Sheet1!A1:ZZ\n": "invalid end of range",
+		"rule:\n  D001: off\n":                "field rule not found",
+	}
+	for content, want := range cases {
+		path := filepath.Join(t.TempDir(), ".witanlint.yml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadLintConfig(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loadLintConfig(%q) = %v, want error containing %q", content, err, want)
+		}
+	}
+}
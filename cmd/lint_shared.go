@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/witanlabs/witan-cli/client"
 )
@@ -20,9 +21,70 @@ const LintRulesHelp = `Available rules:
   D023 (Warning): Currency values mixed with non-currency semantic formats (percent/date/time/text)
   D030 (Warning): Formula references a non-anchor cell in a merged range`
 
+// defaultLintFailOn is the --fail-on threshold when neither the flag nor a
+// lint config sets one: any Error or Warning fails.
+const defaultLintFailOn = "warning"
+
+// lintSeverityRanks orders severities for --fail-on; unknown severities rank
+// as Info.
+var lintSeverityRanks = map[string]int{"Error": 3, "Warning": 2, "Info": 1}
+
+// lintFailOnRank converts a --fail-on value to the lowest severity rank that
+// fails the run; "none" never fails.
+func lintFailOnRank(failOn string) (int, error) {
+	switch strings.ToLower(failOn) {
+	case "error":
+		return 3, nil
+	case "warning":
+		return 2, nil
+	case "info":
+		return 1, nil
+	case "none":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("invalid --fail-on %q: use error, warning, info, or none", failOn)
+}
+
+// parseLintSeverityOverrides parses --severity RULE=LEVEL values into a map
+// of upper-cased rule IDs to API severity names.
+func parseLintSeverityOverrides(values []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(values))
+	for _, v := range values {
+		rule, level, ok := strings.Cut(v, "=")
+		severity, known := lintConfigSeverities[strings.ToLower(strings.TrimSpace(level))]
+		if !ok || strings.TrimSpace(rule) == "" || !known {
+			return nil, fmt.Errorf("invalid --severity %q: use RULE=error|warning|info, e.g. D031=error", v)
+		}
+		overrides[strings.ToUpper(strings.TrimSpace(rule))] = severity
+	}
+	return overrides, nil
+}
+
+// applyLintSeverityOverrides rewrites the severity of diagnostics whose rule
+// has an override.
+func applyLintSeverityOverrides(result *client.LintResponse, overrides map[string]string) {
+	for i, d := range result.Diagnostics {
+		if severity, ok := overrides[strings.ToUpper(d.RuleId)]; ok {
+			result.Diagnostics[i].Severity = severity
+		}
+	}
+}
+
+// lintTemplateData is what --template renders for lint: the response plus
+// the file it describes.
+type lintTemplateData struct {
+	*client.LintResponse
+	File string
+}
+
 // outputLintResult outputs lint diagnostics in either JSON or human-readable format.
-// Returns exit code 2 if any errors or warnings are found.
-func outputLintResult(result *client.LintResponse, useJSON bool) error {
+// Returns exit code 2 if any diagnostic is at or above the failOn severity.
+func outputLintResult(result *client.LintResponse, file string, useJSON bool, failOn string) error {
+	failRank, err := lintFailOnRank(failOn)
+	if err != nil {
+		return err
+	}
+
 	// Group diagnostics by severity
 	var errors, warnings, infos []client.LintDiagnostic
 	for _, d := range result.Diagnostics {
@@ -36,11 +98,11 @@ func outputLintResult(result *client.LintResponse, useJSON bool) error {
 		}
 	}
 
-	if useJSON {
-		if err := jsonPrint(result); err != nil {
-			return err
+	if err := emitResultAs(result, lintTemplateData{result, file}, useJSON, func() error {
+		if resultFormat == resultFormatGitHub {
+			printLintAnnotations(file, result.Diagnostics)
+			return nil
 		}
-	} else {
 		// Sort each group by location
 		sortDiagnostics := func(diags []client.LintDiagnostic) {
 			sort.Slice(diags, func(i, j int) bool {
@@ -78,28 +140,42 @@ func outputLintResult(result *client.LintResponse, useJSON bool) error {
 			fmt.Print("s")
 		}
 		fmt.Printf(", %d info)\n", len(infos))
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	// Exit with code 2 if any errors or warnings
-	if len(errors) > 0 || len(warnings) > 0 {
-		return &ExitError{Code: 2}
+	// Exit with code 2 if any diagnostic reaches the --fail-on threshold
+	for _, d := range result.Diagnostics {
+		if lintDiagnosticFails(d, failRank) {
+			return &ExitError{Code: ExitFindings}
+		}
 	}
 	return nil
 }
 
+// lintDiagnosticFails reports whether d reaches the --fail-on threshold.
+func lintDiagnosticFails(d client.LintDiagnostic, failRank int) bool {
+	rank, ok := lintSeverityRanks[d.Severity]
+	if !ok {
+		rank = lintSeverityRanks["Info"]
+	}
+	return failRank > 0 && rank >= failRank
+}
+
 // printDiagnosticGroup prints a group of diagnostics with the same severity.
 func printDiagnosticGroup(severity string, diagnostics []client.LintDiagnostic) {
 	if len(diagnostics) == 0 {
 		return
 	}
 
-	fmt.Printf("%s (%d):\n", severity, len(diagnostics))
+	fmt.Println(colorSeverity(severity, fmt.Sprintf("%s (%d):", severity, len(diagnostics))))
 	for _, d := range diagnostics {
 		location := ""
 		if d.Location != nil {
 			location = *d.Location
 		}
-		fmt.Printf("  %-6s %-20s %s\n", d.RuleId, location, d.Message)
+		fmt.Printf("  %s %-20s %s\n", colorSeverity(severity, fmt.Sprintf("%-6s", d.RuleId)), location, d.Message)
 	}
 	fmt.Println()
 }
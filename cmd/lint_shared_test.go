@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestOutputLintResult_FailOnThreshold(t *testing.T) {
+	result := func() *client.LintResponse {
+		return &client.LintResponse{Diagnostics: []client.LintDiagnostic{
+			{Severity: "Warning", RuleId: "D031", Message: "misspelled"},
+			{Severity: "Info", RuleId: "D003", Message: "empty ref"},
+		}, Total: 2}
+	}
+
+	cases := []struct {
+		failOn    string
+		overrides []string
+		wantExit  bool
+	}{
+		{"warning", nil, true},
+		{"error", nil, false},
+		{"error", []string{"d031=Error"}, true},
+		{"info", []string{"D031=info"}, true},
+		{"none", []string{"D031=error"}, false},
+	}
+	for _, tc := range cases {
+		overrides, err := parseLintSeverityOverrides(tc.overrides)
+		if err != nil {
+			t.Fatalf("parseLintSeverityOverrides(%v): %v", tc.overrides, err)
+		}
+		r := result()
+		applyLintSeverityOverrides(r, overrides)
+		_, err = captureExecStdout(t, func() error {
+			return outputLintResult(r, "book.xlsx", true, tc.failOn)
+		})
+		var exitErr *ExitError
+		if got := errors.As(err, &exitErr) && exitErr.Code == 2; got != tc.wantExit {
+			t.Errorf("fail-on %s with %v: exit 2 = %v, want %v (err %v)", tc.failOn, tc.overrides, got, tc.wantExit, err)
+		}
+	}
+
+	if err := outputLintResult(result(), "book.xlsx", true, "fatal"); err == nil || !strings.Contains(err.Error(), "invalid --fail-on") {
+		t.Fatalf("expected invalid --fail-on error, got %v", err)
+	}
+	for _, bad := range []string{"D031", "D031=loud", "=error"} {
+		if _, err := parseLintSeverityOverrides([]string{bad}); err == nil {
+			t.Errorf("parseLintSeverityOverrides(%q) should fail", bad)
+		}
+	}
+}
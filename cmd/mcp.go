@@ -0,0 +1,579 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// mcpProtocolVersion is the newest Model Context Protocol revision the
+// server speaks. Clients asking for an older supported revision get it back.
+const mcpProtocolVersion = "2025-06-18"
+
+var mcpSupportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// mcpMaxMessageBytes bounds a single JSON-RPC line on stdin; exec scripts
+// and input payloads can be large.
+const mcpMaxMessageBytes = 64 << 20
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run a Model Context Protocol server on stdio",
+	Long: `Run a Model Context Protocol (MCP) server over stdio.
+
+The server reads newline-delimited JSON-RPC 2.0 messages on stdin and writes
+responses on stdout. Diagnostics (including --verbose request logs) go to
+stderr. Tools operate on local file paths, exactly like the matching
+commands, and use the same auth, org, and stateless settings.
+
+Tools:
+  xlsx_calc     Recalculate a workbook and report errors (like "xlsx calc")
+  xlsx_exec     Run JavaScript against a workbook (like "xlsx exec")
+  xlsx_lint     Lint workbook formulas (like "xlsx lint")
+  xlsx_render   Render a range as an image (like "xlsx render")
+  read          Extract text or an outline from a document (like "read")
+
+Tool results are the JSON responses from the API; render returns an image.
+
+Example MCP client configuration:
+  {"mcpServers": {"witan": {"command": "witan", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	mcpCmd.SilenceUsage = true
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	s := &mcpServer{client: newAPIClient(key, orgID)}
+	return s.serve(cmd.InOrStdin(), cmd.OutOrStdout())
+}
+
+// mcpServer answers MCP requests one at a time with a shared API client, so
+// stateful mode reuses uploaded revisions across tool calls.
+type mcpServer struct {
+	client *client.Client
+}
+
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC 2.0 error codes.
+const (
+	mcpParseError     = -32700
+	mcpInvalidRequest = -32600
+	mcpMethodNotFound = -32601
+	mcpInvalidParams  = -32602
+)
+
+type mcpContent struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+type mcpTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+
+	call func(s *mcpServer, args json.RawMessage) (*mcpToolResult, error)
+}
+
+// serve processes messages until in is exhausted. Malformed lines get a
+// JSON-RPC error response; they never stop the server.
+func (s *mcpServer) serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), mcpMaxMessageBytes)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if resp := s.handle([]byte(line)); resp != nil {
+			if err := enc.Encode(resp); err != nil {
+				return fmt.Errorf("writing MCP response: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading MCP input: %w", err)
+	}
+	return nil
+}
+
+// handle dispatches one message and returns the response to send, or nil
+// for notifications.
+func (s *mcpServer) handle(raw []byte) *mcpResponse {
+	var req mcpRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return &mcpResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &mcpError{Code: mcpParseError, Message: err.Error()}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &mcpResponse{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &mcpError{Code: mcpInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}}
+	}
+	if len(req.ID) == 0 {
+		// Notifications (notifications/initialized, cancellations) need no reply.
+		return nil
+	}
+
+	resp := &mcpResponse{JSONRPC: "2.0", ID: req.ID}
+	result, rpcErr := s.dispatch(req)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+func (s *mcpServer) dispatch(req mcpRequest) (any, *mcpError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := mcpProtocolVersion
+		for _, v := range mcpSupportedVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "witan", "version": strings.TrimSpace(Version)},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &mcpError{Code: mcpInvalidParams, Message: err.Error()}
+		}
+		tool := findMCPTool(params.Name)
+		if tool == nil {
+			return nil, &mcpError{Code: mcpInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		result, err := tool.call(s, params.Arguments)
+		if err != nil {
+			// Tool failures are reported in-band so the model can react.
+			return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return result, nil
+	default:
+		return nil, &mcpError{Code: mcpMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func findMCPTool(name string) *mcpTool {
+	for i := range mcpTools {
+		if mcpTools[i].Name == name {
+			return &mcpTools[i]
+		}
+	}
+	return nil
+}
+
+// mcpJSONResult wraps an API response as a single JSON text block.
+func mcpJSONResult(v any) (*mcpToolResult, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding result: %w", err)
+	}
+	return &mcpToolResult{Content: []mcpContent{{Type: "text", Text: string(b)}}}, nil
+}
+
+// decodeMCPArgs strictly decodes tool arguments so typos surface as errors.
+func decodeMCPArgs(raw json.RawMessage, v any) error {
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// mcpWorkbookPath validates a workbook argument the way the xlsx commands do.
+func mcpWorkbookPath(file string) (string, error) {
+	if strings.TrimSpace(file) == "" {
+		return "", errors.New("file is required")
+	}
+	return fixExcelExtension(file)
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "xlsx_calc",
+		Description: "Recalculate formulas in a local .xlsx workbook and report formula errors. Writes recalculated values back to the file unless verify is true. Ranges accept A1 or R1C1 addresses, defined names, or table references.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"file":{"type":"string","description":"Path to the workbook"},` +
+			`"ranges":{"type":"array","items":{"type":"string"},"description":"Ranges to seed recalculation from (default: whole workbook)"},` +
+			`"verify":{"type":"boolean","description":"Check without writing back; reports cells whose values would change"}` +
+			`},"required":["file"]}`),
+		call: (*mcpServer).callCalc,
+	},
+	{
+		Name:        "xlsx_exec",
+		Description: "Run JavaScript against a local .xlsx workbook using the xlsx API (the workbook is the global wb). Returns stdout and the script's JSON result. With save, writes changes back to the file; with create, starts a new .xlsx at file.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"file":{"type":"string","description":"Path to the workbook (or new .xlsx target with create)"},` +
+			`"code":{"type":"string","description":"Script body; use return to produce a result"},` +
+			`"input":{"description":"JSON value passed to the script as input"},` +
+			`"locale":{"type":"string","description":"Execution locale, e.g. en-US"},` +
+			`"timeout_ms":{"type":"integer","minimum":1,"description":"Execution timeout in milliseconds"},` +
+			`"save":{"type":"boolean","description":"Write the modified workbook back to file"},` +
+			`"create":{"type":"boolean","description":"Create a new workbook at file (must not exist)"}` +
+			`},"required":["file","code"]}`),
+		call: (*mcpServer).callExec,
+	},
+	{
+		Name:        "xlsx_lint",
+		Description: "Run semantic formula lint rules on a local .xlsx workbook and return diagnostics.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"file":{"type":"string","description":"Path to the workbook"},` +
+			`"ranges":{"type":"array","items":{"type":"string"},"description":"Ranges to lint (default: whole workbook)"},` +
+			`"skip_rules":{"type":"array","items":{"type":"string"},"description":"Rule IDs to skip"},` +
+			`"only_rules":{"type":"array","items":{"type":"string"},"description":"Run only these rule IDs"}` +
+			`},"required":["file"]}`),
+		call: (*mcpServer).callLint,
+	},
+	{
+		Name:        "xlsx_render",
+		Description: "Render a sheet range of a local .xlsx workbook as an image.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"file":{"type":"string","description":"Path to the workbook"},` +
+			`"range":{"type":"string","description":"Sheet-qualified range, defined name, or table reference, e.g. Sheet1!A1:F20"},` +
+			`"dpr":{"type":"integer","minimum":1,"maximum":3,"description":"Device pixel ratio (default: auto)"},` +
+			`"format":{"type":"string","enum":["png","webp"],"description":"Image format (default: png)"}` +
+			`},"required":["file","range"]}`),
+		call: (*mcpServer).callRender,
+	},
+	{
+		Name:        "read",
+		Description: "Extract text (or an outline) from a local document or HTTP(S) URL: PDF, Word, Excel, PowerPoint, HTML, EPUB, RTF, ODT, email (.eml/.msg), plain text, or an image (read with OCR).",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"input":{"type":"string","description":"File path or HTTP(S) URL"},` +
+			`"outline":{"type":"boolean","description":"Return the document structure instead of content"},` +
+			`"pages":{"type":"string","description":"PDF page range, e.g. 1-5"},` +
+			`"slides":{"type":"string","description":"Slide range, e.g. 1-3"},` +
+			`"offset":{"type":"integer","minimum":1,"description":"Start line (1-indexed)"},` +
+			`"limit":{"type":"integer","minimum":1,"description":"Maximum lines to return"},` +
+			`"ocr":{"type":"boolean","description":"Recognize text from page images, for scanned PDFs"},` +
+			`"lang":{"type":"array","items":{"type":"string"},"description":"OCR language hints, e.g. [\"en\", \"de\"]"}` +
+			`},"required":["input"]}`),
+		call: (*mcpServer).callRead,
+	},
+}
+
+func (s *mcpServer) callCalc(raw json.RawMessage) (*mcpToolResult, error) {
+	var args struct {
+		File   string   `json:"file"`
+		Ranges []string `json:"ranges"`
+		Verify bool     `json:"verify"`
+	}
+	if err := decodeMCPArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	filePath, err := mcpWorkbookPath(args.File)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := resolveRangeAddresses(s.client, filePath, args.Ranges)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	for _, r := range ranges {
+		params.Add("address", r)
+	}
+	if args.Verify {
+		params.Set("verify", "true")
+	}
+	saveTo := ""
+	if !args.Verify {
+		saveTo = filePath
+	}
+	result, err := calcWorkbook(s.client, filePath, params, saveTo, "")
+	if err != nil {
+		return nil, err
+	}
+	return mcpJSONResult(result)
+}
+
+func (s *mcpServer) callExec(raw json.RawMessage) (*mcpToolResult, error) {
+	var args struct {
+		File      string `json:"file"`
+		Code      string `json:"code"`
+		Input     any    `json:"input"`
+		Locale    string `json:"locale"`
+		TimeoutMS int    `json:"timeout_ms"`
+		Save      bool   `json:"save"`
+		Create    bool   `json:"create"`
+	}
+	if err := decodeMCPArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Code) == "" {
+		return nil, errors.New("code must not be empty")
+	}
+	if args.TimeoutMS < 0 {
+		return nil, errors.New("timeout_ms must be > 0")
+	}
+	if strings.TrimSpace(args.File) == "" {
+		return nil, errors.New("file is required")
+	}
+	filePath, err := resolveExecWorkbookPath(args.File, args.Create)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := args.Locale
+	if locale != "" {
+		normalized, ok := normalizeLocale(locale)
+		if !ok {
+			return nil, fmt.Errorf("invalid locale %q", locale)
+		}
+		locale = normalized
+	} else if locale, err = resolveLocale(&cobra.Command{}, "locale", "", true, true); err != nil {
+		return nil, err
+	}
+
+	input := args.Input
+	if input == nil {
+		input = map[string]any{}
+	}
+	req := client.ExecRequest{
+		Code:      args.Code,
+		Input:     input,
+		Locale:    locale,
+		TimeoutMS: args.TimeoutMS,
+	}
+	c := s.client
+	if args.Create {
+		req.Filename = filepath.Base(filePath)
+		c = newAPIClientMode(c.BearerToken(), c.OrgID, true)
+	}
+	result, err := execWorkbook(c, filePath, req, args.Save, args.Create, "", "")
+	if err != nil {
+		return nil, err
+	}
+	result.File = nil
+	out, err := mcpJSONResult(result)
+	if err != nil {
+		return nil, err
+	}
+	out.IsError = !result.Ok
+	return out, nil
+}
+
+func (s *mcpServer) callLint(raw json.RawMessage) (*mcpToolResult, error) {
+	var args struct {
+		File      string   `json:"file"`
+		Ranges    []string `json:"ranges"`
+		SkipRules []string `json:"skip_rules"`
+		OnlyRules []string `json:"only_rules"`
+	}
+	if err := decodeMCPArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	filePath, err := mcpWorkbookPath(args.File)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := resolveRangeAddresses(s.client, filePath, args.Ranges)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	for _, r := range ranges {
+		params.Add("range", r)
+	}
+	for _, r := range args.SkipRules {
+		params.Add("skipRule", r)
+	}
+	for _, r := range args.OnlyRules {
+		params.Add("onlyRule", r)
+	}
+	result, err := fetchLint(s.client, filePath, params)
+	if err != nil {
+		return nil, err
+	}
+	return mcpJSONResult(result)
+}
+
+func (s *mcpServer) callRender(raw json.RawMessage) (*mcpToolResult, error) {
+	var args struct {
+		File   string `json:"file"`
+		Range  string `json:"range"`
+		DPR    int    `json:"dpr"`
+		Format string `json:"format"`
+	}
+	if err := decodeMCPArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Format == "" {
+		args.Format = "png"
+	}
+	if args.Format != "png" && args.Format != "webp" {
+		return nil, fmt.Errorf("format must be 'png' or 'webp', got %q", args.Format)
+	}
+	if args.Range == "" {
+		return nil, errors.New("range is required (e.g. \"Sheet1!A1:Z50\")")
+	}
+	filePath, err := mcpWorkbookPath(args.File)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveRangeAddresses(s.client, filePath, []string{args.Range})
+	if err != nil {
+		return nil, err
+	}
+	address := resolved[0]
+	dpr := args.DPR
+	if dpr == 0 {
+		dpr = autoDPR(address)
+	}
+	if dpr < 1 || dpr > 3 {
+		return nil, fmt.Errorf("dpr must be 1-3, got %d", dpr)
+	}
+
+	imageBytes, contentType, err := fetchRender(s.client, filePath, map[string]string{
+		"address": address,
+		"dpr":     strconv.Itoa(dpr),
+		"format":  args.Format,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if mediaType, _, ok := strings.Cut(contentType, ";"); ok {
+		contentType = mediaType
+	}
+	if contentType == "" {
+		contentType = "image/" + args.Format
+	}
+	return &mcpToolResult{Content: []mcpContent{{
+		Type:     "image",
+		Data:     base64.StdEncoding.EncodeToString(imageBytes),
+		MimeType: strings.TrimSpace(contentType),
+	}}}, nil
+}
+
+func (s *mcpServer) callRead(raw json.RawMessage) (*mcpToolResult, error) {
+	var args struct {
+		Input   string   `json:"input"`
+		Outline bool     `json:"outline"`
+		Pages   string   `json:"pages"`
+		Slides  string   `json:"slides"`
+		Offset  int      `json:"offset"`
+		Limit   int      `json:"limit"`
+		OCR     bool     `json:"ocr"`
+		Lang    []string `json:"lang"`
+	}
+	if err := decodeMCPArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Input) == "" {
+		return nil, errors.New("input is required")
+	}
+	for _, lang := range args.Lang {
+		if !readLangPattern.MatchString(lang) {
+			return nil, fmt.Errorf("lang must be a language code such as en or de, got %q", lang)
+		}
+	}
+	filePath, cleanup, err := resolveReadInput(s.client, args.Input)
+	if err != nil {
+		return nil, err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+
+	params := url.Values{}
+	if args.Pages != "" {
+		params.Set("pages", args.Pages)
+	}
+	if args.Slides != "" {
+		params.Set("slides", args.Slides)
+	}
+	if args.Offset > 0 {
+		params.Set("offset", strconv.Itoa(args.Offset))
+	}
+	if args.Limit > 0 {
+		params.Set("limit", strconv.Itoa(args.Limit))
+	}
+	if args.OCR {
+		params.Set("ocr", "true")
+	}
+	if len(args.Lang) > 0 {
+		params.Set("lang", strings.Join(args.Lang, ","))
+	}
+
+	if args.Outline {
+		result, err := fetchReadOutline(s.client, filePath, params)
+		if err != nil {
+			return nil, err
+		}
+		return mcpJSONResult(result)
+	}
+	result, err := fetchReadContent(s.client, filePath, params)
+	if err != nil {
+		return nil, err
+	}
+	return mcpJSONResult(result)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func decodeMCPResponses(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var responses []map[string]any
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decoding response: %v\n%s", err, out.String())
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestMCPServer_HandshakeAndErrors(t *testing.T) {
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"xlsx_edit","arguments":{}}}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	s := &mcpServer{client: newAPIClient("", "")}
+	if err := s.serve(strings.NewReader(in), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+
+	responses := decodeMCPResponses(t, &out)
+	if len(responses) != 5 {
+		t.Fatalf("expected 5 responses (notification gets none), got %d:\n%s", len(responses), out.String())
+	}
+
+	initResult := responses[0]["result"].(map[string]any)
+	if initResult["protocolVersion"] != "2025-03-26" {
+		t.Fatalf("expected negotiated protocol version, got %v", initResult["protocolVersion"])
+	}
+	if _, ok := initResult["capabilities"].(map[string]any)["tools"]; !ok {
+		t.Fatalf("expected tools capability, got %v", initResult["capabilities"])
+	}
+
+	tools := responses[1]["result"].(map[string]any)["tools"].([]any)
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	if got := strings.Join(names, ","); got != "xlsx_calc,xlsx_exec,xlsx_lint,xlsx_render,read" {
+		t.Fatalf("unexpected tools: %s", got)
+	}
+
+	if code := responses[2]["error"].(map[string]any)["code"].(float64); code != mcpMethodNotFound {
+		t.Fatalf("expected method-not-found, got %v", responses[2])
+	}
+	if code := responses[3]["error"].(map[string]any)["code"].(float64); code != mcpParseError {
+		t.Fatalf("expected parse error, got %v", responses[3])
+	}
+	if code := responses[4]["error"].(map[string]any)["code"].(float64); code != mcpInvalidParams {
+		t.Fatalf("expected invalid params for unknown tool, got %v", responses[4])
+	}
+}
+
+func TestMCPServer_LintToolCallsAPI(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/xlsx/lint" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query()["onlyRule"]; len(got) != 1 || got[0] != "D001" {
+			t.Fatalf("expected onlyRule=D001, got %v", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"diagnostics":[{"severity":"Warning","ruleId":"D001","message":"double counting","location":"Sheet1!B2"}],"total":1}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+
+	call, _ := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      "lint-1",
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      "xlsx_lint",
+			"arguments": map[string]any{"file": filePath, "only_rules": []string{"D001"}},
+		},
+	})
+	bad := `{"jsonrpc":"2.0","id":"lint-2","method":"tools/call","params":{"name":"xlsx_lint","arguments":{"file":"x.xlsx","rules":["D001"]}}}`
+
+	var out bytes.Buffer
+	s := &mcpServer{client: newAPIClient("", "")}
+	if err := s.serve(strings.NewReader(string(call)+"\n"+bad+"\n"), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	responses := decodeMCPResponses(t, &out)
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d:\n%s", len(responses), out.String())
+	}
+
+	result := responses[0]["result"].(map[string]any)
+	if result["isError"] == true {
+		t.Fatalf("unexpected tool error: %v", result)
+	}
+	text := result["content"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(text, `"ruleId": "D001"`) || !strings.Contains(text, `"total": 1`) {
+		t.Fatalf("unexpected lint result text: %s", text)
+	}
+
+	// Unknown arguments are tool errors, reported in-band.
+	bad2 := responses[1]["result"].(map[string]any)
+	if bad2["isError"] != true {
+		t.Fatalf("expected isError for unknown argument, got %v", bad2)
+	}
+}
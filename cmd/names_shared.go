@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// resolveRangeAddresses normalizes user-supplied range addresses to the
+// sheet-qualified A1 form the API expects:
+//   - R1C1 references ("Sheet1!R2C3:R10C5") are converted locally;
+//   - table structured references ("Table1[Sales]") are resolved from the
+//     table's layout;
+//   - workbook defined names ("Revenue_Table") are resolved to their range.
+//
+// Other addresses pass through unchanged. Workbook metadata is only fetched
+// when an address needs it, with at most one call for names and one for tables.
+func resolveRangeAddresses(c *client.Client, filePath string, addrs []string) ([]string, error) {
+	resolved := make([]string, len(addrs))
+	var nameIdx, tableIdx []int
+	var refs []internal.StructuredRef
+	for i, a := range addrs {
+		switch {
+		case internal.IsR1C1(a):
+			r, err := internal.R1C1ToA1(a)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		case internal.IsStructuredRef(a):
+			ref, err := internal.ParseStructuredRef(a)
+			if err != nil {
+				return nil, err
+			}
+			tableIdx = append(tableIdx, i)
+			refs = append(refs, ref)
+		case internal.IsDefinedName(a):
+			nameIdx = append(nameIdx, i)
+		default:
+			resolved[i] = a
+		}
+	}
+
+	if len(nameIdx) > 0 {
+		names, err := c.ListDefinedNames(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("resolving named ranges: %w", err)
+		}
+		for _, i := range nameIdx {
+			r, err := lookupDefinedName(names, addrs[i])
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+	}
+
+	if len(tableIdx) > 0 {
+		var tableNames []string
+		seen := map[string]bool{}
+		for _, ref := range refs {
+			if !seen[ref.Table] {
+				seen[ref.Table] = true
+				tableNames = append(tableNames, ref.Table)
+			}
+		}
+		tables, err := c.GetTables(filePath, tableNames)
+		if err != nil {
+			return nil, fmt.Errorf("resolving table references: %w", err)
+		}
+		for j, i := range tableIdx {
+			t, ok := tables[refs[j].Table]
+			if !ok || t.Ref == "" {
+				return nil, fmt.Errorf("%q: table %s not found in this workbook", addrs[i], refs[j].Table)
+			}
+			r, err := internal.ResolveStructuredRef(refs[j], tableLayout(t))
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", addrs[i], err)
+			}
+			resolved[i] = r
+		}
+	}
+	return resolved, nil
+}
+
+func tableLayout(t client.Table) internal.TableLayout {
+	sheet, ref := t.Sheet, t.Ref
+	if s, r, ok := strings.Cut(t.Ref, "!"); ok {
+		sheet, ref = strings.Trim(s, "'"), r
+	}
+	columns := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		columns[i] = col.Name
+	}
+	return internal.TableLayout{
+		Sheet:     sheet,
+		Ref:       ref,
+		Columns:   columns,
+		HasHeader: t.ShowHeaderRow == nil || *t.ShowHeaderRow,
+		HasTotals: t.ShowTotalsRow,
+	}
+}
+
+// lookupDefinedName finds name (case-insensitively, as Excel does) and returns
+// its range. Workbook-scoped names win; a sheet-scoped name is used only when
+// it is the sole match.
+func lookupDefinedName(names []client.DefinedName, name string) (string, error) {
+	var sheetScoped []client.DefinedName
+	var match *client.DefinedName
+	for i := range names {
+		n := &names[i]
+		if !strings.EqualFold(n.Name, name) {
+			continue
+		}
+		if n.Scope == nil || *n.Scope == "" {
+			match = n
+			break
+		}
+		sheetScoped = append(sheetScoped, *n)
+	}
+	if match == nil {
+		switch len(sheetScoped) {
+		case 0:
+			return "", fmt.Errorf("%q is not a sheet-qualified range or a defined name in this workbook", name)
+		case 1:
+			match = &sheetScoped[0]
+		default:
+			scopes := make([]string, len(sheetScoped))
+			for i, n := range sheetScoped {
+				scopes[i] = *n.Scope
+			}
+			return "", fmt.Errorf("defined name %q is ambiguous; it is scoped to sheets %s — use a sheet-qualified range instead", name, strings.Join(scopes, ", "))
+		}
+	}
+
+	r := strings.TrimPrefix(strings.TrimSpace(match.Range), "=")
+	if _, _, _, _, _, err := internal.ParseRange(r); err != nil {
+		return "", fmt.Errorf("defined name %q does not refer to a single range (%s)", name, match.Range)
+	}
+	return r, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	providerS3  = "s3"
+	providerGCS = "gcs"
+)
+
+// parseObjectURL recognizes s3://bucket/key and gs://bucket/key object
+// paths. A gs:// reference without a key is a Google Sheets ID, not an
+// object.
+func parseObjectURL(raw string) (provider, key string, ok bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(raw, "s3://"):
+		provider, rest = providerS3, strings.TrimPrefix(raw, "s3://")
+	case strings.HasPrefix(raw, "gs://"):
+		provider, rest = providerGCS, strings.TrimPrefix(raw, "gs://")
+	default:
+		return "", "", false
+	}
+	bucket, key, found := strings.Cut(rest, "/")
+	if !found || bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", false
+	}
+	return provider, key, true
+}
+
+// objectCopy copies between a local path and an object path with the cloud
+// provider's CLI (aws or gcloud), so the ambient credentials those tools
+// already use apply. Tests replace it.
+var objectCopy = func(src, dst string) error {
+	remote := src
+	if _, _, ok := parseObjectURL(dst); ok {
+		remote = dst
+	}
+	provider, _, _ := parseObjectURL(remote)
+	var scheme, name string
+	var args []string
+	switch provider {
+	case providerS3:
+		scheme, name, args = "s3://", "aws", []string{"s3", "cp", "--only-show-errors", src, dst}
+	case providerGCS:
+		scheme, name, args = "gs://", "gcloud", []string{"storage", "cp", "--quiet", src, dst}
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("%s paths need the %s CLI on PATH; it supplies the cloud credentials", scheme, name)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s %s: %s", name, args[0], msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return nil
+}
+
+// downloadObject copies an s3:// or gs:// workbook to a temp file named
+// after the object.
+func (rw *remoteWorkbook) downloadObject() error {
+	dir, err := os.MkdirTemp("", "witan-remote-*")
+	if err != nil {
+		return fmt.Errorf("buffering remote workbook: %w", err)
+	}
+	rw.cleanup = func() { os.RemoveAll(dir) }
+	rw.Path = filepath.Join(dir, path.Base(rw.FileID))
+	if err := objectCopy(rw.URL, rw.Path); err != nil {
+		rw.cleanup()
+		return fmt.Errorf("downloading %s: %w", rw.URL, err)
+	}
+	data, err := os.ReadFile(rw.Path)
+	if err != nil {
+		rw.cleanup()
+		return fmt.Errorf("downloading %s: %w", rw.URL, err)
+	}
+	rw.downloaded = sha256.Sum256(data)
+	return nil
+}
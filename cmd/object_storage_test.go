@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// fakeObjectStore replaces objectCopy with an in-memory bucket keyed by
+// object URL.
+func fakeObjectStore(t *testing.T, objects map[string]string) {
+	t.Helper()
+	prev := objectCopy
+	t.Cleanup(func() {
+		objectCopy = prev
+		closeRemoteFiles()
+	})
+	t.Setenv("WITAN_OFFLINE", "")
+	objectCopy = func(src, dst string) error {
+		if _, _, ok := parseObjectURL(src); ok {
+			data, found := objects[src]
+			if !found {
+				return os.ErrNotExist
+			}
+			return os.WriteFile(dst, []byte(data), 0o600)
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		objects[dst] = string(data)
+		return nil
+	}
+}
+
+func TestParseObjectURL(t *testing.T) {
+	tests := []struct {
+		raw, provider, key string
+	}{
+		{"s3://bucket/models/q3.xlsx", providerS3, "models/q3.xlsx"},
+		{"gs://lake/q3.xlsx", providerGCS, "q3.xlsx"},
+		{"gs://abc123", "", ""},
+		{"s3://bucket", "", ""},
+		{"s3://bucket/dir/", "", ""},
+		{"s3:///key.xlsx", "", ""},
+		{"q3.xlsx", "", ""},
+	}
+	for _, tt := range tests {
+		provider, key, ok := parseObjectURL(tt.raw)
+		if ok != (tt.provider != "") || provider != tt.provider || key != tt.key {
+			t.Errorf("parseObjectURL(%q) = %q, %q, %v; want %q, %q", tt.raw, provider, key, ok, tt.provider, tt.key)
+		}
+	}
+	// gs:// without a key stays a Google Sheets reference.
+	if rw, ok := parseRemoteWorkbookURL("gs://lake/q3.xlsx"); !ok || rw.Provider != providerGCS {
+		t.Errorf("gs://lake/q3.xlsx parsed as %+v", rw)
+	}
+}
+
+func TestRemoteWorkbook_S3RoundTrip(t *testing.T) {
+	objects := map[string]string{"s3://bucket/models/q3.xlsx": "before"}
+	fakeObjectStore(t, objects)
+
+	args := []string{"s3://bucket/models/q3.xlsx"}
+	if err := openRemoteWorkbookArg(args); err != nil {
+		t.Fatalf("openRemoteWorkbookArg: %v", err)
+	}
+	if filepath.Base(args[0]) != "q3.xlsx" {
+		t.Fatalf("args[0] = %q", args[0])
+	}
+	if err := os.WriteFile(args[0], []byte("after"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := finishRemoteFiles(nil); err != nil {
+		t.Fatalf("finishRemoteFiles: %v", err)
+	}
+	if objects["s3://bucket/models/q3.xlsx"] != "after" {
+		t.Fatalf("object = %q, want the changed workbook", objects["s3://bucket/models/q3.xlsx"])
+	}
+	if _, err := os.Stat(args[0]); !os.IsNotExist(err) {
+		t.Fatalf("temp copy not removed: %v", err)
+	}
+}
+
+func TestOpenRemoteOutputFlag(t *testing.T) {
+	objects := map[string]string{}
+	fakeObjectStore(t, objects)
+
+	var output string
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "")
+	if err := cmd.Flags().Set("output", "gs://lake/reports/lint.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := openRemoteOutputFlag(cmd); err != nil {
+		t.Fatalf("openRemoteOutputFlag: %v", err)
+	}
+	if strings.HasPrefix(output, "gs://") || filepath.Base(output) != "lint.json" {
+		t.Fatalf("output = %q, want a local temp path", output)
+	}
+	if err := os.WriteFile(output, []byte(`{"diagnostics":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Findings still upload the report.
+	err := finishRemoteFiles(&ExitError{Code: ExitFindings})
+	if exitErr, ok := err.(*ExitError); !ok || exitErr.Code != ExitFindings {
+		t.Fatalf("finishRemoteFiles = %v, want the findings exit", err)
+	}
+	if objects["gs://lake/reports/lint.json"] != `{"diagnostics":[]}` {
+		t.Fatalf("report not uploaded: %v", objects)
+	}
+}
+
+func TestFinishRemoteFiles_SkipsUploadOnFailure(t *testing.T) {
+	objects := map[string]string{"s3://bucket/q3.xlsx": "before"}
+	fakeObjectStore(t, objects)
+
+	args := []string{"s3://bucket/q3.xlsx"}
+	if err := openRemoteWorkbookArg(args); err != nil {
+		t.Fatalf("openRemoteWorkbookArg: %v", err)
+	}
+	if err := os.WriteFile(args[0], []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := finishRemoteFiles(&ExitError{Code: ExitFailure}); err == nil {
+		t.Fatal("expected the command's error")
+	}
+	if objects["s3://bucket/q3.xlsx"] != "before" {
+		t.Fatalf("object = %q, want it untouched", objects["s3://bucket/q3.xlsx"])
+	}
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/config"
+)
+
+var (
+	orgInfoJSON bool
+	usageJSON   bool
+	usageMonth  string
+)
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Show the active organization's plan and limits",
+	Long: `Inspect the organization that API requests are billed to: the session's
+organization, or the API key's.
+
+Examples:
+  witan org info
+  witan org info --json`,
+}
+
+var orgInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show plan limits and rate-limit tiers",
+	Long: `Show the active organization's plan, its monthly request and byte limits,
+the largest file it may upload, and its rate-limit tiers.
+
+Use --json for the full object.`,
+	Args: cobra.NoArgs,
+	RunE: runOrgInfo,
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show requests made and bytes processed in a month",
+	Long: `Show the active organization's consumption for a calendar month (UTC):
+requests made and bytes processed, against the plan's limits.
+
+--month selects the month as YYYY-MM (default: the current month). Use
+--json to monitor consumption from scripts.
+
+Examples:
+  witan usage
+  witan usage --month 2025-01 --json`,
+	Args: cobra.NoArgs,
+	RunE: runUsage,
+}
+
+func init() {
+	orgInfoCmd.Flags().BoolVar(&orgInfoJSON, "json", false, "Output the organization as JSON")
+	addResultOutputFlag(orgInfoCmd)
+	orgCmd.AddCommand(orgInfoCmd)
+	rootCmd.AddCommand(orgCmd)
+
+	usageCmd.Flags().StringVar(&usageMonth, "month", "", "Month to report as YYYY-MM (default: current month, UTC)")
+	usageCmd.Flags().BoolVar(&usageJSON, "json", false, "Output the usage report as JSON")
+	addResultOutputFlag(usageCmd)
+	rootCmd.AddCommand(usageCmd)
+}
+
+// orgLimits are a plan's limits; zero means unlimited.
+type orgLimits struct {
+	MonthlyRequests int64 `json:"monthly_requests,omitempty"`
+	MonthlyBytes    int64 `json:"monthly_bytes,omitempty"`
+	MaxFileBytes    int64 `json:"max_file_bytes,omitempty"`
+}
+
+// rateLimitTier is one rate limit applied to an organization's requests,
+// e.g. the default tier or a stricter one for render endpoints.
+type rateLimitTier struct {
+	Tier              string `json:"tier"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	Burst             int    `json:"burst,omitempty"`
+}
+
+// orgInfo is the management API's GET /v0/orgs/{id} response.
+type orgInfo struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Plan       string          `json:"plan"`
+	Limits     orgLimits       `json:"limits"`
+	RateLimits []rateLimitTier `json:"rate_limits"`
+}
+
+// orgUsage is the management API's GET /v0/orgs/{id}/usage response.
+type orgUsage struct {
+	OrgID          string    `json:"org_id"`
+	Month          string    `json:"month"`
+	Requests       int64     `json:"requests"`
+	BytesProcessed int64     `json:"bytes_processed"`
+	Limits         orgLimits `json:"limits"`
+	RateLimitTier  string    `json:"rate_limit_tier,omitempty"`
+}
+
+func runOrgInfo(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	authHeader, orgID, err := resolveManagementAuth()
+	if err != nil {
+		return err
+	}
+	var info orgInfo
+	if err := getManagementJSON(authHeader, "/v0/orgs/"+url.PathEscape(orgID), nil, &info); err != nil {
+		return fmt.Errorf("fetching organization: %w", err)
+	}
+	return emitResult(info, orgInfoJSON, func() error {
+		name := info.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		fmt.Printf("%s  %s\n", info.ID, name)
+		if info.Plan != "" {
+			fmt.Printf("  plan:       %s\n", info.Plan)
+		}
+		fmt.Printf("  requests:   %s per month\n", formatLimit(info.Limits.MonthlyRequests, formatCount))
+		fmt.Printf("  processed:  %s per month\n", formatLimit(info.Limits.MonthlyBytes, formatBytes))
+		fmt.Printf("  max file:   %s\n", formatLimit(info.Limits.MaxFileBytes, formatBytes))
+		for _, tier := range info.RateLimits {
+			line := fmt.Sprintf("  rate limit: %s, %d requests/min", tier.Tier, tier.RequestsPerMinute)
+			if tier.Burst > 0 {
+				line += fmt.Sprintf(" (burst %d)", tier.Burst)
+			}
+			fmt.Println(line)
+		}
+		return nil
+	})
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	month := usageMonth
+	if month == "" {
+		month = time.Now().UTC().Format("2006-01")
+	} else if _, err := time.Parse("2006-01", month); err != nil {
+		return fmt.Errorf("invalid --month %q: use YYYY-MM, e.g. 2025-01", month)
+	}
+	authHeader, orgID, err := resolveManagementAuth()
+	if err != nil {
+		return err
+	}
+	var usage orgUsage
+	if err := getManagementJSON(authHeader, "/v0/orgs/"+url.PathEscape(orgID)+"/usage", url.Values{"month": {month}}, &usage); err != nil {
+		return fmt.Errorf("fetching usage: %w", err)
+	}
+	if usage.OrgID == "" {
+		usage.OrgID = orgID
+	}
+	if usage.Month == "" {
+		usage.Month = month
+	}
+	return emitResult(usage, usageJSON, func() error {
+		fmt.Printf("%s  %s\n", usage.OrgID, usage.Month)
+		fmt.Printf("  requests:   %s\n", formatUsage(usage.Requests, usage.Limits.MonthlyRequests, formatCount))
+		fmt.Printf("  processed:  %s\n", formatUsage(usage.BytesProcessed, usage.Limits.MonthlyBytes, formatBytes))
+		if usage.RateLimitTier != "" {
+			fmt.Printf("  rate limit: %s\n", usage.RateLimitTier)
+		}
+		return nil
+	})
+}
+
+// resolveManagementAuth returns the Authorization header and org for
+// management API calls: the API key from --api-key or WITAN_API_KEY, else
+// the saved session's JWT.
+func resolveManagementAuth() (authHeader, orgID string, err error) {
+	if rawKey := resolveRawAPIKey(); rawKey != "" {
+		orgID, err := resolveAPIKeyOrgID(rawKey)
+		if err != nil {
+			return "", "", err
+		}
+		return "ApiKey " + rawKey, orgID, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", "", fmt.Errorf("loading auth config: %w", err)
+	}
+	if cfg.SessionToken == "" {
+		return "", "", fmt.Errorf("not authenticated: run 'witan auth login' or set --api-key / WITAN_API_KEY")
+	}
+	if cfg.SessionOrgID == "" {
+		return "", "", fmt.Errorf("organization not selected: run 'witan auth login --org <id>' (or set WITAN_ORG) to finish signing in")
+	}
+	jwt, err := sessionJWT(cfg)
+	if err != nil {
+		return "", "", fmt.Errorf("session expired: run 'witan auth login' to re-authenticate")
+	}
+	return "Bearer " + jwt, cfg.SessionOrgID, nil
+}
+
+// getManagementJSON GETs a management API path and decodes the response
+// into v.
+func getManagementJSON(authHeader, path string, query url.Values, v any) error {
+	u := resolveManagementAPIURL() + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	setCLIUserAgent(req)
+	req.Header.Set("Authorization", authHeader)
+
+	resp, err := newHTTPClient(30 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return parseManagementAPIError(resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// formatLimit renders a plan limit, where zero means unlimited.
+func formatLimit(n int64, format func(int64) string) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return format(n)
+}
+
+// formatUsage renders consumption against a limit, e.g. "1200 of 10000 (12%)".
+func formatUsage(used, limit int64, format func(int64) string) string {
+	if limit <= 0 {
+		return format(used) + " (no limit)"
+	}
+	return fmt.Sprintf("%s of %s (%d%%)", format(used), format(limit), used*100/limit)
+}
+
+func formatCount(n int64) string { return fmt.Sprintf("%d", n) }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/config"
+)
+
+func TestRunOrgInfoAndUsage_WithAPIKey(t *testing.T) {
+	origAPIKey := apiKey
+	origOrgJSON, origUsageJSON, origMonth := orgInfoJSON, usageJSON, usageMonth
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		orgInfoJSON, usageJSON, usageMonth = origOrgJSON, origUsageJSON, origMonth
+	})
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = "wk_ci"
+	cfg := config.Config{}
+	cfg.SetOrgIDForAPIKey("wk_ci", "org_1")
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "ApiKey wk_ci" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v0/orgs/org_1":
+			fmt.Fprint(w, `{"id":"org_1","name":"Acme","plan":"team","limits":{"monthly_requests":10000,"monthly_bytes":1073741824},"rate_limits":[{"tier":"default","requests_per_minute":600,"burst":100}]}`)
+		case "/v0/orgs/org_1/usage":
+			if got := r.URL.Query().Get("month"); got != "2025-01" {
+				t.Errorf("month = %q, want 2025-01", got)
+			}
+			fmt.Fprint(w, `{"org_id":"org_1","month":"2025-01","requests":1200,"bytes_processed":536870912,"limits":{"monthly_requests":10000,"monthly_bytes":1073741824},"rate_limit_tier":"default"}`)
+		default:
+			http.Error(w, `{"error":{"code":"not_found","message":"no such route"}}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("WITAN_MANAGEMENT_API_URL", server.URL)
+
+	orgInfoJSON = false
+	out, err := captureExecStdout(t, func() error { return runOrgInfo(&cobra.Command{}, nil) })
+	if err != nil {
+		t.Fatalf("runOrgInfo: %v", err)
+	}
+	for _, want := range []string{"org_1  Acme", "plan:       team", "10000 per month", "1.0 GB per month", "max file:   unlimited", "default, 600 requests/min (burst 100)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("org info output missing %q:\n%s", want, out)
+		}
+	}
+
+	usageJSON, usageMonth = false, "2025-01"
+	out, err = captureExecStdout(t, func() error { return runUsage(&cobra.Command{}, nil) })
+	if err != nil {
+		t.Fatalf("runUsage: %v", err)
+	}
+	if !strings.Contains(out, "1200 of 10000 (12%)") || !strings.Contains(out, "512.0 MB of 1.0 GB (50%)") {
+		t.Fatalf("unexpected usage output:\n%s", out)
+	}
+
+	usageJSON = true
+	out, err = captureExecStdout(t, func() error { return runUsage(&cobra.Command{}, nil) })
+	if err != nil {
+		t.Fatalf("runUsage --json: %v", err)
+	}
+	var usage orgUsage
+	if err := json.Unmarshal([]byte(out), &usage); err != nil || usage.Requests != 1200 || usage.RateLimitTier != "default" {
+		t.Fatalf("usage JSON = %+v, %v", usage, err)
+	}
+
+	usageMonth = "January"
+	if err := runUsage(&cobra.Command{}, nil); err == nil || !strings.Contains(err.Error(), "YYYY-MM") {
+		t.Fatalf("expected --month validation error, got %v", err)
+	}
+}
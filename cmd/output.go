@@ -2,8 +2,38 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes. Every non-zero exit belongs to exactly one category so scripts
+// and agents can branch on the code instead of parsing messages.
+const (
+	// ExitOK: the command succeeded.
+	ExitOK = 0
+	// ExitFailure: a transport, API, or usage error, or a script that
+	// returned ok=false. Plain errors returned from a command exit with it.
+	ExitFailure = 1
+	// ExitFindings: the checks ran and reported findings (lint diagnostics,
+	// calc formula errors or --verify changes, update --check staleness).
+	// --exit-zero maps it to 0.
+	ExitFindings = 2
+	// ExitAssertion: an exec --expect/--expect-json assertion failed, or
+	// xlsx assert found cells that differ from the snapshot.
+	ExitAssertion = 3
+	// ExitAuth: sign-in, organization selection, or Google authorization is
+	// required before the command can proceed.
+	ExitAuth = 4
+	// ExitOffline: --offline is set and the command needed the network (no
+	// cached response was available).
+	ExitOffline = 5
+	// ExitConflict: the file changed on the server. --if-revision did not
+	// match, or another client saved a newer revision mid-command.
+	ExitConflict = 6
 )
 
 // ExitError signals a non-zero exit code without printing an error message.
@@ -11,6 +41,36 @@ type ExitError struct{ Code int }
 
 func (e *ExitError) Error() string { return "" }
 
+// Category names the exit code's category: "failure", "findings",
+// "assertion", "auth", "offline", or "conflict".
+func (e *ExitError) Category() string {
+	switch e.Code {
+	case ExitOK:
+		return "ok"
+	case ExitFindings:
+		return "findings"
+	case ExitAssertion:
+		return "assertion"
+	case ExitAuth:
+		return "auth"
+	case ExitOffline:
+		return "offline"
+	case ExitConflict:
+		return "conflict"
+	}
+	return "failure"
+}
+
+// exitZeroFindings drops an ExitFindings error when --exit-zero is set, so
+// report-only runs succeed. Other errors pass through unchanged.
+func exitZeroFindings(err error, exitZero bool) error {
+	var exitErr *ExitError
+	if exitZero && errors.As(err, &exitErr) && exitErr.Code == ExitFindings {
+		return nil
+	}
+	return err
+}
+
 func jsonPrint(v any) error {
 	return jsonPrintTo(os.Stdout, v)
 }
@@ -27,3 +87,92 @@ func jsonPrintTo(w io.Writer, v any) error {
 	enc.SetIndent("", "  ")
 	return enc.Encode(v)
 }
+
+// Output conventions shared by commands that produce a result:
+//
+//   - stdout carries the result: a human summary, or JSON with --json.
+//   - stderr carries everything else: errors, warnings, progress, and the
+//     paths of side files such as annotated copies.
+//   - -o/--output FILE writes the JSON result to FILE instead of stdout;
+//     the human summary is still printed unless --json or --quiet is set.
+//   - -q/--quiet suppresses the human summary. Exit codes are unchanged.
+//   - --template replaces the human summary with a Go template rendered
+//     against the result (see output_template.go).
+//   - --ndjson replaces the result with a stream of events, one JSON object
+//     per line, ending in a "result" event (see events.go).
+var (
+	resultOutputPath string
+	quietOutput      bool
+)
+
+// addResultOutputFlag registers -o/--output on a command whose result is
+// emitted through emitResult. Render commands keep their own -o for the
+// image path.
+func addResultOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&resultOutputPath, "output", "o", "", "Write the JSON result to this file")
+}
+
+// emitResult writes v as the command's machine output and calls human for
+// the human summary, following the conventions above. With --template the
+// template is rendered against v instead of calling human.
+func emitResult(v any, useJSON bool, human func() error) error {
+	return emitResultAs(v, v, useJSON, human)
+}
+
+// emitResultAs is emitResult with a separate value for --template to render,
+// for commands that add fields such as File to their result.
+func emitResultAs(v, templateData any, useJSON bool, human func() error) error {
+	return emitResultTo(os.Stdout, v, templateData, useJSON, human)
+}
+
+// emitResultTo is emitResultAs writing the JSON result and template to w,
+// for commands that print to cmd.OutOrStdout().
+func emitResultTo(w io.Writer, v, templateData any, useJSON bool, human func() error) error {
+	if resultOutputPath != "" {
+		if err := writeJSONFile(resultOutputPath, v); err != nil {
+			return err
+		}
+	}
+	if ndjsonOutput {
+		emitEvent("result", map[string]any{"result": v})
+		return nil
+	}
+	if useJSON && resultOutputPath == "" {
+		return jsonPrintTo(w, v)
+	}
+	if useJSON || quietOutput {
+		return nil
+	}
+	if resultTemplate != nil {
+		return printResultTemplate(w, templateData)
+	}
+	return human()
+}
+
+// resultShownOnStdout reports whether emitResult printed anything to stdout,
+// so callers know whether failures also need reporting on stderr.
+func resultShownOnStdout(useJSON bool) bool {
+	if ndjsonOutput {
+		return true
+	}
+	if useJSON {
+		return resultOutputPath == ""
+	}
+	return !quietOutput
+}
+
+// writeJSONFile writes v to path as indented JSON.
+func writeJSONFile(path string, v any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if err := jsonPrintTo(f, v); err != nil {
+		f.Close()
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
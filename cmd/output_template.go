@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// --template and --template-file replace the human summary with a Go
+// template rendered against the command's typed result, e.g.
+// '{{.Total}} issues in {{.File}}'. Field names are the Go struct fields
+// (see `witan introspect --json` for their JSON shape).
+var (
+	resultTemplateText string
+	resultTemplatePath string
+	resultTemplate     *template.Template
+)
+
+// addResultTemplateFlags registers --template and --template-file on a
+// command whose result is emitted through emitResult.
+func addResultTemplateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&resultTemplateText, "template", "", "Print the result through this Go template instead of the summary, e.g. '{{.Total}} issues in {{.File}}'")
+	cmd.Flags().StringVar(&resultTemplatePath, "template-file", "", "Like --template, reading the template from this file")
+}
+
+// loadResultTemplate parses --template or --template-file. Commands call it
+// before doing any work so a bad template fails without an API call.
+func loadResultTemplate() error {
+	resultTemplate = nil
+	if resultTemplateText == "" && resultTemplatePath == "" {
+		return nil
+	}
+	if resultTemplateText != "" && resultTemplatePath != "" {
+		return fmt.Errorf("--template and --template-file are mutually exclusive")
+	}
+	if jsonOutput || ndjsonOutput {
+		return fmt.Errorf("--template cannot be combined with --json or --ndjson")
+	}
+	text, name := resultTemplateText, "--template"
+	if resultTemplatePath != "" {
+		data, err := os.ReadFile(resultTemplatePath)
+		if err != nil {
+			return fmt.Errorf("reading --template-file: %w", err)
+		}
+		text, name = string(data), resultTemplatePath
+	}
+	tmpl, err := template.New(name).Funcs(resultTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+	resultTemplate = tmpl
+	return nil
+}
+
+var resultTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// printResultTemplate renders the loaded template against data on w,
+// ending with a newline.
+func printResultTemplate(w io.Writer, data any) error {
+	var buf bytes.Buffer
+	if err := resultTemplate.Execute(&buf, data); err != nil {
+		return fmt.Errorf("rendering template: %w", err)
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
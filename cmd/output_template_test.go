@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func resetResultTemplate(t *testing.T) {
+	origText, origPath, origTemplate := resultTemplateText, resultTemplatePath, resultTemplate
+	origJSON := jsonOutput
+	t.Cleanup(func() {
+		resultTemplateText, resultTemplatePath, resultTemplate = origText, origPath, origTemplate
+		jsonOutput = origJSON
+	})
+	resultTemplateText, resultTemplatePath, resultTemplate = "", "", nil
+	jsonOutput = false
+}
+
+func TestResultTemplate_RendersLintResult(t *testing.T) {
+	resetResultTemplate(t)
+	resultTemplateText = `{{.Total}} issues in {{.File}}{{range .Diagnostics}} [{{.RuleId}}]{{end}}`
+	if err := loadResultTemplate(); err != nil {
+		t.Fatalf("loadResultTemplate: %v", err)
+	}
+
+	result := &client.LintResponse{Diagnostics: []client.LintDiagnostic{
+		{Severity: "Warning", RuleId: "D031", Message: "misspelled"},
+	}, Total: 1}
+	out, err := captureExecStdout(t, func() error {
+		return outputLintResult(result, "book.xlsx", false, "warning")
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitFindings {
+		t.Fatalf("expected findings exit, got %v", err)
+	}
+	if out != "1 issues in book.xlsx [D031]\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestResultTemplate_ExecResultIsDecoded(t *testing.T) {
+	resetResultTemplate(t)
+	path := filepath.Join(t.TempDir(), "slack.tmpl")
+	if err := os.WriteFile(path, []byte(`{{.File}}: total {{.Result.total}} {{json .Result.rows}}`), 0o644); err != nil {
+		t.Fatalf("writing template: %v", err)
+	}
+	resultTemplatePath = path
+	if err := loadResultTemplate(); err != nil {
+		t.Fatalf("loadResultTemplate: %v", err)
+	}
+
+	result := &client.ExecResponse{Ok: true, Result: json.RawMessage(`{"total":42,"rows":[1,2]}`)}
+	out, err := captureExecStdout(t, func() error {
+		return outputExecResult(result, "model.xlsx", false, formatExecError)
+	})
+	if err != nil {
+		t.Fatalf("outputExecResult: %v", err)
+	}
+	if out != "model.xlsx: total 42 [1,2]\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestLoadResultTemplate_Rejects(t *testing.T) {
+	resetResultTemplate(t)
+
+	resultTemplateText = "{{.Total"
+	if err := loadResultTemplate(); err == nil || !strings.Contains(err.Error(), "parsing template") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	resultTemplateText = "{{.Total}}"
+	jsonOutput = true
+	if err := loadResultTemplate(); err == nil || !strings.Contains(err.Error(), "--json") {
+		t.Fatalf("expected --json conflict, got %v", err)
+	}
+	jsonOutput = false
+	resultTemplatePath = "x.tmpl"
+	if err := loadResultTemplate(); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
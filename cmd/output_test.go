@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestEmitResult_OutputFileAndQuiet(t *testing.T) {
+	origOutput := resultOutputPath
+	origQuiet := quietOutput
+	t.Cleanup(func() {
+		resultOutputPath = origOutput
+		quietOutput = origQuiet
+	})
+
+	result := map[string]int{"total": 3}
+	cases := []struct {
+		name       string
+		output     bool
+		quiet      bool
+		useJSON    bool
+		wantStdout string
+		wantHuman  bool
+	}{
+		{name: "human", wantStdout: "summary\n", wantHuman: true},
+		{name: "json", useJSON: true, wantStdout: "{\n  \"total\": 3\n}\n"},
+		{name: "quiet", quiet: true},
+		{name: "output keeps summary", output: true, wantStdout: "summary\n", wantHuman: true},
+		{name: "output with json", output: true, useJSON: true},
+		{name: "output with quiet", output: true, quiet: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resultOutputPath = ""
+			if tc.output {
+				resultOutputPath = filepath.Join(t.TempDir(), "result.json")
+			}
+			quietOutput = tc.quiet
+
+			humanCalled := false
+			stdout, err := captureExecStdout(t, func() error {
+				return emitResult(result, tc.useJSON, func() error {
+					humanCalled = true
+					_, err := os.Stdout.WriteString("summary\n")
+					return err
+				})
+			})
+			if err != nil {
+				t.Fatalf("emitResult: %v", err)
+			}
+			if stdout != tc.wantStdout || humanCalled != tc.wantHuman {
+				t.Fatalf("stdout = %q (human %v), want %q (human %v)", stdout, humanCalled, tc.wantStdout, tc.wantHuman)
+			}
+			if tc.output {
+				data, err := os.ReadFile(resultOutputPath)
+				if err != nil {
+					t.Fatalf("reading output file: %v", err)
+				}
+				var got map[string]int
+				if err := json.Unmarshal(data, &got); err != nil || got["total"] != 3 {
+					t.Fatalf("output file = %s (%v)", data, err)
+				}
+			}
+			if shown := resultShownOnStdout(tc.useJSON); shown != (tc.wantStdout != "") {
+				t.Fatalf("resultShownOnStdout = %v, want %v", shown, tc.wantStdout != "")
+			}
+		})
+	}
+}
+
+func TestResultCommands_HaveOutputFlag(t *testing.T) {
+	for _, cmd := range []*cobra.Command{
+		calcCmd, xlsxExecCmd, lintCmd, snapshotCmd, assertCmd, goalSeekCmd, scenariosCmd,
+		depsCmd, searchCmd, statsCmd, historyCmd, undoCmd, sessionStartCmd, sessionStatusCmd,
+		pptxExecCmd, pptxLintCmd, sheetsExecCmd, sheetsLintCmd, sheetsStatusCmd, sheetsCreateCmd,
+		readCmd, runCmd, jobsStatusCmd, jobsResultCmd, orgInfoCmd, usageCmd, cleanTempCmd,
+		authStatusCmd, configListCmd, doctorCmd, introspectCmd,
+	} {
+		f := cmd.Flags().Lookup("output")
+		if f == nil || f.Shorthand != "o" || f.Usage != "Write the JSON result to this file" {
+			t.Errorf("%s: expected -o/--output for the JSON result, got %+v", cmd.CommandPath(), f)
+		}
+	}
+}
+
+func TestExitZeroFindings(t *testing.T) {
+	findings := &ExitError{Code: ExitFindings}
+	if err := exitZeroFindings(findings, true); err != nil {
+		t.Fatalf("--exit-zero must drop findings, got %v", err)
+	}
+	if err := exitZeroFindings(findings, false); err != findings {
+		t.Fatalf("findings must pass through without --exit-zero, got %v", err)
+	}
+	for _, code := range []int{ExitFailure, ExitAssertion, ExitAuth} {
+		err := &ExitError{Code: code}
+		if got := exitZeroFindings(err, true); got != err {
+			t.Fatalf("--exit-zero must not drop exit %d (%s)", code, err.Category())
+		}
+	}
+	if got := (&ExitError{Code: ExitAuth}).Category(); got != "auth" {
+		t.Fatalf("Category() = %q, want auth", got)
+	}
+}
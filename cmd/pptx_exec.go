@@ -20,12 +20,14 @@ var (
 	pptxExecExpr           string
 	pptxExecInputJSON      string
 	pptxExecInputFiles     []string
+	pptxExecData           []string
 	pptxExecLocale         string
 	pptxExecStdinTimeoutMS int
 	pptxExecTimeoutMS      int
 	pptxExecMaxOutputChars int
 	pptxExecSave           bool
 	pptxExecCreate         bool
+	pptxExecIfRevision     string
 )
 
 var pptxExecCmd = &cobra.Command{
@@ -36,6 +38,7 @@ var pptxExecCmd = &cobra.Command{
 Provide exactly one code source: --code, --script, --stdin, or --expr.
 Use --create with a new .pptx path to start from an empty PPTX file.
 Use --save to write changes back to the local file.
+Use --data to attach files the script reads as files["<base name>"].
 
 Examples:
   witan pptx exec deck.pptx --expr 'PowerPoint.run(async context => { const count = context.presentation.slides.getCount(); await context.sync(); return count.value })'
@@ -53,12 +56,16 @@ func init() {
 	pptxExecCmd.Flags().StringVar(&pptxExecExpr, "expr", "", "Single-expression shorthand; wraps as return (<expr>);")
 	pptxExecCmd.Flags().StringVar(&pptxExecInputJSON, "input-json", "", "JSON value passed as input to the script")
 	pptxExecCmd.Flags().StringArrayVar(&pptxExecInputFiles, "input-file", nil, "Add a PNG/JPEG file to input as a data URI using key=@path (repeatable)")
+	pptxExecCmd.Flags().StringArrayVar(&pptxExecData, "data", nil, "Attach a data file the script reads as files[\"<base name>\"] (repeatable)")
 	pptxExecCmd.Flags().StringVar(&pptxExecLocale, "locale", "", "Execution locale (env: WITAN_LOCALE; otherwise LC_ALL / LC_MESSAGES / LANG)")
 	pptxExecCmd.Flags().IntVar(&pptxExecStdinTimeoutMS, "stdin-timeout-ms", defaultExecStdinTimeoutMS, "Maximum time to wait for EOF when reading --stdin (0 disables)")
 	pptxExecCmd.Flags().IntVar(&pptxExecTimeoutMS, "timeout-ms", 0, "Execution timeout in milliseconds (> 0)")
 	pptxExecCmd.Flags().IntVar(&pptxExecMaxOutputChars, "max-output-chars", 0, "Maximum stdout characters to capture (> 0)")
 	pptxExecCmd.Flags().BoolVar(&pptxExecCreate, "create", false, "Create a new .pptx file instead of opening an existing file")
 	pptxExecCmd.Flags().BoolVar(&pptxExecSave, "save", false, "Write returned PPTX bytes to the target path")
+	pptxExecCmd.Flags().StringVar(&pptxExecIfRevision, "if-revision", "", "Fail with exit code 6 unless <file> is unchanged since it was uploaded as this revision and it is still the server's latest")
+	addImageModeFlag(pptxExecCmd)
+	addResultOutputFlag(pptxExecCmd)
 	pptxCmd.AddCommand(pptxExecCmd)
 }
 
@@ -69,6 +76,12 @@ func runPPTXExec(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := validateImageMode(); err != nil {
+		return err
+	}
+	if pptxExecIfRevision != "" && pptxExecCreate {
+		return fmt.Errorf("--if-revision cannot be combined with --create")
+	}
 	if err := validateExecPositiveFlag(cmd, "timeout-ms", pptxExecTimeoutMS); err != nil {
 		return err
 	}
@@ -95,6 +108,10 @@ func runPPTXExec(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	dataFiles, err := execDataFiles(pptxExecData)
+	if err != nil {
+		return err
+	}
 
 	locale, err := resolvePPTXExecLocale(cmd)
 	if err != nil {
@@ -107,6 +124,7 @@ func runPPTXExec(cmd *cobra.Command, args []string) error {
 		Locale:         locale,
 		TimeoutMS:      pptxExecTimeoutMS,
 		MaxOutputChars: pptxExecMaxOutputChars,
+		DataFiles:      dataFiles,
 	}
 
 	key, orgID, err := resolveAuth()
@@ -116,31 +134,46 @@ func runPPTXExec(cmd *cobra.Command, args []string) error {
 
 	c := newAPIClient(key, orgID)
 	if pptxExecCreate {
-		c = client.New(resolveAPIURL(), key, orgID, true)
-		c.UserAgent = cliUserAgent()
+		c = newAPIClientMode(key, orgID, true)
 	}
 
 	var result *client.ExecResponse
 	var fileID string
 	if pptxExecCreate {
+		emitEvent("exec_started", map[string]any{"file": filePath})
 		result, err = c.PPTXExecCreate(filePath, req, pptxExecSave)
+	} else if pptxExecIfRevision != "" {
+		fileID, err = checkIfRevision(c, filePath, pptxExecIfRevision)
+		if err == nil {
+			emitEvent("exec_started", map[string]any{"file": filePath})
+			result, err = c.FilesPPTXExec(fileID, pptxExecIfRevision, req, pptxExecSave)
+			err = asRevisionConflict(err, filePath, fileID, pptxExecIfRevision)
+		}
 	} else if c.Stateless {
+		emitEvent("exec_started", map[string]any{"file": filePath})
 		result, err = c.PPTXExec(filePath, req, pptxExecSave)
 	} else {
 		var revisionID string
 		fileID, revisionID, err = c.EnsureUploaded(filePath)
 		if err == nil {
+			emitEvent("exec_started", map[string]any{"file": filePath})
 			result, err = c.FilesPPTXExec(fileID, revisionID, req, pptxExecSave)
 			if client.IsNotFound(err) {
 				fileID, revisionID, err = c.ReuploadFile(filePath)
 				if err == nil {
 					result, err = c.FilesPPTXExec(fileID, revisionID, req, pptxExecSave)
 				}
+			} else if client.IsRevisionConflict(err) && resolveAutoRefresh() {
+				fileID, revisionID, err = c.RefreshRevision(filePath)
+				if err == nil {
+					result, err = c.FilesPPTXExec(fileID, revisionID, req, pptxExecSave)
+				}
 			}
+			err = asRevisionConflict(err, filePath, fileID, revisionID)
 		}
 	}
 	if err != nil {
-		return err
+		return handleRevisionConflict(err, pptxJSONOutput)
 	}
 
 	if pptxExecSave && result.Ok {
@@ -150,32 +183,37 @@ func runPPTXExec(cmd *cobra.Command, args []string) error {
 				if err != nil {
 					return fmt.Errorf("decoding PPTX bytes: %w", err)
 				}
-				if err := os.WriteFile(filePath, decoded, 0o644); err != nil {
+				if err := writeFileAtomic(filePath, decoded); err != nil {
 					return fmt.Errorf("writing PPTX file: %w", err)
 				}
 			} else if pptxExecCreate {
 				return fmt.Errorf("creating PPTX file: expected file bytes in response")
 			}
 		} else if result.RevisionID != nil {
-			fileBytes, err := c.DownloadFileContent(fileID, *result.RevisionID)
+			fileBytes, changed, err := c.DownloadFileContentIfChanged(fileID, *result.RevisionID, uploadedHash(c, filePath))
 			if err != nil {
 				return fmt.Errorf("downloading updated PPTX file: %w", err)
 			}
-			if err := os.WriteFile(filePath, fileBytes, 0o644); err != nil {
-				return fmt.Errorf("writing updated PPTX file: %w", err)
-			}
-			if err := c.UpdateCachedRevision(filePath, fileID, *result.RevisionID); err != nil {
-				return fmt.Errorf("updating local cache: %w", err)
+			if !changed {
+				if err := c.UpdateCachedRevision(filePath, fileID, *result.RevisionID); err != nil {
+					return fmt.Errorf("updating local cache: %w", err)
+				}
+			} else {
+				if err := writeFileAtomic(filePath, fileBytes); err != nil {
+					return fmt.Errorf("writing updated PPTX file: %w", err)
+				}
+				if err := c.RecordDownloadedRevision(filePath, fileID, *result.RevisionID, fileBytes); err != nil {
+					return fmt.Errorf("updating local cache: %w", err)
+				}
 			}
 		}
 	}
 
-	if pptxJSONOutput {
-		result.File = nil
-		if err := jsonPrint(result); err != nil {
-			return err
-		}
-	} else {
+	result.File = nil
+	if err := applyImageMode(result, pptxJSONOutput, "witan-pptx-exec-"); err != nil {
+		return err
+	}
+	if err := emitResult(result, pptxJSONOutput, func() error {
 		if result.Stdout != "" {
 			fmt.Print(result.Stdout)
 		}
@@ -187,14 +225,18 @@ func runPPTXExec(cmd *cobra.Command, args []string) error {
 			fmt.Println(formatExecError(result.Error))
 		}
 		for _, img := range result.Images {
-			if err := writePPTXExecImage(img); err != nil {
-				return err
-			}
+			fmt.Println(img)
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	if !result.Ok {
-		return &ExitError{Code: 1}
+		if !resultShownOnStdout(pptxJSONOutput) {
+			fmt.Fprintln(os.Stderr, formatExecError(result.Error))
+		}
+		return &ExitError{Code: ExitFailure}
 	}
 	return nil
 }
@@ -311,31 +353,3 @@ func resolvePPTXExecLocale(cmd *cobra.Command) (string, error) {
 	}
 	return "", nil
 }
-
-func writePPTXExecImage(dataURL string) error {
-	ext := execImageExt(dataURL)
-	b64 := dataURL
-	if _, after, ok := strings.Cut(dataURL, ","); ok {
-		b64 = after
-	}
-	decoded, err := base64.StdEncoding.DecodeString(b64)
-	if err != nil {
-		return fmt.Errorf("decoding exec image: %w", err)
-	}
-	f, err := os.CreateTemp("", "witan-pptx-exec-*"+ext)
-	if err != nil {
-		return fmt.Errorf("creating temp image file: %w", err)
-	}
-	tmpPath := f.Name()
-	if _, err := f.Write(decoded); err != nil {
-		f.Close()
-		os.Remove(tmpPath)
-		return fmt.Errorf("writing exec image: %w", err)
-	}
-	if err := f.Close(); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("closing exec image file: %w", err)
-	}
-	fmt.Println(tmpPath)
-	return nil
-}
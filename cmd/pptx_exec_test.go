@@ -297,12 +297,14 @@ func resetPPTXExecTestGlobals(t *testing.T) {
 	origExecExpr := pptxExecExpr
 	origExecInputJSON := pptxExecInputJSON
 	origExecInputFiles := pptxExecInputFiles
+	origExecData := pptxExecData
 	origExecLocale := pptxExecLocale
 	origExecStdinTimeoutMS := pptxExecStdinTimeoutMS
 	origExecTimeoutMS := pptxExecTimeoutMS
 	origExecMaxOutputChars := pptxExecMaxOutputChars
 	origExecSave := pptxExecSave
 	origExecCreate := pptxExecCreate
+	origExecIfRevision := pptxExecIfRevision
 
 	t.Cleanup(func() {
 		apiKey = origAPIKey
@@ -315,12 +317,14 @@ func resetPPTXExecTestGlobals(t *testing.T) {
 		pptxExecExpr = origExecExpr
 		pptxExecInputJSON = origExecInputJSON
 		pptxExecInputFiles = origExecInputFiles
+		pptxExecData = origExecData
 		pptxExecLocale = origExecLocale
 		pptxExecStdinTimeoutMS = origExecStdinTimeoutMS
 		pptxExecTimeoutMS = origExecTimeoutMS
 		pptxExecMaxOutputChars = origExecMaxOutputChars
 		pptxExecSave = origExecSave
 		pptxExecCreate = origExecCreate
+		pptxExecIfRevision = origExecIfRevision
 	})
 
 	mockMgmtOrgsServer(t)
@@ -334,12 +338,14 @@ func resetPPTXExecTestGlobals(t *testing.T) {
 	pptxExecExpr = ""
 	pptxExecInputJSON = ""
 	pptxExecInputFiles = nil
+	pptxExecData = nil
 	pptxExecLocale = ""
 	pptxExecStdinTimeoutMS = defaultExecStdinTimeoutMS
 	pptxExecTimeoutMS = 0
 	pptxExecMaxOutputChars = 0
 	pptxExecSave = false
 	pptxExecCreate = false
+	pptxExecIfRevision = ""
 }
 
 func newPPTXExecTestCommand() *cobra.Command {
@@ -64,6 +64,7 @@ func init() {
 	pptxLintCmd.Flags().IntSliceVarP(&pptxLintSlides, "slide", "p", nil, `1-based slide number to lint (repeatable)`)
 	pptxLintCmd.Flags().StringArrayVarP(&pptxLintSkipRule, "skip-rule", "s", nil, `Rule ID to skip (repeatable)`)
 	pptxLintCmd.Flags().StringArrayVar(&pptxLintOnlyRule, "only-rule", nil, `Run only these rule IDs (repeatable)`)
+	addResultOutputFlag(pptxLintCmd)
 	pptxCmd.AddCommand(pptxLintCmd)
 }
 
@@ -114,11 +115,7 @@ func runPPTXLint(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if pptxJSONOutput {
-		if err := jsonPrint(result); err != nil {
-			return err
-		}
-	} else {
+	if err := emitResult(result, pptxJSONOutput, func() error {
 		// Group diagnostics by severity
 		errors := []client.LintDiagnostic{}
 		warnings := []client.LintDiagnostic{}
@@ -160,12 +157,15 @@ func runPPTXLint(cmd *cobra.Command, args []string) error {
 			fmt.Print("s")
 		}
 		fmt.Printf(", %d info)\n", len(infos))
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	// Exit 2 when any error- or warning-severity diagnostics exist
 	for _, d := range result.Diagnostics {
 		if d.Severity == "Error" || d.Severity == "Warning" {
-			return &ExitError{Code: 2}
+			return &ExitError{Code: ExitFindings}
 		}
 	}
 	return nil
@@ -19,6 +19,7 @@ var (
 	pptxRenderDPR    int
 	pptxRenderOutput string
 	pptxRenderDiff   string
+	pptxRenderOpen   bool
 )
 
 var pptxRenderCmd = &cobra.Command{
@@ -29,7 +30,8 @@ var pptxRenderCmd = &cobra.Command{
 Examples:
   witan pptx render deck.pptx --slide 1
   witan pptx render deck.pptx --slide 3 --dpr 2 -o slide-3.png
-  witan pptx render deck.pptx --slide 1 --diff baseline.png`,
+  witan pptx render deck.pptx --slide 1 --diff baseline.png
+  witan pptx render deck.pptx --slide 1 --open`,
 	Args: cobra.ExactArgs(1),
 	RunE: runPPTXRender,
 }
@@ -39,6 +41,7 @@ func init() {
 	pptxRenderCmd.Flags().IntVar(&pptxRenderDPR, "dpr", 1, "Device pixel ratio 1-3")
 	pptxRenderCmd.Flags().StringVarP(&pptxRenderOutput, "output", "o", "", "Write image to this path (default: temporary file)")
 	pptxRenderCmd.Flags().StringVar(&pptxRenderDiff, "diff", "", "Compare against baseline PNG and write highlighted PNG diff")
+	pptxRenderCmd.Flags().BoolVar(&pptxRenderOpen, "open", false, "Open the rendered image in the default viewer")
 	pptxCmd.AddCommand(pptxRenderCmd)
 }
 
@@ -118,7 +121,11 @@ func runPPTXRender(cmd *cobra.Command, args []string) error {
 
 	outPath := pptxRenderOutput
 	if outPath == "" {
-		f, err := os.CreateTemp("", "witan-pptx-render-*.png")
+		dir, err := runTempDir()
+		if err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(dir, "witan-pptx-render-*.png")
 		if err != nil {
 			return fmt.Errorf("creating temp file: %w", err)
 		}
@@ -135,9 +142,12 @@ func runPPTXRender(cmd *cobra.Command, args []string) error {
 	}
 
 	if diffSummary != "" {
-		fmt.Printf("%s\nslide=%d | dpr=%d | %s\n", outPath, pptxRenderSlide, pptxRenderDPR, diffSummary)
+		fmt.Printf("%s\nslide=%d | dpr=%d | %s\n", outPath, pptxRenderSlide, pptxRenderDPR, colorDiffSummary(diffSummary))
 	} else {
 		fmt.Printf("%s\nslide=%d | dpr=%d | %s\n", outPath, pptxRenderSlide, pptxRenderDPR, contentType)
 	}
+	if pptxRenderOpen {
+		openRenderedOutput(outPath)
+	}
 	return nil
 }
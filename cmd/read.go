@@ -1,14 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/witanlabs/witan-cli/client"
@@ -21,11 +22,32 @@ var (
 	readLimit   int
 	readOutline bool
 	readJSON    bool
+
+	readConcurrency int
+
+	readGrep    string
+	readSearch  string
+	readContext int
+
+	readTables    string
+	readImages    bool
+	readImagesDir string
+
+	readOCR   bool
+	readLangs []string
+
+	readChunkSize    int
+	readChunkOverlap int
+	readChunkUnit    string
+
+	readNoCache bool
 )
 
+const defaultReadConcurrency = 4
+
 var readCmd = &cobra.Command{
-	Use:   "read <file-or-url>",
-	Short: "Extract text from documents (PDF, DOCX, PPTX, HTML, text)",
+	Use:   "read <file-or-url>...",
+	Short: "Extract text from documents (PDF, Word, Excel, PowerPoint, HTML, email, text, images)",
 	Long: `Extract text content or document outline from source material.
 
 Supported formats:
@@ -33,16 +55,61 @@ Supported formats:
   Word  (.doc, .docx)  Markdown via mammoth
   PPTX  (.ppt, .pptx)  Slide text extraction
   HTML  (.html, .htm)   Markdown via readability + turndown
+  EPUB  (.epub)   Markdown, one section per chapter
+  RTF   (.rtf)    Plain text
+  ODT   (.odt)    Markdown, like Word documents
+  Email (.eml, .msg)  Headers, then the body as Markdown; attachments listed
+  Excel (.xlsx, .xlsm, .xls)  Markdown table per sheet of displayed values;
+                              --outline lists sheets, --tables gives each
+                              sheet as a table
   Text  (.txt, .md, .csv, .json, .xml, .yaml, .toml)
+  Image (.png, .jpg, .jpeg, .tif, .tiff)  Text recognition (OCR)
 
 Navigation:
   Use --outline to get the document structure first, then target
   specific sections with --pages, --slides, or --offset/--limit.
 
+OCR:
+  --ocr recognizes text from page images, for scanned PDFs that have no
+  text layer. Image inputs are always read with OCR. --lang gives language
+  hints (ISO 639 codes such as en or de, repeatable) to improve recognition
+  of non-English documents.
+
+Chunking:
+  --chunk-size N splits the whole document into chunks of at most N
+  characters (or estimated tokens with --chunk-unit tokens) for LLM
+  ingestion, breaking between lines. --chunk-overlap repeats up to that
+  much of the previous chunk's trailing lines. Each chunk has an ID derived
+  from its text and the pages or slides it came from; use --json for a
+  machine-readable list.
+
 URL support:
   Pass an HTTP(S) URL as the argument to download and read remote
   content. Content-Type is detected from the HTTP response header.
 
+Search:
+  Use --grep <regex> or --search <text> (case-insensitive) to print only
+  matching lines, with --context N lines around each match. Without
+  --pages, --slides, --offset, or --limit the whole document is searched,
+  walking long PDFs and decks in 10-page windows. Each match reports the
+  page/slide window and line, so it can be re-read with --pages/--slides
+  and --offset.
+
+Tables and images:
+  --tables prints detected tables instead of text: CSV blocks separated by
+  a blank line, or a JSON array with --tables=json. --images writes embedded
+  images to files (under --images-dir, default a temp directory) and prints
+  their paths. With --json, the full response includes "tables" and
+  "images" (with local "path" values in place of image data).
+
+Multiple inputs:
+  Pass several files, URLs, or quoted glob patterns to read them in one
+  invocation. Inputs are read in parallel (--concurrency) with a shared
+  client, so stateful mode reuses uploaded revisions across files. Human
+  output prints a "==> <input> <==" header before each document; --json
+  prints a single array of {"input","result"} or {"input","error"} objects
+  in argument order. Exits 1 if any input fails.
+
 Examples:
   witan read report.pdf
   witan read report.pdf --outline
@@ -50,8 +117,15 @@ Examples:
   witan read slides.pptx --slides 1-3
   witan read notes.docx --offset 50 --limit 100
   witan read https://example.com/report.pdf --outline
-  witan read data.csv --json`,
-	Args: cobra.ExactArgs(1),
+  witan read data.csv --json
+  witan read manual.pdf --search "termination clause" --context 3
+  witan read report.pdf --pages 4-6 --tables
+  witan read notes.docx --images --images-dir ./figures
+  witan read scanned-contract.pdf --ocr --lang en --lang de
+  witan read screenshot.png
+  witan read handbook.pdf --chunk-size 2000 --chunk-overlap 200 --json
+  witan read 'docs/*.pdf' --outline --json`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runRead,
 }
 
@@ -62,30 +136,109 @@ func init() {
 	readCmd.Flags().IntVar(&readLimit, "limit", 0, "Max lines to return")
 	readCmd.Flags().BoolVar(&readOutline, "outline", false, "Show document structure instead of content")
 	readCmd.Flags().BoolVar(&readJSON, "json", false, "Output full JSON response")
+	readCmd.Flags().StringVar(&readGrep, "grep", "", "Print only lines matching this regular expression")
+	readCmd.Flags().StringVar(&readSearch, "search", "", "Print only lines containing this text (case-insensitive)")
+	readCmd.Flags().IntVar(&readContext, "context", 0, "Lines of context to print around each --grep/--search match")
+	readCmd.Flags().StringVar(&readTables, "tables", "", "Print detected tables instead of text: csv or json")
+	readCmd.Flags().Lookup("tables").NoOptDefVal = "csv"
+	readCmd.Flags().BoolVar(&readImages, "images", false, "Extract embedded images to files and print their paths")
+	readCmd.Flags().StringVar(&readImagesDir, "images-dir", "", "Directory for --images output (default: temporary files)")
+	readCmd.Flags().BoolVar(&readOCR, "ocr", false, "Recognize text from page images (for scanned PDFs)")
+	readCmd.Flags().StringSliceVar(&readLangs, "lang", nil, "OCR language hint, e.g. en or de (repeatable)")
+	readCmd.Flags().IntVar(&readChunkSize, "chunk-size", 0, "Split the whole document into chunks of at most this size")
+	readCmd.Flags().IntVar(&readChunkOverlap, "chunk-overlap", 0, "Repeat up to this much of the previous chunk at the start of each chunk")
+	readCmd.Flags().StringVar(&readChunkUnit, "chunk-unit", "chars", "Unit for --chunk-size and --chunk-overlap: chars or tokens (estimated)")
+	readCmd.Flags().BoolVar(&readNoCache, "no-cache", false, "Download URLs again instead of revalidating the cached copy")
+	readCmd.Flags().IntVar(&readConcurrency, "concurrency", defaultReadConcurrency, "Maximum inputs read in parallel when multiple files are given")
+	addResultOutputFlag(readCmd)
 	rootCmd.AddCommand(readCmd)
 }
 
 func runRead(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
-	input := args[0]
 
-	// Resolve input: URL or local file
-	filePath, cleanup, err := resolveReadInput(input)
+	if readConcurrency <= 0 {
+		return fmt.Errorf("--concurrency must be > 0")
+	}
+	if readGrep != "" && readSearch != "" {
+		return fmt.Errorf("--grep and --search cannot be combined")
+	}
+	searching := readGrep != "" || readSearch != ""
+	if searching && readOutline {
+		return fmt.Errorf("--grep/--search cannot be combined with --outline")
+	}
+	if readContext < 0 {
+		return fmt.Errorf("--context must be >= 0")
+	}
+	if readContext > 0 && !searching {
+		return fmt.Errorf("--context requires --grep or --search")
+	}
+	for _, lang := range readLangs {
+		if !readLangPattern.MatchString(lang) {
+			return fmt.Errorf("--lang must be a language code such as en or de, got %q", lang)
+		}
+	}
+	if err := validateReadChunking(); err != nil {
+		return err
+	}
+	if readTables != "" && readTables != "csv" && readTables != "json" {
+		return fmt.Errorf("--tables must be 'csv' or 'json', got %q", readTables)
+	}
+	if readImagesDir != "" && !readImages {
+		return fmt.Errorf("--images-dir requires --images")
+	}
+	if readExtracting() && (readOutline || searching) {
+		return fmt.Errorf("--tables/--images cannot be combined with --outline, --grep, or --search")
+	}
+
+	inputs, multi, err := expandReadInputs(args)
 	if err != nil {
 		return err
 	}
-	if cleanup != nil {
-		defer cleanup()
+	if multi {
+		key, orgID, err := resolveAuth()
+		if err != nil {
+			return err
+		}
+		return runReadMany(newAPIClient(key, orgID), inputs, buildReadParams())
 	}
 
+	// A missing local file is reported before any sign-in problem.
+	if !isReadURL(inputs[0]) {
+		if err := checkReadFile(inputs[0]); err != nil {
+			return err
+		}
+	}
 	key, orgID, err := resolveAuth()
 	if err != nil {
 		return err
 	}
-
 	c := newAPIClient(key, orgID)
 
-	// Build query params
+	// Resolve input: URL or local file
+	filePath, cleanup, err := resolveReadInput(c, inputs[0])
+	if err != nil {
+		return err
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	params := buildReadParams()
+
+	if readOutline {
+		return runReadOutline(c, filePath, params)
+	}
+	if searching {
+		return runReadSearch(c, filePath, params)
+	}
+	if readChunkSize > 0 {
+		return runReadChunks(c, filePath, params)
+	}
+	return runReadContent(c, filePath, params)
+}
+
+// buildReadParams builds the query params shared by every input of a read.
+func buildReadParams() url.Values {
 	params := url.Values{}
 	if readPages != "" {
 		params.Set("pages", readPages)
@@ -99,41 +252,271 @@ func runRead(cmd *cobra.Command, args []string) error {
 	if readLimit > 0 {
 		params.Set("limit", fmt.Sprintf("%d", readLimit))
 	}
+	if readTables != "" {
+		params.Set("tables", "true")
+	}
+	if readImages {
+		params.Set("images", "true")
+	}
+	if readOCR {
+		params.Set("ocr", "true")
+	}
+	if len(readLangs) > 0 {
+		params.Set("lang", strings.Join(readLangs, ","))
+	}
+	return params
+}
 
-	if readOutline {
-		return runReadOutline(c, filePath, params)
+// readLangPattern matches an ISO 639 code with an optional region or
+// script, e.g. en, deu, pt-BR, zh_Hans.
+var readLangPattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,4})?$`)
+
+// isReadImage reports whether filePath is an image, which can only be read
+// with OCR.
+func isReadImage(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".png", ".jpg", ".jpeg", ".tif", ".tiff":
+		return true
 	}
-	return runReadContent(c, filePath, params)
+	return false
 }
 
-func runReadContent(c *client.Client, filePath string, params url.Values) error {
-	var result *client.ReadResponse
-	var err error
+// readParamsFor returns params for reading filePath, turning on OCR for
+// image inputs.
+func readParamsFor(filePath string, params url.Values) url.Values {
+	if !isReadImage(filePath) || params.Get("ocr") != "" {
+		return params
+	}
+	withOCR := url.Values{}
+	for k, v := range params {
+		withOCR[k] = v
+	}
+	withOCR.Set("ocr", "true")
+	return withOCR
+}
 
-	if c.Stateless {
-		result, err = c.Read(filePath, params)
-	} else {
-		var fileId, revisionId string
-		fileId, revisionId, err = c.EnsureUploaded(filePath)
-		if err == nil {
-			result, err = c.FilesRead(fileId, revisionId, params)
-			if client.IsNotFound(err) {
-				fileId, revisionId, err = c.ReuploadFile(filePath)
-				if err == nil {
-					result, err = c.FilesRead(fileId, revisionId, params)
+// expandReadInputs expands glob patterns in local-file arguments. URLs and
+// paths that exist as-is are passed through unchanged. multi reports whether
+// the invocation should use the combined multi-file output, which is the case
+// whenever more than one argument or any glob pattern was given.
+func expandReadInputs(args []string) (inputs []string, multi bool, err error) {
+	multi = len(args) > 1
+	for _, arg := range args {
+		if isReadURL(arg) || !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		if _, statErr := os.Stat(arg); statErr == nil {
+			inputs = append(inputs, arg)
+			continue
+		}
+		matches, globErr := filepath.Glob(arg)
+		if globErr != nil {
+			return nil, false, fmt.Errorf("invalid glob pattern %q: %w", arg, globErr)
+		}
+		if len(matches) == 0 {
+			return nil, false, fmt.Errorf("no files match %q", arg)
+		}
+		inputs = append(inputs, matches...)
+		multi = true
+	}
+	return inputs, multi, nil
+}
+
+// readFileResult is one entry of the combined multi-file read output.
+type readFileResult struct {
+	Input  string `json:"input"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runReadMany reads every input with a shared client, at most
+// readConcurrency at a time, and prints the results in argument order.
+func runReadMany(c *client.Client, inputs []string, params url.Values) error {
+	results := make([]readFileResult, len(inputs))
+
+	workers := min(readConcurrency, len(inputs))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = readOneInput(c, input, params)
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	// An input whose tables or images cannot be written does not stop the
+	// others; the failures are returned together at the end.
+	var printErrs []error
+	if err := emitResult(results, readJSON, func() error {
+		for i, r := range results {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("==> %s <==\n", r.Input)
+			switch res := r.Result.(type) {
+			case *client.ReadResponse:
+				if readExtracting() {
+					if err := printReadExtractions(res); err != nil {
+						printErrs = append(printErrs, fmt.Errorf("%s: %w", r.Input, err))
+					}
+				} else {
+					printReadContent(res)
 				}
+			case *client.ReadOutlineResponse:
+				printReadOutline(res)
+			case *readSearchResult:
+				printReadSearch(res)
+			case *readChunkResult:
+				printReadChunks(res)
+			default:
+				fmt.Fprintf(os.Stderr, "error: %s: %s\n", r.Input, r.Error)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !resultShownOnStdout(readJSON) {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(os.Stderr, "error: %s: %s\n", r.Input, r.Error)
 			}
 		}
 	}
+
+	if len(printErrs) > 0 {
+		return errors.Join(printErrs...)
+	}
+	if failed > 0 {
+		return &ExitError{Code: ExitFailure}
+	}
+	return nil
+}
+
+// readOneInput resolves and reads a single input for runReadMany, capturing
+// any failure in the returned result instead of aborting the batch.
+func readOneInput(c *client.Client, input string, params url.Values) readFileResult {
+	entry := readFileResult{Input: input}
+
+	filePath, cleanup, err := resolveReadInput(c, input)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+
+	if readOutline {
+		result, err := fetchReadOutline(c, filePath, params)
+		if err != nil {
+			entry.Error = err.Error()
+			return entry
+		}
+		entry.Result = result
+		return entry
+	}
+
+	if readGrep != "" || readSearch != "" {
+		result, err := fetchReadSearch(c, filePath, params)
+		if err != nil {
+			entry.Error = err.Error()
+			return entry
+		}
+		entry.Result = result
+		return entry
+	}
+
+	if readChunkSize > 0 {
+		result, err := fetchReadChunks(c, filePath, params)
+		if err != nil {
+			entry.Error = err.Error()
+			return entry
+		}
+		entry.Result = result
+		return entry
+	}
+
+	result, err := fetchReadContent(c, filePath, params)
+	if err == nil {
+		err = saveReadImages(result)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Result = result
+	return entry
+}
+
+func runReadChunks(c *client.Client, filePath string, params url.Values) error {
+	result, err := fetchReadChunks(c, filePath, params)
 	if err != nil {
 		return err
 	}
+	return emitResult(result, readJSON, func() error {
+		printReadChunks(result)
+		return nil
+	})
+}
 
-	if readJSON {
-		return jsonPrint(result)
+func runReadContent(c *client.Client, filePath string, params url.Values) error {
+	result, err := fetchReadContent(c, filePath, params)
+	if err != nil {
+		return err
 	}
+	if err := saveReadImages(result); err != nil {
+		return err
+	}
+
+	return emitResult(result, readJSON, func() error {
+		if readExtracting() {
+			return printReadExtractions(result)
+		}
+		printReadContent(result)
+		return nil
+	})
+}
+
+func fetchReadContent(c *client.Client, filePath string, params url.Values) (*client.ReadResponse, error) {
+	if isReadWorkbook(filePath) {
+		return readWorkbookContent(c, filePath, params)
+	}
+	params = readParamsFor(filePath, params)
+	if c.Stateless {
+		return c.Read(filePath, params)
+	}
+
+	fileId, revisionId, err := c.EnsureUploaded(filePath)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.FilesRead(fileId, revisionId, params)
+	if client.IsNotFound(err) {
+		fileId, revisionId, err = c.ReuploadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		result, err = c.FilesRead(fileId, revisionId, params)
+	}
+	return result, err
+}
 
-	// Human-friendly output: line-numbered content to stdout
+// printReadContent prints line-numbered content to stdout and a metadata
+// summary to stderr.
+func printReadContent(result *client.ReadResponse) {
 	lineCount := 0
 	if result.Content != "" {
 		lines := strings.Split(result.Content, "\n")
@@ -162,42 +545,54 @@ func runReadContent(c *client.Client, filePath string, params url.Values) error
 		parts = append(parts, fmt.Sprintf("%d slides%s", *meta.TotalSlides, slidesRead))
 	}
 	parts = append(parts, fmt.Sprintf("%d lines total", meta.TotalLines))
+	if meta.OCR {
+		parts = append(parts, "OCR")
+	}
 	if lineCount > 0 {
 		parts = append(parts, fmt.Sprintf("showing %d–%d", meta.Offset, meta.Offset+lineCount-1))
 	}
 	fmt.Fprintf(os.Stderr, "%s  [%s]\n", result.Format, strings.Join(parts, ", "))
-
-	return nil
 }
 
 func runReadOutline(c *client.Client, filePath string, params url.Values) error {
-	var result *client.ReadOutlineResponse
-	var err error
+	result, err := fetchReadOutline(c, filePath, params)
+	if err != nil {
+		return err
+	}
+
+	return emitResult(result, readJSON, func() error {
+		printReadOutline(result)
+		return nil
+	})
+}
 
+func fetchReadOutline(c *client.Client, filePath string, params url.Values) (*client.ReadOutlineResponse, error) {
+	if isReadWorkbook(filePath) {
+		return readWorkbookOutline(c, filePath, params)
+	}
+	params = readParamsFor(filePath, params)
 	if c.Stateless {
-		result, err = c.ReadOutline(filePath, params)
-	} else {
-		var fileId, revisionId string
-		fileId, revisionId, err = c.EnsureUploaded(filePath)
-		if err == nil {
-			result, err = c.FilesReadOutline(fileId, revisionId, params)
-			if client.IsNotFound(err) {
-				fileId, revisionId, err = c.ReuploadFile(filePath)
-				if err == nil {
-					result, err = c.FilesReadOutline(fileId, revisionId, params)
-				}
-			}
-		}
+		return c.ReadOutline(filePath, params)
 	}
+
+	fileId, revisionId, err := c.EnsureUploaded(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if readJSON {
-		return jsonPrint(result)
+	result, err := c.FilesReadOutline(fileId, revisionId, params)
+	if client.IsNotFound(err) {
+		fileId, revisionId, err = c.ReuploadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		result, err = c.FilesReadOutline(fileId, revisionId, params)
 	}
+	return result, err
+}
 
-	// Human-friendly outline output
+// printReadOutline prints the outline to stdout and a metadata summary to
+// stderr.
+func printReadOutline(result *client.ReadOutlineResponse) {
 	if len(result.Outline) == 0 {
 		fmt.Println("(no outline)")
 	} else {
@@ -230,64 +625,97 @@ func runReadOutline(c *client.Client, filePath string, params url.Values) error
 	if len(parts) > 0 {
 		fmt.Fprintf(os.Stderr, "[%s]\n", strings.Join(parts, ", "))
 	}
+}
+
+func isReadURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
 
+// checkReadFile reports a local read input that cannot be accessed.
+func checkReadFile(input string) error {
+	if _, err := os.Stat(input); err != nil {
+		return fmt.Errorf("cannot access file: %w", err)
+	}
 	return nil
 }
 
-// resolveReadInput handles both local files and URLs.
+// resolveReadInput handles both local files and URLs. URLs are downloaded
+// through c, so they get the API client's retries and resume support.
 // Returns the local file path and an optional cleanup function.
-func resolveReadInput(input string) (string, func(), error) {
-	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
-		// Local file
-		if _, err := os.Stat(input); err != nil {
-			return "", nil, fmt.Errorf("cannot access file: %w", err)
-		}
-		return input, nil, nil
+func resolveReadInput(c *client.Client, input string) (string, func(), error) {
+	if !isReadURL(input) {
+		return input, nil, checkReadFile(input)
 	}
 
 	// URL: download to temp file
-	httpClient := &http.Client{Timeout: 60 * time.Second}
-	req, err := http.NewRequest("GET", input, nil)
+	dir, err := runTempDir()
 	if err != nil {
-		return "", nil, fmt.Errorf("invalid URL: %w", err)
+		return "", nil, err
 	}
-	setCLIUserAgent(req)
-
-	resp, err := httpClient.Do(req)
+	tmpFile, err := os.CreateTemp(dir, "witan-read-*")
 	if err != nil {
-		return "", nil, fmt.Errorf("downloading URL: %w", err)
+		return "", nil, fmt.Errorf("creating temp file: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return "", nil, fmt.Errorf("downloading URL: HTTP %d", resp.StatusCode)
+	info, err := downloadReadURL(c, input, tmpFile)
+	tmpFile.Close()
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", nil, fmt.Errorf("downloading URL: %w", err)
 	}
 
 	// Determine extension from Content-Type header, then URL path
-	ext := extFromContentType(resp.Header.Get("Content-Type"))
+	ext := extFromContentType(info.ContentType)
 	if ext == "" {
 		ext = filepath.Ext(urlPath(input))
 	}
 	if ext == "" {
 		ext = ".bin"
 	}
-
-	tmpFile, err := os.CreateTemp("", "witan-read-*"+ext)
-	if err != nil {
+	path := tmpFile.Name() + ext
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		os.Remove(tmpFile.Name())
 		return "", nil, fmt.Errorf("creating temp file: %w", err)
 	}
 
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-		return "", nil, fmt.Errorf("downloading URL: %w", err)
+	cleanup := func() {
+		os.Remove(path)
 	}
-	tmpFile.Close()
+	return path, cleanup, nil
+}
 
-	cleanup := func() {
-		os.Remove(tmpFile.Name())
+// downloadReadURL downloads rawURL into dst. A URL downloaded before is
+// revalidated with a conditional request and, when unchanged, copied from the
+// read URL cache instead.
+func downloadReadURL(c *client.Client, rawURL string, dst *os.File) (*client.DownloadInfo, error) {
+	if readNoCache {
+		return c.DownloadURL(rawURL, dst)
+	}
+	entry, cached := loadReadURLCache(rawURL)
+	if entry == nil {
+		info, err := c.DownloadURL(rawURL, dst)
+		if err == nil {
+			storeReadURLCache(rawURL, info, dst.Name())
+		}
+		return info, err
+	}
+
+	info, err := c.DownloadURLIfModified(rawURL, dst, entry.ETag, entry.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	if !info.NotModified {
+		storeReadURLCache(rawURL, info, dst.Name())
+		return info, nil
+	}
+	src, err := os.Open(cached)
+	if err != nil {
+		return nil, fmt.Errorf("reading cached download: %w", err)
+	}
+	defer src.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		return nil, fmt.Errorf("reading cached download: %w", err)
 	}
-	return tmpFile.Name(), cleanup, nil
+	return &client.DownloadInfo{ContentType: entry.ContentType, Size: entry.Size, ETag: entry.ETag, LastModified: entry.LastModified}, nil
 }
 
 func extFromContentType(ct string) string {
@@ -306,6 +734,24 @@ func extFromContentType(ct string) string {
 		return ".ppt"
 	case "text/html":
 		return ".html"
+	case "application/epub+zip":
+		return ".epub"
+	case "application/rtf", "text/rtf":
+		return ".rtf"
+	case "application/vnd.oasis.opendocument.text":
+		return ".odt"
+	case "message/rfc822":
+		return ".eml"
+	case "application/vnd.ms-outlook":
+		return ".msg"
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		return ".xlsx"
+	case "application/vnd.ms-excel.sheet.macroenabled.12":
+		return ".xlsm"
+	case "application/vnd.ms-excel.sheet.binary.macroenabled.12":
+		return ".xlsb"
+	case "application/vnd.ms-excel":
+		return ".xls"
 	case "text/markdown":
 		return ".md"
 	case "text/csv":
@@ -314,6 +760,12 @@ func extFromContentType(ct string) string {
 		return ".json"
 	case "application/xml", "text/xml":
 		return ".xml"
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/tiff":
+		return ".tiff"
 	default:
 		if strings.HasPrefix(ct, "text/") {
 			return ".txt"
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// charsPerToken is the rough characters-per-token ratio used to size chunks
+// with --chunk-unit tokens; it holds for English text with common LLM
+// tokenizers.
+const charsPerToken = 4
+
+// readChunk is one piece of a chunked document. ID is derived from the text
+// and its pages or slides, so re-chunking an unchanged document yields the
+// same IDs. Sizes are counted in characters (runes), not bytes. Pages or Slides
+// names where the text came from; line numbers are relative to the first
+// and last page or slide, as in --grep output.
+type readChunk struct {
+	ID        string `json:"id"`
+	Index     int    `json:"index"`
+	Text      string `json:"text"`
+	Chars     int    `json:"chars"`
+	Tokens    int    `json:"tokens"` // estimated
+	Pages     string `json:"pages,omitempty"`
+	Slides    string `json:"slides,omitempty"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// readChunkResult is the output of `witan read --chunk-size`.
+type readChunkResult struct {
+	Format       string      `json:"format"`
+	ChunkSize    int         `json:"chunk_size"`
+	ChunkOverlap int         `json:"chunk_overlap"`
+	ChunkUnit    string      `json:"chunk_unit"`
+	Chunks       []readChunk `json:"chunks"`
+	Total        int         `json:"total"`
+}
+
+// chunkUnit is one line (or a piece of a line too long for a chunk) with the
+// page or slide it came from.
+type chunkUnit struct {
+	text   string
+	window int // index of the page/slide in windows
+	line   int
+}
+
+func validateReadChunking() error {
+	if readChunkSize == 0 {
+		if readChunkOverlap != 0 || (readChunkUnit != "" && readChunkUnit != "chars") {
+			return fmt.Errorf("--chunk-overlap and --chunk-unit require --chunk-size")
+		}
+		return nil
+	}
+	if readChunkSize < 0 {
+		return fmt.Errorf("--chunk-size must be > 0")
+	}
+	if readChunkOverlap < 0 || readChunkOverlap >= readChunkSize {
+		return fmt.Errorf("--chunk-overlap must be >= 0 and less than --chunk-size")
+	}
+	if readChunkUnit != "chars" && readChunkUnit != "tokens" {
+		return fmt.Errorf("--chunk-unit must be 'chars' or 'tokens', got %q", readChunkUnit)
+	}
+	if readOutline || readGrep != "" || readSearch != "" || readExtracting() {
+		return fmt.Errorf("--chunk-size cannot be combined with --outline, --grep, --search, --tables, or --images")
+	}
+	if readPages != "" || readSlides != "" || readOffset != 0 || readLimit != 0 {
+		return fmt.Errorf("--chunk-size reads the whole document; it cannot be combined with --pages, --slides, --offset, or --limit")
+	}
+	return nil
+}
+
+// fetchReadChunks reads the whole document one page or slide at a time, so
+// every chunk knows exactly which pages it spans, and splits it into chunks.
+func fetchReadChunks(c *client.Client, filePath string, params url.Values) (*readChunkResult, error) {
+	windows, first, err := walkReadDocument(c, filePath, params, 1)
+	if err != nil {
+		return nil, err
+	}
+	size, overlap := readChunkSize, readChunkOverlap
+	if readChunkUnit == "tokens" {
+		size, overlap = size*charsPerToken, overlap*charsPerToken
+	}
+	chunks := chunkReadWindows(windows, size, overlap)
+	return &readChunkResult{
+		Format:       first.Format,
+		ChunkSize:    readChunkSize,
+		ChunkOverlap: readChunkOverlap,
+		ChunkUnit:    readChunkUnit,
+		Chunks:       chunks,
+		Total:        len(chunks),
+	}, nil
+}
+
+// chunkReadWindows splits the text of windows into chunks of at most size
+// characters, breaking between lines where possible. Each chunk after the
+// first starts with up to overlap characters of whole lines from the end of
+// the previous one.
+func chunkReadWindows(windows []*readWindow, size, overlap int) []readChunk {
+	var units []chunkUnit
+	for wi, w := range windows {
+		for _, line := range w.Lines {
+			for _, piece := range splitChunkLine(line.Text, size) {
+				units = append(units, chunkUnit{text: piece, window: wi, line: line.Line})
+			}
+		}
+	}
+
+	chunks := []readChunk{}
+	seen := map[string]int{}
+	for start := 0; start < len(units); {
+		end, n := start, 0
+		for end < len(units) {
+			add := utf8.RuneCountInString(units[end].text)
+			if end > start {
+				add++ // newline
+			}
+			if end > start && n+add > size {
+				break
+			}
+			n += add
+			end++
+		}
+
+		texts := make([]string, 0, end-start)
+		for _, u := range units[start:end] {
+			texts = append(texts, u.text)
+		}
+		text := strings.Join(texts, "\n")
+		if strings.TrimSpace(text) != "" {
+			first, last := units[start], units[end-1]
+			chars := utf8.RuneCountInString(text)
+			chunk := readChunk{
+				Index:     len(chunks),
+				Text:      text,
+				Chars:     chars,
+				Tokens:    (chars + charsPerToken - 1) / charsPerToken,
+				Pages:     joinWindowRanges(windows[first.window].Pages, windows[last.window].Pages),
+				Slides:    joinWindowRanges(windows[first.window].Slides, windows[last.window].Slides),
+				StartLine: first.line,
+				EndLine:   last.line,
+			}
+			sum := sha256.Sum256([]byte(chunk.Pages + "\x00" + chunk.Slides + "\x00" + text))
+			chunk.ID = hex.EncodeToString(sum[:8])
+			seen[chunk.ID]++
+			if n := seen[chunk.ID]; n > 1 {
+				chunk.ID += "-" + strconv.Itoa(n)
+			}
+			chunks = append(chunks, chunk)
+		}
+		if end >= len(units) {
+			break
+		}
+
+		// Back up over whole lines for the overlap, always moving forward and
+		// leaving room for the next new line.
+		next, back := end, 0
+		for next-1 > start {
+			add := utf8.RuneCountInString(units[next-1].text) + 1
+			if back+add > overlap || back+add+utf8.RuneCountInString(units[end].text) > size {
+				break
+			}
+			back += add
+			next--
+		}
+		start = next
+	}
+	return chunks
+}
+
+// splitChunkLine splits a line longer than size characters at the last
+// space before the limit, or at the limit when there is none. Cuts fall on
+// rune boundaries.
+func splitChunkLine(line string, size int) []string {
+	var pieces []string
+	for utf8.RuneCountInString(line) > size {
+		limit := runeOffset(line, size)
+		cut := strings.LastIndexByte(line[:limit], ' ')
+		if cut <= 0 {
+			cut = limit
+		}
+		pieces = append(pieces, line[:cut])
+		line = strings.TrimLeft(line[cut:], " ")
+	}
+	return append(pieces, line)
+}
+
+// runeOffset returns the byte offset of the rune at index n of s, or len(s)
+// when s has n runes or fewer.
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+// joinWindowRanges combines the ranges of a chunk's first and last window,
+// e.g. "3" and "4" into "3-4".
+func joinWindowRanges(first, last string) string {
+	if first == "" || first == last {
+		return first
+	}
+	start, _, _ := strings.Cut(first, "-")
+	end := last
+	if i := strings.LastIndexByte(last, '-'); i >= 0 {
+		end = last[i+1:]
+	}
+	return start + "-" + end
+}
+
+// printReadChunks prints each chunk under a header naming its ID and
+// source location.
+func printReadChunks(result *readChunkResult) {
+	for i, chunk := range result.Chunks {
+		if i > 0 {
+			fmt.Println()
+		}
+		where := fmt.Sprintf("lines %d-%d", chunk.StartLine, chunk.EndLine)
+		if chunk.Pages != "" {
+			where = "pages " + chunk.Pages + ", " + where
+		} else if chunk.Slides != "" {
+			where = "slides " + chunk.Slides + ", " + where
+		}
+		fmt.Printf("==> chunk %d %s [%s] <==\n%s\n", chunk.Index+1, chunk.ID, where, chunk.Text)
+	}
+	fmt.Fprintf(os.Stderr, "%s  [%s]\n", result.Format, pluralize(result.Total, "chunk", "chunks"))
+}
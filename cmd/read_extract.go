@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// readExtracting reports whether --tables or --images replaces the default
+// text output.
+func readExtracting() bool {
+	return readTables != "" || readImages
+}
+
+// saveReadImages decodes each embedded image into a file under
+// readImagesDir (or the per-invocation temp dir), records the path on the
+// image, and drops the base64 payload so --json output stays small.
+func saveReadImages(result *client.ReadResponse) error {
+	if !readImages || len(result.Images) == 0 {
+		return nil
+	}
+
+	dir := readImagesDir
+	if dir == "" {
+		var err error
+		if dir, err = runTempDir(); err != nil {
+			return err
+		}
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating images directory: %w", err)
+	}
+
+	for i := range result.Images {
+		img := &result.Images[i]
+		decoded, err := base64.StdEncoding.DecodeString(img.Data)
+		if err != nil {
+			return fmt.Errorf("decoding read image: %w", err)
+		}
+		f, err := os.CreateTemp(dir, "witan-read-*"+readImageExt(img.ContentType))
+		if err != nil {
+			return fmt.Errorf("creating image file: %w", err)
+		}
+		path := f.Name()
+		if _, err := f.Write(decoded); err != nil {
+			f.Close()
+			os.Remove(path)
+			return fmt.Errorf("writing read image: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(path)
+			return fmt.Errorf("closing image file: %w", err)
+		}
+		img.Path = path
+		img.Data = ""
+	}
+	return nil
+}
+
+// readImageExt maps an image content type to a file extension.
+func readImageExt(contentType string) string {
+	ct := strings.TrimSpace(strings.ToLower(strings.SplitN(contentType, ";", 2)[0]))
+	switch ct {
+	case "image/png":
+		return ".png"
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/svg+xml":
+		return ".svg"
+	case "image/tiff":
+		return ".tiff"
+	case "image/bmp":
+		return ".bmp"
+	case "image/x-emf", "image/emf":
+		return ".emf"
+	default:
+		return ".bin"
+	}
+}
+
+// printReadExtractions prints detected tables (CSV blocks or a JSON array) and
+// extracted image paths to stdout, with a count summary on stderr.
+func printReadExtractions(result *client.ReadResponse) error {
+	switch readTables {
+	case "json":
+		tables := result.Tables
+		if tables == nil {
+			tables = []client.ReadTable{}
+		}
+		if err := jsonPrint(tables); err != nil {
+			return err
+		}
+	case "csv":
+		for i, table := range result.Tables {
+			if i > 0 {
+				fmt.Println()
+			}
+			w := csv.NewWriter(os.Stdout)
+			if err := w.WriteAll(table.Rows); err != nil {
+				return fmt.Errorf("writing table CSV: %w", err)
+			}
+		}
+	}
+
+	for _, img := range result.Images {
+		fmt.Println(img.Path)
+	}
+
+	parts := []string{}
+	if readTables != "" {
+		parts = append(parts, pluralize(len(result.Tables), "table", "tables"))
+	}
+	if readImages {
+		parts = append(parts, pluralize(len(result.Images), "image", "images"))
+	}
+	fmt.Fprintf(os.Stderr, "%s  [%s]\n", result.Format, strings.Join(parts, ", "))
+	return nil
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// readSearchPageWindow matches the server's per-read page and slide cap, so a
+// search over a long document walks it in windows of this size.
+const readSearchPageWindow = 10
+
+// readSearchLine is a single numbered line of read output.
+type readSearchLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// readSearchMatch is one matching line plus its surrounding context. Pages or
+// Slides names the window the line number is relative to, so the match can be
+// re-read with --pages/--slides plus --offset.
+type readSearchMatch struct {
+	Pages  string           `json:"pages,omitempty"`
+	Slides string           `json:"slides,omitempty"`
+	Line   int              `json:"line"`
+	Text   string           `json:"text"`
+	Before []readSearchLine `json:"before,omitempty"`
+	After  []readSearchLine `json:"after,omitempty"`
+}
+
+// readSearchResult is the output of `witan read --grep/--search`.
+type readSearchResult struct {
+	Pattern string            `json:"pattern"`
+	Format  string            `json:"format"`
+	Matches []readSearchMatch `json:"matches"`
+	Total   int               `json:"total"`
+}
+
+// readWindow is the full text of one page or slide window.
+type readWindow struct {
+	Pages  string
+	Slides string
+	Lines  []readSearchLine
+}
+
+func runReadSearch(c *client.Client, filePath string, params url.Values) error {
+	result, err := fetchReadSearch(c, filePath, params)
+	if err != nil {
+		return err
+	}
+
+	return emitResult(result, readJSON, func() error {
+		printReadSearch(result)
+		return nil
+	})
+}
+
+func fetchReadSearch(c *client.Client, filePath string, params url.Values) (*readSearchResult, error) {
+	match, pattern, err := readSearchMatcher(readGrep, readSearch)
+	if err != nil {
+		return nil, err
+	}
+	return searchReadInput(c, filePath, params, match, pattern, readContext)
+}
+
+// readSearchMatcher returns the line predicate for --grep (regular
+// expression) or --search (case-insensitive literal text).
+func readSearchMatcher(grep, search string) (func(string) bool, string, error) {
+	if grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		return re.MatchString, grep, nil
+	}
+	needle := strings.ToLower(search)
+	return func(line string) bool {
+		return strings.Contains(strings.ToLower(line), needle)
+	}, search, nil
+}
+
+// searchReadInput reads filePath and returns the lines matching match. When
+// no --pages, --slides, --offset, or --limit is given, the whole document is
+// walked in page/slide windows and line pages so matches beyond the first
+// read are found without extra round trips from the caller.
+func searchReadInput(c *client.Client, filePath string, params url.Values, match func(string) bool, pattern string, context int) (*readSearchResult, error) {
+	paginateLines := readOffset == 0 && readLimit == 0
+	walkDocument := paginateLines && readPages == "" && readSlides == ""
+
+	var windows []*readWindow
+	var firstResp *client.ReadResponse
+	var err error
+	if walkDocument {
+		windows, firstResp, err = walkReadDocument(c, filePath, params, readSearchPageWindow)
+	} else {
+		var first *readWindow
+		first, firstResp, err = fetchReadWindow(c, filePath, params, paginateLines)
+		if first != nil {
+			first.Pages = readPages
+			first.Slides = readSlides
+			windows = []*readWindow{first}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &readSearchResult{Pattern: pattern, Format: firstResp.Format, Matches: []readSearchMatch{}}
+	for _, w := range windows {
+		for i, line := range w.Lines {
+			if !match(line.Text) {
+				continue
+			}
+			m := readSearchMatch{Pages: w.Pages, Slides: w.Slides, Line: line.Line, Text: line.Text}
+			if context > 0 {
+				m.Before = append([]readSearchLine(nil), w.Lines[max(0, i-context):i]...)
+				m.After = append([]readSearchLine(nil), w.Lines[i+1:min(len(w.Lines), i+1+context)]...)
+			}
+			result.Matches = append(result.Matches, m)
+		}
+	}
+	result.Total = len(result.Matches)
+	return result, nil
+}
+
+// walkReadDocument reads the whole document: every line of every page or
+// slide, in windows of at most window pages or slides, each labelled with
+// the range it covers. Documents without pages or slides are one window. It
+// returns the first response so callers can inspect document-level metadata.
+func walkReadDocument(c *client.Client, filePath string, params url.Values, window int) ([]*readWindow, *client.ReadResponse, error) {
+	first, firstResp, err := fetchReadWindow(c, filePath, params, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := firstResp.Metadata
+	var key string
+	var total, read int
+	switch {
+	case meta.TotalPages != nil:
+		key, total, read = "pages", *meta.TotalPages, *meta.TotalPages
+		if meta.ReadPages != nil {
+			read = *meta.ReadPages
+		}
+	case meta.TotalSlides != nil:
+		key, total, read = "slides", *meta.TotalSlides, *meta.TotalSlides
+		if meta.ReadSlides != nil {
+			read = *meta.ReadSlides
+		}
+	default:
+		return []*readWindow{first}, firstResp, nil
+	}
+
+	var windows []*readWindow
+	start := 1
+	if read <= window {
+		// The first read is a usable window; otherwise it is re-read in
+		// smaller ones below.
+		first.setRange(key, readWindowRange(1, read))
+		windows = append(windows, first)
+		start = read + 1
+	}
+	for ; start <= total; start += window {
+		r := readWindowRange(start, min(start+window-1, total))
+		w, err := fetchReadWindowFor(c, filePath, params, key, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		w.setRange(key, r)
+		windows = append(windows, w)
+	}
+	return windows, firstResp, nil
+}
+
+func (w *readWindow) setRange(key, r string) {
+	if key == "pages" {
+		w.Pages = r
+	} else {
+		w.Slides = r
+	}
+}
+
+func fetchReadWindowFor(c *client.Client, filePath string, base url.Values, key, value string) (*readWindow, error) {
+	params := make(url.Values)
+	for k, v := range base {
+		params[k] = v
+	}
+	params.Set(key, value)
+	w, _, err := fetchReadWindow(c, filePath, params, true)
+	return w, err
+}
+
+// fetchReadWindow reads one window and, when paginateLines is set, follows
+// the line offset until the window's total_lines are covered. It returns the
+// first response so callers can inspect document-level metadata.
+func fetchReadWindow(c *client.Client, filePath string, base url.Values, paginateLines bool) (*readWindow, *client.ReadResponse, error) {
+	params := make(url.Values)
+	for k, v := range base {
+		params[k] = v
+	}
+
+	w := &readWindow{}
+	var first *client.ReadResponse
+	for {
+		result, err := fetchReadContent(c, filePath, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		if first == nil {
+			first = result
+		}
+		if result.Content == "" {
+			break
+		}
+
+		lines := strings.Split(result.Content, "\n")
+		for i, line := range lines {
+			w.Lines = append(w.Lines, readSearchLine{Line: result.Metadata.Offset + i, Text: line})
+		}
+
+		next := result.Metadata.Offset + len(lines)
+		if !paginateLines || next > result.Metadata.TotalLines {
+			break
+		}
+		params.Set("offset", strconv.Itoa(next))
+	}
+	return w, first, nil
+}
+
+func readWindowRange(start, end int) string {
+	if start == end {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+// printReadSearch prints matches grep-style: "N:" marks a matching line,
+// "N-" a context line, and "--" separates non-adjacent groups. A
+// "[pages X]" or "[slides X]" header precedes the first match of each window.
+func printReadSearch(result *readSearchResult) {
+	type windowKey struct{ pages, slides string }
+
+	matchLines := make(map[windowKey]map[int]bool)
+	for _, m := range result.Matches {
+		k := windowKey{m.Pages, m.Slides}
+		if matchLines[k] == nil {
+			matchLines[k] = make(map[int]bool)
+		}
+		matchLines[k][m.Line] = true
+	}
+
+	var current windowKey
+	last := 0
+	started := false
+	for _, m := range result.Matches {
+		k := windowKey{m.Pages, m.Slides}
+		if !started || k != current {
+			if started {
+				fmt.Println()
+			}
+			if k.pages != "" {
+				fmt.Printf("[pages %s]\n", k.pages)
+			} else if k.slides != "" {
+				fmt.Printf("[slides %s]\n", k.slides)
+			}
+			current = k
+			last = 0
+			started = true
+		}
+
+		lines := append(append(append([]readSearchLine(nil), m.Before...), readSearchLine{Line: m.Line, Text: m.Text}), m.After...)
+		for _, line := range lines {
+			if line.Line <= last {
+				continue
+			}
+			if last != 0 && line.Line > last+1 {
+				fmt.Println("--")
+			}
+			sep := "-"
+			if matchLines[k][line.Line] {
+				sep = ":"
+			}
+			fmt.Printf("%6d%s\t%s\n", line.Line, sep, line.Text)
+			last = line.Line
+		}
+	}
+
+	noun := "matches"
+	if result.Total == 1 {
+		noun = "match"
+	}
+	fmt.Fprintf(os.Stderr, "%s  [%d %s for %q]\n", result.Format, result.Total, noun, result.Pattern)
+}
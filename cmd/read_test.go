@@ -0,0 +1,700 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestExpandReadInputs_ExpandsGlobsAndKeepsURLs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pdf", "b.pdf", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	inputs, multi, err := expandReadInputs([]string{filepath.Join(dir, "*.pdf")})
+	if err != nil {
+		t.Fatalf("expandReadInputs failed: %v", err)
+	}
+	if !multi {
+		t.Fatal("expected glob input to select multi-file mode")
+	}
+	want := []string{filepath.Join(dir, "a.pdf"), filepath.Join(dir, "b.pdf")}
+	if len(inputs) != len(want) || inputs[0] != want[0] || inputs[1] != want[1] {
+		t.Fatalf("unexpected inputs: %v", inputs)
+	}
+
+	inputs, multi, err = expandReadInputs([]string{"https://example.com/a?b=*"})
+	if err != nil {
+		t.Fatalf("expandReadInputs failed: %v", err)
+	}
+	if multi || len(inputs) != 1 || inputs[0] != "https://example.com/a?b=*" {
+		t.Fatalf("expected URL passthrough, got %v multi=%v", inputs, multi)
+	}
+
+	if _, _, err := expandReadInputs([]string{filepath.Join(dir, "*.docx")}); err == nil {
+		t.Fatal("expected error for glob with no matches")
+	}
+}
+
+func TestRunRead_MultipleInputsPrintsCombinedJSON(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origReadJSON := readJSON
+	origReadOutline := readOutline
+	origReadConcurrency := readConcurrency
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		readJSON = origReadJSON
+		readOutline = origReadOutline
+		readConcurrency = origReadConcurrency
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/read" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"content":"hello","format":"text","metadata":{"total_lines":1,"offset":1,"limit":1}}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	missing := filepath.Join(dir, "missing.txt")
+	for _, p := range []string{first, second} {
+		if err := os.WriteFile(p, []byte("hello"), 0o644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	readJSON = true
+	readOutline = false
+	readConcurrency = 2
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{first, missing, second})
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected exit code 1 for the missing input, got %v", err)
+	}
+
+	var results []struct {
+		Input  string          `json:"input"`
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("parsing output: %v\n%s", err, out)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Input != first || len(results[0].Result) == 0 || results[0].Error != "" {
+		t.Fatalf("unexpected first result: %+v", results[0])
+	}
+	if results[1].Input != missing || results[1].Error == "" {
+		t.Fatalf("expected error for missing input, got %+v", results[1])
+	}
+	if results[2].Input != second || len(results[2].Result) == 0 {
+		t.Fatalf("unexpected third result: %+v", results[2])
+	}
+}
+
+func TestRunReadMany_KeepsPrintingAfterAnExtractionError(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless := apiKey, apiURL, stateless
+	origReadJSON, origReadTables, origReadImages, origReadConcurrency := readJSON, readTables, readImages, readConcurrency
+	origStdout := os.Stdout
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless = origAPIKey, origAPIURL, origStateless
+		readJSON, readTables, readImages, readConcurrency = origReadJSON, origReadTables, origReadImages, origReadConcurrency
+		os.Stdout = origStdout
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":"text","format":"pdf","metadata":{"total_lines":1,"offset":1,"limit":2000},`+
+			`"tables":[{"page":1,"rows":[["Region","Revenue"]]}]}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.pdf")
+	second := filepath.Join(dir, "second.pdf")
+	for _, p := range []string{first, second} {
+		if err := os.WriteFile(p, []byte("%PDF-1.7"), 0o644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey, apiURL, stateless = "", server.URL, true
+	readJSON, readTables, readImages, readConcurrency = false, "csv", false, 2
+
+	// Writing the CSV tables fails for every input.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	w.Close()
+	os.Stdout = w
+
+	err = runRead(&cobra.Command{}, []string{first, second})
+	os.Stdout = origStdout
+	if err == nil || !strings.Contains(err.Error(), first+": writing table CSV") || !strings.Contains(err.Error(), second+": writing table CSV") {
+		t.Fatalf("expected CSV errors for both inputs, got %v", err)
+	}
+}
+
+func TestRunRead_SearchWalksPageWindows(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origReadJSON := readJSON
+	origReadSearch := readSearch
+	origReadContext := readContext
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		readJSON = origReadJSON
+		readSearch = origReadSearch
+		readContext = origReadContext
+	})
+
+	var requestedPages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages := r.URL.Query().Get("pages")
+		requestedPages = append(requestedPages, pages)
+		w.Header().Set("Content-Type", "application/json")
+		switch pages {
+		case "":
+			fmt.Fprint(w, `{"content":"intro\nthe Termination Clause\noutro","format":"pdf","metadata":{"total_pages":12,"read_pages":10,"total_lines":3,"offset":1,"limit":2000}}`)
+		case "11-12":
+			fmt.Fprint(w, `{"content":"appendix\nsee termination clause","format":"pdf","metadata":{"total_pages":12,"read_pages":2,"total_lines":2,"offset":1,"limit":2000}}`)
+		default:
+			t.Errorf("unexpected pages=%q", pages)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "manual.pdf")
+	if err := os.WriteFile(filePath, []byte("%PDF-1.7"), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	readJSON = true
+	readSearch = "termination clause"
+	readContext = 1
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRead failed: %v", err)
+	}
+
+	var result readSearchResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("parsing output: %v\n%s", err, out)
+	}
+	if result.Total != 2 {
+		t.Fatalf("expected 2 matches, got %d: %s", result.Total, out)
+	}
+	if m := result.Matches[0]; m.Pages != "1-10" || m.Line != 2 || len(m.Before) != 1 || len(m.After) != 1 {
+		t.Fatalf("unexpected first match: %+v", m)
+	}
+	if m := result.Matches[1]; m.Pages != "11-12" || m.Line != 2 || m.Before[0].Text != "appendix" {
+		t.Fatalf("unexpected second match: %+v", m)
+	}
+	if len(requestedPages) != 2 {
+		t.Fatalf("expected 2 reads, got %v", requestedPages)
+	}
+}
+
+func TestRunRead_TablesAndImagesExtraction(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origReadJSON := readJSON
+	origReadTables := readTables
+	origReadImages := readImages
+	origReadImagesDir := readImagesDir
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		readJSON = origReadJSON
+		readTables = origReadTables
+		readImages = origReadImages
+		readImagesDir = origReadImagesDir
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("tables") != "true" || q.Get("images") != "true" {
+			t.Errorf("expected tables=true and images=true, got %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":"text","format":"pdf","metadata":{"total_lines":1,"offset":1,"limit":2000},`+
+			`"tables":[{"page":2,"rows":[["Region","Revenue"],["North, East","100"]]}],`+
+			`"images":[{"page":1,"content_type":"image/png","data":"aGVsbG8="}]}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(filePath, []byte("%PDF-1.7"), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+	imagesDir := filepath.Join(t.TempDir(), "figures")
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	readJSON = false
+	readTables = "csv"
+	readImages = true
+	readImagesDir = imagesDir
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRead failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 2 CSV rows and 1 image path, got:\n%s", out)
+	}
+	if lines[0] != "Region,Revenue" || lines[1] != `"North, East",100` {
+		t.Fatalf("unexpected CSV output:\n%s", out)
+	}
+	if filepath.Dir(lines[2]) != imagesDir || filepath.Ext(lines[2]) != ".png" {
+		t.Fatalf("unexpected image path %q", lines[2])
+	}
+	data, err := os.ReadFile(lines[2])
+	if err != nil {
+		t.Fatalf("reading extracted image: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("unexpected image bytes %q", data)
+	}
+}
+
+func TestSaveReadImages_DefaultsToRunTempDir(t *testing.T) {
+	origReadImages, origReadImagesDir := readImages, readImagesDir
+	prev := runTemp.dir
+	t.Cleanup(func() {
+		readImages, readImagesDir = origReadImages, origReadImagesDir
+		runTemp.dir = prev
+	})
+	runTemp.once.Do(func() {})
+	runTemp.dir = t.TempDir()
+	readImages, readImagesDir = true, ""
+
+	result := &client.ReadResponse{Images: []client.ReadImage{{ContentType: "image/png", Data: "aGVsbG8="}}}
+	if err := saveReadImages(result); err != nil {
+		t.Fatalf("saveReadImages: %v", err)
+	}
+	if got := filepath.Dir(result.Images[0].Path); got != runTemp.dir {
+		t.Fatalf("image saved under %q, want the run temp dir %q", got, runTemp.dir)
+	}
+}
+
+func TestRunRead_ImageInputUsesOCR(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origReadJSON := readJSON
+	origReadOCR := readOCR
+	origReadLangs := readLangs
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		readJSON = origReadJSON
+		readOCR = origReadOCR
+		readLangs = origReadLangs
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("ocr") != "true" || q.Get("lang") != "en,de" {
+			t.Errorf("expected ocr=true and lang=en,de, got %q", r.URL.RawQuery)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "image/png" {
+			t.Errorf("Content-Type = %q, want image/png", ct)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":"Vertrag","format":"image","metadata":{"total_lines":1,"offset":1,"limit":2000,"ocr":true}}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "scan.png")
+	if err := os.WriteFile(filePath, []byte("\x89PNG"), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	readJSON = true
+	readOCR = false
+	readLangs = []string{"en", "de"}
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRead failed: %v", err)
+	}
+	if !strings.Contains(out, `"ocr": true`) {
+		t.Fatalf("expected OCR metadata in output:\n%s", out)
+	}
+
+	readLangs = []string{"english please"}
+	if err := runRead(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "--lang") {
+		t.Fatalf("expected --lang validation error, got %v", err)
+	}
+}
+
+func TestRunRead_MissingFileReportedBeforeAuth(t *testing.T) {
+	origAPIKey := apiKey
+	t.Cleanup(func() { apiKey = origAPIKey })
+
+	// The API key cannot be resolved to an organization, so signing in
+	// would fail if it were tried first.
+	mgmt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected management request: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer mgmt.Close()
+	t.Setenv("WITAN_MANAGEMENT_API_URL", mgmt.URL)
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = "bad-key"
+
+	missing := filepath.Join(t.TempDir(), "missing.pdf")
+	err := runRead(&cobra.Command{}, []string{missing})
+	if err == nil || !strings.Contains(err.Error(), "cannot access file") || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a file-not-found error, got %v", err)
+	}
+}
+
+func TestExtFromContentType_DocumentFormats(t *testing.T) {
+	tests := map[string]string{
+		"application/epub+zip":                    ".epub",
+		"text/rtf; charset=us-ascii":              ".rtf",
+		"application/rtf":                         ".rtf",
+		"application/vnd.oasis.opendocument.text": ".odt",
+		"message/rfc822":                          ".eml",
+		"application/vnd.ms-outlook":              ".msg",
+		"text/plain":                              ".txt",
+	}
+	for ct, want := range tests {
+		if got := extFromContentType(ct); got != want {
+			t.Errorf("extFromContentType(%q) = %q, want %q", ct, got, want)
+		}
+	}
+}
+
+func TestWorkbookLines(t *testing.T) {
+	lines, starts := workbookLines([]client.WorkbookSheetText{
+		{Sheet: "Summary", Address: "A1:B2", Rows: [][]string{{"Region", "Revenue"}, {"North|East", "100"}}},
+		{Sheet: "Notes", Address: "A1", Hidden: true},
+	})
+	want := []string{
+		"## Summary (A1:B2)",
+		"",
+		"| Region | Revenue |",
+		"| --- | --- |",
+		`| North\|East | 100 |`,
+		"",
+		"## Notes (A1) [hidden]",
+		"",
+		"(empty)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected lines:\n%s", strings.Join(lines, "\n"))
+	}
+	if len(starts) != 2 || starts[0] != 1 || starts[1] != 7 {
+		t.Fatalf("starts = %v, want [1 7]", starts)
+	}
+}
+
+func TestRunRead_WorkbookOutlineAndContent(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origReadJSON := readJSON
+	origReadOutline := readOutline
+	origReadOffset := readOffset
+	origReadPages := readPages
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		readJSON = origReadJSON
+		readOutline = origReadOutline
+		readOffset = origReadOffset
+		readPages = origReadPages
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":[`+
+			`{"sheet":"Summary","address":"A1:B2","rows":[["Region","Revenue"],["North","100"]]},`+
+			`{"sheet":"Data","address":"A1:A1","rows":[["x"]]}]}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "model.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	readJSON = true
+	readOutline = true
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRead --outline failed: %v", err)
+	}
+	var outline client.ReadOutlineResponse
+	if err := json.Unmarshal([]byte(out), &outline); err != nil {
+		t.Fatalf("parsing outline: %v\n%s", err, out)
+	}
+	if len(outline.Outline) != 2 || outline.Outline[1].Title != "Data" || *outline.Outline[1].Offset != 7 {
+		t.Fatalf("unexpected outline: %s", out)
+	}
+
+	readOutline = false
+	readOffset = 7
+	out, err = captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRead failed: %v", err)
+	}
+	var content client.ReadResponse
+	if err := json.Unmarshal([]byte(out), &content); err != nil {
+		t.Fatalf("parsing content: %v\n%s", err, out)
+	}
+	if !strings.HasPrefix(content.Content, "## Data (A1:A1)") || content.Metadata.TotalLines != 10 {
+		t.Fatalf("unexpected content: %s", out)
+	}
+
+	readOffset = 0
+	readPages = "1-2"
+	if err := runRead(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "do not apply to workbooks") {
+		t.Fatalf("expected --pages error, got %v", err)
+	}
+}
+
+func TestChunkReadWindows(t *testing.T) {
+	windows := []*readWindow{
+		{Pages: "1", Lines: []readSearchLine{{Line: 1, Text: "aaaa"}, {Line: 2, Text: "bbbb"}}},
+		{Pages: "2", Lines: []readSearchLine{{Line: 1, Text: "cccc"}, {Line: 2, Text: "dddd eeee ffff"}}},
+	}
+	chunks := chunkReadWindows(windows, 9, 5)
+
+	var texts []string
+	for _, c := range chunks {
+		texts = append(texts, c.Text)
+	}
+	// The long line splits into "dddd" and "eeee ffff", which is too long to
+	// share a chunk with the overlap.
+	want := []string{"aaaa\nbbbb", "bbbb\ncccc", "cccc\ndddd", "eeee ffff"}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Fatalf("chunks = %q, want %q", texts, want)
+	}
+	if chunks[1].Pages != "1-2" || chunks[1].StartLine != 2 || chunks[1].EndLine != 1 {
+		t.Fatalf("unexpected second chunk: %+v", chunks[1])
+	}
+	if chunks[3].Pages != "2" || chunks[3].Index != 3 || chunks[3].StartLine != 2 {
+		t.Fatalf("unexpected fourth chunk: %+v", chunks[3])
+	}
+
+	again := chunkReadWindows(windows, 9, 5)
+	if again[2].ID != chunks[2].ID || chunks[2].ID == chunks[1].ID {
+		t.Fatalf("chunk IDs should be stable and distinct: %q %q %q", chunks[1].ID, chunks[2].ID, again[2].ID)
+	}
+
+	// Sizes count characters, and long lines are cut between runes.
+	multibyte := []*readWindow{{Pages: "1", Lines: []readSearchLine{{Line: 1, Text: "größe übergröße"}}}}
+	chunks = chunkReadWindows(multibyte, 7, 0)
+	texts = texts[:0]
+	for _, c := range chunks {
+		if !utf8.ValidString(c.Text) || c.Chars != utf8.RuneCountInString(c.Text) {
+			t.Fatalf("chunk %q: invalid UTF-8 or Chars %d", c.Text, c.Chars)
+		}
+		texts = append(texts, c.Text)
+	}
+	if want := []string{"größe", "übergrö", "ße"}; strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Fatalf("chunks = %q, want %q", texts, want)
+	}
+
+	// The same text on another page gets another ID.
+	repeated := []*readWindow{
+		{Pages: "1", Lines: []readSearchLine{{Line: 1, Text: "same"}}},
+		{Pages: "2", Lines: []readSearchLine{{Line: 1, Text: "same"}}},
+	}
+	chunks = chunkReadWindows(repeated, 4, 0)
+	if len(chunks) != 2 || chunks[0].ID == chunks[1].ID || strings.Contains(chunks[1].ID, "-") {
+		t.Fatalf("expected distinct IDs per page, got %+v", chunks)
+	}
+}
+
+func TestRunRead_MultipleInputsPrintsChunks(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless, origReadJSON := apiKey, apiURL, stateless, readJSON
+	origChunkSize, origChunkOverlap, origChunkUnit := readChunkSize, readChunkOverlap, readChunkUnit
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless, readJSON = origAPIKey, origAPIURL, origStateless, origReadJSON
+		readChunkSize, readChunkOverlap, readChunkUnit = origChunkSize, origChunkOverlap, origChunkUnit
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":"hello","format":"pdf","metadata":{"total_pages":1,"read_pages":1,"total_lines":1,"offset":1,"limit":2000}}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.pdf")
+	second := filepath.Join(dir, "b.pdf")
+	for _, p := range []string{first, second} {
+		if err := os.WriteFile(p, []byte("%PDF-1.7"), 0o644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey, apiURL, stateless, readJSON = "", server.URL, true, false
+	readChunkSize, readChunkOverlap, readChunkUnit = 100, 0, "chars"
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{first, second})
+	})
+	if err != nil {
+		t.Fatalf("runRead failed: %v", err)
+	}
+	for _, p := range []string{first, second} {
+		if !strings.Contains(out, "==> "+p+" <==\n==> chunk 1 ") {
+			t.Fatalf("expected chunks for %s in output:\n%s", p, out)
+		}
+	}
+}
+
+func TestRunRead_ChunksWholeDocumentByPage(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origReadJSON := readJSON
+	origChunkSize := readChunkSize
+	origChunkOverlap := readChunkOverlap
+	origChunkUnit := readChunkUnit
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		readJSON = origReadJSON
+		readChunkSize = origChunkSize
+		readChunkOverlap = origChunkOverlap
+		readChunkUnit = origChunkUnit
+	})
+
+	var requestedPages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages := r.URL.Query().Get("pages")
+		requestedPages = append(requestedPages, pages)
+		w.Header().Set("Content-Type", "application/json")
+		switch pages {
+		case "":
+			fmt.Fprint(w, `{"content":"one\ntwo","format":"pdf","metadata":{"total_pages":2,"read_pages":2,"total_lines":2,"offset":1,"limit":2000}}`)
+		case "1":
+			fmt.Fprint(w, `{"content":"one","format":"pdf","metadata":{"total_pages":2,"read_pages":1,"total_lines":1,"offset":1,"limit":2000}}`)
+		case "2":
+			fmt.Fprint(w, `{"content":"two","format":"pdf","metadata":{"total_pages":2,"read_pages":1,"total_lines":1,"offset":1,"limit":2000}}`)
+		default:
+			t.Errorf("unexpected pages=%q", pages)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(filePath, []byte("%PDF-1.7"), 0o644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	readJSON = true
+	readChunkSize = 1
+	readChunkOverlap = 0
+	readChunkUnit = "tokens"
+
+	out, err := captureExecStdout(t, func() error {
+		return runRead(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRead failed: %v", err)
+	}
+	var result readChunkResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("parsing output: %v\n%s", err, out)
+	}
+	if result.Total != 2 || result.Chunks[0].Pages != "1" || result.Chunks[1].Pages != "2" || result.Chunks[1].Text != "two" {
+		t.Fatalf("unexpected chunks: %s", out)
+	}
+	if strings.Join(requestedPages, ",") != ",1,2" {
+		t.Fatalf("requested pages %q, want the first read then one page at a time", requestedPages)
+	}
+
+	readChunkOverlap = 1
+	if err := runRead(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "--chunk-overlap") {
+		t.Fatalf("expected overlap validation error, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// maxReadURLCacheEntries bounds the read URL cache; the least recently read
+// URLs are dropped first.
+const maxReadURLCacheEntries = 64
+
+// readURLCacheEntry records the validators of a URL read downloaded, kept
+// next to the body so the next read of the URL can revalidate with a
+// conditional request instead of downloading it again.
+type readURLCacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int64     `json:"size"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// readURLCachePaths returns the metadata and body paths for rawURL's entry.
+func readURLCachePaths(rawURL string) (meta, body string, err error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	base := filepath.Join(dir, "witan", "read-urls", hex.EncodeToString(sum[:16]))
+	return base + ".json", base + ".body", nil
+}
+
+// loadReadURLCache returns the cached entry for rawURL and the path of its
+// body, or nil when there is none or the body does not match the entry.
+func loadReadURLCache(rawURL string) (*readURLCacheEntry, string) {
+	metaPath, bodyPath, err := readURLCachePaths(rawURL)
+	if err != nil {
+		return nil, ""
+	}
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, ""
+	}
+	var entry readURLCacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.URL != rawURL {
+		return nil, ""
+	}
+	if info, err := os.Stat(bodyPath); err != nil || info.Size() != entry.Size {
+		return nil, ""
+	}
+	// Mark the entry recently used so pruning keeps it.
+	now := time.Now()
+	_ = os.Chtimes(metaPath, now, now)
+	return &entry, bodyPath
+}
+
+// storeReadURLCache keeps the downloaded file at src for rawURL
+// best-effort; a failed write only costs a download on the next read.
+// Responses without an ETag or Last-Modified cannot be revalidated and are
+// not kept.
+func storeReadURLCache(rawURL string, info *client.DownloadInfo, src string) {
+	if info.ETag == "" && info.LastModified == "" {
+		return
+	}
+	metaPath, bodyPath, err := readURLCachePaths(rawURL)
+	if err != nil || os.MkdirAll(filepath.Dir(metaPath), 0o700) != nil {
+		return
+	}
+	if copyFileAtomic(src, bodyPath) != nil {
+		return
+	}
+	data, err := json.Marshal(readURLCacheEntry{
+		URL:          rawURL,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+		ContentType:  info.ContentType,
+		Size:         info.Size,
+		FetchedAt:    time.Now().UTC(),
+	})
+	if err != nil || writeFileAtomic(metaPath, data) != nil {
+		os.Remove(bodyPath)
+		return
+	}
+	pruneReadURLCache(filepath.Dir(metaPath))
+}
+
+// pruneReadURLCache drops the least recently read entries beyond
+// maxReadURLCacheEntries.
+func pruneReadURLCache(dir string) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(matches) <= maxReadURLCacheEntries {
+		return
+	}
+	modTimes := make(map[string]time.Time, len(matches))
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil {
+			modTimes[m] = info.ModTime()
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return modTimes[matches[i]].After(modTimes[matches[j]]) })
+	for _, m := range matches[maxReadURLCacheEntries:] {
+		os.Remove(m)
+		os.Remove(strings.TrimSuffix(m, ".json") + ".body")
+	}
+}
+
+// copyFileAtomic copies src to dst through a temporary file in dst's
+// directory, so readers never see a partial copy.
+func copyFileAtomic(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = io.Copy(tmp, in); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
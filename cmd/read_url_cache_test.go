@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestDownloadReadURL_RevalidatesCachedCopy(t *testing.T) {
+	origNoCache := readNoCache
+	t.Cleanup(func() { readNoCache = origNoCache })
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	readNoCache = false
+
+	var full, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "%PDF-1.7 report")
+	}))
+	defer server.Close()
+	c := client.New(server.URL, "", "", true)
+	rawURL := server.URL + "/report.pdf"
+
+	download := func() (*client.DownloadInfo, string) {
+		t.Helper()
+		f, err := os.Create(filepath.Join(t.TempDir(), "download"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		info, err := downloadReadURL(c, rawURL, f)
+		if err != nil {
+			t.Fatalf("downloadReadURL: %v", err)
+		}
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info, string(data)
+	}
+
+	download()
+	info, body := download()
+	if full != 1 || notModified != 1 {
+		t.Fatalf("full=%d notModified=%d, want one download then one revalidation", full, notModified)
+	}
+	if body != "%PDF-1.7 report" || info.ContentType != "application/pdf" {
+		t.Fatalf("cached read: info=%+v body=%q", info, body)
+	}
+
+	readNoCache = true
+	download()
+	if full != 2 {
+		t.Fatalf("full=%d, want --no-cache to download again", full)
+	}
+}
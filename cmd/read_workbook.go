@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// defaultReadLimit is the line limit the read endpoint applies when --limit
+// is not given; workbook reads match it.
+const defaultReadLimit = 2000
+
+// isReadWorkbook reports whether filePath is a spreadsheet, which read renders
+// itself from the workbook's cells rather than sending to the read endpoint.
+func isReadWorkbook(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".xlsx", ".xlsm", ".xls", ".xlsb":
+		return true
+	}
+	return false
+}
+
+// readWorkbookText reads a workbook's sheets, rejecting read options that do
+// not apply to spreadsheets.
+func readWorkbookText(c *client.Client, filePath string, params url.Values) ([]client.WorkbookSheetText, error) {
+	if params.Get("pages") != "" || params.Get("slides") != "" {
+		return nil, fmt.Errorf("--pages and --slides do not apply to workbooks; use --outline and --offset/--limit")
+	}
+	if params.Get("ocr") != "" {
+		return nil, fmt.Errorf("--ocr does not apply to workbooks")
+	}
+	filePath, err := fixExcelExtension(filePath)
+	if err != nil {
+		return nil, err
+	}
+	filePath, cleanup, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return c.ReadWorkbookText(filePath)
+}
+
+// workbookLines renders sheets as Markdown: a "## <sheet>" heading per sheet
+// followed by its used range as a table whose header is the first row. It
+// returns the lines and the 1-indexed line of each sheet heading.
+func workbookLines(sheets []client.WorkbookSheetText) ([]string, []int) {
+	var lines []string
+	starts := make([]int, len(sheets))
+	for i, s := range sheets {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		starts[i] = len(lines) + 1
+		heading := "## " + s.Sheet
+		if s.Address != "" {
+			heading += " (" + s.Address + ")"
+		}
+		if s.Hidden {
+			heading += " [hidden]"
+		}
+		lines = append(lines, heading, "")
+		if len(s.Rows) == 0 {
+			lines = append(lines, "(empty)")
+			continue
+		}
+		width := 0
+		for _, row := range s.Rows {
+			width = max(width, len(row))
+		}
+		for r, row := range s.Rows {
+			lines = append(lines, markdownTableRow(row, width))
+			if r == 0 {
+				lines = append(lines, "|"+strings.Repeat(" --- |", width))
+			}
+		}
+	}
+	return lines, starts
+}
+
+func markdownTableRow(cells []string, width int) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for i := 0; i < width; i++ {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		cell = strings.ReplaceAll(cell, "|", `\|`)
+		cell = strings.ReplaceAll(cell, "\n", "<br>")
+		b.WriteString(" " + cell + " |")
+	}
+	return b.String()
+}
+
+// readWorkbookContent reads a workbook as a Markdown document, honoring
+// --offset/--limit and --tables like the read endpoint.
+func readWorkbookContent(c *client.Client, filePath string, params url.Values) (*client.ReadResponse, error) {
+	sheets, err := readWorkbookText(c, filePath, params)
+	if err != nil {
+		return nil, err
+	}
+	lines, starts := workbookLines(sheets)
+
+	offset, limit := 1, defaultReadLimit
+	if n, err := strconv.Atoi(params.Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+	if n, err := strconv.Atoi(params.Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	start := min(offset-1, len(lines))
+	end := min(start+limit, len(lines))
+
+	result := &client.ReadResponse{
+		Content:  strings.Join(lines[start:end], "\n"),
+		Format:   "xlsx",
+		Metadata: client.ReadMetadata{TotalLines: len(lines), Offset: offset, Limit: limit},
+	}
+	if params.Get("tables") == "true" {
+		for i, s := range sheets {
+			if len(s.Rows) == 0 {
+				continue
+			}
+			line := starts[i]
+			result.Tables = append(result.Tables, client.ReadTable{Sheet: s.Sheet, Line: &line, Rows: s.Rows})
+		}
+	}
+	return result, nil
+}
+
+// readWorkbookOutline lists a workbook's sheets with the line each starts on.
+func readWorkbookOutline(c *client.Client, filePath string, params url.Values) (*client.ReadOutlineResponse, error) {
+	sheets, err := readWorkbookText(c, filePath, params)
+	if err != nil {
+		return nil, err
+	}
+	lines, starts := workbookLines(sheets)
+	result := &client.ReadOutlineResponse{Outline: []client.OutlineEntry{}}
+	for i, s := range sheets {
+		line := starts[i]
+		result.Outline = append(result.Outline, client.OutlineEntry{Title: s.Sheet, Level: 1, Offset: &line})
+	}
+	total := len(lines)
+	result.Metadata.TotalLines = &total
+	return result, nil
+}
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// Google Sheets, SharePoint/OneDrive, and object storage URLs are accepted
+// in place of the workbook argument of xlsx commands: the workbook is
+// downloaded to a temp file before the command runs and, when the command
+// wrote it back, uploaded to the provider afterwards.
+
+var providerToken string
+
+// Provider endpoints; tests point them at a local server.
+var (
+	googleDocsURL     = "https://docs.google.com"
+	googleAPIURL      = "https://www.googleapis.com"
+	microsoftGraphURL = "https://graph.microsoft.com/v1.0"
+)
+
+const (
+	providerGoogle    = "google"
+	providerMicrosoft = "microsoft"
+
+	xlsxMIMEType          = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	remoteWorkbookTimeout = 60 * time.Second
+)
+
+// remoteWorkbook is a provider-hosted workbook and its local temp copy.
+type remoteWorkbook struct {
+	URL      string
+	Provider string
+	FileID   string // Google Drive file ID, Microsoft Graph item ID, or object key
+	DriveID  string // Microsoft Graph drive ID
+	Path     string
+
+	downloaded [sha256.Size]byte
+	cleanup    func()
+}
+
+// remoteFiles are the remote workbook and -o outputs the running command
+// uses; closeRemoteFiles removes their temp copies.
+var remoteFiles []*remoteWorkbook
+
+// parseRemoteWorkbookURL recognizes Google Sheets (including gs://ID),
+// SharePoint/OneDrive URLs, and s3:// and gs:// object paths. Other
+// arguments, including other URLs, are left to the command.
+func parseRemoteWorkbookURL(raw string) (*remoteWorkbook, bool) {
+	if provider, key, ok := parseObjectURL(raw); ok {
+		return &remoteWorkbook{URL: raw, Provider: provider, FileID: key}, true
+	}
+	if client.IsGoogleSheetsURL(raw) {
+		id := client.ExtractSpreadsheetID(raw)
+		if id == "" || strings.ContainsAny(id, "/?#") {
+			return nil, false
+		}
+		return &remoteWorkbook{URL: raw, Provider: providerGoogle, FileID: id}, true
+	}
+	if !strings.HasPrefix(raw, "https://") {
+		return nil, false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, false
+	}
+	host := strings.ToLower(u.Hostname())
+	if strings.HasSuffix(host, ".sharepoint.com") || host == "onedrive.live.com" || host == "1drv.ms" {
+		return &remoteWorkbook{URL: raw, Provider: providerMicrosoft}, true
+	}
+	return nil, false
+}
+
+// resolveProviderToken returns the OAuth access token for the provider from
+// --provider-token or WITAN_PROVIDER_TOKEN.
+func resolveProviderToken() string {
+	if providerToken != "" {
+		return providerToken
+	}
+	return strings.TrimSpace(os.Getenv("WITAN_PROVIDER_TOKEN"))
+}
+
+// openRemoteWorkbookArg replaces a remote workbook URL in args[0] with the
+// path of a downloaded copy. Cobra passes the same args slice to the
+// command's RunE.
+func openRemoteWorkbookArg(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	rw, ok := parseRemoteWorkbookURL(args[0])
+	if !ok {
+		return nil
+	}
+	if resolveOffline() {
+		fmt.Fprintf(os.Stderr, "downloading %s needs the network; --offline is set\n", rw.URL)
+		return &ExitError{Code: ExitOffline}
+	}
+	if err := rw.download(); err != nil {
+		return err
+	}
+	remoteFiles = append(remoteFiles, rw)
+	args[0] = rw.Path
+	return nil
+}
+
+// openRemoteOutputFlag points an -o flag holding an s3:// or gs:// object
+// path at a local temp file, which syncRemoteFiles uploads once the command
+// has written it.
+func openRemoteOutputFlag(cmd *cobra.Command) error {
+	f := cmd.Flags().ShorthandLookup("o")
+	if f == nil {
+		return nil
+	}
+	provider, key, ok := parseObjectURL(f.Value.String())
+	if !ok {
+		return nil
+	}
+	rw := &remoteWorkbook{URL: f.Value.String(), Provider: provider, FileID: key}
+	if resolveOffline() {
+		fmt.Fprintf(os.Stderr, "writing %s needs the network; --offline is set\n", rw.URL)
+		return &ExitError{Code: ExitOffline}
+	}
+	dir, err := os.MkdirTemp("", "witan-remote-*")
+	if err != nil {
+		return fmt.Errorf("buffering remote output: %w", err)
+	}
+	rw.cleanup = func() { os.RemoveAll(dir) }
+	rw.Path = filepath.Join(dir, path.Base(key))
+	remoteFiles = append(remoteFiles, rw)
+	return cmd.Flags().Set(f.Name, rw.Path)
+}
+
+// syncRemoteFiles uploads each temp copy the command created or changed
+// back to where it came from.
+func syncRemoteFiles() error {
+	for _, rw := range remoteFiles {
+		data, err := os.ReadFile(rw.Path)
+		if err != nil || sha256.Sum256(data) == rw.downloaded {
+			continue
+		}
+		if err := rw.upload(data); err != nil {
+			return fmt.Errorf("uploading %s: %w (the updated workbook was not saved)", rw.URL, err)
+		}
+		fmt.Fprintf(os.Stderr, "Uploaded %s to %s\n", filepath.Base(rw.Path), rw.URL)
+	}
+	return nil
+}
+
+// finishRemoteFiles runs after the command: it uploads remote files when the
+// command completed, including with findings or a failed assertion whose
+// report still belongs at the -o destination, and removes the temp copies.
+func finishRemoteFiles(err error) error {
+	defer closeRemoteFiles()
+	var exitErr *ExitError
+	if err != nil && !(errors.As(err, &exitErr) && (exitErr.Code == ExitFindings || exitErr.Code == ExitAssertion)) {
+		return err
+	}
+	if syncErr := syncRemoteFiles(); syncErr != nil {
+		return syncErr
+	}
+	return err
+}
+
+func closeRemoteFiles() {
+	for _, rw := range remoteFiles {
+		if rw.cleanup != nil {
+			rw.cleanup()
+		}
+	}
+	remoteFiles = nil
+}
+
+func (rw *remoteWorkbook) download() error {
+	if rw.Provider == providerS3 || rw.Provider == providerGCS {
+		return rw.downloadObject()
+	}
+	token := resolveProviderToken()
+	var contentURL string
+	switch rw.Provider {
+	case providerGoogle:
+		if token != "" {
+			contentURL = googleAPIURL + "/drive/v3/files/" + url.PathEscape(rw.FileID) + "/export?mimeType=" + url.QueryEscape(xlsxMIMEType)
+		} else {
+			// Works for sheets shared with anyone who has the link.
+			contentURL = googleDocsURL + "/spreadsheets/d/" + url.PathEscape(rw.FileID) + "/export?format=xlsx"
+		}
+	case providerMicrosoft:
+		if token == "" {
+			return fmt.Errorf("SharePoint and OneDrive URLs need a Microsoft Graph access token: use --provider-token or WITAN_PROVIDER_TOKEN")
+		}
+		var item struct {
+			ID              string `json:"id"`
+			ParentReference struct {
+				DriveID string `json:"driveId"`
+			} `json:"parentReference"`
+		}
+		shareID := "u!" + base64.RawURLEncoding.EncodeToString([]byte(rw.URL))
+		body, _, err := rw.do("GET", microsoftGraphURL+"/shares/"+shareID+"/driveItem?$select=id,parentReference", nil, "")
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, &item); err != nil || item.ID == "" || item.ParentReference.DriveID == "" {
+			return fmt.Errorf("resolving %s: unexpected Microsoft Graph response", rw.URL)
+		}
+		rw.FileID, rw.DriveID = item.ID, item.ParentReference.DriveID
+		contentURL = rw.itemContentURL()
+	}
+
+	data, header, err := rw.do("GET", contentURL, nil, "")
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "witan-remote-*")
+	if err != nil {
+		return fmt.Errorf("buffering remote workbook: %w", err)
+	}
+	rw.cleanup = func() { os.RemoveAll(dir) }
+	rw.Path = filepath.Join(dir, remoteWorkbookName(header.Get("Content-Disposition"), rw.FileID))
+	if err := os.WriteFile(rw.Path, data, 0o600); err != nil {
+		rw.cleanup()
+		return fmt.Errorf("buffering remote workbook: %w", err)
+	}
+	rw.downloaded = sha256.Sum256(data)
+	return nil
+}
+
+func (rw *remoteWorkbook) upload(data []byte) error {
+	if rw.Provider == providerS3 || rw.Provider == providerGCS {
+		return objectCopy(rw.Path, rw.URL)
+	}
+	if resolveProviderToken() == "" {
+		return fmt.Errorf("saving to the provider needs an access token: use --provider-token or WITAN_PROVIDER_TOKEN")
+	}
+	var err error
+	switch rw.Provider {
+	case providerGoogle:
+		_, _, err = rw.do("PATCH", googleAPIURL+"/upload/drive/v3/files/"+url.PathEscape(rw.FileID)+"?uploadType=media", data, xlsxMIMEType)
+	case providerMicrosoft:
+		_, _, err = rw.do("PUT", rw.itemContentURL(), data, "application/octet-stream")
+	}
+	return err
+}
+
+func (rw *remoteWorkbook) itemContentURL() string {
+	return microsoftGraphURL + "/drives/" + url.PathEscape(rw.DriveID) + "/items/" + url.PathEscape(rw.FileID) + "/content"
+}
+
+// do sends one provider request with the access token, if any, and returns
+// the response body of a 2xx response.
+func (rw *remoteWorkbook) do(method, rawURL string, body []byte, contentType string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	setCLIUserAgent(req)
+	if token := resolveProviderToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := newHTTPClient(remoteWorkbookTimeout).Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching %s: %w", rw.URL, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching %s: %w", rw.URL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		hint := ""
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+			hint = " (check that the file exists and --provider-token can access it)"
+		}
+		return nil, nil, fmt.Errorf("fetching %s: HTTP %d%s", rw.URL, resp.StatusCode, hint)
+	}
+	return data, resp.Header, nil
+}
+
+// remoteWorkbookName is the temp copy's file name: the provider's file name
+// when it sends one, otherwise the file ID, with a workbook extension.
+func remoteWorkbookName(contentDisposition, fallback string) string {
+	name := fallback
+	if _, params, err := mime.ParseMediaType(contentDisposition); err == nil && params["filename"] != "" {
+		name = filepath.Base(filepath.Clean("/" + params["filename"]))
+	}
+	if name == "" || name == "/" || name == "." {
+		name = "workbook"
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xlsx", ".xlsm", ".xls", ".xlsb":
+		return name
+	}
+	return name + ".xlsx"
+}
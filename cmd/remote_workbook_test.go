@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setRemoteWorkbookTestServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	prevDocs, prevAPI, prevGraph, prevToken := googleDocsURL, googleAPIURL, microsoftGraphURL, providerToken
+	t.Cleanup(func() {
+		googleDocsURL, googleAPIURL, microsoftGraphURL, providerToken = prevDocs, prevAPI, prevGraph, prevToken
+		closeRemoteFiles()
+	})
+	googleDocsURL, googleAPIURL, microsoftGraphURL = server.URL, server.URL, server.URL+"/v1.0"
+	providerToken = ""
+	t.Setenv("WITAN_PROVIDER_TOKEN", "")
+	t.Setenv("WITAN_OFFLINE", "")
+}
+
+func TestParseRemoteWorkbookURL(t *testing.T) {
+	tests := []struct {
+		arg      string
+		provider string
+		id       string
+	}{
+		{"https://docs.google.com/spreadsheets/d/abc_123-X/edit#gid=0", providerGoogle, "abc_123-X"},
+		{"gs://abc123", providerGoogle, "abc123"},
+		{"https://contoso.sharepoint.com/:x:/r/sites/fin/Shared%20Documents/Plan.xlsx", providerMicrosoft, ""},
+		{"https://onedrive.live.com/edit?id=ABC", providerMicrosoft, ""},
+		{"https://1drv.ms/x/s!abc", providerMicrosoft, ""},
+		{"https://example.com/report.xlsx", "", ""},
+		{"report.xlsx", "", ""},
+	}
+	for _, tt := range tests {
+		rw, ok := parseRemoteWorkbookURL(tt.arg)
+		if tt.provider == "" {
+			if ok {
+				t.Errorf("%s: parsed as %s, want not remote", tt.arg, rw.Provider)
+			}
+			continue
+		}
+		if !ok || rw.Provider != tt.provider || rw.FileID != tt.id {
+			t.Errorf("%s: got %+v, %v; want %s %q", tt.arg, rw, ok, tt.provider, tt.id)
+		}
+	}
+}
+
+func TestOpenRemoteWorkbookArg_GooglePublicExport(t *testing.T) {
+	setRemoteWorkbookTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/spreadsheets/d/sheet1/export" || r.URL.Query().Get("format") != "xlsx" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("public export sent Authorization header")
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="Budget 2026.xlsx"`)
+		_, _ = w.Write([]byte("xlsx-bytes"))
+	})
+
+	args := []string{"https://docs.google.com/spreadsheets/d/sheet1/edit"}
+	if err := openRemoteWorkbookArg(args); err != nil {
+		t.Fatalf("openRemoteWorkbookArg: %v", err)
+	}
+	if filepath.Base(args[0]) != "Budget 2026.xlsx" {
+		t.Fatalf("args[0] = %q, want temp copy named after the sheet", args[0])
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil || string(data) != "xlsx-bytes" {
+		t.Fatalf("temp copy = %q, %v", data, err)
+	}
+
+	// Unchanged copies are not uploaded; the handler rejects anything else.
+	if err := syncRemoteFiles(); err != nil {
+		t.Fatalf("syncRemoteFiles: %v", err)
+	}
+	closeRemoteFiles()
+	if _, err := os.Stat(args[0]); !os.IsNotExist(err) {
+		t.Fatalf("temp copy not removed: %v", err)
+	}
+}
+
+func TestRemoteWorkbook_GoogleUploadsChanges(t *testing.T) {
+	var uploaded string
+	setRemoteWorkbookTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/drive/v3/files/sheet1/export":
+			if r.URL.Query().Get("mimeType") != xlsxMIMEType {
+				t.Errorf("mimeType = %q", r.URL.Query().Get("mimeType"))
+			}
+			_, _ = w.Write([]byte("before"))
+		case r.Method == "PATCH" && r.URL.Path == "/upload/drive/v3/files/sheet1":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = string(body)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	providerToken = "tok"
+
+	args := []string{"gs://sheet1"}
+	if err := openRemoteWorkbookArg(args); err != nil {
+		t.Fatalf("openRemoteWorkbookArg: %v", err)
+	}
+	if filepath.Base(args[0]) != "sheet1.xlsx" {
+		t.Fatalf("args[0] = %q", args[0])
+	}
+	if err := os.WriteFile(args[0], []byte("after"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncRemoteFiles(); err != nil {
+		t.Fatalf("syncRemoteFiles: %v", err)
+	}
+	if uploaded != "after" {
+		t.Fatalf("uploaded %q, want the changed workbook", uploaded)
+	}
+}
+
+func TestRemoteWorkbook_SharePointResolvesDriveItem(t *testing.T) {
+	shareURL := "https://contoso.sharepoint.com/:x:/r/sites/fin/Plan.xlsx"
+	shareID := "u!" + base64.RawURLEncoding.EncodeToString([]byte(shareURL))
+	var uploaded string
+	setRemoteWorkbookTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1.0/shares/"+shareID+"/driveItem":
+			_, _ = w.Write([]byte(`{"id":"item1","parentReference":{"driveId":"drive1"}}`))
+		case r.Method == "GET" && r.URL.Path == "/v1.0/drives/drive1/items/item1/content":
+			w.Header().Set("Content-Disposition", `attachment; filename="Plan.xlsm"`)
+			_, _ = w.Write([]byte("before"))
+		case r.Method == "PUT" && r.URL.Path == "/v1.0/drives/drive1/items/item1/content":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = string(body)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	args := []string{shareURL}
+	if err := openRemoteWorkbookArg(args); err == nil || !strings.Contains(err.Error(), "--provider-token") {
+		t.Fatalf("expected token error, got %v", err)
+	}
+
+	t.Setenv("WITAN_PROVIDER_TOKEN", "tok")
+	if err := openRemoteWorkbookArg(args); err != nil {
+		t.Fatalf("openRemoteWorkbookArg: %v", err)
+	}
+	if filepath.Base(args[0]) != "Plan.xlsm" {
+		t.Fatalf("args[0] = %q", args[0])
+	}
+	if err := os.WriteFile(args[0], []byte("after"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncRemoteFiles(); err != nil {
+		t.Fatalf("syncRemoteFiles: %v", err)
+	}
+	if uploaded != "after" {
+		t.Fatalf("uploaded %q, want the changed workbook", uploaded)
+	}
+}
+
+func TestRemoteWorkbookName(t *testing.T) {
+	tests := []struct{ disposition, fallback, want string }{
+		{`attachment; filename="Q1.xlsx"`, "id", "Q1.xlsx"},
+		{`attachment; filename="../../etc/passwd"`, "id", "passwd.xlsx"},
+		{"", "abc", "abc.xlsx"},
+		{`attachment; filename="Macros.XLSM"`, "id", "Macros.XLSM"},
+	}
+	for _, tt := range tests {
+		if got := remoteWorkbookName(tt.disposition, tt.fallback); got != tt.want {
+			t.Errorf("remoteWorkbookName(%q, %q) = %q, want %q", tt.disposition, tt.fallback, got, tt.want)
+		}
+	}
+}
@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
 	"image/png"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
 	"github.com/witanlabs/witan-cli/internal"
 )
 
@@ -39,54 +47,291 @@ func estimatePixels(address string, dpr int) (int, int) {
 	return cols * 64 * dpr, rows * 15 * dpr
 }
 
+// renderViewOptions holds the view flags shared by range render commands.
+// Unset flags are not sent, so the API's defaults apply.
+type renderViewOptions struct {
+	gridlines bool
+	headers   bool
+	zoom      float64
+	theme     string
+}
+
+func addRenderViewFlags(cmd *cobra.Command, o *renderViewOptions) {
+	cmd.Flags().BoolVar(&o.gridlines, "show-gridlines", false, "Draw cell gridlines (default: API default)")
+	cmd.Flags().BoolVar(&o.headers, "show-headers", false, "Draw row numbers and column letters (default: API default)")
+	cmd.Flags().Float64Var(&o.zoom, "zoom", 1, "Zoom factor, 0.25-4 (e.g. 1.5)")
+	cmd.Flags().StringVar(&o.theme, "theme", "", "Color theme: light or dark (default: light)")
+}
+
+// applyRenderViewFlags validates the view flags the user set and adds them
+// to the render query params.
+func applyRenderViewFlags(cmd *cobra.Command, o *renderViewOptions, params map[string]string) error {
+	flags := cmd.Flags()
+	if flags.Changed("show-gridlines") {
+		params["gridlines"] = strconv.FormatBool(o.gridlines)
+	}
+	if flags.Changed("show-headers") {
+		params["headers"] = strconv.FormatBool(o.headers)
+	}
+	if flags.Changed("zoom") {
+		if o.zoom < 0.25 || o.zoom > 4 {
+			return fmt.Errorf("--zoom must be between 0.25 and 4, got %g", o.zoom)
+		}
+		params["zoom"] = strconv.FormatFloat(o.zoom, 'f', -1, 64)
+	}
+	if flags.Changed("theme") {
+		if o.theme != "light" && o.theme != "dark" {
+			return fmt.Errorf("--theme must be 'light' or 'dark', got %q", o.theme)
+		}
+		params["theme"] = o.theme
+	}
+	return nil
+}
+
+// scalePixels adjusts an estimatePixels result for --zoom.
+func (o *renderViewOptions) scalePixels(w, h int) (int, int) {
+	if o.zoom <= 0 || o.zoom == 1 {
+		return w, h
+	}
+	return int(float64(w) * o.zoom), int(float64(h) * o.zoom)
+}
+
 // runRenderDiffPipeline compares a baseline PNG image with a new rendered image.
 // It returns the diff image bytes and a formatted summary string.
 // The format parameter must be "png" or this will return an error.
 // The baselinePath is the path to the baseline PNG file.
 // The renderedBytes are the new rendered image bytes.
-func runRenderDiffPipeline(format string, baselinePath string, renderedBytes []byte) (diffBytes []byte, summary string, err error) {
+// When withReport is set, it also returns the changed-pixel regions for
+// --diff-report; otherwise the report is nil.
+func runRenderDiffPipeline(format string, baselinePath string, renderedBytes []byte, withReport bool) (diffBytes []byte, summary string, report *diffReport, err error) {
 	if format != "png" {
-		return nil, "", fmt.Errorf("--diff requires --format png (got %q)", format)
+		return nil, "", nil, fmt.Errorf("--diff requires --format png (got %q)", format)
 	}
 
 	beforeBytes, err := os.ReadFile(baselinePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("reading baseline image: %w", err)
+		return nil, "", nil, fmt.Errorf("reading baseline image: %w", err)
 	}
 	beforeImg, err := png.Decode(bytes.NewReader(beforeBytes))
 	if err != nil {
-		return nil, "", fmt.Errorf("decoding baseline image: %w", err)
+		return nil, "", nil, fmt.Errorf("decoding baseline image: %w", err)
 	}
 	afterImg, err := png.Decode(bytes.NewReader(renderedBytes))
 	if err != nil {
-		return nil, "", fmt.Errorf("decoding rendered image: %w", err)
+		return nil, "", nil, fmt.Errorf("decoding rendered image: %w", err)
 	}
 
 	diffImg, changed, err := internal.DiffImages(beforeImg, afterImg)
 	if err != nil {
-		return nil, "", fmt.Errorf("diffing images: %w", err)
+		return nil, "", nil, fmt.Errorf("diffing images: %w", err)
 	}
 
 	total := diffImg.Bounds().Dx() * diffImg.Bounds().Dy()
 	summary = internal.FormatDiffSummary(changed, total)
 
+	if withReport {
+		regions, err := internal.DiffRegions(beforeImg, afterImg, diffRegionGap)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("diffing images: %w", err)
+		}
+		report = &diffReport{
+			Baseline:      baselinePath,
+			Width:         diffImg.Bounds().Dx(),
+			Height:        diffImg.Bounds().Dy(),
+			ChangedPixels: changed,
+			TotalPixels:   total,
+			Cells:         []string{},
+			Regions:       make([]diffReportRegion, 0, len(regions)),
+		}
+		for _, r := range regions {
+			report.Regions = append(report.Regions, diffReportRegion{
+				X:      r.Bounds.Min.X,
+				Y:      r.Bounds.Min.Y,
+				Width:  r.Bounds.Dx(),
+				Height: r.Bounds.Dy(),
+				Pixels: r.Pixels,
+			})
+		}
+	}
+
 	var buf bytes.Buffer
 	if err := png.Encode(&buf, diffImg); err != nil {
-		return nil, "", fmt.Errorf("encoding diff image: %w", err)
+		return nil, "", nil, fmt.Errorf("encoding diff image: %w", err)
+	}
+	return buf.Bytes(), summary, report, nil
+}
+
+// diffRegionGap is how far apart (in pixels) two changed pixels can be and
+// still count as one region. It is wide enough to join the glyphs of a
+// single value without merging neighbouring cells.
+const diffRegionGap = 3
+
+// diffReport is the JSON document written by --diff-report.
+type diffReport struct {
+	Baseline      string             `json:"baseline"`
+	Range         string             `json:"range,omitempty"`
+	DPR           int                `json:"dpr"`
+	Width         int                `json:"width"`
+	Height        int                `json:"height"`
+	ChangedPixels int                `json:"changed_pixels"`
+	TotalPixels   int                `json:"total_pixels"`
+	Cells         []string           `json:"cells"`
+	Regions       []diffReportRegion `json:"regions"`
+}
+
+// diffReportRegion is one cluster of changed pixels and the cells it
+// covers. Range is an estimate; see mapDiffRegionsToCells.
+type diffReportRegion struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Pixels int    `json:"pixels"`
+	Range  string `json:"range,omitempty"`
+}
+
+// mapDiffRegionsToCells estimates which cells each region covers. It uses
+// the uniform grid from estimatePixels (64×15 px per cell at dpr 1),
+// stretched to the actual image size so zoom and non-default column widths
+// are spread evenly. Addresses that are not plain A1 ranges leave the cell
+// fields empty.
+func mapDiffRegionsToCells(report *diffReport, address string) {
+	sheet, sr, sc, er, ec, err := internal.ParseRange(address)
+	if err != nil {
+		return
+	}
+	report.Range = internal.FormatAddress(sheet, sr, sc, er, ec)
+	estW, estH := estimatePixels(address, report.DPR)
+	if estW == 0 || estH == 0 || report.Width == 0 || report.Height == 0 {
+		return
+	}
+	cellW := float64(64*report.DPR) * float64(report.Width) / float64(estW)
+	cellH := float64(15*report.DPR) * float64(report.Height) / float64(estH)
+	col := func(x int) int { return min(ec, sc+int(float64(x)/cellW)) }
+	row := func(y int) int { return min(er, sr+int(float64(y)/cellH)) }
+
+	seen := map[[2]int]bool{}
+	var cells [][2]int
+	for i := range report.Regions {
+		r := &report.Regions[i]
+		r1, c1 := row(r.Y), col(r.X)
+		r2, c2 := row(r.Y+r.Height-1), col(r.X+r.Width-1)
+		r.Range = internal.FormatAddress(sheet, r1, c1, r2, c2)
+		for rr := r1; rr <= r2; rr++ {
+			for cc := c1; cc <= c2; cc++ {
+				if !seen[[2]int{rr, cc}] {
+					seen[[2]int{rr, cc}] = true
+					cells = append(cells, [2]int{rr, cc})
+				}
+			}
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i][0] != cells[j][0] {
+			return cells[i][0] < cells[j][0]
+		}
+		return cells[i][1] < cells[j][1]
+	})
+	report.Cells = make([]string, 0, len(cells))
+	for _, cell := range cells {
+		report.Cells = append(report.Cells, internal.FormatAddress(sheet, cell[0], cell[1], cell[0], cell[1]))
 	}
-	return buf.Bytes(), summary, nil
+}
+
+// writeRenderDiffReport fills in the cell estimates and writes the report
+// as indented JSON.
+func writeRenderDiffReport(path string, report *diffReport, address string, dpr int) error {
+	report.DPR = dpr
+	mapDiffRegionsToCells(report, address)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding diff report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing diff report: %w", err)
+	}
+	return nil
+}
+
+// unsupportedRenderFormatCodes are the API error codes for a render format
+// the server does not produce.
+var unsupportedRenderFormatCodes = map[string]bool{
+	"invalid_format":     true,
+	"unsupported_format": true,
+}
+
+// isUnsupportedRenderFormat reports whether err is the API rejecting the
+// requested output format. Other 400 and 422 errors, such as a bad range,
+// are not.
+func isUnsupportedRenderFormat(err error) bool {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode != http.StatusBadRequest && apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	return unsupportedRenderFormatCodes[strings.ToLower(apiErr.Code)]
+}
+
+// explainUnsupportedRenderFormat turns a rejected render format into a
+// message listing the formats the server does render, when it says.
+func explainUnsupportedRenderFormat(c *client.Client, format string, err error) error {
+	if format == "png" || !isUnsupportedRenderFormat(err) {
+		return err
+	}
+	caps := serverCapabilities(c)
+	if caps == nil || len(caps.RenderFormats) == 0 || caps.SupportsRenderFormat(format) {
+		return err
+	}
+	return fmt.Errorf("this Witan API does not render --format %s (supported: %s)", format, strings.Join(caps.RenderFormats, ", "))
+}
+
+// pngToPDF wraps a rendered PNG in a single-page PDF.
+func pngToPDF(pngBytes []byte, dpr int) ([]byte, error) {
+	img, err := png.Decode(bytes.NewReader(pngBytes))
+	if err != nil {
+		return nil, fmt.Errorf("decoding rendered image: %w", err)
+	}
+	pdf, err := internal.ImagesToPDF([]image.Image{img}, dpr)
+	if err != nil {
+		return nil, fmt.Errorf("building PDF: %w", err)
+	}
+	return pdf, nil
+}
+
+// renderOutputDir returns where renders without -o go: the render_dir
+// setting, created if needed, or this run's temp dir.
+func renderOutputDir() (string, error) {
+	if settingsRenderDir == "" {
+		return runTempDir()
+	}
+	if err := os.MkdirAll(settingsRenderDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating render_dir: %w", err)
+	}
+	return settingsRenderDir, nil
 }
 
 // writeRenderedImage writes image bytes to the specified output path.
-// If outPath is empty, creates a temp file with appropriate extension.
+// If outPath is empty, creates a file with appropriate extension in
+// renderOutputDir.
 // Returns the actual path written to.
 func writeRenderedImage(outPath string, contentType string, imageBytes []byte) (string, error) {
 	if outPath == "" {
 		ext := ".png"
-		if strings.Contains(contentType, "webp") {
+		switch {
+		case strings.Contains(contentType, "webp"):
 			ext = ".webp"
+		case strings.Contains(contentType, "svg"):
+			ext = ".svg"
+		case strings.Contains(contentType, "pdf"):
+			ext = ".pdf"
+		}
+		dir, err := renderOutputDir()
+		if err != nil {
+			return "", err
 		}
-		f, err := os.CreateTemp("", "witan-render-*"+ext)
+		f, err := os.CreateTemp(dir, "witan-render-*"+ext)
 		if err != nil {
 			return "", fmt.Errorf("creating temp file: %w", err)
 		}
@@ -105,9 +350,34 @@ func writeRenderedImage(outPath string, contentType string, imageBytes []byte) (
 	return outPath, nil
 }
 
-// printRenderResult prints render output info and warnings.
+// openRendered launches path in the system's default viewer. It is a
+// variable so tests can stub it.
+var openRendered = openBrowser
+
+// openRenderedOutput opens a rendered file for --open. Failing to launch a
+// viewer (e.g. on a headless machine) is reported but does not fail the command.
+func openRenderedOutput(path string) {
+	if err := openRendered(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not open %s: %v\n", path, err)
+	}
+}
+
+// printRenderResult prints render output info and warnings, or the result
+// event with --ndjson.
 func printRenderResult(outPath, rangeStr string, pixelW, pixelH, dpr int, diffSummary string) {
+	if ndjsonOutput {
+		result := map[string]any{"path": outPath, "range": rangeStr, "dpr": dpr}
+		if pixelW > 0 && pixelH > 0 {
+			result["width_px"], result["height_px"] = pixelW, pixelH
+		}
+		if diffSummary != "" {
+			result["diff"] = diffSummary
+		}
+		emitEvent("result", map[string]any{"result": result})
+		return
+	}
 	if diffSummary != "" {
+		diffSummary = colorDiffSummary(diffSummary)
 		if pixelW > 0 && pixelH > 0 {
 			fmt.Printf("%s\n%s | ~%d×%dpx | dpr=%d | %s\n", outPath, rangeStr, pixelW, pixelH, dpr, diffSummary)
 		} else {
@@ -123,6 +393,6 @@ func printRenderResult(outPath, rangeStr string, pixelW, pixelH, dpr int, diffSu
 
 	// Vision model warning
 	if pixelW > 1568 || pixelH > 1568 {
-		fmt.Printf("Warning: Image exceeds 1568px. Vision models may downscale, reducing detail. Consider a smaller --range.\n")
+		fmt.Printf("%s Image exceeds 1568px. Vision models may downscale, reducing detail. Consider a smaller --range.\n", colorize("Warning:", ansiYellow))
 	}
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// maxRenderPixels is the largest image side vision models take without
+// downscaling; --tile keeps each tile within it.
+const maxRenderPixels = 1568
+
+// renderTile is one sub-range of a tiled render and the image written for it.
+type renderTile struct {
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Range  string `json:"range"`
+	Path   string `json:"path"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// renderTileIndex is the JSON index written next to the tiles.
+type renderTileIndex struct {
+	Range string       `json:"range"`
+	DPR   int          `json:"dpr"`
+	Rows  int          `json:"rows"`
+	Cols  int          `json:"cols"`
+	Tiles []renderTile `json:"tiles"`
+}
+
+// planRenderTiles splits address into a grid of sub-ranges whose estimated
+// size (see estimatePixels) stays within maxRenderPixels at dpr and zoom.
+// Tile paths are left empty.
+func planRenderTiles(address string, dpr int, zoom float64) (*renderTileIndex, error) {
+	sheetPart, _, _ := strings.Cut(address, "!")
+	_, sr, sc, er, ec, err := internal.ParseRange(address)
+	if err != nil {
+		return nil, fmt.Errorf("--tile needs a rectangular range: %w", err)
+	}
+	if zoom <= 0 {
+		zoom = 1
+	}
+	colsPerTile := max(1, int(float64(maxRenderPixels)/(64*float64(dpr)*zoom)))
+	rowsPerTile := max(1, int(float64(maxRenderPixels)/(15*float64(dpr)*zoom)))
+
+	index := &renderTileIndex{
+		Range: internal.FormatAddress(sheetPart, sr, sc, er, ec),
+		DPR:   dpr,
+		Rows:  (er - sr + rowsPerTile) / rowsPerTile,
+		Cols:  (ec - sc + colsPerTile) / colsPerTile,
+	}
+	for r := 0; r < index.Rows; r++ {
+		for c := 0; c < index.Cols; c++ {
+			r1 := sr + r*rowsPerTile
+			c1 := sc + c*colsPerTile
+			r2 := min(er, r1+rowsPerTile-1)
+			c2 := min(ec, c1+colsPerTile-1)
+			index.Tiles = append(index.Tiles, renderTile{
+				Row:   r + 1,
+				Col:   c + 1,
+				Range: internal.FormatAddress(sheetPart, r1, c1, r2, c2),
+			})
+		}
+	}
+	return index, nil
+}
+
+// tilePaths returns the path prefix for tile images and the index. With
+// -o out.png the tiles are out.r1c1.png, out.r1c2.png, ... and the index is
+// out.tiles.json; without -o they go to a new directory under render_dir
+// (default: this run's temp dir).
+func tilePaths(outPath string) (base string, err error) {
+	if outPath == "" {
+		parent, err := renderOutputDir()
+		if err != nil {
+			return "", err
+		}
+		dir, err := os.MkdirTemp(parent, "witan-render-tiles-*")
+		if err != nil {
+			return "", fmt.Errorf("creating temp directory: %w", err)
+		}
+		return filepath.Join(dir, "tile"), nil
+	}
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)), nil
+}
+
+// writeRenderTileIndex writes the tile index as indented JSON.
+func writeRenderTileIndex(path string, index *renderTileIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding tile index: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing tile index: %w", err)
+	}
+	return nil
+}
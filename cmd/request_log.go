@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	verbose     bool
+	logFilePath string
+
+	// requestLogger is attached to every API client; nil disables logging.
+	requestLogger func(client.RequestLogEvent)
+)
+
+func resolveLogFilePath() string {
+	if logFilePath != "" {
+		return logFilePath
+	}
+	return os.Getenv("WITAN_LOG")
+}
+
+// configureRequestLogging sets requestLogger from --verbose and --log-file.
+// Verbose output is human-readable on stderr; the log file gets one JSON
+// object per line and is appended to across runs.
+func configureRequestLogging(stderr io.Writer) error {
+	var sinks []func(client.RequestLogEvent)
+	if verbose {
+		sinks = append(sinks, func(ev client.RequestLogEvent) {
+			writeVerboseRequestLog(stderr, ev)
+		})
+	}
+	if path := resolveLogFilePath(); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("opening log file: %w", err)
+		}
+		enc := json.NewEncoder(f)
+		sinks = append(sinks, func(ev client.RequestLogEvent) {
+			_ = enc.Encode(ev)
+		})
+	}
+	if len(sinks) == 0 {
+		requestLogger = nil
+		return nil
+	}
+
+	// Commands such as `read` issue requests from several goroutines.
+	var mu sync.Mutex
+	requestLogger = func(ev client.RequestLogEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, sink := range sinks {
+			sink(ev)
+		}
+	}
+	return nil
+}
+
+func writeVerboseRequestLog(w io.Writer, ev client.RequestLogEvent) {
+	duration := time.Duration(ev.DurationMS) * time.Millisecond
+	switch ev.Event {
+	case client.LogEventRetryWait:
+		wait := time.Duration(ev.WaitMS) * time.Millisecond
+		fmt.Fprintf(w, "witan: retrying %s %s in %s (after attempt %d)\n", ev.Method, ev.URL, wait, ev.Attempt)
+		return
+	case client.LogEventError:
+		fmt.Fprintf(w, "witan: %s %s attempt=%d error=%q (%s)\n", ev.Method, ev.URL, ev.Attempt, ev.Error, duration)
+	default:
+		fmt.Fprintf(w, "witan: %s %s attempt=%d status=%d (%s)\n", ev.Method, ev.URL, ev.Attempt, ev.Status, duration)
+	}
+
+	keys := make([]string, 0, len(ev.Headers))
+	for k := range ev.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "witan:   > %s: %s\n", k, ev.Headers[k])
+	}
+}
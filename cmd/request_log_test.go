@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestConfigureRequestLogging_VerboseAndLogFile(t *testing.T) {
+	origVerbose := verbose
+	origLogFile := logFilePath
+	origLogger := requestLogger
+	t.Cleanup(func() {
+		verbose = origVerbose
+		logFilePath = origLogFile
+		requestLogger = origLogger
+	})
+
+	verbose = true
+	logFilePath = ""
+	path := filepath.Join(t.TempDir(), "witan.log")
+	t.Setenv("WITAN_LOG", path)
+
+	var stderr bytes.Buffer
+	if err := configureRequestLogging(&stderr); err != nil {
+		t.Fatalf("configureRequestLogging: %v", err)
+	}
+	if requestLogger == nil {
+		t.Fatal("expected request logger to be configured")
+	}
+
+	requestLogger(client.RequestLogEvent{
+		Event:      client.LogEventResponse,
+		Method:     "POST",
+		URL:        "https://api.test.local/v0/xlsx/calc",
+		Attempt:    1,
+		Status:     503,
+		DurationMS: 120,
+		Headers:    map[string]string{"Authorization": "Bearer [REDACTED]"},
+	})
+	requestLogger(client.RequestLogEvent{
+		Event:   client.LogEventRetryWait,
+		Method:  "POST",
+		URL:     "https://api.test.local/v0/xlsx/calc",
+		Attempt: 1,
+		WaitMS:  400,
+	})
+
+	out := stderr.String()
+	for _, want := range []string{
+		"witan: POST https://api.test.local/v0/xlsx/calc attempt=1 status=503 (120ms)",
+		"witan:   > Authorization: Bearer [REDACTED]",
+		"witan: retrying POST https://api.test.local/v0/xlsx/calc in 400ms (after attempt 1)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected verbose output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading log file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON log lines, got %d:\n%s", len(lines), data)
+	}
+	var ev client.RequestLogEvent
+	if err := json.Unmarshal([]byte(lines[1]), &ev); err != nil {
+		t.Fatalf("parsing log line: %v", err)
+	}
+	if ev.Event != client.LogEventRetryWait || ev.WaitMS != 400 {
+		t.Fatalf("unexpected log event: %+v", ev)
+	}
+}
+
+func TestConfigureRequestLogging_DisabledByDefault(t *testing.T) {
+	origVerbose := verbose
+	origLogFile := logFilePath
+	origLogger := requestLogger
+	t.Cleanup(func() {
+		verbose = origVerbose
+		logFilePath = origLogFile
+		requestLogger = origLogger
+	})
+
+	verbose = false
+	logFilePath = ""
+	t.Setenv("WITAN_LOG", "")
+	if err := configureRequestLogging(&bytes.Buffer{}); err != nil {
+		t.Fatalf("configureRequestLogging: %v", err)
+	}
+	if requestLogger != nil {
+		t.Fatal("expected request logging to be disabled")
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// noAutoRefresh turns off the re-upload and retry after a revision conflict.
+var noAutoRefresh bool
+
+// resolveAutoRefresh reports whether a 409 revision conflict is retried on a
+// fresh upload; off with --no-auto-refresh or WITAN_NO_AUTO_REFRESH=1.
+func resolveAutoRefresh() bool {
+	if noAutoRefresh {
+		return false
+	}
+	v := os.Getenv("WITAN_NO_AUTO_REFRESH")
+	return v != "1" && v != "true"
+}
+
+// revisionConflictError reports that another client saved a newer revision
+// of File than the one this command worked from.
+type revisionConflictError struct {
+	File       string
+	FileID     string
+	RevisionID string
+	Err        *client.APIError
+}
+
+func (e *revisionConflictError) Error() string {
+	msg := fmt.Sprintf("%s was changed on the server by another client after revision %s", e.File, e.RevisionID)
+	if !resolveAutoRefresh() {
+		return msg + "; rerun without --no-auto-refresh to upload the local file as the newest revision"
+	}
+	return msg + "; rerun to upload the local file as the newest revision"
+}
+
+func (e *revisionConflictError) Unwrap() error { return e.Err }
+
+// asRevisionConflict wraps a 409 from a files endpoint in a
+// revisionConflictError; any other error is returned unchanged.
+func asRevisionConflict(err error, filePath, fileID, revisionID string) error {
+	apiErr, ok := err.(*client.APIError)
+	if !ok || !client.IsRevisionConflict(err) {
+		return err
+	}
+	return &revisionConflictError{File: filePath, FileID: fileID, RevisionID: revisionID, Err: apiErr}
+}
+
+// ifRevisionError reports that --if-revision did not name the revision a
+// command would have worked from.
+type ifRevisionError struct {
+	File     string
+	FileID   string
+	Expected string
+	Actual   string // the revision found instead, when known
+	Reason   string
+}
+
+func (e *ifRevisionError) Error() string {
+	return fmt.Sprintf("--if-revision %s: %s", e.Expected, e.Reason)
+}
+
+// checkIfRevision confirms, before anything is uploaded or changed, that
+// filePath is unchanged since it was uploaded as revision want and that want
+// is still the server's latest revision. It returns the file's ID.
+func checkIfRevision(c *client.Client, filePath, want string) (string, error) {
+	if c.Stateless {
+		return "", fmt.Errorf("--if-revision requires files-backed mode: sign in with 'witan auth login' or set --api-key, and do not use --stateless")
+	}
+	entry, ok := c.CachedUpload(filePath)
+	if !ok {
+		return "", &ifRevisionError{File: filePath, Expected: want, Reason: fmt.Sprintf("%s has not been uploaded from this machine", filePath)}
+	}
+	mismatch := &ifRevisionError{File: filePath, FileID: entry.FileID, Expected: want, Actual: entry.RevisionID}
+	current, err := c.UploadIsCurrent(filePath)
+	if err != nil {
+		return "", err
+	}
+	if !current {
+		mismatch.Reason = fmt.Sprintf("%s has local changes since revision %s", filePath, entry.RevisionID)
+		return "", mismatch
+	}
+	if entry.RevisionID != want {
+		mismatch.Reason = fmt.Sprintf("%s is revision %s", filePath, entry.RevisionID)
+		return "", mismatch
+	}
+	revisions, err := c.ListRevisions(entry.FileID)
+	if err != nil {
+		return "", err
+	}
+	if len(revisions) > 0 && revisions[0].ID != want {
+		mismatch.Actual = revisions[0].ID
+		mismatch.Reason = fmt.Sprintf("the server's latest revision of %s is %s", filePath, revisions[0].ID)
+		return "", mismatch
+	}
+	return entry.FileID, nil
+}
+
+// revisionConflictOutput is the JSON shape printed under --json when the
+// file changed on the server.
+type revisionConflictOutput struct {
+	Ok    bool `json:"ok"`
+	Error struct {
+		Code             string `json:"code"` // revision_conflict or revision_mismatch
+		File             string `json:"file"`
+		FileID           string `json:"file_id,omitempty"`
+		RevisionID       string `json:"revision_id,omitempty"`
+		ExpectedRevision string `json:"expected_revision,omitempty"`
+		Message          string `json:"message"`
+	} `json:"error"`
+}
+
+// handleRevisionConflict reports a revision conflict or --if-revision
+// mismatch (as JSON when jsonOut is set) and returns an ExitConflict
+// ExitError. Any other error passes through unchanged.
+func handleRevisionConflict(err error, jsonOut bool) error {
+	var conflict *revisionConflictError
+	var mismatch *ifRevisionError
+	var out revisionConflictOutput
+	switch {
+	case errors.As(err, &conflict):
+		out.Error.Code = "revision_conflict"
+		out.Error.File = conflict.File
+		out.Error.FileID = conflict.FileID
+		out.Error.RevisionID = conflict.RevisionID
+		out.Error.Message = conflict.Error()
+	case errors.As(err, &mismatch):
+		out.Error.Code = "revision_mismatch"
+		out.Error.File = mismatch.File
+		out.Error.FileID = mismatch.FileID
+		out.Error.RevisionID = mismatch.Actual
+		out.Error.ExpectedRevision = mismatch.Expected
+		out.Error.Message = mismatch.Error()
+	default:
+		return err
+	}
+	if jsonOut {
+		if err := jsonPrint(out); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(os.Stderr, out.Error.Message)
+	}
+	return &ExitError{Code: ExitConflict}
+}
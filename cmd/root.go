@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,9 +20,26 @@ import (
 var Version = "dev"
 
 var (
-	apiKey    string
-	apiURL    string
-	stateless bool
+	apiKey         string
+	apiURL         string
+	stateless      bool
+	requestTimeout time.Duration
+	noCompress     bool
+	maxConcurrency int
+	cacheResponses bool
+	offline        bool
+	dryRun         string
+	recordPath     string
+	replayPath     string
+
+	caCertPath         string
+	insecureSkipVerify bool
+
+	// httpTransport is shared by every HTTP client the CLI builds. It is set
+	// in PersistentPreRunE; nil means net/http's default transport.
+	httpTransport http.RoundTripper
+	// recorder captures API traffic for --record; saved as the CLI exits.
+	recorder *client.Recorder
 )
 
 const versionHealthRequestTimeout = 5 * time.Second
@@ -31,10 +50,19 @@ var rootCmd = &cobra.Command{
 	Long: `Witan CLI provides spreadsheet workflows for calculation, script-driven read/write automation, linting, and rendering, plus PPTX slide rendering and Office.js-compatible execution.
 
 Workflows:
-  auth     Sign in, inspect auth status, or sign out for organization-backed requests.
-  read     Extract text from documents (PDF, DOCX, PPTX, HTML, text).
-  pptx     Render PPTX slides and run Office.js-compatible scripts.
-  xlsx     Recalculate formulas, run read/write scripts, lint formulas, and render ranges.
+  auth        Sign in, inspect auth status, create CI API keys, or sign out.
+  config      Store defaults for frequently used flags in settings.json.
+  doctor      Check directories, credentials, connectivity, and clock skew.
+  clean-temp  Remove exec images, renders, and downloads left by earlier runs.
+  introspect  Describe all commands, flags, exit codes, and JSON outputs (--json).
+  update      Replace this binary with the latest release (--check to compare only).
+  jobs        Check and fetch calc/exec jobs submitted with --async.
+  org         Show the organization's plan limits and rate-limit tiers.
+  usage       Show requests made and bytes processed in a month.
+  read        Extract text from documents (PDF, DOCX, PPTX, HTML, text).
+  run         Run a pipeline file of read, exec, calc, lint, and render steps.
+  pptx        Render PPTX slides and run Office.js-compatible scripts.
+  xlsx        Recalculate formulas, run read/write scripts, lint formulas, and render ranges.
 
 Modes:
   Stateful (default when authenticated):
@@ -42,6 +70,32 @@ Modes:
   Stateless (--stateless, or when no credentials are available):
     Sends the workbook with each request and keeps no server-side file cache.
 
+Offline:
+  --offline makes no network calls. With --cache-responses results stored
+  by earlier runs (lint, calc --verify, read) are reused for unchanged
+  files; anything else exits 5.
+
+Record and replay:
+  --record FILE saves every API request and response to a JSON cassette,
+  with credentials redacted. --replay FILE answers requests from it without
+  the network, matching method, path, and query in order; a request it has
+  no recording for fails. Use them to test scripts that drive witan.
+
+Dry run:
+  --dry-run prints each API request instead of sending it: method, URL,
+  query, headers (the key redacted), and the JSON or script payload, with
+  files summarized by name, size, and SHA-256. --dry-run=curl prints curl
+  commands instead. Sign-in lookups still run. The command stops at the
+  first request whose response it needs, so in stateful mode that is the
+  upload; add --stateless to see the whole operation as one request.
+
+Output:
+  Results go to stdout, as a human summary or as JSON with --json. Errors,
+  warnings, and progress go to stderr. On calc, exec, lint, and read,
+  -o FILE writes the JSON result to FILE and keeps the summary on stdout.
+  --quiet suppresses the summary. Render commands use -o for the image path.
+  Summaries are colored on a terminal unless --no-color or NO_COLOR is set.
+
 Quick start:
   witan auth login
   witan auth status
@@ -55,9 +109,37 @@ Quick start:
   witan xlsx render report.xlsx -r "Sheet1!A1:F20" -o preview.png
 
 Limits:
-  Workbook inputs must be 25 MB or smaller.`,
+  Inputs must be within the API's file size limit (25 MB unless the
+  deployment reports another); larger files are rejected before upload.`,
 	Version:       Version,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applySettings(cmd); err != nil {
+			return err
+		}
+		if _, err := resolveRequestTimeout(); err != nil {
+			return err
+		}
+		if _, err := resolveMaxConcurrency(); err != nil {
+			return err
+		}
+		if _, err := resolveFixExtMode(); err != nil {
+			return err
+		}
+		if dryRun != "" {
+			if _, err := client.ParseDryRunFormat(dryRun); err != nil {
+				return fmt.Errorf("--dry-run: %w", err)
+			}
+		}
+		if err := configureRequestLogging(cmd.ErrOrStderr()); err != nil {
+			return err
+		}
+		if err := configureHTTPTransport(); err != nil {
+			return err
+		}
+		startTelemetryFlush()
+		return nil
+	},
 }
 
 func init() {
@@ -67,6 +149,26 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", "", "API key for Witan requests (env: WITAN_API_KEY)")
 	rootCmd.PersistentFlags().StringVar(&apiURL, "api-url", "", "Override the Witan API base URL (env: WITAN_API_URL)")
 	rootCmd.PersistentFlags().BoolVar(&stateless, "stateless", false, "Send workbook bytes on every request; do not reuse uploaded revisions (env: WITAN_STATELESS)")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", 0, "Per-request timeout for Witan API calls, e.g. 90s or 5m (default 60s; env: WITAN_TIMEOUT)")
+	rootCmd.PersistentFlags().IntVar(&maxConcurrency, "max-concurrency", 0, "Most API requests in flight at once across a command, e.g. for directory runs (default unlimited; env: WITAN_MAX_CONCURRENCY)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Make no network calls: answer from cached responses (see --cache-responses) or exit 5 (env: WITAN_OFFLINE)")
+	rootCmd.PersistentFlags().StringVar(&dryRun, "dry-run", "", "Print API requests instead of sending them (request, or curl for curl commands)")
+	rootCmd.PersistentFlags().Lookup("dry-run").NoOptDefVal = string(client.DryRunRequest)
+	rootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "Record every API request and response to this cassette file, credentials redacted (env: WITAN_RECORD)")
+	rootCmd.PersistentFlags().StringVar(&replayPath, "replay", "", "Answer API requests from a cassette written by --record instead of the network (env: WITAN_REPLAY)")
+	rootCmd.PersistentFlags().BoolVar(&cacheResponses, "cache-responses", false, "Reuse stored lint, calc --verify, and read results for an unchanged workbook revision instead of calling the API (env: WITAN_RESPONSE_CACHE)")
+	rootCmd.PersistentFlags().BoolVar(&noAutoRefresh, "no-auto-refresh", false, "Fail on a revision conflict (another client saved the file) instead of uploading the local file as the newest revision and retrying once (env: WITAN_NO_AUTO_REFRESH)")
+	rootCmd.PersistentFlags().StringVar(&fixExtMode, "fix-ext", "", "When a workbook's extension does not match its content: rename it, work on a temp copy, or use it as named (rename, copy, off; env: WITAN_FIX_EXT)")
+	rootCmd.PersistentFlags().BoolVar(&noFixExt, "no-fix-ext", false, "Never rename a workbook whose extension does not match its content; same as --fix-ext off")
+	rootCmd.PersistentFlags().BoolVar(&noCompress, "no-compress", false, "Send request bodies uncompressed instead of gzipping large text and JSON payloads (env: WITAN_NO_COMPRESS)")
+	rootCmd.PersistentFlags().StringVar(&caCertPath, "ca-cert", "", "PEM CA bundle to trust in addition to system roots (env: WITAN_CA_CERT)")
+	rootCmd.PersistentFlags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "Disable TLS certificate verification; for testing self-hosted gateways only (env: WITAN_INSECURE_SKIP_VERIFY)")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Log API requests (method, URL, status, attempts, retry waits, timing) to stderr")
+	rootCmd.PersistentFlags().StringVar(&logFilePath, "log-file", "", "Append structured JSON request logs to this file (env: WITAN_LOG)")
+	rootCmd.PersistentFlags().BoolVarP(&quietOutput, "quiet", "q", false, "Suppress human-readable summaries on stdout; errors still go to stderr")
+	rootCmd.PersistentFlags().BoolVar(&ndjsonOutput, "ndjson", false, "Stream progress events (uploads, retries, calc/exec start) and the result to stdout as one JSON object per line")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR or when stdout is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noTelemetry, "no-telemetry", false, "Do not record or send usage metrics, even when the telemetry setting is on (env: WITAN_NO_TELEMETRY)")
 }
 
 type healthResponse struct {
@@ -76,6 +178,9 @@ type healthResponse struct {
 }
 
 func versionDetails(cmd *cobra.Command) string {
+	// --version short-circuits before PersistentPreRunE; a bad CA bundle just
+	// makes the health check report "unavailable".
+	_ = configureHTTPTransport()
 	return formatVersionDetails(cmd.DisplayName(), cmd.Version, resolveAPIURL())
 }
 
@@ -110,7 +215,7 @@ func fetchHealthVersion(baseURL string) (string, error) {
 	}
 	setCLIUserAgent(req)
 
-	httpClient := &http.Client{Timeout: versionHealthRequestTimeout}
+	httpClient := newHTTPClient(versionHealthRequestTimeout)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", err
@@ -142,6 +247,163 @@ func resolveStateless() bool {
 	return !hasAuthCredentials()
 }
 
+// resolveOffline reports whether network calls are refused; on with
+// --offline or WITAN_OFFLINE=1.
+func resolveOffline() bool {
+	if offline {
+		return true
+	}
+	v := os.Getenv("WITAN_OFFLINE")
+	return v == "1" || v == "true"
+}
+
+// resolveResponseCache reports whether revision-keyed responses are cached;
+// on with --cache-responses or WITAN_RESPONSE_CACHE=1.
+func resolveResponseCache() bool {
+	if cacheResponses {
+		return true
+	}
+	v := os.Getenv("WITAN_RESPONSE_CACHE")
+	return v == "1" || v == "true"
+}
+
+// resolveCompression reports whether request bodies may be gzipped; off with
+// --no-compress or WITAN_NO_COMPRESS=1.
+func resolveCompression() bool {
+	if noCompress {
+		return false
+	}
+	v := os.Getenv("WITAN_NO_COMPRESS")
+	return v != "1" && v != "true"
+}
+
+// resolveMaxConcurrency returns the in-flight request cap from
+// --max-concurrency or WITAN_MAX_CONCURRENCY. Zero means no cap.
+func resolveMaxConcurrency() (int, error) {
+	if maxConcurrency < 0 {
+		return 0, fmt.Errorf("--max-concurrency must be > 0")
+	}
+	if maxConcurrency > 0 {
+		return maxConcurrency, nil
+	}
+	v := strings.TrimSpace(os.Getenv("WITAN_MAX_CONCURRENCY"))
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("WITAN_MAX_CONCURRENCY must be a positive integer, got %q", v)
+	}
+	return n, nil
+}
+
+// resolveRequestTimeout returns the client request timeout from --timeout or
+// WITAN_TIMEOUT. Zero means "use the client default". WITAN_TIMEOUT accepts a
+// Go duration ("90s", "5m") or a bare number of seconds.
+func resolveRequestTimeout() (time.Duration, error) {
+	if requestTimeout < 0 {
+		return 0, fmt.Errorf("--timeout must be > 0")
+	}
+	if requestTimeout > 0 {
+		return requestTimeout, nil
+	}
+	v := strings.TrimSpace(os.Getenv("WITAN_TIMEOUT"))
+	if v == "" {
+		return 0, nil
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs <= 0 {
+			return 0, fmt.Errorf("WITAN_TIMEOUT must be > 0, got %q", v)
+		}
+		return time.Duration(secs) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid WITAN_TIMEOUT %q: expected a positive duration like 90s or 5m", v)
+	}
+	return d, nil
+}
+
+func resolveCACertPath() string {
+	if caCertPath != "" {
+		return caCertPath
+	}
+	return os.Getenv("WITAN_CA_CERT")
+}
+
+func resolveInsecureSkipVerify() bool {
+	if insecureSkipVerify {
+		return true
+	}
+	v := os.Getenv("WITAN_INSECURE_SKIP_VERIFY")
+	return v == "1" || v == "true"
+}
+
+// configureHTTPTransport builds the shared transport from --ca-cert and
+// --insecure-skip-verify. Proxies come from HTTP_PROXY / HTTPS_PROXY / NO_PROXY.
+func configureHTTPTransport() error {
+	record, replay := resolveRecordPath(), resolveReplayPath()
+	switch {
+	case record != "" && replay != "":
+		return fmt.Errorf("--record and --replay cannot be combined")
+	case record != "" && resolveOffline():
+		return fmt.Errorf("--record cannot be combined with --offline")
+	case replay != "":
+		cassette, err := client.LoadCassette(replay)
+		if err != nil {
+			return err
+		}
+		httpTransport = client.NewReplayer(cassette)
+		return nil
+	}
+	if resolveOffline() {
+		httpTransport = client.OfflineTransport{}
+		return nil
+	}
+	t, err := client.NewTransport(client.TransportOptions{
+		CACertPath:         resolveCACertPath(),
+		InsecureSkipVerify: resolveInsecureSkipVerify(),
+	})
+	if err != nil {
+		return err
+	}
+	httpTransport = t
+	if record != "" {
+		recorder = client.NewRecorder(t)
+		httpTransport = recorder
+	}
+	return nil
+}
+
+// resolveRecordPath returns the --record cassette path, or WITAN_RECORD.
+func resolveRecordPath() string {
+	if recordPath != "" {
+		return client.ExpandHome(recordPath)
+	}
+	return client.ExpandHome(os.Getenv("WITAN_RECORD"))
+}
+
+// resolveReplayPath returns the --replay cassette path, or WITAN_REPLAY.
+func resolveReplayPath() string {
+	if replayPath != "" {
+		return client.ExpandHome(replayPath)
+	}
+	return client.ExpandHome(os.Getenv("WITAN_REPLAY"))
+}
+
+// saveRecording writes the --record cassette, if any, as the CLI exits.
+func saveRecording() error {
+	if recorder == nil {
+		return nil
+	}
+	return recorder.Cassette().Save(resolveRecordPath())
+}
+
+// newHTTPClient returns an HTTP client using the shared CLI transport.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: httpTransport}
+}
+
 func resolveRawAPIKey() string {
 	if apiKey != "" {
 		return apiKey
@@ -174,11 +436,18 @@ func resolveAuth() (string, string, error) {
 		return "", "", fmt.Errorf("not authenticated: run 'witan auth login' or set --api-key / WITAN_API_KEY")
 	}
 
-	jwt, err := exchangeSessionForJWT(resolveManagementAPIURL(), cfg.SessionToken)
+	// Offline, the session is not exchanged: cached responses only need
+	// the org, and no request will carry the token.
+	if resolveOffline() && cfg.SessionOrgID != "" {
+		return "", cfg.SessionOrgID, nil
+	}
+
+	jwt, err := sessionJWT(cfg)
 	if err != nil {
 		if isInvalidSavedSessionError(err) {
 			cfg.SessionToken = ""
 			cfg.SessionOrgID = ""
+			cfg.SessionJWT = nil
 			if saveErr := config.Save(cfg); saveErr != nil {
 				return "", "", fmt.Errorf("clearing invalid auth config: %w", saveErr)
 			}
@@ -193,6 +462,7 @@ func resolveAuth() (string, string, error) {
 	if cfg.SessionOrgID == "" {
 		return "", "", fmt.Errorf("organization not selected: run 'witan auth login --org <id>' (or set WITAN_ORG) to finish signing in")
 	}
+	sessionAuth = true
 	return jwt, cfg.SessionOrgID, nil
 }
 
@@ -257,7 +527,7 @@ func listOrgs(mgmtURL, authHeader string) ([]orgEntry, error) {
 	setCLIUserAgent(req)
 	req.Header.Set("Authorization", authHeader)
 
-	httpClient := &http.Client{Timeout: 10 * time.Second}
+	httpClient := newHTTPClient(10 * time.Second)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -332,8 +602,8 @@ func exchangeSessionForJWT(mgmtURL, sessionToken string) (string, error) {
 	setCLIUserAgent(req)
 	req.Header.Set("Authorization", "Bearer "+sessionToken)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	httpClient := newHTTPClient(10 * time.Second)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -366,8 +636,63 @@ func resolveAPIURL() string {
 }
 
 func newAPIClient(bearerToken, orgID string) *client.Client {
-	c := client.New(resolveAPIURL(), bearerToken, orgID, resolveStateless())
-	c.UserAgent = cliUserAgent()
+	return newAPIClientMode(bearerToken, orgID, resolveStateless())
+}
+
+// newAPIClientMode builds a client with an explicit stateless setting, for
+// commands (e.g. exec --create) that must bypass the stateful file endpoints.
+func newAPIClientMode(bearerToken, orgID string, stateless bool) *client.Client {
+	opts := []client.Option{client.WithUserAgent(cliUserAgent())}
+	// Validated in PersistentPreRunE; an error here means the value was unset.
+	if timeout, err := resolveRequestTimeout(); err == nil {
+		opts = append(opts, client.WithTimeout(timeout))
+	}
+	if n, err := resolveMaxConcurrency(); err == nil && n > 0 {
+		opts = append(opts, client.WithMaxConcurrency(n))
+	}
+	if settingsMaxAttempts > 0 {
+		opts = append(opts, client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: settingsMaxAttempts}))
+	}
+	if resolveResponseCache() {
+		opts = append(opts, client.WithResponseCache(true))
+	}
+	if resolveOffline() {
+		opts = append(opts, client.WithOffline())
+	}
+	if !resolveCompression() {
+		opts = append(opts, client.WithRequestCompression(false))
+	}
+	opts = append(opts, client.WithNoticeHandler(warnAPINotice))
+	if uploadStrip != client.StripOff {
+		opts = append(opts, client.WithStrip(uploadStrip))
+	}
+	if !stateless {
+		if s := openShellSession(); s != nil {
+			opts = append(opts, client.WithSession(s))
+		}
+	}
+	if sessionAuth && bearerToken != "" {
+		opts = append(opts, client.WithTokenRefresh(refreshSessionJWT))
+	}
+	// Only a limit an earlier command already fetched; no request here.
+	if caps := cachedCapabilities(resolveAPIURL()); caps != nil && caps.MaxFileBytes > 0 {
+		opts = append(opts, client.WithMaxFileBytes(caps.MaxFileBytes))
+	}
+	if ndjsonOutput {
+		opts = append(opts, client.WithUploadProgress(uploadEvents()))
+	} else if progress := uploadProgressPrinter(os.Stderr); progress != nil && dryRun == "" {
+		opts = append(opts, client.WithUploadProgress(progress))
+	}
+	c := client.New(resolveAPIURL(), bearerToken, orgID, stateless, opts...)
+	if httpTransport != nil {
+		c.HTTPClient.Transport = httpTransport
+	}
+	if dryRun != "" {
+		// Only API calls are printed; sign-in lookups above still run.
+		format, _ := client.ParseDryRunFormat(dryRun)
+		c.HTTPClient.Transport = client.DryRunTransport{Format: format, Out: os.Stdout}
+	}
+	c.Logger = withRetryEvents(requestLogger)
 	return c
 }
 
@@ -384,5 +709,24 @@ func setCLIUserAgent(req *http.Request) {
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	shutdownTracing := setupTracing(os.Stderr)
+	defer shutdownTracing()
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	if errors.Is(err, client.ErrDryRun) {
+		// The request was printed; stopping there is the point.
+		err = nil
+	}
+	err = finishRemoteFiles(err)
+	if saveErr := saveRecording(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	removeFixExtCopies()
+	finishRunTempDir(os.Stderr)
+	if err != nil {
+		emitErrorEvent(err)
+	}
+	recordTelemetry(cmd, start, err)
+	waitTelemetryFlush()
+	return err
 }
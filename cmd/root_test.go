@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -9,8 +10,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
+	"github.com/witanlabs/witan-cli/client"
 	"github.com/witanlabs/witan-cli/config"
 )
 
@@ -546,3 +549,148 @@ func resetCommandFlag(flagSet interface{ Lookup(string) *pflag.Flag }, name, val
 	_ = flag.Value.Set(value)
 	flag.Changed = false
 }
+
+func TestResolveRequestTimeout(t *testing.T) {
+	origTimeout := requestTimeout
+	t.Cleanup(func() { requestTimeout = origTimeout })
+
+	tests := []struct {
+		name    string
+		flag    time.Duration
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: 0},
+		{name: "flag", flag: 90 * time.Second, env: "10", want: 90 * time.Second},
+		{name: "env seconds", env: "120", want: 120 * time.Second},
+		{name: "env duration", env: "5m", want: 5 * time.Minute},
+		{name: "env invalid", env: "soon", wantErr: true},
+		{name: "env zero", env: "0", wantErr: true},
+		{name: "flag negative", flag: -time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestTimeout = tt.flag
+			t.Setenv("WITAN_TIMEOUT", tt.env)
+
+			got, err := resolveRequestTimeout()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveMaxConcurrency(t *testing.T) {
+	origMaxConcurrency := maxConcurrency
+	t.Cleanup(func() { maxConcurrency = origMaxConcurrency })
+
+	tests := []struct {
+		name    string
+		flag    int
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 0},
+		{name: "flag", flag: 4, env: "2", want: 4},
+		{name: "env", env: "3", want: 3},
+		{name: "env invalid", env: "many", wantErr: true},
+		{name: "env zero", env: "0", wantErr: true},
+		{name: "flag negative", flag: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxConcurrency = tt.flag
+			t.Setenv("WITAN_MAX_CONCURRENCY", tt.env)
+
+			got, err := resolveMaxConcurrency()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigureHTTPTransport_AppliesToAPIClient(t *testing.T) {
+	origTransport := httpTransport
+	origCACert := caCertPath
+	origInsecure := insecureSkipVerify
+	t.Cleanup(func() {
+		httpTransport = origTransport
+		caCertPath = origCACert
+		insecureSkipVerify = origInsecure
+	})
+
+	caCertPath = ""
+	insecureSkipVerify = false
+	t.Setenv("WITAN_INSECURE_SKIP_VERIFY", "1")
+	if err := configureHTTPTransport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := newAPIClientMode("test-key", "", true)
+	tr, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected shared *http.Transport, got %T", c.HTTPClient.Transport)
+	}
+	if tr.TLSClientConfig == nil || !tr.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("expected WITAN_INSECURE_SKIP_VERIFY to disable verification")
+	}
+}
+
+func TestConfigureHTTPTransport_OfflineRefusesRequests(t *testing.T) {
+	origTransport := httpTransport
+	origOffline := offline
+	t.Cleanup(func() {
+		httpTransport = origTransport
+		offline = origOffline
+	})
+
+	offline = true
+	if err := configureHTTPTransport(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := newAPIClientMode("test-key", "", true)
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(path, []byte("xlsx"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Lint(path, nil); !errors.Is(err, client.ErrOffline) {
+		t.Fatalf("expected client.ErrOffline, got %v", err)
+	}
+}
+
+func TestConfigureHTTPTransport_RejectsMissingCACert(t *testing.T) {
+	origTransport := httpTransport
+	origCACert := caCertPath
+	t.Cleanup(func() {
+		httpTransport = origTransport
+		caCertPath = origCACert
+	})
+
+	caCertPath = filepath.Join(t.TempDir(), "missing.pem")
+	if err := configureHTTPTransport(); err == nil || !strings.Contains(err.Error(), "reading CA bundle") {
+		t.Fatalf("expected CA bundle error, got %v", err)
+	}
+}
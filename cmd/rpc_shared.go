@@ -35,7 +35,7 @@ func dialRPCWebSocket(ctx context.Context, wsURL string, apiKey string, userAgen
 	headers := http.Header{}
 	headers.Set("User-Agent", userAgent)
 
-	opts := &websocket.DialOptions{HTTPHeader: headers}
+	opts := &websocket.DialOptions{HTTPHeader: headers, HTTPClient: newHTTPClient(0)}
 	if apiKey != "" {
 		opts.Subprotocols = []string{"bearer-" + apiKey}
 	}
@@ -0,0 +1,586 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"gopkg.in/yaml.v3"
+)
+
+var runJSON bool
+
+var runCmd = &cobra.Command{
+	Use:   "run <pipeline.yaml> [file...]",
+	Short: "Run a pipeline of read, exec, calc, lint, and render steps",
+	Long: `Run the steps declared in a pipeline file against one or more workbooks.
+
+Each workbook is uploaded once and every step works on that revision; an
+exec or calc step with save: true writes the workbook back and later steps
+see the saved revision. Workbooks are processed one after another, and a
+failure in one never stops the others.
+
+Pipeline file:
+  files: [models/*.xlsx]    # globs; workbook arguments replace this list
+  on_failure: stop          # stop (skip the file's remaining steps) or continue
+  steps:
+    - step: read            # the sheets as Markdown
+      output: out/{name}.md
+    - step: exec
+      script: checks.js     # or code: 'return 1'
+      input: {threshold: 0.05}
+      save: false           # true writes the workbook back
+      output: out/{name}.exec.json
+    - step: calc            # checks formulas (--verify) unless save: true
+      range: Summary!A1:H40
+    - step: lint
+      fail_on: error        # error, warning (default), info, or none
+    - step: render
+      range: Summary!A1:H40
+      output: out/{name}.png
+      on_failure: continue  # overrides the pipeline's on_failure
+
+Paths in the pipeline file are relative to it. {name} in an output is the
+workbook's name without its extension; with several workbooks each output
+must contain it. A step's result JSON, its rendered image, or the read text
+goes to output. name labels a step in the report.
+
+A step is ok, has findings (lint diagnostics at fail_on, calc errors or
+changed values, a failed exec script), failed (the request or a write
+failed), or skipped. The report (--json, or -o FILE) lists every step of
+every workbook with its status and duration. Exits 1 if a step failed,
+otherwise 2 if a step had findings.
+
+Examples:
+  witan run pipeline.yaml
+  witan run pipeline.yaml q3.xlsx q4.xlsx
+  witan run pipeline.yaml -o report.json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPipeline,
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&runJSON, "json", false, "Output the run report as JSON")
+	addResultOutputFlag(runCmd)
+	rootCmd.AddCommand(runCmd)
+}
+
+// pipeline is a witan run pipeline file; see runCmd's help.
+type pipeline struct {
+	Files     []string       `yaml:"files"`
+	OnFailure string         `yaml:"on_failure"`
+	Steps     []pipelineStep `yaml:"steps"`
+
+	dir string // the pipeline file's directory
+}
+
+// pipelineStep is one step. Step selects the kind; the other fields apply
+// to the kinds noted.
+type pipelineStep struct {
+	Step      string `yaml:"step"`
+	Name      string `yaml:"name"`
+	Output    string `yaml:"output"`
+	OnFailure string `yaml:"on_failure"`
+
+	Code   string `yaml:"code"`   // exec
+	Script string `yaml:"script"` // exec
+	Input  any    `yaml:"input"`  // exec
+	Save   bool   `yaml:"save"`   // exec, calc
+	Range  string `yaml:"range"`  // calc, lint, render
+	FailOn string `yaml:"fail_on"`
+	DPR    int    `yaml:"dpr"`    // render
+	Format string `yaml:"format"` // render
+}
+
+// pipelineStepFields lists the kind-specific fields each step accepts.
+var pipelineStepFields = map[string][]string{
+	"read":   nil,
+	"exec":   {"code", "script", "input", "save"},
+	"calc":   {"range", "save"},
+	"lint":   {"range", "fail_on"},
+	"render": {"range", "dpr", "format"},
+}
+
+// Step statuses in a run report.
+const (
+	runStepOK       = "ok"
+	runStepFindings = "findings"
+	runStepFailed   = "failed"
+	runStepSkipped  = "skipped"
+)
+
+// runReport is witan run's result.
+type runReport struct {
+	Pipeline string          `json:"pipeline"`
+	Ok       bool            `json:"ok"`
+	Files    []runFileReport `json:"files"`
+}
+
+type runFileReport struct {
+	File       string          `json:"file"`
+	Ok         bool            `json:"ok"`
+	FileID     string          `json:"file_id,omitempty"`
+	RevisionID string          `json:"revision_id,omitempty"` // the last revision the steps used
+	Error      string          `json:"error,omitempty"`
+	Steps      []runStepReport `json:"steps"`
+}
+
+type runStepReport struct {
+	Name       string `json:"name"`
+	Step       string `json:"step"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Summary    string `json:"summary,omitempty"`
+	Output     string `json:"output,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Result     any    `json:"result,omitempty"`
+}
+
+// loadPipeline reads and validates a pipeline file. Exec scripts are read
+// and syntax-checked here, so a likely mistake is warned about before
+// anything is uploaded.
+func loadPipeline(path string) (*pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading pipeline: %w", err)
+	}
+	p := &pipeline{dir: filepath.Dir(path)}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := validateOnFailure(p.OnFailure); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(p.Steps) == 0 {
+		return nil, fmt.Errorf("%s: no steps", path)
+	}
+	for i := range p.Steps {
+		s := &p.Steps[i]
+		if err := p.validateStep(s); err != nil {
+			return nil, fmt.Errorf("%s: steps[%d]: %w", path, i, err)
+		}
+	}
+	return p, nil
+}
+
+func validateOnFailure(v string) error {
+	switch v {
+	case "", "stop", "continue":
+		return nil
+	}
+	return fmt.Errorf("on_failure: %q is not stop or continue", v)
+}
+
+func (p *pipeline) validateStep(s *pipelineStep) error {
+	allowed, ok := pipelineStepFields[s.Step]
+	if !ok {
+		return fmt.Errorf("step: %q is not read, exec, calc, lint, or render", s.Step)
+	}
+	if s.Name == "" {
+		s.Name = s.Step
+	}
+	if err := validateOnFailure(s.OnFailure); err != nil {
+		return err
+	}
+	set := map[string]bool{
+		"code": s.Code != "", "script": s.Script != "", "input": s.Input != nil, "save": s.Save,
+		"range": s.Range != "", "fail_on": s.FailOn != "", "dpr": s.DPR != 0, "format": s.Format != "",
+	}
+	for _, field := range slices.Sorted(maps.Keys(set)) {
+		if set[field] && !slices.Contains(allowed, field) {
+			return fmt.Errorf("%s does not apply to a %s step", field, s.Step)
+		}
+	}
+
+	switch s.Step {
+	case "exec":
+		if (s.Code == "") == (s.Script == "") {
+			return fmt.Errorf("exec needs exactly one of code or script")
+		}
+		name := "code"
+		if s.Script != "" {
+			data, err := os.ReadFile(p.resolve(s.Script))
+			if err != nil {
+				return fmt.Errorf("reading script: %w", err)
+			}
+			s.Code, name = string(data), s.Script
+		}
+		if err := checkExecSyntax(s.Code); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: possible syntax error in %s:%v\n", name, err)
+		}
+	case "lint":
+		if s.FailOn == "" {
+			s.FailOn = defaultLintFailOn
+		}
+		if _, err := lintFailOnRank(s.FailOn); err != nil {
+			return fmt.Errorf("fail_on: %w", err)
+		}
+	case "render":
+		if s.Range == "" {
+			return fmt.Errorf("render needs a range")
+		}
+		if s.DPR != 0 && (s.DPR < 1 || s.DPR > 3) {
+			return fmt.Errorf("dpr must be 1-3, got %d", s.DPR)
+		}
+		switch s.Format {
+		case "":
+			s.Format = "png"
+		case "png", "webp", "svg":
+		default:
+			return fmt.Errorf("format must be png, webp, or svg, got %q", s.Format)
+		}
+	}
+	return nil
+}
+
+// resolve makes a path from the pipeline file relative to its directory.
+func (p *pipeline) resolve(path string) string {
+	path = client.ExpandHome(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.dir, path)
+}
+
+// workbooks expands the pipeline's file globs, in order and without
+// duplicates.
+func (p *pipeline) workbooks() ([]string, error) {
+	var paths []string
+	for _, pattern := range p.Files {
+		matches, err := filepath.Glob(p.resolve(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("files: %w", err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("files: %s matches no workbooks", pattern)
+		}
+		for _, m := range matches {
+			ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(m)), ".")
+			if slices.Contains(workbookExtensions, ext) && !strings.HasPrefix(filepath.Base(m), "~$") && !slices.Contains(paths, m) {
+				paths = append(paths, m)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// outputPath returns where a step writes its output for workbook, or "".
+func (p *pipeline) outputPath(s pipelineStep, workbook string) string {
+	if s.Output == "" {
+		return ""
+	}
+	base := filepath.Base(workbook)
+	return p.resolve(strings.ReplaceAll(s.Output, "{name}", strings.TrimSuffix(base, filepath.Ext(base))))
+}
+
+func runPipeline(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	p, err := loadPipeline(args[0])
+	if err != nil {
+		return err
+	}
+	var files []string
+	if len(args) > 1 {
+		files = args[1:]
+	} else if files, err = p.workbooks(); err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no workbooks to run: list them under files: in %s or as arguments", args[0])
+	}
+	if len(files) > 1 {
+		for _, s := range p.Steps {
+			if s.Output != "" && !strings.Contains(s.Output, "{name}") {
+				return fmt.Errorf("step %s: output %s would be overwritten by each workbook; include {name}", s.Name, s.Output)
+			}
+		}
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+
+	report := &runReport{Pipeline: args[0], Ok: true}
+	for _, file := range files {
+		f := p.runFile(c, file)
+		report.Ok = report.Ok && f.Ok
+		report.Files = append(report.Files, f)
+	}
+
+	if err := emitResult(report, runJSON, func() error {
+		printRunReport(report)
+		return nil
+	}); err != nil {
+		return err
+	}
+	code := 0
+	for _, f := range report.Files {
+		if f.Error != "" {
+			code = ExitFailure
+		}
+		for _, s := range f.Steps {
+			switch {
+			case s.Status == runStepFailed:
+				code = ExitFailure
+			case s.Status == runStepFindings && code == 0:
+				code = ExitFindings
+			}
+		}
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// runFile runs every step on one workbook. Steps after a failure are
+// skipped when the step's (or the pipeline's) on_failure is stop.
+func (p *pipeline) runFile(c *client.Client, file string) runFileReport {
+	f := runFileReport{File: file, Ok: true, Steps: []runStepReport{}}
+	path, err := fixExcelExtension(file)
+	if err == nil && !c.Stateless {
+		// Upload once up front; the steps find this revision in the upload
+		// cache, or the one a saving step wrote back.
+		f.FileID, f.RevisionID, err = c.EnsureUploaded(path)
+	}
+	if err != nil {
+		f.Ok = false
+		f.Error = err.Error()
+		return f
+	}
+
+	stopped := false
+	for _, s := range p.Steps {
+		if stopped {
+			f.Steps = append(f.Steps, runStepReport{Name: s.Name, Step: s.Step, Status: runStepSkipped})
+			continue
+		}
+		start := time.Now()
+		r := p.runStep(c, s, path)
+		r.DurationMS = time.Since(start).Milliseconds()
+		f.Steps = append(f.Steps, r)
+		if r.Status == runStepOK {
+			continue
+		}
+		f.Ok = false
+		onFailure := s.OnFailure
+		if onFailure == "" {
+			onFailure = p.OnFailure
+		}
+		stopped = onFailure != "continue"
+	}
+	if !c.Stateless {
+		if entry, ok := c.CachedUpload(path); ok {
+			f.FileID, f.RevisionID = entry.FileID, entry.RevisionID
+		}
+	}
+	return f
+}
+
+func (p *pipeline) runStep(c *client.Client, s pipelineStep, path string) runStepReport {
+	r := runStepReport{Name: s.Name, Step: s.Step, Status: runStepOK, Output: p.outputPath(s, path)}
+	fail := func(err error) runStepReport {
+		r.Status = runStepFailed
+		r.Error = err.Error()
+		return r
+	}
+
+	switch s.Step {
+	case "read":
+		result, err := readWorkbookContent(c, path, url.Values{"limit": {strconv.Itoa(1 << 30)}})
+		if err != nil {
+			return fail(err)
+		}
+		r.Summary = pluralize(result.Metadata.TotalLines, "line", "lines")
+		if r.Output == "" {
+			r.Result = result
+		} else if err := writeRunOutput(r.Output, []byte(result.Content+"\n")); err != nil {
+			return fail(err)
+		}
+
+	case "exec":
+		result, err := execWorkbook(c, path, client.ExecRequest{Code: s.Code, Input: s.Input}, s.Save, false, "", "")
+		if err != nil {
+			return fail(err)
+		}
+		r.Result = result
+		if !result.Ok {
+			r.Status = runStepFindings
+			r.Error = formatExecError(result.Error)
+		} else if s.Save {
+			r.Summary = "saved"
+		}
+
+	case "calc":
+		params := url.Values{}
+		for _, a := range splitRunRanges(s.Range) {
+			params.Add("address", a)
+		}
+		if !s.Save {
+			params.Set("verify", "true")
+		}
+		saveTo := ""
+		if s.Save {
+			saveTo = path
+		}
+		result, err := calcWorkbook(c, path, params, saveTo, "")
+		if err != nil {
+			return fail(err)
+		}
+		r.Result = result
+		r.Summary = pluralize(len(result.Errors), "error", "errors")
+		if !s.Save {
+			r.Summary += ", " + pluralize(len(result.Changed), "changed value", "changed values")
+		}
+		if len(result.Errors) > 0 || !s.Save && len(result.Changed) > 0 {
+			r.Status = runStepFindings
+		}
+
+	case "lint":
+		params := url.Values{}
+		for _, a := range splitRunRanges(s.Range) {
+			params.Add("range", a)
+		}
+		result, err := fetchLint(c, path, params)
+		if err != nil {
+			return fail(err)
+		}
+		r.Result = result
+		r.Summary = pluralize(result.Total, "issue", "issues")
+		failRank, _ := lintFailOnRank(s.FailOn)
+		for _, d := range result.Diagnostics {
+			if lintDiagnosticFails(d, failRank) {
+				r.Status = runStepFindings
+				break
+			}
+		}
+
+	case "render":
+		resolved, err := resolveRangeAddresses(c, path, []string{s.Range})
+		if err != nil {
+			return fail(err)
+		}
+		dpr := s.DPR
+		if dpr == 0 {
+			dpr = autoDPR(resolved[0])
+		}
+		params := map[string]string{"address": resolved[0], "dpr": strconv.Itoa(dpr), "format": s.Format}
+		image, contentType, err := fetchRender(c, path, params)
+		if err != nil {
+			return fail(err)
+		}
+		if r.Output, err = writeRenderedImage(r.Output, contentType, image); err != nil {
+			return fail(err)
+		}
+		r.Summary = resolved[0]
+		return r
+	}
+
+	// Results with an output go there; the report keeps the summary.
+	if r.Output != "" && s.Step != "read" {
+		if err := writeRunJSONOutput(r.Output, r.Result); err != nil {
+			return fail(err)
+		}
+		r.Result = nil
+	}
+	return r
+}
+
+// splitRunRanges splits a step's range on commas outside quoted sheet
+// names, so one step can cover several ranges.
+func splitRunRanges(s string) []string {
+	var ranges []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				ranges = append(ranges, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		ranges = append(ranges, rest)
+	}
+	return ranges
+}
+
+func writeRunOutput(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+func writeRunJSONOutput(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return writeJSONFile(path, v)
+}
+
+func printRunReport(report *runReport) {
+	var ok, failed int
+	for _, f := range report.Files {
+		if f.Ok {
+			ok++
+		} else {
+			failed++
+		}
+		fmt.Println(f.File)
+		if f.Error != "" {
+			fmt.Printf("  %s %s\n", colorize("✗", ansiRed), f.Error)
+			continue
+		}
+		width := 0
+		for _, s := range f.Steps {
+			width = max(width, len(s.Name))
+		}
+		for _, s := range f.Steps {
+			line := fmt.Sprintf("%-*s", width, s.Name)
+			switch s.Status {
+			case runStepSkipped:
+				fmt.Printf("  - %s  skipped\n", line)
+				continue
+			case runStepOK:
+				line = colorize("✓", ansiGreen) + " " + line
+			case runStepFindings:
+				line = colorize("!", ansiYellow) + " " + line
+			default:
+				line = colorize("✗", ansiRed) + " " + line
+			}
+			line += fmt.Sprintf("  %6.1fs", float64(s.DurationMS)/1000)
+			for _, detail := range []string{s.Summary, s.Error} {
+				if detail != "" {
+					line += "  " + detail
+				}
+			}
+			if s.Output != "" {
+				line += "  -> " + s.Output
+			}
+			fmt.Println("  " + line)
+		}
+	}
+	fmt.Printf("\n%s: %d ok, %d with problems\n", pluralize(len(report.Files), "workbook", "workbooks"), ok, failed)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runTempPrefix names the per-invocation directories under runTempRoot.
+const runTempPrefix = "run-"
+
+// runTempLive marks a run directory whose invocation is still running;
+// finishRunTempDir removes it.
+const runTempLive = ".live"
+
+// runTempMaxAge is how old a run directory gets before a later invocation
+// removes it. Live run directories are kept until they reach it, even by
+// clean-temp.
+const runTempMaxAge = 7 * 24 * time.Hour
+
+// runTemp is this invocation's temp directory, created on first use.
+var runTemp struct {
+	once sync.Once
+	dir  string
+	err  error
+}
+
+// runTempRoot is the directory that holds every invocation's temp
+// directory: $TMPDIR/witan-<uid>, so users of a shared host do not share
+// it. On Windows, where TMPDIR is already per user, it is $TMPDIR/witan.
+func runTempRoot() string {
+	name := "witan"
+	if uid := os.Getuid(); uid >= 0 {
+		name = fmt.Sprintf("witan-%d", uid)
+	}
+	return filepath.Join(os.TempDir(), name)
+}
+
+// runTempDir returns this invocation's temp directory,
+// runTempRoot()/run-<id>, for exec images, renders, and downloads. It is
+// created on first use, after run directories older than runTempMaxAge are
+// removed. When the root cannot be used (it is not writable, or is open to
+// other users), the directory is created directly under $TMPDIR instead.
+func runTempDir() (string, error) {
+	runTemp.once.Do(func() {
+		pattern := runTempPrefix + time.Now().Format("20060102-150405") + "-*"
+		root := runTempRoot()
+		var dir string
+		var err error
+		if privateDir(root) {
+			// Best-effort: a failure here must not fail the command.
+			_, _, _ = removeRunTempDirs(root, runTempMaxAge)
+			dir, err = os.MkdirTemp(root, pattern)
+		}
+		if dir == "" {
+			dir, err = os.MkdirTemp("", "witan-"+pattern)
+		}
+		if err != nil {
+			runTemp.err = fmt.Errorf("creating temp directory: %w", err)
+			return
+		}
+		// Best-effort: without the marker clean-temp may remove the
+		// directory early, which only costs this run's temp files.
+		_ = os.WriteFile(filepath.Join(dir, runTempLive), nil, 0o600)
+		runTemp.dir = dir
+	})
+	return runTemp.dir, runTemp.err
+}
+
+// privateDir creates dir 0700 if needed and reports whether it is a
+// directory that only its owner can access.
+func privateDir(dir string) bool {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return false
+	}
+	info, err := os.Lstat(dir)
+	return err == nil && info.IsDir() && info.Mode().Perm()&0o077 == 0
+}
+
+// finishRunTempDir runs as the CLI exits: it prints this invocation's temp
+// directory once when files were left in it, and removes it when empty.
+func finishRunTempDir(w io.Writer) {
+	if runTemp.dir == "" {
+		return
+	}
+	os.Remove(filepath.Join(runTemp.dir, runTempLive))
+	entries, err := os.ReadDir(runTemp.dir)
+	if err != nil {
+		return
+	}
+	if len(entries) == 0 {
+		os.Remove(runTemp.dir)
+		return
+	}
+	fmt.Fprintf(w, "Temp files for this run: %s\n", runTemp.dir)
+}
+
+// removeRunTempDirs removes the run directories under root last modified
+// more than olderThan ago (all of them when olderThan is 0), except this
+// invocation's and those of invocations still running. It returns how many it removed and their total size.
+func removeRunTempDirs(root string, olderThan time.Duration) (removed int, size int64, err error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), runTempPrefix) {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if dir == runTemp.dir {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (olderThan > 0 && info.ModTime().After(cutoff)) {
+			continue
+		}
+		// Another invocation is still using it.
+		if live, err := os.Stat(filepath.Join(dir, runTempLive)); err == nil && time.Since(live.ModTime()) < runTempMaxAge {
+			continue
+		}
+		n := dirSize(dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, size, err
+		}
+		removed++
+		size += n
+	}
+	return removed, size, nil
+}
+
+// dirSize returns the total size of the files under dir.
+func dirSize(dir string) int64 {
+	var n int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				n += info.Size()
+			}
+		}
+		return nil
+	})
+	return n
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemoveRunTempDirs_KeepsRecentAndCurrent(t *testing.T) {
+	root := t.TempDir()
+	mkRun := func(name string, age time.Duration) string {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "image.png"), []byte("png"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(dir, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	old := mkRun("run-old", 10*24*time.Hour)
+	recent := mkRun("run-recent", time.Hour)
+	current := mkRun("run-current", 30*24*time.Hour)
+	other := mkRun("uploads", 30*24*time.Hour)
+
+	prev := runTemp.dir
+	runTemp.dir = current
+	t.Cleanup(func() { runTemp.dir = prev })
+
+	removed, size, err := removeRunTempDirs(root, runTempMaxAge)
+	if err != nil {
+		t.Fatalf("removeRunTempDirs: %v", err)
+	}
+	if removed != 1 || size != 3 {
+		t.Errorf("removed %d dirs of %d bytes, want 1 of 3", removed, size)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed", old)
+	}
+	for _, dir := range []string{recent, current, other} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s was removed", dir)
+		}
+	}
+
+	// Zero age removes every run directory but the current one.
+	if removed, _, _ := removeRunTempDirs(root, 0); removed != 1 {
+		t.Errorf("removed %d dirs, want 1", removed)
+	}
+	if _, err := os.Stat(recent); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed", recent)
+	}
+}
+
+func TestFinishRunTempDir(t *testing.T) {
+	prev := runTemp.dir
+	t.Cleanup(func() { runTemp.dir = prev })
+
+	runTemp.dir = t.TempDir()
+	var out bytes.Buffer
+	finishRunTempDir(&out)
+	if out.Len() != 0 {
+		t.Errorf("empty run dir printed %q", out.String())
+	}
+	if _, err := os.Stat(runTemp.dir); !os.IsNotExist(err) {
+		t.Error("empty run dir was not removed")
+	}
+
+	runTemp.dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(runTemp.dir, "render.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	finishRunTempDir(&out)
+	if !strings.Contains(out.String(), runTemp.dir) {
+		t.Errorf("output = %q, want the run dir", out.String())
+	}
+}
+
+func TestRemoveRunTempDirs_KeepsLiveRuns(t *testing.T) {
+	root := t.TempDir()
+	live := filepath.Join(root, "run-live")
+	done := filepath.Join(root, "run-done")
+	for _, dir := range []string{live, done} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(live, runTempLive), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// clean-temp's default age of 0 must not remove a running invocation's
+	// directory.
+	if removed, _, err := removeRunTempDirs(root, 0); err != nil || removed != 1 {
+		t.Fatalf("removed %d dirs, %v; want 1", removed, err)
+	}
+	if _, err := os.Stat(live); err != nil {
+		t.Errorf("live run dir was removed: %v", err)
+	}
+	if _, err := os.Stat(done); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed", done)
+	}
+}
+
+func TestPrivateDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "witan-test")
+	if !privateDir(dir) {
+		t.Fatalf("privateDir(%s) = false for a new directory", dir)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// A root another user created open to others is not used.
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if privateDir(dir) {
+		t.Errorf("privateDir accepted a 0755 directory")
+	}
+}
+
+func TestRunTempRoot_PerUser(t *testing.T) {
+	if uid := os.Getuid(); uid >= 0 && !strings.HasSuffix(runTempRoot(), fmt.Sprintf("witan-%d", uid)) {
+		t.Errorf("runTempRoot() = %s, want it scoped to uid %d", runTempRoot(), uid)
+	}
+}
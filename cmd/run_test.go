@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/client/clienttest"
+)
+
+// setupRunTest points the CLI at a mock API in stateful mode and returns it
+// with a temp dir holding book.xlsx.
+func setupRunTest(t *testing.T) (*clienttest.Server, string) {
+	t.Helper()
+	origAPIKey, origAPIURL, origStateless := apiKey, apiURL, stateless
+	origRunJSON, origOutput := runJSON, resultOutputPath
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless = origAPIKey, origAPIURL, origStateless
+		runJSON, resultOutputPath = origRunJSON, origOutput
+	})
+
+	srv := clienttest.NewServer(t)
+	mockMgmtOrgsServer(t)
+	apiKey = "test-key"
+	apiURL = srv.URL
+	stateless = false
+	runJSON = true
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "book.xlsx"), []byte("PK\x03\x04original"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	resultOutputPath = filepath.Join(dir, "report.json")
+	return srv, dir
+}
+
+func writePipeline(t *testing.T, dir, yaml string) string {
+	t.Helper()
+	path := filepath.Join(dir, "pipeline.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("writing pipeline: %v", err)
+	}
+	return path
+}
+
+func readRunReport(t *testing.T) runReport {
+	t.Helper()
+	data, err := os.ReadFile(resultOutputPath)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	var report runReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("parsing report: %v\n%s", err, data)
+	}
+	return report
+}
+
+func TestRunPipeline_SharesRevisionAcrossSteps(t *testing.T) {
+	srv, dir := setupRunTest(t)
+	saved := base64.StdEncoding.EncodeToString([]byte("PK\x03\x04saved"))
+	srv.Exec = func(wb clienttest.Workbook, req client.ExecRequest) (*client.ExecResponse, error) {
+		return &client.ExecResponse{Ok: true, Result: json.RawMessage(`{"rows":3}`), File: &saved}, nil
+	}
+	srv.Calc = func(wb clienttest.Workbook, params url.Values) (*client.CalcResponse, error) {
+		if string(wb.Content) != "PK\x03\x04saved" {
+			t.Errorf("calc ran on %q, want the saved revision", wb.Content)
+		}
+		return &client.CalcResponse{Touched: map[string]client.CalcTouchedCell{}, Errors: []client.CellError{}}, nil
+	}
+	pipelinePath := writePipeline(t, dir, `
+files: ["*.xlsx"]
+steps:
+  - step: exec
+    code: "return { rows: 3 }"
+    save: true
+    output: out/{name}.exec.json
+  - step: calc
+  - step: render
+    name: summary
+    range: Sheet1!A1:C3
+    output: out/{name}.png
+`)
+
+	if err := runPipeline(&cobra.Command{}, []string{pipelinePath}); err != nil {
+		t.Fatalf("runPipeline: %v", err)
+	}
+
+	report := readRunReport(t)
+	if !report.Ok || len(report.Files) != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	f := report.Files[0]
+	var statuses []string
+	for _, s := range f.Steps {
+		statuses = append(statuses, s.Name+"="+s.Status)
+	}
+	if got := strings.Join(statuses, " "); got != "exec=ok calc=ok summary=ok" {
+		t.Errorf("steps = %s", got)
+	}
+
+	uploads := 0
+	for _, r := range srv.Requests() {
+		if r.Method == "POST" && r.Path == "/files" {
+			uploads++
+		}
+		if strings.HasSuffix(r.Path, "/xlsx/render") && r.Query.Get("revision") != f.RevisionID {
+			t.Errorf("render used revision %s, want %s", r.Query.Get("revision"), f.RevisionID)
+		}
+	}
+	if uploads != 1 {
+		t.Errorf("uploaded %d times, want once", uploads)
+	}
+	if content, _ := srv.Content(f.FileID, f.RevisionID); string(content) != "PK\x03\x04saved" {
+		t.Errorf("report revision holds %q, want the saved workbook", content)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "book.xlsx")); err != nil || string(data) != "PK\x03\x04saved" {
+		t.Errorf("workbook after save = %q, %v", data, err)
+	}
+	for _, name := range []string{"book.exec.json", "book.png"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", name)); err != nil {
+			t.Errorf("missing output: %v", err)
+		}
+	}
+}
+
+func TestRunPipeline_StopsFileAfterFindings(t *testing.T) {
+	srv, dir := setupRunTest(t)
+	srv.Calc = func(wb clienttest.Workbook, params url.Values) (*client.CalcResponse, error) {
+		return &client.CalcResponse{Touched: map[string]client.CalcTouchedCell{}, Errors: []client.CellError{{Address: "Sheet1!A1", Code: "#DIV/0!"}}}, nil
+	}
+	srv.Lint = func(wb clienttest.Workbook, params url.Values) (*client.LintResponse, error) {
+		return nil, clienttest.Error(500, "internal", "lint unavailable")
+	}
+	pipelinePath := writePipeline(t, dir, `
+files: [book.xlsx]
+steps:
+  - step: calc
+    on_failure: continue
+  - step: lint
+  - step: read
+`)
+
+	err := runPipeline(&cobra.Command{}, []string{pipelinePath})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitFailure {
+		t.Fatalf("expected exit %d, got %v", ExitFailure, err)
+	}
+
+	steps := readRunReport(t).Files[0].Steps
+	want := []string{runStepFindings, runStepFailed, runStepSkipped}
+	for i, s := range steps {
+		if s.Status != want[i] {
+			t.Errorf("step %s: status %s, want %s", s.Name, s.Status, want[i])
+		}
+	}
+	if !strings.Contains(steps[1].Error, "lint unavailable") {
+		t.Errorf("lint error = %q", steps[1].Error)
+	}
+}
+
+func TestLoadPipeline_Validates(t *testing.T) {
+	for _, tc := range []struct {
+		name, yaml, want string
+	}{
+		{"no steps", "files: [a.xlsx]\n", "no steps"},
+		{"unknown kind", "steps:\n  - step: publish\n", `"publish" is not read`},
+		{"unknown field", "steps:\n  - step: read\n    pages: 1\n", "field pages not found"},
+		{"field of another kind", "steps:\n  - step: lint\n    save: true\n", "save does not apply to a lint step"},
+		{"exec without code", "steps:\n  - step: exec\n", "exactly one of code or script"},
+		{"render without range", "steps:\n  - step: render\n", "render needs a range"},
+		{"bad policy", "on_failure: retry\nsteps:\n  - step: read\n", `"retry" is not stop or continue`},
+		{"bad fail_on", "steps:\n  - step: lint\n    fail_on: fatal\n", "fail_on"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadPipeline(writePipeline(t, t.TempDir(), tc.yaml))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/witanlabs/witan-cli/config"
+)
+
+// sessionJWTRefreshMargin is how long before expiry a cached session JWT is
+// exchanged again, so it does not expire mid-command.
+const sessionJWTRefreshMargin = 2 * time.Minute
+
+// sessionAuth is set when resolveAuth returned a JWT exchanged for the saved
+// session. Clients built with it re-exchange the session when the API
+// rejects the JWT with a 401, so an expired or revoked JWT does not fail a
+// long-running command.
+var sessionAuth bool
+
+// sessionJWT returns a JWT for cfg's session token: the cached one while it
+// is not near expiry, otherwise a fresh exchange, which is cached in the
+// config for later commands.
+func sessionJWT(cfg config.Config) (string, error) {
+	if c := cfg.CachedSessionJWT(); c != nil && time.Now().Add(sessionJWTRefreshMargin).Before(c.ExpiresAt) {
+		return c.Token, nil
+	}
+	jwt, err := exchangeSessionForJWT(resolveManagementAPIURL(), cfg.SessionToken)
+	if err != nil {
+		return "", err
+	}
+	cacheSessionJWT(cfg, jwt)
+	return jwt, nil
+}
+
+// refreshSessionJWT exchanges the saved session token for a new JWT,
+// bypassing the cache; clients call it when the API rejects the JWT.
+func refreshSessionJWT() (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	jwt, err := exchangeSessionForJWT(resolveManagementAPIURL(), cfg.SessionToken)
+	if err != nil {
+		return "", err
+	}
+	cacheSessionJWT(cfg, jwt)
+	return jwt, nil
+}
+
+// cacheSessionJWT saves jwt for cfg's session best-effort. A JWT without a
+// readable expiry is not cached, since there is no telling when to refresh it.
+func cacheSessionJWT(cfg config.Config, jwt string) {
+	expiresAt, ok := jwtExpiry(jwt)
+	if !ok || cfg.SessionToken == "" {
+		return
+	}
+	cfg.SetSessionJWT(jwt, expiresAt)
+	_ = config.Save(cfg)
+}
+
+// jwtExpiry returns the "exp" claim of a JWT. The signature is not checked;
+// the API does that, and the expiry only decides when to refresh.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp json.Number `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == "" {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil || exp <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
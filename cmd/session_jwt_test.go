@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/witanlabs/witan-cli/config"
+)
+
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"user_1","exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJIUzI1NiJ9." + payload + ".sig"
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Unix(1790000000, 0)
+	if got, ok := jwtExpiry(testJWT(exp)); !ok || !got.Equal(exp) {
+		t.Fatalf("jwtExpiry = %v, %v; want %v", got, ok, exp)
+	}
+	for _, token := range []string{"", "opaque-token", "a.!!!.c", "a." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + ".c"} {
+		if _, ok := jwtExpiry(token); ok {
+			t.Errorf("jwtExpiry(%q) ok, want false", token)
+		}
+	}
+}
+
+func TestResolveAuth_CachesSessionJWTUntilNearExpiry(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origSessionAuth := sessionAuth
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		sessionAuth = origSessionAuth
+	})
+	apiKey = ""
+	apiURL = ""
+	stateless = false
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	t.Setenv("WITAN_API_KEY", "")
+	t.Setenv("WITAN_STATELESS", "")
+
+	if err := config.Save(config.Config{SessionToken: "session", SessionOrgID: "org_1"}); err != nil {
+		t.Fatalf("saving config: %v", err)
+	}
+
+	exchanges := 0
+	next := testJWT(time.Now().Add(time.Hour))
+	mgmtServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		fmt.Fprintf(w, `{"token":%q}`, next)
+	}))
+	defer mgmtServer.Close()
+	t.Setenv("WITAN_MANAGEMENT_API_URL", mgmtServer.URL)
+
+	first := next
+	for i := 0; i < 2; i++ {
+		key, orgID, err := resolveAuth()
+		if err != nil || key != first || orgID != "org_1" {
+			t.Fatalf("resolveAuth = %q, %q, %v", key, orgID, err)
+		}
+	}
+	if exchanges != 1 || !sessionAuth {
+		t.Fatalf("exchanges=%d sessionAuth=%v, want the second call served from the cache", exchanges, sessionAuth)
+	}
+
+	// A JWT close to expiry is exchanged again.
+	cfg, _ := config.Load()
+	cfg.SetSessionJWT(first, time.Now().Add(time.Minute))
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	next = testJWT(time.Now().Add(2 * time.Hour))
+	if key, _, err := resolveAuth(); err != nil || key != next || exchanges != 2 {
+		t.Fatalf("near expiry: key fresh=%v exchanges=%d err=%v", key == next, exchanges, err)
+	}
+
+	// A new login invalidates the cached JWT.
+	cfg, _ = config.Load()
+	cfg.SessionToken = "other-session"
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := resolveAuth(); err != nil || exchanges != 3 {
+		t.Fatalf("new session: exchanges=%d err=%v", exchanges, err)
+	}
+}
+
+func TestNewAPIClient_RefreshesRevokedCachedJWT(t *testing.T) {
+	origAPIURL := apiURL
+	origStateless := stateless
+	origSessionAuth := sessionAuth
+	t.Cleanup(func() {
+		apiURL = origAPIURL
+		stateless = origStateless
+		sessionAuth = origSessionAuth
+	})
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	revoked := testJWT(time.Now().Add(time.Hour))
+	fresh := testJWT(time.Now().Add(2 * time.Hour))
+	if err := config.Save(config.Config{SessionToken: "session", SessionOrgID: "org_1"}); err != nil {
+		t.Fatal(err)
+	}
+	mgmtServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"token":%q}`, fresh)
+	}))
+	defer mgmtServer.Close()
+	t.Setenv("WITAN_MANAGEMENT_API_URL", mgmtServer.URL)
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+fresh {
+			http.Error(w, `{"error":{"code":"unauthorized","message":"token revoked"}}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"libraries":[]}`)
+	}))
+	defer apiServer.Close()
+	apiURL = apiServer.URL
+	stateless = true
+	sessionAuth = true
+
+	c := newAPIClient(revoked, "org_1")
+	if _, err := c.ExecLibraries(); err != nil {
+		t.Fatalf("request with revoked cached JWT: %v", err)
+	}
+	cfg, _ := config.Load()
+	if c := cfg.CachedSessionJWT(); c == nil || c.Token != fresh {
+		t.Fatalf("expected the refreshed JWT to be cached, got %+v", c)
+	}
+}
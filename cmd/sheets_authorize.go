@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"time"
@@ -73,7 +72,7 @@ func runSheetsAuthorize(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := newHTTPClient(30 * time.Second)
 	spreadsheet := client.ExtractSpreadsheetID(ref)
 
 	// Idempotent short-circuit: skip the picker if already authorized.
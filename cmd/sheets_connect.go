@@ -83,7 +83,7 @@ func runSheetsConnect(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := newHTTPClient(30 * time.Second)
 
 	// Check if already connected
 	status, err := getGoogleSheetsIntegrationStatus(httpClient, auth.MgmtURL, auth.JWT)
@@ -31,6 +31,7 @@ Examples:
 func init() {
 	sheetsCreateCmd.SilenceUsage = true
 	sheetsCreateCmd.Flags().StringVar(&sheetsCreateTitle, "title", "", "Title for the new spreadsheet (max 1000 characters)")
+	addResultOutputFlag(sheetsCreateCmd)
 	gsheetsCmd.AddCommand(sheetsCreateCmd)
 }
 
@@ -56,7 +57,7 @@ func runSheetsCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		// Created sheets auto-authorize, so needs_file_authorization can't occur
 		// here, but google_auth_required (not connected) can — surface it as the
-		// structured/exit-code-3 result agents branch on, like the other ops.
+		// structured/exit-code-4 result agents branch on, like the other ops.
 		return handleSheetsOpError(err, "", gsheetsJSONOutput)
 	}
 
@@ -67,18 +68,15 @@ func runSheetsCreate(cmd *cobra.Command, args []string) error {
 		GSURL:         "gs://" + result.SpreadsheetID,
 	}
 
-	if gsheetsJSONOutput {
-		return jsonPrint(output)
-	}
-
-	if output.Title != "" {
-		fmt.Fprintf(os.Stderr, "Created new Google Sheet: %s\n", output.Title)
-	} else {
-		fmt.Fprintln(os.Stderr, "Created new Google Sheet:")
-	}
-	fmt.Println(output.URL)
-	fmt.Fprintf(os.Stderr, "\nUse with gsheets commands:\n")
-	fmt.Fprintf(os.Stderr, "  witan gsheets exec %s --expr '...'\n", output.GSURL)
-
-	return nil
+	return emitResult(output, gsheetsJSONOutput, func() error {
+		if output.Title != "" {
+			fmt.Fprintf(os.Stderr, "Created new Google Sheet: %s\n", output.Title)
+		} else {
+			fmt.Fprintln(os.Stderr, "Created new Google Sheet:")
+		}
+		fmt.Println(output.URL)
+		fmt.Fprintf(os.Stderr, "\nUse with gsheets commands:\n")
+		fmt.Fprintf(os.Stderr, "  witan gsheets exec %s --expr '...'\n", output.GSURL)
+		return nil
+	})
 }
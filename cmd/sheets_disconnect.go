@@ -37,7 +37,7 @@ func runSheetsDisconnect(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := newHTTPClient(30 * time.Second)
 
 	req, err := http.NewRequest("DELETE", auth.MgmtURL+"/v0/integrations/google-sheets", nil)
 	if err != nil {
@@ -99,6 +99,8 @@ func init() {
 	sheetsExecCmd.Flags().IntVar(&sheetsExecTimeoutMS, "timeout-ms", 0, "Execution timeout in milliseconds (> 0)")
 	sheetsExecCmd.Flags().IntVar(&sheetsExecMaxOutputChars, "max-output-chars", 0, "Maximum stdout characters to capture (> 0)")
 	sheetsExecCmd.Flags().BoolVar(&sheetsExecCreate, "create", false, "Create a new Google Sheet instead of opening an existing one")
+	addImageModeFlag(sheetsExecCmd)
+	addResultOutputFlag(sheetsExecCmd)
 	gsheetsCmd.AddCommand(sheetsExecCmd)
 }
 
@@ -115,6 +117,9 @@ func runSheetsExec(cmd *cobra.Command, args []string) error {
 	if err := validateSheetsTitle(sheetsExecTitle, create); err != nil {
 		return err
 	}
+	if err := validateImageMode(); err != nil {
+		return err
+	}
 	if sheetsExecTitle != "" && !create {
 		return fmt.Errorf("--title can only be used with --create or spreadsheet reference new")
 	}
@@ -164,16 +169,18 @@ func runSheetsExec(cmd *cobra.Command, args []string) error {
 	var result *client.ExecResponse
 	var spreadsheetID string
 	if create {
+		emitEvent("exec_started", nil)
 		result, err = auth.Client.GSheetsExecCreate(req)
 	} else {
 		spreadsheetID = client.ExtractSpreadsheetID(args[0])
+		emitEvent("exec_started", map[string]any{"spreadsheet": spreadsheetID})
 		result, err = auth.Client.GSheetsExec(spreadsheetID, req)
 	}
 	if err != nil {
 		return handleSheetsOpError(err, spreadsheetID, gsheetsJSONOutput)
 	}
 
-	if err := outputExecResult(result, gsheetsJSONOutput, formatSheetsExecError); err != nil {
+	if err := outputExecResult(result, spreadsheetID, gsheetsJSONOutput, formatSheetsExecError); err != nil {
 		return err
 	}
 
@@ -47,6 +47,7 @@ func init() {
 	sheetsLintCmd.Flags().StringArrayVarP(&sheetsLintRanges, "range", "r", nil, `Sheet-qualified range to lint (repeatable)`)
 	sheetsLintCmd.Flags().StringArrayVarP(&sheetsLintSkipRule, "skip-rule", "s", nil, `Rule ID to skip (repeatable)`)
 	sheetsLintCmd.Flags().StringArrayVar(&sheetsLintOnlyRule, "only-rule", nil, `Run only these rule IDs (repeatable)`)
+	addResultOutputFlag(sheetsLintCmd)
 	gsheetsCmd.AddCommand(sheetsLintCmd)
 }
 
@@ -80,5 +81,5 @@ func runSheetsLint(cmd *cobra.Command, args []string) error {
 		return handleSheetsOpError(err, spreadsheetID, gsheetsJSONOutput)
 	}
 
-	return outputLintResult(result, gsheetsJSONOutput)
+	return outputLintResult(result, spreadsheetID, gsheetsJSONOutput, defaultLintFailOn)
 }
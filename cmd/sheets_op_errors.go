@@ -20,7 +20,7 @@ type sheetsOpAuthError struct {
 }
 
 // emitSheetsAuthError prints an authorization error (JSON or human) and returns
-// an exit-code-3 ExitError so agents can branch deterministically without
+// an ExitAuth ExitError so agents can branch deterministically without
 // parsing messages.
 func emitSheetsAuthError(code, spreadsheetID, message string, jsonOut bool) error {
 	ref := "<spreadsheet>"
@@ -58,7 +58,7 @@ func emitSheetsAuthError(code, spreadsheetID, message string, jsonOut bool) erro
 }
 
 // handleSheetsOpError converts an authorization failure from a REST sheet
-// operation (exec/lint/render) into agent-friendly output and exit code 3.
+// operation (exec/lint/render) into agent-friendly output and exit code 4.
 // Any other error passes through unchanged.
 func handleSheetsOpError(err error, spreadsheetID string, jsonOut bool) error {
 	if err == nil {
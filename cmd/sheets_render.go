@@ -10,11 +10,14 @@ import (
 )
 
 var (
-	sheetsRenderRange  string
-	sheetsRenderDPR    int
-	sheetsRenderFormat string
-	sheetsRenderOutput string
-	sheetsRenderDiff   string
+	sheetsRenderRange      string
+	sheetsRenderDPR        int
+	sheetsRenderFormat     string
+	sheetsRenderOutput     string
+	sheetsRenderDiff       string
+	sheetsRenderDiffReport string
+	sheetsRenderOpen       bool
+	sheetsRenderView       renderViewOptions
 )
 
 var sheetsRenderCmd = &cobra.Command{
@@ -33,13 +36,20 @@ Behavior:
   - --dpr must be 1-3; default is auto.
   - If --output is omitted, the image is written to a temporary file.
   - --diff compares against a baseline PNG and writes a highlighted PNG diff.
+  - --diff-report writes a JSON report alongside --diff that lists changed
+    pixel regions and the cells they likely cover. Cells are estimated from a
+    uniform grid, so treat them as a guide when column widths vary.
+  - --show-gridlines, --show-headers, --zoom, and --theme control the view;
+    unset flags keep the API defaults.
   - Large images (>1568 px in either dimension) may be downscaled by vision models.
 
 Examples:
   witan gsheets render gs://SPREADSHEET_ID -r "Sheet1!A1:Z50"
   witan gsheets render "https://docs.google.com/spreadsheets/d/ID/edit" -r "'My Sheet'!B5:H20" --dpr 2
   witan gsheets render gs://ID -r "Sheet1!A1:F10" -o before.png
-  witan gsheets render gs://ID -r "Sheet1!A1:F10" --diff before.png`,
+  witan gsheets render gs://ID -r "Sheet1!A1:F10" --diff before.png
+  witan gsheets render gs://ID -r "Sheet1!A1:F10" --open
+  witan gsheets render gs://ID -r "Sheet1!A1:F10" --show-gridlines --zoom 1.5`,
 	Args: cobra.ExactArgs(1),
 	RunE: runSheetsRender,
 }
@@ -51,6 +61,9 @@ func init() {
 	sheetsRenderCmd.Flags().StringVar(&sheetsRenderFormat, "format", "png", "Output image format: png or webp")
 	sheetsRenderCmd.Flags().StringVarP(&sheetsRenderOutput, "output", "o", "", "Write image to this path (default: temporary file)")
 	sheetsRenderCmd.Flags().StringVar(&sheetsRenderDiff, "diff", "", "Compare against baseline PNG and write highlighted diff image")
+	sheetsRenderCmd.Flags().StringVar(&sheetsRenderDiffReport, "diff-report", "", "With --diff, write a JSON report of changed regions and estimated cells to this path")
+	addRenderViewFlags(sheetsRenderCmd, &sheetsRenderView)
+	sheetsRenderCmd.Flags().BoolVar(&sheetsRenderOpen, "open", false, "Open the rendered file in the default viewer")
 	gsheetsCmd.AddCommand(sheetsRenderCmd)
 }
 
@@ -66,6 +79,10 @@ func runSheetsRender(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--format must be 'png' or 'webp', got %q", sheetsRenderFormat)
 	}
 
+	if sheetsRenderDiffReport != "" && sheetsRenderDiff == "" {
+		return fmt.Errorf("--diff-report requires --diff")
+	}
+
 	// Require --range
 	if sheetsRenderRange == "" {
 		return fmt.Errorf("--range is required (e.g. -r \"Sheet1!A1:Z50\" or \"'My Sheet'!A1:Z50\")")
@@ -95,6 +112,9 @@ func runSheetsRender(cmd *cobra.Command, args []string) error {
 		"dpr":     strconv.Itoa(dpr),
 		"format":  sheetsRenderFormat,
 	}
+	if err := applyRenderViewFlags(cmd, &sheetsRenderView, params); err != nil {
+		return err
+	}
 
 	imageBytes, contentType, err := auth.Client.GSheetsRender(spreadsheetID, params)
 	if err != nil {
@@ -105,10 +125,16 @@ func runSheetsRender(cmd *cobra.Command, args []string) error {
 	var diffSummary string
 	if sheetsRenderDiff != "" {
 		var err error
-		imageBytes, diffSummary, err = runRenderDiffPipeline(sheetsRenderFormat, sheetsRenderDiff, imageBytes)
+		var report *diffReport
+		imageBytes, diffSummary, report, err = runRenderDiffPipeline(sheetsRenderFormat, sheetsRenderDiff, imageBytes, sheetsRenderDiffReport != "")
 		if err != nil {
 			return err
 		}
+		if report != nil {
+			if err := writeRenderDiffReport(sheetsRenderDiffReport, report, address, dpr); err != nil {
+				return err
+			}
+		}
 		contentType = "image/png"
 	}
 
@@ -123,10 +149,13 @@ func runSheetsRender(cmd *cobra.Command, args []string) error {
 	pixelWidth, pixelHeight := 0, 0
 	if sheet, sr, sc, er, ec, parseErr := internal.ParseRange(address); parseErr == nil {
 		rangeStr = internal.FormatAddress(sheet, sr, sc, er, ec)
-		pixelWidth, pixelHeight = estimatePixels(address, dpr)
+		pixelWidth, pixelHeight = sheetsRenderView.scalePixels(estimatePixels(address, dpr))
 	}
 
 	printRenderResult(outPath, rangeStr, pixelWidth, pixelHeight, dpr, diffSummary)
+	if sheetsRenderOpen {
+		openRenderedOutput(outPath)
+	}
 	return nil
 }
 
@@ -156,7 +156,7 @@ func openSheetsRPCSession(ctx context.Context, c *client.Client, params sheetsRP
 		return nil, err
 	}
 
-	conn, err := dialRPCWebSocket(ctx, wsURL, c.APIKey, cliUserAgent())
+	conn, err := dialRPCWebSocket(ctx, wsURL, c.BearerToken(), cliUserAgent())
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +223,7 @@ func openSheetsRPCSession(ctx context.Context, c *client.Client, params sheetsRP
 }
 
 // sheetsRPCInitFailure handles a failed RPC init frame. Authorization-related
-// codes are surfaced as agent-friendly output plus an exit-code-3 ExitError;
+// codes are surfaced as agent-friendly output plus an ExitAuth ExitError;
 // the spreadsheet id is the one we sent in init (the server no longer echoes
 // it). Branch on the frame code, not the WebSocket close code. Other failures
 // fall back to the formatted message error.
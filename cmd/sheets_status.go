@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
 	"time"
@@ -46,6 +45,7 @@ Examples:
 func init() {
 	sheetsStatusCmd.SilenceUsage = true
 	sheetsStatusCmd.Flags().BoolVar(&sheetsStatusWait, "wait", false, "Poll until connected/authorized")
+	addResultOutputFlag(sheetsStatusCmd)
 	gsheetsCmd.AddCommand(sheetsStatusCmd)
 }
 
@@ -75,12 +75,7 @@ func runSheetsConnectionStatus(cmd *cobra.Command) error {
 
 	report := inspectSheetsStatus()
 
-	if gsheetsJSONOutput {
-		return jsonPrintTo(cmd.OutOrStdout(), report)
-	}
-
-	printSheetsStatus(cmd, report)
-	return nil
+	return emitSheetsStatus(cmd, report)
 }
 
 func waitForConnection(cmd *cobra.Command) error {
@@ -88,7 +83,7 @@ func waitForConnection(cmd *cobra.Command) error {
 	if err != nil {
 		return err
 	}
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := newHTTPClient(30 * time.Second)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
@@ -113,12 +108,14 @@ func waitForConnection(cmd *cobra.Command) error {
 
 	// Emit the same shape as `status` without --wait, so agents that poll and
 	// check `status == "connected"` (per the skill docs) work on both paths.
-	report := inspectSheetsStatus()
-	if gsheetsJSONOutput {
-		return jsonPrintTo(cmd.OutOrStdout(), report)
-	}
-	printSheetsStatus(cmd, report)
-	return nil
+	return emitSheetsStatus(cmd, inspectSheetsStatus())
+}
+
+func emitSheetsStatus(cmd *cobra.Command, report sheetsStatusReport) error {
+	return emitResultTo(cmd.OutOrStdout(), report, report, gsheetsJSONOutput, func() error {
+		printSheetsStatus(cmd, report)
+		return nil
+	})
 }
 
 func runSheetStatusForSheet(cmd *cobra.Command, ref string) error {
@@ -133,7 +130,7 @@ func runSheetStatusForSheet(cmd *cobra.Command, ref string) error {
 	if err != nil {
 		return err
 	}
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := newHTTPClient(30 * time.Second)
 	spreadsheet := client.ExtractSpreadsheetID(ref)
 
 	check := func() (bool, error) {
@@ -169,18 +166,17 @@ func runSheetStatusForSheet(cmd *cobra.Command, ref string) error {
 		}
 	}
 
-	if gsheetsJSONOutput {
-		return jsonPrint(sheetStatusOutput{Authorized: authorized})
-	}
-
 	out := cmd.OutOrStdout()
-	if authorized {
-		fmt.Fprintln(out, "Sheet: authorized")
+	result := sheetStatusOutput{Authorized: authorized}
+	return emitResultTo(out, result, result, gsheetsJSONOutput, func() error {
+		if authorized {
+			fmt.Fprintln(out, "Sheet: authorized")
+			return nil
+		}
+		fmt.Fprintln(out, "Sheet: not authorized")
+		fmt.Fprintf(os.Stderr, "Hint: run 'witan gsheets authorize %s'\n", ref)
 		return nil
-	}
-	fmt.Fprintln(out, "Sheet: not authorized")
-	fmt.Fprintf(os.Stderr, "Hint: run 'witan gsheets authorize %s'\n", ref)
-	return nil
+	})
 }
 
 func inspectSheetsStatus() sheetsStatusReport {
@@ -222,7 +218,7 @@ func inspectSheetsStatus() sheetsStatusReport {
 		}
 	}
 
-	httpClient := &http.Client{Timeout: 10 * time.Second}
+	httpClient := newHTTPClient(10 * time.Second)
 
 	integration, err := getGoogleSheetsIntegrationStatus(httpClient, mgmtURL, jwt)
 	if err != nil {
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// stdioPath as a workbook argument reads the workbook from stdin; as a
+// --save-to target it writes the resulting workbook to stdout.
+const stdioPath = "-"
+
+// readStdinWorkbook copies a workbook from r into a temp file so it can be
+// uploaded like a local file. The extension follows the detected format.
+// The caller must call cleanup.
+func readStdinWorkbook(r io.Reader) (path string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "witan-stdin-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("buffering stdin workbook: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	path = filepath.Join(dir, "stdin.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("buffering stdin workbook: %w", err)
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("reading workbook from stdin: %w", err)
+	}
+	if n == 0 {
+		cleanup()
+		return "", nil, fmt.Errorf("no workbook on stdin")
+	}
+
+	if format, _ := detectExcelFormat(path); format == excelFormatOLE2 {
+		xlsPath := filepath.Join(dir, "stdin.xls")
+		if err := os.Rename(path, xlsPath); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("buffering stdin workbook: %w", err)
+		}
+		path = xlsPath
+	}
+	return path, cleanup, nil
+}
+
+// workbookToStdout reserves stdout for workbook bytes: the human summary is
+// suppressed, and a JSON result needs -o so it does not mix with them.
+func workbookToStdout(useJSON bool) error {
+	if useJSON && resultOutputPath == "" {
+		return fmt.Errorf("the workbook is written to stdout; use -o FILE for the JSON result")
+	}
+	quietOutput = true
+	return nil
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// Telemetry is off unless the telemetry setting is on. Events hold only the
+// command path, duration, result class, CLI version, and platform: no
+// arguments, file names, error text, or credentials. They are spooled to
+// the user cache dir and sent in the background by a later command, so
+// reporting never adds a request to the command being measured.
+const (
+	telemetrySpoolLimit = 256 << 10
+	telemetryFlushWait  = 300 * time.Millisecond
+	telemetryOrphanAge  = time.Minute
+	telemetryMaxAge     = 7 * 24 * time.Hour
+)
+
+var (
+	noTelemetry bool
+
+	// settingsTelemetry is the telemetry setting, applied in applySettings.
+	settingsTelemetry bool
+
+	// telemetryFlushed is closed when the background send started in
+	// PersistentPreRunE finishes; nil when none was started.
+	telemetryFlushed chan struct{}
+)
+
+// telemetryEvent is one command run.
+type telemetryEvent struct {
+	Command    string `json:"command"`
+	DurationMS int64  `json:"duration_ms"`
+	Result     string `json:"result"`
+	CLIVersion string `json:"cli_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+}
+
+// resolveTelemetry reports whether usage events are recorded: only when the
+// telemetry setting is on and neither --no-telemetry, WITAN_NO_TELEMETRY=1,
+// nor DO_NOT_TRACK=1 is set. Dry runs and recorded or replayed runs
+// record nothing.
+func resolveTelemetry() bool {
+	if noTelemetry || !settingsTelemetry || dryRun != "" || resolveRecordPath() != "" || resolveReplayPath() != "" {
+		return false
+	}
+	for _, env := range []string{"WITAN_NO_TELEMETRY", "DO_NOT_TRACK"} {
+		if v := os.Getenv(env); v == "1" || v == "true" {
+			return false
+		}
+	}
+	return true
+}
+
+func telemetryDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "witan", "telemetry"), nil
+}
+
+// resultClass classifies a command's outcome without its message, for
+// telemetry and --ndjson error events.
+func resultClass(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Category()
+	}
+	var apiErr *client.APIError
+	switch {
+	case errors.Is(err, client.ErrOffline):
+		return "offline"
+	case errors.Is(err, client.ErrUnauthorized):
+		return "auth"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+		return "rate_limited"
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("api_%dxx", apiErr.StatusCode/100)
+	}
+	return "failure"
+}
+
+// recordTelemetry appends an event for cmd to the spool.
+func recordTelemetry(cmd *cobra.Command, start time.Time, err error) {
+	if cmd == nil || !resolveTelemetry() {
+		return
+	}
+	dir, dirErr := telemetryDir()
+	if dirErr != nil || os.MkdirAll(dir, 0o700) != nil {
+		return
+	}
+	spool := filepath.Join(dir, "events.jsonl")
+	if info, statErr := os.Stat(spool); statErr == nil && info.Size() > telemetrySpoolLimit {
+		return
+	}
+	line, _ := json.Marshal(telemetryEvent{
+		Command:    cmd.CommandPath(),
+		DurationMS: time.Since(start).Milliseconds(),
+		Result:     resultClass(err),
+		CLIVersion: strings.TrimSpace(Version),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+	})
+	f, openErr := os.OpenFile(spool, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if openErr != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(line, '\n'))
+}
+
+// startTelemetryFlush sends spooled events in the background.
+func startTelemetryFlush() {
+	if !resolveTelemetry() || resolveOffline() {
+		return
+	}
+	done := make(chan struct{})
+	telemetryFlushed = done
+	go func() {
+		defer close(done)
+		flushTelemetry(resolveAPIURL())
+	}()
+}
+
+// waitTelemetryFlush gives an unfinished background send a moment before the
+// process exits. Batches it does not finish are picked up by a later run.
+func waitTelemetryFlush() {
+	if telemetryFlushed == nil {
+		return
+	}
+	select {
+	case <-telemetryFlushed:
+	case <-time.After(telemetryFlushWait):
+	}
+}
+
+// flushTelemetry claims the spool by renaming it, so concurrent runs do not
+// send the same events, then posts each claimed batch, including batches an
+// earlier run claimed but did not finish.
+func flushTelemetry(apiBase string) {
+	dir, err := telemetryDir()
+	if err != nil {
+		return
+	}
+	claimed := filepath.Join(dir, fmt.Sprintf("batch-%d-%d.sending", os.Getpid(), time.Now().UnixNano()))
+	_ = os.Rename(filepath.Join(dir, "events.jsonl"), claimed)
+
+	batches, _ := filepath.Glob(filepath.Join(dir, "batch-*.sending"))
+	for _, batch := range batches {
+		info, err := os.Stat(batch)
+		if err != nil {
+			continue
+		}
+		age := time.Since(info.ModTime())
+		if age > telemetryMaxAge {
+			os.Remove(batch)
+			continue
+		}
+		if batch != claimed && age < telemetryOrphanAge {
+			continue // another run is sending it
+		}
+		if sendTelemetryBatch(apiBase, batch) == nil {
+			os.Remove(batch)
+		}
+	}
+}
+
+func sendTelemetryBatch(apiBase, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	events := []json.RawMessage{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); json.Valid(line) {
+			events = append(events, append(json.RawMessage(nil), line...))
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(map[string]any{"events": events})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", strings.TrimRight(apiBase, "/")+"/v0/telemetry", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	setCLIUserAgent(req)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := newHTTPClient(5 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestResolveTelemetry_OffByDefault(t *testing.T) {
+	origSetting := settingsTelemetry
+	origFlag := noTelemetry
+	t.Cleanup(func() {
+		settingsTelemetry = origSetting
+		noTelemetry = origFlag
+	})
+	t.Setenv("WITAN_NO_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "")
+
+	settingsTelemetry = false
+	noTelemetry = false
+	if resolveTelemetry() {
+		t.Fatal("telemetry must be off unless the setting is on")
+	}
+	settingsTelemetry = true
+	if !resolveTelemetry() {
+		t.Fatal("expected telemetry on with the setting")
+	}
+	noTelemetry = true
+	if resolveTelemetry() {
+		t.Fatal("--no-telemetry should turn telemetry off")
+	}
+	noTelemetry = false
+	t.Setenv("DO_NOT_TRACK", "1")
+	if resolveTelemetry() {
+		t.Fatal("DO_NOT_TRACK=1 should turn telemetry off")
+	}
+}
+
+func TestTelemetry_RecordAndFlush(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WITAN_NO_TELEMETRY", "")
+	t.Setenv("DO_NOT_TRACK", "")
+	origSetting := settingsTelemetry
+	origFlag := noTelemetry
+	t.Cleanup(func() {
+		settingsTelemetry = origSetting
+		noTelemetry = origFlag
+	})
+	settingsTelemetry = true
+	noTelemetry = false
+
+	var got struct {
+		Events []telemetryEvent `json:"events"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/telemetry" || r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected request %s (auth %q)", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	root := &cobra.Command{Use: "witan"}
+	calc := &cobra.Command{Use: "calc"}
+	root.AddCommand(calc)
+	recordTelemetry(calc, time.Now().Add(-1500*time.Millisecond), nil)
+	recordTelemetry(calc, time.Now(), &client.APIError{StatusCode: 503})
+	recordTelemetry(calc, time.Now(), errors.New("open /home/me/secret.xlsx: no such file"))
+
+	flushTelemetry(srv.URL)
+
+	if len(got.Events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(got.Events), got.Events)
+	}
+	first := got.Events[0]
+	if first.Command != "witan calc" || first.Result != "ok" || first.DurationMS < 1500 {
+		t.Fatalf("unexpected first event: %+v", first)
+	}
+	if got.Events[1].Result != "api_5xx" || got.Events[2].Result != "failure" {
+		t.Fatalf("unexpected result classes: %+v", got.Events)
+	}
+
+	dir, _ := telemetryDir()
+	left, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(left) != 0 {
+		t.Fatalf("expected the spool to be cleared after a successful send, found %v", left)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events.jsonl")); !os.IsNotExist(err) {
+		t.Fatalf("spool still present: %v", err)
+	}
+}
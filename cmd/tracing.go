@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const tracingShutdownTimeout = 5 * time.Second
+
+// tracingEnabled reports whether the standard OTEL_* env vars ask for trace
+// export. Tracing stays off (and costs nothing) unless an OTLP endpoint is
+// configured or OTEL_TRACES_EXPORTER=otlp is set explicitly.
+func tracingEnabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER"))) {
+	case "none":
+		return false
+	case "otlp":
+		return true
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing installs a global OTLP/HTTP tracer provider when tracing is
+// enabled. The exporter, sampler, batching, and resource all read their
+// standard OTEL_* env vars. The returned func flushes pending spans; it is
+// safe to call when tracing is off. Setup failures are reported to stderr
+// and never fail the command.
+func setupTracing(stderr io.Writer) func() {
+	if !tracingEnabled() {
+		return func() {}
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "witan: tracing disabled: %v\n", err)
+		return func() {}
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", "witan-cli"),
+			attribute.String("service.version", strings.TrimSpace(Version)),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		// Partial resources are still usable; keep going with what we have.
+		fmt.Fprintf(stderr, "witan: tracing resource: %v\n", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			fmt.Fprintf(stderr, "witan: flushing traces: %v\n", err)
+		}
+	}
+}
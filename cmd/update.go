@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	updateDownloadTimeout = 5 * time.Minute
+	updateChecksumsAsset  = "witan-checksums.txt"
+	// updateRepo is the only repository witan update installs from; the
+	// checksums come from the same release, so they vouch for nothing if
+	// the repository can be redirected.
+	updateRepo = "witanlabs/witan-cli"
+)
+
+var (
+	updateCheck bool
+	updateTo    string
+	updateForce bool
+
+	// Swapped in tests.
+	updateReleaseHost = "https://github.com"
+	updateExecutable  = os.Executable
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update witan to the latest release",
+	Long: `Download the latest witan release for this platform and replace the
+running binary.
+
+Behavior:
+  - The archive is checked against the release's witan-checksums.txt
+    (SHA-256) before anything is replaced, and the binary is swapped in
+    with a rename, so an interrupted update leaves the old one in place.
+  - --check only compares versions: it prints both and exits 2 when a newer
+    release exists, 0 when up to date. Development builds always count as
+    out of date.
+  - --to installs a specific release tag instead of the latest (e.g. to
+    roll back).
+  - Installs managed by pip or npm are not replaced; update them with their
+    package manager instead.
+
+Examples:
+  witan update
+  witan update --check
+  witan update --to v1.4.2`,
+	Args: cobra.NoArgs,
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().BoolVar(&updateCheck, "check", false, "Only report whether a newer release exists (exit 2 if so)")
+	updateCmd.Flags().StringVar(&updateTo, "to", "", "Release tag to install instead of the latest (e.g. v1.4.2)")
+	updateCmd.Flags().BoolVar(&updateForce, "force", false, "Reinstall even when already on the target version")
+	rootCmd.AddCommand(updateCmd)
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if resolveOffline() {
+		fmt.Fprintln(os.Stderr, "witan update needs the network; --offline is set")
+		return &ExitError{Code: ExitOffline}
+	}
+
+	repo := updateRepo
+	tag := updateTo
+	if tag == "" {
+		latest, err := latestReleaseTag(repo)
+		if err != nil {
+			return err
+		}
+		tag = latest
+	} else if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	current := strings.TrimSpace(Version)
+	newer := compareVersions(tag, current) > 0
+
+	if updateCheck {
+		if newer {
+			fmt.Printf("witan %s is out of date: %s is available (run `witan update`)\n", current, tag)
+			return &ExitError{Code: ExitFindings}
+		}
+		fmt.Printf("witan %s is up to date (latest %s)\n", current, tag)
+		return nil
+	}
+	if !updateForce && updateTo == "" && !newer {
+		fmt.Printf("witan %s is up to date (latest %s)\n", current, tag)
+		return nil
+	}
+	if !updateForce && updateTo != "" && compareVersions(tag, current) == 0 {
+		fmt.Printf("witan %s is already installed\n", current)
+		return nil
+	}
+
+	exe, err := updateExecutable()
+	if err != nil {
+		return fmt.Errorf("locating the witan binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if manager := packageManagerFor(exe); manager != "" {
+		return fmt.Errorf("%s was installed by %s; update it with %s instead", exe, manager, packageManagerUpdateHint(manager))
+	}
+
+	asset, binName, err := updateAssetName(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	base := fmt.Sprintf("%s/%s/releases/download/%s/", strings.TrimRight(updateReleaseHost, "/"), repo, tag)
+	checksums, err := downloadReleaseAsset(base + updateChecksumsAsset)
+	if err != nil {
+		return err
+	}
+	want, err := checksumFor(checksums, asset)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Downloading %s %s...\n", asset, tag)
+	archive, err := downloadReleaseAsset(base + asset)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s; nothing was replaced", asset, want, got)
+	}
+	binary, err := extractReleaseBinary(asset, archive, binName)
+	if err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, binary); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s from %s to %s\n", exe, current, tag)
+	return nil
+}
+
+// latestReleaseTag resolves the tag that /releases/latest redirects to, as
+// scripts/install.sh does, which avoids the rate-limited GitHub API.
+func latestReleaseTag(repo string) (string, error) {
+	req, err := http.NewRequest("HEAD", fmt.Sprintf("%s/%s/releases/latest", strings.TrimRight(updateReleaseHost, "/"), repo), nil)
+	if err != nil {
+		return "", err
+	}
+	setCLIUserAgent(req)
+	hc := newHTTPClient(versionHealthRequestTimeout)
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := hc.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("checking the latest release: %w", err)
+	}
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	tag := location[strings.LastIndex(location, "/")+1:]
+	if resp.StatusCode/100 != 3 || tag == "" || tag == "latest" {
+		return "", fmt.Errorf("could not resolve the latest release of %s (HTTP %d)", repo, resp.StatusCode)
+	}
+	return tag, nil
+}
+
+func downloadReleaseAsset(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setCLIUserAgent(req)
+	resp, err := newHTTPClient(updateDownloadTimeout).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// updateAssetName returns the release archive for a platform and the binary
+// inside it, matching scripts/build-dist.sh.
+func updateAssetName(goos, goarch string) (asset, binary string, err error) {
+	switch goarch {
+	case "amd64", "arm64":
+	default:
+		return "", "", fmt.Errorf("no release build for %s/%s", goos, goarch)
+	}
+	switch goos {
+	case "darwin", "linux":
+		return fmt.Sprintf("witan-%s-%s.tar.gz", goos, goarch), "witan", nil
+	case "windows":
+		return fmt.Sprintf("witan-windows-%s.zip", goarch), "witan.exe", nil
+	}
+	return "", "", fmt.Errorf("no release build for %s/%s", goos, goarch)
+}
+
+// checksumFor finds asset's SHA-256 in shasum output.
+func checksumFor(checksums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", updateChecksumsAsset, asset)
+}
+
+func extractReleaseBinary(asset string, archive []byte, name string) ([]byte, error) {
+	if strings.HasSuffix(asset, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", asset, err)
+		}
+		for _, f := range zr.File {
+			if f.Name != name {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", asset, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%s does not contain %s", asset, name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", asset, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s does not contain %s", asset, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", asset, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && hdr.Name == name {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable swaps binary in at exe via a temp file in the same
+// directory. Windows cannot overwrite a running executable, so the old one
+// is first moved aside to exe.old.
+func replaceExecutable(exe string, binary []byte) error {
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".witan-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (try again with permission to replace %s): %w", dir, exe, err)
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(binary)
+	if err == nil {
+		err = tmp.Chmod(0o755)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("writing the new binary: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("moving aside %s: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmpPath, exe); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	return nil
+}
+
+// packageManagerFor reports which package manager owns exe ("pip" or "npm"),
+// or "" for a standalone install.
+func packageManagerFor(exe string) string {
+	p := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(p, "/site-packages/") || strings.Contains(p, "/dist-packages/"):
+		return "pip"
+	case strings.Contains(p, "/node_modules/"):
+		return "npm"
+	}
+	return ""
+}
+
+func packageManagerUpdateHint(manager string) string {
+	if manager == "npm" {
+		return "`npm install -g witan@latest`"
+	}
+	return "`pip install -U witan`"
+}
+
+// compareVersions orders release tags like v1.2.3 and v1.2.3-rc.1. A version
+// that does not parse (such as "dev") sorts before every release.
+func compareVersions(a, b string) int {
+	pa, oka := parseVersion(a)
+	pb, okb := parseVersion(b)
+	switch {
+	case !oka && !okb:
+		return 0
+	case !oka:
+		return -1
+	case !okb:
+		return 1
+	}
+	for i := 0; i < 3; i++ {
+		if pa.nums[i] != pb.nums[i] {
+			if pa.nums[i] < pb.nums[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	// A prerelease sorts before its release.
+	switch {
+	case pa.pre == pb.pre:
+		return 0
+	case pa.pre == "":
+		return 1
+	case pb.pre == "":
+		return -1
+	case pa.pre < pb.pre:
+		return -1
+	}
+	return 1
+}
+
+type parsedVersion struct {
+	nums [3]int
+	pre  string
+}
+
+func parseVersion(v string) (parsedVersion, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var p parsedVersion
+	core, pre, _ := strings.Cut(v, "-")
+	p.pre = pre
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return p, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return p, false
+		}
+		p.nums[i] = n
+	}
+	return p, true
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v1.2.3", "1.2.4", -1},
+		{"v1.2.3", "v1.2.3-rc.1", 1},
+		{"v1.2.3-rc.2", "v1.2.3-rc.1", 1},
+		{"v1.0.0", "dev", 1},
+		{"dev", "dev", 0},
+	}
+	for _, tc := range cases {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// fakeReleaseServer serves a /releases/latest redirect to tag and a release
+// holding a tarball of binary, with a checksum file listing checksum (the
+// real one when empty).
+func fakeReleaseServer(t *testing.T, tag string, binary []byte, checksum string) *httptest.Server {
+	t.Helper()
+	asset, binName, err := updateAssetName(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skip(err)
+	}
+	if strings.HasSuffix(asset, ".zip") {
+		t.Skip("tarball fixture only")
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: binName, Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(binary)
+	_ = tw.Close()
+	_ = gz.Close()
+	archive := buf.Bytes()
+	if checksum == "" {
+		sum := sha256.Sum256(archive)
+		checksum = hex.EncodeToString(sum[:])
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/witanlabs/witan-cli/releases/latest":
+			http.Redirect(w, r, "/witanlabs/witan-cli/releases/tag/"+tag, http.StatusFound)
+		case "/witanlabs/witan-cli/releases/download/" + tag + "/witan-checksums.txt":
+			fmt.Fprintf(w, "%s  %s\n", checksum, asset)
+		case "/witanlabs/witan-cli/releases/download/" + tag + "/" + asset:
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func setupUpdateTest(t *testing.T, srv *httptest.Server, current string) string {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "witan")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	origHost := updateReleaseHost
+	origExecutable := updateExecutable
+	origVersion := Version
+	origCheck := updateCheck
+	origTo := updateTo
+	origForce := updateForce
+	t.Cleanup(func() {
+		updateReleaseHost = origHost
+		updateExecutable = origExecutable
+		Version = origVersion
+		updateCheck = origCheck
+		updateTo = origTo
+		updateForce = origForce
+	})
+	updateReleaseHost = srv.URL
+	updateExecutable = func() (string, error) { return exe, nil }
+	Version = current
+	updateCheck = false
+	updateTo = ""
+	updateForce = false
+	t.Setenv("WITAN_OFFLINE", "")
+	return exe
+}
+
+func TestRunUpdate_ReplacesBinary(t *testing.T) {
+	srv := fakeReleaseServer(t, "v1.5.0", []byte("new binary"), "")
+	exe := setupUpdateTest(t, srv, "v1.4.0")
+
+	if _, err := captureExecStdout(t, func() error { return runUpdate(updateCmd, nil) }); err != nil {
+		t.Fatalf("runUpdate: %v", err)
+	}
+	got, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new binary" {
+		t.Fatalf("binary = %q, want new binary", got)
+	}
+}
+
+func TestRunUpdate_ChecksumMismatchKeepsBinary(t *testing.T) {
+	srv := fakeReleaseServer(t, "v1.5.0", []byte("new binary"), strings.Repeat("0", 64))
+	exe := setupUpdateTest(t, srv, "v1.4.0")
+
+	_, err := captureExecStdout(t, func() error { return runUpdate(updateCmd, nil) })
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "old" {
+		t.Fatalf("binary was replaced despite the mismatch: %q", got)
+	}
+}
+
+func TestRunUpdate_CheckReportsStaleness(t *testing.T) {
+	srv := fakeReleaseServer(t, "v1.5.0", []byte("new binary"), "")
+	setupUpdateTest(t, srv, "v1.4.0")
+	updateCheck = true
+
+	out, err := captureExecStdout(t, func() error { return runUpdate(updateCmd, nil) })
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitFindings {
+		t.Fatalf("expected exit %d, got %v", ExitFindings, err)
+	}
+	if !strings.Contains(out, "v1.5.0 is available") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	Version = "v1.5.0"
+	if _, err := captureExecStdout(t, func() error { return runUpdate(updateCmd, nil) }); err != nil {
+		t.Fatalf("expected up to date, got %v", err)
+	}
+}
+
+func TestPackageManagerFor(t *testing.T) {
+	if got := packageManagerFor("/usr/lib/python3/site-packages/witan/bin/witan"); got != "pip" {
+		t.Errorf("site-packages: got %q", got)
+	}
+	if got := packageManagerFor("/usr/lib/node_modules/witan-linux-x64/bin/witan"); got != "npm" {
+		t.Errorf("node_modules: got %q", got)
+	}
+	if got := packageManagerFor("/usr/local/bin/witan"); got != "" {
+		t.Errorf("standalone: got %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/witanlabs/witan-cli/client"
+	"golang.org/x/term"
+)
+
+// uploadProgressMinBytes is the smallest upload that shows a progress line;
+// smaller files finish before a line is worth drawing.
+const uploadProgressMinBytes = 1 << 20
+
+// stderrIsTTY is swapped in tests.
+var stderrIsTTY = func() bool {
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// uploadProgressPrinter returns a progress callback that redraws a single
+// "Uploading" line on w, or nil when progress should not be shown (not a
+// terminal, or --quiet).
+func uploadProgressPrinter(w io.Writer) client.UploadProgressFunc {
+	if quietOutput || !stderrIsTTY() {
+		return nil
+	}
+	lastPct := -1
+	return func(filename string, sent, total int64) {
+		if total < uploadProgressMinBytes {
+			return
+		}
+		pct := int(min(sent, total) * 100 / total)
+		if pct == lastPct {
+			return
+		}
+		lastPct = pct
+		fmt.Fprintf(w, "\rUploading %s %3d%% (%s / %s)", filename, pct, formatBytes(min(sent, total)), formatBytes(total))
+		if pct == 100 {
+			fmt.Fprintln(w)
+			lastPct = -1
+		}
+	}
+}
+
+// formatBytes renders n as a short human-readable size, e.g. "12.3 MB".
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
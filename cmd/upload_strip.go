@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// uploadStripFlag is --strip on xlsx lint and calc. Clients built while it
+// is set upload stripped copies of workbooks; see client.WithStrip.
+var uploadStripFlag string
+
+// uploadStrip is the parsed --strip level, set by resolveUploadStrip.
+var uploadStrip = client.StripOff
+
+func addUploadStripFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&uploadStripFlag, "strip", "off", "Shrink the upload: minimal drops cached formula values, aggressive also unused styles and printer settings (off, minimal, aggressive)")
+	_ = cmd.RegisterFlagCompletionFunc("strip", cobra.FixedCompletions([]string{"off", "minimal", "aggressive"}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// resolveUploadStrip parses --strip and reports whether stripping is on.
+// Stripping applies to stateless uploads only, so callers switch to a
+// stateless client when it is.
+func resolveUploadStrip() (bool, error) {
+	level, err := client.ParseStripLevel(uploadStripFlag)
+	if err != nil {
+		return false, err
+	}
+	uploadStrip = level
+	return level != client.StripOff, nil
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// uploadedHash returns the cached content hash of filePath while the file
+// still holds the bytes it was uploaded with, for a conditional download;
+// otherwise "".
+func uploadedHash(c *client.Client, filePath string) string {
+	if current, _ := c.UploadIsCurrent(filePath); !current {
+		return ""
+	}
+	entry, _ := c.CachedUpload(filePath)
+	return entry.ContentHash
+}
+
+// writeBackRevision downloads revisionID of fileID, writes it to saveTo with
+// writeBackFile, and records the written revision in the file cache. When
+// saveTo is the uploaded file itself and still holds the uploaded bytes, the
+// download is conditional: a revision whose bytes did not change is neither
+// downloaded nor rewritten. A revision that drops filePath's VBA project is
+// handled by checkMacrosKept. It returns the path written, which
+// fixWritebackExtension may have renamed.
+func writeBackRevision(c *client.Client, filePath, saveTo, fileID, revisionID string) (string, error) {
+	knownHash := ""
+	if saveTo == filePath {
+		knownHash = uploadedHash(c, filePath)
+	}
+	fileBytes, changed, err := c.DownloadFileContentIfChanged(fileID, revisionID, knownHash)
+	if err != nil {
+		return "", fmt.Errorf("downloading updated file: %w", err)
+	}
+	if !changed {
+		if err := c.UpdateCachedRevision(saveTo, fileID, revisionID); err != nil {
+			return "", fmt.Errorf("updating local cache: %w", err)
+		}
+		return saveTo, nil
+	}
+	if err := checkMacrosKept(filePath, fileBytes); err != nil {
+		return "", err
+	}
+	if err := writeBackFile(saveTo, fileBytes); err != nil {
+		return "", fmt.Errorf("writing updated file: %w", err)
+	}
+	if saveTo, err = fixWritebackExtension(saveTo); err != nil {
+		return "", err
+	}
+	if saveTo != stdioPath {
+		if err := c.RecordDownloadedRevision(saveTo, fileID, revisionID, fileBytes); err != nil {
+			return "", fmt.Errorf("updating local cache: %w", err)
+		}
+	}
+	return saveTo, nil
+}
+
+// writeBackFile overwrites filePath with data after keeping its previous
+// bytes for 'witan xlsx undo'. A file that does not exist yet is simply
+// written, and "-" writes data to stdout. With --verify-writeback the bytes
+// are checked before anything is replaced and the file is re-read after.
+// Writes to a --fix-ext copy go to the original file too.
+func writeBackFile(filePath string, data []byte) error {
+	if orig := fixExtOriginal(filePath); orig != filePath {
+		if err := writeBackFile(orig, data); err != nil {
+			return err
+		}
+		return writeFileAtomic(filePath, data)
+	}
+	if verifyWriteback {
+		if err := checkWorkbookBytes(data); err != nil {
+			return fmt.Errorf("not replacing %s: %w", filePath, err)
+		}
+	}
+	if filePath == stdioPath {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := keepUndoVersion(filePath); err != nil {
+		return fmt.Errorf("keeping undo copy: %w", err)
+	}
+	if err := writeFileAtomic(filePath, data); err != nil {
+		return err
+	}
+	if verifyWriteback {
+		if err := confirmWrittenFile(filePath, data); err != nil {
+			return fmt.Errorf("%w; witan xlsx undo restores the previous version", err)
+		}
+	}
+	return nil
+}
+
+// keepUndoVersion saves filePath's current bytes under its undo directory
+// and prunes all but the newest undoKeep versions.
+func keepUndoVersion(filePath string) error {
+	prev, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dir, err := undoDirFor(filePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%019d%s", time.Now().UnixNano(), filepath.Ext(filePath))
+	if err := os.WriteFile(filepath.Join(dir, name), prev, 0o600); err != nil {
+		return err
+	}
+
+	versions, err := listUndoVersions(filePath)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions[min(len(versions), undoKeep):] {
+		os.Remove(v.Path)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces filePath with data so that readers see either the
+// old or the new file, never a truncated one: data goes to a temp file in the
+// same directory, is synced, then renamed over filePath. An existing file's
+// permissions are kept; new files get 0644.
+func writeFileAtomic(filePath string, data []byte) (err error) {
+	perm := os.FileMode(0o644)
+	if info, statErr := os.Stat(filePath); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
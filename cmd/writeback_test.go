@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic_KeepsPermissionsAndLeavesNoTempFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("old"), 0o600); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	if err := writeFileAtomic(filePath, []byte("new")); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "new" {
+		t.Fatalf("expected new content, got %q", got)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected permissions 0600 to be kept, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the workbook in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestWriteBackFile_KeepsLastVersions(t *testing.T) {
+	t.Setenv("WITAN_UNDO_DIR", t.TempDir())
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	for i := 0; i <= undoKeep+3; i++ {
+		if err := writeBackFile(filePath, []byte{byte(i)}); err != nil {
+			t.Fatalf("writeBackFile %d: %v", i, err)
+		}
+	}
+	versions, err := listUndoVersions(filePath)
+	if err != nil {
+		t.Fatalf("listUndoVersions: %v", err)
+	}
+	if len(versions) != undoKeep {
+		t.Fatalf("expected %d kept versions, got %d", undoKeep, len(versions))
+	}
+	if got, _ := os.ReadFile(versions[0].Path); len(got) != 1 || got[0] != byte(undoKeep+2) {
+		t.Fatalf("expected newest copy to hold the previous bytes, got %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+)
+
+// verifyWriteback enables --verify-writeback: writeBackFile checks workbook
+// bytes before replacing a file and re-reads the file afterwards. Downloaded
+// revisions are already checked against the digest the server reports, so
+// together this catches corrupt downloads and bad local writes.
+var verifyWriteback bool
+
+// ooxmlRequiredParts are the zip entries every OOXML workbook has.
+var ooxmlRequiredParts = []string{"[Content_Types].xml", "xl/workbook.xml"}
+
+// checkWorkbookBytes reports whether data looks like an intact workbook: an
+// OOXML zip whose entries all decompress with matching CRCs and that holds
+// the required parts, or a legacy OLE2 .xls (checked by signature only).
+func checkWorkbookBytes(data []byte) error {
+	if bytes.HasPrefix(data, []byte{0xd0, 0xcf, 0x11, 0xe0}) {
+		return nil
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("workbook is not a valid zip archive: %w", err)
+	}
+	found := map[string]bool{}
+	for _, f := range zr.File {
+		found[f.Name] = true
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("workbook part %s: %w", f.Name, err)
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("workbook part %s: %w", f.Name, err)
+		}
+	}
+	for _, name := range ooxmlRequiredParts {
+		if !found[name] {
+			return fmt.Errorf("workbook is missing %s", name)
+		}
+	}
+	return nil
+}
+
+// confirmWrittenFile re-reads path and checks it holds exactly data.
+func confirmWrittenFile(path string, data []byte) error {
+	written, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("re-reading %s: %w", path, err)
+	}
+	if sha256.Sum256(written) != sha256.Sum256(data) {
+		return fmt.Errorf("%s does not match the bytes written", path)
+	}
+	return nil
+}
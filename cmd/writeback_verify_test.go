@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testWorkbookZip(t *testing.T, parts ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range parts {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(w, "<%s/>", strings.Repeat("x", 64))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckWorkbookBytes(t *testing.T) {
+	valid := testWorkbookZip(t, "[Content_Types].xml", "xl/workbook.xml", "xl/worksheets/sheet1.xml")
+	if err := checkWorkbookBytes(valid); err != nil {
+		t.Fatalf("valid workbook rejected: %v", err)
+	}
+	if err := checkWorkbookBytes([]byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}); err != nil {
+		t.Fatalf("OLE2 workbook rejected: %v", err)
+	}
+
+	if err := checkWorkbookBytes(valid[:len(valid)/2]); err == nil {
+		t.Fatal("expected a truncated zip to be rejected")
+	}
+	corrupt := bytes.Clone(valid)
+	i := bytes.Index(corrupt, []byte("xxxx"))
+	corrupt[i] = 'y' // stored entry: the CRC no longer matches
+	if err := checkWorkbookBytes(corrupt); err == nil {
+		t.Fatal("expected a corrupted entry to be rejected")
+	}
+	if err := checkWorkbookBytes(testWorkbookZip(t, "[Content_Types].xml")); err == nil || !strings.Contains(err.Error(), "xl/workbook.xml") {
+		t.Fatalf("expected a missing workbook part error, got %v", err)
+	}
+}
+
+func TestRunExec_VerifyWritebackKeepsOriginalOnCorruptFile(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, original := writeWorkbookForExecTest(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"stdout":"","result":true,"writes_detected":true,"file":"%s"}`,
+			base64.StdEncoding.EncodeToString([]byte("PK\x03\x04truncated")))
+	}))
+	defer server.Close()
+
+	stateless = true
+	apiURL = server.URL
+	apiKey = "test-key"
+
+	cmd := newExecTestCommand()
+	for name, value := range map[string]string{"code": "return true;", "save": "true"} {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("setting --%s: %v", name, err)
+		}
+	}
+	verifyWriteback = true
+
+	_, err := captureExecStdout(t, func() error {
+		return runExec(cmd, []string{filePath})
+	})
+	if err == nil || !strings.Contains(err.Error(), "not replacing") {
+		t.Fatalf("expected the corrupt workbook to be refused, got %v", err)
+	}
+	after, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("reading workbook: %v", err)
+	}
+	if !bytes.Equal(after, original) {
+		t.Fatalf("original workbook was modified: %q", after)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// serverWorkbook returns a workbook the API can process for filePath:
+// filePath itself, or for a .xlsb when the server does not list the xlsb
+// feature, an .xlsx converted by the server's convert endpoint into this
+// run's temp dir. writesBack reports that the command would write its
+// result over filePath, which a converted workbook cannot do. The caller
+// must call cleanup.
+func serverWorkbook(c *client.Client, filePath string, writesBack bool) (path string, cleanup func(), err error) {
+	noop := func() {}
+	if !strings.EqualFold(filepath.Ext(filePath), ".xlsb") {
+		return filePath, noop, nil
+	}
+	if caps := serverCapabilities(c); caps != nil && slices.Contains(caps.Features, client.FeatureXLSB) {
+		return filePath, noop, nil
+	}
+	name := filepath.Base(filePath)
+	if writesBack {
+		return "", noop, fmt.Errorf("%s is a binary (.xlsb) workbook, which cannot be written back; use --save-to with an .xlsx path, or save it as .xlsx first", name)
+	}
+
+	data, err := c.ConvertWorkbook(filePath, "xlsx")
+	if err != nil {
+		if client.IsNotFound(err) {
+			return "", noop, fmt.Errorf("this Witan API cannot process .xlsb workbooks; save %s as .xlsx first", name)
+		}
+		return "", noop, fmt.Errorf("converting %s to .xlsx: %w", name, err)
+	}
+	root, err := runTempDir()
+	if err != nil {
+		return "", noop, err
+	}
+	dir, err := os.MkdirTemp(root, "xlsb-*")
+	if err != nil {
+		return "", noop, fmt.Errorf("converting %s to .xlsx: %w", name, err)
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	path = filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".xlsx")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("converting %s to .xlsx: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "note: %s is a binary workbook; working on a converted .xlsx copy\n", name)
+	return path, cleanup, nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func TestServerWorkbook_ConvertsXLSB(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0/meta":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"features":["jobs"]}`))
+		case "/v0/xlsx/convert":
+			if r.URL.Query().Get("format") != "xlsx" || !strings.Contains(r.Header.Get("Content-Type"), "binary") {
+				t.Errorf("convert request: %s %s", r.URL.RawQuery, r.Header.Get("Content-Type"))
+			}
+			w.Write([]byte("PK\x03\x04converted"))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	src := filepath.Join(t.TempDir(), "model.xlsb")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	zw.Create(xlsbWorkbookPart)
+	zw.Close()
+	f.Close()
+	if format, _ := detectExcelFormat(src); format != excelFormatBIFF12 {
+		t.Fatalf("detectExcelFormat = %d, want BIFF12", format)
+	}
+
+	c := client.New(server.URL, "", "", true)
+	if _, _, err := serverWorkbook(c, src, true); err == nil {
+		t.Fatal("expected an error for a write-back to .xlsb")
+	}
+	path, cleanup, err := serverWorkbook(c, src, false)
+	if err != nil {
+		t.Fatalf("serverWorkbook: %v", err)
+	}
+	if filepath.Base(path) != "model.xlsx" {
+		t.Errorf("converted path = %q", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "PK\x03\x04converted" {
+		t.Errorf("converted bytes = %q", data)
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("cleanup left the converted copy")
+	}
+}
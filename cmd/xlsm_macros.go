@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// requireMacros enables --require-macros: a write-back that would drop the
+// source workbook's VBA project fails instead of warning.
+var requireMacros bool
+
+// vbaProjectPart is the zip entry holding a macro-enabled workbook's VBA
+// project.
+const vbaProjectPart = "xl/vbaproject.bin"
+
+// checkMacrosKept compares the workbook at sourcePath with the bytes about to
+// be written back for it. When the source has a VBA project and data does
+// not, it warns on stderr, or with --require-macros returns an error so
+// nothing is written.
+func checkMacrosKept(sourcePath string, data []byte) error {
+	src, err := zip.OpenReader(sourcePath)
+	if err != nil {
+		return nil // not an OOXML workbook, or gone; nothing to compare
+	}
+	hadVBA := zipHasVBAProject(&src.Reader)
+	src.Close()
+	if !hadVBA {
+		return nil
+	}
+	if zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err == nil && zipHasVBAProject(zr) {
+		return nil
+	}
+	if requireMacros {
+		return fmt.Errorf("the returned workbook dropped the VBA project of %s; not writing it (--require-macros)", sourcePath)
+	}
+	fmt.Fprintf(os.Stderr, "warning: the returned workbook has no VBA project; macros from %s were dropped (use --require-macros to fail instead)\n", sourcePath)
+	return nil
+}
+
+func zipHasVBAProject(zr *zip.Reader) bool {
+	for _, f := range zr.File {
+		if strings.EqualFold(f.Name, vbaProjectPart) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckMacrosKept(t *testing.T) {
+	origRequireMacros := requireMacros
+	t.Cleanup(func() { requireMacros = origRequireMacros })
+
+	dir := t.TempDir()
+	withVBA := testWorkbookZip(t, "[Content_Types].xml", "xl/workbook.xml", "xl/vbaProject.bin")
+	withoutVBA := testWorkbookZip(t, "[Content_Types].xml", "xl/workbook.xml")
+	macroBook := filepath.Join(dir, "macros.xlsm")
+	plainBook := filepath.Join(dir, "plain.xlsx")
+	if err := os.WriteFile(macroBook, withVBA, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(plainBook, withoutVBA, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	requireMacros = true
+	if err := checkMacrosKept(macroBook, withVBA); err != nil {
+		t.Fatalf("kept VBA project rejected: %v", err)
+	}
+	if err := checkMacrosKept(plainBook, withoutVBA); err != nil {
+		t.Fatalf("workbook without macros rejected: %v", err)
+	}
+	if err := checkMacrosKept(filepath.Join(dir, "new.xlsx"), withoutVBA); err != nil {
+		t.Fatalf("missing source rejected: %v", err)
+	}
+	if err := checkMacrosKept(macroBook, withoutVBA); err == nil || !strings.Contains(err.Error(), "dropped the VBA project") {
+		t.Fatalf("expected dropped macros to fail, got %v", err)
+	}
+
+	requireMacros = false
+	if err := checkMacrosKept(macroBook, withoutVBA); err != nil {
+		t.Fatalf("expected only a warning without --require-macros, got %v", err)
+	}
+}
+
+func TestRunExec_RequireMacrosKeepsMacroWorkbook(t *testing.T) {
+	resetExecTestGlobals(t)
+	original := testWorkbookZip(t, "[Content_Types].xml", "xl/workbook.xml", "xl/vbaProject.bin")
+	filePath := filepath.Join(t.TempDir(), "model.xlsm")
+	if err := os.WriteFile(filePath, original, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stripped := testWorkbookZip(t, "[Content_Types].xml", "xl/workbook.xml")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"stdout":"","result":true,"writes_detected":true,"file":"%s"}`, base64.StdEncoding.EncodeToString(stripped))
+	}))
+	defer server.Close()
+
+	stateless = true
+	apiURL = server.URL
+	apiKey = "test-key"
+
+	cmd := newExecTestCommand()
+	for name, value := range map[string]string{"code": "return true;", "save": "true"} {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatalf("setting --%s: %v", name, err)
+		}
+	}
+	requireMacros = true
+
+	_, err := captureExecStdout(t, func() error {
+		return runExec(cmd, []string{filePath})
+	})
+	if err == nil || !strings.Contains(err.Error(), "--require-macros") {
+		t.Fatalf("expected --require-macros to refuse the write, got %v", err)
+	}
+	after, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("reading workbook: %v", err)
+	}
+	if !bytes.Equal(after, original) {
+		t.Fatal("macro-enabled workbook was overwritten")
+	}
+}
@@ -10,11 +10,24 @@ var xlsxCmd = &cobra.Command{
 	Long: `Operate on Excel workbooks (.xls, .xlsx, .xlsm).
 
 Commands:
-  calc   Recalculate formulas, update cached values, or run non-mutating verification with --verify.
-  exec   Execute JavaScript against existing workbooks or create new .xlsx files with --create.
-  lint   Run semantic workbook checks and report diagnostics.
-  render Render a sheet range as PNG or WebP.
-  rpc    Run newline-delimited xlsx RPC over stdio.
+  assert  Check computed cell values against a snapshot.
+  calc    Recalculate formulas, update cached values, or run non-mutating verification with --verify.
+  deps    Show a cell's formula precedents or dependents.
+  exec    Execute JavaScript against existing workbooks or create new .xlsx files with --create.
+  goal-seek Find the input value that makes a formula reach a goal.
+  history List a workbook's server-side revisions.
+  lint    Run semantic workbook checks and report diagnostics.
+  merge   Combine sheets and ranges from several workbooks into one.
+  new     Create a workbook, blank or from CSV/JSON/YAML data.
+  render  Render a sheet range as PNG or WebP.
+  restore Restore a server-side revision of a workbook.
+  rpc     Run newline-delimited xlsx RPC over stdio.
+  scenarios Recalculate a workbook for each row of an input matrix.
+  search  Find cells by displayed text or formula.
+  session Pin workbook revisions for the commands of a shell session.
+  snapshot Record computed cell values for xlsx assert.
+  stats   Summarize formulas, volatile functions, and estimated calc cost.
+  undo    Restore the local file as it was before a write-back.
 
 Output:
   default  Human-friendly summaries
@@ -25,10 +38,31 @@ Examples:
   witan xlsx exec report.xlsx --expr 'await xlsx.readCell(wb, "Summary!A1")'
   witan xlsx rpc report.xlsx
   witan xlsx --json lint report.xlsx
-  witan xlsx render report.xlsx -r "Sheet1!A1:F20"`,
+  witan xlsx render report.xlsx -r "Sheet1!A1:F20"
+  witan xlsx calc "https://docs.google.com/spreadsheets/d/<id>/edit" --provider-token "$TOKEN"
+  witan xlsx lint s3://finance/models/q3.xlsx -o s3://finance/reports/q3-lint.json
+
+Remote workbooks:
+  A Google Sheets or SharePoint/OneDrive URL, or an s3://bucket/key or
+  gs://bucket/key object path, can stand in for the workbook argument. The
+  workbook is downloaded before the command runs and, when the command
+  writes it back, uploaded again. SharePoint/OneDrive and uploads to Google
+  need an OAuth access token from --provider-token or WITAN_PROVIDER_TOKEN;
+  object paths use the aws or gcloud CLI and its ambient credentials, and
+  also work as -o outputs.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
+		if err := openRemoteWorkbookArg(args); err != nil {
+			return err
+		}
+		return openRemoteOutputFlag(cmd)
+	},
 }
 
 func init() {
 	xlsxCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Output raw JSON instead of human-formatted summaries")
+	xlsxCmd.PersistentFlags().StringVar(&providerToken, "provider-token", "", "OAuth access token for Google Sheets or SharePoint/OneDrive URLs (env: WITAN_PROVIDER_TOKEN)")
 	rootCmd.AddCommand(xlsxCmd)
 }
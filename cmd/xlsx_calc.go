@@ -6,31 +6,86 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/witanlabs/witan-cli/client"
 )
 
 var (
-	calcRanges      []string
-	calcShowTouched bool
-	calcVerify      bool
+	calcRanges       []string
+	calcShowTouched  bool
+	calcVerify       bool
+	calcTolerance    float64
+	calcRelTolerance float64
+	calcRecursive    bool
+	calcConcurrency  int
+	calcReportPath   string
+	calcExitZero     bool
+	calcAsync        bool
+	calcStaged       bool
+	calcSaveTo       string
+	calcIfRevision   string
+	calcSets         []string
 )
 
 var calcCmd = &cobra.Command{
-	Use:   "calc <file>",
+	Use:   "calc <file|dir>",
 	Short: "Recalculate formulas; use --verify for non-mutating checks",
 	Long: `Recalculate formulas and update cached values in a workbook file.
 
 Behavior:
   - By default, the workbook at <file> is overwritten with updated cached values.
   - With --verify, the workbook at <file> is not modified.
+  - --save-to <path> writes the recalculated workbook to <path> and leaves
+    <file> untouched. (-o/--output is the JSON result file.)
+  - <file> "-" reads the workbook from stdin and writes the recalculated
+    workbook to stdout, statelessly; --save-to - writes any result to
+    stdout. The summary is suppressed then; use -o for the JSON result.
+  - When a macro-enabled workbook (.xlsm) comes back without its VBA
+    project, a warning is printed; --require-macros fails instead and
+    leaves the workbook unchanged.
   - By default, output shows errors only.
   - Use --show-touched to print touched cells with computed values.
   - With one or more --range values, recalculation is seeded from those ranges;
     downstream dependents are still recalculated.
+  - --range accepts a sheet-qualified A1 or R1C1 range, a defined name, or a
+    table reference such as Table1[Sales].
   - Returns exit code 2 when formula errors are found.
   - With --verify, returns exit code 2 when formula errors are found or any computed value changes.
+  - --tolerance and --rel-tolerance with --verify ignore changed cells whose
+    recalculated number is within an absolute or relative tolerance of the
+    value stored in the workbook, so floating-point noise does not fail
+    builds. Only cells whose stored and recalculated values are both plain
+    numbers are compared.
+  - --exit-zero reports findings without failing (exit code 0).
+  - --set CELL=VALUE (repeatable) runs a what-if: the workbook is
+    recalculated with those inputs overridden, and the values of the
+    --range cells are reported next to their current values. Nothing is
+    written back. VALUE is a number (5% is 0.05), TRUE or FALSE, a formula
+    starting with =, or text ("42" forces text). CELL may be a defined name.
+  - --report junit:<path> with --verify also writes a JUnit XML report:
+    one test case per formula error or changed cell. json:<path> writes
+    the JSON result.
+  - --staged with --verify checks the version of <file> staged in git's
+    index instead of the working tree (see witan hook install).
+  - --async submits the calculation as a job, prints the job ID, and exits;
+    see witan jobs. The local workbook is not overwritten. Needs
+    files-backed mode.
+  - --strip minimal uploads a copy without cached formula values, which
+    can be much smaller, statelessly. It needs a full recalculation that
+    writes the workbook back: not --verify, --range, or --async.
+
+Directories:
+  - With --verify, <dir> checks every workbook (.xlsx, .xlsm, .xls) in the
+    directory; add --recursive to include subdirectories. Workbooks are
+    checked in parallel (--concurrency) and never modified.
+  - Prints a table of per-file error and changed counts; --json prints the
+    report instead, and --report <path> also writes it to a file
+    (--report junit:<path> as JUnit XML, one test suite per workbook).
+  - Returns exit code 2 when any workbook is inconsistent, and exit code 1
+    when any workbook could not be checked.
 
 Use --json for machine-readable results.
 
@@ -38,27 +93,142 @@ Examples:
   witan xlsx calc report.xlsx
   witan xlsx calc report.xlsx -r "Sheet1!B1:B20"
   witan xlsx calc report.xlsx -r "Sheet1!B1:B20" -r "Summary!A1:H10"
+  witan xlsx calc report.xlsx -r Revenue_Table
   witan xlsx calc report.xlsx --show-touched
-  witan xlsx calc report.xlsx --verify`,
-	Args: cobra.ExactArgs(1),
-	RunE: runCalc,
+  witan xlsx calc report.xlsx --verify
+  witan xlsx calc report.xlsx --verify --tolerance 1e-9 --rel-tolerance 1e-12
+  witan xlsx calc input.xlsx --save-to recalculated.xlsx
+  cat input.xlsx | witan xlsx calc - > recalculated.xlsx
+  witan xlsx calc ./models --verify --recursive --report verify.json
+  witan xlsx calc ./models --verify --report junit:verify.xml
+  witan xlsx calc large.xlsx --verify --async
+  witan xlsx calc report.xlsx --verify --staged
+  witan xlsx calc report.xlsx --set "Assumptions!B2=0.05" -r "Summary!B10:B14"
+  witan xlsx calc report.xlsx --set GrowthRate=7% --set "Inputs!C3=1200" -r NPV`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runCalc,
 }
 
 func init() {
-	calcCmd.Flags().StringArrayVarP(&calcRanges, "range", "r", nil, `Sheet-qualified range to seed recalculation from (repeatable)`)
+	calcCmd.Flags().StringArrayVarP(&calcRanges, "range", "r", nil, `Range to seed recalculation from: A1 or R1C1, defined name, or table reference (repeatable)`)
+	_ = calcCmd.RegisterFlagCompletionFunc("range", completeWorkbookRange)
 	calcCmd.Flags().BoolVar(&calcShowTouched, "show-touched", false, "Print touched cells with formulas and computed values")
 	calcCmd.Flags().BoolVar(&calcVerify, "verify", false, "Check consistency only: do not overwrite the workbook; exit 2 if errors exist or any values changed")
+	calcCmd.Flags().Float64Var(&calcTolerance, "tolerance", 0, "With --verify, ignore numeric changes up to this absolute difference, e.g. 1e-9")
+	calcCmd.Flags().Float64Var(&calcRelTolerance, "rel-tolerance", 0, "With --verify, ignore numeric changes up to this fraction of the stored value")
+	calcCmd.Flags().BoolVar(&calcRecursive, "recursive", false, "With a directory, also verify workbooks in subdirectories")
+	calcCmd.Flags().IntVar(&calcConcurrency, "concurrency", defaultCalcConcurrency, "With a directory, maximum workbooks verified in parallel")
+	calcCmd.Flags().StringVar(&calcReportPath, "report", "", "With --verify, also write a report: junit:PATH for JUnit XML, or json:PATH (a bare PATH is JSON)")
+	calcCmd.Flags().BoolVar(&calcExitZero, "exit-zero", false, "Exit 0 even when formula errors or --verify changes are found (report-only runs)")
+	calcCmd.Flags().StringVar(&calcSaveTo, "save-to", "", "Write the recalculated workbook to this path instead of overwriting <file>")
+	calcCmd.Flags().StringVar(&calcIfRevision, "if-revision", "", "Fail with exit code 6 unless <file> is unchanged since it was uploaded as this revision and it is still the server's latest")
+	calcCmd.Flags().BoolVar(&calcStaged, "staged", false, "With --verify, check the version of <file> staged in git's index, not the working tree")
+	calcCmd.Flags().BoolVar(&requireMacros, "require-macros", false, "Fail instead of warning when the recalculated workbook drops the VBA project of a macro-enabled workbook")
+	calcCmd.Flags().StringArrayVar(&calcSets, "set", nil, `Override an input for a what-if recalculation, e.g. "Assumptions!B2=0.05"; reports the --range cells without modifying the workbook (repeatable)`)
+	calcCmd.Flags().BoolVar(&calcAsync, "async", false, "Submit as a background job and print its ID (see witan jobs)")
+	addUploadStripFlag(calcCmd)
+	addResultOutputFlag(calcCmd)
+	addResultTemplateFlags(calcCmd)
+	addResultFormatFlag(calcCmd)
 	xlsxCmd.AddCommand(calcCmd)
 }
 
+// calcTemplateData is what --template renders for calc: the response plus
+// the file it describes.
+type calcTemplateData struct {
+	*client.CalcResponse
+	File string
+}
+
 func runCalc(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 	filePath := args[0]
+	if err := loadResultTemplate(); err != nil {
+		return err
+	}
+	if err := validateResultFormat(); err != nil {
+		return err
+	}
+	if err := validateCalcTolerance(); err != nil {
+		return err
+	}
+	whatIf := len(calcSets) > 0
+	if whatIf {
+		if err := validateCalcWhatIf(); err != nil {
+			return err
+		}
+	}
 
-	filePath, err := fixExcelExtension(filePath)
+	strip, err := resolveUploadStrip()
 	if err != nil {
 		return err
 	}
+	if strip {
+		if err := validateCalcStrip(); err != nil {
+			return err
+		}
+	}
+
+	if info, err := os.Stat(filePath); err == nil && info.IsDir() {
+		if calcAsync || calcIfRevision != "" || calcStaged || whatIf || resultTemplate != nil {
+			return fmt.Errorf("--async, --if-revision, --staged, --set, and --template take a single workbook, not a directory")
+		}
+		return runCalcVerifyDir(filePath)
+	}
+	if calcRecursive {
+		return fmt.Errorf("--recursive requires a directory argument")
+	}
+	if calcReportPath != "" {
+		if !calcVerify {
+			return fmt.Errorf("--report requires --verify")
+		}
+		if _, _, err := parseReportSpec(calcReportPath); err != nil {
+			return err
+		}
+	}
+	if requireMacros && (calcVerify || calcAsync) {
+		return fmt.Errorf("--require-macros cannot be combined with --verify or --async, which do not write the workbook")
+	}
+	if calcSaveTo != "" && (calcVerify || calcAsync) {
+		return fmt.Errorf("--save-to cannot be combined with --verify or --async")
+	}
+	fromStdin := filePath == stdioPath
+	if calcIfRevision != "" && (fromStdin || calcAsync) {
+		return fmt.Errorf("--if-revision cannot be combined with stdin input or --async")
+	}
+	// displayPath names the workbook in reports when filePath is a temp copy.
+	displayPath := filePath
+	if fromStdin {
+		if calcAsync {
+			return fmt.Errorf("--async needs a workbook file, not stdin")
+		}
+		path, cleanup, err := readStdinWorkbook(os.Stdin)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		filePath = path
+	}
+	if calcStaged {
+		if !calcVerify || fromStdin || calcAsync || calcIfRevision != "" {
+			return fmt.Errorf("--staged requires --verify and a workbook file, and cannot be combined with --async or --if-revision")
+		}
+		path, cleanup, err := readStagedWorkbook(filePath)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		filePath = path
+	}
+
+	filePath, err = fixExcelExtension(filePath)
+	if err != nil {
+		return err
+	}
+	if !fromStdin && !calcStaged {
+		displayPath = filePath
+	}
 
 	key, orgID, err := resolveAuth()
 	if err != nil {
@@ -66,78 +236,82 @@ func runCalc(cmd *cobra.Command, args []string) error {
 	}
 
 	c := newAPIClient(key, orgID)
+	if fromStdin || calcStaged || strip {
+		// A stdin or staged workbook has no local file to reuse an upload
+		// for, and a stripped copy is never stored as a revision.
+		c = newAPIClientMode(key, orgID, true)
+	}
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, !calcVerify && !calcAsync && !whatIf && calcSaveTo == "")
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	ranges, err := resolveRangeAddresses(c, filePath, calcRanges)
+	if err != nil {
+		return err
+	}
+	if whatIf {
+		return runCalcWhatIf(c, filePath, displayPath, ranges)
+	}
 
 	// Build query params with repeated address values
 	params := url.Values{}
-	for _, r := range calcRanges {
+	for _, r := range ranges {
 		params.Add("address", r)
 	}
 	if calcVerify {
 		params.Set("verify", "true")
 	}
 
-	var result *client.CalcResponse
-	var fileId string
-	if c.Stateless {
-		result, err = c.Calc(filePath, params)
-	} else {
-		var revisionId string
-		fileId, revisionId, err = c.EnsureUploaded(filePath)
-		if err == nil {
-			result, err = c.FilesCalc(fileId, revisionId, params)
-			if client.IsNotFound(err) {
-				fileId, revisionId, err = c.ReuploadFile(filePath)
-				if err == nil {
-					result, err = c.FilesCalc(fileId, revisionId, params)
-				}
-			}
+	if calcAsync {
+		job, err := submitJob(c, filePath, client.JobRequest{Operation: client.JobOperationCalc, Params: params})
+		if err != nil {
+			return err
+		}
+		return outputSubmittedJob(job, jsonOutput)
+	}
+
+	saveTo := ""
+	if !calcVerify {
+		saveTo = filePath
+		if fromStdin {
+			saveTo = stdioPath
 		}
+		if calcSaveTo != "" {
+			saveTo = calcSaveTo
+		}
+	}
+	if saveTo == stdioPath {
+		if err := workbookToStdout(jsonOutput); err != nil {
+			return err
+		}
+	}
+	result, err := calcWorkbook(c, filePath, params, saveTo, calcIfRevision)
+	if err != nil {
+		return handleRevisionConflict(err, jsonOutput)
 	}
+
+	withinTolerance, err := dropChangesWithinTolerance(c, filePath, result)
 	if err != nil {
 		return err
 	}
 
 	changedCount := len(result.Changed)
-
-	// Write back the updated file unless this is verify mode.
-	if !calcVerify {
-		if c.Stateless && result.File != nil {
-			// Stateless: file returned inline as base64
-			decoded, err := base64.StdEncoding.DecodeString(*result.File)
-			if err != nil {
-				return fmt.Errorf("decoding updated file: %w", err)
-			}
-			if err := os.WriteFile(filePath, decoded, 0o644); err != nil {
-				return fmt.Errorf("writing updated file: %w", err)
-			}
-			if _, err := fixWritebackExtension(filePath); err != nil {
-				return err
-			}
-		} else if !c.Stateless && result.RevisionID != nil {
-			// Files-backed: download the new revision
-			fileBytes, err := c.DownloadFileContent(fileId, *result.RevisionID)
-			if err != nil {
-				return fmt.Errorf("downloading updated file: %w", err)
-			}
-			if err := os.WriteFile(filePath, fileBytes, 0o644); err != nil {
-				return fmt.Errorf("writing updated file: %w", err)
-			}
-			if filePath, err = fixWritebackExtension(filePath); err != nil {
-				return err
-			}
-			if err := c.UpdateCachedRevision(filePath, fileId, *result.RevisionID); err != nil {
-				return fmt.Errorf("updating local cache: %w", err)
-			}
+	if calcReportPath != "" {
+		err := writeReportFile(calcReportPath, result, func() junitTestSuites {
+			return newJUnitTestSuites("witan xlsx calc --verify", calcJUnitSuite(displayPath, result))
+		})
+		if err != nil {
+			return err
 		}
 	}
 
-	if jsonOutput {
-		// Nil out File field — it's a huge base64 blob irrelevant to automation
-		result.File = nil
-		if err := jsonPrint(result); err != nil {
-			return err
+	if err := emitResultAs(result, calcTemplateData{result, displayPath}, jsonOutput, func() error {
+		if resultFormat == resultFormatGitHub {
+			printCalcAnnotations(displayPath, result, calcVerify)
+			return nil
 		}
-	} else {
 		// Print results
 		touchedCount := len(result.Touched)
 		errorCount := len(result.Errors)
@@ -165,7 +339,7 @@ func runCalc(cmd *cobra.Command, args []string) error {
 						if e.Detail != nil {
 							detail = " ← " + *e.Detail
 						}
-						fmt.Printf("%-20s %-30s %s%s\n", addr, formula, e.Code, detail)
+						fmt.Printf("%-20s %-30s %s%s\n", addr, formula, colorize(e.Code, ansiRed), detail)
 						break
 					}
 				}
@@ -176,10 +350,7 @@ func runCalc(cmd *cobra.Command, args []string) error {
 
 			fmt.Printf("\n%d cells recalculated, %d changed", touchedCount, changedCount)
 			if errorCount > 0 {
-				fmt.Printf(", %d error", errorCount)
-				if errorCount != 1 {
-					fmt.Print("s")
-				}
+				fmt.Print(", " + colorize(pluralize(errorCount, "error", "errors"), ansiRed))
 			}
 			fmt.Println()
 		} else {
@@ -188,11 +359,7 @@ func runCalc(cmd *cobra.Command, args []string) error {
 				fmt.Printf("%d cells recalculated, 0 errors, %d changed", touchedCount, changedCount)
 				fmt.Println()
 			} else {
-				fmt.Printf("%d error", errorCount)
-				if errorCount != 1 {
-					fmt.Print("s")
-				}
-				fmt.Println(":")
+				fmt.Println(colorize(pluralize(errorCount, "error", "errors")+":", ansiRed, ansiBold))
 				for _, e := range result.Errors {
 					formula := ""
 					if e.Formula != nil {
@@ -202,7 +369,7 @@ func runCalc(cmd *cobra.Command, args []string) error {
 					if e.Detail != nil {
 						detail = " ← " + *e.Detail
 					}
-					fmt.Printf("  %-20s %s  %s%s\n", e.Address, formula, e.Code, detail)
+					fmt.Printf("  %-20s %s  %s%s\n", e.Address, formula, colorize(e.Code, ansiRed), detail)
 				}
 			}
 		}
@@ -211,6 +378,9 @@ func runCalc(cmd *cobra.Command, args []string) error {
 			changedAddresses := append([]string(nil), result.Changed...)
 			sort.Strings(changedAddresses)
 			fmt.Printf("\nChanged (%d):\n", changedCount)
+			if withinTolerance > 0 {
+				fmt.Printf("  (%s within tolerance ignored)\n", pluralize(withinTolerance, "change", "changes"))
+			}
 			if len(changedAddresses) == 0 {
 				fmt.Println("  (none)")
 			} else {
@@ -219,10 +389,156 @@ func runCalc(cmd *cobra.Command, args []string) error {
 				}
 			}
 		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if (len(result.Errors) > 0 || (calcVerify && changedCount > 0)) && !calcExitZero {
+		return &ExitError{Code: ExitFindings}
 	}
+	return nil
+}
 
-	if len(result.Errors) > 0 || (calcVerify && changedCount > 0) {
-		return &ExitError{Code: 2}
+func validateCalcTolerance() error {
+	if calcTolerance < 0 || calcRelTolerance < 0 {
+		return fmt.Errorf("--tolerance and --rel-tolerance must be >= 0")
+	}
+	if (calcTolerance > 0 || calcRelTolerance > 0) && (!calcVerify || calcAsync) {
+		return fmt.Errorf("--tolerance and --rel-tolerance require --verify and cannot be combined with --async")
+	}
+	return nil
+}
+
+// dropChangesWithinTolerance removes from result.Changed the cells whose
+// recalculated number is within --tolerance or --rel-tolerance of the value
+// stored in filePath, and returns how many it removed. The stored values are
+// read with one read-only exec call; cells that are not numbers on both
+// sides stay changed.
+func dropChangesWithinTolerance(c *client.Client, filePath string, result *client.CalcResponse) (int, error) {
+	if (calcTolerance == 0 && calcRelTolerance == 0) || len(result.Changed) == 0 {
+		return 0, nil
+	}
+	stored, err := c.Snapshot(filePath, result.Changed)
+	if err != nil {
+		return 0, fmt.Errorf("reading stored values: %w", err)
+	}
+	kept := []string{}
+	for i, addr := range result.Changed {
+		if i < len(stored) && changeWithinTolerance(stored[i], result.Touched[addr]) {
+			continue
+		}
+		kept = append(kept, addr)
+	}
+	dropped := len(result.Changed) - len(kept)
+	result.Changed = kept
+	return dropped, nil
+}
+
+func changeWithinTolerance(stored client.SnapshotRange, now client.CalcTouchedCell) bool {
+	if len(stored.Cells) != 1 {
+		return false
+	}
+	old, ok := stored.Cells[0].Value.(float64)
+	if !ok {
+		return false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(now.Value), 64)
+	if err != nil {
+		return false
+	}
+	match, _ := snapshotValuesMatch(old, value, calcTolerance, calcRelTolerance)
+	return match
+}
+
+// validateCalcStrip checks that --strip can be used: the whole workbook must
+// be recalculated, so every value it drops comes back, and written back,
+// so aggressive would lose printer settings.
+func validateCalcStrip() error {
+	if calcVerify {
+		return fmt.Errorf("--strip cannot be combined with --verify, which compares against the cached values it removes")
+	}
+	if len(calcRanges) > 0 {
+		return fmt.Errorf("--strip needs a full recalculation and cannot be combined with --range")
+	}
+	if calcAsync || calcIfRevision != "" {
+		return fmt.Errorf("--strip sends a stateless upload and cannot be combined with --async or --if-revision")
+	}
+	if uploadStrip == client.StripAggressive {
+		return fmt.Errorf("--strip aggressive would remove printer settings from the saved workbook; use --strip minimal")
 	}
 	return nil
 }
+
+// calcWorkbook recalculates filePath and, when saveTo is set, writes the
+// recalculated workbook there (saveTo may be filePath itself). The inline
+// file payload is cleared from the returned response. A non-empty
+// ifRevision recalculates exactly that uploaded revision (see
+// checkIfRevision), with no re-upload or retry.
+func calcWorkbook(c *client.Client, filePath string, params url.Values, saveTo, ifRevision string) (*client.CalcResponse, error) {
+	var result *client.CalcResponse
+	var fileId string
+	var err error
+	if ifRevision != "" {
+		fileId, err = checkIfRevision(c, filePath, ifRevision)
+		if err == nil {
+			emitEvent("calc_started", map[string]any{"file": filePath})
+			result, err = c.FilesCalc(fileId, ifRevision, params)
+			err = asRevisionConflict(err, filePath, fileId, ifRevision)
+		}
+	} else if c.Stateless {
+		emitEvent("calc_started", map[string]any{"file": filePath})
+		result, err = c.Calc(filePath, params)
+	} else {
+		var revisionId string
+		fileId, revisionId, err = c.EnsureUploaded(filePath)
+		if err == nil {
+			emitEvent("calc_started", map[string]any{"file": filePath})
+			result, err = c.FilesCalc(fileId, revisionId, params)
+			if client.IsNotFound(err) {
+				fileId, revisionId, err = c.ReuploadFile(filePath)
+				if err == nil {
+					result, err = c.FilesCalc(fileId, revisionId, params)
+				}
+			} else if client.IsRevisionConflict(err) && resolveAutoRefresh() {
+				fileId, revisionId, err = c.RefreshRevision(filePath)
+				if err == nil {
+					result, err = c.FilesCalc(fileId, revisionId, params)
+				}
+			}
+			err = asRevisionConflict(err, filePath, fileId, revisionId)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Write back the updated file unless the caller is only verifying.
+	if saveTo != "" {
+		if c.Stateless && result.File != nil {
+			// Stateless: file returned inline as base64
+			decoded, err := base64.StdEncoding.DecodeString(*result.File)
+			if err != nil {
+				return nil, fmt.Errorf("decoding updated file: %w", err)
+			}
+			if err := checkMacrosKept(filePath, decoded); err != nil {
+				return nil, err
+			}
+			if err := writeBackFile(saveTo, decoded); err != nil {
+				return nil, fmt.Errorf("writing updated file: %w", err)
+			}
+			if _, err := fixWritebackExtension(saveTo); err != nil {
+				return nil, err
+			}
+		} else if !c.Stateless && result.RevisionID != nil {
+			// Files-backed: download the new revision
+			if _, err := writeBackRevision(c, filePath, saveTo, fileId, *result.RevisionID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The file is a huge base64 blob irrelevant to callers once written.
+	result.File = nil
+	return result, nil
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+const defaultCalcConcurrency = 4
+
+// calcVerifyFileResult is one workbook's entry in a directory verify report.
+type calcVerifyFileResult struct {
+	File            string `json:"file"`
+	Status          string `json:"status"` // "ok", "inconsistent", or "failed"
+	Errors          int    `json:"errors"`
+	Changed         int    `json:"changed"`
+	Touched         int    `json:"touched"`
+	WithinTolerance int    `json:"within_tolerance,omitempty"` // changes ignored by --tolerance
+	Error           string `json:"error,omitempty"`
+
+	result *client.CalcResponse // for --report junit:
+}
+
+// calcVerifyReport is the --json / --report output of a directory verify.
+type calcVerifyReport struct {
+	Root         string                 `json:"root"`
+	Recursive    bool                   `json:"recursive"`
+	Total        int                    `json:"total"`
+	Consistent   int                    `json:"consistent"`
+	Inconsistent int                    `json:"inconsistent"`
+	Failed       int                    `json:"failed"`
+	Files        []calcVerifyFileResult `json:"files"`
+}
+
+// findWorkbooks lists the workbooks in dir, sorted by path. Subdirectories
+// are searched only when recursive is set; hidden directories and Excel
+// lock files (~$book.xlsx) are skipped.
+func findWorkbooks(dir string, recursive bool) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !recursive || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), "~$") || !d.Type().IsRegular() {
+			return nil
+		}
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if slices.Contains(workbookExtensions, ext) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// runCalcVerifyDir verifies every workbook under dir with a shared client,
+// at most calcConcurrency at a time. Workbooks are never modified. It exits
+// ExitFailure if any workbook could not be checked, otherwise ExitFindings
+// if any is inconsistent (unless --exit-zero).
+func runCalcVerifyDir(dir string) error {
+	if !calcVerify {
+		return fmt.Errorf("%s is a directory; directories can only be checked with --verify", dir)
+	}
+	if len(calcRanges) > 0 {
+		return fmt.Errorf("--range cannot be used with a directory")
+	}
+	if calcConcurrency <= 0 {
+		return fmt.Errorf("--concurrency must be > 0")
+	}
+	if calcReportPath != "" {
+		if _, _, err := parseReportSpec(calcReportPath); err != nil {
+			return err
+		}
+	}
+
+	paths, err := findWorkbooks(dir, calcRecursive)
+	if err != nil {
+		return fmt.Errorf("listing workbooks: %w", err)
+	}
+	if len(paths) == 0 {
+		hint := ""
+		if !calcRecursive {
+			hint = " (use --recursive to include subdirectories)"
+		}
+		return fmt.Errorf("no workbooks found in %s%s", dir, hint)
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+
+	report := verifyWorkbooks(c, paths)
+	report.Root = dir
+	report.Recursive = calcRecursive
+
+	if calcReportPath != "" {
+		if err := writeReportFile(calcReportPath, report, func() junitTestSuites {
+			return calcVerifyJUnit(report)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := emitResult(report, jsonOutput, func() error {
+		if resultFormat == resultFormatGitHub {
+			printCalcVerifyAnnotations(report)
+			return nil
+		}
+		printCalcVerifyReport(report)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, f := range report.Files {
+		if f.Error != "" {
+			fmt.Fprintf(os.Stderr, "error: %s: %s\n", f.File, f.Error)
+		}
+	}
+
+	switch {
+	case report.Failed > 0:
+		return &ExitError{Code: ExitFailure}
+	case report.Inconsistent > 0 && !calcExitZero:
+		return &ExitError{Code: ExitFindings}
+	}
+	return nil
+}
+
+// verifyWorkbooks runs calc --verify on each path and tallies the results
+// in path order.
+func verifyWorkbooks(c *client.Client, paths []string) *calcVerifyReport {
+	files := make([]calcVerifyFileResult, len(paths))
+
+	workers := min(calcConcurrency, len(paths))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			files[i] = verifyOneWorkbook(c, path)
+		}()
+	}
+	wg.Wait()
+
+	report := &calcVerifyReport{Total: len(files), Files: files}
+	for _, f := range files {
+		switch f.Status {
+		case "ok":
+			report.Consistent++
+		case "inconsistent":
+			report.Inconsistent++
+		default:
+			report.Failed++
+		}
+	}
+	return report
+}
+
+func verifyOneWorkbook(c *client.Client, path string) calcVerifyFileResult {
+	entry := calcVerifyFileResult{File: path}
+	params := url.Values{}
+	params.Set("verify", "true")
+	result, err := calcWorkbook(c, path, params, "", "")
+	if err != nil {
+		entry.Status = "failed"
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.WithinTolerance, err = dropChangesWithinTolerance(c, path, result)
+	if err != nil {
+		entry.Status = "failed"
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.result = result
+	entry.Errors = len(result.Errors)
+	entry.Changed = len(result.Changed)
+	entry.Touched = len(result.Touched)
+	entry.Status = "ok"
+	if entry.Errors > 0 || entry.Changed > 0 {
+		entry.Status = "inconsistent"
+	}
+	return entry
+}
+
+// calcVerifyJUnit maps a directory verify to one test suite per workbook. A
+// workbook that could not be checked is a single erroring case.
+func calcVerifyJUnit(report *calcVerifyReport) junitTestSuites {
+	suites := make([]junitTestSuite, 0, len(report.Files))
+	for _, f := range report.Files {
+		if f.result != nil {
+			suites = append(suites, calcJUnitSuite(f.File, f.result))
+			continue
+		}
+		suite := junitTestSuite{Name: f.File}
+		suite.add(junitTestCase{Name: "verify", Error: &junitFailure{Message: f.Error, Type: "failed", Text: f.Error}})
+		suites = append(suites, suite)
+	}
+	return newJUnitTestSuites("witan xlsx calc --verify", suites...)
+}
+
+func printCalcVerifyReport(report *calcVerifyReport) {
+	width := len("FILE")
+	for _, f := range report.Files {
+		width = max(width, len(f.File))
+	}
+	fmt.Printf("%-*s  %6s  %7s  %s\n", width, "FILE", "ERRORS", "CHANGED", "STATUS")
+	for _, f := range report.Files {
+		if f.Status == "failed" {
+			fmt.Printf("%-*s  %6s  %7s  %s\n", width, f.File, "-", "-", colorCalcVerifyStatus(f.Status))
+			continue
+		}
+		fmt.Printf("%-*s  %6d  %7d  %s\n", width, f.File, f.Errors, f.Changed, colorCalcVerifyStatus(f.Status))
+	}
+
+	fmt.Printf("\n%d workbooks: %d ok, %d inconsistent, %d failed\n", report.Total, report.Consistent, report.Inconsistent, report.Failed)
+}
+
+func colorCalcVerifyStatus(status string) string {
+	switch status {
+	case "ok":
+		return colorize(status, ansiGreen)
+	case "inconsistent":
+		return colorize(status, ansiYellow)
+	}
+	return colorize(status, ansiRed)
+}
@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
 )
 
 func TestRunCalcVerify_StatelessSendsVerifyQueryParam(t *testing.T) {
@@ -116,3 +123,449 @@ func TestRunCalcVerify_FilesBackedSendsVerifyQueryParam(t *testing.T) {
 		t.Fatalf("runCalc failed: %v", err)
 	}
 }
+
+func TestRunCalc_ResolvesDefinedNameRange(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origCalcRanges := append([]string(nil), calcRanges...)
+	origCalcShowTouched := calcShowTouched
+	origCalcVerify := calcVerify
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		calcRanges = origCalcRanges
+		calcShowTouched = origCalcShowTouched
+		calcVerify = origCalcVerify
+	})
+
+	execCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/xlsx/exec":
+			execCalls++
+			fmt.Fprint(w, `{"ok":true,"stdout":"","result":[{"name":"Revenue_Table","range":"Sheet1!$B$2:$D$20","scope":null}]}`)
+		case "/v0/xlsx/calc":
+			addrs := r.URL.Query()["address"]
+			if len(addrs) != 2 || addrs[0] != "Sheet1!$B$2:$D$20" || addrs[1] != "Summary!A1" {
+				t.Fatalf("unexpected addresses: %v", addrs)
+			}
+			fmt.Fprint(w, `{"touched":{},"changed":[],"errors":[]}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = true
+	calcRanges = []string{"revenue_table", "Summary!A1"}
+	calcShowTouched = false
+	calcVerify = true
+
+	if err := runCalc(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runCalc failed: %v", err)
+	}
+	if execCalls != 1 {
+		t.Fatalf("expected 1 defined-name lookup, got %d", execCalls)
+	}
+}
+
+func TestLookupDefinedName(t *testing.T) {
+	sheet1 := "Sheet1"
+	sheet2 := "Sheet2"
+	names := []client.DefinedName{
+		{Name: "Rate", Range: "Sheet1!$A$1", Scope: &sheet1},
+		{Name: "Rate", Range: "=Inputs!$B$1", Scope: nil},
+		{Name: "Local", Range: "Sheet2!A1:A5", Scope: &sheet2},
+		{Name: "Dup", Range: "Sheet1!A1", Scope: &sheet1},
+		{Name: "Dup", Range: "Sheet2!A1", Scope: &sheet2},
+		{Name: "TaxRate", Range: "0.2", Scope: nil},
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "rate", want: "Inputs!$B$1"},
+		{name: "Local", want: "Sheet2!A1:A5"},
+		{name: "Dup", wantErr: "ambiguous"},
+		{name: "TaxRate", wantErr: "does not refer to a single range"},
+		{name: "Missing", wantErr: "not a sheet-qualified range or a defined name"},
+	}
+	for _, tt := range tests {
+		got, err := lookupDefinedName(names, tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("lookupDefinedName(%q) error = %v, want containing %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("lookupDefinedName(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveRangeAddresses_R1C1AndTableReferences(t *testing.T) {
+	execCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		execCalls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":{"Sales":{"name":"Sales","ref":"'Q1 Data'!A1:C5","columns":[{"name":"Region"},{"name":"Units"},{"name":"Amount"}],"showTotalsRow":false}}}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	c := client.New(server.URL, "", "", true)
+
+	got, err := resolveRangeAddresses(c, filePath, []string{"Sheet1!R1C1:R3C2", "Sales[Amount]", "Sales[#All]", "Summary!A1"})
+	if err != nil {
+		t.Fatalf("resolveRangeAddresses: %v", err)
+	}
+	want := []string{"Sheet1!A1:B3", "'Q1 Data'!C2:C5", "'Q1 Data'!A1:C5", "Summary!A1"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if execCalls != 1 {
+		t.Fatalf("expected one table metadata call, got %d", execCalls)
+	}
+
+	if _, err := resolveRangeAddresses(c, filePath, []string{"Missing[Col]"}); err == nil || !strings.Contains(err.Error(), "table Missing not found") {
+		t.Fatalf("expected missing table error, got %v", err)
+	}
+}
+
+func TestRunCalcVerify_DirectoryRecursiveReport(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origCalcRanges := append([]string(nil), calcRanges...)
+	origCalcVerify := calcVerify
+	origCalcRecursive := calcRecursive
+	origCalcConcurrency := calcConcurrency
+	origCalcReportPath := calcReportPath
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		calcRanges = origCalcRanges
+		calcVerify = origCalcVerify
+		calcRecursive = origCalcRecursive
+		calcConcurrency = origCalcConcurrency
+		calcReportPath = origCalcReportPath
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("verify"); got != "true" {
+			t.Errorf("expected verify=true, got %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case bytes.Contains(body, []byte("stale")):
+			fmt.Fprint(w, `{"touched":{},"changed":["Sheet1!B2","Sheet1!B3"],"errors":[]}`)
+		case bytes.Contains(body, []byte("broken")):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"code":"invalid_file","message":"not a workbook"}}`)
+		default:
+			fmt.Fprint(w, `{"touched":{},"changed":[],"errors":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.xlsx":              "PK\x03\x04ok",
+		"notes.txt":           "not a workbook",
+		"~$a.xlsx":            "lock",
+		"sub/b.xlsm":          "PK\x03\x04stale",
+		"sub/deeper/c.xlsx":   "PK\x03\x04broken",
+		".git/ignored.xlsx":   "PK\x03\x04broken",
+		"sub/.cache/old.xlsx": "PK\x03\x04broken",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = false
+	calcRanges = nil
+	calcVerify = true
+	calcRecursive = true
+	calcConcurrency = 2
+	calcReportPath = filepath.Join(t.TempDir(), "report.json")
+
+	output, err := captureExecStdout(t, func() error {
+		return runCalc(&cobra.Command{}, []string{dir})
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected ExitError code 1 for the failed workbook, got %v", err)
+	}
+	if !strings.Contains(output, "3 workbooks: 1 ok, 1 inconsistent, 1 failed") {
+		t.Fatalf("unexpected summary:\n%s", output)
+	}
+
+	data, err := os.ReadFile(calcReportPath)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	var report calcVerifyReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	var got []string
+	for _, f := range report.Files {
+		rel, _ := filepath.Rel(dir, f.File)
+		got = append(got, fmt.Sprintf("%s:%s:%d", filepath.ToSlash(rel), f.Status, f.Changed))
+	}
+	if want := "a.xlsx:ok:0,sub/b.xlsm:inconsistent:2,sub/deeper/c.xlsx:failed:0"; strings.Join(got, ",") != want {
+		t.Fatalf("report files = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	// Without --recursive only the top-level workbook is checked.
+	calcRecursive = false
+	calcReportPath = ""
+	if _, err := captureExecStdout(t, func() error {
+		return runCalc(&cobra.Command{}, []string{dir})
+	}); err != nil {
+		t.Fatalf("expected top-level verify to pass, got %v", err)
+	}
+
+	calcVerify = false
+	if err := runCalc(&cobra.Command{}, []string{dir}); err == nil || !strings.Contains(err.Error(), "--verify") {
+		t.Fatalf("expected directory without --verify to fail, got %v", err)
+	}
+}
+
+func TestRunCalc_SaveToLeavesSourceUntouched(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origCalcRanges := append([]string(nil), calcRanges...)
+	origCalcVerify := calcVerify
+	origCalcSaveTo := calcSaveTo
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		calcRanges = origCalcRanges
+		calcVerify = origCalcVerify
+		calcSaveTo = origCalcSaveTo
+	})
+
+	recalculated := base64.StdEncoding.EncodeToString([]byte("PK\x03\x04recalculated"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/xlsx/calc" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"touched":{},"changed":[],"errors":[],"file":%q}`, recalculated)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04source"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = true
+	calcRanges = nil
+	calcVerify = false
+	calcSaveTo = filepath.Join(dir, "out.xlsx")
+
+	if _, err := captureExecStdout(t, func() error {
+		return runCalc(&cobra.Command{}, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runCalc --save-to failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "PK\x03\x04source" {
+		t.Fatalf("source workbook was modified: %q", got)
+	}
+	if got, _ := os.ReadFile(calcSaveTo); string(got) != "PK\x03\x04recalculated" {
+		t.Fatalf("unexpected --save-to content %q", got)
+	}
+
+	calcVerify = true
+	if err := runCalc(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "--save-to") {
+		t.Fatalf("expected --save-to/--verify conflict, got %v", err)
+	}
+}
+
+func TestRunCalc_StdinWorkbookWritesResultToStdout(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origQuietOutput := quietOutput
+	origCalcRanges := append([]string(nil), calcRanges...)
+	origCalcVerify := calcVerify
+	origCalcSaveTo := calcSaveTo
+	origStdin := os.Stdin
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		quietOutput = origQuietOutput
+		calcRanges = origCalcRanges
+		calcVerify = origCalcVerify
+		calcSaveTo = origCalcSaveTo
+		os.Stdin = origStdin
+	})
+
+	recalculated := base64.StdEncoding.EncodeToString([]byte("PK\x03\x04recalculated"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/xlsx/calc" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if got, _ := io.ReadAll(r.Body); string(got) != "PK\x03\x04piped" {
+			t.Fatalf("expected the stdin workbook to be sent, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"touched":{},"changed":[],"errors":[],"file":%q}`, recalculated)
+	}))
+	defer server.Close()
+
+	stdinPath := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(stdinPath, []byte("PK\x03\x04piped"), 0o644); err != nil {
+		t.Fatalf("writing stdin fixture: %v", err)
+	}
+	stdinFile, err := os.Open(stdinPath)
+	if err != nil {
+		t.Fatalf("opening stdin fixture: %v", err)
+	}
+	defer stdinFile.Close()
+	os.Stdin = stdinFile
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = false
+	quietOutput = false
+	calcRanges = nil
+	calcVerify = false
+	calcSaveTo = ""
+
+	out, err := captureExecStdout(t, func() error {
+		return runCalc(&cobra.Command{}, []string{"-"})
+	})
+	if err != nil {
+		t.Fatalf("runCalc - failed: %v", err)
+	}
+	if out != "PK\x03\x04recalculated" {
+		t.Fatalf("expected only the workbook bytes on stdout, got %q", out)
+	}
+}
+
+func TestRunCalcVerify_ToleranceIgnoresNumericNoise(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origCalcRanges := append([]string(nil), calcRanges...)
+	origCalcVerify := calcVerify
+	origCalcTolerance := calcTolerance
+	origCalcRelTolerance := calcRelTolerance
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		calcRanges = origCalcRanges
+		calcVerify = origCalcVerify
+		calcTolerance = origCalcTolerance
+		calcRelTolerance = origCalcRelTolerance
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v0/xlsx/calc":
+			fmt.Fprint(w, `{"touched":{"Sheet1!B2":{"value":"0.30000000000000004","formula":"=0.1+0.2"},"Sheet1!B3":{"value":"5","formula":"=A3"},"Sheet1!B4":{"value":"n/a","formula":"=A4"}},"changed":["Sheet1!B2","Sheet1!B3","Sheet1!B4"],"errors":[]}`)
+		case "/v0/xlsx/exec":
+			if err := r.ParseMultipartForm(10 << 20); err != nil {
+				t.Fatalf("parsing multipart form: %v", err)
+			}
+			if !strings.Contains(r.FormValue("exec"), `"ranges":["Sheet1!B2","Sheet1!B3","Sheet1!B4"]`) {
+				t.Fatalf("expected the changed cells to be read, got %s", r.FormValue("exec"))
+			}
+			fmt.Fprint(w, `{"ok":true,"stdout":"","result":[`+
+				`{"range":"Sheet1!B2","cells":[{"address":"Sheet1!B2","value":0.3}]},`+
+				`{"range":"Sheet1!B3","cells":[{"address":"Sheet1!B3","value":4}]},`+
+				`{"range":"Sheet1!B4","cells":[{"address":"Sheet1!B4","value":"n/a"}]}]}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = false
+	calcRanges = nil
+	calcVerify = true
+	calcTolerance = 1e-9
+	calcRelTolerance = 0
+
+	output, err := captureExecStdout(t, func() error {
+		return runCalc(&cobra.Command{}, []string{filePath})
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitFindings {
+		t.Fatalf("expected ExitFindings for the remaining changes, got %v", err)
+	}
+	if !strings.Contains(output, "Changed (2):\n  (1 change within tolerance ignored)\n  Sheet1!B3\n  Sheet1!B4\n") {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+
+	calcVerify = false
+	if err := runCalc(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "require --verify") {
+		t.Fatalf("expected --tolerance without --verify to fail, got %v", err)
+	}
+}
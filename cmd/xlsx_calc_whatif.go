@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// parseCalcSet parses a --set CELL=VALUE override. VALUE is a number (5%
+// is 0.05), TRUE or FALSE, a formula when it starts with =, or text; quote
+// it ("42") to force text.
+func parseCalcSet(s string) (client.CellOverride, error) {
+	quoted := false
+	eq := -1
+	for i := 0; i < len(s) && eq < 0; i++ {
+		switch s[i] {
+		case '\'':
+			quoted = !quoted
+		case '=':
+			if !quoted {
+				eq = i
+			}
+		}
+	}
+	if eq <= 0 {
+		return client.CellOverride{}, fmt.Errorf("--set %q: want CELL=VALUE, e.g. \"Assumptions!B2=0.05\"", s)
+	}
+	raw := strings.TrimSpace(s[eq+1:])
+	if raw == "" {
+		return client.CellOverride{}, fmt.Errorf("--set %q: missing value (use \"\" for empty text)", s)
+	}
+	return cellOverride(strings.TrimSpace(s[:eq]), raw), nil
+}
+
+// cellOverride builds the override of address from raw, read as a --set
+// VALUE.
+func cellOverride(address, raw string) client.CellOverride {
+	cell := client.CellOverride{Address: address}
+	switch {
+	case strings.HasPrefix(raw, "="):
+		cell.Formula = raw
+	case len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"':
+		cell.Value = raw[1 : len(raw)-1]
+	case strings.EqualFold(raw, "true"), strings.EqualFold(raw, "false"):
+		cell.Value = strings.EqualFold(raw, "true")
+	default:
+		if n, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64); err == nil {
+			if strings.HasSuffix(raw, "%") {
+				n /= 100
+			}
+			cell.Value = n
+		} else {
+			cell.Value = raw
+		}
+	}
+	return cell
+}
+
+// validateCalcWhatIf checks the flags that --set cannot be combined with.
+func validateCalcWhatIf() error {
+	if len(calcRanges) == 0 {
+		return fmt.Errorf("--set needs --range for the output cells to report")
+	}
+	if calcVerify || calcSaveTo != "" || calcAsync || calcStaged || calcIfRevision != "" || calcReportPath != "" || calcShowTouched {
+		return fmt.Errorf("--set cannot be combined with --verify, --save-to, --async, --staged, --if-revision, --report, or --show-touched")
+	}
+	return nil
+}
+
+// runCalcWhatIf recalculates filePath with the --set overrides and reports
+// the --range outputs. Nothing is written back.
+func runCalcWhatIf(c *client.Client, filePath, displayPath string, outputs []string) error {
+	inputs := make([]client.CellOverride, 0, len(calcSets))
+	for _, s := range calcSets {
+		cell, err := parseCalcSet(s)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, cell)
+	}
+	addrs := make([]string, len(inputs))
+	for i, in := range inputs {
+		addrs[i] = in.Address
+	}
+	resolved, err := resolveRangeAddresses(c, filePath, addrs)
+	if err != nil {
+		return err
+	}
+	for i := range inputs {
+		inputs[i].Address = resolved[i]
+	}
+
+	emitEvent("calc_started", map[string]any{"file": filePath})
+	result, err := c.WhatIf(filePath, inputs, outputs)
+	if err != nil {
+		return err
+	}
+	return emitResultAs(result, calcWhatIfTemplateData{result, displayPath}, jsonOutput, func() error {
+		printCalcWhatIf(result)
+		return nil
+	})
+}
+
+// calcWhatIfTemplateData is what --template renders for calc --set.
+type calcWhatIfTemplateData struct {
+	*client.WhatIfResult
+	File string
+}
+
+func printCalcWhatIf(result *client.WhatIfResult) {
+	fmt.Println("With:")
+	for _, in := range result.Inputs {
+		value := in.Formula
+		if value == "" {
+			value = fmt.Sprint(in.Value)
+		}
+		fmt.Printf("  %s = %s\n", in.Address, value)
+	}
+	changed := 0
+	for _, r := range result.Outputs {
+		fmt.Printf("\n%s:\n", r.Range)
+		if len(r.Cells) == 0 {
+			fmt.Println("  (empty)")
+			continue
+		}
+		width := 0
+		for _, cell := range r.Cells {
+			width = max(width, len(cell.Address))
+		}
+		for _, cell := range r.Cells {
+			after := whatIfText(cell.Text, cell.Value)
+			if !cell.Changed {
+				fmt.Printf("  %-*s  %s\n", width, cell.Address, after)
+				continue
+			}
+			changed++
+			fmt.Printf("  %-*s  %s → %s\n", width, cell.Address, whatIfText(cell.BeforeText, cell.Before), colorize(after, ansiBold))
+		}
+	}
+	fmt.Printf("\n%s changed; the workbook was not modified\n", pluralize(changed, "output cell", "output cells"))
+}
+
+// whatIfText is a cell's displayed text, or its value when it has none.
+func whatIfText(text string, value any) string {
+	if text != "" {
+		return text
+	}
+	if value == nil {
+		return "(blank)"
+	}
+	return fmt.Sprint(value)
+}
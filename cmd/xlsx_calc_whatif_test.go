@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/client/clienttest"
+)
+
+func TestParseCalcSet(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want client.CellOverride
+	}{
+		{"Assumptions!B2=0.05", client.CellOverride{Address: "Assumptions!B2", Value: 0.05}},
+		{"Assumptions!B2 = 7%", client.CellOverride{Address: "Assumptions!B2", Value: 0.07}},
+		{"Inputs!A1=TRUE", client.CellOverride{Address: "Inputs!A1", Value: true}},
+		{`Inputs!A1="42"`, client.CellOverride{Address: "Inputs!A1", Value: "42"}},
+		{"Inputs!A1=North", client.CellOverride{Address: "Inputs!A1", Value: "North"}},
+		{"Inputs!A1==B1*2", client.CellOverride{Address: "Inputs!A1", Formula: "=B1*2"}},
+		{"'Q=1'!B2=3", client.CellOverride{Address: "'Q=1'!B2", Value: 3.0}},
+		{"GrowthRate=0.1", client.CellOverride{Address: "GrowthRate", Value: 0.1}},
+	} {
+		got, err := parseCalcSet(tc.in)
+		if err != nil {
+			t.Errorf("parseCalcSet(%q): %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseCalcSet(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"Inputs!A1", "=5", "Inputs!A1="} {
+		if _, err := parseCalcSet(bad); err == nil {
+			t.Errorf("parseCalcSet(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRunCalc_SetReportsOutputsWithoutSaving(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless, origJSON := apiKey, apiURL, stateless, jsonOutput
+	origRanges, origSets, origVerify, origOutput := calcRanges, calcSets, calcVerify, resultOutputPath
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless, jsonOutput = origAPIKey, origAPIURL, origStateless, origJSON
+		calcRanges, calcSets, calcVerify, resultOutputPath = origRanges, origSets, origVerify, origOutput
+	})
+
+	srv := clienttest.NewServer(t)
+	var got client.ExecRequest
+	srv.Exec = func(wb clienttest.Workbook, req client.ExecRequest) (*client.ExecResponse, error) {
+		got = req
+		return &client.ExecResponse{Ok: true, Result: json.RawMessage(`[{"range":"Summary!B10","cells":[{"address":"Summary!B10","value":110,"text":"110","before":100,"before_text":"100","changed":true}]}]`)}, nil
+	}
+	mockMgmtOrgsServer(t)
+	apiKey, apiURL, stateless, jsonOutput = "test-key", srv.URL, true, true
+	calcVerify = false
+	calcRanges = []string{"Summary!B10"}
+	calcSets = []string{"Assumptions!B2=10%"}
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "model.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04model"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	resultOutputPath = filepath.Join(dir, "result.json")
+
+	if err := runCalc(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runCalc: %v", err)
+	}
+
+	if !strings.Contains(got.Code, "xlsx.setCells") {
+		t.Errorf("exec code does not set cells:\n%s", got.Code)
+	}
+	input, _ := json.Marshal(got.Input)
+	if want := `{"cells":[{"address":"Assumptions!B2","value":0.1}],"outputs":["Summary!B10"]}`; string(input) != want {
+		t.Errorf("exec input = %s, want %s", input, want)
+	}
+	for _, r := range srv.Requests() {
+		if r.Query.Get("save") == "true" {
+			t.Errorf("what-if exec was saved: %s %s", r.Method, r.Path)
+		}
+	}
+	if data, _ := os.ReadFile(filePath); string(data) != "PK\x03\x04model" {
+		t.Errorf("workbook was modified: %q", data)
+	}
+
+	var result client.WhatIfResult
+	data, err := os.ReadFile(resultOutputPath)
+	if err != nil {
+		t.Fatalf("reading result: %v", err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("parsing result: %v", err)
+	}
+	if len(result.Outputs) != 1 || !result.Outputs[0].Cells[0].Changed || result.Inputs[0].Value != 0.1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRunCalc_SetNeedsRange(t *testing.T) {
+	origRanges, origSets := calcRanges, calcSets
+	t.Cleanup(func() { calcRanges, calcSets = origRanges, origSets })
+	calcRanges, calcSets = nil, []string{"A!B2=1"}
+
+	err := runCalc(&cobra.Command{}, []string{"model.xlsx"})
+	if err == nil || !strings.Contains(err.Error(), "--set needs --range") {
+		t.Fatalf("expected --range error, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	depsPrecedents bool
+	depsDependents bool
+	depsDepth      int
+	depsMaxNodes   int
+)
+
+var depsCmd = &cobra.Command{
+	Use:   "deps <file> <cell>",
+	Short: "Show a cell's formula precedents or dependents",
+	Long: `Trace the formula dependencies of a cell and print them as a tree.
+
+--precedents (the default) follows the cells a formula reads from;
+--dependents follows the cells whose formulas read this one. --depth sets
+how many levels to follow. A cell reached along more than one path is
+expanded once and marked "(see above)" elsewhere.
+
+Use --json for the graph as nodes and edges.
+
+Examples:
+  witan xlsx deps report.xlsx "Summary!B10"
+  witan xlsx deps report.xlsx "Summary!B10" --precedents --depth 3
+  witan xlsx deps report.xlsx "Inputs!C4" --dependents --depth 2 --json`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeWorkbookThenRange,
+	RunE:              runDeps,
+}
+
+func init() {
+	depsCmd.Flags().BoolVar(&depsPrecedents, "precedents", false, "Trace cells the formula reads from (default)")
+	depsCmd.Flags().BoolVar(&depsDependents, "dependents", false, "Trace cells whose formulas read this cell")
+	depsCmd.Flags().IntVar(&depsDepth, "depth", 1, "Levels of dependencies to follow")
+	depsCmd.Flags().IntVar(&depsMaxNodes, "max-cells", 500, "Stop after this many cells")
+	addResultOutputFlag(depsCmd)
+	xlsxCmd.AddCommand(depsCmd)
+}
+
+func runDeps(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if depsPrecedents && depsDependents {
+		return fmt.Errorf("--precedents and --dependents are mutually exclusive")
+	}
+	if depsDepth <= 0 {
+		return fmt.Errorf("--depth must be > 0")
+	}
+	if depsMaxNodes <= 0 {
+		return fmt.Errorf("--max-cells must be > 0")
+	}
+	cell := strings.TrimSpace(args[1])
+	if cell == "" {
+		return fmt.Errorf("cell must not be empty")
+	}
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	graph, err := c.Deps(filePath, cell, client.DepsOptions{
+		Dependents: depsDependents,
+		Depth:      depsDepth,
+		MaxNodes:   depsMaxNodes,
+	})
+	if err != nil {
+		return err
+	}
+	return emitResult(graph, jsonOutput, func() error {
+		printDepsTree(graph)
+		return nil
+	})
+}
+
+func printDepsTree(graph *client.DepsGraph) {
+	nodes := make(map[string]client.DepsNode, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		nodes[n.Address] = n
+	}
+	children := make(map[string][]string)
+	for _, e := range graph.Edges {
+		children[e.From] = append(children[e.From], e.To)
+	}
+
+	expanded := make(map[string]bool)
+	var walk func(address string, level int)
+	walk = func(address string, level int) {
+		line := strings.Repeat("  ", level) + depsNodeLabel(address, nodes[address])
+		if expanded[address] && len(children[address]) > 0 {
+			fmt.Println(line + "  (see above)")
+			return
+		}
+		fmt.Println(line)
+		expanded[address] = true
+		for _, child := range children[address] {
+			walk(child, level+1)
+		}
+	}
+	walk(graph.Root, 0)
+
+	if len(graph.Nodes) <= 1 {
+		fmt.Printf("No %s found.\n", graph.Direction)
+	}
+	if graph.Truncated {
+		fmt.Printf("Stopped after %s; raise --max-cells to see more.\n", pluralize(len(graph.Nodes), "cell", "cells"))
+	}
+	for _, w := range graph.Warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+}
+
+// depsNodeLabel renders a node as its address, formula, and displayed value.
+func depsNodeLabel(address string, n client.DepsNode) string {
+	parts := []string{address}
+	if n.Formula != "" {
+		formula := n.Formula
+		if !strings.HasPrefix(formula, "=") {
+			formula = "=" + formula
+		}
+		parts = append(parts, formula)
+	}
+	if n.Text != "" {
+		parts = append(parts, "["+n.Text+"]")
+	}
+	if n.ReferenceType != "" && n.ReferenceType != "direct" {
+		parts = append(parts, "("+n.ReferenceType+")")
+	}
+	return strings.Join(parts, "  ")
+}
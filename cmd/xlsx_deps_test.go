@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func resetDepsTestGlobals(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origPrecedents := depsPrecedents
+	origDependents := depsDependents
+	origDepth := depsDepth
+	origMaxNodes := depsMaxNodes
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		depsPrecedents = origPrecedents
+		depsDependents = origDependents
+		depsDepth = origDepth
+		depsMaxNodes = origMaxNodes
+	})
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	stateless = true
+	jsonOutput = false
+	depsPrecedents = false
+	depsDependents = false
+	depsDepth = 1
+	depsMaxNodes = 500
+}
+
+func TestRunDeps_PrintsPrecedentTree(t *testing.T) {
+	resetDepsTestGlobals(t)
+
+	var input map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Fatalf("parsing multipart form: %v", err)
+		}
+		var payload struct {
+			Input map[string]any `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(r.FormValue("exec")), &payload); err != nil {
+			t.Fatalf("decoding exec payload: %v", err)
+		}
+		input = payload.Input
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":{
+			"root":"Summary!B10",
+			"nodes":[
+				{"address":"Summary!B10","depth":0,"text":"30","formula":"=B8+B9"},
+				{"address":"Summary!B8","depth":1,"text":"10","formula":"=Inputs!C4"},
+				{"address":"Summary!B9","depth":1,"text":"20","formula":"=Inputs!C4*2"},
+				{"address":"Inputs!C4","depth":2,"text":"10"}
+			],
+			"edges":[
+				{"from":"Summary!B10","to":"Summary!B8"},
+				{"from":"Summary!B10","to":"Summary!B9"},
+				{"from":"Summary!B8","to":"Inputs!C4"},
+				{"from":"Summary!B9","to":"Inputs!C4"}
+			],
+			"truncated":false}}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	apiURL = server.URL
+	depsDepth = 3
+
+	out, err := captureExecStdout(t, func() error {
+		return runDeps(&cobra.Command{}, []string{filePath, "Summary!B10"})
+	})
+	if err != nil {
+		t.Fatalf("runDeps failed: %v", err)
+	}
+	if input["cell"] != "Summary!B10" || input["dependents"] != false || input["depth"] != float64(3) {
+		t.Fatalf("unexpected exec input: %v", input)
+	}
+	want := strings.Join([]string{
+		"Summary!B10  =B8+B9  [30]",
+		"  Summary!B8  =Inputs!C4  [10]",
+		"    Inputs!C4  [10]",
+		"  Summary!B9  =Inputs!C4*2  [20]",
+		"    Inputs!C4  [10]",
+		"",
+	}, "\n")
+	if out != want {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestRunDeps_RejectsBothDirections(t *testing.T) {
+	resetDepsTestGlobals(t)
+	depsPrecedents = true
+	depsDependents = true
+
+	err := runDeps(&cobra.Command{}, []string{"book.xlsx", "A1"})
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
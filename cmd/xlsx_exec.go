@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"mime"
 	"net/http"
 	"os"
@@ -22,12 +23,23 @@ var (
 	execExpr           string
 	execInputJSON      string
 	execInputFiles     []string
+	execData           []string
+	execRequire        []string
 	execLocale         string
 	execStdinTimeoutMS int
 	execTimeoutMS      int
 	execMaxOutputChars int
 	execSave           bool
 	execCreate         bool
+	execExpect         []string
+	execExpectJSON     string
+	execStream         bool
+	execInit           bool
+	execListAPI        bool
+	execNoSyntaxCheck  bool
+	execAsync          bool
+	execSaveTo         string
+	execIfRevision     string
 )
 
 const defaultExecStdinTimeoutMS = 2000
@@ -47,6 +59,8 @@ Inputs:
   - <file> is the workbook to execute against, or the new .xlsx target path when --create is set.
   - --input-json passes any JSON value to the script as input.
   - --input-file key=@path reads a PNG/JPEG file, converts it to a data URI, and sets input[key].
+  - --data path attaches a file (CSV, JSON, text, ...) that the script reads as files["<base name>"].
+  - --require stats,dates loads server-side helper libraries; unknown names are rejected before the request.
   - --locale sets the workbook execution locale explicitly.
   - If --input-json is omitted, input defaults to {}.
 
@@ -62,6 +76,9 @@ Output:
   - Default mode prints stdout first, then:
       - pretty JSON result when ok=true
       - formatted error summary when ok=false
+  - --stream prints console output as the script runs instead of after it
+    finishes (to stderr with --json or --quiet). Servers that cannot stream
+    return it at the end as usual. Streamed requests are not retried.
   - --json prints the full response envelope.
     Success shape:
       {"ok":true,"stdout":"...","result":<json>,"writes_detected":<bool>,"accesses":[...]}
@@ -75,23 +92,80 @@ Behavior:
   - --create requires a target path ending in .xlsx that does not already exist.
   - By default, does not overwrite the local workbook.
   - With --save, writes updated workbook bytes when the API returns file/revision output.
+  - --save-to <path> saves like --save but writes to <path>, leaving <file>
+    untouched. (-o/--output is the JSON result file.)
+  - <file> "-" reads the workbook from stdin and runs statelessly; with
+    --save (or --save-to -) the workbook bytes go to stdout, the summary is
+    suppressed, and -o carries the JSON result.
   - With --create --save, writes the newly created workbook to the target path.
+  - --verify-writeback checks the returned workbook before replacing the
+    local file: every zip entry must decompress with a matching CRC and the
+    core OOXML parts must be present. The file is then re-read and compared
+    with the bytes written. Files-backed downloads are always checked
+    against the checksum the server reports, when it sends one.
+  - When the source workbook has a VBA project (.xlsm) and the returned
+    workbook does not, a warning is printed; --require-macros fails
+    instead and leaves the local file unchanged.
+  - --async submits the script as a job, prints the job ID, and exits; see
+    witan jobs. With --save the server keeps the new revision, but the local
+    workbook is not overwritten. Not available with --create, --stream, or
+    assertions. Needs files-backed mode.
+
+Assertions:
+  - --expect checks the response envelope (the --json shape) with a path,
+    an optional "| length", and an optional comparison with a JSON value:
+      .result.total >= 1000
+      .result.status == "balanced"
+      .result.errors | length == 0
+      .writes_detected == false
+    A path with no comparison must be truthy. Repeat --expect to add checks.
+  - --expect-json requires the result to equal the given JSON value.
+  - Each failed assertion is printed to stderr.
 
 Exit codes:
-  - 0: response has ok=true
+  - 0: response has ok=true (and every assertion held)
   - 1: transport/API error, invalid request, or response has ok=false
+  - 3: response has ok=true but an --expect/--expect-json assertion failed
+  - 4: authentication is required
+
+Getting started:
+  - --init prints a commented starter script covering sheets, cells,
+    input, and the returned result; it takes no workbook.
+  - --list-api prints the scripting functions the server provides.
+  - Before anything is uploaded, the script is checked locally for
+    unterminated strings, template literals, comments, and regular
+    expressions, and for unbalanced brackets. The check is a heuristic:
+    problems are printed as warnings with line:column and the script is
+    sent anyway. --no-syntax-check skips the check.
 
 Examples:
+  witan xlsx exec --init > script.js
+  witan xlsx exec --list-api
   witan xlsx exec report.xlsx --expr 'await xlsx.readCell(wb, "Summary!A1")'
   witan xlsx exec report.xlsx --script ./exec.ts --input-json '{"threshold":10}'
   witan xlsx exec report.xlsx --input-file logo=@./logo.png --code 'return input.logo'
+  witan xlsx exec model.xlsx --data sales.csv --data rates.json --script ./join.ts
   witan xlsx exec report.xlsx --code 'console.log("hi"); return {"ok":true}'
   witan xlsx exec model.xlsx --create --save --code 'await xlsx.addSheet(wb, "Inputs"); return true'
-  cat script.js | witan xlsx exec report.xlsx --stdin`,
-	Args: cobra.ExactArgs(1),
+  cat script.js | witan xlsx exec report.xlsx --stdin
+  witan xlsx exec input.xlsx --script ./fill.ts --save-to filled.xlsx
+  witan xlsx exec model.xlsx --script ./update.ts --save --verify-writeback
+  cat in.xlsx | witan xlsx exec - --script ./fill.ts --save > out.xlsx
+  witan xlsx exec model.xlsx --script ./rebuild.ts --stream --timeout 30m
+  witan xlsx exec model.xlsx --expr 'await xlsx.readCell(wb, "Summary!B10")' --expect '.result.value >= 1000'`,
+	Args: execArgs,
 	RunE: runExec,
 }
 
+// execArgs requires the workbook argument except with --init and
+// --list-api, which take none.
+func execArgs(cmd *cobra.Command, args []string) error {
+	if execInit || execListAPI {
+		return cobra.NoArgs(cmd, args)
+	}
+	return cobra.ExactArgs(1)(cmd, args)
+}
+
 func init() {
 	xlsxExecCmd.Flags().StringVar(&execCode, "code", "", "Inline TypeScript or JavaScript source")
 	xlsxExecCmd.Flags().StringVar(&execScript, "script", "", "Path to a TypeScript or JavaScript file")
@@ -99,19 +173,67 @@ func init() {
 	xlsxExecCmd.Flags().StringVar(&execExpr, "expr", "", `Single-expression shorthand; wraps as return (<expr>);`)
 	xlsxExecCmd.Flags().StringVar(&execInputJSON, "input-json", "", "JSON value passed as input to the script")
 	xlsxExecCmd.Flags().StringArrayVar(&execInputFiles, "input-file", nil, "Add a PNG/JPEG file to input as a data URI using key=@path (repeatable)")
+	xlsxExecCmd.Flags().StringSliceVar(&execRequire, "require", nil, "Load server-side helper libraries, e.g. stats,dates (repeatable)")
+	xlsxExecCmd.Flags().StringArrayVar(&execData, "data", nil, "Attach a data file the script reads as files[\"<base name>\"] (repeatable)")
 	xlsxExecCmd.Flags().StringVar(&execLocale, "locale", "", "Execution locale (env: WITAN_LOCALE; otherwise LC_ALL / LC_MESSAGES / LANG)")
 	xlsxExecCmd.Flags().IntVar(&execStdinTimeoutMS, "stdin-timeout-ms", defaultExecStdinTimeoutMS, "Maximum time to wait for EOF when reading --stdin (0 disables)")
 	xlsxExecCmd.Flags().IntVar(&execTimeoutMS, "timeout-ms", 0, "Execution timeout in milliseconds (> 0)")
 	xlsxExecCmd.Flags().IntVar(&execMaxOutputChars, "max-output-chars", 0, "Maximum stdout characters to capture (> 0)")
 	xlsxExecCmd.Flags().BoolVar(&execCreate, "create", false, "Create a new .xlsx workbook instead of opening an existing file; target path must not exist")
 	xlsxExecCmd.Flags().BoolVar(&execSave, "save", false, "Write returned workbook bytes to the target path")
+	xlsxExecCmd.Flags().StringVar(&execSaveTo, "save-to", "", "Like --save, but write the workbook to this path and leave <file> untouched")
+	xlsxExecCmd.Flags().BoolVar(&requireMacros, "require-macros", false, "With --save, fail instead of warning when the returned workbook drops the VBA project of a macro-enabled workbook")
+	xlsxExecCmd.Flags().BoolVar(&verifyWriteback, "verify-writeback", false, "With --save, check the returned workbook is intact before replacing the file, and re-read it after writing")
+	xlsxExecCmd.Flags().StringVar(&execIfRevision, "if-revision", "", "Fail with exit code 6 unless <file> is unchanged since it was uploaded as this revision and it is still the server's latest")
+	xlsxExecCmd.Flags().BoolVar(&execAsync, "async", false, "Submit as a background job and print its ID (see witan jobs)")
+	xlsxExecCmd.Flags().BoolVar(&execStream, "stream", false, "Print console output as the script runs")
+	xlsxExecCmd.Flags().BoolVar(&execInit, "init", false, "Print a commented starter script and exit, e.g. witan xlsx exec --init > script.js")
+	xlsxExecCmd.Flags().BoolVar(&execNoSyntaxCheck, "no-syntax-check", false, "Skip the local check that warns about unterminated strings and unbalanced brackets")
+	xlsxExecCmd.Flags().BoolVar(&execListAPI, "list-api", false, "Print the scripting API reference from the server and exit (--json for JSON)")
+	xlsxExecCmd.Flags().StringArrayVar(&execExpect, "expect", nil, `Assert on the response, e.g. '.result.total >= 1000'; exits 3 on failure (repeatable)`)
+	xlsxExecCmd.Flags().StringVar(&execExpectJSON, "expect-json", "", "Assert the result equals this JSON value; exits 3 on failure")
+	addImageModeFlag(xlsxExecCmd)
+	addResultOutputFlag(xlsxExecCmd)
+	addResultTemplateFlags(xlsxExecCmd)
 	xlsxCmd.AddCommand(xlsxExecCmd)
 }
 
 func runExec(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
+	if execInit && execListAPI {
+		return fmt.Errorf("--init and --list-api cannot be combined")
+	}
+	if execInit {
+		_, err := io.WriteString(cmd.OutOrStdout(), execStarterScript)
+		return err
+	}
+	if execListAPI {
+		return runExecListAPI(cmd.OutOrStdout())
+	}
+	if err := validateImageMode(); err != nil {
+		return err
+	}
+	if err := loadResultTemplate(); err != nil {
+		return err
+	}
 
-	filePath, err := resolveExecWorkbookPath(args[0], execCreate)
+	fromStdin := args[0] == stdioPath
+	if execAsync && len(execData) > 0 {
+		return fmt.Errorf("--data cannot be combined with --async")
+	}
+	if fromStdin && (execCreate || execStdin || execAsync) {
+		return fmt.Errorf("a workbook read from stdin cannot be combined with --create, --stdin, or --async")
+	}
+	filePath := args[0]
+	if fromStdin {
+		path, cleanup, err := readStdinWorkbook(os.Stdin)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		filePath = path
+	}
+	filePath, err := resolveExecWorkbookPath(filePath, execCreate)
 	if err != nil {
 		return err
 	}
@@ -125,6 +247,33 @@ func runExec(cmd *cobra.Command, args []string) error {
 	if err := validateExecPositiveFlag(cmd, "max-output-chars", execMaxOutputChars); err != nil {
 		return err
 	}
+	if err := validateExecExpectations(execExpect, execExpectJSON); err != nil {
+		return err
+	}
+	if execAsync && (execCreate || execStream || len(execExpect) > 0 || execExpectJSON != "") {
+		return fmt.Errorf("--async cannot be combined with --create, --stream, --expect, or --expect-json")
+	}
+	if execSaveTo != "" && (execCreate || execAsync) {
+		return fmt.Errorf("--save-to cannot be combined with --create or --async")
+	}
+	if verifyWriteback && (!(execSave || execSaveTo != "") || execAsync) {
+		return fmt.Errorf("--verify-writeback requires --save or --save-to and cannot be combined with --async")
+	}
+	if requireMacros && (!(execSave || execSaveTo != "") || execAsync || execCreate) {
+		return fmt.Errorf("--require-macros requires --save or --save-to and cannot be combined with --async or --create")
+	}
+	if execIfRevision != "" && (fromStdin || execCreate || execAsync) {
+		return fmt.Errorf("--if-revision cannot be combined with stdin input, --create, or --async")
+	}
+	saveTo := execSaveTo
+	if fromStdin && execSave && saveTo == "" {
+		saveTo = stdioPath
+	}
+	if saveTo == stdioPath {
+		if err := workbookToStdout(jsonOutput); err != nil {
+			return err
+		}
+	}
 
 	code, err := resolveExecCodeSource(cmd, os.Stdin, execCode, execScript, execStdin, execExpr, execStdinTimeoutMS)
 	if err != nil {
@@ -133,6 +282,9 @@ func runExec(cmd *cobra.Command, args []string) error {
 	if strings.TrimSpace(code) == "" {
 		return fmt.Errorf("exec code must not be empty")
 	}
+	if !execNoSyntaxCheck {
+		warnExecSyntax(cmd, code)
+	}
 
 	input, err := parseExecInput(execInputJSON, cmd.Flags().Changed("input-json"))
 	if err != nil {
@@ -142,6 +294,10 @@ func runExec(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	dataFiles, err := execDataFiles(execData)
+	if err != nil {
+		return err
+	}
 
 	locale, err := resolveLocale(cmd, "locale", execLocale, true, true)
 	if err != nil {
@@ -155,10 +311,16 @@ func runExec(cmd *cobra.Command, args []string) error {
 		Locale:         locale,
 		TimeoutMS:      execTimeoutMS,
 		MaxOutputChars: execMaxOutputChars,
+		Require:        parseExecRequire(execRequire),
+		DataFiles:      dataFiles,
 	}
 	if execCreate {
 		req.Filename = filepath.Base(filePath)
 	}
+	execStdoutStreamed = false
+	if execStream {
+		req.OnStdout = streamExecStdout(jsonOutput)
+	}
 
 	key, orgID, err := resolveAuth()
 	if err != nil {
@@ -166,78 +328,159 @@ func runExec(cmd *cobra.Command, args []string) error {
 	}
 
 	c := newAPIClient(key, orgID)
-	if execCreate {
-		c = client.New(resolveAPIURL(), key, orgID, true)
-		c.UserAgent = cliUserAgent()
+	if execCreate || fromStdin {
+		c = newAPIClientMode(key, orgID, true)
+	}
+	if !execCreate {
+		path, cleanupXLSB, err := serverWorkbook(c, filePath, execSave && execSaveTo == "")
+		if err != nil {
+			return err
+		}
+		defer cleanupXLSB()
+		filePath = path
+	}
+	if err := validateExecRequire(c, req.Require); err != nil {
+		return err
 	}
 
+	if execAsync {
+		job, err := submitJob(c, filePath, client.JobRequest{Operation: client.JobOperationExec, Exec: &req, Save: execSave})
+		if err != nil {
+			return err
+		}
+		return outputSubmittedJob(job, jsonOutput)
+	}
+
+	result, err := execWorkbook(c, filePath, req, execSave || saveTo != "", execCreate, saveTo, execIfRevision)
+	if err != nil {
+		return handleRevisionConflict(err, jsonOutput)
+	}
+
+	resultFile := filePath
+	if fromStdin {
+		resultFile = stdioPath
+	}
+	if err := outputExecResult(result, resultFile, jsonOutput, formatExecError); err != nil {
+		return err
+	}
+	return enforceExecExpectations(result, execExpect, execExpectJSON)
+}
+
+// runExecListAPI prints the scripting API reference the server reports.
+func runExecListAPI(w io.Writer) error {
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	fns, err := newAPIClient(key, orgID).ExecAPIReference()
+	if err != nil {
+		if client.IsNotFound(err) {
+			return fmt.Errorf("this Witan API does not publish a scripting API reference")
+		}
+		return fmt.Errorf("fetching the scripting API reference: %w", err)
+	}
+	return emitResultTo(w, fns, fns, jsonOutput, func() error {
+		writeExecAPIReference(w, fns)
+		return nil
+	})
+}
+
+// execWorkbook runs req against filePath (or creates it when create is set)
+// and, when save is set and the script succeeded, writes the resulting
+// workbook back to saveTo, or to filePath when saveTo is empty. A non-empty
+// ifRevision runs against exactly that uploaded revision (see
+// checkIfRevision), with no re-upload or retry.
+func execWorkbook(c *client.Client, filePath string, req client.ExecRequest, save, create bool, saveTo, ifRevision string) (*client.ExecResponse, error) {
 	var result *client.ExecResponse
 	var fileID string
-	if execCreate {
-		result, err = c.ExecCreate(filePath, req, execSave)
+	var err error
+	if create {
+		emitEvent("exec_started", map[string]any{"file": filePath})
+		result, err = c.ExecCreate(filePath, req, save)
+	} else if ifRevision != "" {
+		fileID, err = checkIfRevision(c, filePath, ifRevision)
+		if err == nil {
+			emitEvent("exec_started", map[string]any{"file": filePath})
+			result, err = c.FilesExec(fileID, ifRevision, req, save)
+			err = asRevisionConflict(err, filePath, fileID, ifRevision)
+		}
 	} else if c.Stateless {
-		result, err = c.Exec(filePath, req, execSave)
+		emitEvent("exec_started", map[string]any{"file": filePath})
+		result, err = c.Exec(filePath, req, save)
 	} else {
 		var revisionID string
 		fileID, revisionID, err = c.EnsureUploaded(filePath)
 		if err == nil {
-			result, err = c.FilesExec(fileID, revisionID, req, execSave)
+			emitEvent("exec_started", map[string]any{"file": filePath})
+			result, err = c.FilesExec(fileID, revisionID, req, save)
 			if client.IsNotFound(err) {
 				fileID, revisionID, err = c.ReuploadFile(filePath)
 				if err == nil {
-					result, err = c.FilesExec(fileID, revisionID, req, execSave)
+					result, err = c.FilesExec(fileID, revisionID, req, save)
+				}
+			} else if client.IsRevisionConflict(err) && resolveAutoRefresh() {
+				fileID, revisionID, err = c.RefreshRevision(filePath)
+				if err == nil {
+					result, err = c.FilesExec(fileID, revisionID, req, save)
 				}
 			}
+			err = asRevisionConflict(err, filePath, fileID, revisionID)
 		}
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if execSave && result.Ok {
-		if execCreate {
+	if saveTo == "" {
+		saveTo = filePath
+	}
+	if save && result.Ok {
+		if create {
 			if result.File == nil {
-				return fmt.Errorf("creating workbook: expected file bytes in response")
+				return nil, fmt.Errorf("creating workbook: expected file bytes in response")
 			}
 			decoded, err := base64.StdEncoding.DecodeString(*result.File)
 			if err != nil {
-				return fmt.Errorf("decoding created file: %w", err)
+				return nil, fmt.Errorf("decoding created file: %w", err)
+			}
+			if verifyWriteback {
+				if err := checkWorkbookBytes(decoded); err != nil {
+					return nil, fmt.Errorf("not writing %s: %w", filePath, err)
+				}
+			}
+			if err := writeFileAtomic(filePath, decoded); err != nil {
+				return nil, fmt.Errorf("writing created file: %w", err)
 			}
-			if err := os.WriteFile(filePath, decoded, 0o644); err != nil {
-				return fmt.Errorf("writing created file: %w", err)
+			if verifyWriteback {
+				if err := confirmWrittenFile(filePath, decoded); err != nil {
+					return nil, err
+				}
 			}
 			if _, err := fixWritebackExtension(filePath); err != nil {
-				return err
+				return nil, err
 			}
 		} else if c.Stateless && result.File != nil {
 			decoded, err := base64.StdEncoding.DecodeString(*result.File)
 			if err != nil {
-				return fmt.Errorf("decoding updated file: %w", err)
+				return nil, fmt.Errorf("decoding updated file: %w", err)
 			}
-			if err := os.WriteFile(filePath, decoded, 0o644); err != nil {
-				return fmt.Errorf("writing updated file: %w", err)
+			if err := checkMacrosKept(filePath, decoded); err != nil {
+				return nil, err
 			}
-			if _, err := fixWritebackExtension(filePath); err != nil {
-				return err
+			if err := writeBackFile(saveTo, decoded); err != nil {
+				return nil, fmt.Errorf("writing updated file: %w", err)
 			}
-		} else if !c.Stateless && result.RevisionID != nil {
-			fileBytes, err := c.DownloadFileContent(fileID, *result.RevisionID)
-			if err != nil {
-				return fmt.Errorf("downloading updated file: %w", err)
-			}
-			if err := os.WriteFile(filePath, fileBytes, 0o644); err != nil {
-				return fmt.Errorf("writing updated file: %w", err)
+			if _, err := fixWritebackExtension(saveTo); err != nil {
+				return nil, err
 			}
-			if filePath, err = fixWritebackExtension(filePath); err != nil {
-				return err
-			}
-			if err := c.UpdateCachedRevision(filePath, fileID, *result.RevisionID); err != nil {
-				return fmt.Errorf("updating local cache: %w", err)
+		} else if !c.Stateless && result.RevisionID != nil {
+			if _, err := writeBackRevision(c, filePath, saveTo, fileID, *result.RevisionID); err != nil {
+				return nil, err
 			}
 		}
 	}
 
-	return outputExecResult(result, jsonOutput, formatExecError)
+	return result, nil
 }
 
 func resolveExecWorkbookPath(filePath string, create bool) (string, error) {
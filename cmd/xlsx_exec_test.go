@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +19,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
 )
 
 func TestResolveExecCodeSource_Exclusivity(t *testing.T) {
@@ -251,6 +254,39 @@ func TestApplyExecInputFiles(t *testing.T) {
 	}
 }
 
+func TestExecDataFiles(t *testing.T) {
+	dir := t.TempDir()
+	salesPath := filepath.Join(dir, "sales.csv")
+	if err := os.WriteFile(salesPath, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatalf("writing csv: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	otherSales := filepath.Join(dir, "sub", "sales.csv")
+	if err := os.WriteFile(otherSales, []byte("c,d\n"), 0o644); err != nil {
+		t.Fatalf("writing csv: %v", err)
+	}
+
+	files, err := execDataFiles([]string{salesPath})
+	if err != nil {
+		t.Fatalf("execDataFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "sales.csv" || files[0].Path != salesPath {
+		t.Fatalf("unexpected data files: %+v", files)
+	}
+
+	if _, err := execDataFiles([]string{salesPath, otherSales}); err == nil || !strings.Contains(err.Error(), `both named "sales.csv"`) {
+		t.Fatalf("expected duplicate name error, got: %v", err)
+	}
+	if _, err := execDataFiles([]string{filepath.Join(dir, "sub")}); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Fatalf("expected directory error, got: %v", err)
+	}
+	if _, err := execDataFiles([]string{filepath.Join(dir, "missing.json")}); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
+
 func TestXlsxExecHelp_ContractSectionsPresent(t *testing.T) {
 	required := []string{
 		"Contract:",
@@ -452,6 +488,48 @@ func TestRunExec_StatelessSuccessHumanOutputAndNoOverwrite(t *testing.T) {
 	}
 }
 
+func TestRunExec_StreamPrintsConsoleOutputOnce(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, _ := writeWorkbookForExecTest(t)
+	t.Setenv("WITAN_LOCALE", "en-US")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "true" {
+			t.Fatalf("expected stream=true, got %q", r.URL.RawQuery)
+		}
+		if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			t.Fatalf("unexpected Accept header: %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: stdout\ndata: {\"text\":\"step 1\\n\"}\n\n")
+		fmt.Fprint(w, "event: stdout\ndata: {\"text\":\"step 2\\n\"}\n\n")
+		fmt.Fprint(w, "event: result\ndata: {\"ok\":true,\"stdout\":\"step 1\\nstep 2\\n\",\"result\":7}\n\n")
+	}))
+	defer server.Close()
+
+	stateless = true
+	apiURL = server.URL
+	apiKey = "test-key"
+
+	cmd := newExecTestCommand()
+	if err := cmd.Flags().Set("code", "return 7;"); err != nil {
+		t.Fatalf("setting --code: %v", err)
+	}
+	if err := cmd.Flags().Set("stream", "true"); err != nil {
+		t.Fatalf("setting --stream: %v", err)
+	}
+
+	output, err := captureExecStdout(t, func() error {
+		return runExec(cmd, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runExec failed: %v", err)
+	}
+	if output != "step 1\nstep 2\n7\n" {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+}
+
 func TestRunExec_StatelessSaveWritesWorkbookAndSetsQuery(t *testing.T) {
 	resetExecTestGlobals(t)
 	filePath, _ := writeWorkbookForExecTest(t)
@@ -719,6 +797,55 @@ func TestRunExec_OkFalseReturnsExit1AndSummary(t *testing.T) {
 	}
 }
 
+func TestRunExec_ExpectFailureReturnsExitAssertion(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, _ := writeWorkbookForExecTest(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":{"total":750,"status":"balanced"},"writes_detected":false,"accesses":[]}`)
+	}))
+	defer server.Close()
+
+	stateless = true
+	apiURL = server.URL
+	apiKey = "test-key"
+
+	run := func(t *testing.T, expects []string, expectJSON string) error {
+		t.Helper()
+		cmd := newExecTestCommand()
+		if err := cmd.Flags().Set("code", "return 1"); err != nil {
+			t.Fatalf("setting --code: %v", err)
+		}
+		execExpect = expects
+		execExpectJSON = expectJSON
+		_, err := captureExecStdout(t, func() error {
+			return runExec(cmd, []string{filePath})
+		})
+		return err
+	}
+
+	if err := run(t, []string{`.result.total >= 500`, `.result.status == "balanced"`}, `{"status":"balanced","total":750}`); err != nil {
+		t.Fatalf("expected passing assertions, got %v", err)
+	}
+
+	err := run(t, []string{`.result.total >= 1000`}, "")
+	var exitErr *ExitError
+	if err == nil || !errors.As(err, &exitErr) || exitErr.Code != ExitAssertion {
+		t.Fatalf("expected ExitAssertion, got %v", err)
+	}
+
+	err = run(t, nil, `{"total":1}`)
+	if err == nil || !errors.As(err, &exitErr) || exitErr.Code != ExitAssertion {
+		t.Fatalf("expected ExitAssertion for --expect-json mismatch, got %v", err)
+	}
+
+	err = run(t, []string{`result.total`}, "")
+	if err == nil || !strings.Contains(err.Error(), "invalid --expect") {
+		t.Fatalf("expected invalid --expect error, got %v", err)
+	}
+}
+
 func TestRunExec_StatefulReuploadsOnNotFound(t *testing.T) {
 	resetExecTestGlobals(t)
 	filePath, _ := writeWorkbookForExecTest(t)
@@ -783,6 +910,163 @@ func TestRunExec_StatefulReuploadsOnNotFound(t *testing.T) {
 	}
 }
 
+func TestRunExec_StatefulRefreshesOnRevisionConflict(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, _ := writeWorkbookForExecTest(t)
+
+	var uploadCalls, versionCalls, execCalls int
+	latest := "rev_3"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files":
+			uploadCalls++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_1","status":"ready"}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/v0/orgs/org_test/files/file_1":
+			versionCalls++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_3","status":"ready"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files/file_1/xlsx/exec":
+			execCalls++
+			if r.URL.Query().Get("revision") != latest {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"error":{"code":"revision_conflict","message":"revision rev_1 is not the latest"}}`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true,"stdout":"","result":{"ok":true}}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	stateless = false
+	apiURL = server.URL
+	apiKey = "test-key"
+	jsonOutput = true
+
+	cmd := newExecTestCommand()
+	if err := cmd.Flags().Set("code", "return true;"); err != nil {
+		t.Fatalf("setting --code: %v", err)
+	}
+	if _, err := captureExecStdout(t, func() error {
+		return runExec(cmd, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runExec failed: %v", err)
+	}
+	if uploadCalls != 1 || versionCalls != 1 || execCalls != 2 {
+		t.Fatalf("expected upload, conflict, new version, retry; got %d uploads, %d versions, %d execs", uploadCalls, versionCalls, execCalls)
+	}
+
+	// With --no-auto-refresh the conflict is reported as JSON instead.
+	noAutoRefresh = true
+	latest = "rev_9"
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	versionCalls = 0
+	execCalls = 0
+	output, err := captureExecStdout(t, func() error {
+		return runExec(cmd, []string{filePath})
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitConflict {
+		t.Fatalf("expected ExitConflict, got %v", err)
+	}
+	if versionCalls != 1 || execCalls != 1 {
+		t.Fatalf("expected no retry, got %d versions, %d execs", versionCalls, execCalls)
+	}
+	var out revisionConflictOutput
+	if err := json.Unmarshal([]byte(output), &out); err != nil {
+		t.Fatalf("decoding output %q: %v", output, err)
+	}
+	if out.Ok || out.Error.Code != "revision_conflict" || out.Error.FileID != "file_1" || out.Error.RevisionID != "rev_3" || !strings.Contains(out.Error.Message, "--no-auto-refresh") {
+		t.Fatalf("unexpected conflict output: %+v", out)
+	}
+}
+
+func TestRunExec_IfRevision(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, _ := writeWorkbookForExecTest(t)
+
+	latest := "rev_1"
+	var execRevisions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_1","status":"ready"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/files/file_1/revisions":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"object":"list","data":[{"id":%q,"object":"revision"},{"id":"rev_1","object":"revision"}]}`, latest)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files/file_1/xlsx/exec":
+			execRevisions = append(execRevisions, r.URL.Query().Get("revision"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true,"stdout":"","result":{"ok":true}}`)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	stateless = false
+	apiURL = server.URL
+	apiKey = "test-key"
+	jsonOutput = true
+
+	cmd := newExecTestCommand()
+	if err := cmd.Flags().Set("code", "return true;"); err != nil {
+		t.Fatalf("setting --code: %v", err)
+	}
+	run := func(ifRevision string) (string, error) {
+		execIfRevision = ifRevision
+		return captureExecStdout(t, func() error {
+			return runExec(cmd, []string{filePath})
+		})
+	}
+
+	// Without an upload on record there is nothing to compare against.
+	if _, err := run("rev_1"); !errors.As(err, new(*ExitError)) || len(execRevisions) != 0 {
+		t.Fatalf("expected a conflict before the first upload, got %v", err)
+	}
+	if _, err := run(""); err != nil {
+		t.Fatalf("uploading: %v", err)
+	}
+	if _, err := run("rev_1"); err != nil {
+		t.Fatalf("matching --if-revision failed: %v", err)
+	}
+	if got := strings.Join(execRevisions, ","); got != "rev_1,rev_1" {
+		t.Fatalf("exec revisions = %s", got)
+	}
+
+	latest = "rev_2"
+	output, err := run("rev_1")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitConflict {
+		t.Fatalf("expected ExitConflict, got %v", err)
+	}
+	if len(execRevisions) != 2 {
+		t.Fatalf("exec ran despite the mismatch: %v", execRevisions)
+	}
+	var out revisionConflictOutput
+	if err := json.Unmarshal([]byte(output), &out); err != nil {
+		t.Fatalf("decoding output %q: %v", output, err)
+	}
+	if out.Error.Code != "revision_mismatch" || out.Error.ExpectedRevision != "rev_1" || out.Error.RevisionID != "rev_2" {
+		t.Fatalf("unexpected mismatch output: %+v", out)
+	}
+
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	latest = "rev_1"
+	output, _ = run("rev_1")
+	if !strings.Contains(output, "has local changes since revision rev_1") {
+		t.Fatalf("expected a local-changes mismatch, got %s", output)
+	}
+}
+
 func TestRunExec_StatefulSaveDownloadsNewRevisionAndSetsQuery(t *testing.T) {
 	resetExecTestGlobals(t)
 	filePath, _ := writeWorkbookForExecTest(t)
@@ -847,6 +1131,66 @@ func TestRunExec_StatefulSaveDownloadsNewRevisionAndSetsQuery(t *testing.T) {
 	}
 }
 
+func TestRunExec_StatefulSaveSkipsUnchangedRevision(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, original := writeWorkbookForExecTest(t)
+	before, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ifNoneMatch string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_1","status":"ready"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files/file_1/xlsx/exec":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true,"stdout":"","result":{"ok":true},"writes_detected":true,"revision_id":"rev_2"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/files/file_1/content":
+			ifNoneMatch = r.Header.Get("If-None-Match")
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	stateless = false
+	apiURL = server.URL
+	apiKey = "test-key"
+
+	cmd := newExecTestCommand()
+	if err := cmd.Flags().Set("code", "return true;"); err != nil {
+		t.Fatalf("setting --code: %v", err)
+	}
+	if err := cmd.Flags().Set("save", "true"); err != nil {
+		t.Fatalf("setting --save: %v", err)
+	}
+	if _, err := captureExecStdout(t, func() error {
+		return runExec(cmd, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runExec failed: %v", err)
+	}
+
+	sum := sha256.Sum256(original)
+	if want := hex.EncodeToString(sum[:]); !strings.Contains(ifNoneMatch, want) {
+		t.Fatalf("If-None-Match %q does not carry the local hash %s", ifNoneMatch, want)
+	}
+	after, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Fatal("an unchanged revision should not be rewritten")
+	}
+	c := newAPIClient("test-key", "org_test")
+	if entry, ok := c.CachedUpload(filePath); !ok || entry.RevisionID != "rev_2" {
+		t.Fatalf("expected the cache to move to rev_2, got %+v", entry)
+	}
+}
+
 func TestRunExec_JSONOutputRawEnvelope(t *testing.T) {
 	resetExecTestGlobals(t)
 	filePath, _ := writeWorkbookForExecTest(t)
@@ -1005,6 +1349,77 @@ func TestRunExec_ImagesWrittenToTempFiles(t *testing.T) {
 	os.Remove(imgPath)
 }
 
+func TestRunExec_ImageModes(t *testing.T) {
+	resetExecTestGlobals(t)
+	filePath, _ := writeWorkbookForExecTest(t)
+
+	imgDataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"stdout":"","result":"done","images":["%s","%s"]}`, imgDataURL, imgDataURL)
+	}))
+	defer server.Close()
+
+	stateless = true
+	apiURL = server.URL
+	apiKey = "test-key"
+	jsonOutput = true
+	settingsRenderDir = t.TempDir()
+
+	run := func(mode string) client.ExecResponse {
+		t.Helper()
+		cmd := newExecTestCommand()
+		if err := cmd.Flags().Set("code", "return 'done';"); err != nil {
+			t.Fatalf("setting --code: %v", err)
+		}
+		if err := cmd.Flags().Set("image-mode", mode); err != nil {
+			t.Fatalf("setting --image-mode: %v", err)
+		}
+		output, err := captureExecStdout(t, func() error {
+			return runExec(cmd, []string{filePath})
+		})
+		if err != nil {
+			t.Fatalf("runExec failed: %v", err)
+		}
+		var resp client.ExecResponse
+		if err := json.Unmarshal([]byte(output), &resp); err != nil {
+			t.Fatalf("output should be valid JSON, got %q: %v", output, err)
+		}
+		return resp
+	}
+
+	if resp := run("inline"); len(resp.Images) != 2 || resp.Images[0] != imgDataURL {
+		t.Fatalf("inline mode should keep data URLs, got %v", resp.Images)
+	}
+	if resp := run("discard"); len(resp.Images) != 0 {
+		t.Fatalf("discard mode should drop images, got %v", resp.Images)
+	}
+
+	// The same image, returned twice in each of two runs, is written once.
+	first := run("files")
+	second := run("files")
+	if len(first.Images) != 2 || first.Images[0] != first.Images[1] || second.Images[0] != first.Images[0] {
+		t.Fatalf("identical images should share one path, got %v and %v", first.Images, second.Images)
+	}
+	if !strings.HasPrefix(first.Images[0], settingsRenderDir) || !strings.HasSuffix(first.Images[0], ".png") {
+		t.Fatalf("expected a .png under render_dir, got %q", first.Images[0])
+	}
+	entries, err := os.ReadDir(settingsRenderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 image file, found %d", len(entries))
+	}
+
+	cmd := newExecTestCommand()
+	_ = cmd.Flags().Set("code", "return 'done';")
+	_ = cmd.Flags().Set("image-mode", "thumbnails")
+	if err := runExec(cmd, []string{filePath}); err == nil || !strings.Contains(err.Error(), "--image-mode") {
+		t.Fatalf("expected an --image-mode error, got %v", err)
+	}
+}
+
 func TestExecImageExt(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -1202,12 +1617,24 @@ func resetExecTestGlobals(t *testing.T) {
 	origExecExpr := execExpr
 	origExecInputJSON := execInputJSON
 	origExecInputFiles := execInputFiles
+	origExecData := execData
+	origExecRequire := execRequire
 	origExecLocale := execLocale
 	origExecStdinTimeoutMS := execStdinTimeoutMS
 	origExecTimeoutMS := execTimeoutMS
 	origExecMaxOutputChars := execMaxOutputChars
 	origExecSave := execSave
 	origExecCreate := execCreate
+	origExecExpect := execExpect
+	origExecExpectJSON := execExpectJSON
+	origExecStream := execStream
+	origExecStdoutStreamed := execStdoutStreamed
+	origExecImageMode := execImageMode
+	origExecIfRevision := execIfRevision
+	origVerifyWriteback := verifyWriteback
+	origRequireMacros := requireMacros
+	origNoAutoRefresh := noAutoRefresh
+	origSettingsRenderDir := settingsRenderDir
 
 	t.Cleanup(func() {
 		apiKey = origAPIKey
@@ -1220,12 +1647,24 @@ func resetExecTestGlobals(t *testing.T) {
 		execExpr = origExecExpr
 		execInputJSON = origExecInputJSON
 		execInputFiles = origExecInputFiles
+		execData = origExecData
+		execRequire = origExecRequire
 		execLocale = origExecLocale
 		execStdinTimeoutMS = origExecStdinTimeoutMS
 		execTimeoutMS = origExecTimeoutMS
 		execMaxOutputChars = origExecMaxOutputChars
 		execSave = origExecSave
 		execCreate = origExecCreate
+		execExpect = origExecExpect
+		execExpectJSON = origExecExpectJSON
+		execStream = origExecStream
+		execStdoutStreamed = origExecStdoutStreamed
+		execImageMode = origExecImageMode
+		execIfRevision = origExecIfRevision
+		verifyWriteback = origVerifyWriteback
+		requireMacros = origRequireMacros
+		noAutoRefresh = origNoAutoRefresh
+		settingsRenderDir = origSettingsRenderDir
 	})
 
 	mockMgmtOrgsServer(t)
@@ -1239,12 +1678,24 @@ func resetExecTestGlobals(t *testing.T) {
 	execExpr = ""
 	execInputJSON = ""
 	execInputFiles = nil
+	execData = nil
+	execRequire = nil
 	execLocale = ""
 	execStdinTimeoutMS = defaultExecStdinTimeoutMS
 	execTimeoutMS = 0
 	execMaxOutputChars = 0
 	execSave = false
 	execCreate = false
+	execExpect = nil
+	execExpectJSON = ""
+	execStream = false
+	execStdoutStreamed = false
+	execImageMode = ""
+	execIfRevision = ""
+	verifyWriteback = false
+	requireMacros = false
+	noAutoRefresh = false
+	settingsRenderDir = ""
 }
 
 func newExecTestCommand() *cobra.Command {
@@ -1255,12 +1706,18 @@ func newExecTestCommand() *cobra.Command {
 	cmd.Flags().StringVar(&execExpr, "expr", "", "")
 	cmd.Flags().StringVar(&execInputJSON, "input-json", "", "")
 	cmd.Flags().StringArrayVar(&execInputFiles, "input-file", nil, "")
+	cmd.Flags().StringArrayVar(&execData, "data", nil, "")
+	cmd.Flags().StringSliceVar(&execRequire, "require", nil, "")
 	cmd.Flags().StringVar(&execLocale, "locale", "", "")
 	cmd.Flags().IntVar(&execStdinTimeoutMS, "stdin-timeout-ms", defaultExecStdinTimeoutMS, "")
 	cmd.Flags().IntVar(&execTimeoutMS, "timeout-ms", 0, "")
 	cmd.Flags().IntVar(&execMaxOutputChars, "max-output-chars", 0, "")
 	cmd.Flags().BoolVar(&execCreate, "create", false, "")
 	cmd.Flags().BoolVar(&execSave, "save", false, "")
+	cmd.Flags().StringArrayVar(&execExpect, "expect", nil, "")
+	cmd.Flags().StringVar(&execExpectJSON, "expect-json", "", "")
+	cmd.Flags().BoolVar(&execStream, "stream", false, "")
+	cmd.Flags().StringVar(&execImageMode, "image-mode", "", "")
 	return cmd
 }
 
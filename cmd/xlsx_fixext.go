@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"archive/zip"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/witanlabs/witan-cli/client"
 )
 
 // excelFormat represents the detected binary format of an Excel file.
@@ -14,8 +18,13 @@ const (
 	excelFormatUnknown excelFormat = iota
 	excelFormatOLE2                // Binary .xls (magic: d0cf11e0a1b11ae1)
 	excelFormatOOXML               // ZIP-based .xlsx (magic: 504b0304)
+	excelFormatBIFF12              // ZIP-based .xlsb: OOXML package with binary parts
 )
 
+// xlsbWorkbookPart is the workbook part of a BIFF12 (.xlsb) package; XML
+// workbooks have xl/workbook.xml instead.
+const xlsbWorkbookPart = "xl/workbook.bin"
+
 // detectExcelFormat reads the first bytes of a file and returns the detected format.
 func detectExcelFormat(filePath string) (excelFormat, error) {
 	f, err := os.Open(filePath)
@@ -40,84 +49,216 @@ func detectExcelFormat(filePath string) (excelFormat, error) {
 
 	// ZIP (OOXML): PK\x03\x04
 	if buf[0] == 0x50 && buf[1] == 0x4b && buf[2] == 0x03 && buf[3] == 0x04 {
+		if zipHasPart(filePath, xlsbWorkbookPart) {
+			return excelFormatBIFF12, nil
+		}
 		return excelFormatOOXML, nil
 	}
 
 	return excelFormatUnknown, nil
 }
 
-// fixExcelExtension checks whether a file's extension matches its actual content.
-// If there is a mismatch (.xls with OOXML content or .xlsx with OLE2 content),
-// it renames the file on disk and returns the new path. A note is emitted to stderr.
-// If the extension matches or the file is not .xls/.xlsx, it returns the path unchanged.
-func fixExcelExtension(filePath string) (string, error) {
+// Modes for a workbook whose extension does not match its content, set by
+// --fix-ext, WITAN_FIX_EXT, or the fix_ext setting.
+const (
+	fixExtRename = "rename" // rename the file on disk (default)
+	fixExtCopy   = "copy"   // work on a correctly named temp copy
+	fixExtOff    = "off"    // use the file as named
+)
+
+var (
+	fixExtMode string
+	noFixExt   bool
+)
+
+// fixExtCopies maps the temp copies made in copy mode to the files they
+// copy, so writes to a copy land on the original; see writeBackFile.
+var fixExtCopies = struct {
+	sync.Mutex
+	m map[string]string
+}{m: map[string]string{}}
+
+// resolveFixExtMode returns the mode: --no-fix-ext means off, else
+// --fix-ext, WITAN_FIX_EXT, or the fix_ext setting, defaulting to rename.
+func resolveFixExtMode() (string, error) {
+	if noFixExt {
+		return fixExtOff, nil
+	}
+	v := fixExtMode
+	if v == "" {
+		v = os.Getenv("WITAN_FIX_EXT")
+	}
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "":
+		return fixExtRename, nil
+	case fixExtRename, fixExtCopy, fixExtOff:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid --fix-ext %q: use rename, copy, or off", v)
+}
+
+// correctedExcelPath returns the path filePath should have for its content,
+// or "" when its extension already matches or it is not an Excel workbook.
+// OOXML content in a .xls or .xlsb becomes .xlsm when it has a VBA project
+// and .xlsx otherwise; OLE2 content becomes .xls and BIFF12 content .xlsb.
+// A .xlsm holding OOXML keeps its extension.
+func correctedExcelPath(filePath string) (string, excelFormat, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext != ".xls" && ext != ".xlsx" {
-		return filePath, nil
+	if ext != ".xls" && ext != ".xlsx" && ext != ".xlsm" && ext != ".xlsb" {
+		return "", excelFormatUnknown, nil
 	}
 
 	format, err := detectExcelFormat(filePath)
 	if err != nil {
-		return filePath, err
+		return "", excelFormatUnknown, err
 	}
 
-	if format == excelFormatUnknown {
+	base := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+	switch {
+	case (ext == ".xls" || ext == ".xlsb") && format == excelFormatOOXML:
+		if zipHasPart(filePath, vbaProjectPart) {
+			return base + ".xlsm", format, nil
+		}
+		return base + ".xlsx", format, nil
+	case ext != ".xls" && format == excelFormatOLE2:
+		return base + ".xls", format, nil
+	case ext != ".xlsb" && format == excelFormatBIFF12:
+		return base + ".xlsb", format, nil
+	}
+	return "", format, nil // extension matches content, or content unknown
+}
+
+// zipHasPart reports whether the zip at filePath has a part called name,
+// compared case-insensitively as OPC part names are.
+func zipHasPart(filePath, name string) bool {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if strings.EqualFold(f.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func excelFormatName(format excelFormat) string {
+	switch format {
+	case excelFormatOLE2:
+		return "OLE2"
+	case excelFormatBIFF12:
+		return "BIFF12"
+	}
+	return "OOXML"
+}
+
+// fixExcelExtension checks whether a file's extension matches its actual content.
+// If there is a mismatch (.xls with OOXML content or .xlsx with OLE2 content),
+// it renames the file on disk and returns the new path. A note is emitted to stderr.
+// With --fix-ext copy it returns a correctly named temp copy instead, and
+// with --fix-ext off (or --no-fix-ext) the path as given.
+// If the extension matches or the file is not .xls/.xlsx/.xlsm/.xlsb, it returns the
+// path unchanged apart from expanding a leading ~ (see client.ExpandHome).
+func fixExcelExtension(filePath string) (string, error) {
+	filePath = client.ExpandHome(filePath)
+	newPath, format, err := correctedExcelPath(filePath)
+	if err != nil {
+		return filePath, err
+	}
+	if newPath == "" {
 		return filePath, nil
 	}
+	mode, err := resolveFixExtMode()
+	if err != nil {
+		return "", err
+	}
 
-	var newPath string
-	switch {
-	case ext == ".xls" && format == excelFormatOOXML:
-		newPath = filePath + "x" // .xls → .xlsx
-	case ext == ".xlsx" && format == excelFormatOLE2:
-		newPath = strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".xls" // .xlsx → .xls
-	default:
-		return filePath, nil // extension matches content
+	name, formatName := filepath.Base(filePath), excelFormatName(format)
+	switch mode {
+	case fixExtOff:
+		fmt.Fprintf(os.Stderr, "note: %s is %s format; using it as named (--fix-ext off)\n", name, formatName)
+		return filePath, nil
+	case fixExtCopy:
+		copyPath, err := copyForExtension(filePath, filepath.Base(newPath))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s is %s format; working on a copy named %s and leaving the file as named\n", name, formatName, filepath.Base(newPath))
+		return copyPath, nil
 	}
 
 	// Don't silently overwrite an existing file
 	if _, err := os.Stat(newPath); err == nil {
-		return "", fmt.Errorf("cannot rename %s to %s: target already exists", filepath.Base(filePath), filepath.Base(newPath))
+		return "", fmt.Errorf("cannot rename %s to %s: target already exists (use --fix-ext copy to work on a copy)", name, filepath.Base(newPath))
 	}
 
 	if err := os.Rename(filePath, newPath); err != nil {
-		return "", fmt.Errorf("renaming %s: %w", filepath.Base(filePath), err)
+		return "", fmt.Errorf("renaming %s: %w", name, err)
 	}
 
-	formatName := "OOXML"
-	if format == excelFormatOLE2 {
-		formatName = "OLE2"
-	}
-	fmt.Fprintf(os.Stderr, "note: %s is %s format — renamed to %s\n", filepath.Base(filePath), formatName, filepath.Base(newPath))
+	fmt.Fprintf(os.Stderr, "note: %s is %s format — renamed to %s\n", name, formatName, filepath.Base(newPath))
 
 	return newPath, nil
 }
 
-// fixWritebackExtension checks a file that was just written back by the server.
-// If the server converted OLE2→OOXML, the written bytes
-// may not match the file extension. This renames to match.
-func fixWritebackExtension(filePath string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext != ".xls" && ext != ".xlsx" {
-		return filePath, nil
+// copyForExtension copies filePath to a new directory in this run's temp
+// dir under name, and records the copy in fixExtCopies.
+func copyForExtension(filePath, name string) (string, error) {
+	root, err := runTempDir()
+	if err != nil {
+		return "", err
 	}
-
-	format, err := detectExcelFormat(filePath)
+	dir, err := os.MkdirTemp(root, "fixext-*")
 	if err != nil {
-		return filePath, err
+		return "", fmt.Errorf("copying %s: %w", filepath.Base(filePath), err)
 	}
+	copyPath := filepath.Join(dir, name)
+	if err := copyFileAtomic(filePath, copyPath); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("copying %s: %w", filepath.Base(filePath), err)
+	}
+	fixExtCopies.Lock()
+	fixExtCopies.m[copyPath] = filePath
+	fixExtCopies.Unlock()
+	return copyPath, nil
+}
 
-	if format == excelFormatUnknown {
-		return filePath, nil
+// fixExtOriginal returns the file a copy-mode temp copy was made from, or
+// path itself.
+func fixExtOriginal(path string) string {
+	fixExtCopies.Lock()
+	defer fixExtCopies.Unlock()
+	if orig, ok := fixExtCopies.m[path]; ok {
+		return orig
 	}
+	return path
+}
 
-	var newPath string
-	switch {
-	case ext == ".xls" && format == excelFormatOOXML:
-		newPath = filePath + "x"
-	case ext == ".xlsx" && format == excelFormatOLE2:
-		newPath = strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".xls"
-	default:
+// removeFixExtCopies deletes the copy-mode temp copies as the CLI exits.
+func removeFixExtCopies() {
+	fixExtCopies.Lock()
+	defer fixExtCopies.Unlock()
+	for copyPath := range fixExtCopies.m {
+		os.RemoveAll(filepath.Dir(copyPath))
+		delete(fixExtCopies.m, copyPath)
+	}
+}
+
+// fixWritebackExtension checks a file that was just written back by the server.
+// If the server converted OLE2→OOXML, the written bytes
+// may not match the file extension. This renames to match, except with
+// --fix-ext copy or off, which leave user files as named.
+func fixWritebackExtension(filePath string) (string, error) {
+	newPath, format, err := correctedExcelPath(filePath)
+	if err != nil || newPath == "" {
+		return filePath, err
+	}
+	if mode, err := resolveFixExtMode(); err != nil {
+		return "", err
+	} else if mode != fixExtRename {
+		fmt.Fprintf(os.Stderr, "note: %s now holds %s content; leaving it as named (--fix-ext %s)\n", filepath.Base(fixExtOriginal(filePath)), excelFormatName(format), mode)
 		return filePath, nil
 	}
 
@@ -128,6 +269,9 @@ func fixWritebackExtension(filePath string) (string, error) {
 	if err := os.Rename(filePath, newPath); err != nil {
 		return "", fmt.Errorf("renaming %s: %w", filepath.Base(filePath), err)
 	}
+	if err := copyUndoVersions(filePath, newPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not keep undo copies for %s: %v\n", filepath.Base(newPath), err)
+	}
 
 	fmt.Fprintf(os.Stderr, "note: converted output saved as %s\n", filepath.Base(newPath))
 
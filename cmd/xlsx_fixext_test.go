@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"archive/zip"
 	"os"
 	"path/filepath"
 	"testing"
@@ -224,3 +225,79 @@ func TestFixWritebackExtension(t *testing.T) {
 		}
 	})
 }
+
+func TestFixExcelExtension_Modes(t *testing.T) {
+	ooxmlHeader := []byte{0x50, 0x4b, 0x03, 0x04, 0x00, 0x00, 0x00, 0x00}
+	t.Setenv("WITAN_UNDO_DIR", t.TempDir())
+	t.Cleanup(func() { fixExtMode, noFixExt = "", false })
+
+	t.Run("off uses the file as named", func(t *testing.T) {
+		fixExtMode, noFixExt = "", true
+		f := filepath.Join(t.TempDir(), "budget.xls")
+		if err := os.WriteFile(f, ooxmlHeader, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := fixExcelExtension(f)
+		if err != nil || got != f {
+			t.Fatalf("got %q, %v; want %q", got, err, f)
+		}
+	})
+
+	t.Run("copy works on a renamed copy and writes back to the original", func(t *testing.T) {
+		fixExtMode, noFixExt = fixExtCopy, false
+		t.Cleanup(removeFixExtCopies)
+		f := filepath.Join(t.TempDir(), "budget.xls")
+		if err := os.WriteFile(f, ooxmlHeader, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := fixExcelExtension(f)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if filepath.Base(got) != "budget.xlsx" || got == filepath.Join(filepath.Dir(f), "budget.xlsx") {
+			t.Fatalf("got %q, want a temp copy named budget.xlsx", got)
+		}
+		if _, err := os.Stat(f); err != nil {
+			t.Fatalf("original was moved: %v", err)
+		}
+
+		updated := append([]byte(nil), ooxmlHeader...)
+		updated = append(updated, "updated"...)
+		if err := writeBackFile(got, updated); err != nil {
+			t.Fatalf("writeBackFile: %v", err)
+		}
+		for _, path := range []string{f, got} {
+			if data, _ := os.ReadFile(path); string(data) != string(updated) {
+				t.Errorf("%s = %q, want the written bytes", path, data)
+			}
+		}
+	})
+
+	t.Run("xls with a VBA project renames to xlsm", func(t *testing.T) {
+		fixExtMode, noFixExt = "", false
+		dir := t.TempDir()
+		f := filepath.Join(dir, "macros.xls")
+		out, err := os.Create(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(out)
+		if _, err := zw.Create("xl/vbaProject.bin"); err != nil {
+			t.Fatal(err)
+		}
+		zw.Close()
+		out.Close()
+
+		got, err := fixExcelExtension(f)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := filepath.Join(dir, "macros.xlsm"); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		// An .xlsm holding OOXML keeps its extension.
+		if again, err := fixExcelExtension(got); err != nil || again != got {
+			t.Errorf("second pass = %q, %v; want %q", again, err, got)
+		}
+	})
+}
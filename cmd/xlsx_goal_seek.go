@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	goalSeekTarget        string
+	goalSeekBy            string
+	goalSeekMin           float64
+	goalSeekMax           float64
+	goalSeekTolerance     float64
+	goalSeekMaxIterations int
+)
+
+var goalSeekCmd = &cobra.Command{
+	Use:   "goal-seek <file> --target CELL=GOAL --by CELL --min N --max N",
+	Short: "Find the input value that makes a formula reach a goal",
+	Long: `Find the value of an input cell that makes a formula cell reach a goal,
+like Excel's Goal Seek.
+
+Behavior:
+  - --target CELL=GOAL names the formula cell and the number it should
+    reach; --by names the input cell to vary between --min and --max.
+    Either cell may be a defined name.
+  - The workbook is recalculated with candidate input values until the
+    target is within --tolerance of the goal (default 1e-6), or
+    --max-iterations recalculations have run (default 100).
+  - The target must cross the goal somewhere between --min and --max;
+    when it does not, the values at both ends are reported so the range
+    can be widened.
+  - The workbook is not modified: the solving input is reported, not
+    written. Use witan xlsx exec or calc --set to apply it.
+  - Returns exit code 2 when no solution is found.
+
+Use --json for machine-readable results.
+
+Examples:
+  witan xlsx goal-seek model.xlsx --target "Summary!D10=1000000" --by "Inputs!B2" --min 0 --max 1
+  witan xlsx goal-seek model.xlsx --target NPV=0 --by DiscountRate --min 0 --max 0.5 --tolerance 0.01`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runGoalSeek,
+}
+
+func init() {
+	goalSeekCmd.Flags().StringVar(&goalSeekTarget, "target", "", `Formula cell and the number it should reach, e.g. "Summary!D10=1000000"`)
+	goalSeekCmd.Flags().StringVar(&goalSeekBy, "by", "", `Input cell to vary, e.g. "Inputs!B2"`)
+	goalSeekCmd.Flags().Float64Var(&goalSeekMin, "min", 0, "Lowest input value to try")
+	goalSeekCmd.Flags().Float64Var(&goalSeekMax, "max", 0, "Highest input value to try")
+	goalSeekCmd.Flags().Float64Var(&goalSeekTolerance, "tolerance", 1e-6, "Largest accepted difference between the target and the goal")
+	goalSeekCmd.Flags().IntVar(&goalSeekMaxIterations, "max-iterations", 100, "Most recalculations to run, including those at --min and --max")
+	for _, name := range []string{"target", "by", "min", "max"} {
+		_ = goalSeekCmd.MarkFlagRequired(name)
+	}
+	addResultOutputFlag(goalSeekCmd)
+	xlsxCmd.AddCommand(goalSeekCmd)
+}
+
+// parseGoalSeekTarget parses --target CELL=GOAL, where GOAL is a number
+// (5% is 0.05).
+func parseGoalSeekTarget(s string) (string, float64, error) {
+	cell, err := parseCalcSet(s)
+	if err != nil || cell.Formula != "" {
+		return "", 0, fmt.Errorf("--target %q: want CELL=GOAL, e.g. \"Summary!D10=1000000\"", s)
+	}
+	goal, ok := cell.Value.(float64)
+	if !ok {
+		return "", 0, fmt.Errorf("--target %q: the goal must be a number", s)
+	}
+	return cell.Address, goal, nil
+}
+
+func runGoalSeek(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	target, goal, err := parseGoalSeekTarget(goalSeekTarget)
+	if err != nil {
+		return err
+	}
+	if goalSeekMin >= goalSeekMax {
+		return fmt.Errorf("--min (%g) must be less than --max (%g)", goalSeekMin, goalSeekMax)
+	}
+	if goalSeekTolerance <= 0 {
+		return fmt.Errorf("--tolerance must be > 0")
+	}
+	if goalSeekMaxIterations < 2 {
+		return fmt.Errorf("--max-iterations must be at least 2")
+	}
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	cells, err := resolveRangeAddresses(c, filePath, []string{target, goalSeekBy})
+	if err != nil {
+		return err
+	}
+	result, err := c.GoalSeek(filePath, client.GoalSeekRequest{
+		Target:        cells[0],
+		Goal:          goal,
+		By:            cells[1],
+		Min:           goalSeekMin,
+		Max:           goalSeekMax,
+		Tolerance:     goalSeekTolerance,
+		MaxIterations: goalSeekMaxIterations,
+	})
+	if err != nil {
+		return err
+	}
+	if err := emitResult(result, jsonOutput, func() error {
+		printGoalSeek(result)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !result.Converged {
+		return &ExitError{Code: ExitFindings}
+	}
+	return nil
+}
+
+func printGoalSeek(result *client.GoalSeekResult) {
+	switch result.Reason {
+	case "":
+		fmt.Printf("%s = %s\n", result.By, colorize(fmt.Sprintf("%.10g", result.Value), ansiBold))
+		fmt.Printf("  %s = %.10g (goal %.10g, residual %.3g)\n", result.Target, result.Result, result.Goal, result.Residual)
+		fmt.Printf("  solved in %s; the workbook was not modified\n", pluralize(result.Iterations, "recalculation", "recalculations"))
+		return
+	case "not_bracketed":
+		fmt.Printf("%s: %s does not reach %.10g between %s = %.10g and %.10g\n", colorize("No solution", ansiRed), result.Target, result.Goal, result.By, goalSeekMin, goalSeekMax)
+		fmt.Printf("  at %.10g: %.10g\n  at %.10g: %.10g\n", goalSeekMin, result.AtMin, goalSeekMax, result.AtMax)
+		fmt.Println("  Widen --min and --max so the target crosses the goal.")
+		return
+	}
+	fmt.Printf("%s after %s: the best value found is %s = %.10g\n", colorize("Not converged", ansiYellow), pluralize(result.Iterations, "recalculation", "recalculations"), result.By, result.Value)
+	fmt.Printf("  %s = %.10g (goal %.10g, residual %.3g)\n", result.Target, result.Result, result.Goal, result.Residual)
+	if result.Reason == "max_iterations" {
+		fmt.Println("  Raise --max-iterations or --tolerance.")
+	}
+}
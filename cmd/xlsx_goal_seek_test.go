@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/client/clienttest"
+)
+
+func TestParseGoalSeekTarget(t *testing.T) {
+	cell, goal, err := parseGoalSeekTarget("Summary!D10=1000000")
+	if err != nil || cell != "Summary!D10" || goal != 1e6 {
+		t.Errorf("parseGoalSeekTarget = %q, %v, %v", cell, goal, err)
+	}
+	if _, goal, _ := parseGoalSeekTarget("Margin=25%"); goal != 0.25 {
+		t.Errorf("goal = %v, want 0.25", goal)
+	}
+	for _, bad := range []string{"Summary!D10", "Summary!D10=high", "Summary!D10==A1*2"} {
+		if _, _, err := parseGoalSeekTarget(bad); err == nil {
+			t.Errorf("parseGoalSeekTarget(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRunGoalSeek(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless, origJSON := apiKey, apiURL, stateless, jsonOutput
+	origTarget, origBy, origMin, origMax := goalSeekTarget, goalSeekBy, goalSeekMin, goalSeekMax
+	origTol, origIter, origOutput := goalSeekTolerance, goalSeekMaxIterations, resultOutputPath
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless, jsonOutput = origAPIKey, origAPIURL, origStateless, origJSON
+		goalSeekTarget, goalSeekBy, goalSeekMin, goalSeekMax = origTarget, origBy, origMin, origMax
+		goalSeekTolerance, goalSeekMaxIterations, resultOutputPath = origTol, origIter, origOutput
+	})
+
+	srv := clienttest.NewServer(t)
+	var got client.ExecRequest
+	converged := true
+	srv.Exec = func(wb clienttest.Workbook, req client.ExecRequest) (*client.ExecResponse, error) {
+		got = req
+		if !converged {
+			return &client.ExecResponse{Ok: true, Result: json.RawMessage(`{"value":1,"result":400000,"residual":-600000,"iterations":2,"converged":false,"reason":"not_bracketed","at_min":100000,"at_max":400000}`)}, nil
+		}
+		return &client.ExecResponse{Ok: true, Result: json.RawMessage(`{"value":0.125,"result":1000000,"residual":0,"iterations":5,"converged":true,"at_min":100000,"at_max":4000000}`)}, nil
+	}
+	mockMgmtOrgsServer(t)
+	apiKey, apiURL, stateless, jsonOutput = "test-key", srv.URL, true, true
+	goalSeekTarget, goalSeekBy = "Summary!D10=1000000", "Inputs!B2"
+	goalSeekMin, goalSeekMax, goalSeekTolerance, goalSeekMaxIterations = 0, 1, 1e-6, 100
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "model.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04model"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	resultOutputPath = filepath.Join(dir, "result.json")
+
+	if err := runGoalSeek(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runGoalSeek: %v", err)
+	}
+	input, _ := json.Marshal(got.Input)
+	if want := `{"by":"Inputs!B2","goal":1000000,"max":1,"max_iterations":100,"min":0,"target":"Summary!D10","tolerance":0.000001}`; string(input) != want {
+		t.Errorf("exec input = %s, want %s", input, want)
+	}
+	for _, r := range srv.Requests() {
+		if r.Query.Get("save") == "true" {
+			t.Errorf("goal seek exec was saved: %s %s", r.Method, r.Path)
+		}
+	}
+	data, err := os.ReadFile(resultOutputPath)
+	if err != nil {
+		t.Fatalf("reading result: %v", err)
+	}
+	var result client.GoalSeekResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("parsing result: %v", err)
+	}
+	if result.Value != 0.125 || result.Iterations != 5 || result.Target != "Summary!D10" || result.By != "Inputs!B2" {
+		t.Errorf("result = %+v", result)
+	}
+
+	converged = false
+	err = runGoalSeek(&cobra.Command{}, []string{filePath})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitFindings {
+		t.Fatalf("expected exit code %d, got %v", ExitFindings, err)
+	}
+}
+
+func TestRunGoalSeek_RejectsEmptyRange(t *testing.T) {
+	origTarget, origMin, origMax := goalSeekTarget, goalSeekMin, goalSeekMax
+	t.Cleanup(func() { goalSeekTarget, goalSeekMin, goalSeekMax = origTarget, origMin, origMax })
+	goalSeekTarget, goalSeekMin, goalSeekMax = "A!B1=5", 1, 1
+
+	err := runGoalSeek(&cobra.Command{}, []string{"model.xlsx"})
+	if err == nil || !strings.Contains(err.Error(), "--min (1) must be less than --max (1)") {
+		t.Fatalf("expected --min error, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	restoreRevision string
+	restoreOutput   string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <file>",
+	Short: "List a workbook's server-side revisions",
+	Long: `List the revisions the server holds for a workbook: every upload and
+every calc, exec, or rpc --save that wrote back a new revision.
+
+The file must have been uploaded from this machine (any files-backed
+command does that); its server identity is looked up in the local upload
+cache. The revision the local file currently corresponds to is marked
+with *.
+
+Examples:
+  witan xlsx history report.xlsx
+  witan xlsx history report.xlsx --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file> --revision <id>",
+	Short: "Restore a server-side revision of a workbook",
+	Long: `Download a revision listed by 'witan xlsx history' and write it over the
+local file, or to -o FILE to keep the local file untouched.
+
+Examples:
+  witan xlsx restore report.xlsx --revision rev_3
+  witan xlsx restore report.xlsx --revision rev_3 -o report-rev3.xlsx`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreRevision, "revision", "", "Revision ID to restore (required)")
+	restoreCmd.Flags().StringVarP(&restoreOutput, "output", "o", "", "Write the revision to this file instead of overwriting <file>")
+	_ = restoreCmd.MarkFlagRequired("revision")
+	addResultOutputFlag(historyCmd)
+	xlsxCmd.AddCommand(historyCmd)
+	xlsxCmd.AddCommand(restoreCmd)
+}
+
+// historyClient returns a files-backed client and the cached server
+// identity of filePath.
+func historyClient(filePath string) (*client.Client, client.CacheEntry, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, client.CacheEntry{}, fmt.Errorf("cannot access file: %w", err)
+	}
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return nil, client.CacheEntry{}, err
+	}
+	c := newAPIClient(key, orgID)
+	if c.Stateless {
+		return nil, client.CacheEntry{}, fmt.Errorf("revision history requires files-backed mode: sign in with 'witan auth login' or set --api-key, and do not use --stateless")
+	}
+	entry, ok := c.CachedUpload(filePath)
+	if !ok {
+		return nil, client.CacheEntry{}, fmt.Errorf("%s has no server history from this machine; run a files-backed command such as 'witan xlsx lint' on it first", filePath)
+	}
+	return c, entry, nil
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	filePath := args[0]
+	c, entry, err := historyClient(filePath)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.ListRevisions(entry.FileID)
+	if err != nil {
+		return err
+	}
+	return emitResult(revisions, jsonOutput, func() error {
+		if len(revisions) == 0 {
+			fmt.Println("No revisions.")
+			return nil
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  REVISION\tCREATED\tSIZE\tSOURCE")
+		for _, rev := range revisions {
+			marker := " "
+			if rev.ID == entry.RevisionID {
+				marker = "*"
+			}
+			fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\n", marker, rev.ID, rev.CreatedAt, formatBytes(rev.Bytes), rev.Source)
+		}
+		return tw.Flush()
+	})
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	filePath := args[0]
+	c, entry, err := historyClient(filePath)
+	if err != nil {
+		return err
+	}
+
+	fileBytes, err := c.DownloadFileContent(entry.FileID, restoreRevision)
+	if err != nil {
+		return err
+	}
+	outPath := filePath
+	if restoreOutput != "" {
+		outPath = restoreOutput
+	}
+	if err := writeBackFile(outPath, fileBytes); err != nil {
+		return fmt.Errorf("writing restored file: %w", err)
+	}
+	// Tie the written file to the restored revision so the next command
+	// reuses it instead of uploading the same bytes again.
+	if err := c.RecordDownloadedRevision(outPath, entry.FileID, restoreRevision, fileBytes); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return jsonPrint(map[string]any{
+			"file_id":     entry.FileID,
+			"revision_id": restoreRevision,
+			"path":        outPath,
+			"bytes":       len(fileBytes),
+		})
+	}
+	if !quietOutput {
+		fmt.Fprintf(os.Stderr, "Restored %s to %s (%s)\n", restoreRevision, outPath, formatBytes(int64(len(fileBytes))))
+	}
+	return nil
+}
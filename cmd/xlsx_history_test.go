@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunHistoryAndRestore(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origRestoreRevision := restoreRevision
+	origRestoreOutput := restoreOutput
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		restoreRevision = origRestoreRevision
+		restoreOutput = origRestoreOutput
+	})
+	t.Setenv("TMPDIR", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v0/orgs/org_test/files":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"file_1","object":"file","filename":"book.xlsx","bytes":8,"revision_id":"rev_2","status":"ready"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/files/file_1/xlsx/lint":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"diagnostics":[]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/files/file_1/revisions":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"object":"list","data":[
+				{"id":"rev_2","object":"revision","bytes":2048,"created_at":"2026-01-02T00:00:00Z","source":"calc"},
+				{"id":"rev_1","object":"revision","bytes":1024,"created_at":"2026-01-01T00:00:00Z","source":"upload"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v0/orgs/org_test/files/file_1/content":
+			if got := r.URL.Query().Get("revision"); got != "rev_1" {
+				t.Fatalf("expected revision rev_1, got %q", got)
+			}
+			fmt.Fprint(w, "PK\x03\x04old")
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04new"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+
+	mockMgmtOrgsServer(t)
+	apiKey = "test-key"
+	apiURL = server.URL
+	stateless = false
+	jsonOutput = false
+
+	if err := runHistory(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "no server history") {
+		t.Fatalf("expected no-history error before upload, got %v", err)
+	}
+	if _, err := captureExecStdout(t, func() error {
+		return runLint(&cobra.Command{}, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runLint failed: %v", err)
+	}
+
+	out, err := captureExecStdout(t, func() error {
+		return runHistory(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runHistory failed: %v", err)
+	}
+	if !strings.Contains(out, "* rev_2") || !strings.Contains(out, "  rev_1") || !strings.Contains(out, "1.0 KB") {
+		t.Fatalf("unexpected history output:\n%s", out)
+	}
+
+	restoreRevision = "rev_1"
+	restoreOutput = filepath.Join(t.TempDir(), "old.xlsx")
+	if err := runRestore(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runRestore -o failed: %v", err)
+	}
+	if got, _ := os.ReadFile(restoreOutput); string(got) != "PK\x03\x04old" {
+		t.Fatalf("unexpected restored content %q", got)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "PK\x03\x04new" {
+		t.Fatalf("restore -o modified the source file: %q", got)
+	}
+
+	restoreOutput = ""
+	if err := runRestore(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runRestore failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "PK\x03\x04old" {
+		t.Fatalf("expected file overwritten with rev_1, got %q", got)
+	}
+}
@@ -1,16 +1,28 @@
 package cmd
 
 import (
+	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/witanlabs/witan-cli/client"
 )
 
 var (
-	lintRanges   []string
-	lintSkipRule []string
-	lintOnlyRule []string
+	lintRanges     []string
+	lintSkipRule   []string
+	lintOnlyRule   []string
+	lintConfigPath string
+	lintNoConfig   bool
+	lintFailOn     string
+	lintSeverity   []string
+	lintAnnotate   bool
+	lintAnnotateTo string
+	lintStaged     bool
+	lintReport     string
+	lintExitZero   bool
 )
 
 const lintRulesHelp = `Available rules:
@@ -49,33 +61,149 @@ var lintCmd = &cobra.Command{
 
 Behavior:
   - Checks the entire workbook by default.
-  - Use one or more --range values to limit analysis.
-  - Returns exit code 2 when any Error or Warning is reported.
+  - Use one or more --range values to limit analysis: sheet-qualified A1 or R1C1
+    ranges, defined names, or table references such as Table1[Sales].
+  - Returns exit code 2 when any Error or Warning is reported. Use
+    --fail-on error|warning|info|none to change which severities fail, or
+    --exit-zero to report findings without failing.
+  - --severity RULE=LEVEL overrides the severity reported for a rule, e.g.
+    --severity D031=error; overrides also decide --fail-on.
+  - --annotate writes each finding as a cell comment into a copy of the
+    workbook (book.lint.xlsx, or --annotate-output) for review in Excel.
+    Cells with an existing comment get the findings as a reply. The
+    original workbook is not modified.
+  - --strip minimal uploads a copy without cached formula values and the
+    thumbnail, statelessly; aggressive also drops printer settings and
+    unused cell styles. The workbook on disk is not modified.
   - Use --json for machine-readable results.
 
+Project config:
+  - The nearest .witanlint.yml (or .witanlint.yaml) in the workbook's
+    directory or a parent directory is applied automatically. Use --config
+    to pick a file, or --no-config to ignore it.
+  - rules: maps rule IDs to off, on, error, warning, or info. Rules set to
+    off are skipped unless named with --only-rule; a severity overrides the
+    reported severity (and so the exit code).
+  - exclude: lists ranges (or bare sheet names) whose diagnostics are
+    dropped, optionally only for the listed rules.
+  - dictionary: lists words the D031 spelling check accepts.
+  - fail_on: sets the default --fail-on threshold.
+
+    rules:
+      D001: off
+      D003: info
+    exclude:
+      - range: "Assumptions!A1:Z200"
+        rules: [D003]
+    dictionary: [EBITDA, Capex]
+
 ` + lintRulesHelp + `
 
 Examples:
   witan xlsx lint report.xlsx
   witan xlsx lint report.xlsx -r "Sheet1!A1:Z50"
+  witan xlsx lint report.xlsx -r Revenue_Table
   witan xlsx lint report.xlsx --skip-rule D001
-  witan xlsx lint report.xlsx --only-rule D001 --only-rule D030`,
-	Args: cobra.ExactArgs(1),
-	RunE: runLint,
+  witan xlsx lint report.xlsx --only-rule D001 --only-rule D030
+  witan xlsx lint report.xlsx --fail-on error --severity D003=error
+  witan xlsx lint report.xlsx --config ci/.witanlint.yml
+  witan xlsx lint report.xlsx --annotate --annotate-output review.xlsx
+  witan xlsx lint report.xlsx --staged
+  witan xlsx lint report.xlsx --report junit:lint.xml`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runLint,
 }
 
 func init() {
-	lintCmd.Flags().StringArrayVarP(&lintRanges, "range", "r", nil, `Sheet-qualified range to lint (repeatable)`)
+	lintCmd.Flags().StringArrayVarP(&lintRanges, "range", "r", nil, `Range to lint: A1 or R1C1, defined name, or table reference (repeatable)`)
+	_ = lintCmd.RegisterFlagCompletionFunc("range", completeWorkbookRange)
 	lintCmd.Flags().StringArrayVarP(&lintSkipRule, "skip-rule", "s", nil, `Rule ID to skip (repeatable)`)
 	lintCmd.Flags().StringArrayVar(&lintOnlyRule, "only-rule", nil, `Run only these rule IDs (repeatable)`)
+	lintCmd.Flags().StringVar(&lintConfigPath, "config", "", "Lint config file (default: nearest .witanlint.yml above the workbook)")
+	lintCmd.Flags().BoolVar(&lintNoConfig, "no-config", false, "Ignore .witanlint.yml files")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "", "Lowest severity that exits 2: error, warning, info, or none (default warning)")
+	lintCmd.Flags().StringArrayVar(&lintSeverity, "severity", nil, "Override a rule's severity, e.g. D031=error (repeatable)")
+	lintCmd.Flags().BoolVar(&lintExitZero, "exit-zero", false, "Exit 0 even when findings are reported (report-only runs)")
+	lintCmd.Flags().BoolVar(&lintAnnotate, "annotate", false, "Write findings as cell comments into a copy of the workbook")
+	lintCmd.Flags().StringVar(&lintAnnotateTo, "annotate-output", "", "Path for the --annotate copy (default: <name>.lint.<ext>)")
+	lintCmd.Flags().StringVar(&lintReport, "report", "", "Also write a report: junit:PATH for JUnit XML, or json:PATH")
+	lintCmd.Flags().BoolVar(&lintStaged, "staged", false, "Lint the version of <file> staged in git's index, not the working tree")
+	addUploadStripFlag(lintCmd)
+	addResultOutputFlag(lintCmd)
+	addResultTemplateFlags(lintCmd)
+	addResultFormatFlag(lintCmd)
 	xlsxCmd.AddCommand(lintCmd)
 }
 
 func runLint(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 	filePath := args[0]
+	if err := loadResultTemplate(); err != nil {
+		return err
+	}
+	if err := validateResultFormat(); err != nil {
+		return err
+	}
 
-	filePath, err := fixExcelExtension(filePath)
+	// workbookPath is what gets linted: filePath, or with --staged a temp
+	// copy of its staged version. Config lookup and reports use filePath.
+	workbookPath := filePath
+	if lintStaged {
+		if lintAnnotate {
+			return fmt.Errorf("--staged cannot be combined with --annotate")
+		}
+		staged, cleanup, err := readStagedWorkbook(filePath)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		workbookPath = staged
+	}
+	workbookPath, err := fixExcelExtension(workbookPath)
+	if err != nil {
+		return err
+	}
+	if !lintStaged {
+		filePath = workbookPath
+	}
+
+	cfg, err := resolveLintConfig(filePath, lintConfigPath, lintNoConfig)
+	if err != nil {
+		return err
+	}
+	overrides, err := parseLintSeverityOverrides(lintSeverity)
+	if err != nil {
+		return err
+	}
+	failOn := lintFailOn
+	if failOn == "" && cfg != nil {
+		failOn = cfg.FailOn
+	}
+	if failOn == "" {
+		failOn = defaultLintFailOn
+	}
+	failRank, err := lintFailOnRank(failOn)
+	if err != nil {
+		return err
+	}
+	if lintReport != "" {
+		if _, _, err := parseReportSpec(lintReport); err != nil {
+			return err
+		}
+	}
+	annotatePath := lintAnnotateTo
+	if annotatePath != "" && !lintAnnotate {
+		return fmt.Errorf("--annotate-output requires --annotate")
+	}
+	if lintAnnotate && annotatePath == "" {
+		annotatePath = defaultAnnotatePath(filePath)
+	}
+	if lintAnnotate && filepath.Clean(annotatePath) == filepath.Clean(filePath) {
+		return fmt.Errorf("--annotate-output must differ from the workbook path")
+	}
+
+	strip, err := resolveUploadStrip()
 	if err != nil {
 		return err
 	}
@@ -86,10 +214,25 @@ func runLint(cmd *cobra.Command, args []string) error {
 	}
 
 	c := newAPIClient(key, orgID)
+	if lintStaged || strip {
+		// The temp copy has no stable path to reuse an upload for, and a
+		// stripped copy is never stored as a revision.
+		c = newAPIClientMode(key, orgID, true)
+	}
+	workbookPath, cleanupXLSB, err := serverWorkbook(c, workbookPath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	ranges, err := resolveRangeAddresses(c, workbookPath, lintRanges)
+	if err != nil {
+		return err
+	}
 
 	// Build query params with repeated values
 	params := url.Values{}
-	for _, r := range lintRanges {
+	for _, r := range ranges {
 		params.Add("range", r)
 	}
 	for _, r := range lintSkipRule {
@@ -98,26 +241,56 @@ func runLint(cmd *cobra.Command, args []string) error {
 	for _, r := range lintOnlyRule {
 		params.Add("onlyRule", r)
 	}
-
-	var result *client.LintResponse
-	if c.Stateless {
-		result, err = c.Lint(filePath, params)
-	} else {
-		var fileId, revisionId string
-		fileId, revisionId, err = c.EnsureUploaded(filePath)
-		if err == nil {
-			result, err = c.FilesLint(fileId, revisionId, params)
-			if client.IsNotFound(err) {
-				fileId, revisionId, err = c.ReuploadFile(filePath)
-				if err == nil {
-					result, err = c.FilesLint(fileId, revisionId, params)
-				}
-			}
-		}
+	if cfg != nil {
+		cfg.applyParams(params, lintOnlyRule)
 	}
+
+	result, err := fetchLint(c, workbookPath, params)
 	if err != nil {
 		return err
 	}
+	if cfg != nil {
+		cfg.filter(result)
+	}
+	applyLintSeverityOverrides(result, overrides)
 
-	return outputLintResult(result, jsonOutput)
+	if lintAnnotate {
+		n, err := annotateLintFindings(c, filePath, annotatePath, result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Annotated %d cell(s): %s\n", n, annotatePath)
+	}
+
+	if lintReport != "" {
+		err := writeReportFile(lintReport, result, func() junitTestSuites {
+			return newJUnitTestSuites("witan xlsx lint", lintJUnitSuite(filePath, result, failRank))
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return exitZeroFindings(outputLintResult(result, filePath, jsonOutput, failOn), lintExitZero)
+}
+
+// fetchLint lints filePath, reusing the uploaded revision when stateful.
+func fetchLint(c *client.Client, filePath string, params url.Values) (*client.LintResponse, error) {
+	if c.Stateless {
+		return c.Lint(filePath, params)
+	}
+
+	fileId, revisionId, err := c.EnsureUploaded(filePath)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.FilesLint(fileId, revisionId, params)
+	if client.IsNotFound(err) {
+		fileId, revisionId, err = c.ReuploadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		result, err = c.FilesLint(fileId, revisionId, params)
+	}
+	return result, err
 }
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	mergeTakes       []string
+	mergeOutput      string
+	mergeOnCollision string
+)
+
+// maxSheetNameLen is Excel's limit on sheet name length.
+const maxSheetNameLen = 31
+
+// mergeExtractScript reads each of input.ranges (a sheet name or a
+// sheet-qualified range; every sheet when empty) and returns its non-blank
+// cells with values, formulas, and number formats.
+const mergeExtractScript = `let specs = input.ranges;
+if (specs.length === 0) {
+  specs = (await xlsx.listSheets(wb)).map((s) => s.sheet);
+}
+const out = [];
+for (const spec of specs) {
+  const bang = spec.lastIndexOf("!");
+  const range = bang >= 0 ? spec : { sheet: spec };
+  const sheet = bang >= 0 ? spec.slice(0, bang).replace(/^'(.*)'$/, "$1").replace(/''/g, "'") : spec;
+  const cells = [];
+  for (const row of await xlsx.readRange(wb, range)) {
+    for (const v of row) {
+      if (v.type === "blank" && !v.formula) continue;
+      cells.push({ row: v.row, col: v.col, value: v.value, formula: v.formula, format: v.format });
+    }
+  }
+  out.push({ sheet, cells });
+}
+return out;`
+
+// mergeWriteScript writes input.sheets into a new workbook, renaming the
+// fresh workbook's empty default sheet for the first one.
+const mergeWriteScript = `const existing = await xlsx.listSheets(wb);
+let reuse = existing.length === 1 && existing[0].rows === 0 ? existing[0].sheet : null;
+for (const s of input.sheets) {
+  if (reuse !== null) {
+    if (reuse !== s.name) await xlsx.renameSheet(wb, reuse, s.name);
+    reuse = null;
+  } else {
+    await xlsx.addSheet(wb, s.name);
+  }
+  const cells = s.cells.map((c) => {
+    const cell = { address: { sheet: s.name, row: c.row, col: c.col } };
+    if (c.formula) cell.formula = c.formula;
+    else cell.value = c.value;
+    if (c.format) cell.format = c.format;
+    return cell;
+  });
+  if (cells.length > 0) await xlsx.setCells(wb, cells);
+}
+return input.sheets.map((s) => s.name);`
+
+var xlsxMergeCmd = &cobra.Command{
+	Use:   "merge --take <book:sheet> ... -o <out.xlsx>",
+	Short: "Combine sheets and ranges from several workbooks into one",
+	Long: `Combine sheets and ranges from several workbooks into a new .xlsx workbook.
+
+Sources (--take, repeatable, in output order):
+  - book.xlsx            every sheet of book.xlsx
+  - book.xlsx:Summary    one sheet
+  - book.xlsx:Data!A1:F100
+                         one range, kept at the same cell addresses
+
+Behavior:
+  - Values, formulas, and number formats are copied. Formulas are copied as
+    written, so references to sheets that were not taken (or were renamed)
+    will not resolve.
+  - Sheet names that collide (case-insensitively) are handled by
+    --on-collision: rename (default) appends " (2)", " (3)", ...; skip
+    drops the later sheet; error stops without writing anything.
+  - The -o path must end in .xlsx and must not already exist.
+
+Examples:
+  witan xlsx merge --take a.xlsx:Summary --take "b.xlsx:Data!A1:F100" -o combined.xlsx
+  witan xlsx merge --take q1.xlsx --take q2.xlsx -o year.xlsx --on-collision skip`,
+	Args: cobra.NoArgs,
+	RunE: runMerge,
+}
+
+func init() {
+	xlsxMergeCmd.Flags().StringArrayVar(&mergeTakes, "take", nil, `Source as book.xlsx, book.xlsx:Sheet, or "book.xlsx:Sheet!A1:F100" (repeatable)`)
+	xlsxMergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "Path of the merged workbook to create (required)")
+	xlsxMergeCmd.Flags().StringVar(&mergeOnCollision, "on-collision", "rename", "When sheet names collide: rename, skip, or error")
+	_ = xlsxMergeCmd.MarkFlagRequired("take")
+	_ = xlsxMergeCmd.MarkFlagRequired("output")
+	xlsxCmd.AddCommand(xlsxMergeCmd)
+}
+
+// mergeTake is one parsed --take source.
+type mergeTake struct {
+	Path  string
+	Range string // sheet name or sheet-qualified range; empty for all sheets
+}
+
+// mergeSheet is one sheet of the merged workbook.
+type mergeSheet struct {
+	Name   string          `json:"name"`
+	Source string          `json:"source"`
+	Cells  json.RawMessage `json:"cells,omitempty"`
+}
+
+// parseMergeTake splits "book.xlsx:Data!A1:F100" after the workbook
+// extension, so drive letters and range colons are not mistaken for the
+// separator.
+func parseMergeTake(spec string) (mergeTake, error) {
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != ':' {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(spec[:i])) {
+		case ".xlsx", ".xlsm", ".xls":
+			if spec[i+1:] == "" {
+				return mergeTake{}, fmt.Errorf("--take %q: missing sheet after ':'", spec)
+			}
+			return mergeTake{Path: spec[:i], Range: spec[i+1:]}, nil
+		}
+	}
+	switch strings.ToLower(filepath.Ext(spec)) {
+	case ".xlsx", ".xlsm", ".xls":
+		return mergeTake{Path: spec}, nil
+	}
+	return mergeTake{}, fmt.Errorf("--take %q: expected book.xlsx, book.xlsx:Sheet, or book.xlsx:Sheet!A1:B2", spec)
+}
+
+// uniqueSheetName returns name, or name with a " (n)" suffix that is not in
+// used (compared case-insensitively), truncated to Excel's length limit.
+func uniqueSheetName(name string, used map[string]bool) string {
+	candidate := truncateSheetName(name, "")
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = truncateSheetName(name, fmt.Sprintf(" (%d)", n))
+	}
+	return candidate
+}
+
+func truncateSheetName(name, suffix string) string {
+	runes := []rune(name)
+	if limit := maxSheetNameLen - len([]rune(suffix)); len(runes) > limit {
+		runes = runes[:limit]
+	}
+	return string(runes) + suffix
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	switch mergeOnCollision {
+	case "rename", "skip", "error":
+	default:
+		return fmt.Errorf("--on-collision must be rename, skip, or error")
+	}
+	outPath, err := resolveExecWorkbookPath(mergeOutput, true)
+	if err != nil {
+		return err
+	}
+	takes := make([]mergeTake, 0, len(mergeTakes))
+	for _, spec := range mergeTakes {
+		take, err := parseMergeTake(spec)
+		if err != nil {
+			return err
+		}
+		if take.Path, err = fixExcelExtension(take.Path); err != nil {
+			return err
+		}
+		takes = append(takes, take)
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+
+	used := map[string]bool{}
+	var sheets []mergeSheet
+	for _, take := range takes {
+		extracted, err := extractMergeSheets(c, take)
+		if err != nil {
+			return err
+		}
+		for _, s := range extracted {
+			name := s.Name
+			if used[strings.ToLower(truncateSheetName(name, ""))] {
+				switch mergeOnCollision {
+				case "skip":
+					fmt.Fprintf(os.Stderr, "Skipping %s from %s: sheet name already taken\n", name, take.Path)
+					continue
+				case "error":
+					return fmt.Errorf("sheet %q from %s collides with an earlier sheet; use --on-collision rename or skip", name, take.Path)
+				}
+			}
+			s.Name = uniqueSheetName(name, used)
+			used[strings.ToLower(s.Name)] = true
+			sheets = append(sheets, s)
+		}
+	}
+	if len(sheets) == 0 {
+		return fmt.Errorf("nothing to merge")
+	}
+
+	req := client.ExecRequest{
+		Code:     mergeWriteScript,
+		Input:    map[string]any{"sheets": sheets},
+		Filename: filepath.Base(outPath),
+	}
+	result, err := execWorkbook(newAPIClientMode(key, orgID, true), outPath, req, true, true, "", "")
+	if err != nil {
+		return err
+	}
+	if !result.Ok {
+		return fmt.Errorf("writing merged workbook: %s", formatExecError(result.Error))
+	}
+
+	for i := range sheets {
+		sheets[i].Cells = nil
+	}
+	return emitResult(map[string]any{"path": outPath, "sheets": sheets}, jsonOutput, func() error {
+		fmt.Printf("Merged %s into %s\n", pluralize(len(sheets), "sheet", "sheets"), outPath)
+		for _, s := range sheets {
+			fmt.Printf("  %-31s  %s\n", s.Name, s.Source)
+		}
+		return nil
+	})
+}
+
+// extractMergeSheets reads the cells of take from its workbook.
+func extractMergeSheets(c *client.Client, take mergeTake) ([]mergeSheet, error) {
+	ranges := []string{}
+	if take.Range != "" {
+		ranges = append(ranges, take.Range)
+	}
+	req := client.ExecRequest{
+		Code:  mergeExtractScript,
+		Input: map[string]any{"ranges": ranges},
+	}
+	result, err := execWorkbook(c, take.Path, req, false, false, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if !result.Ok {
+		return nil, fmt.Errorf("reading %s: %s", take.Path, formatExecError(result.Error))
+	}
+	var extracted []struct {
+		Sheet string          `json:"sheet"`
+		Cells json.RawMessage `json:"cells"`
+	}
+	if err := json.Unmarshal(result.Result, &extracted); err != nil {
+		return nil, fmt.Errorf("reading %s: unexpected result: %w", take.Path, err)
+	}
+	sheets := make([]mergeSheet, 0, len(extracted))
+	for _, e := range extracted {
+		source := take.Path + ":" + e.Sheet
+		if strings.Contains(take.Range, "!") {
+			source = take.Path + ":" + take.Range
+		}
+		sheets = append(sheets, mergeSheet{Name: e.Sheet, Source: source, Cells: e.Cells})
+	}
+	return sheets, nil
+}
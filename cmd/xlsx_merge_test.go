@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseMergeTake(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantPath  string
+		wantRange string
+		wantErr   bool
+	}{
+		{spec: "a.xlsx", wantPath: "a.xlsx"},
+		{spec: "a.xlsx:Summary", wantPath: "a.xlsx", wantRange: "Summary"},
+		{spec: "b.XLSX:Data!A1:F100", wantPath: "b.XLSX", wantRange: "Data!A1:F100"},
+		{spec: `C:\books\c.xlsm:'My Sheet'!A1:B2`, wantPath: `C:\books\c.xlsm`, wantRange: "'My Sheet'!A1:B2"},
+		{spec: "a.xlsx:", wantErr: true},
+		{spec: "notes.txt:Sheet1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseMergeTake(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseMergeTake(%q): expected error", tt.spec)
+			}
+			continue
+		}
+		if err != nil || got.Path != tt.wantPath || got.Range != tt.wantRange {
+			t.Errorf("parseMergeTake(%q) = %+v, %v", tt.spec, got, err)
+		}
+	}
+}
+
+func TestUniqueSheetName(t *testing.T) {
+	used := map[string]bool{"data": true, "data (2)": true}
+	if got := uniqueSheetName("Data", used); got != "Data (3)" {
+		t.Fatalf("expected Data (3), got %q", got)
+	}
+	long := strings.Repeat("x", 40)
+	used[strings.ToLower(long[:maxSheetNameLen])] = true
+	if got := uniqueSheetName(long, used); got != long[:27]+" (2)" {
+		t.Fatalf("expected truncated name with suffix, got %q", got)
+	}
+}
+
+func TestRunMerge_RenamesCollidingSheets(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origMergeTakes := mergeTakes
+	origMergeOutput := mergeOutput
+	origMergeOnCollision := mergeOnCollision
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		mergeTakes = origMergeTakes
+		mergeOutput = origMergeOutput
+		mergeOnCollision = origMergeOnCollision
+	})
+
+	merged := base64.StdEncoding.EncodeToString([]byte("PK\x03\x04merged"))
+	var written []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Fatalf("parsing multipart form: %v", err)
+		}
+		var payload struct {
+			Input map[string]json.RawMessage `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(r.FormValue("exec")), &payload); err != nil {
+			t.Fatalf("parsing exec payload: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("create") != "true" {
+			var ranges []string
+			_ = json.Unmarshal(payload.Input["ranges"], &ranges)
+			if len(ranges) != 1 {
+				t.Fatalf("expected one range per take, got %v", ranges)
+			}
+			sheet, _, _ := strings.Cut(ranges[0], "!")
+			fmt.Fprintf(w, `{"ok":true,"stdout":"","result":[{"sheet":%q,"cells":[{"row":1,"col":1,"value":1}]}]}`, sheet)
+			return
+		}
+		var sheets []mergeSheet
+		if err := json.Unmarshal(payload.Input["sheets"], &sheets); err != nil {
+			t.Fatalf("parsing sheets: %v", err)
+		}
+		for _, s := range sheets {
+			if len(s.Cells) == 0 {
+				t.Fatalf("expected cells for sheet %s", s.Name)
+			}
+			written = append(written, s.Name)
+		}
+		fmt.Fprintf(w, `{"ok":true,"stdout":"","result":[],"file":%q}`, merged)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.xlsx", "b.xlsx"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("PK\x03\x04"+name), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = false
+	mergeTakes = []string{filepath.Join(dir, "a.xlsx") + ":Data", filepath.Join(dir, "b.xlsx") + ":data!A1:B2"}
+	mergeOutput = filepath.Join(dir, "out.xlsx")
+	mergeOnCollision = "rename"
+
+	out, err := captureExecStdout(t, func() error {
+		return runMerge(&cobra.Command{}, nil)
+	})
+	if err != nil {
+		t.Fatalf("runMerge failed: %v", err)
+	}
+	if strings.Join(written, ",") != "Data,data (2)" {
+		t.Fatalf("unexpected merged sheet names %v", written)
+	}
+	if !strings.Contains(out, "Merged 2 sheets") {
+		t.Fatalf("unexpected summary %q", out)
+	}
+	if got, _ := os.ReadFile(mergeOutput); string(got) != "PK\x03\x04merged" {
+		t.Fatalf("unexpected merged workbook %q", got)
+	}
+
+	mergeOnCollision = "error"
+	os.Remove(mergeOutput)
+	if err := runMerge(&cobra.Command{}, nil); err == nil || !strings.Contains(err.Error(), "collides") {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	newOutput   string
+	newFrom     string
+	newSheet    string
+	newTemplate string
+)
+
+// newWorkbookScript writes input.rows into input.sheet starting at A1. A
+// fresh workbook's single empty sheet is renamed rather than left behind.
+const newWorkbookScript = `const sheets = await xlsx.listSheets(wb);
+let sheet = input.sheet;
+if (!sheet) {
+  sheet = sheets.length > 0 ? sheets[0].sheet : "Sheet1";
+}
+if (!sheets.some((s) => s.sheet === sheet)) {
+  if (input.create && sheets.length === 1 && sheets[0].rows === 0) {
+    await xlsx.renameSheet(wb, sheets[0].sheet, sheet);
+  } else {
+    await xlsx.addSheet(wb, sheet);
+  }
+}
+const cells = [];
+let cols = 0;
+input.rows.forEach((row, r) => {
+  cols = Math.max(cols, row.length);
+  row.forEach((value, c) => {
+    if (value !== null) {
+      cells.push({ address: { sheet, row: r + 1, col: c + 1 }, value });
+    }
+  });
+});
+if (cells.length > 0) {
+  await xlsx.setCells(wb, cells);
+}
+if (input.header && cols > 0) {
+  await xlsx.setStyle(wb, { sheet, from: { row: 1, col: 1 }, to: { row: 1, col: cols } }, { font: { bold: true } });
+}
+if (input.create && cols > 0) {
+  await xlsx.autoFitColumns(wb, sheet);
+}
+return { sheet, rows: input.rows.length, cols };`
+
+var xlsxNewCmd = &cobra.Command{
+	Use:   "new -o <file.xlsx>",
+	Short: "Create a workbook, blank or from CSV/JSON/YAML data",
+	Long: `Create a new .xlsx workbook at -o: blank, or filled from a data file.
+
+Data (--from):
+  - .csv or .tsv: one row per line. Numbers become numeric cells.
+  - .json, .yaml, or .yml: an array of objects (keys become a header row,
+    in first-seen order) or an array of arrays. Nested values are written
+    as JSON text.
+  - Data is written to --sheet (default: Sheet1, or the template's first
+    sheet) starting at A1. A header row from objects is made bold.
+
+Template (--template):
+  - Starts from a copy of the template workbook, so its sheets, styles,
+    column widths, and formulas are kept; the data is written into it.
+    The template file is not modified.
+
+Behavior:
+  - The -o path must end in .xlsx and must not already exist.
+
+Examples:
+  witan xlsx new -o blank.xlsx
+  witan xlsx new -o out.xlsx --from data.csv --sheet Data
+  witan xlsx new -o report.xlsx --from rows.json --template report-template.xlsx`,
+	Args: cobra.NoArgs,
+	RunE: runNew,
+}
+
+func init() {
+	xlsxNewCmd.Flags().StringVarP(&newOutput, "output", "o", "", "Path of the workbook to create (required)")
+	xlsxNewCmd.Flags().StringVar(&newFrom, "from", "", "CSV, TSV, JSON, or YAML file with the data to write")
+	xlsxNewCmd.Flags().StringVar(&newSheet, "sheet", "", "Sheet to write the data to")
+	xlsxNewCmd.Flags().StringVar(&newTemplate, "template", "", "Workbook whose sheets and formatting the new workbook starts from")
+	_ = xlsxNewCmd.MarkFlagRequired("output")
+	xlsxCmd.AddCommand(xlsxNewCmd)
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	outPath, err := resolveExecWorkbookPath(newOutput, true)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]any{}
+	header := false
+	if newFrom != "" {
+		if rows, header, err = loadTableData(newFrom); err != nil {
+			return err
+		}
+		if rows == nil {
+			rows = [][]any{}
+		}
+	}
+
+	req := client.ExecRequest{
+		Code: newWorkbookScript,
+		Input: map[string]any{
+			"sheet":  newSheet,
+			"rows":   rows,
+			"header": header && newTemplate == "",
+			"create": newTemplate == "",
+		},
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+
+	var result *client.ExecResponse
+	if newTemplate != "" {
+		templatePath, err := fixExcelExtension(newTemplate)
+		if err != nil {
+			return err
+		}
+		result, err = execWorkbook(newAPIClient(key, orgID), templatePath, req, true, false, outPath, "")
+		if err != nil {
+			return err
+		}
+	} else {
+		req.Filename = filepath.Base(outPath)
+		result, err = execWorkbook(newAPIClientMode(key, orgID, true), outPath, req, true, true, "", "")
+		if err != nil {
+			return err
+		}
+	}
+	result.File = nil
+
+	var summary struct {
+		Sheet string `json:"sheet"`
+		Rows  int    `json:"rows"`
+		Cols  int    `json:"cols"`
+	}
+	if result.Ok {
+		_ = json.Unmarshal(result.Result, &summary)
+	}
+	if err := emitResult(result, jsonOutput, func() error {
+		if !result.Ok {
+			fmt.Println(formatExecError(result.Error))
+			return nil
+		}
+		if summary.Rows == 0 {
+			fmt.Printf("Created %s\n", outPath)
+		} else {
+			fmt.Printf("Created %s: %s × %s in %s\n", outPath,
+				pluralize(summary.Rows, "row", "rows"), pluralize(summary.Cols, "column", "columns"), summary.Sheet)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !result.Ok {
+		if !resultShownOnStdout(jsonOutput) {
+			fmt.Fprintln(os.Stderr, formatExecError(result.Error))
+		}
+		return &ExitError{Code: ExitFailure}
+	}
+	return nil
+}
+
+// loadTableData reads rows from a CSV, TSV, JSON, or YAML file. header
+// reports whether the first row was built from object keys.
+func loadTableData(path string) (rows [][]any, header bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading --from: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv", ".tsv":
+		r := csv.NewReader(strings.NewReader(string(data)))
+		if ext == ".tsv" {
+			r.Comma = '\t'
+		}
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, false, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		for _, record := range records {
+			row := make([]any, len(record))
+			for i, field := range record {
+				row[i] = csvCellValue(field)
+			}
+			rows = append(rows, row)
+		}
+		return rows, false, nil
+	case ".json", ".yaml", ".yml":
+		// JSON is valid YAML, and yaml.Node keeps object keys in file order.
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, false, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		rows, header, err = nodeRows(&doc)
+		if err != nil {
+			return nil, false, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return rows, header, nil
+	default:
+		return nil, false, fmt.Errorf("--from must be a .csv, .tsv, .json, .yaml, or .yml file")
+	}
+}
+
+// csvCellValue converts a CSV field to a cell value: empty fields are
+// skipped, numbers become numeric, and everything else stays text. Numbers
+// with leading zeros (IDs, ZIP codes) stay text.
+func csvCellValue(field string) any {
+	if field == "" {
+		return nil
+	}
+	trimmed := strings.TrimLeft(field, "-")
+	if len(trimmed) > 1 && trimmed[0] == '0' && trimmed[1] != '.' {
+		return field
+	}
+	if n, err := strconv.ParseFloat(field, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+		return n
+	}
+	return field
+}
+
+// nodeRows converts a YAML/JSON document holding an array of objects or an
+// array of arrays into rows.
+func nodeRows(doc *yaml.Node) ([][]any, bool, error) {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil, false, nil
+		}
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.SequenceNode {
+		return nil, false, fmt.Errorf("expected an array of objects or an array of arrays")
+	}
+
+	var keys []string
+	index := map[string]int{}
+	var objects []map[string]any
+	var rows [][]any
+	for _, item := range doc.Content {
+		switch item.Kind {
+		case yaml.MappingNode:
+			obj := map[string]any{}
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				key := item.Content[i].Value
+				if _, seen := index[key]; !seen {
+					index[key] = len(keys)
+					keys = append(keys, key)
+				}
+				v, err := nodeCellValue(item.Content[i+1])
+				if err != nil {
+					return nil, false, err
+				}
+				obj[key] = v
+			}
+			objects = append(objects, obj)
+		case yaml.SequenceNode:
+			row := make([]any, len(item.Content))
+			for i, cell := range item.Content {
+				v, err := nodeCellValue(cell)
+				if err != nil {
+					return nil, false, err
+				}
+				row[i] = v
+			}
+			rows = append(rows, row)
+		default:
+			return nil, false, fmt.Errorf("expected an array of objects or an array of arrays")
+		}
+	}
+	if len(objects) > 0 && len(rows) > 0 {
+		return nil, false, fmt.Errorf("mix of objects and arrays; use one or the other")
+	}
+	if len(objects) == 0 {
+		return rows, false, nil
+	}
+
+	headerRow := make([]any, len(keys))
+	for i, k := range keys {
+		headerRow[i] = k
+	}
+	rows = append(rows, headerRow)
+	for _, obj := range objects {
+		row := make([]any, len(keys))
+		for k, v := range obj {
+			row[index[k]] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, true, nil
+}
+
+// nodeCellValue decodes a scalar to its value; nested arrays and objects
+// are written as JSON text.
+func nodeCellValue(n *yaml.Node) (any, error) {
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch v.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLoadTableData(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+		return path
+	}
+
+	rows, header, err := loadTableData(write("data.csv", "name,amount,zip\nalpha,12.5,02134\nbeta,,NaN\n"))
+	if err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	wantCSV := [][]any{{"name", "amount", "zip"}, {"alpha", 12.5, "02134"}, {"beta", nil, "NaN"}}
+	if header || !reflect.DeepEqual(rows, wantCSV) {
+		t.Fatalf("csv rows = %#v (header %v), want %#v", rows, header, wantCSV)
+	}
+
+	rows, header, err = loadTableData(write("data.json", `[{"z":1,"a":"x"},{"a":"y","extra":{"k":true}}]`))
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	wantJSON := [][]any{{"z", "a", "extra"}, {1, "x", nil}, {nil, "y", `{"k":true}`}}
+	if !header || !reflect.DeepEqual(rows, wantJSON) {
+		t.Fatalf("json rows = %#v (header %v), want %#v", rows, header, wantJSON)
+	}
+
+	rows, header, err = loadTableData(write("data.yaml", "- [1, two]\n- [3, four]\n"))
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	if header || !reflect.DeepEqual(rows, [][]any{{1, "two"}, {3, "four"}}) {
+		t.Fatalf("yaml rows = %#v (header %v)", rows, header)
+	}
+
+	if _, _, err := loadTableData(write("data.json", `{"a":1}`)); err == nil || !strings.Contains(err.Error(), "array") {
+		t.Fatalf("expected array error, got %v", err)
+	}
+}
+
+func TestRunNew_CreatesWorkbookFromCSV(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origNewOutput := newOutput
+	origNewFrom := newFrom
+	origNewSheet := newSheet
+	origNewTemplate := newTemplate
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		newOutput = origNewOutput
+		newFrom = origNewFrom
+		newSheet = origNewSheet
+		newTemplate = origNewTemplate
+	})
+
+	created := base64.StdEncoding.EncodeToString([]byte("PK\x03\x04created"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("create") != "true" || r.URL.Query().Get("save") != "true" {
+			t.Fatalf("expected create and save, got %s", r.URL.RawQuery)
+		}
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Fatalf("parsing multipart form: %v", err)
+		}
+		var payload struct {
+			Filename string         `json:"filename"`
+			Input    map[string]any `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(r.FormValue("exec")), &payload); err != nil {
+			t.Fatalf("parsing exec payload: %v", err)
+		}
+		if payload.Filename != "out.xlsx" || payload.Input["sheet"] != "Data" {
+			t.Fatalf("unexpected payload: %+v", payload)
+		}
+		if got := fmt.Sprint(payload.Input["rows"]); got != "[[a b] [1 2]]" {
+			t.Fatalf("unexpected rows: %s", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"stdout":"","result":{"sheet":"Data","rows":2,"cols":2},"file":%q}`, created)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(dataPath, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatalf("writing data fixture: %v", err)
+	}
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = false
+	newOutput = filepath.Join(dir, "out.xlsx")
+	newFrom = dataPath
+	newSheet = "Data"
+	newTemplate = ""
+
+	out, err := captureExecStdout(t, func() error {
+		return runNew(&cobra.Command{}, nil)
+	})
+	if err != nil {
+		t.Fatalf("runNew failed: %v", err)
+	}
+	if !strings.Contains(out, "2 rows × 2 columns in Data") {
+		t.Fatalf("unexpected summary %q", out)
+	}
+	if got, _ := os.ReadFile(newOutput); string(got) != "PK\x03\x04created" {
+		t.Fatalf("unexpected workbook content %q", got)
+	}
+}
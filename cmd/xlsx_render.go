@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/witanlabs/witan-cli/client"
@@ -10,41 +11,72 @@ import (
 )
 
 var (
-	renderRange  string
-	renderDPR    int
-	renderFormat string
-	renderOutput string
-	renderDiff   string
+	renderRange      string
+	renderDPR        int
+	renderFormat     string
+	renderOutput     string
+	renderDiff       string
+	renderDiffReport string
+	renderOpen       bool
+	renderView       renderViewOptions
+	renderTiles      bool
 )
 
 var renderCmd = &cobra.Command{
 	Use:   "render <file>",
 	Short: "Render a sheet range as an image",
-	Long: `Render a sheet-qualified range as a PNG or WebP image.
+	Long: `Render a sheet-qualified range as a PNG, WebP, SVG, or PDF file.
 
 Behavior:
-  - --range is required (for example "Sheet1!A1:Z50").
-  - --format supports png or webp.
+  - --range is required: a sheet-qualified A1 or R1C1 range ("Sheet1!A1:Z50",
+    "Sheet1!R1C1:R50C26"), a defined name (Revenue_Table), or a table
+    reference (Table1[Sales], Table1[#All]).
+  - --format supports png, webp, svg, or pdf. PDF is assembled locally from a
+    PNG render when the API does not produce it directly.
   - --dpr must be 1-3; default is auto.
   - If --output is omitted, the image is written to a temporary file.
   - --diff compares against a baseline PNG and writes a highlighted PNG diff.
+  - --diff-report writes a JSON report alongside --diff that lists changed
+    pixel regions and the cells they likely cover. Cells are estimated from a
+    uniform grid, so treat them as a guide when column widths vary.
+  - --open launches the result (or diff) in the default viewer.
+  - --show-gridlines, --show-headers, --zoom, and --theme control the view;
+    unset flags keep the API defaults.
   - Large images (>1568 px in either dimension) may be downscaled by vision models.
+  - --tile splits the range into a grid of sub-ranges that each fit in
+    1568 px and renders one image per tile, plus an index JSON mapping tiles
+    to sub-ranges. With -o out.png the tiles are out.r1c1.png, out.r1c2.png,
+    ... and the index is out.tiles.json; otherwise they go to a temporary
+    directory. Supports png, webp, and svg; not --diff or --open.
 
 Examples:
   witan xlsx render report.xlsx -r "Sheet1!A1:Z50"
   witan xlsx render report.xlsx -r "'My Sheet'!B5:H20" --dpr 2
+  witan xlsx render report.xlsx -r Revenue_Table
+  witan xlsx render report.xlsx -r "SalesTable[#All]"
   witan xlsx render report.xlsx -r "Sheet1!A1:F10" -o before.png
-  witan xlsx render report.xlsx -r "Sheet1!A1:F10" --diff before.png`,
-	Args: cobra.ExactArgs(1),
-	RunE: runRender,
+  witan xlsx render report.xlsx -r "Summary!A1:H40" --format pdf -o summary.pdf
+  witan xlsx render report.xlsx -r "Sheet1!A1:F10" --diff before.png
+  witan xlsx render report.xlsx -r "Sheet1!A1:F10" --open
+  witan xlsx render report.xlsx -r "Data!A1:AZ500" --tile -o data.png
+  witan xlsx render report.xlsx -r "Sheet1!A1:F10" --show-gridlines --show-headers --zoom 1.5 --theme dark`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runRender,
 }
 
 func init() {
-	renderCmd.Flags().StringVarP(&renderRange, "range", "r", "", `Sheet-qualified range to render (required)`)
+	renderCmd.Flags().StringVarP(&renderRange, "range", "r", "", `Range to render: A1 or R1C1, defined name, or table reference (required)`)
+	_ = renderCmd.RegisterFlagCompletionFunc("range", completeWorkbookRange)
 	renderCmd.Flags().IntVar(&renderDPR, "dpr", 0, "Device pixel ratio 1-3 (default: auto)")
-	renderCmd.Flags().StringVar(&renderFormat, "format", "png", "Output image format: png or webp")
+	renderCmd.Flags().StringVar(&renderFormat, "format", "png", "Output format: png, webp, svg, or pdf")
+	_ = renderCmd.RegisterFlagCompletionFunc("format", completeRenderFormat)
 	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "Write image to this path (default: temporary file)")
 	renderCmd.Flags().StringVar(&renderDiff, "diff", "", "Compare against baseline PNG and write highlighted diff image")
+	renderCmd.Flags().StringVar(&renderDiffReport, "diff-report", "", "With --diff, write a JSON report of changed regions and estimated cells to this path")
+	addRenderViewFlags(renderCmd, &renderView)
+	renderCmd.Flags().BoolVar(&renderOpen, "open", false, "Open the rendered file in the default viewer")
+	renderCmd.Flags().BoolVar(&renderTiles, "tile", false, "Split large ranges into a grid of images that each fit in 1568 px, with an index JSON")
 	xlsxCmd.AddCommand(renderCmd)
 }
 
@@ -62,18 +94,35 @@ func runRender(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if renderFormat != "png" && renderFormat != "webp" {
-		return fmt.Errorf("--format must be 'png' or 'webp', got %q", renderFormat)
+	switch renderFormat {
+	case "png", "webp", "svg", "pdf":
+	default:
+		return fmt.Errorf("--format must be one of png, webp, svg, or pdf, got %q", renderFormat)
+	}
+	if renderDiff != "" && renderFormat != "png" {
+		return fmt.Errorf("--diff requires --format png (got %q)", renderFormat)
+	}
+	if renderDiffReport != "" && renderDiff == "" {
+		return fmt.Errorf("--diff-report requires --diff")
 	}
 
 	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
 
 	// Require --range (syntax is server-validated)
 	if renderRange == "" {
 		return fmt.Errorf("--range is required (e.g. -r \"Sheet1!A1:Z50\" or \"'My Sheet'!A1:Z50\")")
 	}
 
-	address := renderRange
+	resolved, err := resolveRangeAddresses(c, filePath, []string{renderRange})
+	if err != nil {
+		return err
+	}
+	address := resolved[0]
 
 	// Auto DPR heuristic
 	dpr := renderDPR
@@ -90,36 +139,49 @@ func runRender(cmd *cobra.Command, args []string) error {
 		"dpr":     strconv.Itoa(dpr),
 		"format":  renderFormat,
 	}
+	if err := applyRenderViewFlags(cmd, &renderView, params); err != nil {
+		return err
+	}
 
-	var imageBytes []byte
-	var contentType string
-	if c.Stateless {
-		imageBytes, contentType, err = c.Render(filePath, params)
-	} else {
-		var fileId, revisionId string
-		fileId, revisionId, err = c.EnsureUploaded(filePath)
-		if err == nil {
-			imageBytes, contentType, err = c.FilesRender(fileId, revisionId, params)
-			if client.IsNotFound(err) {
-				fileId, revisionId, err = c.ReuploadFile(filePath)
-				if err == nil {
-					imageBytes, contentType, err = c.FilesRender(fileId, revisionId, params)
-				}
-			}
-		}
+	if renderTiles {
+		return renderTiled(c, filePath, params, address, dpr)
+	}
+
+	if renderFormat == "pdf" && !cachedCapabilities(c.BaseURL).SupportsRenderFormat("pdf") {
+		// Known not to render PDF; skip straight to the PNG fallback below.
+		params["format"] = "png"
+	}
+	imageBytes, contentType, err := fetchRender(c, filePath, params)
+	if renderFormat == "pdf" && isUnsupportedRenderFormat(err) {
+		// Deployments without server-side PDF output: render PNG and wrap it.
+		params["format"] = "png"
+		imageBytes, contentType, err = fetchRender(c, filePath, params)
 	}
 	if err != nil {
-		return err
+		return explainUnsupportedRenderFormat(c, renderFormat, err)
+	}
+	if renderFormat == "pdf" && !strings.Contains(contentType, "pdf") {
+		imageBytes, err = pngToPDF(imageBytes, dpr)
+		if err != nil {
+			return err
+		}
+		contentType = "application/pdf"
 	}
 
 	// If --diff is set, pixel-diff against the baseline image
 	var diffSummary string
 	if renderDiff != "" {
 		var err error
-		imageBytes, diffSummary, err = runRenderDiffPipeline(renderFormat, renderDiff, imageBytes)
+		var report *diffReport
+		imageBytes, diffSummary, report, err = runRenderDiffPipeline(renderFormat, renderDiff, imageBytes, renderDiffReport != "")
 		if err != nil {
 			return err
 		}
+		if report != nil {
+			if err := writeRenderDiffReport(renderDiffReport, report, address, dpr); err != nil {
+				return err
+			}
+		}
 		contentType = "image/png"
 	}
 
@@ -134,10 +196,69 @@ func runRender(cmd *cobra.Command, args []string) error {
 	pixelWidth, pixelHeight := 0, 0
 	if sheet, sr, sc, er, ec, parseErr := internal.ParseRange(address); parseErr == nil {
 		rangeStr = internal.FormatAddress(sheet, sr, sc, er, ec)
-		pixelWidth, pixelHeight = estimatePixels(address, dpr)
+		pixelWidth, pixelHeight = renderView.scalePixels(estimatePixels(address, dpr))
 	}
 
 	printRenderResult(outPath, rangeStr, pixelWidth, pixelHeight, dpr, diffSummary)
+	if renderOpen {
+		openRenderedOutput(outPath)
+	}
 	return nil
 }
 
+// fetchRender renders a range of filePath, reusing the uploaded revision
+// when stateful.
+func fetchRender(c *client.Client, filePath string, params map[string]string) ([]byte, string, error) {
+	if c.Stateless {
+		return c.Render(filePath, params)
+	}
+	fileId, revisionId, err := c.EnsureUploaded(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	imageBytes, contentType, err := c.FilesRender(fileId, revisionId, params)
+	if client.IsNotFound(err) {
+		fileId, revisionId, err = c.ReuploadFile(filePath)
+		if err == nil {
+			imageBytes, contentType, err = c.FilesRender(fileId, revisionId, params)
+		}
+	}
+	return imageBytes, contentType, err
+}
+
+// renderTiled renders address as a grid of tiles and writes the tile index.
+func renderTiled(c *client.Client, filePath string, params map[string]string, address string, dpr int) error {
+	if renderFormat == "pdf" || renderDiff != "" || renderOpen {
+		return fmt.Errorf("--tile supports png, webp, and svg, and cannot be combined with --diff or --open")
+	}
+	index, err := planRenderTiles(address, dpr, renderView.zoom)
+	if err != nil {
+		return err
+	}
+	base, err := tilePaths(renderOutput)
+	if err != nil {
+		return err
+	}
+
+	for i := range index.Tiles {
+		tile := &index.Tiles[i]
+		params["address"] = tile.Range
+		imageBytes, contentType, err := fetchRender(c, filePath, params)
+		if err != nil {
+			return fmt.Errorf("rendering tile %s: %w", tile.Range, err)
+		}
+		path := fmt.Sprintf("%s.r%dc%d.%s", base, tile.Row, tile.Col, renderFormat)
+		if tile.Path, err = writeRenderedImage(path, contentType, imageBytes); err != nil {
+			return err
+		}
+		tile.Width, tile.Height = renderView.scalePixels(estimatePixels(tile.Range, dpr))
+	}
+
+	indexPath := base + ".tiles.json"
+	if err := writeRenderTileIndex(indexPath, index); err != nil {
+		return err
+	}
+	fmt.Printf("%s\n%s | %s (%d×%d) | dpr=%d\n", indexPath, index.Range,
+		pluralize(len(index.Tiles), "tile", "tiles"), index.Rows, index.Cols, dpr)
+	return nil
+}
@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func resetXlsxRenderTestGlobals(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origRange := renderRange
+	origDPR := renderDPR
+	origFormat := renderFormat
+	origOutput := renderOutput
+	origDiff := renderDiff
+	origDiffReport := renderDiffReport
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		renderRange = origRange
+		renderDPR = origDPR
+		renderFormat = origFormat
+		renderOutput = origOutput
+		renderDiff = origDiff
+		renderDiffReport = origDiffReport
+	})
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	stateless = true
+	renderRange = "Sheet1!A1:B2"
+	renderDPR = 1
+	renderFormat = "png"
+	renderOutput = ""
+	renderDiff = ""
+	renderDiffReport = ""
+}
+
+func TestRunRender_PDFFallsBackToLocalConversion(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, image.NewRGBA(image.Rect(0, 0, 4, 2))); err != nil {
+		t.Fatalf("encoding fixture: %v", err)
+	}
+
+	var formats []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		formats = append(formats, format)
+		if format == "pdf" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"code":"invalid_format","message":"unsupported format"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngBuf.Bytes())
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out.pdf")
+	apiURL = server.URL
+	renderFormat = "pdf"
+	renderOutput = outPath
+
+	if _, err := captureExecStdout(t, func() error {
+		return runRender(&cobra.Command{}, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runRender failed: %v", err)
+	}
+	if len(formats) != 2 || formats[0] != "pdf" || formats[1] != "png" {
+		t.Fatalf("expected pdf then png requests, got %v", formats)
+	}
+	written, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if !bytes.HasPrefix(written, []byte("%PDF-")) {
+		t.Fatalf("expected PDF output, got %q", written[:min(len(written), 16)])
+	}
+}
+
+func TestRunRender_PDFDoesNotFallBackOnOtherErrors(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+
+	var formats []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		formats = append(formats, r.URL.Query().Get("format"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"code":"invalid_range","message":"sheet not found"}}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	apiURL = server.URL
+	renderFormat = "pdf"
+	renderOutput = filepath.Join(t.TempDir(), "out.pdf")
+
+	err := runRender(&cobra.Command{}, []string{filePath})
+	if err == nil || !strings.Contains(err.Error(), "sheet not found") {
+		t.Fatalf("expected the range error, got %v", err)
+	}
+	if len(formats) != 1 || formats[0] != "pdf" {
+		t.Fatalf("expected a single pdf request, got %v", formats)
+	}
+}
+
+func TestRunRender_DiffRequiresPNGBeforeUpload(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	apiURL = server.URL
+	renderFormat = "webp"
+	renderDiff = filepath.Join(dir, "before.png")
+
+	err := runRender(&cobra.Command{}, []string{filePath})
+	if err == nil || err.Error() != `--diff requires --format png (got "webp")` {
+		t.Fatalf("expected --diff format error, got %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no API requests, got %d", requests)
+	}
+}
+
+func TestRunRender_SVGPassThrough(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("format"); got != "svg" {
+			t.Fatalf("expected format=svg, got %q", got)
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		fmt.Fprint(w, "<svg/>")
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	apiURL = server.URL
+	renderFormat = "svg"
+
+	output, err := captureExecStdout(t, func() error {
+		return runRender(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runRender failed: %v", err)
+	}
+	outPath := string(bytes.SplitN([]byte(output), []byte("\n"), 2)[0])
+	if filepath.Ext(outPath) != ".svg" {
+		t.Fatalf("expected .svg temp file, got %q", outPath)
+	}
+	t.Cleanup(func() { os.Remove(outPath) })
+}
+
+func TestRunRender_OpenLaunchesViewer(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+	origOpen := renderOpen
+	origOpener := openRendered
+	t.Cleanup(func() {
+		renderOpen = origOpen
+		openRendered = origOpener
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "png bytes")
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out.png")
+	apiURL = server.URL
+	renderOutput = outPath
+	renderOpen = true
+
+	var opened []string
+	openRendered = func(path string) error {
+		opened = append(opened, path)
+		return fmt.Errorf("no display")
+	}
+
+	if _, err := captureExecStdout(t, func() error {
+		return runRender(&cobra.Command{}, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runRender should not fail when the viewer cannot launch: %v", err)
+	}
+	if len(opened) != 1 || opened[0] != outPath {
+		t.Fatalf("expected %q to be opened, got %v", outPath, opened)
+	}
+}
+
+func TestApplyRenderViewFlags(t *testing.T) {
+	newCmd := func(args ...string) (*cobra.Command, *renderViewOptions) {
+		var o renderViewOptions
+		cmd := &cobra.Command{}
+		addRenderViewFlags(cmd, &o)
+		if err := cmd.Flags().Parse(args); err != nil {
+			t.Fatalf("parsing %v: %v", args, err)
+		}
+		return cmd, &o
+	}
+
+	cmd, o := newCmd()
+	params := map[string]string{}
+	if err := applyRenderViewFlags(cmd, o, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(params) != 0 {
+		t.Fatalf("expected no params for unset flags, got %v", params)
+	}
+
+	cmd, o = newCmd("--show-gridlines", "--show-headers=false", "--zoom", "1.5", "--theme", "dark")
+	params = map[string]string{}
+	if err := applyRenderViewFlags(cmd, o, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"gridlines": "true", "headers": "false", "zoom": "1.5", "theme": "dark"}
+	for k, v := range want {
+		if params[k] != v {
+			t.Fatalf("params[%q] = %q, want %q (all: %v)", k, params[k], v, params)
+		}
+	}
+	if w, h := o.scalePixels(100, 40); w != 150 || h != 60 {
+		t.Fatalf("scalePixels = %dx%d, want 150x60", w, h)
+	}
+
+	for _, args := range [][]string{{"--zoom", "0"}, {"--zoom", "5"}, {"--theme", "sepia"}} {
+		cmd, o = newCmd(args...)
+		if err := applyRenderViewFlags(cmd, o, map[string]string{}); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestRunRender_DiffReportMapsRegionsToCells(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+
+	// Sheet1!A1:B2 at dpr=1 is a 128×30 grid of 64×15 cells.
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	before := image.NewRGBA(image.Rect(0, 0, 128, 30))
+	after := image.NewRGBA(image.Rect(0, 0, 128, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 128; x++ {
+			before.SetRGBA(x, y, white)
+			after.SetRGBA(x, y, white)
+		}
+	}
+	for y := 18; y < 25; y++ {
+		for x := 70; x < 80; x++ {
+			after.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var beforeBuf, afterBuf bytes.Buffer
+	if err := png.Encode(&beforeBuf, before); err != nil {
+		t.Fatalf("encoding baseline: %v", err)
+	}
+	if err := png.Encode(&afterBuf, after); err != nil {
+		t.Fatalf("encoding render: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(afterBuf.Bytes())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	baselinePath := filepath.Join(dir, "before.png")
+	if err := os.WriteFile(baselinePath, beforeBuf.Bytes(), 0o644); err != nil {
+		t.Fatalf("writing baseline: %v", err)
+	}
+	reportPath := filepath.Join(dir, "report.json")
+	apiURL = server.URL
+	renderOutput = filepath.Join(dir, "diff.png")
+	renderDiff = baselinePath
+	renderDiffReport = reportPath
+
+	if _, err := captureExecStdout(t, func() error {
+		return runRender(&cobra.Command{}, []string{filePath})
+	}); err != nil {
+		t.Fatalf("runRender: %v", err)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("reading report: %v", err)
+	}
+	var report diffReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decoding report: %v\n%s", err, data)
+	}
+	if report.ChangedPixels != 70 || report.TotalPixels != 128*30 || report.Range != "Sheet1!A1:B2" {
+		t.Fatalf("unexpected report header: %+v", report)
+	}
+	if len(report.Cells) != 1 || report.Cells[0] != "Sheet1!B2" {
+		t.Fatalf("cells = %v, want [Sheet1!B2]", report.Cells)
+	}
+	if len(report.Regions) != 1 {
+		t.Fatalf("regions = %+v, want 1", report.Regions)
+	}
+	if r := report.Regions[0]; r.X != 70 || r.Y != 18 || r.Width != 10 || r.Height != 7 || r.Range != "Sheet1!B2" {
+		t.Fatalf("region = %+v", r)
+	}
+}
+
+func TestRunRender_DiffReportRequiresDiff(t *testing.T) {
+	resetXlsxRenderTestGlobals(t)
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	renderDiffReport = filepath.Join(dir, "report.json")
+
+	err := runRender(&cobra.Command{}, []string{filePath})
+	if err == nil || err.Error() != "--diff-report requires --diff" {
+		t.Fatalf("expected --diff-report requires --diff error, got %v", err)
+	}
+}
+
+func TestSheetRangePrefixes(t *testing.T) {
+	names := []string{"Inputs", "My Sheet", "Model", "2024"}
+	cases := []struct {
+		typed string
+		want  []string
+	}{
+		{"", []string{"Inputs!", "'My Sheet'!", "Model!", "'2024'!"}},
+		{"m", []string{"'My Sheet'!", "Model!"}},
+		{"'my", []string{"'My Sheet'!"}},
+		{"x", nil},
+	}
+	for _, tc := range cases {
+		got := sheetRangePrefixes(names, tc.typed)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("sheetRangePrefixes(%q) = %v, want %v", tc.typed, got, tc.want)
+		}
+	}
+}
+
+func TestPlanRenderTiles(t *testing.T) {
+	// At dpr 1 a tile holds 24 columns (64 px) and 104 rows (15 px).
+	index, err := planRenderTiles("'My Sheet'!A1:AZ250", 1, 1)
+	if err != nil {
+		t.Fatalf("planRenderTiles: %v", err)
+	}
+	if index.Rows != 3 || index.Cols != 3 || len(index.Tiles) != 9 {
+		t.Fatalf("expected a 3×3 grid, got %d×%d (%d tiles)", index.Rows, index.Cols, len(index.Tiles))
+	}
+	if got := index.Tiles[0].Range; got != "'My Sheet'!A1:X104" {
+		t.Fatalf("unexpected first tile %q", got)
+	}
+	if got := index.Tiles[8].Range; got != "'My Sheet'!AW209:AZ250" {
+		t.Fatalf("unexpected last tile %q", got)
+	}
+	for _, tile := range index.Tiles {
+		w, h := estimatePixels(tile.Range, 1)
+		if w > maxRenderPixels || h > maxRenderPixels {
+			t.Fatalf("tile %s estimated at %dx%d px", tile.Range, w, h)
+		}
+	}
+
+	small, err := planRenderTiles("Sheet1!B2:C3", 2, 1)
+	if err != nil || len(small.Tiles) != 1 || small.Tiles[0].Range != "Sheet1!B2:C3" {
+		t.Fatalf("expected a single tile, got %+v, %v", small, err)
+	}
+}
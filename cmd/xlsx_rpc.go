@@ -39,7 +39,9 @@ Use --create to start a new .xlsx workbook session; no local file is written
 until the session receives a save operation.
 
 The CLI owns session setup. Do not include a workbook field. Save metadata
-returned by the API is used for local writeback and omitted from stdout.`,
+returned by the API is used for local writeback and omitted from stdout.
+A save that drops the VBA project of a macro-enabled (.xlsm) workbook warns
+on stderr; with --require-macros the save fails instead.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRPC,
 }
@@ -78,6 +80,7 @@ type rpcSession struct {
 func init() {
 	xlsxRPCCmd.Flags().StringVar(&rpcHint, "hint", "", "Sheet name or address hint for lazy workbook loading")
 	xlsxRPCCmd.Flags().StringVar(&rpcLocale, "locale", "", "Execution locale (env: WITAN_LOCALE; otherwise LC_ALL / LC_MESSAGES / LANG)")
+	xlsxRPCCmd.Flags().BoolVar(&requireMacros, "require-macros", false, "Fail a save instead of warning when it drops the VBA project of a macro-enabled workbook")
 	xlsxRPCCmd.Flags().BoolVar(&rpcCreate, "create", false, "Create a new .xlsx workbook session; target path must not exist and is written only after save")
 	xlsxCmd.AddCommand(xlsxRPCCmd)
 }
@@ -100,8 +103,7 @@ func runRPC(cmd *cobra.Command, args []string) error {
 	}
 	c := newAPIClient(key, orgID)
 	if rpcCreate {
-		c = client.New(resolveAPIURL(), key, orgID, true)
-		c.UserAgent = cliUserAgent()
+		c = newAPIClientMode(key, orgID, true)
 	}
 
 	session, err := openRPCSession(cmd.Context(), c, filePath, rpcHint, locale, rpcCreate)
@@ -133,7 +135,7 @@ func openFilesRPCSession(ctx context.Context, c *client.Client, filePath, hint,
 	if err != nil {
 		return nil, err
 	}
-	conn, err := dialRPCWebSocket(ctx, wsURL, c.APIKey, cliUserAgent())
+	conn, err := dialRPCWebSocket(ctx, wsURL, c.BearerToken(), cliUserAgent())
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +156,7 @@ func openStatelessRPCSession(ctx context.Context, c *client.Client, filePath, hi
 	if err != nil {
 		return nil, err
 	}
-	conn, err := dialRPCWebSocket(ctx, wsURL, c.APIKey, cliUserAgent())
+	conn, err := dialRPCWebSocket(ctx, wsURL, c.BearerToken(), cliUserAgent())
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +304,7 @@ func (s *rpcSession) reconnectFilesRPCSession(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	conn, err := dialRPCWebSocket(ctx, wsURL, s.client.APIKey, cliUserAgent())
+	conn, err := dialRPCWebSocket(ctx, wsURL, s.client.BearerToken(), cliUserAgent())
 	if err != nil {
 		return err
 	}
@@ -377,21 +379,11 @@ func (s *rpcSession) applySaveResponse(resp rpcResponseEnvelope) error {
 		if meta.RevisionID == "" {
 			return fmt.Errorf("save response missing revision_id metadata")
 		}
-		fileBytes, err := s.client.DownloadFileContent(s.fileID, meta.RevisionID)
-		if err != nil {
-			return fmt.Errorf("downloading saved workbook: %w", err)
-		}
-		if err := os.WriteFile(s.filePath, fileBytes, 0o644); err != nil {
-			return fmt.Errorf("writing saved workbook: %w", err)
-		}
-		newPath, err := fixWritebackExtension(s.filePath)
+		newPath, err := writeBackRevision(s.client, s.filePath, s.filePath, s.fileID, meta.RevisionID)
 		if err != nil {
 			return err
 		}
 		s.filePath = newPath
-		if err := s.client.UpdateCachedRevision(s.filePath, s.fileID, meta.RevisionID); err != nil {
-			return fmt.Errorf("updating local cache: %w", err)
-		}
 	case "stateless":
 		if meta.File == "" {
 			return fmt.Errorf("save response missing file metadata")
@@ -400,7 +392,10 @@ func (s *rpcSession) applySaveResponse(resp rpcResponseEnvelope) error {
 		if err != nil {
 			return fmt.Errorf("decoding saved workbook: %w", err)
 		}
-		if err := os.WriteFile(s.filePath, decoded, 0o644); err != nil {
+		if err := checkMacrosKept(s.filePath, decoded); err != nil {
+			return err
+		}
+		if err := writeBackFile(s.filePath, decoded); err != nil {
 			return fmt.Errorf("writing saved workbook: %w", err)
 		}
 		newPath, err := fixWritebackExtension(s.filePath)
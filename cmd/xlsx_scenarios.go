@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	scenariosFrom        string
+	scenariosRanges      []string
+	scenariosConcurrency int
+	scenariosCSV         string
+)
+
+// scenariosReport is the output of `witan xlsx scenarios`.
+type scenariosReport struct {
+	File      string                  `json:"file"`
+	Outputs   []string                `json:"outputs"`
+	Total     int                     `json:"total"`
+	Failed    int                     `json:"failed"`
+	Scenarios []client.ScenarioResult `json:"scenarios"`
+}
+
+var scenariosCmd = &cobra.Command{
+	Use:   "scenarios <file> --from <scenarios> -r <range>",
+	Short: "Recalculate a workbook for each row of an input matrix",
+	Long: `Recalculate a workbook once per scenario, with that scenario's input cells
+overridden, and collect the values of the --range output cells into a
+results table. It works like an Excel data table, and a generated
+scenario file can drive a Monte Carlo run.
+
+Scenario files:
+  - --from is a .csv, .tsv, .json, .yaml, or .yml file. In CSV and TSV the
+    header row names the input cells (sheet-qualified addresses or defined
+    names) and each later row is a scenario. JSON and YAML hold an array of
+    objects keyed by input cell.
+  - A "name" column or key names the scenario; otherwise scenarios are
+    numbered from 1.
+  - Values are read like calc --set values: numbers (5% is 0.05), TRUE or
+    FALSE, formulas starting with =, or text ("42" forces text, written
+    """42""" in CSV). An empty value leaves that input unchanged for the
+    scenario.
+
+Behavior:
+  - Scenarios run in parallel, at most --concurrency at a time. The
+    workbook is not modified.
+  - Prints one row per scenario with its inputs and outputs; --json prints
+    the report, and --csv <path> writes the table as CSV ("-" for stdout).
+  - A failed scenario does not stop the others. Returns exit code 1 when
+    any scenario failed.
+
+Examples:
+  witan xlsx scenarios model.xlsx --from scenarios.csv -r "Summary!B10:B12"
+  witan xlsx scenarios model.xlsx --from runs.json -r NPV -r IRR --concurrency 8 --csv results.csv`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runScenarios,
+}
+
+func init() {
+	scenariosCmd.Flags().StringVar(&scenariosFrom, "from", "", "Scenario file: .csv, .tsv, .json, .yaml, or .yml with one scenario per row")
+	_ = scenariosCmd.MarkFlagRequired("from")
+	_ = scenariosCmd.MarkFlagFilename("from", "csv", "tsv", "json", "yaml", "yml")
+	scenariosCmd.Flags().StringArrayVarP(&scenariosRanges, "range", "r", nil, "Output range to collect: A1 or R1C1, defined name, or table reference (repeatable)")
+	_ = scenariosCmd.MarkFlagRequired("range")
+	_ = scenariosCmd.RegisterFlagCompletionFunc("range", completeWorkbookRange)
+	scenariosCmd.Flags().IntVar(&scenariosConcurrency, "concurrency", defaultCalcConcurrency, "Maximum scenarios recalculated in parallel")
+	scenariosCmd.Flags().StringVar(&scenariosCSV, "csv", "", `Also write the results table as CSV to this path ("-" for stdout)`)
+	addResultOutputFlag(scenariosCmd)
+	xlsxCmd.AddCommand(scenariosCmd)
+}
+
+// loadScenarios reads the scenarios in path: a header row of input cells,
+// with an optional "name" column, and one row per scenario.
+func loadScenarios(path string) ([]client.Scenario, error) {
+	rows, _, err := loadTableData(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%s has no scenarios: want a header row of input cells and one row per scenario", path)
+	}
+	header := make([]string, len(rows[0]))
+	nameCol := -1
+	for i, v := range rows[0] {
+		s, ok := v.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			return nil, fmt.Errorf("%s: column %d of the header must name an input cell", path, i+1)
+		}
+		header[i] = strings.TrimSpace(s)
+		if strings.EqualFold(header[i], "name") {
+			nameCol = i
+		}
+	}
+
+	scenarios := make([]client.Scenario, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		if len(row) > len(header) {
+			return nil, fmt.Errorf("%s: scenario %d has %d values for %d columns", path, n+1, len(row), len(header))
+		}
+		s := client.Scenario{Name: fmt.Sprint(n + 1), Inputs: []client.CellOverride{}}
+		for i, v := range row {
+			if v == nil || v == "" {
+				continue
+			}
+			if i == nameCol {
+				s.Name = fmt.Sprint(v)
+				continue
+			}
+			if text, ok := v.(string); ok {
+				s.Inputs = append(s.Inputs, cellOverride(header[i], strings.TrimSpace(text)))
+			} else {
+				s.Inputs = append(s.Inputs, client.CellOverride{Address: header[i], Value: v})
+			}
+		}
+		scenarios = append(scenarios, s)
+	}
+	return scenarios, nil
+}
+
+func runScenarios(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if scenariosConcurrency <= 0 {
+		return fmt.Errorf("--concurrency must be > 0")
+	}
+	scenarios, err := loadScenarios(scenariosFrom)
+	if err != nil {
+		return err
+	}
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	outputs, err := resolveRangeAddresses(c, filePath, scenariosRanges)
+	if err != nil {
+		return err
+	}
+	// Every scenario sets the same cells, so resolve each input once.
+	var inputs []string
+	seen := map[string]bool{}
+	for _, s := range scenarios {
+		for _, in := range s.Inputs {
+			if !seen[in.Address] {
+				seen[in.Address] = true
+				inputs = append(inputs, in.Address)
+			}
+		}
+	}
+	resolved, err := resolveRangeAddresses(c, filePath, inputs)
+	if err != nil {
+		return err
+	}
+	addresses := make(map[string]string, len(inputs))
+	for i, in := range inputs {
+		addresses[in] = resolved[i]
+	}
+	for _, s := range scenarios {
+		for i := range s.Inputs {
+			s.Inputs[i].Address = addresses[s.Inputs[i].Address]
+		}
+	}
+
+	report := scenariosReport{File: filePath, Outputs: outputs, Total: len(scenarios)}
+	report.Scenarios = c.RunScenarios(filePath, scenarios, outputs, scenariosConcurrency)
+	for _, r := range report.Scenarios {
+		if r.Error != "" {
+			report.Failed++
+		}
+	}
+
+	if scenariosCSV != "" {
+		if err := writeScenariosCSV(scenariosCSV, report); err != nil {
+			return err
+		}
+	}
+	if scenariosCSV != "-" {
+		if err := emitResult(report, jsonOutput, func() error {
+			printScenarios(report)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	for _, r := range report.Scenarios {
+		if r.Error != "" {
+			fmt.Fprintf(os.Stderr, "error: scenario %s: %s\n", r.Name, r.Error)
+		}
+	}
+	if report.Failed > 0 {
+		return &ExitError{Code: ExitFailure}
+	}
+	return nil
+}
+
+// scenarioColumns lists the input and output cell addresses across all
+// scenarios, in first-seen order. Blank output cells are not reported, so
+// scenarios may have different output cells.
+func scenarioColumns(report scenariosReport) (inputs, outputs []string) {
+	seen := map[string]bool{}
+	for _, r := range report.Scenarios {
+		for _, in := range r.Inputs {
+			if !seen[in.Address] {
+				seen[in.Address] = true
+				inputs = append(inputs, in.Address)
+			}
+		}
+	}
+	for _, r := range report.Scenarios {
+		for _, out := range r.Outputs {
+			for _, cell := range out.Cells {
+				if !seen[cell.Address] {
+					seen[cell.Address] = true
+					outputs = append(outputs, cell.Address)
+				}
+			}
+		}
+	}
+	return inputs, outputs
+}
+
+// scenarioTable is the results table: a header row, then one row per
+// scenario. text selects displayed text over raw values for outputs.
+func scenarioTable(report scenariosReport, text bool) [][]string {
+	inputs, outputs := scenarioColumns(report)
+	table := [][]string{append(append([]string{"scenario"}, inputs...), outputs...)}
+	for _, r := range report.Scenarios {
+		row := make([]string, 0, len(table[0]))
+		row = append(row, r.Name)
+		set := map[string]string{}
+		for _, in := range r.Inputs {
+			if in.Formula != "" {
+				set[in.Address] = in.Formula
+			} else {
+				set[in.Address] = fmt.Sprint(in.Value)
+			}
+		}
+		for _, addr := range inputs {
+			row = append(row, set[addr])
+		}
+		values := map[string]string{}
+		for _, out := range r.Outputs {
+			for _, cell := range out.Cells {
+				if text {
+					values[cell.Address] = whatIfText(cell.Text, cell.Value)
+				} else if cell.Value != nil {
+					values[cell.Address] = fmt.Sprint(cell.Value)
+				}
+			}
+		}
+		for _, addr := range outputs {
+			v, ok := values[addr]
+			if !ok && r.Error != "" {
+				v = "error"
+			}
+			row = append(row, v)
+		}
+		table = append(table, row)
+	}
+	return table
+}
+
+func printScenarios(report scenariosReport) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range scenarioTable(report, true) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	_ = tw.Flush()
+	summary := fmt.Sprintf("\n%s run; the workbook was not modified", pluralize(report.Total, "scenario", "scenarios"))
+	if report.Failed > 0 {
+		summary += ", " + colorize(fmt.Sprintf("%d failed", report.Failed), ansiRed)
+	}
+	fmt.Println(summary)
+}
+
+func writeScenariosCSV(path string, report scenariosReport) error {
+	out := os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("writing --csv: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := csv.NewWriter(out)
+	if err := w.WriteAll(scenarioTable(report, false)); err != nil {
+		return fmt.Errorf("writing --csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/client/clienttest"
+)
+
+func TestLoadScenarios(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenarios.csv")
+	data := "name,Inputs!B2,Inputs!B3\nbase,5%,North\nhigh,0.1,\n,=B1*2,\"\"\"42\"\"\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("writing scenarios: %v", err)
+	}
+	got, err := loadScenarios(path)
+	if err != nil {
+		t.Fatalf("loadScenarios: %v", err)
+	}
+	want := []client.Scenario{
+		{Name: "base", Inputs: []client.CellOverride{{Address: "Inputs!B2", Value: 0.05}, {Address: "Inputs!B3", Value: "North"}}},
+		{Name: "high", Inputs: []client.CellOverride{{Address: "Inputs!B2", Value: 0.1}}},
+		{Name: "3", Inputs: []client.CellOverride{{Address: "Inputs!B2", Formula: "=B1*2"}, {Address: "Inputs!B3", Value: "42"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadScenarios = %+v, want %+v", got, want)
+	}
+
+	yamlPath := filepath.Join(t.TempDir(), "scenarios.yaml")
+	if err := os.WriteFile(yamlPath, []byte("- Inputs!B2: 1\n- Inputs!B2: 2\n"), 0o644); err != nil {
+		t.Fatalf("writing scenarios: %v", err)
+	}
+	got, err = loadScenarios(yamlPath)
+	if err != nil || len(got) != 2 || got[1].Name != "2" || got[1].Inputs[0].Value != 2 {
+		t.Errorf("loadScenarios(yaml) = %+v, %v", got, err)
+	}
+}
+
+func TestRunScenarios(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless, origJSON := apiKey, apiURL, stateless, jsonOutput
+	origFrom, origRanges, origConcurrency, origCSV, origOutput := scenariosFrom, scenariosRanges, scenariosConcurrency, scenariosCSV, resultOutputPath
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless, jsonOutput = origAPIKey, origAPIURL, origStateless, origJSON
+		scenariosFrom, scenariosRanges, scenariosConcurrency, scenariosCSV, resultOutputPath = origFrom, origRanges, origConcurrency, origCSV, origOutput
+	})
+
+	// Summary!B10 is ten times Inputs!B2; a negative input fails.
+	srv := clienttest.NewServer(t)
+	srv.Exec = func(wb clienttest.Workbook, req client.ExecRequest) (*client.ExecResponse, error) {
+		input := req.Input.(map[string]any)
+		x := input["cells"].([]any)[0].(map[string]any)["value"].(float64)
+		if x < 0 {
+			return &client.ExecResponse{Ok: false, Error: &client.ExecError{Type: "runtime", Message: "negative input"}}, nil
+		}
+		return &client.ExecResponse{Ok: true, Result: json.RawMessage(fmt.Sprintf(`[{"range":"Summary!B10","cells":[{"address":"Summary!B10","value":%g,"text":"%g","before":10,"changed":true}]}]`, x*10, x*10))}, nil
+	}
+	mockMgmtOrgsServer(t)
+	apiKey, apiURL, stateless, jsonOutput = "test-key", srv.URL, true, true
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "model.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04model"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+	scenariosFrom = filepath.Join(dir, "scenarios.csv")
+	if err := os.WriteFile(scenariosFrom, []byte("Inputs!B2\n1\n2\n-1\n3\n"), 0o644); err != nil {
+		t.Fatalf("writing scenarios: %v", err)
+	}
+	scenariosRanges, scenariosConcurrency = []string{"Summary!B10"}, 2
+	scenariosCSV = filepath.Join(dir, "results.csv")
+	resultOutputPath = filepath.Join(dir, "result.json")
+
+	err := runScenarios(&cobra.Command{}, []string{filePath})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitFailure {
+		t.Fatalf("expected exit code %d for the failed scenario, got %v", ExitFailure, err)
+	}
+
+	csvData, err := os.ReadFile(scenariosCSV)
+	if err != nil {
+		t.Fatalf("reading --csv: %v", err)
+	}
+	want := "scenario,Inputs!B2,Summary!B10\n1,1,10\n2,2,20\n3,-1,error\n4,3,30\n"
+	if string(csvData) != want {
+		t.Errorf("--csv =\n%s\nwant\n%s", csvData, want)
+	}
+
+	var report scenariosReport
+	data, err := os.ReadFile(resultOutputPath)
+	if err != nil {
+		t.Fatalf("reading result: %v", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("parsing result: %v", err)
+	}
+	if report.Total != 4 || report.Failed != 1 || report.Scenarios[2].Error == "" {
+		t.Errorf("report = %+v", report)
+	}
+	if data, _ := os.ReadFile(filePath); string(data) != "PK\x03\x04model" {
+		t.Errorf("workbook was modified: %q", data)
+	}
+}
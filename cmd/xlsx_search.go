@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	searchText      string
+	searchFormula   string
+	searchRegex     bool
+	searchMatchCase bool
+	searchIn        string
+	searchLimit     int
+)
+
+const defaultSearchLimit = 100
+
+// xlsxSearchResult is the output of `witan xlsx search`.
+type xlsxSearchResult struct {
+	Pattern string               `json:"pattern"`
+	Field   string               `json:"field"` // text or formula
+	Matches []client.SearchMatch `json:"matches"`
+	Total   int                  `json:"total"`
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <file> (--text <text> | --formula <text>)",
+	Short: "Find cells by displayed text or formula",
+	Long: `Find the cells in a workbook whose displayed text (--text) or formula
+(--formula) contains a pattern, and print their addresses and values.
+
+Behavior:
+  - Matching is case-insensitive substring matching; --match-case makes it
+    case-sensitive.
+  - --regex treats the pattern as a JavaScript regular expression.
+  - --in limits the search to a sheet or range.
+  - At most --limit matches are returned (default 100).
+  - Finding no matches is not an error (exit code 0).
+
+Use --json for machine-readable results.
+
+Examples:
+  witan xlsx search report.xlsx --text "FY2025"
+  witan xlsx search report.xlsx --formula VLOOKUP
+  witan xlsx search report.xlsx --formula 'SUM\(.*Sheet2!' --regex
+  witan xlsx search report.xlsx --text total --in Summary --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runSearch,
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchText, "text", "", "Find cells whose displayed text contains this")
+	searchCmd.Flags().StringVar(&searchFormula, "formula", "", "Find cells whose formula contains this, e.g. VLOOKUP")
+	searchCmd.Flags().BoolVar(&searchRegex, "regex", false, "Treat the pattern as a JavaScript regular expression")
+	searchCmd.Flags().BoolVar(&searchMatchCase, "match-case", false, "Match case exactly")
+	searchCmd.Flags().StringVar(&searchIn, "in", "", "Sheet or range to search, e.g. Summary or 'Sheet1!A1:F50'")
+	_ = searchCmd.RegisterFlagCompletionFunc("in", completeWorkbookRange)
+	searchCmd.Flags().IntVar(&searchLimit, "limit", defaultSearchLimit, "Maximum matches to return")
+	addResultOutputFlag(searchCmd)
+	xlsxCmd.AddCommand(searchCmd)
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if (searchText == "") == (searchFormula == "") {
+		return fmt.Errorf("provide exactly one of --text or --formula")
+	}
+	if searchLimit <= 0 {
+		return fmt.Errorf("--limit must be > 0")
+	}
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	opts := client.SearchOptions{
+		Pattern:   searchText,
+		Regex:     searchRegex,
+		MatchCase: searchMatchCase,
+		In:        searchIn,
+		Limit:     searchLimit,
+	}
+	field := "text"
+	if searchFormula != "" {
+		opts.Pattern = searchFormula
+		opts.Formulas = true
+		field = "formula"
+	}
+	matches, err := c.Search(filePath, opts)
+	if err != nil {
+		return err
+	}
+
+	result := xlsxSearchResult{Pattern: opts.Pattern, Field: field, Matches: matches, Total: len(matches)}
+	return emitResult(result, jsonOutput, func() error {
+		printSearchResult(result)
+		return nil
+	})
+}
+
+func printSearchResult(result xlsxSearchResult) {
+	if result.Total == 0 {
+		fmt.Println("No matches.")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range result.Matches {
+		line := m.Address + "\t" + searchValueText(m)
+		if result.Field == "formula" {
+			line += "\t" + m.Formula
+		}
+		fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+	fmt.Println(pluralize(result.Total, "match", "matches"))
+}
+
+// searchValueText is the cell's displayed text, or its raw value when the
+// server sent no text.
+func searchValueText(m client.SearchMatch) string {
+	if m.Text != "" || m.Value == nil {
+		return strings.ReplaceAll(m.Text, "\n", " ")
+	}
+	if s, ok := m.Value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(m.Value)
+	return string(b)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func resetSearchTestGlobals(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origText := searchText
+	origFormula := searchFormula
+	origRegex := searchRegex
+	origMatchCase := searchMatchCase
+	origIn := searchIn
+	origLimit := searchLimit
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		searchText = origText
+		searchFormula = origFormula
+		searchRegex = origRegex
+		searchMatchCase = origMatchCase
+		searchIn = origIn
+		searchLimit = origLimit
+	})
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	stateless = true
+	jsonOutput = false
+	searchText = ""
+	searchFormula = ""
+	searchRegex = false
+	searchMatchCase = false
+	searchIn = ""
+	searchLimit = defaultSearchLimit
+}
+
+func TestRunSearch_FormulaMatches(t *testing.T) {
+	resetSearchTestGlobals(t)
+
+	var input map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("save") != "" {
+			t.Fatalf("search must not save, got query %q", r.URL.RawQuery)
+		}
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Fatalf("parsing multipart form: %v", err)
+		}
+		var payload struct {
+			Input map[string]any `json:"input"`
+		}
+		if err := json.Unmarshal([]byte(r.FormValue("exec")), &payload); err != nil {
+			t.Fatalf("decoding exec payload: %v", err)
+		}
+		input = payload.Input
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":[{"address":"Summary!C4","sheet":"Summary","value":12,"text":"12","formula":"=VLOOKUP(A4,Data!A:B,2,FALSE)"}]}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	apiURL = server.URL
+	searchFormula = "vlookup"
+	searchIn = "Summary"
+
+	out, err := captureExecStdout(t, func() error {
+		return runSearch(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runSearch failed: %v", err)
+	}
+	if input["pattern"] != "vlookup" || input["formulas"] != true || input["in"] != "Summary" || input["limit"] != float64(defaultSearchLimit) {
+		t.Fatalf("unexpected exec input: %v", input)
+	}
+	if !strings.Contains(out, "Summary!C4") || !strings.Contains(out, "=VLOOKUP(") || !strings.Contains(out, "1 match") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRunSearch_RequiresOnePattern(t *testing.T) {
+	resetSearchTestGlobals(t)
+
+	err := runSearch(&cobra.Command{}, []string{"book.xlsx"})
+	if err == nil || !strings.Contains(err.Error(), "exactly one of --text or --formula") {
+		t.Fatalf("expected pattern error, got %v", err)
+	}
+	searchText = "a"
+	searchFormula = "b"
+	err = runSearch(&cobra.Command{}, []string{"book.xlsx"})
+	if err == nil || !strings.Contains(err.Error(), "exactly one of --text or --formula") {
+		t.Fatalf("expected pattern error, got %v", err)
+	}
+}
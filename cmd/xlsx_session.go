@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+	"github.com/witanlabs/witan-cli/internal"
+)
+
+// sessionEnv names the session file that commands in a shell share.
+const sessionEnv = "WITAN_SESSION"
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Pin workbook revisions for the commands of a shell session",
+	Long: `Pin workbooks to their uploaded revisions for the rest of a shell session.
+
+Every files-backed command hashes the workbook to find its uploaded
+revision. 'session start' uploads the workbooks once and records their
+revisions in a session file named by WITAN_SESSION; while a pinned
+workbook's size and modification time are unchanged, later commands use
+the pinned revision without hashing it. A write-back (exec --save, calc)
+moves the pin to the new revision, and editing the file locally makes the
+next command upload it as usual and re-pin it.
+
+'session start' prints the export line for the shell to evaluate; 'session
+end' deletes the session file and prints the unset line.
+
+Examples:
+  eval "$(witan xlsx session start report.xlsx)"
+  witan xlsx calc report.xlsx --verify
+  witan xlsx session status
+  eval "$(witan xlsx session end)"`,
+}
+
+var sessionStartCmd = &cobra.Command{
+	Use:   "start <file>...",
+	Short: "Upload workbooks and pin their revisions",
+	Long: `Upload each workbook if needed and pin it to its revision in the session
+file named by WITAN_SESSION, creating one under $TMPDIR/witan-<uid> when it is
+unset. Prints 'export WITAN_SESSION=<path>' on stdout, so run it with
+eval; with --json it prints the session and its pins instead.
+
+Examples:
+  eval "$(witan xlsx session start report.xlsx)"
+  eval "$(witan xlsx session start q3.xlsx q4.xlsx)"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSessionStart,
+}
+
+var sessionStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the workbooks pinned in the current session",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionStatus,
+}
+
+var sessionEndCmd = &cobra.Command{
+	Use:   "end",
+	Short: "Release the pins and delete the session file",
+	Long: `Delete the session file named by WITAN_SESSION and print 'unset
+WITAN_SESSION' for the shell to evaluate. Uploaded revisions stay in the
+upload cache, so later commands still reuse them after hashing.
+
+Examples:
+  eval "$(witan xlsx session end)"`,
+	Args: cobra.NoArgs,
+	RunE: runSessionEnd,
+}
+
+func init() {
+	addResultOutputFlag(sessionStartCmd)
+	addResultOutputFlag(sessionStatusCmd)
+	sessionCmd.AddCommand(sessionStartCmd, sessionStatusCmd, sessionEndCmd)
+	xlsxCmd.AddCommand(sessionCmd)
+}
+
+// sessionResult is the --json output of session start and status.
+type sessionResult struct {
+	Session string              `json:"session"`
+	Pins    []client.SessionPin `json:"pins"`
+}
+
+// resolveSessionPath returns the WITAN_SESSION session file, or "".
+func resolveSessionPath() string {
+	return client.ExpandHome(os.Getenv(sessionEnv))
+}
+
+// openShellSession opens the WITAN_SESSION session for an API client. A
+// session that cannot be read is reported and ignored, so commands still
+// run after it has ended in another shell.
+func openShellSession() *client.Session {
+	path := resolveSessionPath()
+	if path == "" {
+		return nil
+	}
+	s, err := client.OpenSession(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s: %v\n", sessionEnv, err)
+		return nil
+	}
+	return s
+}
+
+func runSessionStart(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if resolveStateless() {
+		return fmt.Errorf("sessions pin uploaded revisions and need stateful mode; sign in or drop --stateless")
+	}
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+
+	s, err := startSession()
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		filePath, err := fixExcelExtension(arg)
+		if err != nil {
+			return err
+		}
+		pin, err := s.Pin(c, filePath)
+		if err != nil {
+			return fmt.Errorf("pinning %s: %w", arg, err)
+		}
+		if !jsonOutput {
+			fmt.Fprintf(os.Stderr, "✓ Pinned %s at %s\n", arg, pin.RevisionID)
+		}
+	}
+
+	return emitResult(sessionResult{Session: s.Path(), Pins: s.Pins()}, jsonOutput, func() error {
+		fmt.Printf("export %s=%s\n", sessionEnv, internal.ShellQuote(s.Path()))
+		return nil
+	})
+}
+
+// startSession opens the WITAN_SESSION session, or creates a new session
+// file when none is set or it no longer exists.
+func startSession() (*client.Session, error) {
+	if path := resolveSessionPath(); path != "" {
+		s, err := client.OpenSession(path)
+		if err == nil {
+			return s, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	// The session file names uploaded revisions, so like run directories
+	// it lives under the per-user temp root, or directly under $TMPDIR
+	// when the root cannot be kept private.
+	root, pattern := runTempRoot(), "session-*.json"
+	if !privateDir(root) {
+		root, pattern = "", "witan-session-*.json"
+	}
+	f, err := os.CreateTemp(root, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+	f.Close()
+	s := client.NewSession(f.Name())
+	return s, s.Save()
+}
+
+func runSessionStatus(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	path := resolveSessionPath()
+	if path == "" {
+		return fmt.Errorf("no session: %s is not set (start one with eval \"$(witan xlsx session start <file>)\")", sessionEnv)
+	}
+	s, err := client.OpenSession(path)
+	if err != nil {
+		return err
+	}
+	result := sessionResult{Session: s.Path(), Pins: s.Pins()}
+	return emitResult(result, jsonOutput, func() error {
+		fmt.Printf("Session %s: %s\n", s.Path(), pluralize(len(result.Pins), "pinned workbook", "pinned workbooks"))
+		for _, p := range result.Pins {
+			state := p.RevisionID
+			if state == "" {
+				state = "(re-pinned by the next command)"
+			} else if info, err := os.Stat(p.File); err != nil || info.Size() != p.Size || !info.ModTime().Equal(p.ModTime) {
+				state += " (changed locally)"
+			}
+			fmt.Printf("  %s  %s\n", p.File, state)
+		}
+		return nil
+	})
+}
+
+func runSessionEnd(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	path := resolveSessionPath()
+	if path == "" {
+		return fmt.Errorf("no session: %s is not set", sessionEnv)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("ending session: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "✓ Ended session %s\n", path)
+	fmt.Printf("unset %s\n", sessionEnv)
+	return nil
+}
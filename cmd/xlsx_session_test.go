@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client/clienttest"
+)
+
+func TestSessionStartAndEnd(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless, origJSON := apiKey, apiURL, stateless, jsonOutput
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless, jsonOutput = origAPIKey, origAPIURL, origStateless, origJSON
+	})
+	srv := clienttest.NewServer(t)
+	mockMgmtOrgsServer(t)
+	apiKey, apiURL, stateless, jsonOutput = "test-key", srv.URL, false, false
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv(sessionEnv, "")
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04book"), 0o644); err != nil {
+		t.Fatalf("writing workbook: %v", err)
+	}
+
+	out, err := captureExecStdout(t, func() error {
+		return runSessionStart(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("session start: %v", err)
+	}
+	path, ok := strings.CutPrefix(strings.TrimSpace(out), "export WITAN_SESSION=")
+	if !ok {
+		t.Fatalf("session start printed %q, want an export line", out)
+	}
+	path = strings.Trim(path, "'")
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || (runtime.GOOS != "windows" && info.Mode().Perm() != 0o700) {
+		t.Fatalf("session directory: %v, %v; want mode 0700", info, err)
+	}
+	t.Setenv(sessionEnv, path)
+
+	// A command in the session gets the pin without uploading again.
+	c := newAPIClient("test-key", "org_test")
+	if _, _, err := c.EnsureUploaded(filePath); err != nil {
+		t.Fatalf("EnsureUploaded: %v", err)
+	}
+	uploads := 0
+	for _, r := range srv.Requests() {
+		if r.Method == "POST" && r.Path == "/files" {
+			uploads++
+		}
+	}
+	if uploads != 1 {
+		t.Errorf("uploads = %d, want 1", uploads)
+	}
+
+	out, err = captureExecStdout(t, func() error {
+		return runSessionEnd(&cobra.Command{}, nil)
+	})
+	if err != nil || strings.TrimSpace(out) != "unset WITAN_SESSION" {
+		t.Fatalf("session end = %q, %v", out, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("session file still exists: %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var (
+	snapshotRanges     []string
+	assertSnapshot     string
+	assertTolerance    float64
+	assertRelTolerance float64
+)
+
+// snapshotVersion is the version of the snapshot file format written by
+// `witan xlsx snapshot`.
+const snapshotVersion = 1
+
+const defaultAssertTolerance = 1e-9
+
+// xlsxSnapshot is the output of `witan xlsx snapshot` and the file
+// `witan xlsx assert` compares against.
+type xlsxSnapshot struct {
+	Version  int                    `json:"version"`
+	Workbook string                 `json:"workbook"`
+	Ranges   []client.SnapshotRange `json:"ranges"`
+}
+
+// snapshotMismatch is a cell whose current value differs from the snapshot.
+// A nil value is a blank cell.
+type snapshotMismatch struct {
+	Address  string   `json:"address"`
+	Expected any      `json:"expected"`
+	Actual   any      `json:"actual"`
+	Diff     *float64 `json:"diff,omitempty"` // actual - expected, for numbers
+}
+
+// xlsxAssertResult is the output of `witan xlsx assert`.
+type xlsxAssertResult struct {
+	Snapshot   string             `json:"snapshot"`
+	Passed     bool               `json:"passed"`
+	Cells      int                `json:"cells"`
+	Mismatches []snapshotMismatch `json:"mismatches"`
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot <file>",
+	Short: "Record computed cell values for xlsx assert",
+	Long: `Record the computed values of a workbook's cells as JSON, for
+regression-testing the workbook later with witan xlsx assert.
+
+Behavior:
+  - Use one or more --range values to choose what to record: sheet-qualified
+    A1 or R1C1 ranges, defined names, table references, or sheet names.
+    Without --range every sheet's used range is recorded.
+  - Only non-blank cells are recorded; a cell missing from the snapshot is
+    expected to stay blank.
+  - Values are read as stored in the workbook. Run witan xlsx calc first if
+    cached values may be stale.
+  - Use -o to save the snapshot; without it the cells are listed.
+
+Examples:
+  witan xlsx snapshot report.xlsx -r "Summary!A1:D20" -o snap.json
+  witan xlsx snapshot report.xlsx -r Summary -r Revenue_Table -o snap.json
+  witan xlsx snapshot report.xlsx --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runSnapshot,
+}
+
+var assertCmd = &cobra.Command{
+	Use:   "assert <file> --snapshot <snap.json>",
+	Short: "Check computed cell values against a snapshot",
+	Long: `Compare a workbook's computed values against a snapshot written by
+witan xlsx snapshot, and fail when any cell differs.
+
+Behavior:
+  - Reads the ranges recorded in the snapshot and compares every cell.
+    A cell that became blank, or a blank cell that gained a value, differs.
+  - Numbers match when they differ by at most --tolerance (default 1e-9),
+    or by at most --rel-tolerance times the snapshot value. Text, booleans,
+    and errors must match exactly.
+  - Returns exit code 3 when any cell differs.
+  - Use --json for machine-readable results.
+
+Examples:
+  witan xlsx assert report.xlsx --snapshot snap.json
+  witan xlsx assert report.xlsx --snapshot snap.json --tolerance 0.005
+  witan xlsx assert report.xlsx --snapshot snap.json --rel-tolerance 0.001`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runAssert,
+}
+
+func init() {
+	snapshotCmd.Flags().StringArrayVarP(&snapshotRanges, "range", "r", nil, "Range or sheet to record (repeatable; default: every sheet)")
+	_ = snapshotCmd.RegisterFlagCompletionFunc("range", completeWorkbookRange)
+	addResultOutputFlag(snapshotCmd)
+	xlsxCmd.AddCommand(snapshotCmd)
+
+	assertCmd.Flags().StringVar(&assertSnapshot, "snapshot", "", "Snapshot file written by witan xlsx snapshot")
+	_ = assertCmd.MarkFlagRequired("snapshot")
+	_ = assertCmd.MarkFlagFilename("snapshot", "json")
+	assertCmd.Flags().Float64Var(&assertTolerance, "tolerance", defaultAssertTolerance, "Largest absolute difference allowed between numbers")
+	assertCmd.Flags().Float64Var(&assertRelTolerance, "rel-tolerance", 0, "Largest difference allowed relative to the snapshot value, e.g. 0.001 for 0.1%")
+	addResultOutputFlag(assertCmd)
+	xlsxCmd.AddCommand(assertCmd)
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	ranges, err := resolveRangeAddresses(c, filePath, snapshotRanges)
+	if err != nil {
+		return err
+	}
+	cells, err := c.Snapshot(filePath, ranges)
+	if err != nil {
+		return err
+	}
+
+	snapshot := xlsxSnapshot{Version: snapshotVersion, Workbook: filePath, Ranges: cells}
+	return emitResult(snapshot, jsonOutput, func() error {
+		printSnapshot(snapshot, resultOutputPath == "")
+		return nil
+	})
+}
+
+func printSnapshot(snapshot xlsxSnapshot, listCells bool) {
+	n := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range snapshot.Ranges {
+		n += len(r.Cells)
+		if !listCells {
+			continue
+		}
+		for _, cell := range r.Cells {
+			fmt.Fprintf(tw, "%s\t%s\n", cell.Address, strings.ReplaceAll(cell.Text, "\n", " "))
+		}
+	}
+	_ = tw.Flush()
+	fmt.Printf("Snapshot of %s in %s\n", pluralize(n, "cell", "cells"), pluralize(len(snapshot.Ranges), "range", "ranges"))
+}
+
+func runAssert(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if assertTolerance < 0 || assertRelTolerance < 0 {
+		return fmt.Errorf("--tolerance and --rel-tolerance must be >= 0")
+	}
+	want, err := readSnapshotFile(assertSnapshot)
+	if err != nil {
+		return err
+	}
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	ranges := make([]string, len(want.Ranges))
+	for i, r := range want.Ranges {
+		ranges[i] = r.Range
+	}
+	got, err := c.Snapshot(filePath, ranges)
+	if err != nil {
+		return err
+	}
+
+	cells, mismatches := compareSnapshot(want.Ranges, got, assertTolerance, assertRelTolerance)
+	result := xlsxAssertResult{Snapshot: assertSnapshot, Passed: len(mismatches) == 0, Cells: cells, Mismatches: mismatches}
+	if err := emitResult(result, jsonOutput, func() error {
+		printAssertResult(result)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !result.Passed {
+		return &ExitError{Code: ExitAssertion}
+	}
+	return nil
+}
+
+func readSnapshotFile(path string) (*xlsxSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	var snapshot xlsxSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s: %w", path, err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s", snapshot.Version, path)
+	}
+	return &snapshot, nil
+}
+
+// compareSnapshot compares every cell in want and got, in snapshot order
+// followed by cells only got has, and returns the number of cells compared
+// and those that differ.
+func compareSnapshot(want, got []client.SnapshotRange, absTol, relTol float64) (int, []snapshotMismatch) {
+	current := map[string]any{}
+	var added []string
+	for _, r := range got {
+		for _, cell := range r.Cells {
+			if _, ok := current[cell.Address]; !ok {
+				added = append(added, cell.Address)
+			}
+			current[cell.Address] = cell.Value
+		}
+	}
+
+	mismatches := []snapshotMismatch{}
+	seen := map[string]bool{}
+	for _, r := range want {
+		for _, cell := range r.Cells {
+			if seen[cell.Address] {
+				continue
+			}
+			seen[cell.Address] = true
+			actual := current[cell.Address]
+			if ok, diff := snapshotValuesMatch(cell.Value, actual, absTol, relTol); !ok {
+				mismatches = append(mismatches, snapshotMismatch{Address: cell.Address, Expected: cell.Value, Actual: actual, Diff: diff})
+			}
+		}
+	}
+	for _, addr := range added {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		if actual := current[addr]; actual != nil {
+			mismatches = append(mismatches, snapshotMismatch{Address: addr, Actual: actual})
+		}
+	}
+	return len(seen), mismatches
+}
+
+// snapshotValuesMatch compares an expected and actual cell value, allowing
+// numbers to differ within the tolerances. diff is set for two numbers.
+func snapshotValuesMatch(expected, actual any, absTol, relTol float64) (bool, *float64) {
+	e, eok := expected.(float64)
+	a, aok := actual.(float64)
+	if eok && aok {
+		diff := a - e
+		d := math.Abs(diff)
+		return d <= absTol || d <= relTol*math.Abs(e), &diff
+	}
+	return reflect.DeepEqual(expected, actual), nil
+}
+
+func printAssertResult(result xlsxAssertResult) {
+	if result.Passed {
+		fmt.Printf("OK: %s match %s\n", pluralize(result.Cells, "cell", "cells"), result.Snapshot)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range result.Mismatches {
+		line := fmt.Sprintf("%s\texpected %s\tgot %s", m.Address, snapshotValueText(m.Expected), snapshotValueText(m.Actual))
+		if m.Diff != nil {
+			line += fmt.Sprintf("\t(%+.6g)", *m.Diff)
+		}
+		fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+	fmt.Printf("%d of %s differ from %s\n", len(result.Mismatches), pluralize(result.Cells, "cell", "cells"), result.Snapshot)
+}
+
+// snapshotValueText formats a cell value for assert output.
+func snapshotValueText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "(blank)"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+func resetSnapshotTestGlobals(t *testing.T) {
+	origAPIKey := apiKey
+	origAPIURL := apiURL
+	origStateless := stateless
+	origJSONOutput := jsonOutput
+	origOutputPath := resultOutputPath
+	origRanges := snapshotRanges
+	origSnapshot := assertSnapshot
+	origTolerance := assertTolerance
+	origRelTolerance := assertRelTolerance
+	t.Cleanup(func() {
+		apiKey = origAPIKey
+		apiURL = origAPIURL
+		stateless = origStateless
+		jsonOutput = origJSONOutput
+		resultOutputPath = origOutputPath
+		snapshotRanges = origRanges
+		assertSnapshot = origSnapshot
+		assertTolerance = origTolerance
+		assertRelTolerance = origRelTolerance
+	})
+
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+	apiKey = ""
+	stateless = true
+	jsonOutput = false
+	resultOutputPath = ""
+	snapshotRanges = nil
+	assertSnapshot = ""
+	assertTolerance = defaultAssertTolerance
+	assertRelTolerance = 0
+}
+
+func TestCompareSnapshot(t *testing.T) {
+	want := []client.SnapshotRange{{Range: "Summary!A1:B3", Cells: []client.SnapshotCell{
+		{Address: "Summary!A1", Value: "Revenue"},
+		{Address: "Summary!B1", Value: 100.0},
+		{Address: "Summary!B2", Value: 200.0},
+		{Address: "Summary!B3", Value: 300.0},
+	}}}
+	got := []client.SnapshotRange{{Range: "Summary!A1:B3", Cells: []client.SnapshotCell{
+		{Address: "Summary!A1", Value: "Revenue"},
+		{Address: "Summary!A3", Value: "new"},
+		{Address: "Summary!B1", Value: 100.004},
+		{Address: "Summary!B2", Value: 250.0},
+	}}}
+
+	cells, mismatches := compareSnapshot(want, got, 0.01, 0)
+	if cells != 5 {
+		t.Fatalf("expected 5 cells compared, got %d", cells)
+	}
+	var addrs []string
+	for _, m := range mismatches {
+		addrs = append(addrs, m.Address)
+	}
+	if strings.Join(addrs, ",") != "Summary!B2,Summary!B3,Summary!A3" {
+		t.Fatalf("unexpected mismatches: %v", addrs)
+	}
+	if mismatches[0].Diff == nil || *mismatches[0].Diff != 50 {
+		t.Fatalf("expected a diff of 50, got %+v", mismatches[0])
+	}
+	if mismatches[1].Actual != nil || mismatches[2].Expected != nil {
+		t.Fatalf("blank cells should be nil: %+v", mismatches[1:])
+	}
+
+	if _, mismatches := compareSnapshot(want[:1], want, 0, 0); len(mismatches) != 0 {
+		t.Fatalf("identical snapshots should match: %+v", mismatches)
+	}
+}
+
+func TestSnapshotValuesMatch_RelativeTolerance(t *testing.T) {
+	if ok, _ := snapshotValuesMatch(1000.0, 1000.9, 0, 0.001); !ok {
+		t.Fatal("0.09% difference should be within 0.1%")
+	}
+	if ok, _ := snapshotValuesMatch(1000.0, 1001.1, 0, 0.001); ok {
+		t.Fatal("0.11% difference should exceed 0.1%")
+	}
+	if ok, _ := snapshotValuesMatch("1", 1.0, 1, 1); ok {
+		t.Fatal("text and numbers should never match")
+	}
+}
+
+func TestRunSnapshotThenAssert(t *testing.T) {
+	resetSnapshotTestGlobals(t)
+
+	value := "100"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			t.Fatalf("parsing multipart form: %v", err)
+		}
+		if !strings.Contains(r.FormValue("exec"), `"ranges":["Summary!A1:B2"]`) {
+			t.Fatalf("expected the snapshot range in the exec input, got %s", r.FormValue("exec"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"stdout":"","result":[{"range":"Summary!A1:B2","cells":[{"address":"Summary!A1","value":"Total","text":"Total"},{"address":"Summary!B1","value":%s,"text":"%s"}]}]}`, value, value)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	snapPath := filepath.Join(dir, "snap.json")
+	apiURL = server.URL
+	snapshotRanges = []string{"Summary!A1:B2"}
+	resultOutputPath = snapPath
+
+	out, err := captureExecStdout(t, func() error {
+		return runSnapshot(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runSnapshot failed: %v", err)
+	}
+	if out != "Snapshot of 2 cells in 1 range\n" {
+		t.Fatalf("unexpected snapshot output: %q", out)
+	}
+
+	resultOutputPath = ""
+	assertSnapshot = snapPath
+	out, err = captureExecStdout(t, func() error {
+		return runAssert(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runAssert failed: %v", err)
+	}
+	if !strings.HasPrefix(out, "OK: 2 cells match") {
+		t.Fatalf("unexpected assert output: %q", out)
+	}
+
+	value = "101.5"
+	out, err = captureExecStdout(t, func() error {
+		return runAssert(&cobra.Command{}, []string{filePath})
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != ExitAssertion {
+		t.Fatalf("expected ExitAssertion, got %v", err)
+	}
+	if !strings.Contains(out, "Summary!B1  expected 100  got 101.5  (+1.5)") || !strings.Contains(out, "1 of 2 cells differ from "+snapPath) {
+		t.Fatalf("unexpected assert output: %q", out)
+	}
+}
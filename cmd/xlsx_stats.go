@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats <file>",
+	Short: "Summarize a workbook's formulas and estimated calc cost",
+	Long: `Report how complex a workbook is to recalculate: formula counts per sheet,
+volatile functions (NOW, OFFSET, INDIRECT, ...), array formulas, external
+workbook links, the largest used range, and an estimated calc cost.
+
+The calc cost counts one unit per formula plus one per cell in each range a
+formula references. It is rated low, medium (100k+), or high (10M+); a high
+workbook may need a longer --timeout or --async for calc and exec.
+
+Use --json for machine-readable results.
+
+Examples:
+  witan xlsx stats report.xlsx
+  witan xlsx stats report.xlsx --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorkbookArg,
+	RunE:              runStats,
+}
+
+func init() {
+	addResultOutputFlag(statsCmd)
+	xlsxCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	filePath, err := fixExcelExtension(args[0])
+	if err != nil {
+		return err
+	}
+
+	key, orgID, err := resolveAuth()
+	if err != nil {
+		return err
+	}
+	c := newAPIClient(key, orgID)
+	filePath, cleanupXLSB, err := serverWorkbook(c, filePath, false)
+	if err != nil {
+		return err
+	}
+	defer cleanupXLSB()
+
+	stats, err := c.Stats(filePath)
+	if err != nil {
+		return err
+	}
+	return emitResult(stats, jsonOutput, func() error {
+		return printWorkbookStats(stats)
+	})
+}
+
+func printWorkbookStats(stats *client.WorkbookStats) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SHEET\tUSED RANGE\tFORMULAS\tVOLATILE\tARRAY\tEXTERNAL")
+	for _, s := range stats.Sheets {
+		name := s.Sheet
+		if s.Hidden {
+			name += " (hidden)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\n", name, s.UsedRange, s.Formulas, s.VolatileFormulas, s.ArrayFormulas, s.ExternalRefs)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	fmt.Printf("Formulas: %d (%d volatile, %d array)\n", stats.Formulas, stats.VolatileFormulas, stats.ArrayFormulas)
+	if len(stats.Volatile) > 0 {
+		fmt.Printf("Volatile functions: %s\n", formatFunctionCounts(stats.Volatile))
+	}
+	if len(stats.ExternalLinks) > 0 {
+		fmt.Printf("External links: %s\n", strings.Join(stats.ExternalLinks, ", "))
+	}
+	if stats.LargestSheet != "" {
+		fmt.Printf("Largest used range: %s!%s (%s)\n", stats.LargestSheet, stats.LargestRange, pluralize(int(stats.LargestCells), "cell", "cells"))
+	}
+	fmt.Printf("Estimated calc cost: %s (%d)\n", stats.CalcCostLevel, stats.CalcCost)
+	if stats.CalcCostLevel == "high" {
+		fmt.Println("Consider a longer --timeout or --async for calc and exec on this workbook.")
+	}
+	return nil
+}
+
+// formatFunctionCounts renders counts as "OFFSET 12, NOW 2", most used first.
+func formatFunctionCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
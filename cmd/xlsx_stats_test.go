@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunStats_PrintsReport(t *testing.T) {
+	origAPIKey, origAPIURL, origStateless, origJSON := apiKey, apiURL, stateless, jsonOutput
+	t.Cleanup(func() {
+		apiKey, apiURL, stateless, jsonOutput = origAPIKey, origAPIURL, origStateless, origJSON
+	})
+	t.Setenv("WITAN_CONFIG_DIR", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v0/xlsx/exec" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"stdout":"","result":[
+			{"sheet":"Data","used_range":"A1:Z400000","rows":400000,"cols":26,"formulas":400000,"volatile_formulas":400000,
+			 "array_formulas":0,"external_refs":0,"referenced_cells":12000000,"volatile":{"OFFSET":400000}},
+			{"sheet":"Links","used_range":"A1:B2","rows":2,"cols":2,"hidden":true,"formulas":1,"volatile_formulas":0,
+			 "array_formulas":0,"external_refs":1,"referenced_cells":1,"external_links":["Rates.xlsx"]}]}`)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := os.WriteFile(filePath, []byte("PK\x03\x04test"), 0o644); err != nil {
+		t.Fatalf("writing workbook fixture: %v", err)
+	}
+	apiKey = ""
+	apiURL = server.URL
+	stateless = true
+	jsonOutput = false
+
+	out, err := captureExecStdout(t, func() error {
+		return runStats(&cobra.Command{}, []string{filePath})
+	})
+	if err != nil {
+		t.Fatalf("runStats failed: %v", err)
+	}
+	for _, want := range []string{
+		"Links (hidden)",
+		"Volatile functions: OFFSET 400000",
+		"External links: Rates.xlsx",
+		"Largest used range: Data!A1:Z400000 (10400000 cells)",
+		"Estimated calc cost: high (12400002)",
+		"--async",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/witanlabs/witan-cli/client"
+)
+
+// undoKeep is how many previous versions of each file write-back keeps.
+const undoKeep = 10
+
+var (
+	undoSteps int
+	undoList  bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo <file>",
+	Short: "Restore the local file as it was before a write-back",
+	Long: `Restore a workbook as it was before calc, exec --save, or an rpc save
+overwrote it.
+
+Each write-back first copies the file's previous bytes to a local undo
+directory, keeping the last 10 versions per file. undo writes back the
+most recent copy and drops it, so running undo again goes one step further
+back. --steps N goes back N versions at once; --list shows what is kept.
+
+The undo directory is <user cache dir>/witan/undo, or WITAN_UNDO_DIR.
+
+Examples:
+  witan xlsx undo report.xlsx
+  witan xlsx undo report.xlsx --list
+  witan xlsx undo report.xlsx --steps 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUndo,
+}
+
+func init() {
+	undoCmd.Flags().IntVar(&undoSteps, "steps", 1, "Number of versions to go back")
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List the kept versions instead of restoring")
+	addResultOutputFlag(undoCmd)
+	xlsxCmd.AddCommand(undoCmd)
+}
+
+// undoVersion is one kept copy of a file's previous bytes.
+type undoVersion struct {
+	Path    string    `json:"path"`
+	SavedAt time.Time `json:"saved_at"`
+	Bytes   int64     `json:"bytes"`
+}
+
+// resolveUndoDir returns the root of the undo directory.
+func resolveUndoDir() (string, error) {
+	if v := os.Getenv("WITAN_UNDO_DIR"); v != "" {
+		return v, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "witan", "undo"), nil
+}
+
+// undoDirFor returns the directory holding filePath's kept versions, keyed
+// by the normalized path (see client.NormalizePath).
+func undoDirFor(filePath string) (string, error) {
+	root, err := resolveUndoDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(client.NormalizePath(filePath)))
+	return filepath.Join(root, hex.EncodeToString(sum[:8])), nil
+}
+
+// copyUndoVersions makes the versions kept for from available under to as
+// well, for a write-back that fixWritebackExtension renamed: undo then works
+// with either name.
+func copyUndoVersions(from, to string) error {
+	versions, err := listUndoVersions(from)
+	if err != nil || len(versions) == 0 {
+		return err
+	}
+	dir, err := undoDirFor(to)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for _, v := range versions {
+		data, err := os.ReadFile(v.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(v.Path)), data, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listUndoVersions returns filePath's kept versions, newest first.
+func listUndoVersions(filePath string) ([]undoVersion, error) {
+	dir, err := undoDirFor(filePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []undoVersion
+	for _, e := range entries {
+		stamp, _, _ := strings.Cut(e.Name(), ".")
+		nanos, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, undoVersion{
+			Path:    filepath.Join(dir, e.Name()),
+			SavedAt: time.Unix(0, nanos),
+			Bytes:   info.Size(),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].SavedAt.After(versions[j].SavedAt) })
+	return versions, nil
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	filePath := args[0]
+	if undoSteps < 1 {
+		return fmt.Errorf("--steps must be at least 1")
+	}
+
+	versions, err := listUndoVersions(filePath)
+	if err != nil {
+		return err
+	}
+	if undoList {
+		if versions == nil {
+			versions = []undoVersion{}
+		}
+		return emitResult(versions, jsonOutput, func() error {
+			if len(versions) == 0 {
+				fmt.Printf("No undo versions kept for %s.\n", filePath)
+				return nil
+			}
+			for i, v := range versions {
+				fmt.Printf("%3d  %s  %s\n", i+1, v.SavedAt.Local().Format(time.DateTime), formatBytes(v.Bytes))
+			}
+			return nil
+		})
+	}
+
+	if len(versions) == 0 {
+		return fmt.Errorf("no undo versions kept for %s", filePath)
+	}
+	if undoSteps > len(versions) {
+		return fmt.Errorf("only %s kept for %s", pluralize(len(versions), "undo version", "undo versions"), filePath)
+	}
+	target := versions[undoSteps-1]
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return fmt.Errorf("reading undo copy: %w", err)
+	}
+	if err := writeFileAtomic(filePath, data); err != nil {
+		return fmt.Errorf("restoring file: %w", err)
+	}
+	// The restored copy and everything newer are consumed, so the next
+	// undo continues further back.
+	for _, v := range versions[:undoSteps] {
+		os.Remove(v.Path)
+	}
+
+	restored := map[string]any{
+		"path":     filePath,
+		"saved_at": target.SavedAt,
+		"bytes":    target.Bytes,
+	}
+	return emitResult(restored, jsonOutput, func() error {
+		fmt.Fprintf(os.Stderr, "Restored %s to the version from %s (%d more kept)\n",
+			filePath, target.SavedAt.Local().Format(time.DateTime), len(versions)-undoSteps)
+		return nil
+	})
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestMain keeps undo copies made by write-back tests out of the user's
+// cache directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "witan-undo-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("WITAN_UNDO_DIR", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestWriteBackFile_UndoRestoresPreviousVersions(t *testing.T) {
+	origUndoSteps := undoSteps
+	origUndoList := undoList
+	origJSONOutput := jsonOutput
+	t.Cleanup(func() {
+		undoSteps = origUndoSteps
+		undoList = origUndoList
+		jsonOutput = origJSONOutput
+	})
+	t.Setenv("WITAN_UNDO_DIR", t.TempDir())
+	jsonOutput = false
+	undoList = false
+
+	filePath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := writeBackFile(filePath, []byte("v1")); err != nil {
+		t.Fatalf("writeBackFile v1: %v", err)
+	}
+	if versions, _ := listUndoVersions(filePath); len(versions) != 0 {
+		t.Fatalf("expected no undo copy for a new file, got %d", len(versions))
+	}
+	for _, v := range []string{"v2", "v3", "v4"} {
+		if err := writeBackFile(filePath, []byte(v)); err != nil {
+			t.Fatalf("writeBackFile %s: %v", v, err)
+		}
+	}
+
+	undoSteps = 1
+	if err := runUndo(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runUndo failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "v3" {
+		t.Fatalf("expected v3 after undo, got %q", got)
+	}
+
+	undoSteps = 2
+	if err := runUndo(&cobra.Command{}, []string{filePath}); err != nil {
+		t.Fatalf("runUndo --steps 2 failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "v1" {
+		t.Fatalf("expected v1 after undo --steps 2, got %q", got)
+	}
+
+	undoSteps = 1
+	if err := runUndo(&cobra.Command{}, []string{filePath}); err == nil || !strings.Contains(err.Error(), "no undo versions") {
+		t.Fatalf("expected no-versions error, got %v", err)
+	}
+}
+
+func TestWriteBackFile_UndoFollowsExtensionFix(t *testing.T) {
+	origUndoSteps, origUndoList, origJSONOutput := undoSteps, undoList, jsonOutput
+	t.Cleanup(func() { undoSteps, undoList, jsonOutput = origUndoSteps, origUndoList, origJSONOutput })
+	t.Setenv("WITAN_UNDO_DIR", t.TempDir())
+	undoSteps, undoList, jsonOutput = 1, false, false
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "budget.xls")
+	if err := os.WriteFile(filePath, []byte("old xls"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeBackFile(filePath, []byte{0x50, 0x4b, 0x03, 0x04, 0x00, 0x00, 0x00, 0x00}); err != nil {
+		t.Fatalf("writeBackFile: %v", err)
+	}
+	newPath, err := fixWritebackExtension(filePath)
+	if err != nil {
+		t.Fatalf("fixWritebackExtension: %v", err)
+	}
+	if newPath != filepath.Join(dir, "budget.xlsx") {
+		t.Fatalf("newPath = %q", newPath)
+	}
+
+	for _, p := range []string{newPath, filePath} {
+		if versions, _ := listUndoVersions(p); len(versions) != 1 {
+			t.Fatalf("expected one undo version for %s, got %d", filepath.Base(p), len(versions))
+		}
+	}
+	if err := runUndo(&cobra.Command{}, []string{newPath}); err != nil {
+		t.Fatalf("runUndo on the renamed file failed: %v", err)
+	}
+	if got, _ := os.ReadFile(newPath); string(got) != "old xls" {
+		t.Fatalf("expected the previous bytes after undo, got %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const configVersion = 1
@@ -15,6 +16,29 @@ type Config struct {
 	SessionToken string            `json:"session_token,omitempty"`
 	SessionOrgID string            `json:"session_org_id,omitempty"`
 	APIKeyOrgs   map[string]string `json:"api_key_orgs,omitempty"` // sha256(apiKey) -> orgID
+	SessionJWT   *SessionJWT       `json:"session_jwt,omitempty"`
+}
+
+// SessionJWT is the JWT last exchanged for a session token, cached so
+// commands can skip the exchange until it nears expiry.
+type SessionJWT struct {
+	Token     string    `json:"token"`
+	Session   string    `json:"session"` // sha256(session token) it was exchanged for
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CachedSessionJWT returns the cached JWT for the current SessionToken, or
+// nil when none is cached or it was exchanged for a different session.
+func (c *Config) CachedSessionJWT() *SessionJWT {
+	if c.SessionJWT == nil || c.SessionToken == "" || c.SessionJWT.Session != HashAPIKey(c.SessionToken) {
+		return nil
+	}
+	return c.SessionJWT
+}
+
+// SetSessionJWT caches a JWT exchanged for the current SessionToken.
+func (c *Config) SetSessionJWT(token string, expiresAt time.Time) {
+	c.SessionJWT = &SessionJWT{Token: token, Session: HashAPIKey(c.SessionToken), ExpiresAt: expiresAt.UTC()}
 }
 
 // HashAPIKey returns the hex-encoded SHA-256 of an API key.
@@ -39,6 +63,12 @@ func (c *Config) SetOrgIDForAPIKey(apiKey, orgID string) {
 	c.APIKeyOrgs[HashAPIKey(apiKey)] = orgID
 }
 
+// Dir returns the config directory: $WITAN_CONFIG_DIR,
+// $XDG_CONFIG_HOME/witan, or ~/.config/witan.
+func Dir() (string, error) {
+	return dir()
+}
+
 func dir() (string, error) {
 	if v := os.Getenv("WITAN_CONFIG_DIR"); v != "" {
 		return v, nil
@@ -110,7 +140,11 @@ func Save(cfg Config) error {
 	if err != nil {
 		return err
 	}
-	data = append(data, '\n')
+	return writeAtomic(p, append(data, '\n'))
+}
+
+// writeAtomic writes data to p via a temp file + rename.
+func writeAtomic(p string, data []byte) error {
 	tmp := p + ".tmp"
 	if err := os.WriteFile(tmp, data, 0600); err != nil {
 		return err
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Settings holds user defaults for frequently used flags, keyed by setting
+// name (e.g. "stateless", "dpr"). Values are JSON strings, numbers, or
+// booleans. Flags and environment variables take precedence over them.
+type Settings map[string]any
+
+// SettingsPath returns the path of the settings file.
+func SettingsPath() (string, error) {
+	d, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(d, "settings.json"), nil
+}
+
+// LoadSettings reads the settings file. A missing file yields empty settings.
+func LoadSettings() (Settings, error) {
+	p, err := SettingsPath()
+	if err != nil {
+		return Settings{}, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Settings{}, nil
+		}
+		return Settings{}, err
+	}
+	s := Settings{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Settings{}, fmt.Errorf("parsing %s: %w", p, err)
+	}
+	return s, nil
+}
+
+// SaveSettings writes the settings file atomically.
+func SaveSettings(s Settings) error {
+	p, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(p, append(data, '\n'))
+}
@@ -87,3 +87,298 @@ func letterToCol(letters string) int {
 	}
 	return col
 }
+
+// definedNameRe matches an Excel defined name: a letter, underscore, or
+// backslash followed by letters, digits, underscores, periods, or backslashes.
+var definedNameRe = regexp.MustCompile(`^[A-Za-z_\\][A-Za-z0-9_.\\]*$`)
+
+// r1c1LikeRe matches names Excel reserves because they read as R1C1 references.
+var r1c1LikeRe = regexp.MustCompile(`^(?i)(R\d*C\d*|R\d*|C\d*)$`)
+
+// IsDefinedName reports whether address looks like a workbook defined name
+// (e.g. "Revenue_Table") rather than a sheet-qualified A1 address. Names that
+// Excel would read as a cell reference, such as "A1", "XFD100", or "R1C1", are
+// not names.
+func IsDefinedName(address string) bool {
+	if strings.Contains(address, "!") || !definedNameRe.MatchString(address) {
+		return false
+	}
+	if _, _, err := parseRef(address); err == nil {
+		return false
+	}
+	if r1c1LikeRe.MatchString(address) {
+		return false
+	}
+	upper := strings.ToUpper(address)
+	return upper != "TRUE" && upper != "FALSE"
+}
+
+// plainSheetRe matches sheet names that need no quoting in an address.
+var plainSheetRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// a1LikeRe matches sheet names that read as an A1 cell reference, e.g. A1
+// or xfd100.
+var a1LikeRe = regexp.MustCompile(`^(?i)[A-Z]{1,3}\d+$`)
+
+// QuoteSheetName wraps a sheet name in single quotes when an address needs
+// them (spaces, punctuation, a leading digit, or a name that reads as a
+// cell reference such as A1, R2, or RC), e.g. 'My Sheet'.
+func QuoteSheetName(sheet string) string {
+	if plainSheetRe.MatchString(sheet) && !a1LikeRe.MatchString(sheet) && !r1c1LikeRe.MatchString(sheet) {
+		return sheet
+	}
+	return "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+}
+
+// r1c1Re matches an absolute R1C1 cell reference like R2C3.
+var r1c1Re = regexp.MustCompile(`^R(\d+)C(\d+)$`)
+
+// r1c1RelativeRe matches R1C1 references with a relative part, e.g. R[1]C or RC[-2].
+var r1c1RelativeRe = regexp.MustCompile(`^R(\[-?\d+\]|\d*)C(\[-?\d+\]|\d*)$`)
+
+// IsR1C1 reports whether address is an R1C1-style reference (optionally
+// sheet-qualified), e.g. "Sheet1!R2C3:R10C5". A valid A1 reference is
+// never R1C1, so cells in columns R to RC such as "RC5" stay A1.
+func IsR1C1(address string) bool {
+	_, rangePart, hasSheet := strings.Cut(address, "!")
+	if !hasSheet {
+		rangePart = address
+	}
+	from, to, _ := strings.Cut(strings.ToUpper(rangePart), ":")
+	if !isR1C1Ref(from) {
+		return false
+	}
+	return to == "" || isR1C1Ref(to)
+}
+
+// isR1C1Ref reports whether ref is an R1C1 cell reference and not an A1
+// one.
+func isR1C1Ref(ref string) bool {
+	if _, _, err := parseRef(ref); err == nil {
+		return false
+	}
+	return r1c1RelativeRe.MatchString(ref)
+}
+
+// R1C1ToA1 converts an absolute R1C1 address like "Sheet1!R2C3:R10C5" to
+// "Sheet1!C2:E10". Relative references (R[1]C[-1], RC) have no anchor cell
+// outside a formula and are rejected.
+func R1C1ToA1(address string) (string, error) {
+	sheetPart, rangePart, hasSheet := strings.Cut(address, "!")
+	if !hasSheet {
+		return "", fmt.Errorf("address must include sheet name (e.g. Sheet1!R1C1:R2C2), got %q", address)
+	}
+	fromRef, toRef, hasColon := strings.Cut(strings.ToUpper(rangePart), ":")
+	if !hasColon {
+		toRef = fromRef
+	}
+
+	parse := func(ref string) (row, col int, err error) {
+		m := r1c1Re.FindStringSubmatch(ref)
+		if m == nil {
+			if r1c1RelativeRe.MatchString(ref) {
+				return 0, 0, fmt.Errorf("relative R1C1 reference %q needs an anchor cell; use absolute RnCn form", ref)
+			}
+			return 0, 0, fmt.Errorf("invalid R1C1 reference %q", ref)
+		}
+		row, _ = strconv.Atoi(m[1])
+		col, _ = strconv.Atoi(m[2])
+		if row < 1 || col < 1 {
+			return 0, 0, fmt.Errorf("invalid R1C1 reference %q: rows and columns start at 1", ref)
+		}
+		return row, col, nil
+	}
+	sr, sc, err := parse(fromRef)
+	if err != nil {
+		return "", err
+	}
+	er, ec, err := parse(toRef)
+	if err != nil {
+		return "", err
+	}
+	if sr > er {
+		sr, er = er, sr
+	}
+	if sc > ec {
+		sc, ec = ec, sc
+	}
+	return FormatAddress(sheetPart, sr, sc, er, ec), nil
+}
+
+// Structured reference areas (special items).
+const (
+	TableAreaData    = "#Data"
+	TableAreaAll     = "#All"
+	TableAreaHeaders = "#Headers"
+	TableAreaTotals  = "#Totals"
+)
+
+// StructuredRef is a parsed Excel table reference such as Table1[Sales] or
+// Table1[[#Headers],[Sales]:[Cost]].
+type StructuredRef struct {
+	Table       string
+	Area        string // one of the TableArea* constants; #Data by default
+	FirstColumn string // empty means all columns
+	LastColumn  string
+}
+
+// structuredRefRe matches Table[...] with the bracketed specifier captured.
+var structuredRefRe = regexp.MustCompile(`^([A-Za-z_\\][A-Za-z0-9_.\\]*)\[(.*)\]$`)
+
+// IsStructuredRef reports whether address looks like Table1[...].
+func IsStructuredRef(address string) bool {
+	return !strings.Contains(address, "!") && structuredRefRe.MatchString(address)
+}
+
+// ParseStructuredRef parses a table structured reference. Supported forms:
+// Table1[Col], Table1[[Col1]:[Col2]], Table1[#All|#Data|#Headers|#Totals],
+// Table1[[#Headers],[Col]], Table1[[#Totals],[Col1]:[Col2]], and Table1[].
+func ParseStructuredRef(address string) (StructuredRef, error) {
+	m := structuredRefRe.FindStringSubmatch(strings.TrimSpace(address))
+	if m == nil {
+		return StructuredRef{}, fmt.Errorf("invalid structured reference %q (expected Table1[Column])", address)
+	}
+	ref := StructuredRef{Table: m[1], Area: TableAreaData}
+	spec := strings.TrimSpace(m[2])
+	if spec == "" {
+		return ref, nil
+	}
+	// Simple form: Table1[Col] or Table1[#All].
+	if !strings.HasPrefix(spec, "[") {
+		if err := ref.apply(spec); err != nil {
+			return StructuredRef{}, fmt.Errorf("invalid structured reference %q: %w", address, err)
+		}
+		return ref, nil
+	}
+
+	// Bracketed items separated by commas: [#Headers],[Col1]:[Col2]
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		first, last, isSpan := strings.Cut(item, ":")
+		first, ok1 := unbracket(first)
+		if !ok1 {
+			return StructuredRef{}, fmt.Errorf("invalid structured reference %q: bad item %q", address, item)
+		}
+		if !isSpan {
+			if err := ref.apply(first); err != nil {
+				return StructuredRef{}, fmt.Errorf("invalid structured reference %q: %w", address, err)
+			}
+			continue
+		}
+		last, ok2 := unbracket(last)
+		if !ok2 || strings.HasPrefix(first, "#") || strings.HasPrefix(last, "#") {
+			return StructuredRef{}, fmt.Errorf("invalid structured reference %q: bad column span %q", address, item)
+		}
+		if ref.FirstColumn != "" {
+			return StructuredRef{}, fmt.Errorf("invalid structured reference %q: more than one column selection", address)
+		}
+		ref.FirstColumn, ref.LastColumn = first, last
+	}
+	return ref, nil
+}
+
+func (r *StructuredRef) apply(item string) error {
+	if strings.HasPrefix(item, "#") {
+		for _, area := range []string{TableAreaData, TableAreaAll, TableAreaHeaders, TableAreaTotals} {
+			if strings.EqualFold(item, area) {
+				r.Area = area
+				return nil
+			}
+		}
+		return fmt.Errorf("unsupported special item %q", item)
+	}
+	if r.FirstColumn != "" {
+		return fmt.Errorf("more than one column selection")
+	}
+	r.FirstColumn, r.LastColumn = item, item
+	return nil
+}
+
+func unbracket(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return "", false
+	}
+	return strings.TrimSpace(s[1 : len(s)-1]), true
+}
+
+// TableLayout describes an Excel table's position for resolving structured
+// references: Ref is the full table range including header and totals rows.
+type TableLayout struct {
+	Sheet     string
+	Ref       string // e.g. "A1:C10"
+	Columns   []string
+	HasHeader bool
+	HasTotals bool
+}
+
+// ResolveStructuredRef converts ref to a sheet-qualified A1 range using the
+// table's layout.
+func ResolveStructuredRef(ref StructuredRef, t TableLayout) (string, error) {
+	_, sr, sc, er, ec, err := ParseRange("T!" + t.Ref)
+	if err != nil {
+		return "", fmt.Errorf("table %s has an invalid range %q", ref.Table, t.Ref)
+	}
+
+	dataStart, dataEnd := sr, er
+	if t.HasHeader {
+		dataStart++
+	}
+	if t.HasTotals {
+		dataEnd--
+	}
+	switch ref.Area {
+	case TableAreaAll:
+	case TableAreaHeaders:
+		if !t.HasHeader {
+			return "", fmt.Errorf("table %s has no header row", ref.Table)
+		}
+		er = sr
+	case TableAreaTotals:
+		if !t.HasTotals {
+			return "", fmt.Errorf("table %s has no totals row", ref.Table)
+		}
+		sr = er
+	default:
+		if dataStart > dataEnd {
+			return "", fmt.Errorf("table %s has no data rows", ref.Table)
+		}
+		sr, er = dataStart, dataEnd
+	}
+
+	if ref.FirstColumn != "" {
+		first := columnIndex(t.Columns, ref.FirstColumn)
+		if first < 0 {
+			return "", fmt.Errorf("table %s has no column %q", ref.Table, ref.FirstColumn)
+		}
+		last := columnIndex(t.Columns, ref.LastColumn)
+		if last < 0 {
+			return "", fmt.Errorf("table %s has no column %q", ref.Table, ref.LastColumn)
+		}
+		if first > last {
+			first, last = last, first
+		}
+		sc, ec = sc+first, sc+last
+	}
+
+	return FormatAddress(QuoteSheetName(t.Sheet), sr, sc, er, ec), nil
+}
+
+// columnIndex finds a table column by name, case-insensitively. Excel escapes
+// special characters ([ ] # ') in column names with a leading apostrophe.
+func columnIndex(columns []string, name string) int {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\'' && i+1 < len(name) {
+			i++
+		}
+		b.WriteByte(name[i])
+	}
+	name = b.String()
+	for i, c := range columns {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
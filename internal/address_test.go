@@ -75,3 +75,166 @@ func TestFormatAddress(t *testing.T) {
 		t.Errorf("FormatAddress single cell = %q, want %q", got, want)
 	}
 }
+
+func TestIsDefinedName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"Revenue_Table", true},
+		{"_Inputs", true},
+		{"Tax.Rate", true},
+		{"Sheet1!A1:B2", false},
+		{"A1", false},
+		{"xfd100", false},
+		{"R1C1", false},
+		{"rc", false},
+		{"TRUE", false},
+		{"A1:B2", false},
+		{"1st_Name", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsDefinedName(tt.input); got != tt.want {
+			t.Errorf("IsDefinedName(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteSheetName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Sheet1", "Sheet1"},
+		{"Data_2024", "Data_2024"},
+		{"My Sheet", "'My Sheet'"},
+		{"2024", "'2024'"},
+		{"Bob's", "'Bob''s'"},
+		{"A1", "'A1'"},
+		{"xfd100", "'xfd100'"},
+		{"R2", "'R2'"},
+		{"RC", "'RC'"},
+		{"r1c1", "'r1c1'"},
+		{"C3", "'C3'"},
+		{"ABCD1", "ABCD1"},
+		{"Rates", "Rates"},
+	}
+	for _, tt := range tests {
+		if got := QuoteSheetName(tt.input); got != tt.want {
+			t.Errorf("QuoteSheetName(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestR1C1ToA1(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"Sheet1!R2C3:R10C5", "Sheet1!C2:E10", false},
+		{"Sheet1!r1c1", "Sheet1!A1", false},
+		{"'My Sheet'!R10C28:R1C1", "'My Sheet'!A1:AB10", false},
+		{"Sheet1!R[1]C[-1]", "", true},
+		{"Sheet1!RC", "", true},
+		{"Sheet1!R1C", "", true},
+		{"R1C1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if !IsR1C1(tt.input) {
+				t.Fatalf("expected %q to be detected as R1C1", tt.input)
+			}
+			got, err := R1C1ToA1(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("R1C1ToA1(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+
+	// Columns R to RC read as A1 first.
+	for _, notR1C1 := range []string{"Sheet1!A1:B2", "Revenue", "Table1[Sales]", "RC5", "Sheet1!RC12", "Sheet1!RC5:RC10", "Sheet1!R1C1:RC5"} {
+		if IsR1C1(notR1C1) {
+			t.Errorf("expected %q not to be detected as R1C1", notR1C1)
+		}
+	}
+}
+
+func TestParseStructuredRef(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    StructuredRef
+		wantErr bool
+	}{
+		{"Sales[Amount]", StructuredRef{Table: "Sales", Area: TableAreaData, FirstColumn: "Amount", LastColumn: "Amount"}, false},
+		{"Sales[]", StructuredRef{Table: "Sales", Area: TableAreaData}, false},
+		{"Sales[#All]", StructuredRef{Table: "Sales", Area: TableAreaAll}, false},
+		{"Sales[#headers]", StructuredRef{Table: "Sales", Area: TableAreaHeaders}, false},
+		{"Sales[[Region]:[Amount]]", StructuredRef{Table: "Sales", Area: TableAreaData, FirstColumn: "Region", LastColumn: "Amount"}, false},
+		{"Sales[[#Totals],[Amount]]", StructuredRef{Table: "Sales", Area: TableAreaTotals, FirstColumn: "Amount", LastColumn: "Amount"}, false},
+		{"Sales[[#Headers],[Region]:[Amount]]", StructuredRef{Table: "Sales", Area: TableAreaHeaders, FirstColumn: "Region", LastColumn: "Amount"}, false},
+		{"Sales[#ThisRow]", StructuredRef{}, true},
+		{"Sales[[A],[B]]", StructuredRef{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseStructuredRef(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseStructuredRef(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveStructuredRef(t *testing.T) {
+	layout := TableLayout{
+		Sheet:     "Q1 Sales",
+		Ref:       "B2:D10",
+		Columns:   []string{"Region", "Units", "Amount"},
+		HasHeader: true,
+		HasTotals: true,
+	}
+	tests := []struct {
+		ref     StructuredRef
+		want    string
+		wantErr bool
+	}{
+		{StructuredRef{Table: "T", Area: TableAreaData}, "'Q1 Sales'!B3:D9", false},
+		{StructuredRef{Table: "T", Area: TableAreaAll}, "'Q1 Sales'!B2:D10", false},
+		{StructuredRef{Table: "T", Area: TableAreaHeaders, FirstColumn: "units", LastColumn: "units"}, "'Q1 Sales'!C2", false},
+		{StructuredRef{Table: "T", Area: TableAreaTotals, FirstColumn: "Units", LastColumn: "Amount"}, "'Q1 Sales'!C10:D10", false},
+		{StructuredRef{Table: "T", Area: TableAreaData, FirstColumn: "Amount", LastColumn: "Amount"}, "'Q1 Sales'!D3:D9", false},
+		{StructuredRef{Table: "T", Area: TableAreaData, FirstColumn: "Missing", LastColumn: "Missing"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveStructuredRef(tt.ref, layout)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expected error for %+v, got %q", tt.ref, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveStructuredRef(%+v) = %q, %v; want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	noTotals := layout
+	noTotals.HasTotals = false
+	if _, err := ResolveStructuredRef(StructuredRef{Table: "T", Area: TableAreaTotals}, noTotals); err == nil {
+		t.Error("expected error for #Totals on a table without a totals row")
+	}
+}
@@ -37,18 +37,7 @@ func DiffImages(before, after image.Image) (*image.RGBA, int, error) {
 	h := bounds.Dy()
 
 	// Pass 1: build changed-pixel mask
-	mask := make([]bool, w*h)
-	changed := 0
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			br, bg, bb, ba := before.At(x, y).RGBA()
-			ar, ag, ab, aa := after.At(x, y).RGBA()
-			if br != ar || bg != ag || bb != ab || ba != aa {
-				mask[(y-bounds.Min.Y)*w+(x-bounds.Min.X)] = true
-				changed++
-			}
-		}
-	}
+	mask, changed := changedMask(before, after)
 
 	// Pass 2: for each unchanged pixel, compute squared distance to nearest changed pixel.
 	// We only need to distinguish: inner stroke (<=innerRadius), outer stroke (<=outerRadius), or neither.
@@ -139,6 +128,85 @@ func DiffImages(before, after image.Image) (*image.RGBA, int, error) {
 	return result, changed, nil
 }
 
+// changedMask returns a row-major mask of pixels that differ between two
+// same-sized images, and the number of changed pixels.
+func changedMask(before, after image.Image) ([]bool, int) {
+	bounds := after.Bounds()
+	w := bounds.Dx()
+	mask := make([]bool, w*bounds.Dy())
+	changed := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			br, bg, bb, ba := before.At(x, y).RGBA()
+			ar, ag, ab, aa := after.At(x, y).RGBA()
+			if br != ar || bg != ag || bb != ab || ba != aa {
+				mask[(y-bounds.Min.Y)*w+(x-bounds.Min.X)] = true
+				changed++
+			}
+		}
+	}
+	return mask, changed
+}
+
+// DiffRegion is a cluster of changed pixels. Bounds are relative to the
+// image's top-left corner.
+type DiffRegion struct {
+	Bounds image.Rectangle
+	Pixels int
+}
+
+// DiffRegions groups the pixels that differ between two images into
+// clusters. Changed pixels within gap pixels of each other (horizontally,
+// vertically, or diagonally) belong to the same cluster, so the glyphs of one
+// edited value form a single region. Regions are ordered top-to-bottom, then
+// left-to-right by their first pixel.
+func DiffRegions(before, after image.Image, gap int) ([]DiffRegion, error) {
+	if before.Bounds() != after.Bounds() {
+		bb := before.Bounds()
+		ab := after.Bounds()
+		return nil, fmt.Errorf("image dimensions differ: before is %d×%d, after is %d×%d", bb.Dx(), bb.Dy(), ab.Dx(), ab.Dy())
+	}
+	if gap < 1 {
+		gap = 1
+	}
+
+	w, h := after.Bounds().Dx(), after.Bounds().Dy()
+	mask, changed := changedMask(before, after)
+	if changed == 0 {
+		return nil, nil
+	}
+
+	seen := make([]bool, w*h)
+	var regions []DiffRegion
+	var stack []int
+	for start, isChanged := range mask {
+		if !isChanged || seen[start] {
+			continue
+		}
+		seen[start] = true
+		stack = append(stack[:0], start)
+		region := DiffRegion{Bounds: image.Rect(start%w, start/w, start%w+1, start/w+1)}
+		for len(stack) > 0 {
+			idx := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := idx%w, idx/w
+			region.Pixels++
+			region.Bounds = region.Bounds.Union(image.Rect(x, y, x+1, y+1))
+			for ny := max(0, y-gap); ny <= min(h-1, y+gap); ny++ {
+				for nx := max(0, x-gap); nx <= min(w-1, x+gap); nx++ {
+					n := ny*w + nx
+					if mask[n] && !seen[n] {
+						seen[n] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
 // FormatDiffSummary returns a human-readable diff summary string.
 func FormatDiffSummary(changed, total int) string {
 	if changed == 0 {
@@ -131,3 +131,33 @@ func TestFormatDiffSummary(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffRegions_ClustersNearbyPixels(t *testing.T) {
+	before := solidImage(40, 20, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	after := solidImage(40, 20, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	red := color.RGBA{R: 255, A: 255}
+	// Two "glyphs" two pixels apart, plus a distant change.
+	after.SetRGBA(2, 2, red)
+	after.SetRGBA(4, 3, red)
+	after.SetRGBA(30, 15, red)
+	after.SetRGBA(31, 15, red)
+
+	regions, err := DiffRegions(before, after, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(regions) != 2 {
+		t.Fatalf("expected 2 regions, got %d: %+v", len(regions), regions)
+	}
+	if got, want := regions[0].Bounds, image.Rect(2, 2, 5, 4); got != want || regions[0].Pixels != 2 {
+		t.Errorf("region 0 = %v (%d px), want %v (2 px)", got, regions[0].Pixels, want)
+	}
+	if got, want := regions[1].Bounds, image.Rect(30, 15, 32, 16); got != want || regions[1].Pixels != 2 {
+		t.Errorf("region 1 = %v (%d px), want %v (2 px)", got, regions[1].Pixels, want)
+	}
+
+	regions, err = DiffRegions(before, before, 2)
+	if err != nil || len(regions) != 0 {
+		t.Fatalf("identical images: regions=%v err=%v", regions, err)
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+)
+
+// ImagesToPDF assembles images into a PDF with one page per image. Each page
+// is sized to its image at 72 points per pixel divided by dpr, so a dpr=2
+// render prints at its logical size. Pixels are embedded losslessly as
+// Flate-compressed RGB.
+func ImagesToPDF(images []image.Image, dpr int) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no pages to write")
+	}
+	if dpr < 1 {
+		dpr = 1
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string, stream []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s", len(offsets), body)
+		if stream != nil {
+			buf.WriteString("\nstream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream")
+		}
+		buf.WriteString("\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 (catalog) and 2 (page tree) come first; each page then uses
+	// three objects: page, content stream, image.
+	kids := ""
+	for i := range images {
+		kids += fmt.Sprintf("%d 0 R ", 3+i*3)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj(fmt.Sprintf("<< /Type /Pages /Count %d /Kids [%s] >>", len(images), kids), nil)
+
+	for i, img := range images {
+		b := img.Bounds()
+		w, h := b.Dx(), b.Dy()
+		if w == 0 || h == 0 {
+			return nil, fmt.Errorf("page %d is empty", i+1)
+		}
+		pw, ph := float64(w)/float64(dpr), float64(h)/float64(dpr)
+		contentObj, imageObj := 4+i*3, 5+i*3
+
+		pixels, err := flateRGB(img)
+		if err != nil {
+			return nil, fmt.Errorf("encoding page %d: %w", i+1, err)
+		}
+		content := []byte(fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q", pw, ph))
+
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			pw, ph, imageObj, contentObj), nil)
+		obj(fmt.Sprintf("<< /Length %d >>", len(content)), content)
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+			w, h, len(pixels)), pixels)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes(), nil
+}
+
+// flateRGB returns the image's pixels as zlib-compressed 8-bit RGB rows.
+// Transparent pixels are composited onto white.
+func flateRGB(img image.Image) ([]byte, error) {
+	var out bytes.Buffer
+	zw := zlib.NewWriter(&out)
+	b := img.Bounds()
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// Composite premultiplied color over white.
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestImagesToPDF(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	pdf, err := ImagesToPDF([]image.Image{img, img}, 2)
+	if err != nil {
+		t.Fatalf("ImagesToPDF: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("missing PDF header or trailer")
+	}
+	s := string(pdf)
+	if !strings.Contains(s, "/Count 2") {
+		t.Fatalf("expected two pages")
+	}
+	if !strings.Contains(s, "/MediaBox [0 0 10.00 5.00]") {
+		t.Fatalf("expected page size scaled by dpr")
+	}
+	if !strings.Contains(s, "/Width 20 /Height 10") {
+		t.Fatalf("expected full-resolution image")
+	}
+
+	if _, err := ImagesToPDF(nil, 1); err == nil {
+		t.Fatal("expected error for no pages")
+	}
+}
@@ -0,0 +1,8 @@
+package internal
+
+import "strings"
+
+// ShellQuote single-quotes s for a POSIX shell.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
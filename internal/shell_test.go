@@ -0,0 +1,16 @@
+package internal
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                  `''`,
+		"/tmp/session.json": `'/tmp/session.json'`,
+		"it's":              `'it'\''s'`,
+		"$HOME dir":         `'$HOME dir'`,
+	} {
+		if got := ShellQuote(in); got != want {
+			t.Errorf("ShellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
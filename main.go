@@ -20,10 +20,18 @@ func main() {
 		var apiErr *client.APIError
 		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
 			fmt.Fprintln(os.Stderr, apiErr.Error())
-			os.Exit(1)
+			os.Exit(cmd.ExitFailure)
+		}
+		if errors.Is(err, client.ErrOffline) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(cmd.ExitOffline)
+		}
+		if errors.Is(err, client.ErrUnauthorized) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(cmd.ExitAuth)
 		}
 
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(cmd.ExitFailure)
 	}
 }